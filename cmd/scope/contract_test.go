@@ -0,0 +1,1115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// bin is the scope binary built by TestMain
+var bin string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "scope-bin-*")
+	if err != nil {
+		panic(err)
+	}
+
+	bin = filepath.Join(dir, "scope")
+	// A release version, so a cached newer version produces an update notice
+	build := exec.Command("go", "build", "-ldflags", "-X main.Version=0.0.1", "-o", bin, ".")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		panic("failed to build scope: " + err.Error())
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// result is the outcome of one scope invocation
+type result struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// contractEnv is a HOME with tagged folders and a pending update notice
+type contractEnv struct {
+	t    *testing.T
+	home string
+}
+
+func newContractEnv(t *testing.T) *contractEnv {
+	t.Helper()
+
+	home, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	env := &contractEnv{t: t, home: home}
+
+	configDir := filepath.Join(home, ".config", "scope")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, ".update-check"), []byte("9.9.9\nupdate"), 0644); err != nil {
+		t.Fatalf("Failed to write update cache: %v", err)
+	}
+	return env
+}
+
+// folder creates a folder under HOME and tags it
+func (e *contractEnv) folder(name, tagName string) string {
+	e.t.Helper()
+	path := filepath.Join(e.home, name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		e.t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if r := e.run("", "tag", path, tagName); r.err != nil {
+		e.t.Fatalf("scope tag failed: %v\n%s", r.err, r.stderr)
+	}
+	return path
+}
+
+// run invokes scope with stdin, capturing both streams separately
+func (e *contractEnv) run(stdin string, args ...string) result {
+	return e.runEnv(nil, stdin, args...)
+}
+
+// runEnv is run with extra environment variables
+func (e *contractEnv) runEnv(env []string, stdin string, args ...string) result {
+	cmd := exec.Command(bin, args...)
+	cmd.Env = append([]string{
+		"HOME=" + e.home,
+		"PATH=" + os.Getenv("PATH"),
+		// Ask for color: path commands must still not emit any
+		"TERM=xterm-256color",
+		"CLICOLOR_FORCE=1",
+	}, env...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return result{stdout: stdout.String(), stderr: stderr.String(), err: err}
+}
+
+// assertClean checks that neither stream has color codes or an update notice
+func assertClean(t *testing.T, r result) {
+	t.Helper()
+	for name, out := range map[string]string{"stdout": r.stdout, "stderr": r.stderr} {
+		if strings.Contains(out, "\x1b[") {
+			t.Errorf("%s contains color codes: %q", name, out)
+		}
+		if strings.Contains(out, "available") {
+			t.Errorf("%s contains the update notice: %q", name, out)
+		}
+	}
+}
+
+func TestUpdateNoticeShownElsewhere(t *testing.T) {
+	env := newContractEnv(t)
+
+	// Guards the tests below: the cached version does trigger a notice
+	r := env.run("", "list")
+	if !strings.Contains(r.stderr, "9.9.9") {
+		t.Fatalf("Expected an update notice from 'scope list', got stderr %q", r.stderr)
+	}
+}
+
+func TestGoSinglePath(t *testing.T) {
+	env := newContractEnv(t)
+	folder := env.folder("api", "work")
+
+	r := env.run("", "go", "work")
+	if r.err != nil {
+		t.Fatalf("scope go failed: %v\n%s", r.err, r.stderr)
+	}
+	if r.stdout != folder+"\n" {
+		t.Errorf("Expected stdout %q, got %q", folder+"\n", r.stdout)
+	}
+	assertClean(t, r)
+}
+
+func TestGoNullTerminated(t *testing.T) {
+	env := newContractEnv(t)
+	folder := env.folder("api", "work")
+
+	for _, args := range [][]string{{"go", "work", "-0"}, {"go", "-0", "work"}, {"go", "--null", "work"}} {
+		r := env.run("", args...)
+		if r.err != nil {
+			t.Fatalf("scope %v failed: %v\n%s", args, r.err, r.stderr)
+		}
+		if r.stdout != folder+"\x00" {
+			t.Errorf("scope %v: expected stdout %q, got %q", args, folder+"\x00", r.stdout)
+		}
+		assertClean(t, r)
+	}
+}
+
+func TestGoPromptOnStderr(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	r := env.run("2\n", "go", "work")
+	if r.err != nil {
+		t.Fatalf("scope go failed: %v\n%s", r.err, r.stderr)
+	}
+	if r.stdout != web+"\n" {
+		t.Errorf("Expected only the chosen path on stdout, got %q", r.stdout)
+	}
+	if !strings.Contains(r.stderr, "Select folder") {
+		t.Errorf("Expected the prompt on stderr, got %q", r.stderr)
+	}
+	assertClean(t, r)
+}
+
+func TestIndexChoosesWithoutPrompting(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	for _, args := range [][]string{{"--no-input", "go", "work", "--index", "2"}, {"--no-input", "pick", "--index", "2"}} {
+		r := env.run("", args...)
+		if r.err != nil {
+			t.Fatalf("scope %v failed: %v\n%s", args, r.err, r.stderr)
+		}
+		if r.stdout != web+"\n" {
+			t.Errorf("scope %v: expected stdout %q, got %q", args, web+"\n", r.stdout)
+		}
+		assertClean(t, r)
+	}
+
+	if r := env.run("", "go", "work", "--index", "3"); r.err == nil || r.stdout != "" {
+		t.Errorf("Expected an out of range --index to fail, got %q", r.stdout)
+	}
+}
+
+func TestAccessiblePickPrompts(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	r := env.run("2\n", "--accessible", "pick", "work")
+	if r.err != nil {
+		t.Fatalf("scope pick failed: %v\n%s", r.err, r.stderr)
+	}
+	if r.stdout != web+"\n" {
+		t.Errorf("Expected only the chosen path on stdout, got %q", r.stdout)
+	}
+	if !strings.Contains(r.stderr, "Select folder") {
+		t.Errorf("Expected a numbered prompt on stderr, got %q", r.stderr)
+	}
+	assertClean(t, r)
+}
+
+func TestCIPicksFirstFolder(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	env.folder("web", "work")
+
+	for _, extra := range [][]string{{"CI=true"}, {"TERM=dumb"}} {
+		for _, args := range [][]string{{"go", "work"}, {"pick", "work"}} {
+			r := env.runEnv(extra, "2\n", args...)
+			if r.err != nil {
+				t.Fatalf("scope %v with %v failed: %v\n%s", args, extra, r.err, r.stderr)
+			}
+			if r.stdout != api+"\n" {
+				t.Errorf("scope %v with %v: expected the first folder, got %q", args, extra, r.stdout)
+			}
+			if !strings.Contains(r.stderr, "--index") {
+				t.Errorf("scope %v with %v: expected a note about --index, got %q", args, extra, r.stderr)
+			}
+			assertClean(t, r)
+		}
+	}
+}
+
+func TestPathCommandsFailQuietly(t *testing.T) {
+	env := newContractEnv(t)
+
+	tests := [][]string{
+		{"go", "missing"},
+		{"go"},
+		{"pick"},
+		{"pick", "missing"},
+	}
+	for _, args := range tests {
+		r := env.run("", args...)
+		if r.err == nil {
+			t.Errorf("scope %v should fail", args)
+		}
+		if r.stdout != "" {
+			t.Errorf("scope %v wrote to stdout on failure: %q", args, r.stdout)
+		}
+		if !strings.HasPrefix(r.stderr, "Error: ") {
+			t.Errorf("scope %v: expected an error on stderr, got %q", args, r.stderr)
+		}
+		assertClean(t, r)
+	}
+}
+
+func TestQuietSuppressesInfo(t *testing.T) {
+	env := newContractEnv(t)
+	folder := filepath.Join(env.home, "api")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	r := env.run("", "--quiet", "tag", folder, "work")
+	if r.err != nil {
+		t.Fatalf("scope --quiet tag failed: %v\n%s", r.err, r.stderr)
+	}
+	if r.stdout != "" || r.stderr != "" {
+		t.Errorf("Expected no output, got stdout %q, stderr %q", r.stdout, r.stderr)
+	}
+
+	// Results are still printed
+	r = env.run("", "-q", "go", "work")
+	if r.stdout != folder+"\n" {
+		t.Errorf("Expected stdout %q, got %q", folder+"\n", r.stdout)
+	}
+
+	// And so are errors
+	r = env.run("", "-q", "untag", folder, "missing")
+	if r.err == nil || !strings.HasPrefix(r.stderr, "Error: ") {
+		t.Errorf("Expected an error on stderr, got %q", r.stderr)
+	}
+}
+
+func TestNoInputFailsInsteadOfPrompting(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	env.folder("web", "work")
+
+	for _, args := range [][]string{{"--no-input", "go", "work"}, {"--no-input", "pick"}, {"--no-input", "open", "work", "--pick"}, {"--no-input", "ui"}} {
+		r := env.run("1\n", args...)
+		if r.err == nil {
+			t.Errorf("scope %v should fail", args)
+		}
+		if r.stdout != "" {
+			t.Errorf("scope %v wrote to stdout: %q", args, r.stdout)
+		}
+		if !strings.Contains(r.stderr, "--no-input") {
+			t.Errorf("scope %v: expected a --no-input error, got %q", args, r.stderr)
+		}
+		if strings.Contains(r.stderr, "Select folder") {
+			t.Errorf("scope %v prompted: %q", args, r.stderr)
+		}
+	}
+}
+
+func TestOpenRevealUsage(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+
+	for _, args := range [][]string{{"open", "--reveal"}, {"open", "work", "--reveal", api}, {"open", "--reveal", api, "--pick"}} {
+		if r := env.run("", args...); r.err == nil || !strings.Contains(r.stderr, "usage: scope open") {
+			t.Errorf("scope %v: expected a usage error, got %v %q", args, r.err, r.stderr)
+		}
+	}
+	if r := env.run("", "open", "--reveal", filepath.Join(api, "missing.go")); r.err == nil || !strings.Contains(r.stderr, "cannot reveal") {
+		t.Errorf("Expected revealing a missing file to fail, got %v %q", r.err, r.stderr)
+	}
+}
+
+func TestOpenWithConfiguredOpener(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	config := "opener:\n  command: echo browsing {path} now\n  terminal: true\n"
+	if err := os.WriteFile(filepath.Join(env.home, ".config", "scope", "config.yml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	r := env.run("", "--quiet", "open", "work")
+	if r.err != nil {
+		t.Fatalf("scope open failed: %v\n%s", r.err, r.stderr)
+	}
+	expected := "browsing " + api + " now\nbrowsing " + web + " now\n"
+	if r.stdout != expected {
+		t.Errorf("Expected %q, got %q", expected, r.stdout)
+	}
+}
+
+func TestAliasExpandsToPathCommand(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	config := "aliases:\n  w: go work\n"
+	if err := os.WriteFile(filepath.Join(env.home, ".config", "scope", "config.yml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	r := env.run("", "w", "--index", "2")
+	if r.err != nil {
+		t.Fatalf("scope w failed: %v\n%s", r.err, r.stderr)
+	}
+	if r.stdout != web+"\n" {
+		t.Errorf("Expected %q, got %q", web+"\n", r.stdout)
+	}
+	assertClean(t, r)
+}
+
+func TestIncidentStartAndEnd(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	env.folder("web", "work")
+
+	ci := []string{"CI=true", "SHELL=/bin/sh"}
+	r := env.runEnv(ci, "exit\n", "incident", "start", "api", "--no-clone")
+	if r.err != nil {
+		t.Fatalf("scope incident start failed: %v\n%s", r.err, r.stderr)
+	}
+	if !strings.Contains(r.stdout, "Started incident 'incident-") {
+		t.Errorf("Expected the incident tag announced, got %q", r.stdout)
+	}
+
+	if r := env.runEnv(ci, "", "incident", "start", "missing", "--no-clone"); r.err == nil {
+		t.Error("Expected a missing service to fail")
+	}
+
+	r = env.run("", "incident", "end")
+	if r.err != nil {
+		t.Fatalf("scope incident end failed: %v\n%s", r.err, r.stderr)
+	}
+	archives, _ := filepath.Glob(filepath.Join(env.home, ".config", "scope", "incidents", "incident-*.md"))
+	if len(archives) != 1 {
+		t.Fatalf("Expected one archive, got %v", archives)
+	}
+	if content, _ := os.ReadFile(archives[0]); !strings.Contains(string(content), api) {
+		t.Errorf("Expected %s in the archive:\n%s", api, content)
+	}
+
+	if r := env.run("", "incident", "end"); r.err == nil {
+		t.Error("Expected no incident left to end")
+	}
+}
+
+func TestDoctorFix(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	web := env.folder("web", "work")
+	if err := os.RemoveAll(web); err != nil {
+		t.Fatalf("RemoveAll failed: %v", err)
+	}
+
+	r := env.run("", "doctor")
+	if r.err != nil {
+		t.Fatalf("scope doctor failed: %v\n%s", r.err, r.stderr)
+	}
+	if !strings.Contains(r.stdout, web+" (missing, prune)") {
+		t.Errorf("Expected the missing folder reported, got %q", r.stdout)
+	}
+
+	if r := env.run("", "--no-input", "doctor", "--fix"); r.err == nil {
+		t.Error("scope doctor --fix should not repair without asking")
+	}
+	if r := env.run("", "doctor", "--fix", "--yes"); r.err != nil {
+		t.Fatalf("scope doctor --fix --yes failed: %v\n%s", r.err, r.stderr)
+	}
+
+	r = env.run("", "doctor")
+	if r.err != nil || !strings.Contains(r.stdout, "No problems found") {
+		t.Errorf("Expected no problems after fixing, got %v %q", r.err, r.stdout)
+	}
+}
+
+func TestOfflineRefusesNetworkCommands(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+
+	for _, r := range []result{
+		env.run("", "--offline", "pull", "work"),
+		env.runEnv([]string{"SCOPE_OFFLINE=1"}, "", "update"),
+	} {
+		if r.err == nil || !strings.Contains(r.stderr, "--offline") {
+			t.Errorf("Expected an offline error, got %v %q", r.err, r.stderr)
+		}
+	}
+}
+
+func TestBulkFromStdin(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := filepath.Join(env.home, "web")
+	if err := os.MkdirAll(web, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	stdin := "# services\n" + api + "\n" + web + "\n" + filepath.Join(env.home, "gone") + "\n"
+	r := env.run(stdin, "bulk", "-", "work")
+	if r.err != nil {
+		t.Fatalf("scope bulk failed: %v\n%s", r.err, r.stderr)
+	}
+	if !strings.Contains(r.stdout, "1 tagged, 1 already tagged, 1 skipped") {
+		t.Errorf("Expected a summary of the batch, got %q", r.stdout)
+	}
+
+	r = env.run("", "list", "work")
+	if !strings.Contains(r.stdout, web) {
+		t.Errorf("Expected %s tagged, got %q", web, r.stdout)
+	}
+}
+
+func TestQueryIsReadOnly(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+
+	r := env.run("", "query", "--csv", "SELECT name FROM tags")
+	if r.err != nil || r.stdout != "name\nwork\n" {
+		t.Errorf("Expected the tag as CSV, got %v %q %q", r.err, r.stdout, r.stderr)
+	}
+
+	r = env.run("", "query", "DELETE FROM tags")
+	if r.err == nil || !strings.Contains(r.stderr, "only SELECT") {
+		t.Errorf("Expected the write refused, got %v %q", r.err, r.stderr)
+	}
+}
+
+func TestExportCSVMatrix(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	if r := env.run("", "tag", api, "go"); r.err != nil {
+		t.Fatalf("scope tag failed: %v\n%s", r.err, r.stderr)
+	}
+	web := env.folder("web", "work")
+
+	r := env.run("", "export", "--format", "csv", "--matrix")
+	expected := "folder,go,work\n" + api + ",1,1\n" + web + ",0,1\n"
+	if r.err != nil || r.stdout != expected {
+		t.Errorf("Expected %q, got %v %q", expected, r.err, r.stdout)
+	}
+
+	if r := env.run("", "export", "--matrix"); r.err == nil {
+		t.Error("scope export --matrix should require --format csv")
+	}
+}
+
+func TestExportLocateToFile(t *testing.T) {
+	env := newContractEnv(t)
+	web := env.folder("web", "work")
+	api := env.folder("api", "work")
+
+	out := filepath.Join(env.home, "folders.txt")
+	if r := env.run("", "export", "--format", "locate", "--output", out); r.err != nil || r.stdout != "" {
+		t.Fatalf("scope export --output failed: %v %q\n%s", r.err, r.stdout, r.stderr)
+	}
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	if string(content) != api+"\n"+web+"\n" {
+		t.Errorf("Unexpected locate export %q", content)
+	}
+
+	if r := env.run("", "export", "--launch-agent"); r.err == nil {
+		t.Error("scope export --launch-agent should require --output")
+	}
+	r := env.run("", "export", "--format", "plist", "--output", out, "--launch-agent")
+	if r.err != nil || !strings.Contains(r.stdout, "<string>"+out+"</string>") || !strings.Contains(r.stdout, "<key>WatchPaths</key>") {
+		t.Errorf("Unexpected launch agent %v\n%s", r.err, r.stdout)
+	}
+}
+
+func TestImportTagMeta(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+
+	file := filepath.Join(env.home, "backup.yml")
+	content := "version: 2\ntags:\n  work:\n    - " + api + "\n" +
+		"tag_meta:\n  work:\n    description: Day job\n    color: mauve\n    icon: W\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write import file: %v", err)
+	}
+
+	// An unknown color is rejected as tag-set would, without losing the rest
+	r := env.run("", "import", file)
+	if r.err != nil || !strings.Contains(r.stderr, "ignoring color of tag 'work'") || strings.Contains(r.stderr, "tag_meta") {
+		t.Fatalf("Unexpected import result %v\n%s", r.err, r.stderr)
+	}
+	r = env.run("", "tag-info", "work")
+	if r.err != nil || !strings.Contains(r.stdout, "Description: Day job") || !strings.Contains(r.stdout, "Icon:        W") || strings.Contains(r.stdout, "Color:") {
+		t.Errorf("Unexpected tag info %v\n%s", r.err, r.stdout)
+	}
+
+	r = env.run("", "export")
+	if r.err != nil || !strings.Contains(r.stdout, "tag_meta:") || !strings.Contains(r.stdout, "description: Day job") {
+		t.Errorf("Expected the tag info in the export, got %v\n%s", r.err, r.stdout)
+	}
+}
+
+func TestEachFailFast(t *testing.T) {
+	env := newContractEnv(t)
+	for _, name := range []string{"a", "b", "c"} {
+		dir := env.folder(name, "work")
+		if name != "b" {
+			if err := os.WriteFile(filepath.Join(dir, "ok"), nil, 0644); err != nil {
+				t.Fatalf("Failed to write file: %v", err)
+			}
+		}
+	}
+
+	r := env.run("", "each", "work", "--fail-fast", "test", "-e", "ok")
+	if r.err == nil || !strings.Contains(r.stdout, "1 succeeded, 1 failed, 1 skipped") {
+		t.Errorf("Expected a failure after b and c skipped, got %v\n%s", r.err, r.stdout)
+	}
+
+	r = env.run("", "each", "work", "--jobs", "2", "test", "-e", "ok")
+	if r.err == nil || !strings.Contains(r.stdout, "2 succeeded, 1 failed") {
+		t.Errorf("Expected one failure, got %v\n%s", r.err, r.stdout)
+	}
+
+	if r := env.run("", "each", "work", "--jobs", "0", "true"); r.err == nil {
+		t.Error("scope each --jobs 0 should fail")
+	}
+}
+
+func TestEachEnvironment(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	r := env.run("", "--quiet", "each", "work", `echo "$SCOPE_INDEX $SCOPE_TAG $SCOPE_FOLDER_NAME $SCOPE_FOLDER"`)
+	if r.err != nil {
+		t.Fatalf("scope each failed: %v\n%s", r.err, r.stderr)
+	}
+	for _, line := range []string{"1 work api " + api, "2 work web " + web} {
+		if !strings.Contains(r.stdout, line+"\n") {
+			t.Errorf("Expected %q in output:\n%s", line, r.stdout)
+		}
+	}
+}
+
+func TestEachLogDir(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+	env.folder("web", "work")
+
+	logs := filepath.Join(env.home, "logs")
+	r := env.run("", "each", "work", "--log-dir", logs, `echo out; echo err >&2; test "$SCOPE_FOLDER_NAME" = api`)
+	if r.err == nil {
+		t.Fatal("scope each should fail when a folder fails")
+	}
+
+	runs, err := os.ReadDir(logs)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("Expected one run directory, got %v %v", runs, err)
+	}
+	run := filepath.Join(logs, runs[0].Name())
+	if content, _ := os.ReadFile(filepath.Join(run, "02-web.stderr.log")); string(content) != "err\n" {
+		t.Errorf("Unexpected stderr log %q", content)
+	}
+
+	content, err := os.ReadFile(filepath.Join(run, "summary.json"))
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	var summary struct {
+		Command string `json:"command"`
+		Folders []struct {
+			Status   string `json:"status"`
+			ExitCode *int   `json:"exit_code"`
+			Stdout   string `json:"stdout"`
+		} `json:"folders"`
+	}
+	if err := json.Unmarshal(content, &summary); err != nil {
+		t.Fatalf("Invalid summary: %v\n%s", err, content)
+	}
+	if !strings.Contains(summary.Command, ">&2") || len(summary.Folders) != 2 {
+		t.Fatalf("Unexpected summary %s", content)
+	}
+	api, web := summary.Folders[0], summary.Folders[1]
+	if api.Status != "succeeded" || *api.ExitCode != 0 || api.Stdout != "01-api.stdout.log" {
+		t.Errorf("Unexpected api summary %+v", api)
+	}
+	if web.Status != "failed" || *web.ExitCode != 1 {
+		t.Errorf("Unexpected web summary %+v", web)
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	env.folder("notes", "work")
+	if output, err := exec.Command("git", "init", "-q", "-b", "main", api).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+	if err := os.WriteFile(filepath.Join(api, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	r := env.run("", "status", "work", "--json")
+	if r.err != nil {
+		t.Fatalf("scope status failed: %v\n%s", r.err, r.stderr)
+	}
+	var statuses []struct {
+		Folder   string `json:"folder"`
+		Branch   string `json:"branch"`
+		Upstream string `json:"upstream"`
+		Dirty    int    `json:"dirty"`
+	}
+	if err := json.Unmarshal([]byte(r.stdout), &statuses); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", r.stdout, err)
+	}
+	// Only the repository is listed
+	if len(statuses) != 1 || statuses[0].Folder != api || statuses[0].Branch != "main" ||
+		statuses[0].Upstream != "" || statuses[0].Dirty != 1 {
+		t.Errorf("Unexpected statuses %+v", statuses)
+	}
+}
+
+func TestBranchAcrossRepos(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := env.folder("web", "work")
+	for _, repo := range []string{api, web} {
+		for _, args := range [][]string{
+			{"init", "-q", "-b", "main"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "First"},
+		} {
+			if output, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+				t.Fatalf("git %v failed: %v\n%s", args, err, output)
+			}
+		}
+	}
+	if output, err := exec.Command("git", "-C", web, "branch", "feature/x").CombinedOutput(); err != nil {
+		t.Fatalf("git branch failed: %v\n%s", err, output)
+	}
+
+	// web already has the branch
+	r := env.run("", "branch", "work", "--create", "feature/x")
+	if r.err == nil {
+		t.Fatal("scope branch --create should fail where the branch exists")
+	}
+	if !strings.Contains(r.stdout, "api  created feature/x") || !strings.Contains(r.stdout, "Summary: 1 succeeded, 1 failed") {
+		t.Errorf("Unexpected output:\n%s", r.stdout)
+	}
+
+	r = env.run("", "branch", "work", "--checkout", "feature/x")
+	if r.err != nil {
+		t.Fatalf("scope branch --checkout failed: %v\n%s", r.err, r.stdout)
+	}
+	r = env.run("", "--quiet", "branch", "work")
+	if r.err != nil || r.stdout != "api  feature/x  "+api+"\n"+"web  feature/x  "+web+"\n" {
+		t.Errorf("scope branch = %v:\n%s", r.err, r.stdout)
+	}
+}
+
+func TestWorkspaceCreateQuietPrintsDir(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+
+	r := env.run("", "--quiet", "workspace", "create", "day-job", "work")
+	if r.err != nil {
+		t.Fatalf("scope workspace create failed: %v\n%s", r.err, r.stderr)
+	}
+	dir := filepath.Join(env.home, ".local", "share", "scope", "workspaces", "day-job")
+	if strings.TrimSpace(r.stdout) != dir {
+		t.Errorf("Expected only the workspace directory on stdout, got %q", r.stdout)
+	}
+	if target, err := os.Readlink(filepath.Join(dir, "api")); err != nil || target != api {
+		t.Errorf("Expected api linked to %s, got %q %v", api, target, err)
+	}
+}
+
+func TestMineHistoryDryRun(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder(filepath.Join("code", "api"), "work")
+	web := filepath.Join(env.home, "code", "web")
+	if err := os.MkdirAll(web, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	history := "cd ~/code/web\ncd ../api\ncd ~/code/web\n"
+	if err := os.WriteFile(filepath.Join(env.home, ".bash_history"), []byte(history), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	r := env.run("", "mine-history", "--tag", "work", "--dry-run")
+	if r.err != nil {
+		t.Fatalf("scope mine-history failed: %v\n%s", r.err, r.stderr)
+	}
+	if strings.TrimSpace(r.stdout) != "2  "+web+" → work" {
+		t.Errorf("Expected the untagged folder ranked, got %q", r.stdout)
+	}
+}
+
+func TestGoFuzzyTag(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "backend")
+	env.folder("web", "backup")
+
+	r := env.run("", "go", "backe")
+	if r.err != nil || strings.TrimSpace(r.stdout) != api {
+		t.Errorf("Expected %s, got %v %q %q", api, r.err, r.stdout, r.stderr)
+	}
+	if !strings.Contains(r.stderr, "using 'backend'") {
+		t.Errorf("Expected the matched tag on stderr, got %q", r.stderr)
+	}
+
+	r = env.run("", "--no-input", "go", "back")
+	if r.err == nil || !strings.Contains(r.stderr, "did you mean backup, backend") {
+		t.Errorf("Expected the ambiguous candidates, got %v %q", r.err, r.stderr)
+	}
+}
+
+func TestTagRecursive(t *testing.T) {
+	env := newContractEnv(t)
+	root := filepath.Join(env.home, "src")
+	for _, dir := range []string{"api/.git", "api/internal", "web", "vendor/lib/.git", "group/tool/.git", ".cache"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	for _, repo := range []string{"api", "vendor/lib", "group/tool"} {
+		if err := os.WriteFile(filepath.Join(root, repo, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	r := env.run("", "tag", root, "--recursive", "all")
+	if r.err != nil {
+		t.Fatalf("scope tag --recursive failed: %v\n%s", r.err, r.stderr)
+	}
+	r = env.run("", "tag", root, "--children", "repos", "--only-git", "--depth", "2", "--exclude", "vendor")
+	if r.err != nil {
+		t.Fatalf("scope tag --only-git failed: %v\n%s", r.err, r.stderr)
+	}
+
+	for tagName, want := range map[string][]string{
+		"all":   {"api", "group", "vendor", "web"},
+		"repos": {"api", "group/tool"},
+	} {
+		r = env.run("", "--quiet", "list", tagName)
+		var got []string
+		for _, line := range strings.Fields(r.stdout) {
+			rel, err := filepath.Rel(root, line)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				got = append(got, filepath.ToSlash(rel))
+			}
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("scope list %s = %v, want %v\n%s", tagName, got, want, r.stdout)
+		}
+	}
+
+	if r = env.run("", "tag", root, "x", "--only-git"); r.err == nil {
+		t.Error("--only-git without --recursive should fail")
+	}
+}
+
+func TestEditorFormats(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := env.folder("web-api", "work")
+	if r := env.run("", "note", web, "the\tsite"); r.err != nil {
+		t.Fatalf("scope note failed: %v\n%s", r.err, r.stderr)
+	}
+	if r := env.run("", "tag", "me@box:/srv/api", "work"); r.err != nil {
+		t.Fatalf("scope tag failed: %v\n%s", r.err, r.stderr)
+	}
+
+	r := env.run("", "list", "work", "--format", "files")
+	want := api + "\tapi\twork\n" + web + "\tweb-api\twork\nme@box:/srv/api\tapi\twork\n"
+	if r.err != nil || r.stdout != want {
+		t.Errorf("scope list --format files = %v:\n%q\nwant\n%q", r.err, r.stdout, want)
+	}
+
+	// Remote folders can't be opened from a quickfix list
+	r = env.run("", "search", "api", "--format", "vimgrep")
+	want = api + ":1:1:api [work]\n" + web + ":1:1:web-api [work] the site\n"
+	if r.err != nil || r.stdout != want {
+		t.Errorf("scope search --format vimgrep = %v:\n%q\nwant\n%q", r.err, r.stdout, want)
+	}
+
+	r = env.run("", "search", "site", "--tag", "!work", "--format", "files")
+	if r.err != nil || r.stdout != "" {
+		t.Errorf("scope search --tag = %v: %q", r.err, r.stdout)
+	}
+}
+
+func TestWatchOnce(t *testing.T) {
+	env := newContractEnv(t)
+	root := filepath.Join(env.home, "src")
+	api := filepath.Join(root, "api")
+	if err := os.MkdirAll(api, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(api, ".scope"), []byte("tags: [work]\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	r := env.run("", "watch", root, "--once")
+	if r.err != nil || !strings.Contains(r.stdout, "tagged "+api+" from its .scope file") {
+		t.Fatalf("scope watch --once = %v:\n%s%s", r.err, r.stdout, r.stderr)
+	}
+	if r = env.run("", "--quiet", "list", "work"); strings.TrimSpace(r.stdout) != api {
+		t.Errorf("Expected %s tagged work, got %q", api, r.stdout)
+	}
+	if r = env.run("", "watch"); r.err == nil {
+		t.Error("scope watch without paths or watch.roots should fail")
+	}
+}
+
+func TestMv(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("code/api", "work")
+	env.folder("code/api/web", "frontend")
+	env.run("", "note", api, "the API")
+
+	moved := filepath.Join(env.home, "archive", "api")
+	if err := os.MkdirAll(filepath.Dir(moved), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.Rename(api, moved); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	r := env.run("", "doctor")
+	if !strings.Contains(r.stdout, "moved? scope mv "+api+" "+moved) {
+		t.Errorf("Expected doctor to suggest the move, got:\n%s", r.stdout)
+	}
+
+	if r = env.run("", "mv", api, moved); r.err != nil {
+		t.Fatalf("scope mv failed: %v\n%s", r.err, r.stderr)
+	}
+	if r = env.run("", "--quiet", "list", "work"); strings.TrimSpace(r.stdout) != moved {
+		t.Errorf("Expected work on %s, got %q", moved, r.stdout)
+	}
+	if r = env.run("", "--quiet", "list", "frontend"); strings.TrimSpace(r.stdout) != filepath.Join(moved, "web") {
+		t.Errorf("Expected the nested folder moved too, got %q", r.stdout)
+	}
+	if r = env.run("", "note", moved); !strings.Contains(r.stdout, "the API") {
+		t.Errorf("Expected the note to move, got %q", r.stdout)
+	}
+
+	if r = env.run("", "mv", moved, filepath.Join(env.home, "nowhere")); r.err == nil {
+		t.Error("scope mv to a path that doesn't exist should fail")
+	}
+}
+
+func TestListHierarchicalTags(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work/backend")
+	web := env.folder("web", "work/frontend")
+
+	if r := env.run("", "--quiet", "list", "work"); r.stdout != "  "+api+"\n  "+web+"\n" {
+		t.Errorf("Expected work to list its children's folders, got %q", r.stdout)
+	}
+
+	r := env.run("", "--quiet", "list", "--no-pager")
+	expected := "  work                 2 folders\n    backend            1 folder\n    frontend           1 folder\n"
+	if r.stdout != expected {
+		t.Errorf("Expected the tags as a tree:\n%s\ngot:\n%s", expected, r.stdout)
+	}
+
+	if r = env.run("", "list", "--names"); r.stdout != "work\nwork/backend\nwork/frontend\n" {
+		t.Errorf("Expected the parent among the names, got %q", r.stdout)
+	}
+}
+
+func TestEditTemplatesAndReusesWindow(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	config := "editor:\n  command: echo editing {path} now\n  editors:\n    echo:\n      wait: true\n"
+	if err := os.WriteFile(filepath.Join(env.home, ".config", "scope", "config.yml"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	r := env.run("", "--quiet", "edit", "work")
+	if expected := "editing " + api + " now\nediting " + web + " now\n"; r.err != nil || r.stdout != expected {
+		t.Errorf("Expected %q, got %v %q", expected, r.err, r.stdout)
+	}
+	if r = env.run("", "edit", "work", "--reuse-window"); r.err == nil || !strings.Contains(r.stderr, "needs VS Code") {
+		t.Errorf("Expected --reuse-window to need VS Code, got %v %q", r.err, r.stderr)
+	}
+
+	// A stand-in for VS Code's command prints its arguments
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "code"), []byte("#!/bin/sh\necho \"$@\"\n"), 0755); err != nil {
+		t.Fatalf("Failed to write code: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(env.home, ".config", "scope", "config.yml"), []byte("editor:\n  command: code\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	r = env.runEnv([]string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH")}, "", "--quiet", "edit", "work", "-r")
+	if expected := api + " --reuse-window\n" + web + " --add\n"; r.err != nil || r.stdout != expected {
+		t.Errorf("Expected %q, got %v %q", expected, r.err, r.stdout)
+	}
+}
+
+func TestTagInfoAndSet(t *testing.T) {
+	env := newContractEnv(t)
+	env.folder("api", "work")
+
+	if r := env.run("", "tag-set", "work", "--description", "Day job", "--color", "blue", "--icon", "W"); r.err != nil {
+		t.Fatalf("scope tag-set failed: %v\n%s", r.err, r.stderr)
+	}
+	r := env.run("", "tag-info", "work")
+	expected := "Tag:         W work\nDescription: Day job\nColor:       blue\nIcon:        W\nFolders:     1\n"
+	if r.err != nil || r.stdout != expected {
+		t.Errorf("Expected:\n%s\ngot %v:\n%s", expected, r.err, r.stdout)
+	}
+	if r = env.runEnv([]string{"NO_COLOR=1"}, "", "--quiet", "list", "--no-pager"); r.stdout != "  W work               1 folder\n" {
+		t.Errorf("Expected the icon in the listing, got %q", r.stdout)
+	}
+
+	for _, args := range [][]string{{"tag-set", "work", "--color", "mauve"}, {"tag-set", "nope", "--icon", "x"}, {"tag-set", "work"}, {"tag-info", "nope"}} {
+		if r := env.run("", args...); r.err == nil {
+			t.Errorf("scope %v should fail", args)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("code/api", "work")
+	web := env.folder("code/my-web", "work")
+	other := env.folder("old/api", "work")
+
+	r := env.run("", "env", "work")
+	if r.err != nil {
+		t.Fatalf("scope env failed: %v\n%s", r.err, r.stderr)
+	}
+	for _, want := range []string{
+		"export WORK_API=" + api,
+		"export WORK_MY_WEB=" + web,
+		"export WORK_API_2=" + other,
+	} {
+		if !strings.Contains(r.stdout, want+"\n") {
+			t.Errorf("Expected %q in:\n%s", want, r.stdout)
+		}
+	}
+
+	// In a session the tag defaults to the session's
+	file := filepath.Join(env.home, "work.env")
+	if r = env.runEnv([]string{"SCOPE_SESSION=work", "SCOPE_SESSION_TAG=work"}, "", "env", "--export", "--output", file); r.err != nil {
+		t.Fatalf("scope env --export failed: %v\n%s", r.err, r.stderr)
+	}
+	out, err := exec.Command("sh", "-c", ". "+file+" && echo \"$WORK_MY_WEB\"").Output()
+	if err != nil {
+		t.Fatalf("Sourcing %s failed: %v", file, err)
+	}
+	if strings.TrimSpace(string(out)) != web {
+		t.Errorf("Expected WORK_MY_WEB=%s, got %q", web, out)
+	}
+
+	if r = env.run("", "env", "work", "--format", "make", "--prefix", "dev"); !strings.Contains(r.stdout, "export DEV_API := "+api+"\n") {
+		t.Errorf("Expected a make variable, got:\n%s", r.stdout)
+	}
+	if r = env.run("", "env"); r.err == nil {
+		t.Error("scope env outside a session without a tag should fail")
+	}
+}
+
+func TestPathExitStatus(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("code/api", "work")
+	gateway := env.folder("code/gw", "work")
+	env.folder("old/web", "work")
+	env.folder("new/web", "work")
+	if err := os.WriteFile(filepath.Join(gateway, "go.mod"), []byte("module github.com/acme/gateway\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	for name, want := range map[string]string{"api": api, "gateway": gateway, "github.com/acme/gateway": gateway} {
+		r := env.run("", "path", "work", name)
+		if r.err != nil {
+			t.Fatalf("scope path work %s failed: %v\n%s", name, r.err, r.stderr)
+		}
+		if r.stdout != want+"\n" {
+			t.Errorf("scope path work %s: expected stdout %q, got %q", name, want+"\n", r.stdout)
+		}
+		assertClean(t, r)
+	}
+
+	for name, code := range map[string]int{"nothing": 2, "web": 3} {
+		r := env.run("", "path", "work", name)
+		var exitErr *exec.ExitError
+		if !errors.As(r.err, &exitErr) || exitErr.ExitCode() != code {
+			t.Errorf("scope path work %s: expected exit status %d, got %v", name, code, r.err)
+		}
+		if r.stdout != "" {
+			t.Errorf("scope path work %s: expected nothing on stdout, got %q", name, r.stdout)
+		}
+		assertClean(t, r)
+	}
+}
+
+func TestRenameMergeIfExists(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "frontend")
+	web := env.folder("web", "web")
+
+	r := env.run("", "rename", "frontend", "web")
+	if r.err == nil || !strings.Contains(r.stderr, "tag already exists: web") || !strings.Contains(r.stderr, "--merge-if-exists") {
+		t.Fatalf("Expected a friendly error renaming onto an existing tag, got %v\n%s", r.err, r.stderr)
+	}
+
+	if r = env.run("", "rename", "frontend", "web", "--merge-if-exists"); r.err != nil {
+		t.Fatalf("scope rename --merge-if-exists failed: %v\n%s", r.err, r.stderr)
+	}
+	r = env.run("", "--quiet", "list", "web")
+	if got := strings.Fields(r.stdout); !slices.Contains(got, api) || !slices.Contains(got, web) {
+		t.Errorf("Expected both folders on web, got %q", r.stdout)
+	}
+	if r = env.run("", "--quiet", "list", "frontend"); r.err == nil && strings.TrimSpace(r.stdout) != "" {
+		t.Errorf("Expected frontend to be gone, got %q", r.stdout)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	env := newContractEnv(t)
+	api := env.folder("api", "work")
+	web := env.folder("web", "work")
+
+	if r := env.run("", "remove-tag", "work"); r.err != nil {
+		t.Fatalf("scope remove-tag failed: %v\n%s", r.err, r.stderr)
+	}
+	r := env.run("", "trash")
+	if r.err != nil || !strings.Contains(r.stdout, "work") || !strings.Contains(r.stdout, "2 folder(s)") {
+		t.Fatalf("Expected the tag in the trash, got %v %q", r.err, r.stdout)
+	}
+
+	if r = env.run("", "trash", "restore", "work"); r.err != nil {
+		t.Fatalf("scope trash restore failed: %v\n%s", r.err, r.stderr)
+	}
+	r = env.run("", "--quiet", "list", "work")
+	if got := strings.Fields(r.stdout); !slices.Contains(got, api) || !slices.Contains(got, web) {
+		t.Errorf("Expected both folders back on work, got %q", r.stdout)
+	}
+	if r = env.run("", "trash", "restore", "work"); r.err == nil || !strings.Contains(r.stderr, "not in the trash") {
+		t.Errorf("Expected restoring twice to fail, got %v\n%s", r.err, r.stderr)
+	}
+
+	if r := env.run("", "remove-tag", "work"); r.err != nil {
+		t.Fatalf("scope remove-tag failed: %v\n%s", r.err, r.stderr)
+	}
+	if r := env.run("", "--no-input", "trash", "empty"); r.err == nil {
+		t.Error("scope trash empty should not delete without asking")
+	}
+	if r := env.run("", "trash", "empty", "--yes"); r.err != nil {
+		t.Fatalf("scope trash empty --yes failed: %v\n%s", r.err, r.stderr)
+	}
+	if r = env.run("", "trash"); r.err != nil || !strings.Contains(r.stdout, "The trash is empty") {
+		t.Errorf("Expected an empty trash, got %v %q", r.err, r.stdout)
+	}
+}
@@ -3,58 +3,175 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
-	"github.com/charmbracelet/huh"
-	"gopkg.in/yaml.v3"
-
+	"github.com/gabssanto/Scope/internal/audit"
+	"github.com/gabssanto/Scope/internal/backup"
 	"github.com/gabssanto/Scope/internal/completions"
+	"github.com/gabssanto/Scope/internal/config"
 	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/events"
+	"github.com/gabssanto/Scope/internal/export"
+	"github.com/gabssanto/Scope/internal/finder"
+	"github.com/gabssanto/Scope/internal/forge"
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/graph"
+	"github.com/gabssanto/Scope/internal/hint"
+	"github.com/gabssanto/Scope/internal/httpcache"
+	"github.com/gabssanto/Scope/internal/incident"
+	"github.com/gabssanto/Scope/internal/journal"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/migrate"
+	"github.com/gabssanto/Scope/internal/mine"
+	"github.com/gabssanto/Scope/internal/network"
+	"github.com/gabssanto/Scope/internal/organize"
+	"github.com/gabssanto/Scope/internal/paths"
+	"github.com/gabssanto/Scope/internal/picker"
+	"github.com/gabssanto/Scope/internal/project"
+	"github.com/gabssanto/Scope/internal/query"
+	"github.com/gabssanto/Scope/internal/release"
 	"github.com/gabssanto/Scope/internal/scan"
+	"github.com/gabssanto/Scope/internal/secrets"
+	"github.com/gabssanto/Scope/internal/selfcheck"
+	"github.com/gabssanto/Scope/internal/server"
+	"github.com/gabssanto/Scope/internal/service"
 	"github.com/gabssanto/Scope/internal/session"
+	"github.com/gabssanto/Scope/internal/shell"
+	"github.com/gabssanto/Scope/internal/snapshot"
+	"github.com/gabssanto/Scope/internal/standup"
+	"github.com/gabssanto/Scope/internal/suggest"
 	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/tidy"
+	"github.com/gabssanto/Scope/internal/timetrack"
+	"github.com/gabssanto/Scope/internal/ui"
 	"github.com/gabssanto/Scope/internal/update"
+	"github.com/gabssanto/Scope/internal/watch"
 )
 
 // Version is set at build time via ldflags
 var Version = "dev"
 
+// cfg holds the global configuration loaded at startup
+var cfg = config.Default()
+
+// changes is the sync journal, when sync.dir is configured
+var changes *journal.Journal
+
+// bus publishes events to integrations, when events are configured
+var bus *events.Bus
+
 const usage = `Scope - Fast folder navigation with tags
 
 Usage:
+  scope [global flags] <command> [args]
+
   scope tag <path> <tag>        Tag a folder (use . for current directory)
-  scope bulk <file> <tag>       Bulk tag paths from file (--dry-run to preview)
+  scope tag <path> -r <tag>     Tag every subdirectory instead (--depth, --only-git, --exclude)
+  scope bulk <file|-> <tag>     Bulk tag paths from a file or stdin (--dry-run to preview)
   scope untag <path> <tag>      Remove a tag from a folder
   scope tags <path>             Show all tags for a folder
-  scope list [tag]              List all tags or folders with specific tag
-  scope start <tag>             Start a scoped session
-  scope scan [path]             Scan for .scope files and apply tags
-  scope go <tag>                Jump to a tagged folder (outputs path)
-  scope pick [tag]              Interactive folder picker
-  scope open <tag>              Open tagged folder(s) in file manager
-  scope edit <tag>              Open tagged folder(s) in editor
-  scope each <tag> <cmd>        Run command in each tagged folder
-  scope status <tag>            Git status across tagged folders
+  scope tags --organize         Find and merge similar tags (--dry-run to list, --yes to merge)
+  scope note <path> [text]      Show or set a folder's note (--clear to remove)
+  scope subdir <path> [dir]     Show or set the subdirectory go/each/edit use
+  scope todo <tag|path> [text]  Add or list a folder's todos (list, done <id>)
+  scope suggest <path>          Suggest tags for a folder
+  scope list [tag] [-v]         List all tags or folders with a tag (-v: health)
+  scope list --grouped          List tags grouped by category (prefix:)
+  scope search <words...>       Find folders by path, tags and note (--tag, --format files|vimgrep)
+  scope packages <tag>          List tagged folders grouped by git repository
+  scope order <tag> [path...]   Reorder a tag's folders for go, pick, each and sessions
+  scope start <tag>             Start a scoped session (--flat=false to nest, --tmux)
+  scope session refresh         Update the current session's links to match its tag
+  scope session log [tag]       Show the commands run in the last recorded session
+  scope workspace create|open   Persistent named workspaces of tags (also list, delete)
+  scope incident start <svc>... Tag services with a new incident tag and start a session
+  scope incident end [tag]      Archive an incident with its session log
+  scope env [tag] [--export]    Print or write an env var per tagged folder (--format sh|fish|make)
+  scope scan [path] [--all]     Scan for .scope files and apply tags
+  scope go <tag> [--index n]    Jump to a tagged folder (outputs path)
+  scope path <tag> <name>       Print the path of a tagged folder by name, for scripts
+  scope ui                      Full-screen dashboard of tags and folders (jump, tag, sessions)
+  scope pick [tag] [--index n]  Interactive folder picker (outputs path)
+  scope open <tag> [--web]      Open tagged folder(s) in file manager (or forge pages, --pick one)
+  scope open --reveal <file>    Show a file selected in the file manager
+  scope edit <tag>              Open tagged folder(s) in editor (--reuse-window for VS Code)
+  scope each <tag> <cmd>        Run command in each tagged folder (-p, --jobs n, --fail-fast, --log-dir)
+  scope deps <tag> [--update]   Update dependencies in each folder (--branch <name>)
+  scope status <tag>            Branch, ahead/behind, changes and stashes per repository (--fetch, --json)
+  scope branch <tag> [name]     Current branches, or --create/--checkout/--delete one everywhere
   scope pull <tag>              Git pull across tagged folders
-  scope rename <old> <new>      Rename a tag
-  scope remove-tag <tag>        Delete a tag entirely
+  scope secrets <tag>           Scan tagged folders for committed keys and tokens
+  scope audit <tag> [--csv]     Licenses and dependencies of tagged folders
+  scope ci <tag>                Latest CI run per GitHub/GitLab/Bitbucket repository
+  scope enrich <tag>            Store forge descriptions, topics and archived status
+  scope prs <tag>               Your open pull/merge requests across repositories
+  scope release <tag>           Tag the next version in each repository (--bump minor)
+  scope snapshot <tag>          Archive tagged folders (--list, --restore <name>)
+  scope backup-folders <tag>    Copy tagged folders to the tag's backup destination
+  scope rename <old> <new>      Rename a tag (--merge-if-exists, --scope-files to update .scope files)
+  scope remove-tag <tag>        Delete a tag entirely (restore it with scope trash)
+  scope tag-info <tag>          Show a tag's description, color and icon
+  scope tag-set <tag>           Describe a tag (--description, --color, --icon)
+  scope mv <old> <new>          Move the tags of a folder that was moved or renamed
   scope prune [--dry-run]       Remove folders that no longer exist
-  scope export                  Export all tags to YAML
+  scope trash [list]            Deleted tags and folders (restore <tag|path>..., empty [--yes])
+  scope sync [--seed]           Replay changes journaled by other machines
+  scope lock / unlock           Make the database read-only / writable again
+  scope tidy [--dry-run|--yes]  Review and apply all cleanups interactively
+  scope doctor [--fix] [--yes]  Check the database for missing folders, empty tags and duplicates
+  scope export                  Export all tags to YAML (--format csv|plist|windows-terminal|..., -o <file>)
+  scope export --to-scope-files Write tags into each folder's .scope file
   scope import <file>           Import tags from YAML file
+  scope migrate export|apply    Move tags, repositories and config to a new machine
   scope update [--check]        Update to latest version
   scope completions <shell>     Generate shell completions (bash/zsh/fish)
+  scope init <shell>            Print shell integration (sg wrapper, hints, time)
+  scope hint [--off|--on]       Suggest tagging an untagged repository
+  scope mine-history            Tag the untagged folders you cd into most (--tag, --dry-run)
+  scope finder sync [--dry-run] Sync mapped tags with macOS Finder tags, both ways
+  scope serve [--addr <addr>]   Serve tags and folders as a local JSON API
+  scope serve token             Print a bearer token for the API (--read-only, --reset)
+  scope service install <name>  Run serve at login under systemd/launchd (status, uninstall)
+  scope editor-rpc              Answer JSON-RPC on stdin/stdout, for editor plugins
+  scope watch [path...]         Keep tags in sync as folders appear, move and disappear (--once)
+  scope time [--today|--week]   Time spent per tag in sessions and folders
+  scope standup [tag]           Markdown summary of commits and time since yesterday
+  scope graph [tag] [--open]    Graph tags and folders (DOT or --format mermaid)
+  scope query <sql>             Run a read-only SELECT on the database (--json, --csv)
   scope debug                   Show debug information
+  scope selfcheck               Verify the installation and suggest fixes
   scope help                    Show this help message
   scope version                 Show version information
 
+Global flags:
+  -q, --quiet                   Only print results and errors
+  --no-input                    Fail instead of prompting (for scripts and CI)
+  --accessible                  Plain text and prompts for screen readers
+  --offline                     Never use the network (cached forge data only)
+
 Sessions:
   When you run 'scope start <tag>', a new shell opens in a temporary
   workspace containing symlinks to all folders with that tag.
@@ -64,14 +181,20 @@ Sessions:
 
 Navigation:
   'scope go' outputs a path for shell integration. Add to your .bashrc/.zshrc:
-    sg() { cd "$(scope go "$@")" 2>/dev/null || scope go "$@"; }
+    eval "$(scope init bash)"    (or zsh; for fish: scope init fish | source)
+  This defines 'sg <tag>' to cd into a tagged folder, and hints at tagging
+  new repositories you cd into.
 
 Examples:
   scope tag . work              Tag current directory with 'work'
   scope tag ~/projects/app dev  Tag a specific folder
+  scope tag me@box:/srv/app dev Tag a folder on another machine
+  scope tag ~/src -r work --only-git  Tag every repository in ~/src
   scope tags .                  Show tags for current directory
   scope list                    Show all tags
   scope list work               Show all folders tagged 'work'
+  scope list --grouped          Show tags under category headings
+  scope list work --limit 20    Show the first 20 folders tagged 'work'
   scope start work              Open scoped session with 'work' folders
   scope go work                 Output path to 'work' folder (for cd)
   scope open work               Open 'work' folders in Finder/Explorer
@@ -82,6 +205,7 @@ Examples:
   scope bulk paths.txt work     Bulk tag paths from file
   scope bulk paths.txt work --dry-run  Preview bulk tagging
   scope rename old new          Rename 'old' tag to 'new'
+  scope mv ~/code/api ~/src/api Move the tags of a folder that was moved
   scope remove-tag old          Delete 'old' tag entirely
   scope prune --dry-run         Preview folders to be removed
 `
@@ -89,23 +213,41 @@ Examples:
 func main() {
 	if err := run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, db.ErrReadOnly) {
+			fmt.Fprintln(os.Stderr, readOnlyHint)
+		}
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError is an error that exits with a status other than 1, for
+// commands whose exit status scripts tell apart
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
 // showUpdateNotice displays update notification if available
 func showUpdateNotice() {
 	// Skip for certain commands that output paths (for shell integration)
 	if len(os.Args) >= 2 {
 		cmd := os.Args[1]
-		// Skip for commands where stdout is used for data
-		if cmd == "go" || cmd == "version" || cmd == "--version" || cmd == "-v" {
+		// Skip for commands where stdout is used for data, and for the
+		// shell hook, which runs on every prompt
+		if pathCommands[cmd] || cmd == "ui" || cmd == "hint" || cmd == "time" || cmd == "version" || cmd == "--version" || cmd == "-v" {
 			return
 		}
 	}
 
 	// Check if running in a non-interactive context
-	if os.Getenv("SCOPE_NO_UPDATE_CHECK") != "" {
+	if os.Getenv("SCOPE_NO_UPDATE_CHECK") != "" || ui.Quiet() {
 		return
 	}
 
@@ -115,13 +257,103 @@ func showUpdateNotice() {
 	}
 }
 
+// parseGlobalFlags consumes the global flags that precede the command,
+// so handlers see os.Args as if they had not been given. Flags after the
+// command belong to it (e.g. the command run by 'scope each').
+func parseGlobalFlags() {
+	ui.SetQuiet(envFlag("SCOPE_QUIET"))
+	ui.SetNoInput(envFlag("SCOPE_NO_INPUT"))
+	ui.SetAccessible(envFlag("SCOPE_ACCESSIBLE"))
+	network.SetOffline(envFlag("SCOPE_OFFLINE"))
+
+	i := 1
+	for ; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "--quiet", "-q":
+			ui.SetQuiet(true)
+		case "--no-input":
+			ui.SetNoInput(true)
+		case "--accessible":
+			ui.SetAccessible(true)
+		case "--offline":
+			network.SetOffline(true)
+		default:
+			os.Args = append(os.Args[:1], os.Args[i:]...)
+			return
+		}
+	}
+	os.Args = os.Args[:1]
+}
+
 func run() error {
+	parseGlobalFlags()
+
+	// selfcheck diagnoses broken configs and databases, so it must run
+	// before either is loaded
+	if len(os.Args) >= 2 && os.Args[1] == "selfcheck" {
+		return handleSelfcheck()
+	}
+
+	// Load global configuration
+	loaded, err := config.Load()
+	if err != nil {
+		return err
+	}
+	cfg = loaded
+	if cfg.UI.Accessible {
+		ui.SetAccessible(true)
+	}
+	if cfg.Offline() {
+		network.SetOffline(true)
+	}
+	tag.SetSymlinkPolicy(cfg.Paths.SymlinkPolicy())
+	if cfg.Trash.Disabled {
+		tag.SetTrashRetention(-1)
+	} else {
+		tag.SetTrashRetention(cfg.Trash.Retention)
+	}
+	cacheOpts, err := cfg.API.CacheOptions()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: API response cache disabled: %v\n", err)
+	}
+	httpcache.Configure(cacheOpts)
+
 	// Initialize database
+	opts := cfg.Database.PoolOptions()
+	if envFlag("SCOPE_READ_ONLY") {
+		opts.ReadOnly = true
+	}
+	db.Configure(opts)
 	if err := db.InitDB(); err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
 	}
 	defer func() { _ = db.Close() }()
 
+	// Journal changes for other machines to replay
+	if cfg.Sync.Enabled() {
+		changes, err = openJournal()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: sync journal disabled: %v\n", err)
+		} else {
+			changes.Record(tag.Default())
+		}
+	}
+
+	// Publish changes to integrations
+	if cfg.Events.Enabled() {
+		socket, err := cfg.Events.SocketPath()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: events socket disabled: %v\n", err)
+		}
+		bus = events.New(socket, cfg.Events.Webhooks, cfg.Events.Timeout)
+		bus.Observe(tag.Default())
+	}
+
+	// Mirror mapped tags to Finder as they change
+	if cfg.Finder.Auto && finder.Supported() {
+		finder.Observe(tag.Default(), cfg.Finder.Mapping())
+	}
+
 	// Show update notice at the end (only for interactive commands)
 	defer showUpdateNotice()
 
@@ -131,8 +363,12 @@ func run() error {
 		return nil
 	}
 
-	command := os.Args[1]
+	return dispatch(os.Args[1])
+}
 
+// dispatch runs command with the arguments in os.Args. Built-in commands
+// take precedence over aliases of the same name.
+func dispatch(command string) error {
 	switch command {
 	case "tag":
 		return handleTag()
@@ -142,40 +378,130 @@ func run() error {
 		return handleUntag()
 	case "tags":
 		return handleTags()
+	case "note":
+		return handleNote()
+	case "subdir":
+		return handleSubdir()
+	case "todo":
+		return handleTodo()
 	case "list":
 		return handleList()
+	case "suggest":
+		return handleSuggest()
+	case "packages":
+		return handlePackages()
+	case "order":
+		return handleOrder()
 	case "start":
 		return handleStart()
+	case "session":
+		return handleSession()
+	case "workspace":
+		return handleWorkspace()
+	case "incident":
+		return handleIncident()
+	case "env":
+		return handleEnv()
 	case "scan":
 		return handleScan()
+	case "search":
+		return handleSearch()
 	case "go":
 		return handleGo()
 	case "pick":
 		return handlePick()
+	case "path":
+		return handlePath()
 	case "open":
 		return handleOpen()
 	case "edit":
 		return handleEdit()
 	case "each":
 		return handleEach()
+	case "deps":
+		return handleDeps()
 	case "status":
 		return handleStatus()
+	case "branch":
+		return handleBranch()
 	case "pull":
 		return handlePull()
 	case "rename":
 		return handleRename()
+	case "mv":
+		return handleMv()
 	case "remove-tag":
 		return handleRemoveTag()
+	case "tag-info":
+		return handleTagInfo()
+	case "tag-set":
+		return handleTagSet()
 	case "prune":
 		return handlePrune()
+	case "trash":
+		return handleTrash()
+	case "sync":
+		return handleSync()
+	case "lock":
+		return handleLock(true)
+	case "unlock":
+		return handleLock(false)
+	case "tidy":
+		return handleTidy()
+	case "doctor":
+		return handleDoctor()
 	case "export":
 		return handleExport()
 	case "import":
 		return handleImport()
+	case "migrate":
+		return handleMigrate()
 	case "update":
 		return handleUpdate()
+	case "secrets":
+		return handleSecrets()
+	case "audit":
+		return handleAudit()
+	case "ci":
+		return handleCI()
+	case "enrich":
+		return handleEnrich()
+	case "prs":
+		return handlePRs()
+	case "release":
+		return handleRelease()
+	case "snapshot":
+		return handleSnapshot()
+	case "backup-folders":
+		return handleBackupFolders()
 	case "completions":
 		return handleCompletions()
+	case "init":
+		return handleInit()
+	case "hint":
+		return handleHint()
+	case "mine-history":
+		return handleMineHistory()
+	case "finder":
+		return handleFinder()
+	case "serve":
+		return handleServe()
+	case "service":
+		return handleService()
+	case "editor-rpc":
+		return handleEditorRPC()
+	case "watch":
+		return handleWatch()
+	case "time":
+		return handleTime()
+	case "standup":
+		return handleStandup()
+	case "graph":
+		return handleGraph()
+	case "ui":
+		return handleUI()
+	case "query":
+		return handleQuery()
 	case "debug":
 		return handleDebug()
 	case "help", "--help", "-h":
@@ -185,53 +511,246 @@ func run() error {
 		fmt.Printf("scope version %s\n", Version)
 		return nil
 	default:
+		if ran, err := runAlias(command); ran {
+			return err
+		}
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", command)
 		fmt.Print(usage)
 		return nil
 	}
 }
 
-func handleTag() error {
-	if len(os.Args) < 4 {
-		return fmt.Errorf("usage: scope tag <path> <tag>")
+// expandedAliases are the aliases being expanded, so one that refers back
+// to itself fails instead of looping
+var expandedAliases = make(map[string]bool)
+
+// runAlias runs name if it is an alias from the config, reporting whether
+// it was. The arguments after the alias are appended to its definition.
+func runAlias(name string) (bool, error) {
+	args, command, err := cfg.Alias(name)
+	if err != nil {
+		return true, fmt.Errorf("alias %s: %w", name, err)
 	}
+	extra := os.Args[2:]
+
+	switch {
+	case command != "":
+		// Like git's ! aliases: the arguments are passed on as "$@"
+		sh := os.Getenv("SHELL")
+		if sh == "" {
+			sh = "/bin/sh"
+		}
+		cmd := exec.Command(sh, append([]string{"-c", command + ` "$@"`, name}, extra...)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return true, fmt.Errorf("alias %s: %w", name, err)
+		}
+		return true, nil
+	case args != nil:
+		if expandedAliases[name] {
+			return true, fmt.Errorf("alias %s refers to itself", name)
+		}
+		expandedAliases[name] = true
+		os.Args = append(append([]string{os.Args[0]}, args...), extra...)
+		return true, dispatch(os.Args[1])
+	}
+	return false, nil
+}
 
-	path := os.Args[2]
-	tagName := os.Args[3]
+func handleTag() error {
+	usage := fmt.Errorf("usage: scope tag <path> <tag>\n       scope tag <path> --recursive <tag> [--depth <n>] [--only-git] [--exclude <glob>]...")
+	var positional, exclude []string
+	recursive, onlyGit, depth := false, false, 1
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--recursive", "--children", "-r":
+			recursive = true
+		case "--only-git":
+			onlyGit = true
+		case "--depth", "--exclude":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			if arg == "--exclude" {
+				exclude = append(exclude, args[i])
+				continue
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return fmt.Errorf("--depth must be a positive number, got %q", args[i])
+			}
+			depth = n
+		default:
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				return usage
+			}
+			positional = append(positional, arg)
+		}
+	}
+	if len(positional) != 2 {
+		return usage
+	}
+	if !recursive && (onlyGit || depth != 1 || len(exclude) > 0) {
+		return fmt.Errorf("--depth, --only-git and --exclude need --recursive")
+	}
+	path, tagName := positional[0], positional[1]
 
 	// Resolve path
-	absPath, err := resolvePath(path)
+	absPath, err := resolveFolder(path)
 	if err != nil {
 		return err
 	}
+	if recursive {
+		return tagChildren(absPath, tagName, depth, onlyGit, exclude)
+	}
 
 	// Add tag
 	if err := tag.AddTag(absPath, tagName); err != nil {
 		return err
 	}
 
-	fmt.Printf("Tagged '%s' with '%s'\n", absPath, tagName)
+	ui.Infof("Tagged '%s' with '%s'\n", absPath, tagName)
+	return nil
+}
+
+// tagChildren tags the directories under root, down to depth levels, rather
+// than root itself
+func tagChildren(root, tagName string, depth int, onlyGit bool, exclude []string) error {
+	if _, ok := location.Parse(root); ok {
+		return fmt.Errorf("--recursive only works on local folders")
+	}
+	for _, pattern := range exclude {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --exclude pattern %q: %w", pattern, err)
+		}
+	}
+	folders, err := childFolders(root, depth, onlyGit, exclude)
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders to tag under %s", root)
+	}
+
+	tagged := 0
+	for _, folder := range folders {
+		if err := tag.AddTag(folder, tagName); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to tag %s: %v\n", folder, err)
+			continue
+		}
+		ui.Infof("  %s %s\n", ui.Color("green", "✓"), folder)
+		tagged++
+	}
+	ui.Infof("Tagged %d folders under '%s' with '%s'\n", tagged, root, tagName)
+	if tagged < len(folders) {
+		return fmt.Errorf("failed to tag %d of %d folders", len(folders)-tagged, len(folders))
+	}
 	return nil
 }
 
+// childFolders returns the directories under root down to depth levels,
+// sorted. Hidden directories and those whose name or path relative to root
+// matches an exclude glob are skipped along with everything below them.
+// With onlyGit, only repository roots are returned, and nothing inside one
+// is looked at.
+func childFolders(root string, depth int, onlyGit bool, exclude []string) ([]string, error) {
+	var folders []string
+	err := filepath.WalkDir(root, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if dir == root {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", dir, err)
+			return fs.SkipDir
+		}
+		if dir == root || !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") || excluded(d.Name(), filepath.ToSlash(rel), exclude) {
+			return fs.SkipDir
+		}
+
+		level := strings.Count(filepath.ToSlash(rel), "/") + 1
+		if !onlyGit {
+			folders = append(folders, dir)
+		} else if git.IsRepo(dir) {
+			folders = append(folders, dir)
+			return fs.SkipDir
+		}
+		if level >= depth {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	return folders, nil
+}
+
+// excluded reports whether a directory's name or relative path matches one
+// of the globs
+func excluded(name, rel string, globs []string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(glob, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveFolder resolves a folder argument: remote locations are
+// normalized, anything else is resolved as a local path
+func resolveFolder(arg string) (string, error) {
+	if loc, ok := location.Parse(arg); ok {
+		return loc.String(), nil
+	}
+	return paths.Resolve(arg)
+}
+
 func handleBulk() error {
 	if len(os.Args) < 4 {
-		return fmt.Errorf("usage: scope bulk <file> <tag> [--dry-run]")
+		return fmt.Errorf("usage: scope bulk <file|-> <tag> [--dry-run]")
 	}
 
-	filePath := os.Args[2]
 	tagName := os.Args[3]
 	dryRun := len(os.Args) >= 5 && (os.Args[4] == "--dry-run" || os.Args[4] == "-n")
 
-	// Read file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file '%s': %w", filePath, err)
+	// Read the list, "-" being stdin
+	var content []byte
+	if os.Args[2] == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		content = data
+	} else {
+		filePath, err := paths.Expand(os.Args[2])
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read file '%s': %w", filePath, err)
+		}
+		content = data
 	}
 
 	lines := strings.Split(string(content), "\n")
 
-	successCount := 0
+	var folders []string
+	seen := make(map[string]bool)
 	skipCount := 0
 	errorCount := 0
 
@@ -245,7 +764,7 @@ func handleBulk() error {
 		}
 
 		// Resolve path
-		absPath, err := resolvePath(line)
+		absPath, err := paths.Resolve(line)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Line %d: failed to resolve path '%s': %v\n", lineNum+1, line, err)
 			errorCount++
@@ -270,28 +789,41 @@ func handleBulk() error {
 			continue
 		}
 
-		if dryRun {
-			fmt.Printf("[DRY-RUN] Would tag '%s' with '%s'\n", absPath, tagName)
-			successCount++
-		} else {
-			if err := tag.AddTag(absPath, tagName); err != nil {
-				fmt.Fprintf(os.Stderr, "Line %d: failed to tag '%s': %v\n", lineNum+1, absPath, err)
-				errorCount++
-				continue
-			}
-			fmt.Printf("Tagged '%s' with '%s'\n", absPath, tagName)
-			successCount++
+		if seen[absPath] {
+			skipCount++
+			continue
 		}
+		seen[absPath] = true
+		folders = append(folders, absPath)
 	}
 
-	// Summary
-	fmt.Println()
 	if dryRun {
-		fmt.Printf("Dry-run complete: %d would be tagged, %d skipped, %d errors\n", successCount, skipCount, errorCount)
-	} else {
-		fmt.Printf("Bulk tagging complete: %d tagged, %d skipped, %d errors\n", successCount, skipCount, errorCount)
+		for _, folder := range folders {
+			fmt.Printf("[DRY-RUN] Would tag '%s' with '%s'\n", folder, tagName)
+		}
+		ui.Infoln()
+		ui.Infof("Dry-run complete: %d would be tagged, %d skipped, %d errors\n", len(folders), skipCount, errorCount)
+		return nil
+	}
+
+	// Tag everything in one transaction, so a failure leaves no folder
+	// half-way through the list tagged
+	if errorCount > 0 {
+		return fmt.Errorf("%d paths could not be read; nothing was tagged", errorCount)
+	}
+	added, err := tag.TagFolders(folders, tagName)
+	if err != nil {
+		return fmt.Errorf("nothing was tagged: %w", err)
+	}
+	for _, folder := range added {
+		ui.Infof("Tagged '%s' with '%s'\n", folder, tagName)
 	}
 
+	// Summary
+	ui.Infoln()
+	ui.Infof("Bulk tagging complete: %d tagged, %d already tagged, %d skipped\n",
+		len(added), len(folders)-len(added), skipCount)
+
 	return nil
 }
 
@@ -304,7 +836,7 @@ func handleUntag() error {
 	tagName := os.Args[3]
 
 	// Resolve path
-	absPath, err := resolvePath(path)
+	absPath, err := resolveFolder(path)
 	if err != nil {
 		return err
 	}
@@ -314,31 +846,103 @@ func handleUntag() error {
 		return err
 	}
 
-	fmt.Printf("Removed tag '%s' from '%s'\n", tagName, absPath)
+	ui.Infof("Removed tag '%s' from '%s'\n", tagName, absPath)
 	return nil
 }
 
-func handleList() error {
+func handleList() (err error) {
+	usage := fmt.Errorf("usage: scope list [tag] [--verbose] [--limit n] [--offset n] [--no-pager] | --grouped | --names\n       scope list [tag] --format files")
+
+	tagName, format := "", "text"
+	grouped, verbose, paged, bare := false, false, true, false
+	limit, offset := 0, 0
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--format", "-f":
+			if i+1 >= len(args) || (args[i+1] != "text" && args[i+1] != "files") {
+				return fmt.Errorf("--format requires a value (text or files)")
+			}
+			i++
+			format = args[i]
+		case "--grouped", "-g":
+			grouped = true
+		case "--verbose", "-v":
+			verbose = true
+		case "--no-pager":
+			paged = false
+		case "--names":
+			bare = true
+		case "--limit", "--offset":
+			if i+1 >= len(args) {
+				return usage
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid %s %q (expected a number of entries)", args[i], args[i+1])
+			}
+			if args[i] == "--limit" {
+				limit = n
+			} else {
+				offset = n
+			}
+			i++
+		default:
+			if strings.HasPrefix(args[i], "-") || tagName != "" {
+				return usage
+			}
+			tagName = args[i]
+		}
+	}
+	if (grouped || bare) && (tagName != "" || verbose) || grouped && bare {
+		return usage
+	}
+	if format == "files" {
+		if grouped || bare || verbose {
+			return usage
+		}
+		return listFiles(tagName, offset, limit)
+	}
+
+	// The output is only written on Close, so its error is the command's
+	out := ui.StartPager(paged && !bare)
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
 	// If tag name provided, list folders for that tag
-	if len(os.Args) >= 3 {
-		tagName := os.Args[2]
-		folders, err := tag.ListFoldersByTag(tagName)
+	if tagName != "" {
+		folders, err := tag.SelectFolders(tagName)
 		if err != nil {
 			return err
 		}
 
 		if len(folders) == 0 {
-			fmt.Printf("No folders found with tag '%s'\n", tagName)
+			ui.Infof("No folders found with tag '%s'\n", tagName)
 			return nil
 		}
 
-		fmt.Printf("Folders tagged with '%s':\n", tagName)
-		for _, folder := range folders {
-			fmt.Printf("  %s\n", folder)
+		if tag.IsExpr(tagName) {
+			ui.Infof("Folders matching '%s':\n", tagName)
+		} else {
+			ui.Infof("Folders tagged with '%s':\n", tagName)
 		}
-		fmt.Printf("\nTotal: %d folders\n", len(folders))
+		shown := page(folders, offset, limit)
+		if verbose {
+			printFolderHealth(out, shown)
+		} else {
+			for _, folder := range shown {
+				fmt.Fprintf(out, "  %s\n", folder)
+			}
+		}
+		ui.Infof("\n%s\n", pageTotal(len(shown), offset, len(folders), "folders"))
 		return nil
 	}
+	if verbose {
+		return usage
+	}
 
 	// Otherwise, list all tags
 	tags, err := tag.ListTags()
@@ -346,11 +950,16 @@ func handleList() error {
 		return err
 	}
 
-	if len(tags) == 0 {
-		fmt.Println("No tags found. Use 'scope tag <path> <tag>' to create one.")
+	if len(tags) == 0 && !bare {
+		ui.Infoln("No tags found. Use 'scope tag <path> <tag>' to create one.")
 		return nil
 	}
 
+	infos, err := tag.ListTagInfo()
+	if err != nil {
+		return err
+	}
+
 	// Sort tags by name
 	names := make([]string, 0, len(tags))
 	for name := range tags {
@@ -358,743 +967,5994 @@ func handleList() error {
 	}
 	sort.Strings(names)
 
-	fmt.Println("Tags:")
-	for _, name := range names {
-		count := tags[name]
-		plural := ""
-		if count != 1 {
-			plural = "s"
+	// Hierarchical tags are listed as a tree, with the parents that only
+	// exist through their children, which can be listed and completed too
+	var tree []tag.TreeEntry
+	if slices.ContainsFunc(names, func(name string) bool { return len(tag.Ancestors(name)) > 0 }) {
+		folderTags, err := tag.ListFolderTags()
+		if err != nil {
+			return err
+		}
+		tree = tag.TagTree(tags, folderTags)
+		names = names[:0]
+		for _, entry := range tree {
+			names = append(names, entry.Name)
 		}
-		fmt.Printf("  %-20s %d folder%s\n", name, count, plural)
 	}
+	shown := page(names, offset, limit)
 
-	fmt.Printf("\nTotal: %d tags\n", len(tags))
-	return nil
-}
+	// Bare names, one per line, are for completions and scripts
+	if bare {
+		for _, name := range shown {
+			fmt.Fprintln(out, name)
+		}
+		return nil
+	}
 
-func handleStart() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope start <tag>")
+	if grouped {
+		for i, group := range tag.GroupTags(shown, cfg.Tags.TagCategories()) {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			color := group.Category.Color
+			if color == "" {
+				color = "bold"
+			}
+			fmt.Fprintf(out, "%s\n", ui.Color(color, group.Category.Title+":"))
+			for _, name := range group.Tags {
+				printTagCount(out, tagLabel(name, infos[name], 20), tags[name])
+			}
+		}
+	} else if tree != nil {
+		ui.Infoln("Tags:")
+		for _, entry := range page(tree, offset, limit) {
+			indent := strings.Repeat("  ", entry.Depth)
+			label := tagLabel(entry.Label(), infos[entry.Name], max(20-len(indent), 0))
+			printTagCount(out, indent+label, entry.Folders)
+		}
+	} else {
+		ui.Infoln("Tags:")
+		for _, name := range shown {
+			printTagCount(out, tagLabel(name, infos[name], 20), tags[name])
+		}
 	}
 
-	tagName := os.Args[2]
-	return session.StartSession(tagName)
+	ui.Infof("\n%s\n", pageTotal(len(shown), offset, len(names), "tags"))
+	return nil
 }
 
-func handleRemoveTag() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope remove-tag <tag>")
+func handleSearch() error {
+	usage := fmt.Errorf("usage: scope search <words...> [--tag <expr>] [--limit n] [--format text|files|vimgrep]")
+	var words []string
+	tagExpr, format, limit := "", "text", 0
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--tag", "-t", "--limit", "--format", "-f":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			switch arg {
+			case "--tag", "-t":
+				tagExpr = args[i]
+			case "--limit":
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 0 {
+					return fmt.Errorf("invalid --limit %q (expected a number of folders)", args[i])
+				}
+				limit = n
+			default:
+				if args[i] != "text" && args[i] != "files" && args[i] != "vimgrep" {
+					return fmt.Errorf("--format requires a value (text, files or vimgrep)")
+				}
+				format = args[i]
+			}
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return usage
+			}
+			words = append(words, arg)
+		}
 	}
+	if len(words) == 0 {
+		return usage
+	}
+	query := strings.Join(words, " ")
 
-	tagName := os.Args[2]
-
-	if err := tag.DeleteTag(tagName); err != nil {
+	folders, err := tag.Search(query)
+	if err != nil {
 		return err
 	}
+	if tagExpr != "" {
+		selected, err := tag.SelectFolders(tagExpr)
+		if err != nil {
+			return err
+		}
+		folders = slices.DeleteFunc(folders, func(f string) bool { return !slices.Contains(selected, f) })
+	}
+	folders = page(folders, 0, limit)
+	if format != "text" {
+		return writeFolderLines(os.Stdout, format, folders)
+	}
 
-	fmt.Printf("Removed tag '%s'\n", tagName)
+	if len(folders) == 0 {
+		ui.Infof("No folders match '%s'\n", query)
+		return nil
+	}
+	folderTags, err := tag.ListFolderTags()
+	if err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		fmt.Printf("%s  %s\n", folder, ui.Color("blue", strings.Join(folderTags[folder], ", ")))
+	}
 	return nil
 }
 
-func handleScan() error {
-	// Default to current directory
-	path := "."
-	if len(os.Args) >= 3 {
-		path = os.Args[2]
+// listFiles lists the folders with a tag, or all of them, one per line as
+// path<TAB>name<TAB>tags, for pickers such as telescope.nvim
+func listFiles(tagName string, offset, limit int) error {
+	var folders []string
+	var err error
+	if tagName != "" {
+		folders, err = tag.SelectFolders(tagName)
+	} else {
+		folders, err = tag.ListAllFolders()
 	}
-
-	// Resolve to absolute path
-	absPath, err := resolvePath(path)
 	if err != nil {
 		return err
 	}
+	return writeFolderLines(os.Stdout, "files", page(folders, offset, limit))
+}
 
-	// Verify it's a directory
-	info, err := os.Stat(absPath)
+// writeFolderLines writes folders for editors to parse, one per line.
+// files is path<TAB>name<TAB>tags (comma-separated). vimgrep is
+// path:1:1:name [tags] note, as grep -n --column writes for quickfix lists;
+// remote folders, which an editor can't open, are left out of it.
+func writeFolderLines(w io.Writer, format string, folders []string) error {
+	folderTags, err := tag.ListFolderTags()
 	if err != nil {
-		return fmt.Errorf("cannot access path: %w", err)
+		return err
 	}
-	if !info.IsDir() {
-		return fmt.Errorf("path is not a directory: %s", absPath)
+	notes, err := tag.ListNotes()
+	if err != nil {
+		return err
 	}
-
-	return scan.RunScan(absPath)
-}
-
-// resolvePath converts a path (including .) to an absolute path
-func resolvePath(path string) (string, error) {
-	// Handle current directory
-	if path == "." {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return "", fmt.Errorf("failed to get current directory: %w", err)
+	bw := bufio.NewWriter(w)
+	for _, folder := range folders {
+		name := filepath.Base(folder)
+		if loc, ok := location.Parse(folder); ok {
+			if format == "vimgrep" {
+				continue
+			}
+			name = loc.Base()
 		}
-		return cwd, nil
-	}
-
-	// Expand home directory
-	if strings.HasPrefix(path, "~") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get home directory: %w", err)
+		tags := folderTags[folder]
+		switch format {
+		case "files":
+			fmt.Fprintf(bw, "%s\t%s\t%s\n", folder, name, strings.Join(tags, ","))
+		case "vimgrep":
+			text := name
+			if len(tags) > 0 {
+				text += " [" + strings.Join(tags, ", ") + "]"
+			}
+			if note := notes[folder]; note != "" {
+				text += " " + strings.Join(strings.Fields(note), " ")
+			}
+			fmt.Fprintf(bw, "%s:1:1:%s\n", folder, text)
 		}
-		path = filepath.Join(homeDir, path[1:])
 	}
+	return bw.Flush()
+}
 
-	// Get absolute path
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return "", fmt.Errorf("failed to resolve path: %w", err)
+// page returns the items selected by --offset and --limit; a zero limit
+// means no limit
+func page[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return nil
 	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
 
-	return absPath, nil
+// pageTotal is the total line of a listing, saying which part of it was
+// shown when it was cut by --offset or --limit
+func pageTotal(shown, offset, total int, noun string) string {
+	switch {
+	case shown == total:
+		return fmt.Sprintf("Total: %d %s", total, noun)
+	case shown == 0:
+		return fmt.Sprintf("Showing none of %d %s (offset %d)", total, noun, offset)
+	default:
+		return fmt.Sprintf("Showing %d-%d of %d %s", offset+1, offset+shown, total, noun)
+	}
 }
 
-func handleTags() error {
+func handlePackages() error {
 	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope tags <path>")
+		return fmt.Errorf("usage: scope packages <tag>")
 	}
 
-	path := os.Args[2]
-
-	// Resolve path
-	absPath, err := resolvePath(path)
-	if err != nil {
-		return err
-	}
-
-	tags, err := tag.GetTagsForFolder(absPath)
+	tagName := os.Args[2]
+	folders, err := tag.ListFoldersByTag(tagName)
 	if err != nil {
 		return err
 	}
 
-	if len(tags) == 0 {
-		fmt.Printf("No tags found for '%s'\n", absPath)
+	if len(folders) == 0 {
+		ui.Infof("No folders found with tag '%s'\n", tagName)
 		return nil
 	}
 
-	fmt.Printf("Tags for '%s':\n", absPath)
-	for _, t := range tags {
-		fmt.Printf("  %s\n", t)
-	}
-	return nil
-}
-
-func handleRename() error {
-	if len(os.Args) < 4 {
-		return fmt.Errorf("usage: scope rename <old> <new>")
-	}
-
-	oldName := os.Args[2]
-	newName := os.Args[3]
+	groups := project.GroupByRepo(folders)
+	for i, group := range groups {
+		if i > 0 {
+			fmt.Println()
+		}
+		if group.Repo == "" {
+			fmt.Println(ui.Color("bold", "(not in a repository)"))
+			for _, p := range group.Packages {
+				fmt.Printf("  %s\n", p.Path)
+			}
+			continue
+		}
 
-	if err := tag.RenameTag(oldName, newName); err != nil {
-		return err
+		fmt.Printf("%s %s\n", ui.Color("blue", filepath.Base(group.Repo)), group.Repo)
+		for _, p := range group.Packages {
+			if p.IsPackage() {
+				fmt.Printf("  %s\n", p.Rel)
+			} else {
+				fmt.Printf("  . (repository root)\n")
+			}
+		}
 	}
 
-	fmt.Printf("Renamed tag '%s' to '%s'\n", oldName, newName)
+	ui.Infof("\nTotal: %d folders in %d groups\n", len(folders), len(groups))
 	return nil
 }
 
-func handlePrune() error {
-	dryRun := len(os.Args) >= 3 && (os.Args[2] == "--dry-run" || os.Args[2] == "-n")
-
-	result, err := tag.Prune(dryRun)
-	if err != nil {
-		return err
+// printFolderHealth lists folders with an icon per health check and what
+// they are missing
+func printFolderHealth(out io.Writer, folders []string) {
+	if len(folders) == 0 {
+		return
 	}
-
-	if result.RemovedCount == 0 {
-		fmt.Println("No stale folders found. Everything is clean!")
-		return nil
+	health := make([]project.Health, len(folders))
+	var wg sync.WaitGroup
+	for i, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, folder string) {
+			defer wg.Done()
+			health[i] = project.CheckHealth(folder)
+		}(i, folder)
 	}
+	wg.Wait()
 
-	if dryRun {
-		fmt.Printf("Would remove %d stale folder(s):\n", result.RemovedCount)
-	} else {
-		fmt.Printf("Removed %d stale folder(s):\n", result.RemovedCount)
-	}
+	// Descriptions and archived status stored by scope enrich; the list
+	// is still useful without them
+	meta, _ := tag.ListMeta()
 
-	for _, path := range result.RemovedFolders {
-		fmt.Printf("  %s\n", path)
+	now := time.Now()
+	var legend []string
+	for _, c := range health[0].Checks(now) {
+		legend = append(legend, c.Icon+" "+c.Name)
 	}
 
-	return nil
+	for i, folder := range folders {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(out, "  %-7s  %s %s\n", "", folder, ui.Color("yellow", "(remote)"))
+			continue
+		}
+		var icons []string
+		for _, c := range health[i].Checks(now) {
+			if c.OK {
+				icons = append(icons, ui.Color("green", c.Icon))
+			} else {
+				icons = append(icons, "·")
+			}
+		}
+		line := fmt.Sprintf("  %s  %s", strings.Join(icons, " "), folder)
+		if problems := health[i].Problems(now); problems != "" {
+			line += "  " + ui.Color("yellow", problems)
+		}
+		if meta[folder][forge.MetaArchived] == "true" {
+			line += "  " + ui.Color("yellow", "archived")
+		}
+		fmt.Fprintln(out, line)
+		if description := meta[folder][forge.MetaDescription]; description != "" {
+			fmt.Fprintf(out, "  %*s  %s\n", len(icons)*2-1, "", description)
+		}
+	}
+	ui.Infof("\n%s\n", strings.Join(legend, "  "))
 }
 
-// ExportData represents the structure of exported data
-type ExportData struct {
-	Version int                 `yaml:"version"`
-	Tags    map[string][]string `yaml:"tags"`
-}
+func handleOrder() error {
+	usage := fmt.Errorf("usage: scope order <tag> [path...] | <tag> --reset")
+	if len(os.Args) < 3 || strings.HasPrefix(os.Args[2], "-") {
+		return usage
+	}
 
-func handleExport() error {
-	tags, err := tag.ListTags()
+	tagName := os.Args[2]
+	args := os.Args[3:]
+	folders, err := tag.ListFoldersByExactTag(tagName)
 	if err != nil {
 		return err
 	}
 
-	if len(tags) == 0 {
-		fmt.Fprintln(os.Stderr, "No tags to export")
-		return nil
-	}
-
-	data := ExportData{
-		Version: 1,
-		Tags:    make(map[string][]string),
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
 	}
 
-	// Get folders for each tag
-	for tagName := range tags {
-		folders, err := tag.ListFoldersByTag(tagName)
+	var order []string
+	switch {
+	case len(args) == 1 && args[0] == "--reset":
+		order = nil
+	case len(args) > 0:
+		// The given folders come first, the rest keep their current order
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") {
+				return usage
+			}
+			folder, err := resolveFolder(arg)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(folders, folder) {
+				return fmt.Errorf("folder %s is not tagged '%s'", folder, tagName)
+			}
+			if !slices.Contains(order, folder) {
+				order = append(order, folder)
+			}
+		}
+		for _, folder := range folders {
+			if !slices.Contains(order, folder) {
+				order = append(order, folder)
+			}
+		}
+	default:
+		if err := ui.CanPrompt(); err != nil {
+			return fmt.Errorf("pass the folders in order: %w", err)
+		}
+		order, err = picker.Reorder(fmt.Sprintf("Order of '%s'", tagName), folders)
+		if errors.Is(err, picker.ErrCanceled) {
+			ui.Infoln("Order unchanged")
+			return nil
+		}
 		if err != nil {
-			return fmt.Errorf("failed to get folders for tag '%s': %w", tagName, err)
+			return err
 		}
-		data.Tags[tagName] = folders
 	}
 
-	// Marshal to YAML
-	output, err := yaml.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal to YAML: %w", err)
+	if err := tag.SetOrder(tagName, order); err != nil {
+		return err
+	}
+	if order == nil {
+		ui.Infof("'%s' is back to path order\n", tagName)
+		return nil
+	}
+	for i, folder := range order {
+		fmt.Printf("%3d. %s\n", i+1, folder)
 	}
-
-	fmt.Print(string(output))
 	return nil
 }
 
-func handleImport() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope import <file>")
+// printTagCount prints one line of the tag listing, with the tag's label
+// from tagLabel
+func printTagCount(out io.Writer, label string, count int) {
+	plural := ""
+	if count != 1 {
+		plural = "s"
 	}
+	fmt.Fprintf(out, "  %s %d folder%s\n", label, count, plural)
+}
 
-	filePath := os.Args[2]
-
-	// Read file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+// tagLabel returns a tag's name as listed, after its icon and padded to
+// width, in its color
+func tagLabel(name string, info tag.Info, width int) string {
+	if info.Icon != "" {
+		name = info.Icon + " " + name
 	}
-
-	// Parse YAML
-	var data ExportData
-	if err := yaml.Unmarshal(content, &data); err != nil {
-		return fmt.Errorf("failed to parse YAML: %w", err)
+	name = fmt.Sprintf("%-*s", width, name)
+	if info.Color != "" {
+		name = ui.Color(info.Color, name)
 	}
+	return name
+}
 
-	if len(data.Tags) == 0 {
-		fmt.Println("No tags found in import file")
-		return nil
+func handleStart() error {
+	usage := fmt.Errorf("usage: scope start <tag> [--flat=false] [--exit origin|first] [--nesting nest|deny|replace] [--record] [--tmux]")
+	if len(os.Args) < 3 {
+		return usage
 	}
 
-	// Import tags
-	imported := 0
-	skipped := 0
-
-	for tagName, folders := range data.Tags {
-		for _, folder := range folders {
-			// Check if folder exists
-			if _, err := os.Stat(folder); os.IsNotExist(err) {
-				fmt.Fprintf(os.Stderr, "Skipping non-existent folder: %s\n", folder)
-				skipped++
-				continue
+	tagName := os.Args[2]
+	opts := session.Options{Nesting: cfg.Sessions.NestingPolicy(), Args: os.Args[1:]}
+	exitDir := cfg.Sessions.ExitDir()
+	record := cfg.Sessions.Record
+	tmux := false
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--flat", "--flat=true":
+			opts.Nested = false
+		case "--flat=false":
+			opts.Nested = true
+		case "--exit":
+			if i+1 >= len(args) {
+				return usage
 			}
-
-			if err := tag.AddTag(folder, tagName); err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: failed to add tag '%s' to %s: %v\n", tagName, folder, err)
-				continue
+			i++
+			dir, err := session.ParseExitDir(args[i])
+			if err != nil {
+				return err
 			}
-			imported++
+			exitDir = dir
+		case "--nesting":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			nesting, err := session.ParseNesting(args[i])
+			if err != nil {
+				return err
+			}
+			opts.Nesting = nesting
+		case "--record":
+			record = true
+		case "--tmux":
+			tmux = true
+		default:
+			return usage
 		}
 	}
 
-	fmt.Printf("Imported %d tag assignments (%d skipped)\n", imported, skipped)
-	return nil
-}
+	// tmux sessions outlive scope, so there is no workspace to track
+	if tmux {
+		return session.StartTmux(tagName)
+	}
 
-func handleDebug() error {
-	homeDir, err := os.UserHomeDir()
+	origin, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return err
 	}
-	dbPath := filepath.Join(homeDir, ".config", "scope", "scope.db")
-
-	fmt.Println("Scope Debug Information")
-	fmt.Println("=======================")
-	fmt.Printf("Version:     %s\n", Version)
-	fmt.Printf("OS/Arch:     %s/%s\n", runtime.GOOS, runtime.GOARCH)
-	fmt.Printf("Go version:  %s\n", runtime.Version())
-	fmt.Printf("Database:    %s\n", dbPath)
 
-	// Check if db exists
-	if _, err := os.Stat(dbPath); err == nil {
-		info, _ := os.Stat(dbPath)
-		fmt.Printf("DB size:     %d bytes\n", info.Size())
-	} else {
-		fmt.Printf("DB size:     (not found)\n")
+	files, err := cfg.Sessions.WorkspaceFiles(tagName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
+	opts.Files = files
 
-	// Shell info
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "(unknown)"
+	if record {
+		dir, err := cfg.Sessions.HistoryDir()
+		if err == nil {
+			err = os.MkdirAll(dir, 0755)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create session history directory: %w", err)
+		}
+		opts.HistoryFile = session.HistoryFile(dir, tagName, time.Now())
 	}
-	fmt.Printf("Shell:       %s\n", shell)
 
-	// Scope session info
-	scopeSession := os.Getenv("SCOPE_SESSION")
-	if scopeSession != "" {
-		fmt.Printf("In session:  %s\n", scopeSession)
-		fmt.Printf("Workspace:   %s\n", os.Getenv("SCOPE_WORKSPACE"))
+	var started time.Time
+	opts.OnStart = func(workspace string, folders []string) {
+		started = time.Now()
+		emit(events.Event{Type: events.SessionStarted, Tag: tagName, Workspace: workspace, Folders: folders})
 	}
 
-	// Stats
-	tags, _ := tag.ListTags()
-	totalFolders := 0
-	for _, count := range tags {
-		totalFolders += count
+	err = session.StartSession(tagName, opts)
+	if !started.IsZero() {
+		emit(events.Event{Type: events.SessionEnded, Tag: tagName})
+		if !cfg.Time.Disabled {
+			if err := timeTracker().RecordSession(tagName, started, time.Now()); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to record session time: %v\n", err)
+			}
+		}
 	}
-	fmt.Printf("\nStats:\n")
-	fmt.Printf("  Tags:      %d\n", len(tags))
-	fmt.Printf("  Folders:   %d tag assignments\n", totalFolders)
 
-	return nil
+	var replaced *session.Replaced
+	if errors.As(err, &replaced) {
+		return runReplacement(replaced.Args)
+	}
+	if !started.IsZero() {
+		if err := session.WriteExitDir(sessionExitDir(tagName, exitDir, origin)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write exit directory: %v\n", err)
+		}
+	}
+	return err
 }
 
-func handleGo() error {
+func handleSession() error {
+	usage := fmt.Errorf("usage: scope session refresh\n       scope session log [tag] [-n sessions]")
 	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope go <tag>")
+		return usage
 	}
-
-	tagName := os.Args[2]
-
-	folders, err := tag.ListFoldersByTag(tagName)
-	if err != nil {
-		return err
+	switch os.Args[2] {
+	case "refresh":
+		if len(os.Args) != 3 {
+			return usage
+		}
+		return refreshSession()
+	case "log":
+		return sessionLog(os.Args[3:], usage)
 	}
+	return usage
+}
 
-	if len(folders) == 0 {
-		return fmt.Errorf("no folders found with tag '%s'", tagName)
+func handleWorkspace() error {
+	usage := fmt.Errorf("usage: scope workspace create <name> <tag>...\n       scope workspace open <name>\n       scope workspace list\n       scope workspace delete <name>")
+	if len(os.Args) < 3 {
+		return usage
 	}
-
-	// Single folder - just output the path
-	if len(folders) == 1 {
-		fmt.Println(folders[0])
+	args := os.Args[3:]
+	switch os.Args[2] {
+	case "create":
+		if len(args) < 2 {
+			return usage
+		}
+		w, refreshed, err := session.CreateWorkspace(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		for _, folder := range refreshed.Added {
+			ui.Infof("%s %s\n", ui.Color("green", "+"), folder)
+		}
+		for _, folder := range refreshed.Removed {
+			ui.Infof("%s %s\n", ui.Color("red", "-"), folder)
+		}
+		ui.Infof("Workspace '%s' (%s) is at:\n", w.Name, w.Label())
+		fmt.Println(w.Dir)
 		return nil
-	}
-
-	// Multiple folders - show picker
-	fmt.Fprintf(os.Stderr, "Multiple folders found for '%s':\n", tagName)
-	for i, folder := range folders {
-		fmt.Fprintf(os.Stderr, "  [%d] %s\n", i+1, folder)
-	}
-	fmt.Fprintf(os.Stderr, "\nSelect folder (1-%d): ", len(folders))
-
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
-	}
-
-	input = strings.TrimSpace(input)
-	choice, err := strconv.Atoi(input)
-	if err != nil || choice < 1 || choice > len(folders) {
-		return fmt.Errorf("invalid selection: %s", input)
-	}
-
-	fmt.Println(folders[choice-1])
-	return nil
-}
-
-func handlePick() error {
-	var folders []string
-	var err error
-
-	// If tag provided, filter by tag
-	if len(os.Args) >= 3 {
-		tagName := os.Args[2]
-		folders, err = tag.ListFoldersByTag(tagName)
+	case "open":
+		if len(args) != 1 {
+			return usage
+		}
+		// Files configured for any of the workspace's tags are generated
+		w, err := session.GetWorkspace(args[0])
 		if err != nil {
 			return err
 		}
-		if len(folders) == 0 {
-			return fmt.Errorf("no folders found with tag '%s'", tagName)
+		opts := session.Options{Nesting: cfg.Sessions.NestingPolicy(), Args: os.Args[1:]}
+		seen := make(map[string]bool)
+		for _, tagName := range w.Tags {
+			files, err := cfg.Sessions.WorkspaceFiles(tagName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			for _, f := range files {
+				if !seen[f.Path] {
+					seen[f.Path] = true
+					opts.Files = append(opts.Files, f)
+				}
+			}
 		}
-	} else {
-		// Get all folders from all tags
-		folders, err = tag.ListAllFolders()
+		err = session.OpenWorkspace(w.Name, opts)
+		var replaced *session.Replaced
+		if errors.As(err, &replaced) {
+			return runReplacement(replaced.Args)
+		}
+		return err
+	case "list":
+		if len(args) != 0 {
+			return usage
+		}
+		workspaces, err := session.ListWorkspaces()
 		if err != nil {
 			return err
 		}
-		if len(folders) == 0 {
-			fmt.Println("No tagged folders found. Use 'scope tag <path> <tag>' to tag folders.")
+		if len(workspaces) == 0 {
+			ui.Infoln("No workspaces. Create one with 'scope workspace create <name> <tag>...'")
 			return nil
 		}
+		for _, w := range workspaces {
+			fmt.Printf("%-20s %-30s %s\n", w.Name, w.Label(), w.Dir)
+		}
+		return nil
+	case "delete":
+		if len(args) != 1 {
+			return usage
+		}
+		if err := session.DeleteWorkspace(args[0]); err != nil {
+			return err
+		}
+		ui.Infof("Deleted workspace '%s'\n", args[0])
+		return nil
 	}
+	return usage
+}
 
-	// Build options for select
-	options := make([]huh.Option[string], len(folders))
-	for i, folder := range folders {
-		folderName := filepath.Base(folder)
-		options[i] = huh.NewOption(fmt.Sprintf("%s (%s)", folderName, folder), folder)
+// refreshSession updates the links of the session scope runs in
+func refreshSession() error {
+	current, ok := session.CurrentSession()
+	if !ok || current.Workspace == "" {
+		return fmt.Errorf("not in a scope session")
 	}
-
-	var selected string
-	form := huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Select a folder").
-				Description("Use / to filter, enter to select").
-				Options(options...).
-				Value(&selected),
-		),
-	)
-
-	err = form.Run()
+	refreshed, err := session.RefreshSession(current.Workspace, current.Tag)
 	if err != nil {
-		return fmt.Errorf("selection canceled: %w", err)
+		return err
 	}
 
-	// Output the selected path
-	fmt.Println(selected)
+	for _, folder := range refreshed.Added {
+		ui.Infof("%s %s\n", ui.Color("green", "+"), folder)
+	}
+	for _, folder := range refreshed.Removed {
+		ui.Infof("%s %s\n", ui.Color("red", "-"), folder)
+	}
+	if len(refreshed.Added) == 0 && len(refreshed.Removed) == 0 {
+		ui.Infof("Session '%s' is up to date\n", current.Tag)
+	} else {
+		ui.Infof("Refreshed session '%s': %d added, %d removed\n", current.Tag, len(refreshed.Added), len(refreshed.Removed))
+	}
 	return nil
 }
 
-func handleOpen() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope open <tag>")
+// sessionLog prints the commands of the latest recorded sessions, of the
+// given tag or else the current session's
+func sessionLog(args []string, usage error) error {
+	tagName, count := "", 1
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "-n":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid session count: %s", args[i])
+			}
+			count = n
+		case strings.HasPrefix(args[i], "-") || tagName != "":
+			return usage
+		default:
+			tagName = args[i]
+		}
+	}
+	if current, ok := session.CurrentSession(); ok && tagName == "" {
+		tagName = current.Tag
 	}
 
-	tagName := os.Args[2]
-
-	folders, err := tag.ListFoldersByTag(tagName)
+	dir, err := cfg.Sessions.HistoryDir()
 	if err != nil {
 		return err
 	}
-
-	if len(folders) == 0 {
-		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	recordings, err := session.ListRecordings(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list recorded sessions: %w", err)
 	}
-
-	// Determine the open command based on OS
-	var openCmd string
-	switch runtime.GOOS {
-	case "darwin":
-		openCmd = "open"
-	case "linux":
-		openCmd = "xdg-open"
-	case "windows":
-		openCmd = "explorer"
-	default:
-		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	if tagName != "" {
+		recordings = slices.DeleteFunc(recordings, func(r session.Recording) bool { return r.Tag != tagName })
 	}
-
-	// Open each folder
-	for _, folder := range folders {
-		cmd := exec.Command(openCmd, folder)
-		if err := cmd.Start(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to open '%s': %v\n", folder, err)
-			continue
+	if len(recordings) == 0 {
+		if tagName != "" {
+			ui.Infof("No recorded sessions for tag '%s'\n", tagName)
+		} else {
+			ui.Infoln("No recorded sessions")
 		}
-		fmt.Printf("Opened: %s\n", folder)
+		ui.Infoln("Record one with 'scope start <tag> --record' or sessions.record in the config")
+		return nil
 	}
 
+	recordings = recordings[max(0, len(recordings)-count):]
+	for i, recording := range recordings {
+		commands, err := session.ReadHistory(recording.Path)
+		if err != nil {
+			return fmt.Errorf("failed to read session history: %w", err)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s  %s  (%d commands)\n", ui.Color("blue", recording.Tag), recording.Started.Format("2006-01-02 15:04"), len(commands))
+		for _, command := range commands {
+			fmt.Printf("  %s\n", strings.ReplaceAll(command, "\n", "\n  "))
+		}
+	}
 	return nil
 }
 
-func handleEdit() error {
+func handleIncident() error {
+	usage := fmt.Errorf("usage: scope incident start <service>... [--no-clone]\n       scope incident end [tag]")
 	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope edit <tag>")
+		return usage
+	}
+	switch os.Args[2] {
+	case "start":
+		return startIncident(os.Args[3:], usage)
+	case "end":
+		if len(os.Args) > 4 {
+			return usage
+		}
+		return endIncident(strings.Join(os.Args[3:], ""))
 	}
+	return usage
+}
 
-	tagName := os.Args[2]
+// startIncident tags the services with a new incident tag and starts a
+// recorded session of it
+func startIncident(args []string, usage error) error {
+	var services []string
+	clone := true
+	for _, arg := range args {
+		switch {
+		case arg == "--no-clone":
+			clone = false
+		case strings.HasPrefix(arg, "-"):
+			return usage
+		default:
+			services = append(services, arg)
+		}
+	}
+	if len(services) == 0 {
+		return usage
+	}
 
-	folders, err := tag.ListFoldersByTag(tagName)
+	opts, err := cfg.Incident.Options()
 	if err != nil {
 		return err
 	}
-
-	if len(folders) == 0 {
-		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	folders, err := tag.ListAllFolders()
+	if err != nil {
+		return err
 	}
-
-	// Determine editor
-	editor := os.Getenv("VISUAL")
-	if editor == "" {
-		editor = os.Getenv("EDITOR")
+	resolved, err := incident.Resolve(services, folders, opts)
+	if err != nil {
+		return err
 	}
-	if editor == "" {
-		// Try common editors
-		for _, e := range []string{"code", "vim", "nano"} {
-			if _, err := exec.LookPath(e); err == nil {
-				editor = e
-				break
-			}
+	for _, svc := range resolved {
+		if !svc.Missing {
+			continue
+		}
+		if !clone {
+			return fmt.Errorf("service %s not found", svc.Name)
+		}
+		ui.Infof("Cloning %s into %s\n", svc.Name, svc.Folder)
+		if err := incident.Clone(svc, opts); err != nil {
+			return err
 		}
-	}
-	if editor == "" {
-		return fmt.Errorf("no editor found. Set $EDITOR or $VISUAL environment variable")
 	}
 
-	// Open each folder in editor
-	for _, folder := range folders {
-		cmd := exec.Command(editor, folder)
-		if err := cmd.Start(); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to open '%s' in %s: %v\n", folder, editor, err)
-			continue
+	tags, err := tag.ListTags()
+	if err != nil {
+		return err
+	}
+	tagName := incident.NewTag(tags, time.Now())
+	for _, svc := range resolved {
+		if err := tag.AddTag(svc.Folder, tagName); err != nil {
+			return fmt.Errorf("failed to tag %s: %w", svc.Folder, err)
 		}
-		fmt.Printf("Opened in %s: %s\n", editor, folder)
 	}
+	ui.Infof("Started incident '%s' with %d services; end it with 'scope incident end'\n", tagName, len(resolved))
 
-	return nil
+	os.Args = []string{os.Args[0], "start", tagName, "--record"}
+	return handleStart()
 }
 
-func handleEach() error {
-	if len(os.Args) < 4 {
-		return fmt.Errorf("usage: scope each <tag> [-p] <command>")
+// endIncident archives an incident with its session log and removes its
+// tag. Without a tag it ends the current session's incident, or the only
+// one in progress.
+func endIncident(tagName string) error {
+	if tagName == "" {
+		if current, ok := session.CurrentSession(); ok && incident.IsTag(current.Tag) {
+			tagName = current.Tag
+		}
 	}
-
-	tagName := os.Args[2]
-	parallel := false
-	cmdStart := 3
-
-	// Check for parallel flag
-	if os.Args[3] == "-p" || os.Args[3] == "--parallel" {
-		parallel = true
-		cmdStart = 4
-		if len(os.Args) < 5 {
-			return fmt.Errorf("usage: scope each <tag> [-p] <command>")
+	if tagName == "" {
+		tags, err := tag.ListTags()
+		if err != nil {
+			return err
+		}
+		var open []string
+		for name := range tags {
+			if incident.IsTag(name) {
+				open = append(open, name)
+			}
+		}
+		sort.Strings(open)
+		switch len(open) {
+		case 0:
+			return fmt.Errorf("no incident in progress")
+		case 1:
+			tagName = open[0]
+		default:
+			return fmt.Errorf("several incidents in progress (%s); name the one to end", strings.Join(open, ", "))
 		}
 	}
-
-	// Join remaining args as command
-	command := strings.Join(os.Args[cmdStart:], " ")
+	if !incident.IsTag(tagName) {
+		return fmt.Errorf("%s is not an incident tag", tagName)
+	}
 
 	folders, err := tag.ListFoldersByTag(tagName)
 	if err != nil {
 		return err
 	}
-
 	if len(folders) == 0 {
-		return fmt.Errorf("no folders found with tag '%s'", tagName)
+		return fmt.Errorf("no incident found with tag: %s", tagName)
 	}
-
-	if parallel {
-		return runEachParallel(folders, command)
+	historyDir, err := cfg.Sessions.HistoryDir()
+	if err != nil {
+		return err
 	}
-	return runEachSequential(folders, command)
-}
-
-func runEachSequential(folders []string, command string) error {
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
+	recordings, err := session.ListRecordings(historyDir)
+	if err != nil {
+		return fmt.Errorf("failed to list recorded sessions: %w", err)
+	}
+	archiveDir, err := cfg.Incident.ArchiveDir()
+	if err != nil {
+		return err
+	}
+	path, err := incident.Archive(archiveDir, tagName, folders, recordings)
+	if err != nil {
+		return err
+	}
+	if err := tag.DeleteTag(tagName); err != nil {
+		return err
 	}
 
-	successCount := 0
-	failCount := 0
-
-	for _, folder := range folders {
-		folderName := filepath.Base(folder)
-		fmt.Printf("\n\033[1;34m[%s]\033[0m %s\n", folderName, folder)
-		fmt.Println(strings.Repeat("-", 40))
-
-		cmd := exec.Command(shell, "-c", command)
-		cmd.Dir = folder
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	ui.Infof("Ended incident '%s'; archived to %s\n", tagName, path)
+	return nil
+}
 
-		if err := cmd.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "\033[1;31mError:\033[0m %v\n", err)
-			failCount++
-		} else {
-			successCount++
+// runReplacement runs the scope command that replaced a session, in the
+// shell the session was started from. It nests rather than replacing
+// again, since the session it replaced is already gone.
+func runReplacement(args []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to start replacement session: %w", err)
+	}
+	cmd := exec.Command(self, append(args, "--nesting", string(session.Nest))...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// The replacement already reported its error
+			os.Exit(exitErr.ExitCode())
 		}
+		return fmt.Errorf("failed to start replacement session: %w", err)
 	}
-
-	fmt.Printf("\n\033[1mSummary:\033[0m %d succeeded, %d failed\n", successCount, failCount)
 	return nil
 }
 
-func runEachParallel(folders []string, command string) error {
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
+// sessionExitDir returns the directory to go to after a session of
+// tagName. A tag whose first folder is remote returns to origin.
+func sessionExitDir(tagName string, exitDir session.ExitDir, origin string) string {
+	if exitDir != session.ExitFirst {
+		return origin
 	}
-
-	type result struct {
-		folder string
-		output string
-		err    error
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil || len(folders) == 0 || location.IsRemote(folders[0]) {
+		return origin
 	}
-
-	results := make(chan result, len(folders))
-	var wg sync.WaitGroup
-
-	for _, folder := range folders {
-		wg.Add(1)
-		go func(f string) {
-			defer wg.Done()
-
-			var stdout, stderr bytes.Buffer
-			cmd := exec.Command(shell, "-c", command)
-			cmd.Dir = f
-			cmd.Stdout = &stdout
-			cmd.Stderr = &stderr
-
-			err := cmd.Run()
-			output := stdout.String()
-			if stderr.Len() > 0 {
-				output += stderr.String()
-			}
-
-			results <- result{folder: f, output: output, err: err}
-		}(folder)
+	dirs, err := workDirs(folders[:1])
+	if err != nil {
+		return origin
 	}
+	return dirs[0]
+}
 
-	// Close results channel when all goroutines complete
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	// Collect and print results
-	successCount := 0
-	failCount := 0
-
-	for r := range results {
-		folderName := filepath.Base(r.folder)
-		fmt.Printf("\n\033[1;34m[%s]\033[0m %s\n", folderName, r.folder)
-		fmt.Println(strings.Repeat("-", 40))
+// emit publishes ev to the configured integrations, if any
+func emit(ev events.Event) {
+	if bus == nil {
+		return
+	}
+	if err := bus.Emit(ev); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to publish event: %v\n", err)
+	}
+}
 
-		if r.output != "" {
-			fmt.Print(r.output)
+// envFile is where scope env --export writes when no --output is given
+const envFile = ".scope.env"
+
+// handleEnv prints, or with --export writes, one environment variable per
+// folder of a tag, e.g. export WORK_API=/path/to/api, so scripts and
+// Makefiles can refer to sibling projects without hardcoding paths
+func handleEnv() error {
+	usage := fmt.Errorf("usage: scope env [tag] [--format sh|fish|make] [--prefix <name>] [--export] [--output <file>]")
+	tagName, format, prefix, output := "", "sh", "", ""
+	export := false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--format", "--prefix", "--output", "-o":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			switch args[i-1] {
+			case "--format":
+				format = args[i]
+			case "--prefix":
+				prefix = args[i]
+			default:
+				output = args[i]
+			}
+		case "--export":
+			export = true
+		default:
+			if strings.HasPrefix(args[i], "-") || tagName != "" {
+				return usage
+			}
+			tagName = args[i]
 		}
-
-		if r.err != nil {
-			fmt.Fprintf(os.Stderr, "\033[1;31mError:\033[0m %v\n", r.err)
-			failCount++
-		} else {
-			successCount++
+	}
+	if tagName == "" {
+		current, ok := session.CurrentSession()
+		if !ok {
+			return usage
 		}
+		tagName = current.Tag
 	}
 
-	fmt.Printf("\n\033[1mSummary:\033[0m %d succeeded, %d failed\n", successCount, failCount)
-	return nil
-}
-
-func handleStatus() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope status <tag>")
+	var line func(name, value string) string
+	switch format {
+	case "sh":
+		line = func(name, value string) string { return "export " + name + "=" + shell.Quote(value) }
+	case "fish":
+		line = func(name, value string) string { return "set -gx " + name + " " + shell.QuoteFish(value) }
+	case "make":
+		line = func(name, value string) string { return "export " + name + " := " + value }
+	default:
+		return fmt.Errorf("unknown format %q (expected sh, fish or make)", format)
 	}
 
-	tagName := os.Args[2]
+	if prefix == "" {
+		// An expression has no name of its own
+		prefix = tagName
+		if tag.IsExpr(tagName) {
+			prefix = "scope"
+		}
+	}
+	prefix = envName(prefix)
 
-	folders, err := tag.ListFoldersByTag(tagName)
+	folders, err := tag.SelectFolders(tagName)
 	if err != nil {
 		return err
 	}
-
 	if len(folders) == 0 {
 		return fmt.Errorf("no folders found with tag '%s'", tagName)
 	}
 
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
-	}
-
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by scope env %s\n", tagName)
+	used := make(map[string]bool)
 	for _, folder := range folders {
-		// Check if it's a git repo
-		gitDir := filepath.Join(folder, ".git")
-		if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s\n", folder)
 			continue
 		}
-
-		folderName := filepath.Base(folder)
-
-		// Get git status
-		cmd := exec.Command(shell, "-c", "git status -s")
-		cmd.Dir = folder
-		output, _ := cmd.Output()
-
-		if len(output) > 0 {
-			fmt.Printf("\033[1;33m[%s]\033[0m %s\n", folderName, folder)
-			fmt.Print(string(output))
-			fmt.Println()
+		base := envName(prefix + "_" + filepath.Base(folder))
+		// Folders with the same name get numbered in tag order
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
 		}
+		used[name] = true
+		b.WriteString(line(name, folder) + "\n")
 	}
 
+	if !export && output == "" {
+		fmt.Print(b.String())
+		return nil
+	}
+	if output == "" {
+		output = envFile
+	}
+	if err := os.WriteFile(output, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	ui.Infof("Wrote %d variables to %s\n", len(used), output)
 	return nil
 }
 
-func handlePull() error {
+// envName makes s a valid environment variable name: upper case, with
+// runs of anything but letters and digits replaced by _
+func envName(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			underscore = false
+		} else if !underscore && b.Len() > 0 {
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	name := strings.TrimSuffix(b.String(), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "_" + name
+	}
+	return name
+}
+
+func handleRemoveTag() error {
 	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope pull <tag>")
+		return fmt.Errorf("usage: scope remove-tag <tag>")
 	}
 
 	tagName := os.Args[2]
 
-	folders, err := tag.ListFoldersByTag(tagName)
+	if err := tag.DeleteTag(tagName); err != nil {
+		return err
+	}
+
+	ui.Infof("Removed tag '%s' (undo with 'scope trash restore %s')\n", tagName, tagName)
+	return nil
+}
+
+// handleTagInfo shows what describes a tag
+func handleTagInfo() error {
+	if len(os.Args) != 3 {
+		return fmt.Errorf("usage: scope tag-info <tag>")
+	}
+	name := os.Args[2]
+	info, err := tag.GetTagInfo(name)
+	if err != nil {
+		return err
+	}
+	folders, err := tag.ListFoldersByTag(name)
 	if err != nil {
 		return err
 	}
 
-	if len(folders) == 0 {
-		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	fmt.Printf("Tag:         %s\n", strings.TrimSpace(tagLabel(name, info, 0)))
+	if info.Description != "" {
+		fmt.Printf("Description: %s\n", info.Description)
 	}
+	if info.Color != "" {
+		fmt.Printf("Color:       %s\n", info.Color)
+	}
+	if info.Icon != "" {
+		fmt.Printf("Icon:        %s\n", info.Icon)
+	}
+	fmt.Printf("Folders:     %d\n", len(folders))
+	return nil
+}
 
-	// Filter to git repos only
-	var gitFolders []string
-	for _, folder := range folders {
-		gitDir := filepath.Join(folder, ".git")
-		if _, err := os.Stat(gitDir); err == nil {
-			gitFolders = append(gitFolders, folder)
+// handleTagSet changes the description, color or icon of a tag
+func handleTagSet() error {
+	usage := fmt.Errorf("usage: scope tag-set <tag> [--description <text>] [--color <color>] [--icon <icon>]")
+	if len(os.Args) < 5 || strings.HasPrefix(os.Args[2], "-") {
+		return usage
+	}
+	name := os.Args[2]
+	var update tag.InfoUpdate
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return usage
+		}
+		value := args[i+1]
+		switch args[i] {
+		case "--description", "-d":
+			update.Description = &value
+		case "--color", "-c":
+			if err := checkColor(value); err != nil {
+				return err
+			}
+			update.Color = &value
+		case "--icon", "-i":
+			update.Icon = &value
+		default:
+			return usage
 		}
+		i++
 	}
 
-	if len(gitFolders) == 0 {
-		fmt.Println("No git repositories found with this tag")
-		return nil
+	if err := tag.SetTagInfo(name, update); err != nil {
+		return err
 	}
+	ui.Infof("Updated tag '%s'\n", name)
+	return nil
+}
 
-	fmt.Printf("Pulling %d repositories...\n", len(gitFolders))
-	return runEachParallel(gitFolders, "git pull")
+// checkColor rejects color names ui.Color doesn't know; "" clears a color
+func checkColor(value string) error {
+	if _, ok := ui.ColorCode(value); !ok && value != "" {
+		return fmt.Errorf("unknown color %q (expected one of %s, or \"\" for none)", value, strings.Join(ui.ColorNames(), ", "))
+	}
+	return nil
 }
 
-func handleCompletions() error {
-	if len(os.Args) < 3 {
-		return fmt.Errorf("usage: scope completions <shell>\nSupported shells: bash, zsh, fish")
+func handleScan() error {
+	// Default to current directory
+	path := "."
+	all := false
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--all" || arg == "-a":
+			all = true
+		case strings.HasPrefix(arg, "-") || path != ".":
+			return fmt.Errorf("usage: scope scan [path] [--all]")
+		default:
+			path = arg
+		}
 	}
 
-	shell := os.Args[2]
-	script, err := completions.Generate(shell)
+	// Resolve to absolute path
+	absPath, err := paths.Resolve(path)
 	if err != nil {
 		return err
 	}
 
-	fmt.Print(script)
+	// Verify it's a directory
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("cannot access path: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("path is not a directory: %s", absPath)
+	}
+
+	// With nobody to ask, apply everything, as --all does
+	if !all && ui.Mode() == ui.Default {
+		ui.Infoln("No terminal to ask in; applying every .scope file found (as --all)")
+		all = true
+	}
+	scanner := scan.NewScanner(nil)
+	scanner.All = all
+	if err := scanner.Run(absPath); err != nil {
+		return err
+	}
+	emit(events.Event{Type: events.ScanRan, Path: absPath, Folders: scanner.Applied})
 	return nil
 }
 
-func handleUpdate() error {
-	checkOnly := len(os.Args) >= 3 && (os.Args[2] == "--check" || os.Args[2] == "-c")
+func handleTags() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope tags <path>\n       scope tags --organize [--dry-run] [--yes] [--scope-files]")
+	}
 
-	if checkOnly {
-		info, err := update.CheckForUpdate(Version)
-		if err != nil {
-			return fmt.Errorf("failed to check for updates: %w", err)
+	if os.Args[2] == "--organize" {
+		dryRun, yes, scopeFiles := false, false, false
+		for _, arg := range os.Args[3:] {
+			switch arg {
+			case "--dry-run", "-n":
+				dryRun = true
+			case "--yes", "-y":
+				yes = true
+			case "--scope-files":
+				scopeFiles = true
+			default:
+				return fmt.Errorf("unknown flag: %s", arg)
+			}
 		}
+		return organizeTags(dryRun, yes, scopeFiles)
+	}
 
-		if info.UpdateAvailable {
-			fmt.Printf("Update available: %s (current: %s)\n", info.LatestVersion, info.CurrentVersion)
+	path := os.Args[2]
+
+	// Resolve path
+	absPath, err := resolveFolder(path)
+	if err != nil {
+		return err
+	}
+
+	tags, err := tag.GetTagsForFolder(absPath)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		ui.Infof("No tags found for '%s'\n", absPath)
+		return nil
+	}
+
+	ui.Infof("Tags for '%s':\n", absPath)
+	for _, t := range tags {
+		fmt.Printf("  %s\n", t)
+	}
+
+	todos, err := tag.ListTodos(absPath, false)
+	if err != nil {
+		return err
+	}
+	if len(todos) > 0 {
+		// Informational only, so scripts reading the tags aren't affected
+		ui.Infoln("\nTodos:")
+		for _, todo := range todos {
+			ui.Infof("  %4d  %s\n", todo.ID, todo.Text)
+		}
+	}
+	return nil
+}
+
+// organizeTags finds similar tags and merges the ones the user picks
+func organizeTags(dryRun, yes, scopeFiles bool) error {
+	groups, err := organize.Find(tag.Default())
+	if err != nil {
+		return err
+	}
+
+	if len(groups) == 0 {
+		ui.Infoln("No similar tags found")
+		return nil
+	}
+
+	if dryRun {
+		ui.Infof("Found %d groups of similar tags:\n", len(groups))
+		for _, g := range groups {
+			fmt.Printf("  %s -> %s\n", g.Label(), g.Target)
+		}
+		ui.Infoln("\nRun without --dry-run to choose which to merge")
+		return nil
+	}
+
+	// --yes merges each group into its suggested tag
+	var merges []organize.Merge
+	if yes {
+		for _, g := range groups {
+			merges = append(merges, organize.Merges(g, g.Target)...)
+		}
+	} else {
+		merges, err = organize.SelectMerges(groups)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(merges) == 0 {
+		ui.Infoln("No tags merged")
+		return nil
+	}
+
+	applied, errs := organize.Apply(tag.Default(), merges)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if scopeFiles {
+		for _, mg := range applied {
+			renameInScopeFiles(mg.From, mg.Into)
+		}
+	}
+
+	ui.Infof("Merged %d of %d tags\n", len(applied), len(merges))
+	return nil
+}
+
+func handleNote() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope note <path> [text] [--clear]")
+	}
+
+	absPath, err := resolveFolder(os.Args[2])
+	if err != nil {
+		return err
+	}
+
+	args := os.Args[3:]
+	if len(args) == 1 && args[0] == "--clear" {
+		if err := tag.SetNote(absPath, ""); err != nil {
+			return err
+		}
+		ui.Infof("Cleared note for '%s'\n", absPath)
+		return nil
+	}
+
+	if len(args) > 0 {
+		if err := tag.SetNote(absPath, strings.Join(args, " ")); err != nil {
+			return err
+		}
+		ui.Infof("Saved note for '%s'\n", absPath)
+		return nil
+	}
+
+	note, err := tag.GetNote(absPath)
+	if err != nil {
+		return err
+	}
+	if note == "" {
+		ui.Infof("No note for '%s'\n", absPath)
+		return nil
+	}
+	fmt.Println(note)
+	return nil
+}
+
+// folderOrTag interprets arg as a folder when it looks like a path
+// ("." or containing a separator or ~), then as an existing tag, then as
+// an existing directory. Exactly one of the results is set.
+func folderOrTag(arg string) (folder, tagName string, err error) {
+	looksLikePath := arg == "." || arg == ".." || strings.ContainsAny(arg, "/\\") || strings.HasPrefix(arg, "~")
+	if !looksLikePath {
+		tags, err := tag.ListTags()
+		if err != nil {
+			return "", "", err
+		}
+		if _, ok := tags[arg]; ok {
+			return "", arg, nil
+		}
+		if info, err := os.Stat(arg); err != nil || !info.IsDir() {
+			return "", "", fmt.Errorf("no tag or folder named '%s'", arg)
+		}
+	}
+	folder, err = resolveFolder(arg)
+	return folder, "", err
+}
+
+func handleTodo() error {
+	usage := fmt.Errorf("usage: scope todo <tag|path> [text]\n       scope todo list [tag|path] [--all]\n       scope todo done <id>... [--undo]")
+	if len(os.Args) < 3 {
+		return usage
+	}
+
+	switch os.Args[2] {
+	case "done":
+		return completeTodos(os.Args[3:], usage)
+	case "list":
+		target, all := "", false
+		for _, arg := range os.Args[3:] {
+			switch {
+			case arg == "--all" || arg == "-a":
+				all = true
+			case strings.HasPrefix(arg, "-") || target != "":
+				return usage
+			default:
+				target = arg
+			}
+		}
+		return listTodos(target, all)
+	}
+
+	if len(os.Args) == 3 {
+		return listTodos(os.Args[2], false)
+	}
+
+	folder, tagName, err := folderOrTag(os.Args[2])
+	if err != nil {
+		return err
+	}
+	if tagName != "" {
+		// A tag names a folder only if it has exactly one
+		folders, err := tag.ListFoldersByTag(tagName)
+		if err != nil {
+			return err
+		}
+		if len(folders) != 1 {
+			return fmt.Errorf("tag '%s' has %d folders; give the folder's path instead", tagName, len(folders))
+		}
+		folder = folders[0]
+	}
+
+	todo, err := tag.AddTodo(folder, strings.Join(os.Args[3:], " "))
+	if err != nil {
+		return err
+	}
+	ui.Infof("Added todo %d to '%s'\n", todo.ID, todo.Path)
+	return nil
+}
+
+// listTodos prints the todos of a folder, a tag's folders, or every folder
+// when target is empty, grouped by folder
+func listTodos(target string, all bool) error {
+	var todos []tag.Todo
+	var err error
+	switch {
+	case target == "":
+		todos, err = tag.ListTodosByTag("", all)
+	default:
+		folder, tagName, resolveErr := folderOrTag(target)
+		if resolveErr != nil {
+			return resolveErr
+		}
+		if tagName != "" {
+			todos, err = tag.ListTodosByTag(tagName, all)
+		} else {
+			todos, err = tag.ListTodos(folder, all)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(todos) == 0 {
+		ui.Infoln("No todos")
+		return nil
+	}
+
+	open := 0
+	for i, todo := range todos {
+		if i == 0 || todo.Path != todos[i-1].Path {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s %s\n", ui.Color("blue", filepath.Base(todo.Path)), todo.Path)
+		}
+		if todo.IsDone() {
+			fmt.Printf("  %4d  %s %s\n", todo.ID, ui.Color("green", "✓"), todo.Text)
+			continue
+		}
+		open++
+		fmt.Printf("  %4d  %s\n", todo.ID, todo.Text)
+	}
+
+	ui.Infof("\n%d open todos\n", open)
+	return nil
+}
+
+// completeTodos marks the todos with the given ids done, or open again
+// with --undo
+func completeTodos(args []string, usage error) error {
+	done := true
+	var ids []int64
+	for _, arg := range args {
+		if arg == "--undo" {
+			done = false
+			continue
+		}
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return usage
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return usage
+	}
+
+	for _, id := range ids {
+		todo, err := tag.SetTodoDone(id, done)
+		if err != nil {
+			return err
+		}
+		if done {
+			ui.Infof("Done: %s\n", todo.Text)
+		} else {
+			ui.Infof("Reopened: %s\n", todo.Text)
+		}
+	}
+	return nil
+}
+
+func handleSubdir() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope subdir <path> [dir] [--clear]")
+	}
+
+	absPath, err := resolveFolder(os.Args[2])
+	if err != nil {
+		return err
+	}
+
+	args := os.Args[3:]
+	if len(args) == 1 && args[0] == "--clear" {
+		if err := tag.SetSubdir(absPath, ""); err != nil {
+			return err
+		}
+		ui.Infof("Cleared subdirectory for '%s'\n", absPath)
+		return nil
+	}
+
+	if len(args) == 1 {
+		subdir, err := tag.CleanSubdir(args[0])
+		if err != nil {
+			return err
+		}
+		// Remote folders can't be checked from here
+		if !location.IsRemote(absPath) && subdir != "" {
+			info, err := os.Stat(filepath.Join(absPath, filepath.FromSlash(subdir)))
+			if err != nil || !info.IsDir() {
+				return fmt.Errorf("not a directory: %s", filepath.Join(absPath, filepath.FromSlash(subdir)))
+			}
+		}
+		if err := tag.SetSubdir(absPath, subdir); err != nil {
+			return err
+		}
+		ui.Infof("go, each and edit will use '%s'\n", workDir(absPath, subdir))
+		return nil
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("usage: scope subdir <path> [dir] [--clear]")
+	}
+
+	subdir, err := tag.GetSubdir(absPath)
+	if err != nil {
+		return err
+	}
+	if subdir == "" {
+		ui.Infof("No subdirectory for '%s'\n", absPath)
+		return nil
+	}
+	fmt.Println(subdir)
+	return nil
+}
+
+// workDirs maps folders to the directories go, each and edit work in: the
+// folder, or its subdirectory if one is set
+func workDirs(folders []string) ([]string, error) {
+	subdirs, err := tag.ListSubdirs()
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, len(folders))
+	for i, folder := range folders {
+		dirs[i] = workDir(folder, subdirs[folder])
+	}
+	return dirs, nil
+}
+
+// workDir joins a stored folder and its subdirectory
+func workDir(folder, subdir string) string {
+	if subdir == "" {
+		return folder
+	}
+	if loc, ok := location.Parse(folder); ok {
+		loc.Path = path.Join(loc.Path, subdir)
+		return loc.String()
+	}
+	return filepath.Join(folder, filepath.FromSlash(subdir))
+}
+
+func handleSuggest() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope suggest <path> [--dry-run | --yes]")
+	}
+
+	dryRun := len(os.Args) >= 4 && (os.Args[3] == "--dry-run" || os.Args[3] == "-n")
+	yes := len(os.Args) >= 4 && (os.Args[3] == "--yes" || os.Args[3] == "-y")
+
+	absPath, err := paths.Resolve(os.Args[2])
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return fmt.Errorf("folder does not exist: %s", absPath)
+	}
+	absPath = paths.Canonical(absPath, cfg.Paths.SymlinkPolicy())
+
+	suggestions, err := suggest.For(tag.Default(), absPath)
+	if err != nil {
+		return err
+	}
+
+	if len(suggestions) == 0 {
+		ui.Infof("No suggestions for %s\n", absPath)
+		return nil
+	}
+
+	if dryRun {
+		ui.Infof("Suggested tags for %s:\n", absPath)
+		for _, s := range suggestions {
+			fmt.Printf("  %s\n", s.Label())
+		}
+		return nil
+	}
+
+	// --yes takes the suggestions the prompt would start with selected
+	selected := suggest.Preselected(suggestions)
+	if !yes {
+		selected, err = suggest.SelectSuggestions(absPath, suggestions)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(selected) == 0 {
+		ui.Infoln("No tags selected")
+		return nil
+	}
+
+	for _, tagName := range selected {
+		if err := tag.AddTag(absPath, tagName); err != nil {
+			return err
+		}
+	}
+
+	ui.Infof("Tagged %s with: %s\n", absPath, strings.Join(selected, ", "))
+	return nil
+}
+
+func handleRename() error {
+	var names []string
+	scopeFiles, merge := false, false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--scope-files":
+			scopeFiles = true
+		case "--merge-if-exists":
+			merge = true
+		default:
+			names = append(names, arg)
+		}
+	}
+	if len(names) != 2 {
+		return fmt.Errorf("usage: scope rename <old> <new> [--merge-if-exists] [--scope-files]")
+	}
+
+	oldName := names[0]
+	newName := names[1]
+
+	if merge {
+		// Merging into a tag that doesn't exist renames it
+		if err := tag.MergeTag(oldName, newName); err != nil {
+			return err
+		}
+	} else if err := tag.RenameTag(oldName, newName); err != nil {
+		if errors.Is(err, tag.ErrTagExists) && oldName != newName {
+			return fmt.Errorf("%w (use --merge-if-exists to move its folders into '%s')", err, newName)
+		}
+		return err
+	}
+
+	ui.Infof("Renamed tag '%s' to '%s'\n", oldName, newName)
+	if scopeFiles {
+		renameInScopeFiles(oldName, newName)
+	}
+	return nil
+}
+
+// handleMv moves the tags, note and metadata of a folder that was moved
+// or renamed to its new path, along with those of the tagged folders
+// inside it
+func handleMv() error {
+	if len(os.Args) != 4 {
+		return fmt.Errorf("usage: scope mv <old-path> <new-path>")
+	}
+	oldPath, err := paths.Resolve(os.Args[2])
+	if err != nil {
+		return err
+	}
+	newPath, err := paths.Resolve(os.Args[3])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(newPath); err != nil || !info.IsDir() {
+		return fmt.Errorf("not a directory: %s (move the folder first, then run scope mv)", newPath)
+	}
+
+	folders, err := tag.ListAllFolders()
+	if err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(oldPath, string(filepath.Separator)) + string(filepath.Separator)
+	moved := 0
+	for _, folder := range folders {
+		if folder != oldPath && !strings.HasPrefix(folder, prefix) {
+			continue
+		}
+		target := newPath + strings.TrimPrefix(folder, oldPath)
+		if err := tag.MoveFolder(folder, target); err != nil {
+			return err
+		}
+		ui.Infof("Moved %s to %s\n", folder, target)
+		moved++
+	}
+	if moved == 0 {
+		// Let the store report it, after canonicalizing the path
+		if err := tag.MoveFolder(oldPath, newPath); err != nil {
+			return err
+		}
+		ui.Infof("Moved %s to %s\n", oldPath, newPath)
+	}
+	return nil
+}
+
+// renameInScopeFiles rewrites from to into in the .scope files of the folders
+// now tagged into, so a later scan doesn't bring the old tag back
+func renameInScopeFiles(from, into string) {
+	folders, err := tag.ListFoldersByExactTag(into)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		changed, err := scan.RenameInScopeFile(folder, from, into, false)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		if changed {
+			ui.Infof("Updated %s\n", scan.ScopeFilePath(folder))
+		}
+	}
+}
+
+func handlePrune() error {
+	dryRun := len(os.Args) >= 3 && (os.Args[2] == "--dry-run" || os.Args[2] == "-n")
+
+	result, err := tag.Prune(dryRun)
+	if err != nil {
+		return err
+	}
+
+	if result.RemovedCount == 0 {
+		ui.Infoln("No stale folders found. Everything is clean!")
+		return nil
+	}
+
+	if dryRun {
+		ui.Infof("Would remove %d stale folder(s):\n", result.RemovedCount)
+	} else {
+		ui.Infof("Removed %d stale folder(s):\n", result.RemovedCount)
+		emit(events.Event{Type: events.PruneRan, Folders: result.RemovedFolders})
+	}
+
+	for _, path := range result.RemovedFolders {
+		fmt.Printf("  %s\n", path)
+	}
+	if !dryRun {
+		ui.Infoln("They are in the trash: 'scope trash restore <path>' brings one back")
+	}
+
+	return nil
+}
+
+func handleTrash() error {
+	usage := fmt.Errorf("usage: scope trash [list]\n       scope trash restore <tag|path>...\n       scope trash empty [--yes]")
+	sub := "list"
+	if len(os.Args) >= 3 {
+		sub = os.Args[2]
+	}
+	args := os.Args[min(len(os.Args), 3):]
+
+	switch sub {
+	case "list":
+		if len(args) != 0 {
+			return usage
+		}
+		items, err := tag.ListTrash()
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			ui.Infoln("The trash is empty")
+			return nil
+		}
+		for _, item := range items {
+			count := fmt.Sprintf("%d folder(s)", item.Count)
+			if item.Kind == "folder" {
+				count = fmt.Sprintf("%d tag(s)", item.Count)
+			}
+			fmt.Printf("%-6s  %-40s  %-12s  deleted %s, expires %s\n", item.Kind, item.Name, count,
+				item.DeletedAt.Format("2006-01-02 15:04"), item.ExpiresAt.Format("2006-01-02"))
+		}
+		return nil
+	case "restore":
+		if len(args) == 0 {
+			return usage
+		}
+		for _, name := range args {
+			item, err := tag.Restore(name)
+			if err != nil {
+				return err
+			}
+			if item.Kind == "tag" {
+				ui.Infof("Restored tag '%s' on %d folder(s)\n", item.Name, item.Count)
+			} else {
+				ui.Infof("Restored %s with %d tag(s)\n", item.Name, item.Count)
+			}
+		}
+		return nil
+	case "empty":
+		yes := false
+		for _, arg := range args {
+			switch arg {
+			case "--yes", "-y":
+				yes = true
+			default:
+				return usage
+			}
+		}
+		if !yes {
+			ok, err := ui.Confirm("Empty the trash?", "Deleted tags and folders can't be restored afterwards")
+			if err != nil {
+				return err
+			}
+			if !ok {
+				ui.Infoln("Nothing deleted")
+				return nil
+			}
+		}
+		n, err := tag.EmptyTrash()
+		if err != nil {
+			return err
+		}
+		ui.Infof("Deleted %d item(s) for good\n", n)
+		return nil
+	}
+	return usage
+}
+
+// readOnlyHint explains how to leave read-only mode
+const readOnlyHint = `Scope is in read-only mode, so tags can't be changed. It is enabled by
+'scope lock' (undo with 'scope unlock'), database.read_only in
+~/.config/scope/config.yml, or the SCOPE_READ_ONLY environment variable.`
+
+// envFlag reports whether the environment variable name is set to a true
+// value, e.g. SCOPE_READ_ONLY=1
+func envFlag(name string) bool {
+	v := os.Getenv(name)
+	return v != "" && v != "0" && v != "false"
+}
+
+func handleLock(lock bool) error {
+	store := db.Default()
+	if lock {
+		if err := db.Lock(store.Path()); err != nil {
+			return err
+		}
+		ui.Infoln("Database locked: scope is now read-only ('scope unlock' to undo)")
+		return nil
+	}
+
+	if err := db.Unlock(store.Path()); err != nil {
+		return err
+	}
+	if cfg.Database.ReadOnly || envFlag("SCOPE_READ_ONLY") {
+		ui.Infoln("Database unlocked, but read-only mode is still set by config or SCOPE_READ_ONLY")
+		return nil
+	}
+	ui.Infoln("Database unlocked")
+	return nil
+}
+
+// openJournal opens the sync journal configured in sync.dir
+func openJournal() (*journal.Journal, error) {
+	dir, err := cfg.Sync.JournalDir()
+	if err != nil {
+		return nil, err
+	}
+	return journal.New(dir, cfg.Sync.Machine)
+}
+
+func handleSync() error {
+	if changes == nil {
+		return fmt.Errorf("sync is not configured; set sync.dir in ~/.config/scope/config.yml")
+	}
+
+	if len(os.Args) >= 3 && os.Args[2] == "--seed" {
+		n, err := changes.Seed(tag.Default())
+		if err != nil {
+			return err
+		}
+		ui.Infof("Journaled %d existing tags and notes as '%s'\n", n, changes.Machine())
+		return nil
+	}
+
+	// Entries that fail are not retried, so don't consume them when every
+	// one of them would fail
+	if db.Default().ReadOnly() {
+		return db.ErrReadOnly
+	}
+
+	result, err := changes.Replay(tag.Default())
+	if err != nil {
+		return err
+	}
+
+	ui.Infof("Replayed %d change(s) from other machines\n", result.Applied)
+	for _, s := range result.Skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipped %s from %s: %v\n", s.Entry.Op, s.Entry.Machine, s.Err)
+	}
+	if result.Invalid > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignored %d unreadable journal line(s)\n", result.Invalid)
+	}
+	return nil
+}
+
+func handleTidy() error {
+	dryRun := len(os.Args) >= 3 && (os.Args[2] == "--dry-run" || os.Args[2] == "-n")
+	yes := len(os.Args) >= 3 && (os.Args[2] == "--yes" || os.Args[2] == "-y")
+
+	items, err := tidy.Collect(tag.Default(), tidy.Options{})
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		ui.Infoln("Nothing to tidy")
+		return nil
+	}
+
+	if dryRun {
+		ui.Infof("Found %d cleanups:\n", len(items))
+		for _, item := range items {
+			fmt.Printf("  %s\n", item.Label())
+		}
+		ui.Infoln("\nRun without --dry-run to choose which to apply")
+		return nil
+	}
+
+	// --yes applies the cleanups the prompt would start with selected
+	selected := tidy.Preselected(items)
+	if !yes {
+		selected, err = tidy.SelectItems(items)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(selected) == 0 {
+		ui.Infoln("No cleanups selected")
+		return nil
+	}
+
+	applied, errs := tidy.Apply(tag.Default(), selected)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	ui.Infof("Applied %d of %d cleanups\n", applied, len(selected))
+	return nil
+}
+
+func handleDoctor() error {
+	fix, yes := false, false
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--fix":
+			fix = true
+		case "--yes", "-y":
+			yes = true
+		default:
+			return fmt.Errorf("usage: scope doctor [--fix] [--yes]")
+		}
+	}
+
+	report, err := tag.Doctor(false)
+	if err != nil {
+		return err
+	}
+	if report.Problems() == 0 {
+		ui.Infoln("No problems found")
+		return nil
+	}
+
+	moved := make(map[string][]string, len(report.Moved))
+	for _, m := range report.Moved {
+		moved[m.Path] = m.Candidates
+	}
+	for _, path := range report.Missing {
+		fmt.Printf("  %s %s (missing, prune)\n", ui.Color("red", "[missing]"), path)
+		for _, candidate := range moved[path] {
+			fmt.Printf("      moved? scope mv %s %s\n", shell.Quote(path), shell.Quote(candidate))
+		}
+	}
+	for _, name := range report.EmptyTags {
+		fmt.Printf("  %s %s (no folders, delete)\n", ui.Color("yellow", "[empty tag]"), name)
+	}
+	for _, path := range report.Orphans {
+		fmt.Printf("  %s %s (no tags, forget)\n", ui.Color("yellow", "[orphan]"), path)
+	}
+	for _, dup := range report.Duplicates {
+		fmt.Printf("  %s %s (merge %s)\n", ui.Color("yellow", "[duplicate]"), dup.Canonical, strings.Join(dup.Paths, ", "))
+	}
+
+	if !fix {
+		ui.Infof("\nFound %d problems. Run 'scope doctor --fix' to repair them\n", report.Problems())
+		return nil
+	}
+	if !yes {
+		detail := "Missing folders and orphans are forgotten, empty tags deleted and duplicates merged"
+		if len(report.Moved) > 0 {
+			detail += ". Run the suggested scope mv first to keep the tags of folders that moved"
+		}
+		ok, err := ui.Confirm(fmt.Sprintf("Repair %d problems?", report.Problems()), detail)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			ui.Infoln("Nothing repaired")
+			return nil
+		}
+	}
+
+	report, err = tag.Doctor(true)
+	if err != nil {
+		return err
+	}
+	ui.Infof("\nRepaired %d problems\n", report.Problems())
+	return nil
+}
+
+// exportFormats are the formats scope export writes
+var exportFormats = []string{"yaml", "csv", "plist", "locate", "windows-terminal", "powertoys"}
+
+func handleExport() error {
+	usage := fmt.Errorf("usage: scope export [--format <format> [--matrix]] [--output <file> [--launch-agent [--interval <duration>]]]\n       scope export --to-scope-files [--dry-run]\nformats: %s", strings.Join(exportFormats, ", "))
+
+	var toScopeFiles, dryRun, matrix, launchAgent bool
+	var output string
+	var interval time.Duration
+	format := "yaml"
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--to-scope-files":
+			toScopeFiles = true
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--format", "-f":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires a value (%s)", strings.Join(exportFormats, ", "))
+			}
+			i++
+			format = args[i]
+		case "--matrix":
+			matrix = true
+		case "--output", "-o":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			output = args[i]
+		case "--launch-agent":
+			launchAgent = true
+		case "--interval":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d < time.Minute {
+				return fmt.Errorf("invalid interval %q (expected a duration of at least 1m)", args[i])
+			}
+			interval = d
+		default:
+			return usage
+		}
+	}
+	if !slices.Contains(exportFormats, format) {
+		return fmt.Errorf("unknown export format %q (expected one of %s)", format, strings.Join(exportFormats, ", "))
+	}
+	if matrix && format != "csv" {
+		return fmt.Errorf("--matrix requires --format csv")
+	}
+	if launchAgent && output == "" {
+		return fmt.Errorf("--launch-agent requires --output: the file the agent keeps up to date")
+	}
+	if interval > 0 && !launchAgent {
+		return fmt.Errorf("--interval requires --launch-agent")
+	}
+	if toScopeFiles {
+		return exportScopeFiles(dryRun)
+	}
+	if output != "" {
+		abs, err := filepath.Abs(output)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", output, err)
+		}
+		output = abs
+	}
+	if launchAgent {
+		return writeExportLaunchAgent(format, matrix, output, interval)
+	}
+
+	tags, err := tag.ListTags()
+	if err != nil {
+		return err
+	}
+	// A file kept up to date is written even when it ends up empty
+	if len(tags) == 0 && output == "" {
+		fmt.Fprintln(os.Stderr, "No tags to export")
+		return nil
+	}
+
+	data, err := export.Build(tag.Default())
+	if err != nil {
+		return err
+	}
+
+	// Only YAML can be imported. CSV holds only the tags, for spreadsheets;
+	// the other formats are for launchers, terminals and locate databases.
+	var write func(io.Writer) error
+	switch {
+	case format == "csv" && matrix:
+		write = func(w io.Writer) error { return export.WriteMatrixCSV(w, data) }
+	case format == "csv":
+		write = func(w io.Writer) error { return export.WriteCSV(w, data) }
+	case format == "plist":
+		write = func(w io.Writer) error { return export.WritePlist(w, data) }
+	case format == "locate":
+		write = func(w io.Writer) error { return export.WriteLocate(w, data) }
+	case format == "windows-terminal":
+		write = func(w io.Writer) error { return export.WriteTerminalFragment(w, data) }
+	case format == "powertoys":
+		write = func(w io.Writer) error { return export.WritePowerToys(w, data) }
+	default:
+		content, err := export.Marshal(data)
+		if err != nil {
+			return err
+		}
+		write = func(w io.Writer) error {
+			_, err := w.Write(content)
+			return err
+		}
+	}
+
+	if output == "" {
+		return write(os.Stdout)
+	}
+	return export.WriteFile(output, write)
+}
+
+// writeExportLaunchAgent prints a LaunchAgent running this export into
+// output whenever the database changes
+func writeExportLaunchAgent(format string, matrix bool, output string, interval time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the scope executable: %w", err)
+	}
+	dbDir := filepath.Dir(db.Default().Path())
+	// Writing into the watched directory would rerun the export forever
+	if rel, err := filepath.Rel(dbDir, output); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("--output must be outside %s, which the agent watches for changes", dbDir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	args := []string{exe, "export", "--format", format, "--output", output}
+	if matrix {
+		args = append(args, "--matrix")
+	}
+	if err := export.WriteLaunchAgent(os.Stdout, &export.LaunchAgent{
+		Args:       args,
+		WatchPaths: []string{dbDir},
+		Interval:   interval,
+		Log:        filepath.Join(home, "Library", "Logs", "scope-export.log"),
+	}); err != nil {
+		return err
+	}
+	// stdout is the agent itself
+	fmt.Fprintf(os.Stderr, "Save as ~/Library/LaunchAgents/%s.plist and load it with launchctl load\n", export.LaunchAgentLabel)
+	return nil
+}
+
+// exportScopeFiles writes each folder's tags into a .scope file in the
+// folder, merging with any file already there
+func exportScopeFiles(dryRun bool) error {
+	folderTags, err := tag.ListFolderTags()
+	if err != nil {
+		return err
+	}
+
+	folders := make([]string, 0, len(folderTags))
+	for folder := range folderTags {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+
+	written, unchanged, skipped := 0, 0, 0
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(os.Stderr, "Skipping remote folder: %s\n", folder)
+			skipped++
+			continue
+		}
+		if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "Skipping non-existent folder: %s\n", folder)
+			skipped++
+			continue
+		}
+
+		added, err := scan.MergeScopeFile(folder, folderTags[folder], dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			skipped++
+			continue
+		}
+		if len(added) == 0 {
+			unchanged++
+			continue
+		}
+
+		written++
+		if dryRun {
+			fmt.Printf("[DRY-RUN] Would add %s to %s\n", strings.Join(added, ", "), scan.ScopeFilePath(folder))
+		} else {
+			ui.Infof("Added %s to %s\n", strings.Join(added, ", "), scan.ScopeFilePath(folder))
+		}
+	}
+
+	ui.Infoln()
+	if dryRun {
+		ui.Infof("Dry-run complete: %d .scope files would change, %d up to date, %d skipped\n", written, unchanged, skipped)
+	} else {
+		ui.Infof("Export complete: %d .scope files written, %d up to date, %d skipped\n", written, unchanged, skipped)
+	}
+	return nil
+}
+
+func handleImport() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope import <file>")
+	}
+
+	filePath, err := paths.Expand(os.Args[2])
+	if err != nil {
+		return err
+	}
+
+	// Read file
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// Parse and upgrade to the current format
+	data, err := export.Parse(content)
+	if err != nil {
+		return err
+	}
+
+	return importData(data)
+}
+
+// importData tags the folders of an export document and restores their
+// notes and subdirectories
+func importData(data *export.Data) error {
+	if len(data.Tags) == 0 {
+		ui.Infoln("No tags found in import file")
+		return nil
+	}
+
+	// Import tags
+	imported := 0
+	skipped := 0
+
+	for tagName, folders := range data.Tags {
+		for _, entry := range folders {
+			// Hand-written import files may use ~ or $VARS
+			folder, err := resolveFolder(entry)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping invalid path '%s': %v\n", entry, err)
+				skipped++
+				continue
+			}
+
+			// Check if folder exists
+			if _, err := os.Stat(folder); os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Skipping non-existent folder: %s\n", folder)
+				skipped++
+				continue
+			}
+
+			if err := tag.AddTag(folder, tagName); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to add tag '%s' to %s: %v\n", tagName, folder, err)
+				continue
+			}
+			imported++
+		}
+	}
+
+	// Notes and subdirectories attach to folders, so they're applied once
+	// the tags exist
+	notes := 0
+	for entry, note := range data.Notes {
+		folder, err := resolveFolder(entry)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping note for invalid path '%s': %v\n", entry, err)
+			continue
+		}
+		if err := tag.SetNote(folder, note); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to import note for %s: %v\n", folder, err)
+			continue
+		}
+		notes++
+	}
+
+	for entry, subdir := range data.Subdirs {
+		folder, err := resolveFolder(entry)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping subdirectory for invalid path '%s': %v\n", entry, err)
+			continue
+		}
+		if err := tag.SetSubdir(folder, subdir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to import subdirectory for %s: %v\n", folder, err)
+		}
+	}
+
+	// Tag metadata goes through the same checks as tag-set; fields the
+	// file leaves empty keep their current value
+	for tagName, meta := range data.TagMeta {
+		var update tag.InfoUpdate
+		if meta.Description != "" {
+			update.Description = &meta.Description
+		}
+		if meta.Color != "" {
+			if err := checkColor(meta.Color); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: ignoring color of tag '%s': %v\n", tagName, err)
+			} else {
+				update.Color = &meta.Color
+			}
+		}
+		if meta.Icon != "" {
+			update.Icon = &meta.Icon
+		}
+		if err := tag.SetTagInfo(tagName, update); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to import description, color and icon of tag '%s': %v\n", tagName, err)
+		}
+	}
+
+	// Sections this version cannot store yet are reported, not dropped silently
+	ignored := []struct {
+		section string
+		count   int
+	}{
+		{"groups", len(data.Groups)},
+		{"aliases", len(data.Aliases)},
+	}
+	for _, s := range ignored {
+		if s.count > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %d '%s' entries (not supported by this version)\n", s.count, s.section)
+		}
+	}
+
+	ui.Infof("Imported %d tag assignments (%d skipped)\n", imported, skipped)
+	if notes > 0 {
+		ui.Infof("Imported %d notes\n", notes)
+	}
+	return nil
+}
+
+func handleMigrate() error {
+	usage := fmt.Errorf("usage: scope migrate export [file] | apply <file> [--dry-run] [--no-clone] [--no-shell]")
+	if len(os.Args) < 3 {
+		return usage
+	}
+
+	switch os.Args[2] {
+	case "export":
+		if len(os.Args) > 4 {
+			return usage
+		}
+		return exportBundle(os.Args[3:])
+	case "apply":
+		var file string
+		dryRun, clone, shell := false, true, true
+		for _, arg := range os.Args[3:] {
+			switch arg {
+			case "--dry-run", "-n":
+				dryRun = true
+			case "--no-clone":
+				clone = false
+			case "--no-shell":
+				shell = false
+			default:
+				if strings.HasPrefix(arg, "-") || file != "" {
+					return usage
+				}
+				file = arg
+			}
+		}
+		if file == "" {
+			return usage
+		}
+		return applyBundle(file, dryRun, clone, shell)
+	default:
+		return usage
+	}
+}
+
+// exportBundle writes a migration bundle to the file in args, or stdout
+func exportBundle(args []string) error {
+	configPath, err := config.Path()
+	if err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	bundle, err := migrate.Build(tag.Default(), configPath, home)
+	if err != nil {
+		return err
+	}
+	output, err := migrate.Marshal(bundle)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		fmt.Print(string(output))
+		return nil
+	}
+	file, err := paths.Resolve(args[0])
+	if err != nil {
+		return err
+	}
+	// The config may hold webhook URLs and other private settings
+	if err := os.WriteFile(file, output, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	ui.Infof("Wrote %d tags and %d repositories to %s\n", len(bundle.Data.Tags), len(bundle.Repos), file)
+	return nil
+}
+
+// applyBundle recreates a migration bundle on this machine: the config,
+// the repositories that are missing, the tags and the shell integration
+func applyBundle(file string, dryRun, clone, shell bool) error {
+	path, err := paths.Expand(file)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	bundle, err := migrate.Parse(content)
+	if err != nil {
+		return err
+	}
+
+	// An existing config is never overwritten
+	if bundle.Config != "" {
+		configPath, err := config.Path()
+		if err != nil {
+			return err
+		}
+		switch _, err := os.Stat(configPath); {
+		case err == nil:
+			ui.Infof("Keeping the existing config at %s\n", configPath)
+		case dryRun:
+			fmt.Printf("[DRY-RUN] Would write the config to %s\n", configPath)
+		default:
+			if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+				return fmt.Errorf("failed to create config directory: %w", err)
+			}
+			if err := os.WriteFile(configPath, []byte(bundle.Config), 0644); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			ui.Infof("Wrote the config to %s\n", configPath)
+		}
+	}
+
+	if clone {
+		cloned, failed := 0, 0
+		for _, repo := range bundle.Repos {
+			dir, err := paths.Resolve(repo.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Skipping invalid path '%s': %v\n", repo.Path, err)
+				continue
+			}
+			if _, err := os.Stat(dir); err == nil {
+				continue
+			}
+			if dryRun {
+				fmt.Printf("[DRY-RUN] Would clone %s into %s\n", repo.Remote, dir)
+				continue
+			}
+			ui.Infof("Cloning %s into %s\n", repo.Remote, dir)
+			if err := migrate.Clone(repo, dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				failed++
+				continue
+			}
+			cloned++
+		}
+		if !dryRun {
+			ui.Infof("Cloned %d of %d missing repositories\n", cloned, cloned+failed)
+		}
+	}
+
+	if dryRun {
+		assignments := 0
+		for _, folders := range bundle.Data.Tags {
+			assignments += len(folders)
+		}
+		fmt.Printf("[DRY-RUN] Would import %d tags (%d tag assignments)\n", len(bundle.Data.Tags), assignments)
+		if shell {
+			fmt.Println("[DRY-RUN] Would add the shell integration to your shell's startup file")
+		}
+		return nil
+	}
+
+	if err := importData(bundle.Data); err != nil {
+		return err
+	}
+
+	if shell {
+		env, err := selfcheck.DefaultEnv()
+		if err != nil {
+			return err
+		}
+		rcFile, added, err := migrate.InstallShell(env)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if len(added) > 0 {
+			ui.Infof("Added %s to %s (open a new shell to load it)\n", strings.Join(added, " and "), rcFile)
+		}
+	}
+	return nil
+}
+
+func handleQuery() error {
+	usage := fmt.Errorf("usage: scope query [--json|--csv] <sql>")
+
+	format := "table"
+	var parts []string
+	for _, arg := range os.Args[2:] {
+		switch arg {
+		case "--json":
+			format = "json"
+		case "--csv":
+			format = "csv"
+		default:
+			parts = append(parts, arg)
+		}
+	}
+	if len(parts) == 0 {
+		return usage
+	}
+
+	// "-" reads the query from stdin, for ones too long to quote
+	stmt := strings.Join(parts, " ")
+	if stmt == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+		stmt = string(data)
+	}
+
+	result, err := query.Run(db.GetReadDB(), stmt)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		return query.WriteJSON(os.Stdout, result)
+	case "csv":
+		return query.WriteCSV(os.Stdout, result)
+	}
+	if err := query.WriteTable(os.Stdout, result); err != nil {
+		return err
+	}
+	ui.Infof("\n%d rows\n", len(result.Rows))
+	return nil
+}
+
+func handleDebug() error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	dbPath := filepath.Join(homeDir, ".config", "scope", "scope.db")
+
+	fmt.Println("Scope Debug Information")
+	fmt.Println("=======================")
+	fmt.Printf("Version:     %s\n", Version)
+	fmt.Printf("OS/Arch:     %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("Go version:  %s\n", runtime.Version())
+	fmt.Printf("Database:    %s\n", dbPath)
+	if configPath, err := config.Path(); err == nil {
+		fmt.Printf("Config:      %s\n", configPath)
+	}
+
+	// Check if db exists
+	if _, err := os.Stat(dbPath); err == nil {
+		info, _ := os.Stat(dbPath)
+		fmt.Printf("DB size:     %d bytes\n", info.Size())
+	} else {
+		fmt.Printf("DB size:     (not found)\n")
+	}
+
+	// Shell info
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "(unknown)"
+	}
+	fmt.Printf("Shell:       %s\n", shell)
+
+	// Scope session info
+	if current, ok := session.CurrentSession(); ok {
+		fmt.Printf("In session:  %s\n", current.Name)
+		fmt.Printf("Depth:       %d\n", current.Depth)
+		fmt.Printf("Workspace:   %s\n", current.Workspace)
+	}
+
+	// Stats
+	tags, _ := tag.ListTags()
+	totalFolders := 0
+	for _, count := range tags {
+		totalFolders += count
+	}
+	fmt.Printf("\nStats:\n")
+	fmt.Printf("  Tags:      %d\n", len(tags))
+	fmt.Printf("  Folders:   %d tag assignments\n", totalFolders)
+
+	return nil
+}
+
+func handleSelfcheck() error {
+	env, err := selfcheck.DefaultEnv()
+	if err != nil {
+		return err
+	}
+
+	results := selfcheck.Run(env)
+
+	fmt.Println("Scope Self-check")
+	fmt.Println("================")
+	for _, r := range results {
+		if r.OK {
+			fmt.Printf("%s %s", ui.Color("green", "✓"), r.Name)
+		} else {
+			fmt.Printf("%s %s", ui.Color("red", "✗"), r.Name)
+		}
+		if r.Detail != "" {
+			fmt.Printf(": %s", r.Detail)
+		}
+		fmt.Println()
+		if r.Fix != "" {
+			fmt.Printf("    Fix: %s\n", r.Fix)
+		}
+	}
+
+	failed := selfcheck.Failed(results)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+
+	fmt.Printf("\nAll %d checks passed\n", len(results))
+	return nil
+}
+
+// Path-emitting commands (go, pick, path) follow an output contract that shell
+// wrappers rely on:
+//   - on success, stdout holds exactly one path (for remote folders, the
+//     command that opens a shell there) terminated by a newline, or by a
+//     NUL byte with -0
+//   - prompts, listings, warnings and errors go to stderr; on failure
+//     nothing is written to stdout and the exit status is 1 (path tells
+//     no match and several matches apart with 2 and 3)
+//   - no color codes and no update notice, on either stream
+//
+// cmd/scope/contract_test.go enforces it.
+var pathCommands = map[string]bool{"go": true, "pick": true, "path": true}
+
+// Exit statuses of scope path besides 0 and 1
+const (
+	exitNoMatch   = 2
+	exitAmbiguous = 3
+)
+
+// splitNullFlag removes -0 / --null from args, reporting whether it was given
+func splitNullFlag(args []string) ([]string, bool) {
+	rest := make([]string, 0, len(args))
+	null := false
+	for _, arg := range args {
+		if arg == "-0" || arg == "--null" {
+			null = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, null
+}
+
+// splitIndexFlag removes --index n from args, returning n (1-based, as the
+// prompt numbers folders) or 0 when it wasn't given
+func splitIndexFlag(args []string) ([]string, int, error) {
+	rest := make([]string, 0, len(args))
+	index := 0
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--index" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, 0, fmt.Errorf("--index needs a folder number")
+		}
+		i++
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n < 1 {
+			return nil, 0, fmt.Errorf("invalid --index %q (expected a folder number from 1)", args[i])
+		}
+		index = n
+	}
+	return rest, index, nil
+}
+
+// pickIndex returns the folder chosen with --index
+func pickIndex(folders []string, index int) (int, error) {
+	if index > len(folders) {
+		return 0, fmt.Errorf("--index %d is out of range (%d folders)", index, len(folders))
+	}
+	return index - 1, nil
+}
+
+// defaultIndex returns 1, the first folder, where there is nobody to ask
+// (CI, dumb terminals), saying so on stderr; otherwise 0
+func defaultIndex(count int) int {
+	if ui.Mode() != ui.Default {
+		return 0
+	}
+	fmt.Fprintf(os.Stderr, "Using the first of %d folders (no terminal to ask in; choose with --index)\n", count)
+	return 1
+}
+
+// writePath prints the result of a path-emitting command
+func writePath(path string, null bool) {
+	if null {
+		fmt.Print(path + "\x00")
+		return
+	}
+	fmt.Println(path)
+}
+
+func handleGo() error {
+	args, null := splitNullFlag(os.Args[2:])
+	args, index, err := splitIndexFlag(args)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("usage: scope go <tag> [--index n] [-0]")
+	}
+
+	tagName := args[0]
+
+	folders, err := tag.SelectFolders(tagName)
+	if err != nil {
+		return err
+	}
+
+	// A mistyped or partial tag name is matched to an existing tag
+	if len(folders) == 0 && !tag.IsExpr(tagName) {
+		match, err := fuzzyTag(tagName)
+		if err != nil {
+			return err
+		}
+		if match != "" && match != tagName {
+			tagName = match
+			if folders, err = tag.SelectFolders(tagName); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	dirs, err := workDirs(folders)
+	if err != nil {
+		return err
+	}
+
+	if index == 0 && len(folders) > 1 {
+		index = defaultIndex(len(folders))
+	}
+	if index > 0 {
+		choice, err := pickIndex(folders, index)
+		if err != nil {
+			return err
+		}
+		writePath(goTarget(dirs[choice]), null)
+		return nil
+	}
+
+	// Single folder - just output the path
+	if len(folders) == 1 {
+		writePath(goTarget(dirs[0]), null)
+		return nil
+	}
+
+	if ui.NoInput() {
+		return fmt.Errorf("%d folders are tagged '%s' (choose one with --index): %w", len(folders), tagName, ui.ErrNoInput)
+	}
+
+	// Multiple folders - show picker
+	choice, err := picker.Choose(fmt.Sprintf("Multiple folders found for '%s':", tagName), folders)
+	if err != nil {
+		return err
+	}
+
+	writePath(goTarget(dirs[choice]), null)
+	return nil
+}
+
+// fuzzyTag returns the existing tag query most likely means, or "" if
+// none is close. When several are about as close, the user picks one.
+func fuzzyTag(query string) (string, error) {
+	matches, err := tag.FuzzyTags(query)
+	if err != nil {
+		return "", err
+	}
+	closest := tag.Closest(matches)
+	names := make([]string, len(closest))
+	for i, m := range closest {
+		names[i] = m.Tag
+	}
+
+	switch {
+	case len(names) == 0:
+		return "", nil
+	case len(names) == 1:
+		// stdout is for the path
+		fmt.Fprintf(os.Stderr, "No tag '%s'; using '%s'\n", query, names[0])
+		return names[0], nil
+	case ui.NoInput():
+		return "", fmt.Errorf("no tag '%s'; did you mean %s? %w", query, strings.Join(names, ", "), ui.ErrNoInput)
+	}
+
+	choice, err := picker.ChooseTag(fmt.Sprintf("No tag '%s'. Did you mean:", query), names)
+	if err != nil {
+		return "", err
+	}
+	return names[choice], nil
+}
+
+// goTarget is what 'scope go' prints for folder: its path, or for a remote
+// folder the ssh command that opens a shell in it
+func goTarget(folder string) string {
+	if loc, ok := location.Parse(folder); ok {
+		return loc.ShellCommand()
+	}
+	return folder
+}
+
+// handlePath prints the folder of a tag with a given name, for scripts:
+// cp config.yaml "$(scope path work api)/configs/". Unlike go it never
+// prompts or guesses; a name matching no folder, or several, fails.
+func handlePath() error {
+	args, null := splitNullFlag(os.Args[2:])
+	if len(args) != 2 {
+		return fmt.Errorf("usage: scope path <tag> <name> [-0]")
+	}
+	tagName, name := args[0], args[1]
+
+	folders, err := tag.SelectFolders(tagName)
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+	folders = slices.DeleteFunc(folders, location.IsRemote)
+
+	// A folder's own name wins over the name in its manifest
+	var matches []string
+	for _, folder := range folders {
+		if filepath.Base(folder) == name {
+			matches = append(matches, folder)
+		}
+	}
+	if len(matches) == 0 {
+		for _, folder := range folders {
+			if project.MatchesName(project.Name(folder), name) {
+				matches = append(matches, folder)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return &exitError{exitNoMatch, fmt.Errorf("no folder named '%s' has tag '%s'", name, tagName)}
+	case 1:
+	default:
+		return &exitError{exitAmbiguous, fmt.Errorf("several folders with tag '%s' are named '%s': %s", tagName, name, strings.Join(matches, ", "))}
+	}
+	if _, err := os.Stat(matches[0]); err != nil {
+		return fmt.Errorf("folder %s is missing (see scope doctor)", matches[0])
+	}
+
+	if null {
+		fmt.Print(matches[0] + "\x00")
+	} else {
+		fmt.Println(matches[0])
+	}
+	return nil
+}
+
+func handlePick() error {
+	var folders []string
+	var err error
+
+	args, null := splitNullFlag(os.Args[2:])
+	args, index, err := splitIndexFlag(args)
+	if err != nil {
+		return err
+	}
+
+	// If tag provided, filter by tag
+	if len(args) >= 1 {
+		tagName := args[0]
+		folders, err = tag.SelectFolders(tagName)
+		if err != nil {
+			return err
+		}
+		if len(folders) == 0 {
+			return fmt.Errorf("no folders found with tag '%s'", tagName)
+		}
+	} else {
+		// Get all folders from all tags
+		folders, err = tag.ListAllFolders()
+		if err != nil {
+			return err
+		}
+		if len(folders) == 0 {
+			return fmt.Errorf("no tagged folders found. Use 'scope tag <path> <tag>' to tag folders")
+		}
+	}
+
+	if index == 0 && len(folders) > 1 {
+		index = defaultIndex(len(folders))
+	}
+	if index > 0 {
+		choice, err := pickIndex(folders, index)
+		if err != nil {
+			return err
+		}
+		writePath(folders[choice], null)
+		return nil
+	}
+	if len(folders) == 1 {
+		writePath(folders[0], null)
+		return nil
+	}
+	selected, err := chooseFolder(folders, "choose one with --index")
+	if err != nil {
+		return err
+	}
+
+	// Output the selected path
+	writePath(selected, null)
+	return nil
+}
+
+// handleUI runs the dashboard, then does what was chosen in it: prints
+// the folder jumped to (which the shell integration cds to) or starts a
+// session
+func handleUI() error {
+	if len(os.Args) != 2 {
+		return fmt.Errorf("usage: scope ui")
+	}
+	if ui.NoInput() {
+		return fmt.Errorf("scope ui is interactive: %w", ui.ErrNoInput)
+	}
+	if ui.Accessible() {
+		return fmt.Errorf("scope ui needs a full-screen terminal; use scope list, go and start instead")
+	}
+
+	action, err := picker.Dashboard(tag.Default())
+	if err != nil {
+		return err
+	}
+
+	switch action.Kind {
+	case picker.ActionJump:
+		dirs, err := workDirs([]string{action.Folder})
+		if err != nil {
+			return err
+		}
+		writePath(goTarget(dirs[0]), false)
+		if !location.IsRemote(dirs[0]) {
+			if err := session.WriteExitDir(dirs[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to write exit directory: %v\n", err)
+			}
+		}
+	case picker.ActionStart:
+		os.Args = []string{os.Args[0], "start", action.Tag}
+		return handleStart()
+	}
+	return nil
+}
+
+// chooseFolder asks which of several folders to use with the picker,
+// which draws on stderr so stdout carries only the result. hint tells how
+// to choose without a terminal.
+func chooseFolder(folders []string, hint string) (string, error) {
+	if len(folders) == 1 {
+		return folders[0], nil
+	}
+	if ui.NoInput() {
+		return "", fmt.Errorf("%d folders to pick from (%s): %w", len(folders), hint, ui.ErrNoInput)
+	}
+	return picker.Pick(folders, picker.Options{
+		Preview: func(folder string) string {
+			return picker.Preview(tag.Default(), folder)
+		},
+		Badges: picker.TagBadges(tag.Default()),
+		Tags:   tag.Default(),
+	})
+}
+
+func handleOpen() error {
+	usage := fmt.Errorf("usage: scope open <tag> [--web] [--pick]\n       scope open --reveal <file>")
+	tagName, reveal := "", ""
+	web, pick := false, false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--web":
+			web = true
+		case arg == "--pick":
+			pick = true
+		case arg == "--reveal":
+			if i+1 >= len(args) || reveal != "" {
+				return usage
+			}
+			i++
+			reveal = args[i]
+		case strings.HasPrefix(arg, "-") || tagName != "":
+			return usage
+		default:
+			tagName = arg
+		}
+	}
+	if reveal != "" {
+		if tagName != "" || web || pick {
+			return usage
+		}
+		return revealFile(reveal)
+	}
+	if tagName == "" {
+		return usage
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+	if pick {
+		folder, err := chooseFolder(folders, "open them all without --pick")
+		if err != nil {
+			return err
+		}
+		folders = []string{folder}
+	}
+
+	if web {
+		openCmd, err := systemOpener()
+		if err != nil {
+			return err
+		}
+		repos := forgeRepos(folders)
+		if len(repos) == 0 {
+			ui.Infoln("No GitHub, GitLab or Bitbucket repositories found with this tag")
+			return nil
+		}
+		for _, r := range repos {
+			page := r.forge.WebURL(r.repo)
+			if err := exec.Command(openCmd, page).Start(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to open '%s': %v\n", page, err)
+				continue
+			}
+			ui.Infof("Opened: %s\n", page)
+		}
+		return nil
+	}
+
+	// Open each folder
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping remote folder '%s'\n", folder)
+			continue
+		}
+		if err := openFolder(folder); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to open '%s': %v\n", folder, err)
+			continue
+		}
+		ui.Infof("Opened: %s\n", folder)
+	}
+
+	return nil
+}
+
+// openFolder opens folder with the opener configured for this platform,
+// or else the system's file manager
+func openFolder(folder string) error {
+	args, err := cfg.Opener.Args(runtime.GOOS, folder)
+	if err != nil {
+		return err
+	}
+	if args == nil {
+		openCmd, err := systemOpener()
+		if err != nil {
+			return err
+		}
+		return exec.Command(openCmd, folder).Start()
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if !cfg.Opener.Terminal {
+		return cmd.Start()
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// revealFile opens the file manager at the folder of file, with file
+// selected where the file manager can do that
+func revealFile(file string) error {
+	path, err := paths.Resolve(file)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("cannot reveal %s: %w", file, err)
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-R", path)
+	case "windows":
+		// explorer takes /select,<path> as a single argument
+		cmd = exec.Command("explorer", "/select,"+path)
+	case "linux":
+		// File managers implementing the freedesktop FileManager1
+		// interface can select the file; the others just open its folder
+		uri := (&url.URL{Scheme: "file", Path: path}).String()
+		err := exec.Command("dbus-send", "--session", "--print-reply", "--dest=org.freedesktop.FileManager1",
+			"--type=method_call", "/org/freedesktop/FileManager1", "org.freedesktop.FileManager1.ShowItems",
+			"array:string:"+uri, "string:").Run()
+		if err == nil {
+			ui.Infof("Revealed: %s\n", path)
+			return nil
+		}
+		cmd = exec.Command("xdg-open", filepath.Dir(path))
+	default:
+		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to reveal '%s': %w", path, err)
+	}
+	ui.Infof("Revealed: %s\n", path)
+	return nil
+}
+
+// systemOpener returns the command that opens files and folders with the
+// desktop's default application
+func systemOpener() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open", nil
+	case "linux":
+		return "xdg-open", nil
+	case "windows":
+		return "explorer", nil
+	default:
+		return "", fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+}
+
+func handleGraph() error {
+	opts := graph.Options{Format: graph.DOT}
+	open := false
+	tagName := ""
+
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--format", "-f":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--format requires a value (dot or mermaid)")
+			}
+			i++
+			format, err := graph.ParseFormat(args[i])
+			if err != nil {
+				return err
+			}
+			opts.Format = format
+		case "--mermaid":
+			opts.Format = graph.Mermaid
+		case "--cooccurrence", "-c":
+			opts.CoOccurrence = true
+		case "--open", "-o":
+			open = true
+		default:
+			if strings.HasPrefix(arg, "-") || tagName != "" {
+				return fmt.Errorf("usage: scope graph [tag] [--format dot|mermaid] [--cooccurrence] [--open]")
+			}
+			tagName = arg
+		}
+	}
+
+	if open && opts.Format != graph.DOT {
+		return fmt.Errorf("--open renders with Graphviz and requires --format dot")
+	}
+
+	folderTags, err := tag.ListFolderTags()
+	if err != nil {
+		return err
+	}
+
+	// Restrict to the folders with the given tag
+	if tagName != "" {
+		for folder, tags := range folderTags {
+			found := false
+			for _, t := range tags {
+				if t == tagName {
+					found = true
+					break
+				}
+			}
+			if !found {
+				delete(folderTags, folder)
+			}
+		}
+		if len(folderTags) == 0 {
+			return fmt.Errorf("no folders found with tag '%s'", tagName)
+		}
+	}
+
+	output, err := graph.Render(folderTags, opts)
+	if err != nil {
+		return err
+	}
+
+	if !open {
+		fmt.Print(output)
+		return nil
+	}
+
+	dotPath, err := exec.LookPath("dot")
+	if err != nil {
+		return fmt.Errorf("--open needs Graphviz 'dot' on PATH (or pipe the DOT output elsewhere): %w", err)
+	}
+
+	svg, err := os.CreateTemp("", "scope-graph-*.svg")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	_ = svg.Close()
+
+	render := exec.Command(dotPath, "-Tsvg", "-o", svg.Name())
+	render.Stdin = strings.NewReader(output)
+	render.Stderr = os.Stderr
+	if err := render.Run(); err != nil {
+		return fmt.Errorf("dot failed: %w", err)
+	}
+
+	openCmd, err := systemOpener()
+	if err != nil {
+		return err
+	}
+	if err := exec.Command(openCmd, svg.Name()).Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", svg.Name(), err)
+	}
+
+	ui.Infof("Opened: %s\n", svg.Name())
+	return nil
+}
+
+func handleEdit() error {
+	usage := fmt.Errorf("usage: scope edit <tag> [--reuse-window]")
+	tagName, reuse := "", false
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--reuse-window" || arg == "-r":
+			reuse = true
+		case strings.HasPrefix(arg, "-") || tagName != "":
+			return usage
+		default:
+			tagName = arg
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	// Determine editor
+	editor := cfg.Editor.Command
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		// Try common editors
+		for _, e := range []string{"code", "vim", "nano"} {
+			if _, err := exec.LookPath(e); err == nil {
+				editor = e
+				break
+			}
+		}
+	}
+	if editor == "" {
+		return fmt.Errorf("no editor found. Set $EDITOR or $VISUAL environment variable")
+	}
+	if reuse && !isVSCode(editor) {
+		return fmt.Errorf("--reuse-window needs VS Code, not %s", editor)
+	}
+
+	dirs, err := workDirs(folders)
+	if err != nil {
+		return err
+	}
+
+	// Open each folder in editor
+	opened := 0
+	for _, folder := range dirs {
+		args, wait, err := cfg.Editor.Args(editor, folder)
+		if err != nil {
+			return fmt.Errorf("invalid editor %q: %w", editor, err)
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		if loc, ok := location.Parse(folder); ok {
+			cmd, err = loc.EditCommand(editor)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping '%s': %v\n", folder, err)
+				continue
+			}
+			wait = false
+		}
+		if reuse {
+			// The first folder replaces the window's, the others join it.
+			// VS Code's command hands off to the window and exits, so each
+			// is run to the end to keep them in order.
+			if opened == 0 {
+				cmd.Args = append(cmd.Args, "--reuse-window")
+			} else {
+				cmd.Args = append(cmd.Args, "--add")
+			}
+			wait = true
+		}
+
+		if wait {
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+			err = cmd.Run()
+		} else {
+			err = cmd.Start()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to open '%s' in %s: %v\n", folder, editor, err)
+			continue
+		}
+		opened++
+		ui.Infof("Opened in %s: %s\n", editor, folder)
+	}
+
+	return nil
+}
+
+// isVSCode reports whether the editor command starts VS Code or one of
+// the editors built on it, which share its command line
+func isVSCode(editor string) bool {
+	words := strings.Fields(editor)
+	if len(words) == 0 {
+		return false
+	}
+	switch strings.TrimSuffix(filepath.Base(words[0]), ".exe") {
+	case "code", "code-insiders", "codium", "cursor":
+		return true
+	}
+	return false
+}
+
+func handleEach() error {
+	usage := fmt.Errorf("usage: scope each <tag> [-p] [--jobs <n>] [--fail-fast] [--log-dir <dir>] <command>")
+	if len(os.Args) < 4 {
+		return usage
+	}
+
+	tagName := os.Args[2]
+	opts := eachOptions{jobs: 1}
+	parallel, jobsSet := false, false
+
+	// Flags come before the command; everything from the first other
+	// argument on is the command
+	cmdStart := 3
+flags:
+	for ; cmdStart < len(os.Args); cmdStart++ {
+		arg := os.Args[cmdStart]
+		switch {
+		case arg == "-p" || arg == "--parallel":
+			parallel = true
+		case arg == "--fail-fast":
+			opts.failFast = true
+		case arg == "--log-dir" || strings.HasPrefix(arg, "--log-dir="):
+			value, ok := strings.CutPrefix(arg, "--log-dir=")
+			if !ok {
+				if cmdStart+1 >= len(os.Args) {
+					return usage
+				}
+				cmdStart++
+				value = os.Args[cmdStart]
+			}
+			dir, err := paths.Resolve(value)
+			if err != nil {
+				return err
+			}
+			opts.logDir = dir
+		case arg == "--jobs" || arg == "-j" || strings.HasPrefix(arg, "--jobs="):
+			value, ok := strings.CutPrefix(arg, "--jobs=")
+			if !ok {
+				if cmdStart+1 >= len(os.Args) {
+					return usage
+				}
+				cmdStart++
+				value = os.Args[cmdStart]
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of jobs: %s", value)
+			}
+			opts.jobs, jobsSet = n, true
+		case arg == "--":
+			cmdStart++
+			break flags
+		default:
+			break flags
+		}
+	}
+	if cmdStart >= len(os.Args) {
+		return usage
+	}
+	// -p alone runs every folder at once
+	if parallel && !jobsSet {
+		opts.jobs = 0
+	}
+
+	// Join remaining args as command
+	command := strings.Join(os.Args[cmdStart:], " ")
+
+	folders, err := tag.SelectFolders(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	if opts.subdirs, err = tag.ListSubdirs(); err != nil {
+		return err
+	}
+	opts.tag = tagName
+	return runEach(folders, command, opts)
+}
+
+// eachOptions control how 'scope each' runs its command
+type eachOptions struct {
+	// jobs is how many folders run at once: 1 runs them one after the
+	// other with their output streamed, 0 runs them all at once
+	jobs int
+	// failFast stops at the first failure: running commands are killed and
+	// folders not started yet are skipped
+	failFast bool
+	// tag is the tag (or expression) the folders were selected by, for
+	// SCOPE_TAG
+	tag string
+	// subdirs are the folders' working subdirectories, which the command
+	// runs in
+	subdirs map[string]string
+	// logDir keeps each folder's output and a summary.json, in a
+	// directory per run
+	logDir string
+}
+
+// eachCommand returns the command 'scope each' runs in folder, over ssh
+// for remote folders, with env added to its environment
+func eachCommand(sh, folder, command string, env []string) *exec.Cmd {
+	if loc, ok := location.Parse(folder); ok {
+		// The environment doesn't travel over ssh; set it in the remote
+		// shell instead
+		if len(env) > 0 {
+			exports := make([]string, len(env))
+			for i, kv := range env {
+				k, v, _ := strings.Cut(kv, "=")
+				exports[i] = k + "=" + shell.Quote(v)
+			}
+			command = "export " + strings.Join(exports, " ") + "; " + command
+		}
+		return loc.Command(command)
+	}
+	cmd := exec.Command(sh, "-c", command)
+	cmd.Dir = folder
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+// eachEnv returns the variables describing the index-th (from 1) tagged
+// folder to the command run in it. A remote folder is its path on the host.
+func eachEnv(folder string, index int, tagName string) []string {
+	if loc, ok := location.Parse(folder); ok {
+		folder = loc.Path
+	}
+	return []string{
+		"SCOPE_FOLDER=" + folder,
+		"SCOPE_FOLDER_NAME=" + filepath.Base(folder),
+		"SCOPE_TAG=" + tagName,
+		"SCOPE_INDEX=" + strconv.Itoa(index),
+	}
+}
+
+// eachResult is how the command went in one folder
+type eachResult struct {
+	dir      string
+	ran      bool
+	started  time.Time
+	duration time.Duration
+	err      error
+	// cancelled is set when --fail-fast killed the command
+	cancelled bool
+	// stdout and stderr name the log files, with --log-dir
+	stdout, stderr string
+}
+
+// runEach runs command in each tagged folder (or its working subdirectory)
+// and prints a summary. It fails when the command failed in any folder.
+func runEach(folders []string, command string, opts eachOptions) error {
+	var log *eachLog
+	if opts.logDir != "" {
+		var err error
+		if log, err = newEachLog(opts.logDir, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	started := time.Now()
+	var results []eachResult
+	if opts.jobs == 1 {
+		results = runEachSequential(folders, command, opts, log)
+	} else {
+		results = runEachParallel(folders, command, opts, log)
+	}
+
+	var succeeded, failed int
+	for _, r := range results {
+		switch {
+		case !r.ran || r.cancelled:
+		case r.err != nil:
+			failed++
+		default:
+			succeeded++
+		}
+	}
+	summary := fmt.Sprintf("%d succeeded, %d failed", succeeded, failed)
+	if skipped := len(folders) - succeeded - failed; skipped > 0 {
+		summary += fmt.Sprintf(", %d skipped", skipped)
+	}
+	ui.Infof("\n%s %s\n", ui.Color("bold", "Summary:"), summary)
+
+	if log != nil {
+		if err := log.writeSummary(command, started, folders, results, opts); err != nil {
+			return err
+		}
+		ui.Infof("Logs: %s\n", log.dir)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("command failed in %d of %d folders", failed, len(folders))
+	}
+	return nil
+}
+
+// command returns the command run in the index-th (from 1) folder and the
+// directory it runs in
+func (o eachOptions) command(sh, folder string, index int, command string) (*exec.Cmd, string) {
+	dir := workDir(folder, o.subdirs[folder])
+	return eachCommand(sh, dir, command, eachEnv(folder, index, o.tag)), dir
+}
+
+// eachShell returns the shell commands run in
+func eachShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// printEachHeader prints the heading of a folder's output
+func printEachHeader(folder string) {
+	fmt.Printf("\n%s %s\n", ui.Color("blue", "["+filepath.Base(folder)+"]"), folder)
+	fmt.Println(strings.Repeat("-", 40))
+}
+
+// printEachError reports how the command failed in a folder
+func printEachError(r eachResult) {
+	switch {
+	case r.cancelled:
+		fmt.Fprintf(os.Stderr, "%s stopped after another folder failed\n", ui.Color("yellow", "Cancelled:"))
+	case r.err != nil:
+		fmt.Fprintf(os.Stderr, "%s %v\n", ui.Color("red", "Error:"), r.err)
+	}
+}
+
+func runEachSequential(folders []string, command string, opts eachOptions, log *eachLog) []eachResult {
+	sh := eachShell()
+	results := make([]eachResult, len(folders))
+	for i, folder := range folders {
+		cmd, dir := opts.command(sh, folder, i+1, command)
+		printEachHeader(dir)
+
+		r := &results[i]
+		r.dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		closeLogs, err := log.attach(cmd, i+1, dir, r)
+		if err != nil {
+			r.ran, r.err = true, err
+		} else {
+			r.ran, r.started = true, time.Now()
+			r.err = cmd.Run()
+			r.duration = time.Since(r.started)
+			closeLogs()
+		}
+
+		printEachError(*r)
+		if r.err != nil && opts.failFast {
+			break
+		}
+	}
+	return results
+}
+
+func runEachParallel(folders []string, command string, opts eachOptions, log *eachLog) []eachResult {
+	sh := eachShell()
+
+	jobs := opts.jobs
+	if jobs <= 0 || jobs > len(folders) {
+		jobs = len(folders)
+	}
+
+	// Closed on the first failure with --fail-fast
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+
+	type output struct {
+		index int
+		text  string
+	}
+	results := make([]eachResult, len(folders))
+	done := make(chan output, len(folders))
+	slots := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i, folder := range folders {
+		wg.Add(1)
+		go func(index int, f string) {
+			defer wg.Done()
+
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-stop:
+				return
+			}
+			// Stopped while waiting for a slot
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd, dir := opts.command(sh, f, index+1, command)
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			// Killing the shell can leave its children holding the
+			// output open
+			cmd.WaitDelay = time.Second
+
+			// Each goroutine has its own result, so no locking is needed
+			r := &results[index]
+			r.dir, r.ran = dir, true
+			closeLogs, err := log.attach(cmd, index+1, dir, r)
+			if err != nil {
+				r.err = err
+			} else {
+				r.started = time.Now()
+				r.cancelled, r.err = runUntil(cmd, stop)
+				r.duration = time.Since(r.started)
+				closeLogs()
+			}
+			if r.err != nil && !r.cancelled && opts.failFast {
+				stopOnce.Do(func() { close(stop) })
+			}
+
+			text := stdout.String()
+			if stderr.Len() > 0 {
+				text += stderr.String()
+			}
+			done <- output{index: index, text: text}
+		}(i, folder)
+	}
+
+	// Close the channel when all goroutines complete
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Print each folder's output as it finishes
+	for out := range done {
+		r := results[out.index]
+		printEachHeader(r.dir)
+		if out.text != "" {
+			fmt.Print(out.text)
+		}
+		printEachError(r)
+	}
+	return results
+}
+
+// runUntil runs cmd, killing it if stop is closed first. It reports
+// whether cmd was killed.
+func runUntil(cmd *exec.Cmd, stop <-chan struct{}) (bool, error) {
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	done := make(chan struct{})
+	killed := make(chan bool, 1)
+	go func() {
+		select {
+		case <-stop:
+			_ = cmd.Process.Kill()
+			killed <- true
+		case <-done:
+			killed <- false
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	return <-killed && err != nil, err
+}
+
+// eachLog is the directory of a 'scope each --log-dir' run: a stdout and a
+// stderr file per folder, and summary.json
+type eachLog struct {
+	dir string
+}
+
+// newEachLog creates the directory of a run started at now under root,
+// named after the time
+func newEachLog(root string, now time.Time) (*eachLog, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	base := filepath.Join(root, now.Format("20060102-150405"))
+	dir := base
+	// Runs started in the same second get directories of their own
+	for n := 2; ; n++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			return &eachLog{dir: dir}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		dir = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// attach copies the output of the index-th folder's command into its log
+// files, recording their names in r. The returned function closes them
+// once the command is done. A nil log leaves cmd alone.
+func (l *eachLog) attach(cmd *exec.Cmd, index int, dir string, r *eachResult) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	// The index keeps folders with the same name apart
+	name := fmt.Sprintf("%02d-%s", index, strings.ReplaceAll(filepath.Base(dir), string(filepath.Separator), "_"))
+	stdout, err := os.Create(filepath.Join(l.dir, name+".stdout.log"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+	stderr, err := os.Create(filepath.Join(l.dir, name+".stderr.log"))
+	if err != nil {
+		_ = stdout.Close()
+		return nil, fmt.Errorf("failed to create log file: %w", err)
+	}
+	r.stdout, r.stderr = filepath.Base(stdout.Name()), filepath.Base(stderr.Name())
+	cmd.Stdout = io.MultiWriter(cmd.Stdout, stdout)
+	cmd.Stderr = io.MultiWriter(cmd.Stderr, stderr)
+	return func() {
+		_ = stdout.Close()
+		_ = stderr.Close()
+	}, nil
+}
+
+// eachSummary is the summary.json of a logged run
+type eachSummary struct {
+	Tag             string              `json:"tag"`
+	Command         string              `json:"command"`
+	Started         time.Time           `json:"started"`
+	DurationSeconds float64             `json:"duration_seconds"`
+	Folders         []eachFolderSummary `json:"folders"`
+}
+
+// eachFolderSummary is how the command went in one folder. Status is
+// succeeded, failed, cancelled (killed by --fail-fast) or skipped (not
+// run); the exit code is null unless the command exited by itself.
+type eachFolderSummary struct {
+	Index           int        `json:"index"`
+	Folder          string     `json:"folder"`
+	Dir             string     `json:"dir"`
+	Status          string     `json:"status"`
+	ExitCode        *int       `json:"exit_code"`
+	Error           string     `json:"error,omitempty"`
+	Started         *time.Time `json:"started,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	Stdout          string     `json:"stdout,omitempty"`
+	Stderr          string     `json:"stderr,omitempty"`
+}
+
+// writeSummary writes summary.json for the run
+func (l *eachLog) writeSummary(command string, started time.Time, folders []string, results []eachResult, opts eachOptions) error {
+	summary := eachSummary{
+		Tag:             opts.tag,
+		Command:         command,
+		Started:         started,
+		DurationSeconds: time.Since(started).Seconds(),
+		Folders:         make([]eachFolderSummary, len(folders)),
+	}
+	for i, r := range results {
+		f := eachFolderSummary{Index: i + 1, Folder: folders[i], Dir: r.dir, Stdout: r.stdout, Stderr: r.stderr}
+		switch {
+		case !r.ran:
+			f.Status = "skipped"
+		case r.cancelled:
+			f.Status = "cancelled"
+		case r.err != nil:
+			f.Status = "failed"
+		default:
+			f.Status = "succeeded"
+		}
+		if r.ran {
+			started := r.started
+			f.Started = &started
+			f.DurationSeconds = r.duration.Seconds()
+		}
+		var exitErr *exec.ExitError
+		switch {
+		case r.err == nil && r.ran:
+			code := 0
+			f.ExitCode = &code
+		case errors.As(r.err, &exitErr) && exitErr.ExitCode() >= 0 && !r.cancelled:
+			code := exitErr.ExitCode()
+			f.ExitCode = &code
+		}
+		if r.err != nil {
+			f.Error = r.err.Error()
+		}
+		if !r.ran {
+			f.Dir = workDir(folders[i], opts.subdirs[folders[i]])
+		}
+		summary.Folders[i] = f
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	// Commands are full of < > &
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(summary); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(l.dir, "summary.json"), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+func handleDeps() error {
+	usage := fmt.Errorf("usage: scope deps <tag> [--update] [--branch <name>]")
+
+	tagName, branch := "", ""
+	update := false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--update", "-u":
+			update = true
+		case "--branch":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			branch = args[i]
+		default:
+			if strings.HasPrefix(args[i], "-") || tagName != "" {
+				return usage
+			}
+			tagName = args[i]
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+	if branch != "" && !update {
+		return fmt.Errorf("--branch only applies with --update")
+	}
+	if update {
+		if err := network.Check("scope deps --update"); err != nil {
+			return err
+		}
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	dirs, err := workDirs(folders)
+	if err != nil {
+		return err
+	}
+
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	type outcome struct {
+		folder string
+		result string
+		err    error
+	}
+	var outcomes []outcome
+	for _, dir := range dirs {
+		if location.IsRemote(dir) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s\n", dir)
+			continue
+		}
+		updaters := project.Updaters(dir)
+		if len(updaters) == 0 {
+			continue
+		}
+
+		if !update {
+			fmt.Printf("%s %s\n", ui.Color("blue", "["+filepath.Base(dir)+"]"), dir)
+			for _, u := range updaters {
+				fmt.Printf("  %-8s %s\n", u.Toolchain, u.Command)
+			}
+			continue
+		}
+
+		fmt.Printf("\n%s %s\n", ui.Color("blue", "["+filepath.Base(dir)+"]"), dir)
+		fmt.Println(strings.Repeat("-", 40))
+		result, err := updateDeps(shell, dir, updaters, branch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", ui.Color("red", "Error:"), err)
+		}
+		outcomes = append(outcomes, outcome{folder: dir, result: result, err: err})
+	}
+
+	if !update {
+		ui.Infoln("\nRun with --update to update these dependencies")
+		return nil
+	}
+	if len(outcomes) == 0 {
+		ui.Infoln("No folders with a known dependency updater")
+		return nil
+	}
+
+	ui.Infof("\n%s\n", ui.Color("bold", "Summary:"))
+	for _, o := range outcomes {
+		if o.err != nil {
+			ui.Infof("  %-24s %s: %v\n", filepath.Base(o.folder), ui.Color("red", "failed"), o.err)
+			continue
+		}
+		ui.Infof("  %-24s %s\n", filepath.Base(o.folder), o.result)
+	}
+	return nil
+}
+
+// updateDeps runs updaters in dir, the way 'scope each' runs commands, and
+// describes what changed. With a branch, the update is made on a new
+// branch and committed there; a branch left with no changes is deleted.
+func updateDeps(shell, dir string, updaters []project.Updater, branch string) (string, error) {
+	root, inRepo := project.RepoRoot(dir)
+	if branch != "" && !inRepo {
+		return "", fmt.Errorf("--branch needs a git repository")
+	}
+
+	var before []string
+	var previous string
+	if inRepo {
+		var err error
+		if before, err = git.Changes(root); err != nil {
+			return "", err
+		}
+	}
+	if branch != "" {
+		if len(before) > 0 {
+			return "", fmt.Errorf("uncommitted changes in %s", root)
+		}
+		var err error
+		if previous, err = git.Branch(root); err != nil {
+			return "", err
+		}
+		if err := git.CreateBranch(root, branch); err != nil {
+			return "", err
+		}
+	}
+
+	for _, u := range updaters {
+		fmt.Printf("$ %s\n", u.Command)
+		cmd := eachCommand(shell, dir, u.Command, nil)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s: %w", u.Command, err)
+		}
+	}
+
+	if !inRepo {
+		return "updated", nil
+	}
+	after, err := git.Changes(root)
+	if err != nil {
+		return "", err
+	}
+	changed := 0
+	for _, p := range after {
+		if !slices.Contains(before, p) {
+			changed++
+		}
+	}
+
+	if branch == "" {
+		if changed == 0 {
+			return "no changes", nil
+		}
+		return fmt.Sprintf("%d files changed", changed), nil
+	}
+	if changed == 0 {
+		if err := git.SwitchBranch(root, previous); err != nil {
+			return "", err
+		}
+		return "no changes", git.DeleteBranch(root, branch)
+	}
+	if err := git.CommitAll(root, "Update dependencies"); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d files changed, committed on %s", changed, branch), nil
+}
+
+func handleStatus() error {
+	usage := fmt.Errorf("usage: scope status <tag> [--fetch] [--json]")
+	tagName := ""
+	fetch, asJSON := false, false
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--fetch":
+			fetch = true
+		case arg == "--json":
+			asJSON = true
+		case strings.HasPrefix(arg, "-") || tagName != "":
+			return usage
+		default:
+			tagName = arg
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+	if fetch {
+		if err := network.Check("scope status --fetch"); err != nil {
+			return err
+		}
+	}
+
+	folders, err := tag.SelectFolders(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	repos := localRepos(folders)
+
+	// Fetching is slow enough to be worth doing in parallel
+	type result struct {
+		status   *git.Status
+		err      error
+		fetchErr error
+	}
+	results := make([]result, len(repos))
+	slots := make(chan struct{}, 8)
+	var wg sync.WaitGroup
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if fetch {
+				results[i].fetchErr = git.Fetch(repo)
+			}
+			results[i].status, results[i].err = git.GetStatus(repo)
+		}(i, repo)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r.fetchErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", r.fetchErr)
+		}
+		if r.err != nil && !asJSON {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", repos[i], r.err)
+		}
+	}
+
+	if asJSON {
+		type repoStatus struct {
+			Folder   string `json:"folder"`
+			Name     string `json:"name"`
+			Branch   string `json:"branch,omitempty"`
+			Upstream string `json:"upstream,omitempty"`
+			Ahead    int    `json:"ahead"`
+			Behind   int    `json:"behind"`
+			Dirty    int    `json:"dirty"`
+			Stashes  int    `json:"stashes"`
+			Error    string `json:"error,omitempty"`
+		}
+		statuses := make([]repoStatus, len(repos))
+		for i, repo := range repos {
+			statuses[i] = repoStatus{Folder: repo, Name: filepath.Base(repo)}
+			if st := results[i].status; st != nil {
+				statuses[i].Branch, statuses[i].Upstream = st.Branch, st.Upstream
+				statuses[i].Ahead, statuses[i].Behind = st.Ahead, st.Behind
+				statuses[i].Dirty, statuses[i].Stashes = st.Dirty, st.Stashes
+			} else {
+				statuses[i].Error = results[i].err.Error()
+			}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+
+	if len(repos) == 0 {
+		ui.Infoln("No git repositories found with this tag")
+		return nil
+	}
+
+	nameWidth, branchWidth, stashWidth := 0, 0, 0
+	for i, repo := range repos {
+		nameWidth = max(nameWidth, len(filepath.Base(repo)))
+		if st := results[i].status; st != nil {
+			branchWidth = max(branchWidth, len(statusBranch(st)))
+			stashWidth = max(stashWidth, len(stashCount(st.Stashes)))
+		}
+	}
+
+	dirty, behind := 0, 0
+	for i, repo := range repos {
+		name := ui.Color("bold", fmt.Sprintf("%-*s", nameWidth, filepath.Base(repo)))
+		st := results[i].status
+		if st == nil {
+			fmt.Printf("%s  %s  %s\n", name, ui.Color("red", "git status failed"), repo)
+			continue
+		}
+		if st.Dirty > 0 {
+			dirty++
+		}
+		if st.Behind > 0 {
+			behind++
+		}
+
+		var upstream string
+		switch {
+		case st.Upstream == "":
+			upstream = ui.Color("white", padRight("no upstream", 11))
+		case st.Ahead == 0 && st.Behind == 0:
+			upstream = ui.Color("green", padRight("up to date", 11))
+		case st.Behind > 0:
+			upstream = ui.Color("yellow", padRight(aheadBehind(st), 11))
+		default:
+			upstream = ui.Color("blue", padRight(aheadBehind(st), 11))
+		}
+		changes := ui.Color("green", fmt.Sprintf("%-10s", "clean"))
+		if st.Dirty > 0 {
+			changes = ui.Color("yellow", fmt.Sprintf("%-10s", fmt.Sprintf("%d changed", st.Dirty)))
+		}
+		stashes := ""
+		if stashWidth > 0 {
+			stashes = fmt.Sprintf("%-*s  ", stashWidth, stashCount(st.Stashes))
+		}
+		fmt.Printf("%s  %-*s  %s  %s  %s%s\n", name, branchWidth, statusBranch(st), upstream, changes, stashes, repo)
+	}
+
+	ui.Infof("\n%d repositories, %d with changes, %d behind upstream\n", len(repos), dirty, behind)
+	return nil
+}
+
+// statusBranch returns the branch scope status shows for a repository
+func statusBranch(st *git.Status) string {
+	if st.Branch == "" {
+		return "(detached)"
+	}
+	return st.Branch
+}
+
+// stashCount describes a number of stash entries, or nothing for none
+func stashCount(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "1 stash"
+	}
+	return fmt.Sprintf("%d stashes", n)
+}
+
+// padRight pads s with spaces to width characters, which fmt does in bytes
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(s)))
+}
+
+// aheadBehind formats the commits a branch is ahead of and behind its
+// upstream as ↑2 ↓1
+func aheadBehind(st *git.Status) string {
+	var parts []string
+	if st.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", st.Ahead))
+	}
+	if st.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", st.Behind))
+	}
+	return strings.Join(parts, " ")
+}
+
+// localRepos returns the folders that are local git repositories
+func localRepos(folders []string) []string {
+	var repos []string
+	for _, folder := range folders {
+		if !location.IsRemote(folder) && git.IsRepo(folder) {
+			repos = append(repos, folder)
+		}
+	}
+	return repos
+}
+
+func handleBranch() error {
+	usage := fmt.Errorf("usage: scope branch <tag> [name]\n       scope branch <tag> --create|--checkout|--delete <name> [--force]")
+	var positional []string
+	op, branch, force := "", "", false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--create", "--checkout", "--delete":
+			if op != "" || i+1 >= len(args) {
+				return usage
+			}
+			op = strings.TrimPrefix(args[i], "--")
+			i++
+			branch = args[i]
+		case "--force", "-f":
+			force = true
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				return usage
+			}
+			positional = append(positional, args[i])
+		}
+	}
+	if len(positional) == 0 || len(positional) > 2 || (op != "" && len(positional) > 1) {
+		return usage
+	}
+	if force && op != "delete" {
+		return fmt.Errorf("--force only applies to --delete")
+	}
+	tagName := positional[0]
+
+	folders, err := tag.SelectFolders(tagName)
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+	repos := localRepos(folders)
+	if len(repos) == 0 {
+		ui.Infoln("No git repositories found with this tag")
+		return nil
+	}
+	nameWidth := 0
+	for _, repo := range repos {
+		nameWidth = max(nameWidth, len(filepath.Base(repo)))
+	}
+
+	if op == "" {
+		if len(positional) == 2 {
+			return listBranch(repos, positional[1], nameWidth)
+		}
+		for _, repo := range repos {
+			current, err := git.Branch(repo)
+			if err != nil {
+				current = ui.Color("red", err.Error())
+			}
+			fmt.Printf("%s  %s  %s\n", ui.Color("bold", fmt.Sprintf("%-*s", nameWidth, filepath.Base(repo))), current, repo)
+		}
+		return nil
+	}
+
+	var verb string
+	var apply func(dir, name string) error
+	switch op {
+	case "create":
+		verb, apply = "created", git.CreateBranch
+	case "checkout":
+		verb, apply = "checked out", git.SwitchBranch
+	case "delete":
+		verb, apply = "deleted", git.DeleteMergedBranch
+		if force {
+			apply = git.DeleteBranch
+		}
+	}
+
+	failed := 0
+	for _, repo := range repos {
+		name := fmt.Sprintf("%-*s", nameWidth, filepath.Base(repo))
+		if err := apply(repo, branch); err != nil {
+			failed++
+			// git's message is the last line; the rest are hints
+			lines := strings.Split(strings.TrimSpace(err.Error()), "\n")
+			fmt.Printf("%s %s  %s\n", ui.Color("red", "✗"), name, strings.TrimSpace(lines[0]))
+			continue
+		}
+		fmt.Printf("%s %s  %s %s\n", ui.Color("green", "✓"), name, verb, branch)
+	}
+
+	ui.Infof("\nSummary: %d succeeded, %d failed\n", len(repos)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%s %s failed in %d of %d repositories", op, branch, failed, len(repos))
+	}
+	return nil
+}
+
+// listBranch shows which repositories have a branch, and which are on it
+func listBranch(repos []string, branch string, nameWidth int) error {
+	have := 0
+	for _, repo := range repos {
+		name := fmt.Sprintf("%-*s", nameWidth, filepath.Base(repo))
+		current, err := git.Branch(repo)
+		if err != nil {
+			return err
+		}
+		branches, err := git.Branches(repo)
+		if err != nil {
+			return err
+		}
+		switch {
+		case current == branch:
+			have++
+			fmt.Printf("%s %s  on %s\n", ui.Color("green", "✓"), name, branch)
+		case slices.Contains(branches, branch):
+			have++
+			fmt.Printf("%s %s  has %s (on %s)\n", ui.Color("yellow", "●"), name, branch, current)
+		default:
+			fmt.Printf("%s %s  no %s (on %s)\n", ui.Color("white", "-"), name, branch, current)
+		}
+	}
+	ui.Infof("\n%d of %d repositories have %s\n", have, len(repos), branch)
+	return nil
+}
+
+func handlePull() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope pull <tag>")
+	}
+	if err := network.Check("scope pull"); err != nil {
+		return err
+	}
+
+	tagName := os.Args[2]
+
+	folders, err := tag.SelectFolders(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	// Filter to git repos only
+	var gitFolders []string
+	for _, folder := range folders {
+		gitDir := filepath.Join(folder, ".git")
+		if _, err := os.Stat(gitDir); err == nil {
+			gitFolders = append(gitFolders, folder)
+		}
+	}
+
+	if len(gitFolders) == 0 {
+		ui.Infoln("No git repositories found with this tag")
+		return nil
+	}
+
+	ui.Infof("Pulling %d repositories...\n", len(gitFolders))
+	return runEach(gitFolders, "git pull", eachOptions{tag: tagName})
+}
+
+func handleSecrets() error {
+	if len(os.Args) != 3 || strings.HasPrefix(os.Args[2], "-") {
+		return fmt.Errorf("usage: scope secrets <tag>")
+	}
+
+	tagName := os.Args[2]
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	// Scan folders in parallel, keeping results in folder order
+	results := make([]*secrets.Result, len(folders))
+	errs := make([]error, len(folders))
+	var wg sync.WaitGroup
+	for i, folder := range folders {
+		if location.IsRemote(folder) {
+			errs[i] = fmt.Errorf("skipping remote folder %s", folder)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, folder string) {
+			defer wg.Done()
+			results[i], errs[i] = secrets.Scan(folder)
+		}(i, folder)
+	}
+	wg.Wait()
+
+	var scanned []*secrets.Result
+	files, found, foldersWithFindings := 0, 0, 0
+	for i, r := range results {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", errs[i])
+			continue
+		}
+		scanned = append(scanned, r)
+		files += r.Files
+		if len(r.Findings) == 0 {
+			continue
+		}
+
+		found += len(r.Findings)
+		foldersWithFindings++
+		fmt.Printf("%s %s\n", ui.Color("red", "["+filepath.Base(r.Folder)+"]"), r.Folder)
+		for _, f := range r.Findings {
+			fmt.Printf("  %s:%d  %s  %s\n", f.Path, f.Line, f.Rule, f.Match)
+		}
+		fmt.Println()
+	}
+
+	if found == 0 {
+		fmt.Printf("No secrets found in %d folders (%d files)\n", len(scanned), files)
+		return nil
+	}
+
+	fmt.Printf("%d possible secrets in %d of %d folders (%d files scanned):\n", found, foldersWithFindings, len(scanned), files)
+	for _, c := range secrets.Counts(scanned) {
+		fmt.Printf("  %-16s %d\n", c.Rule, c.Count)
+	}
+	return fmt.Errorf("found %d possible secrets", found)
+}
+
+func handleAudit() error {
+	usage := fmt.Errorf("usage: scope audit <tag> [--csv]")
+
+	tagName := ""
+	asCSV := false
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--csv":
+			asCSV = true
+		case strings.HasPrefix(arg, "-") || tagName != "":
+			return usage
+		default:
+			tagName = arg
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	var entries []audit.Entry
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s\n", folder)
+			continue
+		}
+		entries = append(entries, audit.Inspect(folder))
+	}
+
+	if asCSV {
+		return audit.WriteCSV(os.Stdout, entries)
+	}
+
+	flagged := 0
+	for _, e := range entries {
+		license := e.License.ID
+		if license == "" {
+			license = "-"
+		}
+		line := fmt.Sprintf("%-20s %-16s %-30s %4d deps", filepath.Base(e.Path), license, e.Source(), e.DependencyCount())
+		if flag := e.Flag(); flag != "" {
+			flagged++
+			color := "red"
+			if flag == audit.FlagCopyleft {
+				color = "yellow"
+			}
+			line += "  " + ui.Color(color, flag)
+		}
+		fmt.Println(line)
+	}
+
+	ui.Infoln("\nLicenses:")
+	for _, c := range audit.Count(entries) {
+		ui.Infof("  %-20s %d\n", c.License, c.Count)
+	}
+	ui.Infof("\n%d of %d folders flagged\n", flagged, len(entries))
+	return nil
+}
+
+// forgeRepo is a tagged folder's repository on a forge
+type forgeRepo struct {
+	root   string
+	repo   forge.Repo
+	forge  forge.Forge
+	branch string
+}
+
+// forgeRepos returns the repositories of folders on GitHub, GitLab or
+// Bitbucket, once each. Folders that aren't in a repository whose origin
+// is on a known forge are skipped.
+func forgeRepos(folders []string) []forgeRepo {
+	forges := cfg.Forges.Registry()
+	var repos []forgeRepo
+	seen := make(map[string]bool)
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		root, ok := project.RepoRoot(folder)
+		if !ok || seen[root] {
+			continue
+		}
+		seen[root] = true
+		remote, err := git.RemoteURL(root, "origin")
+		if err != nil {
+			continue
+		}
+		repo, ok := forge.ParseRemote(remote)
+		if !ok {
+			continue
+		}
+		if f, ok := forges.For(repo); ok {
+			repos = append(repos, forgeRepo{root: root, repo: repo, forge: f, branch: git.RemoteHead(root, "origin")})
+		}
+	}
+	return repos
+}
+
+// warnUnauthenticated warns once for each forge of repos that scope has
+// no token for. Offline, it says instead that results come from the cache.
+func warnUnauthenticated(repos []forgeRepo) {
+	if network.Offline() {
+		fmt.Fprintln(os.Stderr, "Warning: offline; showing cached results, repositories without one fail")
+		return
+	}
+	warned := make(map[forge.Kind]bool)
+	for _, r := range repos {
+		kind := r.forge.Kind()
+		if r.forge.Authenticated() || warned[kind] {
+			continue
+		}
+		warned[kind] = true
+		fmt.Fprintf(os.Stderr, "Warning: no %s token (%s); private repositories will fail\n", kind.Name(), forge.TokenHint(kind))
+	}
+}
+
+func handleCI() error {
+	if len(os.Args) != 3 || strings.HasPrefix(os.Args[2], "-") {
+		return fmt.Errorf("usage: scope ci <tag>")
+	}
+
+	tagName := os.Args[2]
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	repos := forgeRepos(folders)
+	if len(repos) == 0 {
+		ui.Infoln("No GitHub, GitLab or Bitbucket repositories found with this tag")
+		return nil
+	}
+	warnUnauthenticated(repos)
+
+	type result struct {
+		branch string
+		run    *forge.Run
+		err    error
+	}
+	results := make([]result, len(repos))
+	var wg sync.WaitGroup
+	for i, r := range repos {
+		wg.Add(1)
+		go func(i int, r forgeRepo) {
+			defer wg.Done()
+			results[i].branch = r.branch
+			results[i].run, results[i].err = r.forge.LatestRun(r.repo, r.branch)
+			if results[i].run != nil && r.branch == "" {
+				results[i].branch = results[i].run.Branch
+			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, r := range repos {
+		res := results[i]
+		name := fmt.Sprintf("%-30s", r.repo)
+		switch {
+		case res.err != nil:
+			fmt.Printf("%s %s  %v\n", ui.Color("yellow", "?"), name, res.err)
+		case res.run == nil:
+			fmt.Printf("%s %s  %-12s no CI runs\n", ui.Color("white", "-"), name, res.branch)
+		default:
+			symbol := map[string]string{
+				forge.StatePassed:  ui.Color("green", "✓"),
+				forge.StateFailed:  ui.Color("red", "✗"),
+				forge.StateRunning: ui.Color("yellow", "●"),
+			}[res.run.State]
+			if symbol == "" {
+				symbol = ui.Color("white", "-")
+			}
+			if res.run.State == forge.StateFailed {
+				failed++
+			}
+			fmt.Printf("%s %s  %-12s %-8s %-20s %s  %s\n", symbol, name, res.branch, res.run.State, res.run.Name,
+				res.run.UpdatedAt.Local().Format("Jan 2 15:04"), res.run.URL)
+		}
+	}
+
+	ui.Infof("\n%d of %d repositories failing\n", failed, len(repos))
+	return nil
+}
+
+func handleEnrich() error {
+	usage := fmt.Errorf("usage: scope enrich <tag> [--topics]")
+	tagName, topics := "", false
+	for _, arg := range os.Args[2:] {
+		switch {
+		case arg == "--topics":
+			topics = true
+		case strings.HasPrefix(arg, "-") || tagName != "":
+			return usage
+		default:
+			tagName = arg
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	repos := forgeRepos(folders)
+	if len(repos) == 0 {
+		ui.Infoln("No GitHub, GitLab or Bitbucket repositories found with this tag")
+		return nil
+	}
+	// Packages of a monorepo share its details
+	byRoot := make(map[string][]string)
+	for _, folder := range folders {
+		if root, ok := project.RepoRoot(folder); ok {
+			byRoot[root] = append(byRoot[root], folder)
+		}
+	}
+
+	warnUnauthenticated(repos)
+
+	type result struct {
+		info *forge.Details
+		err  error
+	}
+	results := make([]result, len(repos))
+	var wg sync.WaitGroup
+	for i, r := range repos {
+		wg.Add(1)
+		go func(i int, r forgeRepo) {
+			defer wg.Done()
+			results[i].info, results[i].err = r.forge.Details(r.repo)
+		}(i, r)
+	}
+	wg.Wait()
+
+	enriched, tagged := 0, 0
+	for i, r := range repos {
+		info, err := results[i].info, results[i].err
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", r.repo, err)
+			continue
+		}
+		for _, folder := range byRoot[r.root] {
+			if err := tag.SetMeta(folder, info.Meta()); err != nil {
+				return fmt.Errorf("failed to save details of %s: %w", folder, err)
+			}
+			if !topics {
+				continue
+			}
+			for _, topic := range info.Topics {
+				if err := tag.AddTag(folder, topic); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to add tag '%s' to %s: %v\n", topic, folder, err)
+					continue
+				}
+				tagged++
+			}
+		}
+		enriched++
+
+		line := fmt.Sprintf("%s %-30s %s", ui.Color("green", "✓"), r.repo, info.Description)
+		if info.Archived {
+			line += " " + ui.Color("yellow", "(archived)")
+		}
+		fmt.Println(line)
+		if len(info.Topics) > 0 {
+			fmt.Printf("  topics: %s\n", strings.Join(info.Topics, ", "))
+		}
+	}
+
+	ui.Infof("\nEnriched %d of %d repositories", enriched, len(repos))
+	if topics {
+		ui.Infof(", added %d topic tags", tagged)
+	}
+	ui.Infoln()
+	return nil
+}
+
+func handlePRs() error {
+	if len(os.Args) != 3 || strings.HasPrefix(os.Args[2], "-") {
+		return fmt.Errorf("usage: scope prs <tag>")
+	}
+
+	tagName := os.Args[2]
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	repos := forgeRepos(folders)
+	if len(repos) == 0 {
+		ui.Infoln("No GitHub, GitLab or Bitbucket repositories found with this tag")
+		return nil
+	}
+
+	// Who "you" are differs per forge; those without a token are skipped
+	users := make(map[forge.Forge]string)
+	var missing error
+	for _, r := range repos {
+		if _, ok := users[r.forge]; ok {
+			continue
+		}
+		users[r.forge] = ""
+		kind := r.forge.Kind()
+		if !r.forge.Authenticated() {
+			missing = fmt.Errorf("no %s token: %s", kind.Name(), forge.TokenHint(kind))
+			fmt.Fprintf(os.Stderr, "Warning: %v; skipping its repositories\n", missing)
+			continue
+		}
+		user, err := r.forge.CurrentUser()
+		if err != nil {
+			return err
+		}
+		users[r.forge] = user
+	}
+	repos = slices.DeleteFunc(repos, func(r forgeRepo) bool { return users[r.forge] == "" })
+	if len(repos) == 0 {
+		return missing
+	}
+
+	type result struct {
+		pulls []forge.PullRequest
+		err   error
+	}
+	results := make([]result, len(repos))
+	var wg sync.WaitGroup
+	for i, r := range repos {
+		wg.Add(1)
+		go func(i int, r forgeRepo) {
+			defer wg.Done()
+			results[i].pulls, results[i].err = r.forge.OpenPullRequests(r.repo)
+		}(i, r)
+	}
+	wg.Wait()
+
+	authored, assigned, withPulls := 0, 0, 0
+	for i, r := range repos {
+		if results[i].err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", r.repo, results[i].err)
+			continue
+		}
+
+		login := users[r.forge]
+		var mine []forge.PullRequest
+		for _, p := range results[i].pulls {
+			if p.AuthoredBy(login) || p.AssignedTo(login) {
+				mine = append(mine, p)
+			}
+		}
+		if len(mine) == 0 {
+			continue
+		}
+
+		if withPulls > 0 {
+			fmt.Println()
+		}
+		withPulls++
+		fmt.Printf("%s (%d)\n", ui.Color("blue", r.repo.String()), len(mine))
+		for _, p := range mine {
+			role := "assigned"
+			if p.AuthoredBy(login) {
+				role = "author"
+				authored++
+			} else {
+				assigned++
+			}
+			title := p.Title
+			if p.Draft {
+				title += " (draft)"
+			}
+			fmt.Printf("  #%-5d %-50s %-8s %s\n", p.Number, title, role, p.URL)
+		}
+	}
+
+	if withPulls == 0 {
+		ui.Infoln("No open pull requests for you")
+		return nil
+	}
+	ui.Infof("\n%d open pull requests in %d repositories (%d authored, %d assigned)\n",
+		authored+assigned, withPulls, authored, assigned)
+	return nil
+}
+
+func handleRelease() error {
+	usage := fmt.Errorf("usage: scope release <tag> [--bump major|minor|patch] [-m message] [--dry-run] [--yes] [--no-push]")
+
+	tagName, message := "", ""
+	bump := release.Patch
+	dryRun, yes, push := false, false, true
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--bump":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			b, err := release.ParseBump(args[i])
+			if err != nil {
+				return err
+			}
+			bump = b
+		case "-m", "--message":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			message = args[i]
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--yes", "-y":
+			yes = true
+		case "--no-push":
+			push = false
+		default:
+			if strings.HasPrefix(args[i], "-") || tagName != "" {
+				return usage
+			}
+			tagName = args[i]
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	// One release per repository, however many of its folders are tagged
+	var plans []release.Plan
+	seen := make(map[string]bool)
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		root, ok := project.RepoRoot(folder)
+		if !ok || seen[root] {
+			continue
+		}
+		seen[root] = true
+
+		plan, err := release.Propose(root, bump)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		if !plan.Pending() {
+			ui.Infof("%-24s %s is up to date\n", filepath.Base(root), plan.Last)
+			continue
+		}
+		plans = append(plans, plan)
+	}
+
+	if len(plans) == 0 {
+		ui.Infoln("Nothing to release")
+		return nil
+	}
+
+	if dryRun || yes {
+		for _, p := range plans {
+			fmt.Println(p.Label())
+		}
+		if dryRun {
+			return nil
+		}
+	} else {
+		plans, err = release.Select(plans)
+		if err != nil {
+			return err
+		}
+	}
+
+	remote := ""
+	if push && network.Offline() {
+		fmt.Fprintln(os.Stderr, "Warning: offline; tags are created but not pushed")
+	} else if push {
+		remote = "origin"
+	}
+	released := 0
+	for _, p := range plans {
+		if err := release.Apply(p, message, remote); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		released++
+		ui.Infof("Tagged %s %s\n", filepath.Base(p.Repo), p.Next)
+	}
+
+	ui.Infof("Released %d of %d repositories\n", released, len(plans))
+	return nil
+}
+
+func handleSnapshot() error {
+	usage := fmt.Errorf("usage: scope snapshot <tag> | --list [tag] | --restore <name> [--to <dir>] [--yes]")
+
+	var positional []string
+	mode, into, yes := "create", "", false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--list", "-l":
+			mode = "list"
+		case "--restore":
+			mode = "restore"
+		case "--to":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			into = args[i]
+		case "--yes", "-y":
+			yes = true
+		default:
+			if strings.HasPrefix(args[i], "-") {
+				return usage
+			}
+			positional = append(positional, args[i])
+		}
+	}
+
+	dir, err := cfg.Snapshots.Directory()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mode == "list" && len(positional) <= 1:
+		return listSnapshots(dir, strings.Join(positional, ""))
+	case mode == "restore" && len(positional) == 1:
+		return restoreSnapshot(dir, positional[0], into, yes)
+	case mode == "create" && len(positional) == 1 && into == "":
+		return createSnapshot(dir, positional[0])
+	default:
+		return usage
+	}
+}
+
+// createSnapshot archives the local folders tagged tagName into dir
+func createSnapshot(dir, tagName string) error {
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	var local []string
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s\n", folder)
+			continue
+		}
+		if _, err := os.Stat(folder); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", folder, err)
+			continue
+		}
+		local = append(local, folder)
+	}
+	if len(local) == 0 {
+		return fmt.Errorf("no local folders to snapshot for tag '%s'", tagName)
+	}
+
+	exclude := append(slices.Clone(snapshot.DefaultExclude), cfg.Snapshots.Exclude...)
+	archive, err := snapshot.Create(dir, tagName, local, exclude)
+	if err != nil {
+		return err
+	}
+
+	size := int64(0)
+	if info, err := os.Stat(archive); err == nil {
+		size = info.Size()
+	}
+	ui.Infof("Snapshot of %d folders (%s):\n", len(local), formatSize(size))
+	fmt.Println(archive)
+	return nil
+}
+
+// listSnapshots prints the snapshots in dir, only those of tagName if set
+func listSnapshots(dir, tagName string) error {
+	snapshots, err := snapshot.List(dir)
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for _, s := range snapshots {
+		if tagName != "" && s.Tag != tagName {
+			continue
+		}
+		fmt.Printf("%-40s %-16s %8s\n", s.Name, s.Created.Format("2006-01-02 15:04"), formatSize(s.Size))
+		shown++
+	}
+	if shown == 0 {
+		ui.Infof("No snapshots in %s\n", dir)
+	}
+	return nil
+}
+
+// restoreSnapshot extracts the snapshot called name, over the original
+// folders unless into is set
+func restoreSnapshot(dir, name, into string, yes bool) error {
+	s, err := snapshot.Find(dir, name)
+	if err != nil {
+		return err
+	}
+
+	if into != "" {
+		if into, err = paths.Resolve(into); err != nil {
+			return err
+		}
+	} else if !yes {
+		m, err := snapshot.ReadManifest(s.Path)
+		if err != nil {
+			return err
+		}
+		ok, err := snapshot.ConfirmRestore(m)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			ui.Infoln("Restore canceled")
+			return nil
+		}
+	}
+
+	m, err := snapshot.Restore(s.Path, into)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range m.Folders {
+		if into != "" {
+			ui.Infof("Restored %s to %s\n", f.Path, filepath.Join(into, f.Name))
+		} else {
+			ui.Infof("Restored %s\n", f.Path)
+		}
+	}
+	return nil
+}
+
+func handleBackupFolders() error {
+	usage := fmt.Errorf("usage: scope backup-folders <tag> [--dry-run] [--to <dest>] [--tool rsync|rclone] [--include <pattern>] [--exclude <pattern>]")
+
+	tagName := ""
+	dryRun := false
+	var override config.BackupConfig
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--to", "--tool", "--include", "--exclude":
+			if i+1 >= len(args) {
+				return usage
+			}
+			flag, value := args[i], args[i+1]
+			i++
+			switch flag {
+			case "--to":
+				override.Dest = value
+			case "--tool":
+				override.Tool = value
+			case "--include":
+				override.Include = append(override.Include, value)
+			case "--exclude":
+				override.Exclude = append(override.Exclude, value)
+			}
+		default:
+			if strings.HasPrefix(args[i], "-") || tagName != "" {
+				return usage
+			}
+			tagName = args[i]
+		}
+	}
+	if tagName == "" {
+		return usage
+	}
+
+	// Flags add to, or replace, the tag's configured destination
+	conf, configured := cfg.Backups[tagName]
+	if override.Dest != "" {
+		conf.Dest = override.Dest
+	}
+	if override.Tool != "" {
+		conf.Tool = override.Tool
+	}
+	conf.Include = append(slices.Clone(conf.Include), override.Include...)
+	conf.Exclude = append(slices.Clone(conf.Exclude), override.Exclude...)
+	if conf.Dest == "" {
+		return fmt.Errorf("no backup destination for tag '%s': add backups.%s.dest to the config or pass --to", tagName, tagName)
+	}
+	if _, err := backup.ParseTool(conf.Tool); err != nil {
+		return err
+	}
+
+	target, err := conf.Target()
+	if err != nil {
+		return err
+	}
+	if err := target.Available(); err != nil {
+		return err
+	}
+	if target.Local() {
+		// A missing destination usually means an unmounted disk; don't
+		// write the backup to the mount point instead
+		if info, err := os.Stat(target.Dest); err != nil || !info.IsDir() {
+			return fmt.Errorf("backup destination %s does not exist (is the disk mounted?)", target.Dest)
+		}
+	}
+	if !configured {
+		ui.Infof("Backing up to %s\n", target.Dest)
+	}
+
+	folders, err := tag.ListFoldersByTag(tagName)
+	if err != nil {
+		return err
+	}
+
+	if len(folders) == 0 {
+		return fmt.Errorf("no folders found with tag '%s'", tagName)
+	}
+
+	var local []string
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s\n", folder)
+			continue
+		}
+		local = append(local, folder)
+	}
+
+	succeeded, failed := 0, 0
+	for _, job := range target.Plan(local) {
+		fmt.Printf("\n%s %s -> %s\n", ui.Color("blue", "["+filepath.Base(job.Source)+"]"), job.Source, job.Dest)
+		fmt.Println(strings.Repeat("-", 40))
+
+		cmd := target.Command(job, dryRun)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s %v\n", ui.Color("red", "Error:"), err)
+			failed++
+		} else {
+			succeeded++
+		}
+	}
+
+	done := "backed up"
+	if dryRun {
+		done = "checked"
+	}
+	ui.Infof("\n%s %d %s, %d failed\n", ui.Color("bold", "Summary:"), succeeded, done, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d backups failed", failed)
+	}
+	return nil
+}
+
+// formatSize renders a byte count with a binary unit
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func handleCompletions() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope completions <shell>\nSupported shells: bash, zsh, fish")
+	}
+
+	shell := os.Args[2]
+	script, err := completions.Generate(shell)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+func handleInit() error {
+	if len(os.Args) < 3 {
+		return fmt.Errorf("usage: scope init <shell>\nSupported shells: bash, zsh, fish")
+	}
+
+	script, err := completions.Init(os.Args[2])
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+func handleFinder() error {
+	usage := fmt.Errorf("usage: scope finder sync [--dry-run]")
+	if len(os.Args) < 3 || os.Args[2] != "sync" {
+		return usage
+	}
+	dryRun := false
+	for _, arg := range os.Args[3:] {
+		switch arg {
+		case "--dry-run", "-n":
+			dryRun = true
+		default:
+			return usage
+		}
+	}
+
+	if !finder.Supported() {
+		return finder.ErrUnsupported
+	}
+	if len(cfg.Finder.Tags) == 0 {
+		return fmt.Errorf("no Finder tags configured: map tags under finder.tags in the config")
+	}
+
+	result, err := finder.Sync(tag.Default(), cfg.Finder.Mapping(), dryRun)
+	if err != nil {
+		return err
+	}
+	for _, u := range result.ToFinder {
+		fmt.Printf("%s %s: Finder tag %s (%s)\n", ui.Color("green", "→"), u.Path, u.Finder.Name, u.Finder.Color)
+	}
+	for _, u := range result.ToScope {
+		fmt.Printf("%s %s: tag %s\n", ui.Color("blue", "←"), u.Path, u.Tag)
+	}
+
+	verb := "Synced"
+	if dryRun {
+		verb = "Would sync"
+	}
+	ui.Infof("%s %d Finder tags and %d scope tags\n", verb, len(result.ToFinder), len(result.ToScope))
+	return nil
+}
+
+// handleServe runs the HTTP API until interrupted
+func handleServe() error {
+	usage := fmt.Errorf("usage: scope serve [--addr <host:port>]\n       scope serve token [--read-only] [--reset]")
+	if len(os.Args) > 2 && os.Args[2] == "token" {
+		return handleServeToken(usage)
+	}
+
+	addr := cfg.Server.Addr
+	if addr == "" {
+		addr = server.DefaultAddr
+	}
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--addr":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			addr = args[i]
+		default:
+			return usage
+		}
+	}
+
+	tokensPath, err := apiTokensPath()
+	if err != nil {
+		return err
+	}
+	tokens, err := server.LoadTokens(tokensPath, false)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := server.New(tag.Default(), server.Options{
+		DBPath:  db.Default().Path(),
+		Tokens:  tokens,
+		Origins: cfg.Server.Origins,
+		Tracker: timetrack.NewTracker(nil),
+	})
+
+	// Stream what every scope process does when they publish to the events
+	// socket, which then includes this one's changes; otherwise only this
+	// process's own changes can be streamed
+	listening := false
+	if socket, err := cfg.Events.SocketPath(); err == nil && socket != "" {
+		listener, err := events.Listen(socket, srv.Publish)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not streaming events from other scope processes: %v\n", err)
+		} else {
+			defer func() { _ = listener.Close() }()
+			listening = true
+		}
+	}
+	if !listening {
+		srv.Observe(tag.Default())
+	}
+
+	return srv.ListenAndServe(ctx, addr, func(a net.Addr) {
+		ui.Infof("Dashboard and API on http://%s (Ctrl+C to stop); get a token with scope serve token\n", a)
+	})
+}
+
+// handleServeToken prints a bearer token for the API
+func handleServeToken(usage error) error {
+	readOnly, reset := false, false
+	for _, arg := range os.Args[3:] {
+		switch arg {
+		case "--read-only":
+			readOnly = true
+		case "--reset":
+			reset = true
+		default:
+			return usage
+		}
+	}
+
+	path, err := apiTokensPath()
+	if err != nil {
+		return err
+	}
+	tokens, err := server.LoadTokens(path, reset)
+	if err != nil {
+		return err
+	}
+	if reset {
+		// stdout is the token
+		fmt.Fprintln(os.Stderr, "Generated new tokens; restart scope serve to use them")
+	}
+	if readOnly {
+		fmt.Println(tokens[server.ScopeRead])
+	} else {
+		fmt.Println(tokens[server.ScopeWrite])
+	}
+	return nil
+}
+
+// apiTokensPath returns the file holding the API's tokens
+func apiTokensPath() (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "api-tokens"), nil
+}
+
+// handleService installs, inspects and removes the background services
+// that run scope commands at login
+func handleWatch() error {
+	usage := fmt.Errorf("usage: scope watch [path...] [--interval <duration>] [--metrics <host:port>] [--once]")
+	interval, metricsAddr, once := cfg.Watch.Interval, cfg.Watch.Metrics, false
+	var roots []string
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--once":
+			once = true
+		case "--interval", "--metrics":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			if arg == "--metrics" {
+				metricsAddr = args[i]
+				continue
+			}
+			d, err := time.ParseDuration(args[i])
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid --interval %q (expected a duration such as 30s)", args[i])
+			}
+			interval = d
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return usage
+			}
+			root, err := paths.Resolve(arg)
+			if err != nil {
+				return err
+			}
+			roots = append(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		configured, err := cfg.Watch.ResolvedRoots()
+		if err != nil {
+			return err
+		}
+		roots = configured
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("no folders to watch: give their paths or set watch.roots in the config file")
+	}
+	// Folders are stored as the symlink policy has them
+	for i, root := range roots {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", root)
+		}
+		roots[i] = paths.Canonical(root, cfg.Paths.SymlinkPolicy())
+	}
+
+	watcher := watch.New(tag.Default(), roots)
+	watcher.SetDepth(cfg.Watch.Depth)
+	if once {
+		result, err := watcher.Check()
+		if err != nil {
+			return err
+		}
+		printWatchResult(result)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stats := watch.NewMetrics()
+	if metricsAddr != "" {
+		srv := &http.Server{Addr: metricsAddr, Handler: stats, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				fmt.Fprintf(os.Stderr, "Warning: metrics disabled: %v\n", err)
+			}
+		}()
+		defer func() { _ = srv.Close() }()
+	}
+
+	ui.Infof("Watching %s (Ctrl+C to stop)\n", strings.Join(roots, ", "))
+	return watcher.Run(ctx, interval, func(result *watch.Result, took time.Duration, err error) {
+		stats.Record(result, took, err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: check failed: %v\n", err)
+			return
+		}
+		printWatchResult(result)
+	})
+}
+
+// printWatchResult prints what a check of scope watch changed
+func printWatchResult(result *watch.Result) {
+	now := time.Now().Format("15:04:05")
+	for _, folder := range result.Applied {
+		fmt.Printf("%s %s tagged %s from its .scope file\n", now, ui.Color("green", "✓"), folder)
+	}
+	for _, from := range slices.Sorted(maps.Keys(result.Moved)) {
+		fmt.Printf("%s %s moved %s to %s\n", now, ui.Color("blue", "→"), from, result.Moved[from])
+	}
+	for _, folder := range result.Forgotten {
+		fmt.Printf("%s %s moved %s to the trash: it was deleted or left the watched folders (scope trash restore)\n", now, ui.Color("red", "✗"), folder)
+	}
+}
+
+// handleEditorRPC answers an editor plugin's JSON-RPC requests on stdin
+// until it closes
+func handleEditorRPC() error {
+	if len(os.Args) > 2 {
+		return fmt.Errorf("usage: scope editor-rpc")
+	}
+	srv := server.New(tag.Default(), server.Options{Tracker: timetrack.NewTracker(nil)})
+	return srv.ServeRPC(os.Stdin, os.Stdout)
+}
+
+func handleService() error {
+	usage := fmt.Errorf("usage: scope service install|status|uninstall <service>\nservices: %s", strings.Join(service.Names(), ", "))
+	if len(os.Args) != 4 {
+		return usage
+	}
+	svc, err := service.Lookup(os.Args[3])
+	if err != nil {
+		return err
+	}
+
+	switch os.Args[2] {
+	case "install":
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to find the scope executable: %w", err)
+		}
+		path, err := service.Install(svc, exe)
+		if err != nil {
+			return err
+		}
+		ui.Infof("%s Installed %s (%s), running now and at login\n", ui.Color("green", "✓"), svc.Name, path)
+	case "status":
+		status, err := service.GetStatus(svc)
+		if err != nil {
+			return err
+		}
+		if !status.Installed {
+			fmt.Printf("%s is not installed (scope service install %s)\n", svc.Name, svc.Name)
+			return nil
+		}
+		fmt.Printf("%s is installed (%s)\n", svc.Name, status.Path)
+		if status.Output != "" {
+			fmt.Println(status.Output)
+		}
+	case "uninstall":
+		path, err := service.Uninstall(svc)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			ui.Infof("%s is not installed\n", svc.Name)
+			return nil
+		}
+		ui.Infof("%s Uninstalled %s (removed %s)\n", ui.Color("green", "✓"), svc.Name, path)
+	default:
+		return usage
+	}
+	return nil
+}
+
+// handleHint is run by the shell hook on every directory change, so it
+// prints nothing unless it has a hint (the hook discards errors)
+func handleMineHistory() error {
+	usage := fmt.Errorf("usage: scope mine-history [--tag <tag>] [--limit <n>] [--dry-run | --yes]")
+
+	tagName := ""
+	limit := 20
+	dryRun, yes := false, false
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--tag", "-t":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			tagName = args[i]
+		case "--limit":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid limit: %s", args[i])
+			}
+			limit = n
+		case "--dry-run", "-n":
+			dryRun = true
+		case "--yes", "-y":
+			yes = true
+		default:
+			return usage
+		}
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	roots, err := cfg.Hints.ResolvedRoots()
+	if err != nil {
+		return err
+	}
+	if len(roots) == 0 {
+		if roots, err = hint.TaggedParents(tag.Default()); err != nil {
+			return err
+		}
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("no project roots: set hints.roots in the config or tag a folder first")
+	}
+
+	candidates, err := mine.Rank(tag.Default(), mine.Options{Home: home, Roots: roots})
+	if err != nil {
+		return err
+	}
+
+	// Each folder gets --tag, or else its best suggestion
+	var choices []mine.Choice
+	unsuggested := 0
+	for _, c := range candidates {
+		if len(choices) == limit {
+			break
+		}
+		choice := mine.Choice{Candidate: c, Tag: tagName}
+		if choice.Tag == "" {
+			suggestions, err := suggest.For(tag.Default(), c.Path)
+			if err != nil {
+				return err
+			}
+			if len(suggestions) == 0 {
+				unsuggested++
+				continue
+			}
+			choice.Tag = suggestions[0].Tag
+		}
+		choices = append(choices, choice)
+	}
+
+	if len(choices) == 0 {
+		ui.Infoln("No untagged folders found in your shell history")
+		if unsuggested > 0 {
+			ui.Infof("%d folders have no tag suggestion; pass --tag to tag them\n", unsuggested)
+		}
+		return nil
+	}
+
+	if dryRun {
+		for _, c := range choices {
+			fmt.Printf("%5d  %s → %s\n", c.Visits, c.Path, c.Tag)
+		}
+		return nil
+	}
+
+	selected := choices
+	if !yes {
+		if selected, err = mine.SelectChoices(choices); err != nil {
+			return err
+		}
+	}
+	if len(selected) == 0 {
+		ui.Infoln("No folders selected")
+		return nil
+	}
+
+	for _, c := range selected {
+		if err := tag.AddTag(c.Path, c.Tag); err != nil {
+			return err
+		}
+		ui.Infof("Tagged '%s' with '%s'\n", c.Path, c.Tag)
+	}
+	if unsuggested > 0 {
+		ui.Infof("%d more folders have no tag suggestion; pass --tag to tag them\n", unsuggested)
+	}
+	return nil
+}
+
+func handleHint() error {
+	configDir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	stateFile := filepath.Join(configDir, ".hints")
+
+	args := os.Args[2:]
+	if len(args) == 1 && (args[0] == "--off" || args[0] == "--on") {
+		off := args[0] == "--off"
+		if err := hint.SetOff(stateFile, off); err != nil {
+			return err
+		}
+		if off {
+			ui.Infoln("Tagging hints turned off ('scope hint --on' to undo)")
+		} else {
+			ui.Infoln("Tagging hints turned on")
+		}
+		return nil
+	}
+
+	if cfg.Hints.Disabled || envFlag("SCOPE_NO_HINTS") {
+		return nil
+	}
+
+	dir := "."
+	if len(args) >= 1 {
+		dir = args[0]
+	}
+	absPath, err := paths.Resolve(dir)
+	if err != nil {
+		return err
+	}
+	absPath = paths.Canonical(absPath, cfg.Paths.SymlinkPolicy())
+
+	roots, err := cfg.Hints.ResolvedRoots()
+	if err != nil {
+		return err
+	}
+
+	message, err := hint.For(tag.Default(), absPath, hint.Options{
+		Roots:     roots,
+		Interval:  cfg.Hints.Interval,
+		StateFile: stateFile,
+	})
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		fmt.Println(message)
+	}
+	return nil
+}
+
+func handleUpdate() error {
+	checkOnly := len(os.Args) >= 3 && (os.Args[2] == "--check" || os.Args[2] == "-c")
+
+	if checkOnly {
+		info, err := update.CheckForUpdate(Version)
+		if err != nil {
+			return fmt.Errorf("failed to check for updates: %w", err)
+		}
+
+		if info.UpdateAvailable {
+			fmt.Printf("Update available: %s (current: %s)\n", info.LatestVersion, info.CurrentVersion)
 			fmt.Printf("Run 'scope update' to install\n")
 			fmt.Printf("Release: %s\n", info.ReleaseURL)
 		} else {
-			fmt.Printf("Already up to date (version %s)\n", Version)
+			ui.Infof("Already up to date (version %s)\n", Version)
 		}
 		return nil
 	}
 
 	return update.PerformUpdate(Version)
 }
+
+// timeTracker returns the time tracker for the default store
+func timeTracker() *timetrack.Tracker {
+	tracker := timetrack.NewTracker(nil)
+	tracker.MaxVisit = cfg.Time.MaxVisit
+	return tracker
+}
+
+func handleTime() error {
+	usage := fmt.Errorf("usage: scope time [--today|--week|--since YYYY-MM-DD] [tag]")
+
+	now := time.Now()
+	since := timetrack.StartOfWeek(now)
+	label := "This week"
+	tagName := ""
+
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--record":
+			return recordVisit(args[i+1:])
+		case "--today":
+			since, label = timetrack.StartOfDay(now), "Today"
+		case "--week":
+			since, label = timetrack.StartOfWeek(now), "This week"
+		case "--since":
+			if i+1 >= len(args) {
+				return usage
+			}
+			i++
+			day, err := time.ParseInLocation("2006-01-02", args[i], time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", args[i])
+			}
+			since, label = day, "Since "+args[i]
+		default:
+			if strings.HasPrefix(args[i], "-") || tagName != "" {
+				return usage
+			}
+			tagName = args[i]
+		}
+	}
+
+	report, err := timeTracker().Report(since, now)
+	if err != nil {
+		return err
+	}
+
+	tags := report.Tags
+	if tagName != "" {
+		tags = nil
+		for _, tt := range report.Tags {
+			if tt.Tag == tagName {
+				tags = append(tags, tt)
+			}
+		}
+	}
+	if len(tags) == 0 {
+		ui.Infof("No time recorded %s\n", strings.ToLower(label))
+		return nil
+	}
+
+	ui.Infof("%s (since %s):\n", label, since.Format("Mon Jan 2"))
+	for _, tt := range tags {
+		line := fmt.Sprintf("  %-20s %8s", tt.Tag, timetrack.FormatDuration(tt.Total()))
+		if tt.Sessions > 0 {
+			line += fmt.Sprintf("  (sessions %s)", timetrack.FormatDuration(tt.Sessions))
+		}
+		fmt.Println(line)
+	}
+
+	var folders []timetrack.FolderTime
+	for _, f := range report.Folders {
+		if tagName == "" {
+			folders = append(folders, f)
+			continue
+		}
+		folderTags, err := tag.GetTagsForFolder(f.Path)
+		if err != nil {
+			return err
+		}
+		if slices.Contains(folderTags, tagName) {
+			folders = append(folders, f)
+		}
+	}
+	if len(folders) > 0 {
+		ui.Infoln("\nFolders:")
+		for _, f := range folders {
+			fmt.Printf("  %-40s %8s\n", f.Path, timetrack.FormatDuration(f.Duration))
+		}
+	}
+	return nil
+}
+
+// recordVisit records the shell hook's current directory; --exit ends the
+// visit instead. The shell is scope's parent process.
+func recordVisit(args []string) error {
+	if cfg.Time.Disabled {
+		return nil
+	}
+
+	dir := ""
+	if len(args) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		dir = cwd
+	} else if len(args) != 1 || args[0] != "--exit" {
+		return fmt.Errorf("usage: scope time --record [--exit]")
+	}
+
+	return timeTracker().Visit(os.Getppid(), dir, time.Now())
+}
+
+func handleStandup() error {
+	usage := fmt.Errorf("usage: scope standup [tag] [--since YYYY-MM-DD] [--until YYYY-MM-DD] [--all-authors]")
+
+	now := time.Now()
+	opts := standup.Options{Since: standup.DefaultSince(now), Until: now}
+
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--since", "--until":
+			if i+1 >= len(args) {
+				return usage
+			}
+			day, err := time.ParseInLocation("2006-01-02", args[i+1], time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", args[i+1])
+			}
+			if args[i] == "--since" {
+				opts.Since = day
+			} else {
+				// The until day is included
+				opts.Until = day.AddDate(0, 0, 1)
+			}
+			i++
+		case "--all-authors":
+			opts.AllAuthors = true
+		default:
+			if strings.HasPrefix(args[i], "-") || opts.Tag != "" {
+				return usage
+			}
+			opts.Tag = args[i]
+		}
+	}
+	if !opts.Since.Before(opts.Until) {
+		return fmt.Errorf("--since must be before --until")
+	}
+
+	summary, err := standup.Collect(tag.Default(), timeTracker(), opts)
+	if err != nil {
+		return err
+	}
+	for _, err := range summary.Errors {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	fmt.Print(summary.Markdown())
+	return nil
+}
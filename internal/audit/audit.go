@@ -0,0 +1,125 @@
+// Package audit reports the licenses and direct dependencies of tagged
+// folders, flagging those with no license or a copyleft one.
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/gabssanto/Scope/internal/project"
+)
+
+// Flags raised by an audit
+const (
+	FlagMissing      = "missing license"
+	FlagUnrecognized = "unrecognized license"
+	FlagCopyleft     = "copyleft"
+)
+
+// Entry is the audit of one folder
+type Entry struct {
+	Path    string
+	License project.License
+	// FromRepo is set when the license was found at the repository root
+	// rather than in the folder itself
+	FromRepo     bool
+	Dependencies []project.Dependencies
+}
+
+// Flag returns why the entry needs attention, or "" if it doesn't
+func (e Entry) Flag() string {
+	switch {
+	case e.License.ID != "" && e.License.Copyleft():
+		return FlagCopyleft
+	case e.License.ID != "":
+		return ""
+	case e.License.Unrecognized:
+		return FlagUnrecognized
+	default:
+		return FlagMissing
+	}
+}
+
+// Source is the file the license was read from
+func (e Entry) Source() string {
+	if e.FromRepo {
+		return e.License.Source + " (repository root)"
+	}
+	return e.License.Source
+}
+
+// DependencyCount is the total of direct dependencies over all manifests
+func (e Entry) DependencyCount() int {
+	total := 0
+	for _, d := range e.Dependencies {
+		total += d.Count
+	}
+	return total
+}
+
+// Inspect audits one folder. A folder inside a repository with no license
+// of its own inherits the one at the repository root.
+func Inspect(folder string) Entry {
+	e := Entry{
+		Path:         folder,
+		License:      project.DetectLicense(folder),
+		Dependencies: project.CountDependencies(folder),
+	}
+	if e.License.ID != "" {
+		return e
+	}
+	if root, ok := project.RepoRoot(folder); ok && root != folder {
+		if l := project.DetectLicense(root); l.ID != "" || (l.Unrecognized && !e.License.Unrecognized) {
+			e.License = l
+			e.FromRepo = true
+		}
+	}
+	return e
+}
+
+// LicenseCount is how many folders have a license
+type LicenseCount struct {
+	License string
+	Count   int
+}
+
+// Count returns the number of folders per license, most common first;
+// folders without a license are counted under "none"
+func Count(entries []Entry) []LicenseCount {
+	byLicense := make(map[string]int)
+	for _, e := range entries {
+		id := e.License.ID
+		if id == "" {
+			id = "none"
+		}
+		byLicense[id]++
+	}
+	counts := make([]LicenseCount, 0, len(byLicense))
+	for id, n := range byLicense {
+		counts = append(counts, LicenseCount{License: id, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].License < counts[j].License
+	})
+	return counts
+}
+
+// WriteCSV writes one row per entry, with a header
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"path", "license", "source", "dependencies", "flag"}); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{e.Path, e.License.ID, e.Source(), strconv.Itoa(e.DependencyCount()), e.Flag()}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
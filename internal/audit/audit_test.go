@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files (relative path to content) under root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+}
+
+func TestInspect(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"mono/.git/HEAD":            "ref: refs/heads/main\n",
+		"mono/LICENSE":              "Permission is hereby granted, free of charge, to any person",
+		"mono/packages/api/go.mod":  "module api\n\nrequire github.com/a/b v1.0.0\n",
+		"fork/COPYING":              "GNU GENERAL PUBLIC LICENSE Version 2, June 1991",
+		"scratch/package.json":      `{"dependencies": {"left-pad": "1", "is-odd": "3"}}`,
+		"private/LICENSE":           "Copyright Acme. All rights reserved.",
+		"private/.git/HEAD":         "ref: refs/heads/main\n",
+		"private/package.json":      `{"private": true}`,
+		"mono/packages/web/LICENSE": "Apache License\nVersion 2.0, January 2004",
+	})
+
+	tests := []struct {
+		folder   string
+		license  string
+		source   string
+		deps     int
+		flag     string
+		fromRepo bool
+	}{
+		{"mono/packages/api", "MIT", "LICENSE (repository root)", 1, "", true},
+		{"mono/packages/web", "Apache-2.0", "LICENSE", 0, "", false},
+		{"fork", "GPL-2.0", "COPYING", 0, FlagCopyleft, false},
+		{"scratch", "", "", 2, FlagMissing, false},
+		{"private", "", "LICENSE", 0, FlagUnrecognized, false},
+	}
+	var entries []Entry
+	for _, tt := range tests {
+		e := Inspect(filepath.Join(root, tt.folder))
+		entries = append(entries, e)
+		if e.License.ID != tt.license || e.Source() != tt.source || e.DependencyCount() != tt.deps || e.Flag() != tt.flag || e.FromRepo != tt.fromRepo {
+			t.Errorf("%s: unexpected entry %+v (source %q, deps %d, flag %q)", tt.folder, e, e.Source(), e.DependencyCount(), e.Flag())
+		}
+	}
+
+	counts := Count(entries)
+	if len(counts) != 4 || counts[0].License != "none" || counts[0].Count != 2 {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, entries[2:4]); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	expected := "path,license,source,dependencies,flag\n" +
+		filepath.Join(root, "fork") + ",GPL-2.0,COPYING,0,copyleft\n" +
+		filepath.Join(root, "scratch") + ",,,2,missing license\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected CSV:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
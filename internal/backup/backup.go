@@ -0,0 +1,144 @@
+// Package backup copies tagged folders to a per-tag destination with rsync
+// or rclone.
+package backup
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Tools that can copy folders to a destination
+const (
+	ToolRsync  = "rsync"
+	ToolRclone = "rclone"
+)
+
+// Target is where and how a tag's folders are backed up
+type Target struct {
+	// Dest is a directory, an rsync host:path or an rclone remote:path;
+	// each folder is copied into a directory of its own there
+	Dest string
+	// Tool is ToolRsync (the default) or ToolRclone
+	Tool string
+	// Include patterns are matched before Exclude ones, so they can bring
+	// back files an exclude pattern would skip
+	Include []string
+	Exclude []string
+	// Delete removes files from the destination that are no longer in the
+	// folder, making the backup a mirror
+	Delete bool
+}
+
+// ParseTool validates a tool name; "" means rsync
+func ParseTool(name string) (string, error) {
+	switch name {
+	case "", ToolRsync:
+		return ToolRsync, nil
+	case ToolRclone:
+		return ToolRclone, nil
+	default:
+		return "", fmt.Errorf("unknown backup tool %q (expected rsync or rclone)", name)
+	}
+}
+
+// Local reports whether the destination is a path on this machine rather
+// than an rsync host or rclone remote
+func (t Target) Local() bool {
+	return !strings.Contains(t.Dest, ":") || filepath.IsAbs(t.Dest)
+}
+
+// Job is one folder copied to its destination directory
+type Job struct {
+	Source string
+	Dest   string
+}
+
+// Plan gives each folder a directory under the target named after it,
+// numbered when two folders share a name
+func (t Target) Plan(folders []string) []Job {
+	used := make(map[string]int)
+	jobs := make([]Job, len(folders))
+	for i, folder := range folders {
+		name := filepath.Base(folder)
+		if n := used[name]; n > 0 {
+			name = fmt.Sprintf("%s-%d", name, n+1)
+		}
+		used[filepath.Base(folder)]++
+		jobs[i] = Job{Source: folder, Dest: t.join(name)}
+	}
+	return jobs
+}
+
+// join appends name to the destination
+func (t Target) join(name string) string {
+	if t.Local() {
+		return filepath.Join(t.Dest, name)
+	}
+	host, p, _ := strings.Cut(t.Dest, ":")
+	if p == "" {
+		return host + ":" + name
+	}
+	return host + ":" + path.Join(p, name)
+}
+
+// Command returns the command that copies the job's folder; with dryRun it
+// only reports what would be copied
+func (t Target) Command(job Job, dryRun bool) *exec.Cmd {
+	tool, _ := ParseTool(t.Tool)
+	return exec.Command(tool, t.Args(job, dryRun)...)
+}
+
+// Args are the arguments of the job's rsync or rclone command
+func (t Target) Args(job Job, dryRun bool) []string {
+	tool, _ := ParseTool(t.Tool)
+	var args []string
+
+	if tool == ToolRclone {
+		verb := "copy"
+		if t.Delete {
+			verb = "sync"
+		}
+		args = append(args, verb, job.Source, job.Dest, "--verbose")
+		for _, p := range t.Include {
+			args = append(args, "--filter", "+ "+p)
+		}
+		for _, p := range t.Exclude {
+			args = append(args, "--filter", "- "+p)
+		}
+		if dryRun {
+			args = append(args, "--dry-run")
+		}
+		return args
+	}
+
+	args = append(args, "--archive", "--itemize-changes")
+	if t.Delete {
+		args = append(args, "--delete")
+	}
+	if dryRun {
+		args = append(args, "--dry-run")
+	}
+	for _, p := range t.Include {
+		args = append(args, "--include="+p)
+	}
+	for _, p := range t.Exclude {
+		args = append(args, "--exclude="+p)
+	}
+	// The trailing slash copies the folder's contents, not the folder itself
+	return append(args, strings.TrimSuffix(job.Source, "/")+"/", job.Dest+"/")
+}
+
+// Available reports an error if the target's tool isn't installed
+func (t Target) Available() error {
+	tool, err := ParseTool(t.Tool)
+	if err != nil {
+		return err
+	}
+	if _, err := exec.LookPath(tool); err != nil {
+		return fmt.Errorf("%s not found in PATH", tool)
+	}
+	return nil
+}
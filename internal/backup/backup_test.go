@@ -0,0 +1,83 @@
+package backup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		dest string
+		want []string
+	}{
+		{"/mnt/nas/work", []string{"/mnt/nas/work/api", "/mnt/nas/work/web", "/mnt/nas/work/api-2"}},
+		{"nas:backups", []string{"nas:backups/api", "nas:backups/web", "nas:backups/api-2"}},
+		{"gdrive:", []string{"gdrive:api", "gdrive:web", "gdrive:api-2"}},
+	}
+
+	folders := []string{"/home/me/api", "/home/me/web", "/home/me/old/api"}
+	for _, tt := range tests {
+		jobs := Target{Dest: tt.dest}.Plan(folders)
+		var got []string
+		for i, job := range jobs {
+			if job.Source != folders[i] {
+				t.Errorf("Plan(%q) job %d has source %s", tt.dest, i, job.Source)
+			}
+			got = append(got, job.Dest)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Plan(%q) = %v, want %v", tt.dest, got, tt.want)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	job := Job{Source: "/home/me/api", Dest: "/mnt/nas/api"}
+
+	tests := []struct {
+		name   string
+		target Target
+		dryRun bool
+		want   []string
+	}{
+		{
+			name:   "rsync",
+			target: Target{Include: []string{"build/keep"}, Exclude: []string{"build"}},
+			want:   []string{"--archive", "--itemize-changes", "--include=build/keep", "--exclude=build", "/home/me/api/", "/mnt/nas/api/"},
+		},
+		{
+			name:   "rsync mirror dry run",
+			target: Target{Tool: ToolRsync, Delete: true},
+			dryRun: true,
+			want:   []string{"--archive", "--itemize-changes", "--delete", "--dry-run", "/home/me/api/", "/mnt/nas/api/"},
+		},
+		{
+			name:   "rclone",
+			target: Target{Tool: ToolRclone, Exclude: []string{"node_modules/**"}},
+			dryRun: true,
+			want:   []string{"copy", "/home/me/api", "/mnt/nas/api", "--verbose", "--filter", "- node_modules/**", "--dry-run"},
+		},
+		{
+			name:   "rclone mirror",
+			target: Target{Tool: ToolRclone, Delete: true},
+			want:   []string{"sync", "/home/me/api", "/mnt/nas/api", "--verbose"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.target.Args(job, tt.dryRun); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Args = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTool(t *testing.T) {
+	for name, want := range map[string]string{"": ToolRsync, "rsync": ToolRsync, "rclone": ToolRclone} {
+		if got, err := ParseTool(name); err != nil || got != want {
+			t.Errorf("ParseTool(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := ParseTool("scp"); err == nil {
+		t.Error("ParseTool should reject unknown tools")
+	}
+}
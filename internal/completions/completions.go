@@ -10,17 +10,26 @@ func Bash() string {
 	return `# Scope bash completion script
 # Add to ~/.bashrc: eval "$(scope completions bash)"
 
+# _scope_complete_tags adds the tags starting with $cur to COMPREPLY,
+# escaped so names with spaces or quotes complete as one word
+_scope_complete_tags() {
+    local tag
+    while IFS= read -r tag; do
+        [[ -n "${tag}" && "${tag}" == "${cur}"* ]] && COMPREPLY+=( "$(printf '%q' "${tag}")" )
+    done <<< "${tags}"
+}
+
 _scope_completions() {
     local cur prev commands tags
     COMPREPLY=()
     cur="${COMP_WORDS[COMP_CWORD]}"
     prev="${COMP_WORDS[COMP_CWORD-1]}"
 
-    commands="tag bulk untag tags list start scan go pick open edit each status pull rename remove-tag prune export import update debug help version completions"
+    commands="tag bulk untag tags note subdir todo suggest list search packages order start session workspace incident env scan go path pick ui open edit each deps status branch pull secrets audit ci enrich prs release snapshot backup-folders rename mv remove-tag tag-info tag-set prune trash sync lock unlock tidy doctor export import migrate update graph query debug selfcheck help version completions init hint mine-history finder serve service editor-rpc watch time standup"
 
     # Get tags dynamically
     if command -v scope &> /dev/null; then
-        tags=$(scope list 2>/dev/null | grep -E '^\s+\S+' | awk '{print $1}')
+        tags=$(scope list --names 2>/dev/null)
     fi
 
     case "${prev}" in
@@ -28,19 +37,22 @@ _scope_completions() {
             COMPREPLY=( $(compgen -W "${commands}" -- "${cur}") )
             return 0
             ;;
-        tag|untag|tags)
+        --depth|--exclude)
+            return 0
+            ;;
+        tag|untag|tags|note|subdir|suggest|mv)
             # Complete with directories
             COMPREPLY=( $(compgen -d -- "${cur}") )
             return 0
             ;;
-        list|start|go|open|edit|each|status|pull|remove-tag|pick)
+        list|packages|order|start|go|open|edit|each|deps|status|branch|pull|secrets|audit|ci|enrich|prs|release|snapshot|backup-folders|remove-tag|tag-info|tag-set|pick|graph|standup|env|path)
             # Complete with tag names
-            COMPREPLY=( $(compgen -W "${tags}" -- "${cur}") )
+            _scope_complete_tags
             return 0
             ;;
         rename)
             # Complete with tag names for rename
-            COMPREPLY=( $(compgen -W "${tags}" -- "${cur}") )
+            _scope_complete_tags
             return 0
             ;;
         import)
@@ -53,34 +65,163 @@ _scope_completions() {
             if [[ ${COMP_CWORD} -eq 2 ]]; then
                 COMPREPLY=( $(compgen -f -- "${cur}") )
             elif [[ ${COMP_CWORD} -eq 3 ]]; then
-                COMPREPLY=( $(compgen -W "${tags}" -- "${cur}") )
+                _scope_complete_tags
             elif [[ ${COMP_CWORD} -eq 4 ]]; then
                 COMPREPLY=( $(compgen -W "--dry-run" -- "${cur}") )
             fi
             return 0
             ;;
-        completions)
+        completions|init)
             COMPREPLY=( $(compgen -W "bash zsh fish" -- "${cur}") )
             return 0
             ;;
+        hint)
+            COMPREPLY=( $(compgen -W "--off --on" -- "${cur}") )
+            return 0
+            ;;
+        mine-history)
+            COMPREPLY=( $(compgen -W "--tag --limit --dry-run --yes" -- "${cur}") )
+            return 0
+            ;;
+        finder)
+            COMPREPLY=( $(compgen -W "sync --dry-run" -- "${cur}") )
+            return 0
+            ;;
+        serve)
+            COMPREPLY=( $(compgen -W "token --addr" -- "${cur}") )
+            return 0
+            ;;
+        service)
+            if [[ ${COMP_CWORD} -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "install status uninstall" -- "${cur}") )
+            else
+                COMPREPLY=( $(compgen -W "serve watch" -- "${cur}") )
+            fi
+            return 0
+            ;;
+        watch)
+            COMPREPLY=( $(compgen -d -W "--interval --metrics --once" -- "${cur}") )
+            return 0
+            ;;
+        time)
+            COMPREPLY=( $(compgen -W "--today --week --since" -- "${cur}") )
+            _scope_complete_tags
+            return 0
+            ;;
+        todo)
+            # Subcommands, tags or directories
+            COMPREPLY=( $(compgen -W "list done" -- "${cur}") $(compgen -d -- "${cur}") )
+            _scope_complete_tags
+            return 0
+            ;;
+        session)
+            COMPREPLY=( $(compgen -W "refresh log" -- "${cur}") )
+            return 0
+            ;;
+        workspace)
+            COMPREPLY=( $(compgen -W "create open list delete" -- "${cur}") )
+            return 0
+            ;;
+        incident)
+            COMPREPLY=( $(compgen -W "start end" -- "${cur}") )
+            return 0
+            ;;
+        migrate)
+            COMPREPLY=( $(compgen -W "export apply" -- "${cur}") )
+            return 0
+            ;;
+        apply)
+            COMPREPLY=( $(compgen -f -- "${cur}") )
+            return 0
+            ;;
         prune)
             COMPREPLY=( $(compgen -W "--dry-run" -- "${cur}") )
             return 0
             ;;
+        trash)
+            COMPREPLY=( $(compgen -W "list restore empty" -- "${cur}") )
+            return 0
+            ;;
+        tidy)
+            COMPREPLY=( $(compgen -W "--dry-run --yes" -- "${cur}") )
+            return 0
+            ;;
+        doctor)
+            COMPREPLY=( $(compgen -W "--fix --yes" -- "${cur}") )
+            return 0
+            ;;
+        query)
+            COMPREPLY=( $(compgen -W "--json --csv" -- "${cur}") )
+            return 0
+            ;;
+        export)
+            COMPREPLY=( $(compgen -W "--to-scope-files --format --matrix --output --launch-agent --interval" -- "${cur}") )
+            return 0
+            ;;
         update)
             COMPREPLY=( $(compgen -W "--check" -- "${cur}") )
             return 0
             ;;
+        sync)
+            COMPREPLY=( $(compgen -W "--seed" -- "${cur}") )
+            return 0
+            ;;
         each)
             # After 'each', complete with tags, then commands
             if [[ ${COMP_CWORD} -eq 2 ]]; then
-                COMPREPLY=( $(compgen -W "${tags}" -- "${cur}") )
+                _scope_complete_tags
             elif [[ ${COMP_CWORD} -eq 3 ]]; then
-                COMPREPLY=( $(compgen -W "-p --parallel" -- "${cur}") )
+                COMPREPLY=( $(compgen -W "-p --parallel --jobs --fail-fast --log-dir" -- "${cur}") )
             fi
             return 0
             ;;
         *)
+            if [[ ${COMP_WORDS[1]} == tag && ${cur} == -* ]]; then
+                COMPREPLY=( $(compgen -W "--recursive --depth --only-git --exclude" -- "${cur}") )
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == open && ${prev} == --reveal ]]; then
+                COMPREPLY=( $(compgen -f -- "${cur}") )
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == open && ${cur} == -* ]]; then
+                COMPREPLY=( $(compgen -W "--web --pick --reveal" -- "${cur}") )
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == edit && ${cur} == -* ]]; then
+                COMPREPLY=( $(compgen -W "--reuse-window" -- "${cur}") )
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == tag-set ]]; then
+                if [[ ${prev} == --color ]]; then
+                    COMPREPLY=( $(compgen -W "red green yellow blue magenta cyan white bold" -- "${cur}") )
+                elif [[ ${prev} != --description && ${prev} != --icon ]]; then
+                    COMPREPLY=( $(compgen -W "--description --color --icon" -- "${cur}") )
+                fi
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == env ]]; then
+                if [[ ${prev} == --format ]]; then
+                    COMPREPLY=( $(compgen -W "sh fish make" -- "${cur}") )
+                elif [[ ${prev} == --output || ${prev} == -o ]]; then
+                    COMPREPLY=( $(compgen -f -- "${cur}") )
+                elif [[ ${prev} != --prefix ]]; then
+                    COMPREPLY=( $(compgen -W "--format --prefix --export --output" -- "${cur}") )
+                fi
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == rename && ${cur} == -* ]]; then
+                COMPREPLY=( $(compgen -W "--merge-if-exists --scope-files" -- "${cur}") )
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == mv ]]; then
+                COMPREPLY=( $(compgen -d -- "${cur}") )
+                return 0
+            fi
+            if [[ ${COMP_WORDS[1]} == trash && ${COMP_WORDS[2]} == empty ]]; then
+                COMPREPLY=( $(compgen -W "--yes" -- "${cur}") )
+                return 0
+            fi
             ;;
     esac
 
@@ -103,34 +244,82 @@ _scope() {
 
     commands=(
         'tag:Tag a folder'
-        'bulk:Bulk tag paths from file'
+        'bulk:Bulk tag paths from a file or stdin'
         'untag:Remove a tag from a folder'
         'tags:Show all tags for a folder'
+        'note:Show or set a folder note'
+        'subdir:Show or set the subdirectory go/each/edit use'
+        'todo:Add, list or complete folder todos'
+        'suggest:Suggest tags for a folder'
         'list:List all tags or folders with a tag'
+        'search:Find folders by path, tags and note'
+        'packages:List tagged folders by repository'
+        'order:Reorder the folders of a tag'
         'start:Start a scoped session'
+        'session:Manage the current session'
+        'workspace:Manage persistent named workspaces'
+        'incident:Start or end an incident across services'
+        'env:Environment variables for tagged folders'
         'scan:Scan for .scope files'
         'go:Jump to a tagged folder'
+        'path:Print a tagged folder path by name'
         'pick:Interactive folder picker'
+        'ui:Full-screen dashboard of tags and folders'
         'open:Open folder in file manager'
         'edit:Open folder in editor'
         'each:Run command in each folder'
-        'status:Git status across folders'
+        'deps:Update dependencies in each folder'
+        'status:Git dashboard across folders'
+        'branch:List, create, switch or delete branches across repositories'
         'pull:Git pull across folders'
+        'secrets:Scan folders for committed keys and tokens'
+        'audit:Licenses and dependencies of folders'
+        'ci:Latest GitHub Actions run per repository'
+        'enrich:Store forge details of repositories'
+        'prs:Your open pull requests across repositories'
+        'release:Tag the next version in each repository'
+        'snapshot:Archive or restore the folders of a tag'
+        'backup-folders:Copy tagged folders to the tag backup destination'
         'rename:Rename a tag'
         'remove-tag:Delete a tag entirely'
+        'mv:Move the tags of a folder that was moved'
+        'tag-info:Show the description, color and icon of a tag'
+        'tag-set:Describe a tag'
         'prune:Remove non-existent folders'
+        'trash:List, restore or empty deleted tags and folders'
+        'sync:Replay changes from other machines'
+        'lock:Make the database read-only'
+        'unlock:Make the database writable again'
+        'tidy:Review and apply cleanups'
+        'doctor:Check the database for problems'
         'export:Export tags to YAML'
         'import:Import tags from YAML'
+        'migrate:Move tags, repositories and config to a new machine'
         'update:Update to latest version'
+        'graph:Graph tags and folders'
+        'query:Run a read-only SQL query'
         'debug:Show debug information'
+        'selfcheck:Verify the installation'
         'completions:Generate shell completions'
+        'init:Print shell integration'
+        'hint:Suggest tagging an untagged repository'
+        'mine-history:Tag the folders you visit most'
+        'finder:Sync tags with macOS Finder tags'
+        'serve:Serve tags and folders as a JSON API'
+        'service:Run serve at login'
+        'editor-rpc:Answer JSON-RPC on stdio for editor plugins'
+        'watch:Keep tags in sync with the filesystem'
+        'time:Time spent per tag in sessions and folders'
+        'standup:Summarize recent commits and time as Markdown'
         'help:Show help'
         'version:Show version'
     )
 
     # Get tags dynamically
     if (( $+commands[scope] )); then
-        tags=(${(f)"$(scope list 2>/dev/null | grep -E '^\s+\S+' | awk '{print $1}')"})
+        tags=(${(f)"$(scope list --names 2>/dev/null)"})
+        # _describe splits name:description on the first unescaped colon
+        tags=(${tags//:/\\:})
     fi
 
     _arguments -C \
@@ -143,25 +332,89 @@ _scope() {
             ;;
         args)
             case $words[2] in
-                tag|untag|tags)
+                tag)
+                    if [[ $PREFIX == -* ]]; then
+                        _values 'flags' '--recursive[tag every subdirectory]' '--depth[levels of subdirectories]' '--only-git[only repositories]' '--exclude[skip matching directories]'
+                    else
+                        _files -/
+                    fi
+                    ;;
+                untag|tags|note|subdir|suggest|mv)
                     _files -/
                     ;;
-                list|start|go|open|edit|status|pull|remove-tag|pick)
+                list|packages|order|start|go|deps|pull|secrets|audit|ci|enrich|prs|release|snapshot|backup-folders|remove-tag|tag-info|pick|graph|standup|path)
                     _describe -t tags 'tags' tags
                     ;;
                 rename)
-                    _describe -t tags 'tags' tags
+                    if [[ $PREFIX == -* ]]; then
+                        _values 'flags' '--merge-if-exists[merge into the new tag if it exists]' '--scope-files[rewrite .scope files]'
+                    else
+                        _describe -t tags 'tags' tags
+                    fi
+                    ;;
+                tag-set)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _describe -t tags 'tags' tags
+                    elif [[ $words[CURRENT-1] == --color ]]; then
+                        _values 'colors' red green yellow blue magenta cyan white bold
+                    elif [[ $words[CURRENT-1] != --description && $words[CURRENT-1] != --icon ]]; then
+                        _values 'flags' '--description[what the tag is for]' '--color[color in listings]' '--icon[shown before the name]'
+                    fi
+                    ;;
+                edit)
+                    if [[ $PREFIX == -* ]]; then
+                        _values 'flags' '--reuse-window[open in the current VS Code window]'
+                    else
+                        _describe -t tags 'tags' tags
+                    fi
+                    ;;
+                open)
+                    if [[ $words[CURRENT-1] == --reveal ]]; then
+                        _files
+                    elif [[ $PREFIX == -* ]]; then
+                        _values 'flags' '--web[open repository pages on their forge]' '--pick[choose one folder to open]' '--reveal[show a file selected in the file manager]'
+                    else
+                        _describe -t tags 'tags' tags
+                    fi
+                    ;;
+                branch)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _describe -t tags 'tags' tags
+                    else
+                        _values 'flags' '--create[create and switch to a branch]' '--checkout[switch to a branch]' '--delete[delete a merged branch]' '--force[delete even if unmerged]'
+                    fi
+                    ;;
+                env)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _describe -t tags 'tags' tags
+                    else
+                        _values 'flags' '--format[sh, fish or make]' '--prefix[variable name prefix]' '--export[write .scope.env]' '--output[file to write]'
+                    fi
+                    ;;
+                status)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _describe -t tags 'tags' tags
+                    else
+                        _values 'flags' '--fetch[fetch remotes first]' '--json[output JSON]'
+                    fi
                     ;;
                 each)
                     if [[ $CURRENT -eq 3 ]]; then
                         _describe -t tags 'tags' tags
                     elif [[ $CURRENT -eq 4 ]]; then
-                        _values 'flags' '-p[parallel]' '--parallel[parallel]'
+                        _values 'flags' '-p[parallel]' '--parallel[parallel]' '--jobs[folders run at once]' '--fail-fast[stop at the first failure]' '--log-dir[keep logs and a summary]'
                     fi
                     ;;
                 import)
                     _files -g '*.y(a|)ml'
                     ;;
+                migrate)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _values 'subcommands' 'export[write a bundle]' 'apply[recreate a bundle here]'
+                    else
+                        _files
+                    fi
+                    ;;
                 bulk)
                     if [[ $CURRENT -eq 3 ]]; then
                         _files
@@ -171,15 +424,79 @@ _scope() {
                         _values 'flags' '--dry-run[preview changes]'
                     fi
                     ;;
-                completions)
+                completions|init)
                     _values 'shells' 'bash' 'zsh' 'fish'
                     ;;
+                hint)
+                    _values 'flags' '--off[stop hints]' '--on[resume hints]'
+                    ;;
+                mine-history)
+                    _values 'flags' '--tag[tag the picked folders with]' '--limit[number of folders listed]' '--dry-run[only list the folders]' '--yes[tag them all without asking]'
+                    ;;
+                finder)
+                    _values 'subcommands' 'sync[sync mapped tags both ways]' '--dry-run[only list the changes]'
+                    ;;
+                serve)
+                    if [[ $words[3] == token ]]; then
+                        _values 'flags' '--read-only[a token that can only list]' '--reset[replace the tokens]'
+                    else
+                        _values 'flags' 'token[print an API token]' '--addr[host:port to listen on]'
+                    fi
+                    ;;
+                service)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _values 'subcommands' 'install[install and start a service]' 'status[show whether a service is running]' 'uninstall[stop and remove a service]'
+                    else
+                        _values 'services' 'serve[the JSON API]' 'watch[the folder watcher]'
+                    fi
+                    ;;
+                watch)
+                    _values 'flags' '--interval[time between checks]' '--metrics[host:port for /healthz and /metrics]' '--once[check once and exit]'
+                    _files -/
+                    ;;
+                todo)
+                    if [[ $CURRENT -eq 3 ]]; then
+                        _values 'subcommands' 'list[list todos]' 'done[mark todos done]'
+                        _describe -t tags 'tags' tags
+                        _files -/
+                    fi
+                    ;;
+                session)
+                    _values 'subcommands' 'refresh[update the links to match the tag]' 'log[show recorded commands]'
+                    ;;
+                workspace)
+                    _values 'subcommands' 'create[define a workspace of tags]' 'open[open a shell in a workspace]' 'list[list workspaces]' 'delete[delete a workspace]'
+                    ;;
+                incident)
+                    _values 'subcommands' 'start[start an incident]' 'end[archive an incident]'
+                    ;;
+                time)
+                    _values 'flags' '--today[since midnight]' '--week[since Monday]' '--since[since a date]'
+                    ;;
                 prune)
                     _values 'flags' '--dry-run[preview changes]'
                     ;;
+                trash)
+                    _values 'subcommands' 'list[list deleted tags and folders]' 'restore[bring a tag or folder back]' 'empty[delete the trash for good]'
+                    ;;
+                tidy)
+                    _values 'flags' '--dry-run[preview changes]' '--yes[apply the preselected cleanups]'
+                    ;;
+                doctor)
+                    _values 'flags' '--fix[repair the problems found]' '--yes[repair without asking]'
+                    ;;
+                query)
+                    _values 'flags' '--json[print rows as JSON]' '--csv[print rows as CSV]'
+                    ;;
+                export)
+                    _values 'flags' '--to-scope-files[write .scope files into folders]' '--dry-run[preview changes]' '--format[yaml, csv, plist, locate, windows-terminal or powertoys]' '--matrix[folders x tags CSV matrix]' '--output[write to a file]' '--launch-agent[print a LaunchAgent keeping the file current]' '--interval[also rerun the agent periodically]'
+                    ;;
                 update)
                     _values 'flags' '--check[check only]'
                     ;;
+                sync)
+                    _values 'flags' '--seed[journal existing tags]'
+                    ;;
             esac
             ;;
     esac
@@ -199,51 +516,216 @@ complete -c scope -f
 
 # Commands
 complete -c scope -n "__fish_use_subcommand" -a "tag" -d "Tag a folder"
-complete -c scope -n "__fish_use_subcommand" -a "bulk" -d "Bulk tag paths from file"
+complete -c scope -n "__fish_use_subcommand" -a "bulk" -d "Bulk tag paths from a file or stdin"
 complete -c scope -n "__fish_use_subcommand" -a "untag" -d "Remove a tag from a folder"
 complete -c scope -n "__fish_use_subcommand" -a "tags" -d "Show all tags for a folder"
+complete -c scope -n "__fish_use_subcommand" -a "note" -d "Show or set a folder note"
+complete -c scope -n "__fish_use_subcommand" -a "subdir" -d "Show or set the working subdirectory"
+complete -c scope -n "__fish_use_subcommand" -a "todo" -d "Add, list or complete folder todos"
+complete -c scope -n "__fish_use_subcommand" -a "suggest" -d "Suggest tags for a folder"
 complete -c scope -n "__fish_use_subcommand" -a "list" -d "List all tags or folders"
+complete -c scope -n "__fish_use_subcommand" -a "search" -d "Find folders by path, tags and note"
+complete -c scope -n "__fish_use_subcommand" -a "packages" -d "List tagged folders by repository"
+complete -c scope -n "__fish_use_subcommand" -a "order" -d "Reorder the folders of a tag"
 complete -c scope -n "__fish_use_subcommand" -a "start" -d "Start a scoped session"
+complete -c scope -n "__fish_use_subcommand" -a "session" -d "Manage the current session"
+complete -c scope -n "__fish_use_subcommand" -a "workspace" -d "Manage persistent named workspaces"
+complete -c scope -n "__fish_use_subcommand" -a "incident" -d "Start or end an incident across services"
+complete -c scope -n "__fish_use_subcommand" -a "env" -d "Environment variables for tagged folders"
 complete -c scope -n "__fish_use_subcommand" -a "scan" -d "Scan for .scope files"
 complete -c scope -n "__fish_use_subcommand" -a "go" -d "Jump to a tagged folder"
+complete -c scope -n "__fish_use_subcommand" -a "path" -d "Print a tagged folder path by name"
 complete -c scope -n "__fish_use_subcommand" -a "pick" -d "Interactive folder picker"
+complete -c scope -n "__fish_use_subcommand" -a "ui" -d "Full-screen dashboard of tags and folders"
 complete -c scope -n "__fish_use_subcommand" -a "open" -d "Open folder in file manager"
 complete -c scope -n "__fish_use_subcommand" -a "edit" -d "Open folder in editor"
 complete -c scope -n "__fish_use_subcommand" -a "each" -d "Run command in each folder"
-complete -c scope -n "__fish_use_subcommand" -a "status" -d "Git status across folders"
+complete -c scope -n "__fish_use_subcommand" -a "deps" -d "Update dependencies in each folder"
+complete -c scope -n "__fish_use_subcommand" -a "status" -d "Git dashboard across folders"
+complete -c scope -n "__fish_use_subcommand" -a "branch" -d "Branches across repositories"
 complete -c scope -n "__fish_use_subcommand" -a "pull" -d "Git pull across folders"
+complete -c scope -n "__fish_use_subcommand" -a "secrets" -d "Scan folders for committed keys and tokens"
+complete -c scope -n "__fish_use_subcommand" -a "audit" -d "Licenses and dependencies of folders"
+complete -c scope -n "__fish_use_subcommand" -a "ci" -d "Latest GitHub Actions run per repository"
+complete -c scope -n "__fish_use_subcommand" -a "enrich" -d "Store forge details of repositories"
+complete -c scope -n "__fish_use_subcommand" -a "prs" -d "Your open pull requests across repositories"
+complete -c scope -n "__fish_use_subcommand" -a "release" -d "Tag the next version in each repository"
+complete -c scope -n "__fish_use_subcommand" -a "snapshot" -d "Archive or restore the folders of a tag"
+complete -c scope -n "__fish_use_subcommand" -a "backup-folders" -d "Copy tagged folders to the tag's backup destination"
 complete -c scope -n "__fish_use_subcommand" -a "rename" -d "Rename a tag"
 complete -c scope -n "__fish_use_subcommand" -a "remove-tag" -d "Delete a tag entirely"
+complete -c scope -n "__fish_use_subcommand" -a "mv" -d "Move the tags of a folder that was moved"
+complete -c scope -n "__fish_use_subcommand" -a "tag-info" -d "Show the description, color and icon of a tag"
+complete -c scope -n "__fish_use_subcommand" -a "tag-set" -d "Describe a tag"
+complete -c scope -n "__fish_seen_subcommand_from tag-set" -l description -x -d "What the tag is for"
+complete -c scope -n "__fish_seen_subcommand_from tag-set" -l color -xa "red green yellow blue magenta cyan white bold" -d "Color in listings"
+complete -c scope -n "__fish_seen_subcommand_from tag-set" -l icon -x -d "Shown before the name"
 complete -c scope -n "__fish_use_subcommand" -a "prune" -d "Remove non-existent folders"
+complete -c scope -n "__fish_use_subcommand" -a "trash" -d "List, restore or empty deleted tags and folders"
+complete -c scope -n "__fish_use_subcommand" -a "sync" -d "Replay changes from other machines"
+complete -c scope -n "__fish_use_subcommand" -a "lock" -d "Make the database read-only"
+complete -c scope -n "__fish_use_subcommand" -a "unlock" -d "Make the database writable again"
+complete -c scope -n "__fish_use_subcommand" -a "tidy" -d "Review and apply cleanups"
+complete -c scope -n "__fish_use_subcommand" -a "doctor" -d "Check the database for problems"
+complete -c scope -n "__fish_seen_subcommand_from doctor" -l fix -d "Repair the problems found"
 complete -c scope -n "__fish_use_subcommand" -a "export" -d "Export tags to YAML"
 complete -c scope -n "__fish_use_subcommand" -a "import" -d "Import tags from YAML"
+complete -c scope -n "__fish_use_subcommand" -a "migrate" -d "Move tags, repositories and config to a new machine"
 complete -c scope -n "__fish_use_subcommand" -a "update" -d "Update to latest version"
+complete -c scope -n "__fish_use_subcommand" -a "graph" -d "Graph tags and folders"
+complete -c scope -n "__fish_use_subcommand" -a "query" -d "Run a read-only SQL query"
+complete -c scope -n "__fish_seen_subcommand_from query" -l json -d "Print rows as JSON"
+complete -c scope -n "__fish_seen_subcommand_from query" -l csv -d "Print rows as CSV"
 complete -c scope -n "__fish_use_subcommand" -a "debug" -d "Show debug information"
+complete -c scope -n "__fish_use_subcommand" -a "selfcheck" -d "Verify the installation"
 complete -c scope -n "__fish_use_subcommand" -a "completions" -d "Generate shell completions"
+complete -c scope -n "__fish_use_subcommand" -a "init" -d "Print shell integration"
+complete -c scope -n "__fish_use_subcommand" -a "hint" -d "Suggest tagging an untagged repository"
+complete -c scope -n "__fish_use_subcommand" -a "mine-history" -d "Tag the folders you visit most"
+complete -c scope -n "__fish_seen_subcommand_from mine-history" -l tag -xa "(__scope_tags)" -d "Tag the picked folders with"
+complete -c scope -n "__fish_seen_subcommand_from mine-history" -l limit -x -d "Number of folders listed"
+complete -c scope -n "__fish_seen_subcommand_from mine-history" -l dry-run -d "Only list the folders"
+complete -c scope -n "__fish_seen_subcommand_from mine-history" -s y -l yes -d "Tag them all without asking"
+complete -c scope -n "__fish_use_subcommand" -a "finder" -d "Sync tags with macOS Finder tags"
+complete -c scope -n "__fish_seen_subcommand_from finder" -a "sync" -d "Sync mapped tags both ways"
+complete -c scope -n "__fish_seen_subcommand_from finder" -l dry-run -d "Only list the changes"
+complete -c scope -n "__fish_use_subcommand" -a "serve" -d "Serve tags and folders as a JSON API"
+complete -c scope -n "__fish_seen_subcommand_from serve" -l addr -d "host:port to listen on" -r
+complete -c scope -n "__fish_seen_subcommand_from serve; and not __fish_seen_subcommand_from token" -a "token" -d "Print an API token"
+complete -c scope -n "__fish_seen_subcommand_from token" -l read-only -d "A token that can only list"
+complete -c scope -n "__fish_seen_subcommand_from token" -l reset -d "Replace the tokens"
+complete -c scope -n "__fish_use_subcommand" -a "service" -d "Run serve at login"
+complete -c scope -n "__fish_seen_subcommand_from service; and not __fish_seen_subcommand_from install status uninstall" -a "install status uninstall" -d "Service action"
+complete -c scope -n "__fish_seen_subcommand_from service; and __fish_seen_subcommand_from install status uninstall" -a "serve" -d "The JSON API"
+complete -c scope -n "__fish_seen_subcommand_from service; and __fish_seen_subcommand_from install status uninstall" -a "watch" -d "The folder watcher"
+complete -c scope -n "__fish_use_subcommand" -a "editor-rpc" -d "Answer JSON-RPC on stdio for editor plugins"
+complete -c scope -n "__fish_use_subcommand" -a "watch" -d "Keep tags in sync with the filesystem"
+complete -c scope -n "__fish_seen_subcommand_from watch" -a "(__fish_complete_directories)"
+complete -c scope -n "__fish_seen_subcommand_from watch" -l interval -x -d "Time between checks"
+complete -c scope -n "__fish_seen_subcommand_from watch" -l metrics -x -d "host:port for /healthz and /metrics"
+complete -c scope -n "__fish_seen_subcommand_from watch" -l once -d "Check once and exit"
+complete -c scope -n "__fish_use_subcommand" -a "time" -d "Time spent per tag"
+complete -c scope -n "__fish_use_subcommand" -a "standup" -d "Summarize recent commits and time"
 complete -c scope -n "__fish_use_subcommand" -a "help" -d "Show help"
 complete -c scope -n "__fish_use_subcommand" -a "version" -d "Show version"
+complete -c scope -n "__fish_use_subcommand" -s q -l quiet -d "Only print results and errors"
+complete -c scope -n "__fish_use_subcommand" -l no-input -d "Fail instead of prompting"
+complete -c scope -n "__fish_use_subcommand" -l accessible -d "Plain text and prompts"
 
 # Helper function to get tags
 function __scope_tags
-    scope list 2>/dev/null | string match -r '^\s+\S+' | string trim | string split ' ' | head -1
+    scope list --names 2>/dev/null
 end
 
 # Tag completions for commands that take tags
-complete -c scope -n "__fish_seen_subcommand_from list start go open edit status pull remove-tag pick" -a "(__scope_tags)" -d "Tag"
+complete -c scope -n "__fish_seen_subcommand_from list packages order start go open edit deps status pull secrets audit ci enrich prs release snapshot backup-folders remove-tag tag-info tag-set pick graph env path" -a "(__scope_tags)" -d "Tag"
+complete -c scope -n "__fish_seen_subcommand_from graph" -l format -xa "dot mermaid" -d "Output format"
+complete -c scope -n "__fish_seen_subcommand_from start" -l exit -xa "origin first" -d "Where to go when the session ends"
+complete -c scope -n "__fish_seen_subcommand_from start" -l record -d "Record the commands run in the session"
+complete -c scope -n "__fish_seen_subcommand_from start" -l nesting -xa "nest deny replace" -d "What to do inside another session"
+complete -c scope -n "__fish_seen_subcommand_from start" -l tmux -d "Open the folders as tmux windows"
+complete -c scope -n "__fish_seen_subcommand_from graph" -l cooccurrence -d "Only tags, linked by shared folders"
+complete -c scope -n "__fish_seen_subcommand_from graph" -l open -d "Render to SVG and open"
 complete -c scope -n "__fish_seen_subcommand_from rename" -a "(__scope_tags)" -d "Tag"
 complete -c scope -n "__fish_seen_subcommand_from each" -a "(__scope_tags)" -d "Tag"
 
 # Directory completion for tag/untag/tags
-complete -c scope -n "__fish_seen_subcommand_from tag untag tags" -a "(__fish_complete_directories)"
+complete -c scope -n "__fish_seen_subcommand_from tag untag tags note subdir suggest mv" -a "(__fish_complete_directories)"
+complete -c scope -n "__fish_seen_subcommand_from todo" -a "list done (__scope_tags) (__fish_complete_directories)"
 
 # Flags
-complete -c scope -n "__fish_seen_subcommand_from prune" -l dry-run -d "Preview changes"
+complete -c scope -n "__fish_seen_subcommand_from prune tidy" -l dry-run -d "Preview changes"
+complete -c scope -n "__fish_seen_subcommand_from sync" -l seed -d "Journal existing tags"
+complete -c scope -n "__fish_seen_subcommand_from go pick" -s 0 -l null -d "NUL-terminate the path"
+complete -c scope -n "__fish_seen_subcommand_from tag" -s r -l recursive -d "Tag every subdirectory"
+complete -c scope -n "__fish_seen_subcommand_from tag" -l depth -x -d "Levels of subdirectories to tag"
+complete -c scope -n "__fish_seen_subcommand_from tag" -l only-git -d "Only tag repositories"
+complete -c scope -n "__fish_seen_subcommand_from tag" -l exclude -x -d "Skip directories matching a glob"
 complete -c scope -n "__fish_seen_subcommand_from bulk" -l dry-run -d "Preview changes"
 complete -c scope -n "__fish_seen_subcommand_from update" -l check -d "Check only"
+complete -c scope -n "__fish_seen_subcommand_from branch" -a "(__scope_tags)" -d "Tag"
+complete -c scope -n "__fish_seen_subcommand_from branch" -l create -x -d "Create and switch to a branch"
+complete -c scope -n "__fish_seen_subcommand_from branch" -l checkout -x -d "Switch to a branch"
+complete -c scope -n "__fish_seen_subcommand_from branch" -l delete -x -d "Delete a merged branch"
+complete -c scope -n "__fish_seen_subcommand_from branch" -s f -l force -d "Delete even if unmerged"
+complete -c scope -n "__fish_seen_subcommand_from env" -l format -xa "sh fish make" -d "Output format"
+complete -c scope -n "__fish_seen_subcommand_from env" -l prefix -x -d "Variable name prefix"
+complete -c scope -n "__fish_seen_subcommand_from env" -l export -d "Write .scope.env"
+complete -c scope -n "__fish_seen_subcommand_from env" -s o -l output -r -d "File to write"
+complete -c scope -n "__fish_seen_subcommand_from status" -l fetch -d "Fetch remotes first"
+complete -c scope -n "__fish_seen_subcommand_from status" -l json -d "Output JSON"
 complete -c scope -n "__fish_seen_subcommand_from each" -s p -l parallel -d "Run in parallel"
+complete -c scope -n "__fish_seen_subcommand_from each" -s j -l jobs -x -d "Folders run at once"
+complete -c scope -n "__fish_seen_subcommand_from each" -l fail-fast -d "Stop at the first failure"
+complete -c scope -n "__fish_seen_subcommand_from each" -l log-dir -xa "(__fish_complete_directories)" -d "Keep logs and a summary"
 
 # Shell completion for completions command
-complete -c scope -n "__fish_seen_subcommand_from completions" -a "bash zsh fish" -d "Shell"
+complete -c scope -n "__fish_seen_subcommand_from completions init" -a "bash zsh fish" -d "Shell"
+complete -c scope -n "__fish_seen_subcommand_from hint" -a "--off --on"
+complete -c scope -n "__fish_seen_subcommand_from session" -a "refresh" -d "Update the links to match the tag"
+complete -c scope -n "__fish_seen_subcommand_from session" -a "log" -d "Show recorded commands"
+complete -c scope -n "__fish_seen_subcommand_from workspace" -a "create open list delete" -d "Workspace command"
+complete -c scope -n "__fish_seen_subcommand_from trash" -a "list restore empty" -d "Trash command"
+complete -c scope -n "__fish_seen_subcommand_from incident" -a "start" -d "Start an incident"
+complete -c scope -n "__fish_seen_subcommand_from incident" -a "end" -d "Archive an incident"
+complete -c scope -n "__fish_seen_subcommand_from incident" -l no-clone -d "Don't clone missing services"
+complete -c scope -n "__fish_seen_subcommand_from enrich" -l topics -d "Turn repository topics into tags"
+complete -c scope -n "__fish_seen_subcommand_from open" -l web -d "Open repository pages on their forge"
+complete -c scope -n "__fish_seen_subcommand_from open" -l pick -d "Choose one folder to open"
+complete -c scope -n "__fish_seen_subcommand_from edit" -s r -l reuse-window -d "Open in the current VS Code window"
+complete -c scope -n "__fish_seen_subcommand_from open" -l reveal -r -F -d "Show a file selected in the file manager"
+complete -c scope -n "__fish_seen_subcommand_from time" -l today -d "Since midnight"
+complete -c scope -n "__fish_seen_subcommand_from time" -l week -d "Since Monday"
+complete -c scope -n "__fish_seen_subcommand_from time" -l since -x -d "Since a date (YYYY-MM-DD)"
+complete -c scope -n "__fish_seen_subcommand_from time" -a "(__scope_tags)" -d "Tag"
+complete -c scope -n "__fish_seen_subcommand_from standup" -l since -x -d "First day (YYYY-MM-DD)"
+complete -c scope -n "__fish_seen_subcommand_from standup" -l until -x -d "Last day (YYYY-MM-DD)"
+complete -c scope -n "__fish_seen_subcommand_from standup" -l all-authors -d "Include everyone's commits"
+complete -c scope -n "__fish_seen_subcommand_from audit" -l csv -d "Write CSV"
+complete -c scope -n "__fish_seen_subcommand_from release" -l bump -x -a "major minor patch" -d "Version part to increment"
+complete -c scope -n "__fish_seen_subcommand_from release" -l dry-run -d "Only show the proposed tags"
+complete -c scope -n "__fish_seen_subcommand_from release" -l yes -d "Tag without asking"
+complete -c scope -n "__fish_seen_subcommand_from release" -l no-push -d "Create tags without pushing"
+complete -c scope -n "__fish_seen_subcommand_from order" -l reset -d "Go back to path order"
+complete -c scope -n "__fish_seen_subcommand_from list" -l verbose -s v -d "Show each folder's health"
+complete -c scope -n "__fish_seen_subcommand_from list" -l grouped -s g -d "Group tags by category"
+complete -c scope -n "__fish_seen_subcommand_from list" -l limit -r -d "Show at most this many entries"
+complete -c scope -n "__fish_seen_subcommand_from list" -l offset -r -d "Skip this many entries"
+complete -c scope -n "__fish_seen_subcommand_from list" -l no-pager -d "Never page the output"
+complete -c scope -n "__fish_seen_subcommand_from list" -l names -d "Print bare tag names"
+complete -c scope -n "__fish_seen_subcommand_from list" -l format -s f -x -a "text files" -d "Output format"
+complete -c scope -n "__fish_seen_subcommand_from search" -l tag -s t -x -a "(__scope_tags)" -d "Only folders matching a tag expression"
+complete -c scope -n "__fish_seen_subcommand_from search" -l limit -r -d "Show at most this many folders"
+complete -c scope -n "__fish_seen_subcommand_from search" -l format -s f -x -a "text files vimgrep" -d "Output format"
+complete -c scope -n "__fish_seen_subcommand_from snapshot" -l list -d "List snapshots"
+complete -c scope -n "__fish_seen_subcommand_from snapshot" -l restore -x -d "Restore a snapshot by name"
+complete -c scope -n "__fish_seen_subcommand_from snapshot" -l to -r -a "(__fish_complete_directories)" -d "Restore into this directory"
+complete -c scope -n "__fish_seen_subcommand_from snapshot" -l yes -d "Restore without asking"
+complete -c scope -n "__fish_seen_subcommand_from backup-folders" -l dry-run -d "Only show what would be copied"
+complete -c scope -n "__fish_seen_subcommand_from backup-folders" -l to -r -d "Destination instead of the configured one"
+complete -c scope -n "__fish_seen_subcommand_from backup-folders" -l tool -x -a "rsync rclone" -d "Copy with rsync or rclone"
+complete -c scope -n "__fish_seen_subcommand_from backup-folders" -l include -x -d "Pattern to copy even if excluded"
+complete -c scope -n "__fish_seen_subcommand_from backup-folders" -l exclude -x -d "Pattern to leave out"
+complete -c scope -n "__fish_seen_subcommand_from deps" -l update -d "Run the updaters"
+complete -c scope -n "__fish_seen_subcommand_from deps" -l branch -x -d "Commit the update on a new branch"
+complete -c scope -n "__fish_seen_subcommand_from standup" -a "(__scope_tags)" -d "Tag"
+complete -c scope -n "__fish_seen_subcommand_from export" -l to-scope-files -d "Write .scope files into folders"
+complete -c scope -n "__fish_seen_subcommand_from export" -l format -xa "yaml csv plist locate windows-terminal powertoys" -d "Output format"
+complete -c scope -n "__fish_seen_subcommand_from export" -l matrix -d "Folders x tags CSV matrix"
+complete -c scope -n "__fish_seen_subcommand_from export" -s o -l output -r -d "Write to a file"
+complete -c scope -n "__fish_seen_subcommand_from export" -l launch-agent -d "Print a LaunchAgent keeping the file current"
+complete -c scope -n "__fish_seen_subcommand_from export" -l interval -x -d "Also rerun the agent periodically"
+complete -c scope -n "__fish_seen_subcommand_from tags" -l organize -d "Find and merge similar tags"
+complete -c scope -n "__fish_seen_subcommand_from tags suggest tidy doctor" -s y -l yes -d "Apply the preselected choices without asking"
+complete -c scope -n "__fish_seen_subcommand_from scan" -s a -l all -d "Apply every .scope file without asking"
+complete -c scope -n "__fish_seen_subcommand_from go pick" -l index -x -d "Choose the nth folder without asking"
+complete -c scope -n "__fish_seen_subcommand_from rename tags" -l scope-files -d "Rewrite tags in .scope files"
+complete -c scope -n "__fish_seen_subcommand_from rename" -l merge-if-exists -d "Merge into the new tag if it exists"
+
+complete -c scope -n "__fish_seen_subcommand_from migrate; and not __fish_seen_subcommand_from export apply" -a "export apply"
+complete -c scope -n "__fish_seen_subcommand_from apply" -l dry-run -d "Only show what would be done"
+complete -c scope -n "__fish_seen_subcommand_from apply" -l no-clone -d "Don't clone missing repositories"
+complete -c scope -n "__fish_seen_subcommand_from apply" -l no-shell -d "Don't touch the shell startup file"
 
 # File completion for import
 complete -c scope -n "__fish_seen_subcommand_from import" -a "(__fish_complete_suffix .yml .yaml)"
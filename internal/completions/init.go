@@ -0,0 +1,140 @@
+package completions
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bashInit is the bash integration: the sg wrapper, the scope start
+// wrapper that follows sessions.exit, and a prompt hook that asks 'scope
+// hint' about each new directory and records it for 'scope time'
+const bashInit = `# Scope shell integration for bash
+# Add to ~/.bashrc: eval "$(scope init bash)"
+
+sg() { cd "$(scope go "$@")" 2>/dev/null || scope go "$@"; }
+
+# scope start runs a shell; when it ends, cd where sessions.exit says.
+# scope ui cds to the folder jumped to the same way.
+scope() {
+    if [ "${1:-}" = start ] || [ "${1:-}" = ui ]; then
+        local exit_file ret dir
+        exit_file=$(mktemp "${TMPDIR:-/tmp}/scope-exit.XXXXXX") || { command scope "$@"; return; }
+        SCOPE_EXIT_FILE="$exit_file" command scope "$@"
+        ret=$?
+        dir=$(cat "$exit_file" 2>/dev/null)
+        rm -f "$exit_file"
+        if [ -n "$dir" ] && [ "$dir" != "$PWD" ]; then
+            cd "$dir" || true
+        fi
+        return $ret
+    fi
+    command scope "$@"
+}
+
+_scope_hook() {
+    local status=$?
+    if [ "$PWD" != "${_scope_last_pwd:-}" ]; then
+        _scope_last_pwd="$PWD"
+        scope hint 2>/dev/null
+        scope time --record 2>/dev/null
+    fi
+    return $status
+}
+
+case ";${PROMPT_COMMAND:-};" in
+    *";_scope_hook;"*) ;;
+    *) PROMPT_COMMAND="_scope_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}" ;;
+esac
+
+# End the current folder visit when the shell exits, unless another EXIT
+# trap is set
+[ -n "$(trap -p EXIT)" ] || trap 'scope time --record --exit 2>/dev/null' EXIT
+`
+
+// zshInit is the zsh integration: the sg and scope start wrappers, a chpwd
+// hook and an exit hook
+const zshInit = `# Scope shell integration for zsh
+# Add to ~/.zshrc: eval "$(scope init zsh)"
+
+sg() { cd "$(scope go "$@")" 2>/dev/null || scope go "$@"; }
+
+# scope start runs a shell; when it ends, cd where sessions.exit says.
+# scope ui cds to the folder jumped to the same way.
+scope() {
+    if [ "${1:-}" = start ] || [ "${1:-}" = ui ]; then
+        local exit_file ret dir
+        exit_file=$(mktemp "${TMPDIR:-/tmp}/scope-exit.XXXXXX") || { command scope "$@"; return; }
+        SCOPE_EXIT_FILE="$exit_file" command scope "$@"
+        ret=$?
+        dir=$(cat "$exit_file" 2>/dev/null)
+        rm -f "$exit_file"
+        if [ -n "$dir" ] && [ "$dir" != "$PWD" ]; then
+            cd "$dir" || true
+        fi
+        return $ret
+    fi
+    command scope "$@"
+}
+
+_scope_hook() {
+    scope hint 2>/dev/null
+    scope time --record 2>/dev/null
+}
+
+_scope_exit() { scope time --record --exit 2>/dev/null }
+
+autoload -Uz add-zsh-hook
+add-zsh-hook chpwd _scope_hook
+add-zsh-hook zshexit _scope_exit
+`
+
+// fishInit is the fish integration: the sg and scope start wrappers, a PWD
+// watcher and an exit handler
+const fishInit = `# Scope shell integration for fish
+# Add to ~/.config/fish/config.fish: scope init fish | source
+
+function sg
+    set -l dir (scope go $argv); and cd $dir
+end
+
+# scope start runs a shell; when it ends, cd where sessions.exit says.
+# scope ui cds to the folder jumped to the same way.
+function scope
+    if contains -- "$argv[1]" start ui
+        set -l exit_file (mktemp); or begin; command scope $argv; return; end
+        env SCOPE_EXIT_FILE=$exit_file scope $argv
+        set -l ret $status
+        set -l dir (cat $exit_file 2>/dev/null)
+        rm -f $exit_file
+        if test -n "$dir"; and test "$dir" != "$PWD"
+            cd $dir
+        end
+        return $ret
+    end
+    command scope $argv
+end
+
+function __scope_hook --on-variable PWD
+    status is-command-substitution; and return
+    scope hint 2>/dev/null
+    scope time --record 2>/dev/null
+end
+
+function __scope_exit --on-event fish_exit
+    scope time --record --exit 2>/dev/null
+end
+`
+
+// Init returns the shell integration script for the given shell
+func Init(shell string) (string, error) {
+	switch strings.ToLower(shell) {
+	case "bash":
+		return bashInit, nil
+	case "zsh":
+		return zshInit, nil
+	case "fish":
+		return fishInit, nil
+	default:
+		return "", fmt.Errorf("unsupported shell: %s (supported: bash, zsh, fish)", shell)
+	}
+}
@@ -0,0 +1,807 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gabssanto/Scope/internal/backup"
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/finder"
+	"github.com/gabssanto/Scope/internal/forge"
+	"github.com/gabssanto/Scope/internal/httpcache"
+	"github.com/gabssanto/Scope/internal/incident"
+	"github.com/gabssanto/Scope/internal/paths"
+	"github.com/gabssanto/Scope/internal/session"
+	"github.com/gabssanto/Scope/internal/shell"
+	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// Config represents the global configuration file (~/.config/scope/config.yml)
+type Config struct {
+	Database  DatabaseConfig  `yaml:"database"`
+	Paths     PathsConfig     `yaml:"paths"`
+	Tags      TagsConfig      `yaml:"tags"`
+	Sync      SyncConfig      `yaml:"sync"`
+	Hints     HintsConfig     `yaml:"hints"`
+	Events    EventsConfig    `yaml:"events"`
+	Time      TimeConfig      `yaml:"time"`
+	Trash     TrashConfig     `yaml:"trash"`
+	Snapshots SnapshotsConfig `yaml:"snapshots"`
+	Sessions  SessionsConfig  `yaml:"sessions"`
+	Incident  IncidentConfig  `yaml:"incident"`
+	Forges    ForgesConfig    `yaml:"forges"`
+	API       APIConfig       `yaml:"api"`
+	UI        UIConfig        `yaml:"ui"`
+	Finder    FinderConfig    `yaml:"finder"`
+	Server    ServerConfig    `yaml:"server"`
+	Watch     WatchConfig     `yaml:"watch"`
+	Opener    OpenerConfig    `yaml:"opener"`
+	Editor    EditorConfig    `yaml:"editor"`
+	// Network is "on" (default) or "off", which works offline like the
+	// --offline flag
+	Network string `yaml:"network"`
+	// Backups are the backup destinations of tags, by tag name
+	Backups map[string]BackupConfig `yaml:"backups"`
+	// Aliases are user-defined commands, by name: scope arguments
+	// (st: status --dirty-only), or a shell command when they start with !
+	Aliases map[string]string `yaml:"aliases"`
+}
+
+// DatabaseConfig tunes the SQLite connection pools
+type DatabaseConfig struct {
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	MaxReadConns    int           `yaml:"max_read_conns"`
+	// ReadOnly refuses every change to the database
+	ReadOnly bool `yaml:"read_only"`
+}
+
+// PathsConfig controls how tagged paths are stored
+type PathsConfig struct {
+	// Symlinks is "resolve" (default) or "keep"
+	Symlinks string `yaml:"symlinks"`
+}
+
+// TagsConfig controls how tags are presented
+type TagsConfig struct {
+	Categories []CategoryConfig `yaml:"categories"`
+}
+
+// CategoryConfig groups tags with a common prefix under a heading
+type CategoryConfig struct {
+	Prefix string `yaml:"prefix"`
+	Title  string `yaml:"title"`
+	Color  string `yaml:"color"`
+}
+
+// SyncConfig enables the change journal used to sync machines
+type SyncConfig struct {
+	// Dir is a directory shared between machines by a sync tool; journaling
+	// is off when it is empty
+	Dir string `yaml:"dir"`
+	// Machine names this machine's journal (default: the host name)
+	Machine string `yaml:"machine"`
+}
+
+// HintsConfig controls the tagging hints shown by the shell hook
+type HintsConfig struct {
+	// Disabled turns hints off
+	Disabled bool `yaml:"disabled"`
+	// Roots are the directories whose repositories are hinted about
+	// (default: the parent directories of tagged folders)
+	Roots []string `yaml:"roots"`
+	// Interval is the minimum time between two hints (default 1h)
+	Interval time.Duration `yaml:"interval"`
+}
+
+// EventsConfig publishes scope activity to other programs
+type EventsConfig struct {
+	// Socket is a unix socket or named pipe each event is written to as a
+	// line of JSON
+	Socket string `yaml:"socket"`
+	// Webhooks are URLs each event is POSTed to as JSON
+	Webhooks []string `yaml:"webhooks"`
+	// Timeout bounds each delivery (default 2s)
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// TimeConfig controls time tracking
+type TimeConfig struct {
+	// Disabled stops recording sessions and folder visits
+	Disabled bool `yaml:"disabled"`
+	// MaxVisit caps a single folder visit (default 2h)
+	MaxVisit time.Duration `yaml:"max_visit"`
+}
+
+// TrashConfig controls the trash that deleted tags and folders go to
+type TrashConfig struct {
+	// Disabled deletes tags and folders for good right away
+	Disabled bool `yaml:"disabled"`
+	// Retention is how long they can be restored (default 720h)
+	Retention time.Duration `yaml:"retention"`
+}
+
+// ForgesConfig names the forge of self-hosted git servers, by host
+// (git.acme.com: gitlab). github.com, gitlab.com and bitbucket.org are
+// always known.
+type ForgesConfig map[string]string
+
+// APIConfig controls the requests to forge APIs made by ci, prs, enrich
+// and the update check
+type APIConfig struct {
+	// NoCache stops keeping responses on disk
+	NoCache bool `yaml:"no_cache"`
+	// MaxAge is how long a cached response is used without asking the
+	// forge again (default 1m); older ones are revalidated by ETag
+	MaxAge time.Duration `yaml:"max_age"`
+	// MaxWait is the longest to wait for a rate limit to reset (default 1m)
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+// UIConfig controls how scope presents itself
+type UIConfig struct {
+	// Accessible drops colors and replaces interactive forms and the
+	// picker with plain prompts, for screen readers and dumb terminals
+	Accessible bool `yaml:"accessible"`
+}
+
+// ServerConfig controls scope serve
+type ServerConfig struct {
+	// Addr is the host:port to listen on (default 127.0.0.1:7474)
+	Addr string `yaml:"addr"`
+	// Origins are the browser origins allowed to call the API, such as
+	// chrome-extension://<id>; "*" allows any
+	Origins []string `yaml:"origins"`
+}
+
+// WatchConfig controls scope watch
+type WatchConfig struct {
+	// Roots are the directories watched when none is given
+	Roots []string `yaml:"roots"`
+	// Interval is the time between two checks when polling (default 10s)
+	Interval time.Duration `yaml:"interval"`
+	// Depth is how many levels below a root are looked at (default 5)
+	Depth int `yaml:"depth"`
+	// Metrics is a host:port to serve /healthz and /metrics on; none when
+	// empty
+	Metrics string `yaml:"metrics"`
+}
+
+// OpenerConfig replaces the file manager scope open starts with a
+// command, where {path} stands for the folder; the folder is appended
+// when the command leaves it out
+type OpenerConfig struct {
+	// Command is used on the platforms without a command of their own
+	Command string `yaml:"command"`
+	Darwin  string `yaml:"darwin"`
+	Linux   string `yaml:"linux"`
+	Windows string `yaml:"windows"`
+	// Terminal runs the command in scope's terminal and waits for it to
+	// exit, for terminal file managers such as ranger and nnn
+	Terminal bool `yaml:"terminal"`
+}
+
+// EditorConfig controls how scope edit starts the editor
+type EditorConfig struct {
+	// Command is the editor, in place of $VISUAL and $EDITOR. It may
+	// include arguments and {path}, standing for the folder, which is
+	// appended when left out (subl -n {path}).
+	Command string `yaml:"command"`
+	// Editors are settings by editor program name (nvim, subl), whichever
+	// way the editor was chosen
+	Editors map[string]EditorSettings `yaml:"editors"`
+}
+
+// EditorSettings are how one editor is started
+type EditorSettings struct {
+	// Args are added after the editor's command, with {path} as in
+	// EditorConfig.Command
+	Args string `yaml:"args"`
+	// Wait runs the editor in scope's terminal and waits for it to exit,
+	// rather than starting it in the background. It is the default for
+	// terminal editors such as vim, nvim and nano.
+	Wait *bool `yaml:"wait"`
+}
+
+// terminalEditors are the editors that run in the terminal, which scope
+// edit waits for unless configured otherwise
+var terminalEditors = map[string]bool{
+	"vi": true, "vim": true, "nvim": true, "nano": true, "hx": true, "helix": true,
+	"micro": true, "kak": true, "mg": true, "ne": true, "joe": true,
+}
+
+// FinderConfig shows tags as macOS Finder tags
+type FinderConfig struct {
+	// Auto adds and removes Finder tags as mapped tags are added to and
+	// removed from folders
+	Auto bool `yaml:"auto"`
+	// Tags maps scope tags to Finder tags; only these are synced
+	Tags map[string]FinderTagConfig `yaml:"tags"`
+}
+
+// FinderTagConfig is the Finder tag a scope tag is shown as
+type FinderTagConfig struct {
+	// Name is the Finder tag's name (default: the scope tag)
+	Name string `yaml:"name"`
+	// Color is one of Finder's colors: none (default), gray, green,
+	// purple, blue, yellow, red or orange
+	Color string `yaml:"color"`
+}
+
+// SnapshotsConfig controls scope snapshot
+type SnapshotsConfig struct {
+	// Dir is where snapshots are written (default ~/.config/scope/snapshots)
+	Dir string `yaml:"dir"`
+	// Exclude names more directories to leave out, on top of the build
+	// artifact directories that are always skipped
+	Exclude []string `yaml:"exclude"`
+}
+
+// IncidentConfig controls scope incident
+type IncidentConfig struct {
+	// Clone is the URL services that aren't tagged anywhere are cloned
+	// from, with {name} standing for the service
+	// (git@github.com:acme/{name}.git)
+	Clone string `yaml:"clone"`
+	// Dir is where they are cloned (default the current directory)
+	Dir string `yaml:"dir"`
+	// Archive is where ended incidents are written (default
+	// ~/.config/scope/incidents)
+	Archive string `yaml:"archive"`
+}
+
+// SessionsConfig controls the workspaces of scope start
+type SessionsConfig struct {
+	// Files are generated at the root of each session workspace
+	Files []SessionFileConfig `yaml:"files"`
+	// Exit is where the shell integration goes when a session ends:
+	// origin (default) or first, the tag's first folder
+	Exit string `yaml:"exit"`
+	// Nesting is what scope start does inside another session: nest
+	// (default), deny or replace
+	Nesting string `yaml:"nesting"`
+	// Record keeps the commands run in each session for scope session log
+	Record bool `yaml:"record"`
+}
+
+// SessionFileConfig is a file generated in session workspaces, from a
+// template file or inline content
+type SessionFileConfig struct {
+	// Path is relative to the workspace, e.g. .vscode/settings.json
+	Path     string `yaml:"path"`
+	Template string `yaml:"template"`
+	Content  string `yaml:"content"`
+	// Tags limits the file to sessions of these tags (default: all)
+	Tags []string `yaml:"tags"`
+}
+
+// BackupConfig is where scope backup-folders copies a tag's folders
+type BackupConfig struct {
+	// Dest is a directory, an rsync host:path or an rclone remote:path
+	Dest string `yaml:"dest"`
+	// Tool is rsync (default) or rclone
+	Tool    string   `yaml:"tool"`
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Delete removes files from the backup that were deleted locally
+	Delete bool `yaml:"delete"`
+}
+
+// Dir returns the scope config directory
+func Dir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "scope"), nil
+}
+
+// Path returns the path to the config file
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yml"), nil
+}
+
+// Default returns the configuration used when no config file exists
+func Default() *Config {
+	return &Config{}
+}
+
+// Load reads the config file, falling back to defaults if it doesn't exist
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads the config from a specific file
+func LoadFile(path string) (*Config, error) {
+	cfg := Default()
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	if _, err := paths.ParseSymlinkPolicy(cfg.Paths.Symlinks); err != nil {
+		return nil, fmt.Errorf("invalid config %s: paths.symlinks: %w", path, err)
+	}
+
+	for i, c := range cfg.Tags.Categories {
+		if c.Prefix == "" {
+			return nil, fmt.Errorf("invalid config %s: tags.categories[%d]: prefix is required", path, i)
+		}
+		if _, ok := ui.ColorCode(c.Color); c.Color != "" && !ok {
+			return nil, fmt.Errorf("invalid config %s: tags.categories[%d]: unknown color %q (expected one of %s)",
+				path, i, c.Color, strings.Join(ui.ColorNames(), ", "))
+		}
+	}
+
+	for i, hook := range cfg.Events.Webhooks {
+		u, err := url.Parse(hook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid config %s: events.webhooks[%d]: expected an http(s) URL, got %q", path, i, hook)
+		}
+	}
+
+	for i, f := range cfg.Sessions.Files {
+		if err := session.ValidFilePath(f.Path); err != nil {
+			return nil, fmt.Errorf("invalid config %s: sessions.files[%d]: %w", path, i, err)
+		}
+		if (f.Template == "") == (f.Content == "") {
+			return nil, fmt.Errorf("invalid config %s: sessions.files[%d]: set one of template or content", path, i)
+		}
+	}
+
+	if _, err := session.ParseExitDir(cfg.Sessions.Exit); err != nil {
+		return nil, fmt.Errorf("invalid config %s: sessions.exit: %w", path, err)
+	}
+	if _, err := session.ParseNesting(cfg.Sessions.Nesting); err != nil {
+		return nil, fmt.Errorf("invalid config %s: sessions.nesting: %w", path, err)
+	}
+	for host, kind := range cfg.Forges {
+		if _, err := forge.ParseKind(kind); err != nil {
+			return nil, fmt.Errorf("invalid config %s: forges.%s: %w", path, host, err)
+		}
+	}
+
+	switch cfg.Network {
+	case "", "on", "off":
+	default:
+		return nil, fmt.Errorf("invalid config %s: network: expected on or off, got %q", path, cfg.Network)
+	}
+
+	if cfg.Trash.Retention < 0 {
+		return nil, fmt.Errorf("invalid config %s: trash.retention: must not be negative", path)
+	}
+
+	if cfg.API.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid config %s: api.max_age: must not be negative", path)
+	}
+	if cfg.API.MaxWait < 0 {
+		return nil, fmt.Errorf("invalid config %s: api.max_wait: must not be negative", path)
+	}
+
+	for name, b := range cfg.Backups {
+		if b.Dest == "" {
+			return nil, fmt.Errorf("invalid config %s: backups.%s: dest is required", path, name)
+		}
+		if _, err := backup.ParseTool(b.Tool); err != nil {
+			return nil, fmt.Errorf("invalid config %s: backups.%s.tool: %w", path, name, err)
+		}
+	}
+
+	if cfg.Server.Addr != "" {
+		if _, _, err := net.SplitHostPort(cfg.Server.Addr); err != nil {
+			return nil, fmt.Errorf("invalid config %s: server.addr: %w", path, err)
+		}
+	}
+	for i, origin := range cfg.Server.Origins {
+		if origin == "*" {
+			continue
+		}
+		// An origin is a scheme and host, without a path or trailing slash
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return nil, fmt.Errorf("invalid config %s: server.origins[%d]: expected scheme://host[:port], got %q", path, i, origin)
+		}
+	}
+
+	if cfg.Watch.Interval < 0 {
+		return nil, fmt.Errorf("invalid config %s: watch.interval: must not be negative", path)
+	}
+	if cfg.Watch.Depth < 0 {
+		return nil, fmt.Errorf("invalid config %s: watch.depth: must not be negative", path)
+	}
+	if cfg.Watch.Metrics != "" {
+		if _, _, err := net.SplitHostPort(cfg.Watch.Metrics); err != nil {
+			return nil, fmt.Errorf("invalid config %s: watch.metrics: %w", path, err)
+		}
+	}
+
+	for _, goos := range []string{"", "darwin", "linux", "windows"} {
+		if _, err := cfg.Opener.Args(goos, "."); err != nil {
+			key := "command"
+			if goos != "" {
+				key = goos
+			}
+			return nil, fmt.Errorf("invalid config %s: opener.%s: %w", path, key, err)
+		}
+	}
+
+	if cfg.Editor.Command != "" {
+		if _, _, err := cfg.Editor.Args(cfg.Editor.Command, "."); err != nil {
+			return nil, fmt.Errorf("invalid config %s: editor.command: %w", path, err)
+		}
+	}
+	for name, settings := range cfg.Editor.Editors {
+		if _, err := shell.Split(settings.Args); err != nil {
+			return nil, fmt.Errorf("invalid config %s: editor.editors.%s.args: %w", path, name, err)
+		}
+	}
+
+	finderNames := make(map[string]string)
+	for _, name := range slices.Sorted(maps.Keys(cfg.Finder.Tags)) {
+		t := cfg.Finder.Tags[name]
+		if _, err := finder.ParseColor(t.Color); err != nil {
+			return nil, fmt.Errorf("invalid config %s: finder.tags.%s.color: %w", path, name, err)
+		}
+		ft := cfg.Finder.Mapping()[name]
+		if strings.ContainsAny(ft.Name, "\n") {
+			return nil, fmt.Errorf("invalid config %s: finder.tags.%s.name: must be a single line", path, name)
+		}
+		// Finder tag names are not case sensitive
+		if other, ok := finderNames[strings.ToLower(ft.Name)]; ok {
+			return nil, fmt.Errorf("invalid config %s: finder.tags: %s and %s are both shown as %q", path, other, name, ft.Name)
+		}
+		finderNames[strings.ToLower(ft.Name)] = name
+	}
+
+	for name := range cfg.Aliases {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t\n") {
+			return nil, fmt.Errorf("invalid config %s: aliases: invalid name %q", path, name)
+		}
+		if _, _, err := cfg.Alias(name); err != nil {
+			return nil, fmt.Errorf("invalid config %s: aliases.%s: %w", path, name, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Offline reports whether the network is turned off in the config
+func (c *Config) Offline() bool {
+	return c.Network == "off"
+}
+
+// Alias returns the arguments an alias stands for, or for an alias
+// starting with ! the shell command it runs. Both are empty when name is
+// not an alias.
+func (c *Config) Alias(name string) ([]string, string, error) {
+	def, ok := c.Aliases[name]
+	if !ok {
+		return nil, "", nil
+	}
+	if command, ok := strings.CutPrefix(strings.TrimSpace(def), "!"); ok {
+		if strings.TrimSpace(command) == "" {
+			return nil, "", fmt.Errorf("empty shell command")
+		}
+		return nil, command, nil
+	}
+	args, err := shell.Split(def)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(args) == 0 {
+		return nil, "", fmt.Errorf("empty alias")
+	}
+	return args, "", nil
+}
+
+// PoolOptions converts the database section into db pool options
+func (c DatabaseConfig) PoolOptions() db.PoolOptions {
+	return db.PoolOptions{
+		MaxOpenConns:    c.MaxOpenConns,
+		MaxIdleConns:    c.MaxIdleConns,
+		ConnMaxLifetime: c.ConnMaxLifetime,
+		MaxReadConns:    c.MaxReadConns,
+		ReadOnly:        c.ReadOnly,
+	}
+}
+
+// SymlinkPolicy returns the configured symlink policy
+func (c PathsConfig) SymlinkPolicy() paths.SymlinkPolicy {
+	// LoadFile has already validated the name
+	policy, _ := paths.ParseSymlinkPolicy(c.Symlinks)
+	return policy
+}
+
+// Args returns the command that opens path on goos (a runtime.GOOS), or
+// nil when none is configured for it and the system's file manager is used
+func (c OpenerConfig) Args(goos, path string) ([]string, error) {
+	command := map[string]string{"darwin": c.Darwin, "linux": c.Linux, "windows": c.Windows}[goos]
+	if command == "" {
+		command = c.Command
+	}
+	if command == "" {
+		return nil, nil
+	}
+	words, err := shell.Split(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 || words[0] == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+	templated := false
+	for i, word := range words {
+		if strings.Contains(word, "{path}") {
+			words[i] = strings.ReplaceAll(word, "{path}", path)
+			templated = true
+		}
+	}
+	if !templated {
+		words = append(words, path)
+	}
+	return words, nil
+}
+
+// Args returns the command that opens path in editor (such as $EDITOR),
+// with the arguments configured for it, and whether to wait for it
+func (c EditorConfig) Args(editor, path string) ([]string, bool, error) {
+	words, err := shell.Split(editor)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(words) == 0 || words[0] == "" {
+		return nil, false, fmt.Errorf("empty editor command")
+	}
+	program := strings.TrimSuffix(filepath.Base(words[0]), ".exe")
+	settings := c.Editors[program]
+	if settings.Args != "" {
+		args, err := shell.Split(settings.Args)
+		if err != nil {
+			return nil, false, err
+		}
+		words = append(words, args...)
+	}
+
+	templated := false
+	for i, word := range words {
+		if strings.Contains(word, "{path}") {
+			words[i] = strings.ReplaceAll(word, "{path}", path)
+			templated = true
+		}
+	}
+	if !templated {
+		words = append(words, path)
+	}
+
+	wait := terminalEditors[program]
+	if settings.Wait != nil {
+		wait = *settings.Wait
+	}
+	return words, wait, nil
+}
+
+// Enabled reports whether journaling is configured
+func (c SyncConfig) Enabled() bool {
+	return c.Dir != ""
+}
+
+// JournalDir returns the sync directory with ~ and variables expanded
+func (c SyncConfig) JournalDir() (string, error) {
+	return paths.Resolve(c.Dir)
+}
+
+// Enabled reports whether any event destination is configured
+func (c EventsConfig) Enabled() bool {
+	return c.Socket != "" || len(c.Webhooks) > 0
+}
+
+// SocketPath returns the socket with ~ and variables expanded, or "" when
+// none is configured
+func (c EventsConfig) SocketPath() (string, error) {
+	if c.Socket == "" {
+		return "", nil
+	}
+	return paths.Resolve(c.Socket)
+}
+
+// TagCategories converts the configured categories for tag.GroupTags
+func (c TagsConfig) TagCategories() []tag.Category {
+	categories := make([]tag.Category, len(c.Categories))
+	for i, cat := range c.Categories {
+		categories[i] = tag.Category{Prefix: cat.Prefix, Title: cat.Title, Color: cat.Color}
+	}
+	return categories
+}
+
+// ResolvedRoots returns the hint roots with ~ and variables expanded
+func (c HintsConfig) ResolvedRoots() ([]string, error) {
+	roots := make([]string, 0, len(c.Roots))
+	for _, root := range c.Roots {
+		resolved, err := paths.Resolve(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hints root %s: %w", root, err)
+		}
+		roots = append(roots, resolved)
+	}
+	return roots, nil
+}
+
+// ResolvedRoots returns the watched roots with ~ and variables expanded
+func (c WatchConfig) ResolvedRoots() ([]string, error) {
+	roots := make([]string, 0, len(c.Roots))
+	for _, root := range c.Roots {
+		resolved, err := paths.Resolve(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watch root %s: %w", root, err)
+		}
+		roots = append(roots, resolved)
+	}
+	return roots, nil
+}
+
+// Directory returns the snapshot directory with ~ and variables expanded
+func (c SnapshotsConfig) Directory() (string, error) {
+	if c.Dir == "" {
+		dir, err := Dir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "snapshots"), nil
+	}
+	return paths.Resolve(c.Dir)
+}
+
+// Registry returns the forges of the public hosts and the configured ones
+func (c ForgesConfig) Registry() *forge.Registry {
+	hosts := make(map[string]forge.Kind, len(c))
+	for host, kind := range c {
+		// LoadFile has already validated the kind
+		hosts[strings.ToLower(host)], _ = forge.ParseKind(kind)
+	}
+	return &forge.Registry{Hosts: hosts}
+}
+
+// CacheOptions returns the options of the API response cache, kept in
+// ~/.config/scope/cache
+func (c APIConfig) CacheOptions() (httpcache.Options, error) {
+	opts := httpcache.Options{MaxAge: c.MaxAge, MaxWait: c.MaxWait}
+	if opts.MaxAge == 0 {
+		opts.MaxAge = time.Minute
+	}
+	if c.NoCache {
+		return opts, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return opts, err
+	}
+	opts.Dir = filepath.Join(dir, "cache")
+	return opts, nil
+}
+
+// Options returns the incident options with ~ and variables expanded
+func (c IncidentConfig) Options() (incident.Options, error) {
+	dir := c.Dir
+	if dir == "" {
+		dir = "."
+	}
+	resolved, err := paths.Resolve(dir)
+	if err != nil {
+		return incident.Options{}, err
+	}
+	return incident.Options{CloneURL: c.Clone, Dir: resolved}, nil
+}
+
+// ArchiveDir returns where ended incidents are written
+func (c IncidentConfig) ArchiveDir() (string, error) {
+	if c.Archive == "" {
+		dir, err := Dir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "incidents"), nil
+	}
+	return paths.Resolve(c.Archive)
+}
+
+// Target converts a backup section, expanding ~ and variables in local
+// destinations
+func (c BackupConfig) Target() (backup.Target, error) {
+	t := backup.Target{Dest: c.Dest, Tool: c.Tool, Include: c.Include, Exclude: c.Exclude, Delete: c.Delete}
+	if t.Local() {
+		dest, err := paths.Resolve(c.Dest)
+		if err != nil {
+			return backup.Target{}, err
+		}
+		t.Dest = dest
+	}
+	return t, nil
+}
+
+// ExitDir returns where to go when a session ends
+func (c SessionsConfig) ExitDir() session.ExitDir {
+	dir, _ := session.ParseExitDir(c.Exit)
+	return dir
+}
+
+// HistoryDir returns where recorded sessions are kept
+func (c SessionsConfig) HistoryDir() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sessions"), nil
+}
+
+// NestingPolicy returns what to do when starting a session inside another
+func (c SessionsConfig) NestingPolicy() session.Nesting {
+	nesting, _ := session.ParseNesting(c.Nesting)
+	return nesting
+}
+
+// WorkspaceFiles returns the files to generate in a session of tagName,
+// reading template files
+func (c SessionsConfig) WorkspaceFiles(tagName string) ([]session.File, error) {
+	var files []session.File
+	for _, f := range c.Files {
+		if len(f.Tags) > 0 && !slices.Contains(f.Tags, tagName) {
+			continue
+		}
+		content := f.Content
+		if f.Template != "" {
+			path, err := paths.Resolve(f.Template)
+			if err != nil {
+				return nil, err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read template for %s: %w", f.Path, err)
+			}
+			content = string(data)
+		}
+		files = append(files, session.File{Path: f.Path, Template: content})
+	}
+	return files, nil
+}
+
+// Mapping converts the configured tags for finder.Sync. Colors have
+// already been validated by LoadFile.
+func (c FinderConfig) Mapping() finder.Mapping {
+	mapping := make(finder.Mapping, len(c.Tags))
+	for name, t := range c.Tags {
+		ft := finder.Tag{Name: t.Name}
+		if ft.Name == "" {
+			ft.Name = name
+		}
+		ft.Color, _ = finder.ParseColor(t.Color)
+		mapping[name] = ft
+	}
+	return mapping
+}
@@ -0,0 +1,659 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/finder"
+	"github.com/gabssanto/Scope/internal/forge"
+	"github.com/gabssanto/Scope/internal/paths"
+)
+
+func TestLoadFileMissing(t *testing.T) {
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("LoadFile failed for missing file: %v", err)
+	}
+	if cfg.Database.MaxOpenConns != 0 {
+		t.Errorf("Expected default MaxOpenConns 0, got %d", cfg.Database.MaxOpenConns)
+	}
+}
+
+func TestLoadFileDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := `database:
+  max_open_conns: 8
+  max_idle_conns: 2
+  conn_max_lifetime: 5m
+  max_read_conns: 16
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	opts := cfg.Database.PoolOptions()
+	if opts.MaxOpenConns != 8 || opts.MaxIdleConns != 2 || opts.MaxReadConns != 16 {
+		t.Errorf("Unexpected pool options: %+v", opts)
+	}
+	if opts.ConnMaxLifetime != 5*time.Minute {
+		t.Errorf("Expected ConnMaxLifetime 5m, got %v", opts.ConnMaxLifetime)
+	}
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("database: [broken"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile should fail for invalid YAML")
+	}
+}
+
+func TestLoadFileSymlinkPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("paths:\n  symlinks: keep\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.Paths.SymlinkPolicy() != paths.SymlinkKeep {
+		t.Errorf("Expected keep policy, got %q", cfg.Paths.SymlinkPolicy())
+	}
+
+	if Default().Paths.SymlinkPolicy() != paths.SymlinkResolve {
+		t.Errorf("Expected resolve to be the default policy")
+	}
+
+	if err := os.WriteFile(path, []byte("paths:\n  symlinks: follow\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile should reject an unknown symlink policy")
+	}
+}
+
+func TestLoadFileSync(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("sync:\n  dir: ~/Dropbox/scope\n  machine: laptop\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !cfg.Sync.Enabled() || cfg.Sync.Machine != "laptop" {
+		t.Errorf("Unexpected sync config %+v", cfg.Sync)
+	}
+	dir, err := cfg.Sync.JournalDir()
+	if err != nil {
+		t.Fatalf("JournalDir failed: %v", err)
+	}
+	if dir != filepath.Join(home, "Dropbox", "scope") {
+		t.Errorf("Expected expanded journal dir, got %s", dir)
+	}
+
+	if Default().Sync.Enabled() {
+		t.Error("Sync should be off by default")
+	}
+}
+
+func TestLoadFileHints(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("hints:\n  roots: [~/code]\n  interval: 30m\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.Hints.Disabled || cfg.Hints.Interval != 30*time.Minute {
+		t.Errorf("Unexpected hints config %+v", cfg.Hints)
+	}
+	roots, err := cfg.Hints.ResolvedRoots()
+	if err != nil {
+		t.Fatalf("ResolvedRoots failed: %v", err)
+	}
+	if len(roots) != 1 || roots[0] != filepath.Join(home, "code") {
+		t.Errorf("Expected expanded roots, got %v", roots)
+	}
+}
+
+func TestLoadFileEvents(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "events:\n  socket: ~/.scope.sock\n  webhooks: [https://example.com/hook]\n  timeout: 500ms\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if !cfg.Events.Enabled() || cfg.Events.Timeout != 500*time.Millisecond {
+		t.Errorf("Unexpected events config %+v", cfg.Events)
+	}
+	socket, err := cfg.Events.SocketPath()
+	if err != nil {
+		t.Fatalf("SocketPath failed: %v", err)
+	}
+	if socket != filepath.Join(home, ".scope.sock") {
+		t.Errorf("Expected expanded socket, got %s", socket)
+	}
+
+	if Default().Events.Enabled() {
+		t.Error("Events should be off by default")
+	}
+
+	if err := os.WriteFile(path, []byte("events:\n  webhooks: [example.com/hook]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "events.webhooks[0]") {
+		t.Errorf("Expected an invalid webhook error, got %v", err)
+	}
+}
+
+func TestLoadFileTagCategories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := `tags:
+  categories:
+    - prefix: "client:"
+      title: Clients
+      color: magenta
+    - prefix: "lang:"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	categories := cfg.Tags.TagCategories()
+	if len(categories) != 2 {
+		t.Fatalf("Expected 2 categories, got %d", len(categories))
+	}
+	if categories[0].Prefix != "client:" || categories[0].Title != "Clients" || categories[0].Color != "magenta" {
+		t.Errorf("Unexpected first category: %+v", categories[0])
+	}
+
+	invalid := []string{
+		"tags:\n  categories:\n    - title: No prefix\n",
+		"tags:\n  categories:\n    - prefix: \"x:\"\n      color: chartreuse\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileSnapshots(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir, err := Default().Snapshots.Directory()
+	if err != nil {
+		t.Fatalf("Directory failed: %v", err)
+	}
+	if dir != filepath.Join(home, ".config", "scope", "snapshots") {
+		t.Errorf("Unexpected default snapshot dir %s", dir)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("snapshots:\n  dir: ~/backups\n  exclude: [tmp]\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	dir, err = cfg.Snapshots.Directory()
+	if err != nil {
+		t.Fatalf("Directory failed: %v", err)
+	}
+	if dir != filepath.Join(home, "backups") || len(cfg.Snapshots.Exclude) != 1 {
+		t.Errorf("Unexpected snapshots config %+v (dir %s)", cfg.Snapshots, dir)
+	}
+}
+
+func TestLoadFileBackups(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "backups:\n  work:\n    dest: ~/nas/work\n    exclude: [node_modules]\n  photos:\n    dest: \"gdrive:photos\"\n    tool: rclone\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	work, err := cfg.Backups["work"].Target()
+	if err != nil {
+		t.Fatalf("Target failed: %v", err)
+	}
+	if work.Dest != filepath.Join(home, "nas", "work") || len(work.Exclude) != 1 {
+		t.Errorf("Unexpected work target %+v", work)
+	}
+	photos, err := cfg.Backups["photos"].Target()
+	if err != nil {
+		t.Fatalf("Target failed: %v", err)
+	}
+	if photos.Dest != "gdrive:photos" || photos.Tool != "rclone" {
+		t.Errorf("Unexpected photos target %+v", photos)
+	}
+
+	invalid := []string{
+		"backups:\n  work:\n    exclude: [tmp]\n",
+		"backups:\n  work:\n    dest: /mnt\n    tool: scp\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileFinder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "finder:\n  auto: true\n  tags:\n    work:\n      color: red\n    client-a:\n      name: Client A\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	mapping := cfg.Finder.Mapping()
+	if !cfg.Finder.Auto || mapping["work"] != (finder.Tag{Name: "work", Color: finder.Red}) ||
+		mapping["client-a"] != (finder.Tag{Name: "Client A"}) {
+		t.Errorf("Unexpected mapping %+v", mapping)
+	}
+
+	invalid := []string{
+		"finder:\n  tags:\n    work:\n      color: pink\n",
+		"finder:\n  tags:\n    work: {}\n    job:\n      name: Work\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "server:\n  addr: 127.0.0.1:8080\n  origins: [chrome-extension://abcdef, http://localhost:3000]\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.Server.Addr != "127.0.0.1:8080" || len(cfg.Server.Origins) != 2 {
+		t.Errorf("Unexpected server config %+v", cfg.Server)
+	}
+
+	invalid := []string{
+		"server:\n  addr: localhost\n",
+		"server:\n  origins: [http://localhost:3000/]\n",
+		"server:\n  origins: [localhost]\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileWatch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, "config.yml")
+	content := "watch:\n  roots: [~/src]\n  interval: 30s\n  depth: 3\n  metrics: 127.0.0.1:7475\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	roots, err := cfg.Watch.ResolvedRoots()
+	if err != nil {
+		t.Fatalf("ResolvedRoots failed: %v", err)
+	}
+	if len(roots) != 1 || roots[0] != filepath.Join(home, "src") || cfg.Watch.Interval != 30*time.Second || cfg.Watch.Depth != 3 {
+		t.Errorf("Unexpected watch config %+v (roots %v)", cfg.Watch, roots)
+	}
+
+	invalid := []string{
+		"watch:\n  interval: -1s\n",
+		"watch:\n  depth: -1\n",
+		"watch:\n  metrics: 7475\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileOpener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "opener:\n  command: nautilus\n  linux: \"kitty -e nnn '{path}'\"\n  terminal: true\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	cases := []struct {
+		goos     string
+		expected []string
+	}{
+		{"linux", []string{"kitty", "-e", "nnn", "/home/me/my code"}},
+		{"darwin", []string{"nautilus", "/home/me/my code"}},
+	}
+	for _, c := range cases {
+		args, err := cfg.Opener.Args(c.goos, "/home/me/my code")
+		if err != nil || !reflect.DeepEqual(args, c.expected) {
+			t.Errorf("Args(%s) = %q, %v; expected %q", c.goos, args, err, c.expected)
+		}
+	}
+	if args, _ := (OpenerConfig{}).Args("linux", "/tmp"); args != nil {
+		t.Errorf("Expected no command without an opener, got %q", args)
+	}
+
+	invalid := []string{
+		"opener:\n  command: \"nnn '{path}\"\n",
+		"opener:\n  windows: \"''\"\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileEditor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "editor:\n  command: subl -n {path}\n  editors:\n    nvim:\n      args: -c 'cd {path}'\n    subl:\n      wait: true\n    vim:\n      wait: false\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	cases := []struct {
+		editor   string
+		expected []string
+		wait     bool
+	}{
+		{cfg.Editor.Command, []string{"subl", "-n", "/src/api"}, true},
+		{"/usr/bin/nvim", []string{"/usr/bin/nvim", "-c", "cd /src/api"}, true},
+		{"vim", []string{"vim", "/src/api"}, false},
+		{"code --new-window", []string{"code", "--new-window", "/src/api"}, false},
+	}
+	for _, c := range cases {
+		args, wait, err := cfg.Editor.Args(c.editor, "/src/api")
+		if err != nil || !reflect.DeepEqual(args, c.expected) || wait != c.wait {
+			t.Errorf("Args(%q) = %q, %v, %v; expected %q, %v", c.editor, args, wait, err, c.expected, c.wait)
+		}
+	}
+
+	invalid := []string{
+		"editor:\n  command: \"nvim '{path}\"\n",
+		"editor:\n  editors:\n    nvim:\n      args: \"'-c\"\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileSessions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	template := filepath.Join(home, "settings.json")
+	if err := os.WriteFile(template, []byte(`{"editor.tabSize": 4}`), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "sessions:\n  files:\n    - path: .vscode/settings.json\n      template: ~/settings.json\n    - path: .envrc\n      content: \"export TAG={{.Tag}}\"\n      tags: [work]\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	files, err := cfg.Sessions.WorkspaceFiles("work")
+	if err != nil {
+		t.Fatalf("WorkspaceFiles failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Template != `{"editor.tabSize": 4}` || files[1].Path != ".envrc" {
+		t.Errorf("Unexpected files %+v", files)
+	}
+	if files, _ := cfg.Sessions.WorkspaceFiles("personal"); len(files) != 1 {
+		t.Errorf("Expected only the untagged file for other tags, got %+v", files)
+	}
+
+	invalid := []string{
+		"sessions:\n  files:\n    - path: /etc/motd\n      content: x\n",
+		"sessions:\n  files:\n    - path: ../x\n      content: x\n",
+		"sessions:\n  files:\n    - path: .envrc\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := "aliases:\n  st: status --dirty-only\n  w: \"each work 'git log -1'\"\n  hi: \"!echo hello\"\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if args, command, _ := cfg.Alias("st"); strings.Join(args, " ") != "status --dirty-only" || command != "" {
+		t.Errorf("Unexpected st alias %q %q", args, command)
+	}
+	if args, _, _ := cfg.Alias("w"); len(args) != 3 || args[2] != "git log -1" {
+		t.Errorf("Unexpected w alias %q", args)
+	}
+	if args, command, _ := cfg.Alias("hi"); args != nil || command != "echo hello" {
+		t.Errorf("Unexpected hi alias %q %q", args, command)
+	}
+	if args, command, err := cfg.Alias("missing"); args != nil || command != "" || err != nil {
+		t.Errorf("Expected no alias, got %q %q %v", args, command, err)
+	}
+
+	invalid := []string{
+		"aliases:\n  st: \"\"\n",
+		"aliases:\n  st: \"status 'open\"\n",
+		"aliases:\n  \"-s\": status\n",
+		"aliases:\n  hi: \"!\"\n",
+	}
+	for _, content := range invalid {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		if _, err := LoadFile(path); err == nil {
+			t.Errorf("LoadFile should reject %q", content)
+		}
+	}
+}
+
+func TestLoadFileForges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("forges:\n  Git.Acme.com: gitlab\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	f, ok := cfg.Forges.Registry().For(forge.Repo{Host: "git.acme.com", Owner: "team", Name: "api"})
+	if !ok || f.Kind() != forge.GitLab {
+		t.Errorf("Expected git.acme.com to be GitLab, got %v, %v", f, ok)
+	}
+
+	if err := os.WriteFile(path, []byte("forges:\n  git.acme.com: gitea\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "forges.git.acme.com") {
+		t.Errorf("Expected an unknown forge error, got %v", err)
+	}
+}
+
+func TestLoadFileAPI(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cfg := Default()
+	opts, err := cfg.API.CacheOptions()
+	if err != nil {
+		t.Fatalf("CacheOptions failed: %v", err)
+	}
+	if opts.Dir != filepath.Join(home, ".config", "scope", "cache") || opts.MaxAge != time.Minute {
+		t.Errorf("Unexpected default options %+v", opts)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("api:\n  no_cache: true\n  max_wait: 5m\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err = LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if opts, _ := cfg.API.CacheOptions(); opts.Dir != "" || opts.MaxWait != 5*time.Minute {
+		t.Errorf("Unexpected options %+v", opts)
+	}
+
+	if err := os.WriteFile(path, []byte("api:\n  max_age: -1m\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile should reject a negative max_age")
+	}
+}
+
+func TestLoadFileTrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("trash:\n  retention: 168h\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if cfg.Trash.Disabled || cfg.Trash.Retention != 7*24*time.Hour {
+		t.Errorf("Unexpected trash config %+v", cfg.Trash)
+	}
+
+	if err := os.WriteFile(path, []byte("trash:\n  retention: -1h\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile should reject a negative retention")
+	}
+}
+
+func TestLoadFileNetwork(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	tests := []struct {
+		content string
+		offline bool
+		valid   bool
+	}{
+		{"", false, true},
+		{"network: on\n", false, true},
+		{"network: off\n", true, true},
+		{"network: none\n", false, false},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		cfg, err := LoadFile(path)
+		if (err == nil) != tt.valid {
+			t.Errorf("LoadFile(%q) error = %v; expected valid %v", tt.content, err, tt.valid)
+			continue
+		}
+		if err == nil && cfg.Offline() != tt.offline {
+			t.Errorf("LoadFile(%q).Offline() = %v; expected %v", tt.content, cfg.Offline(), tt.offline)
+		}
+	}
+}
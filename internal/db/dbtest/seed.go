@@ -0,0 +1,106 @@
+// Package dbtest generates synthetic databases for tests and benchmarks.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// SeedOptions controls the shape of a synthetic database
+type SeedOptions struct {
+	Folders       int   // number of folders
+	Tags          int   // number of distinct tags
+	TagsPerFolder int   // tags assigned to each folder
+	Seed          int64 // random seed, so runs are reproducible
+}
+
+// Large is the benchmark-sized database: 50k folders across 500 tags
+var Large = SeedOptions{
+	Folders:       50000,
+	Tags:          500,
+	TagsPerFolder: 3,
+	Seed:          1,
+}
+
+// TagName returns the name of the i-th synthetic tag
+func TagName(i int) string {
+	return fmt.Sprintf("tag-%03d", i)
+}
+
+// FolderPath returns the path of the i-th synthetic folder. Folders are
+// spread across 100 parent directories, like repos across orgs.
+func FolderPath(i int) string {
+	return filepath.Join("/bench", fmt.Sprintf("org-%02d", i%100), fmt.Sprintf("repo-%05d", i))
+}
+
+// Seed fills store with synthetic folders and tags. Rows are inserted
+// directly, so the folders don't need to exist on disk.
+func Seed(store *db.Store, opts SeedOptions) error {
+	if opts.TagsPerFolder > opts.Tags {
+		return fmt.Errorf("tags per folder (%d) exceeds tag count (%d)", opts.TagsPerFolder, opts.Tags)
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	return db.WithTx(store.DB(), func(tx *sql.Tx) error {
+		tagStmt, err := tx.Prepare("INSERT INTO tags (id, name, created_at) VALUES (?, ?, 0)")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tagStmt.Close() }()
+
+		for i := 0; i < opts.Tags; i++ {
+			if _, err := tagStmt.Exec(i+1, TagName(i)); err != nil {
+				return fmt.Errorf("failed to insert tag: %w", err)
+			}
+		}
+
+		folderStmt, err := tx.Prepare("INSERT INTO folders (id, path, created_at) VALUES (?, ?, 0)")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = folderStmt.Close() }()
+
+		linkStmt, err := tx.Prepare("INSERT OR IGNORE INTO folder_tags (folder_id, tag_id, created_at) VALUES (?, ?, 0)")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = linkStmt.Close() }()
+
+		for i := 0; i < opts.Folders; i++ {
+			if _, err := folderStmt.Exec(i+1, FolderPath(i)); err != nil {
+				return fmt.Errorf("failed to insert folder: %w", err)
+			}
+			for _, t := range pickDistinct(rng, opts.Tags, opts.TagsPerFolder) {
+				if _, err := linkStmt.Exec(i+1, t+1); err != nil {
+					return fmt.Errorf("failed to insert folder_tag: %w", err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// pickDistinct returns k distinct values in [0, n)
+func pickDistinct(rng *rand.Rand, n, k int) []int {
+	picked := make([]int, 0, k)
+	for len(picked) < k {
+		v := rng.Intn(n)
+		duplicate := false
+		for _, p := range picked {
+			if p == v {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			picked = append(picked, v)
+		}
+	}
+	return picked
+}
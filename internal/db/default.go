@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// The default store backs the package-level functions used by the CLI.
+// Library code should prefer Open and pass the Store explicitly.
+var (
+	store *Store
+	once  sync.Once
+	pool  PoolOptions
+)
+
+// Configure sets the pool options used by InitDB. It must be called before
+// InitDB to take effect.
+func Configure(opts PoolOptions) {
+	pool = opts
+}
+
+// InitDB initializes the default store at DefaultPath
+func InitDB() error {
+	var err error
+	once.Do(func() {
+		path, e := DefaultPath()
+		if e != nil {
+			err = e
+			return
+		}
+		store, err = Open(path, pool)
+	})
+	return err
+}
+
+// Default returns the default store, or nil before InitDB
+func Default() *Store {
+	return store
+}
+
+// GetDB returns the default read-write database instance
+func GetDB() *sql.DB {
+	if store == nil {
+		return nil
+	}
+	return store.DB()
+}
+
+// GetReadDB returns the default read-only database instance for queries
+func GetReadDB() *sql.DB {
+	if store == nil {
+		return nil
+	}
+	return store.ReadDB()
+}
+
+// Close closes the default store
+func Close() error {
+	if store != nil {
+		return store.Close()
+	}
+	return nil
+}
+
+// ResetForTesting resets the database singleton for testing purposes
+// This should only be used in tests
+func ResetForTesting() {
+	if store != nil {
+		_ = store.Close()
+	}
+	store = nil
+	pool = PoolOptions{}
+	once = sync.Once{}
+}
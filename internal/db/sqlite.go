@@ -1,84 +1,117 @@
+// Package db owns the SQLite database shared by every scope command.
+//
+// Threading model: a Store keeps two connection pools on the same file.
+// Store.DB returns the read-write pool; Store.ReadDB returns a pool whose
+// connections are opened with query_only, so nothing issued through it can
+// modify the database (GetDB and GetReadDB return the same pools of the
+// default store). Both are *sql.DB values and are safe for concurrent use
+// from any number of goroutines. The database runs in WAL mode, so reads on
+// the read pool never block behind a writer and always observe the last
+// committed transaction. Writes are serialized by SQLite itself: every
+// transaction begins IMMEDIATE and waits up to the busy timeout for the
+// write lock, and WithTx retries if it still couldn't get it. Long-running
+// subsystems (a server, a TUI) should issue queries on the read pool and
+// wrap mutations in WithTx on the read-write pool; they never need their
+// own locking.
 package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
-	"sync"
+	"time"
 
-	_ "modernc.org/sqlite"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
-var (
-	db   *sql.DB
-	once sync.Once
-)
-
-// InitDB initializes the database connection and creates tables if needed
-func InitDB() error {
-	var err error
-	once.Do(func() {
-		// Get user's home directory
-		homeDir, e := os.UserHomeDir()
-		if e != nil {
-			err = fmt.Errorf("failed to get home directory: %w", e)
-			return
-		}
+const (
+	// busyTimeout is how long a connection waits for another writer (in this
+	// or another process) to release the database before giving up
+	busyTimeout = 5 * time.Second
 
-		// Create config directory
-		configDir := filepath.Join(homeDir, ".config", "scope")
-		if e := os.MkdirAll(configDir, 0755); e != nil {
-			err = fmt.Errorf("failed to create config directory: %w", e)
-			return
-		}
+	// busyRetries is how many times WithTx retries a transaction that still
+	// failed with SQLITE_BUSY after waiting busyTimeout
+	busyRetries = 3
+)
 
-		// Open database
-		dbPath := filepath.Join(configDir, "scope.db")
-		db, e = sql.Open("sqlite", dbPath)
-		if e != nil {
-			err = fmt.Errorf("failed to open database: %w", e)
-			return
-		}
+// open opens the database at path with the connection settings every
+// process must agree on for concurrent writers to be safe:
+//   - foreign keys and the busy timeout are per-connection pragmas, so they
+//     are set in the DSN and applied to every pooled connection
+//   - WAL lets readers proceed while a writer holds the lock
+//   - transactions start with BEGIN IMMEDIATE, taking the write lock up
+//     front so two read-then-write transactions cannot deadlock on upgrade
+func open(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_txlock=immediate",
+		path, busyTimeout.Milliseconds())
+	return sql.Open("sqlite", dsn)
+}
 
-		// Enable foreign keys
-		_, e = db.Exec("PRAGMA foreign_keys = ON")
-		if e != nil {
-			err = fmt.Errorf("failed to enable foreign keys: %w", e)
-			return
-		}
+// openReadOnly opens a handle whose connections reject every write
+func openReadOnly(path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=query_only(1)",
+		path, busyTimeout.Milliseconds())
+	return sql.Open("sqlite", dsn)
+}
 
-		// Create tables
-		err = createTables()
-	})
-	return err
+// IsBusy reports whether err is SQLITE_BUSY (or one of its extended codes),
+// meaning another connection held the write lock for longer than the busy
+// timeout
+func IsBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
 }
 
-// GetDB returns the database instance
-func GetDB() *sql.DB {
-	return db
+// IsUniqueViolation reports whether err is a UNIQUE constraint failure,
+// such as a second tag or folder with the same name or path
+func IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
 }
 
-// Close closes the database connection
-func Close() error {
-	if db != nil {
-		return db.Close()
+// WithTx runs fn inside a write transaction on database, committing if fn
+// succeeds. If the transaction cannot acquire the write lock it is retried
+// with backoff, so mutating commands running at the same time as another
+// scope process (or the watch daemon) wait their turn instead of failing.
+func WithTx(database *sql.DB, fn func(tx *sql.Tx) error) error {
+	var err error
+	for attempt := 0; attempt <= busyRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+		}
+
+		err = runTx(database, fn)
+		if !IsBusy(err) {
+			return err
+		}
 	}
-	return nil
+	return fmt.Errorf("database is locked by another process: %w", err)
 }
 
-// ResetForTesting resets the database singleton for testing purposes
-// This should only be used in tests
-func ResetForTesting() {
-	if db != nil {
-		_ = db.Close()
+// runTx runs a single transaction attempt
+func runTx(database *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := database.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	db = nil
-	once = sync.Once{}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // createTables creates the necessary database tables
-func createTables() error {
+func createTables(db *sql.DB) error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS folders (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -103,6 +136,13 @@ func createTables() error {
 
 	CREATE INDEX IF NOT EXISTS idx_folder_tags_tag ON folder_tags(tag_id);
 	CREATE INDEX IF NOT EXISTS idx_folder_tags_folder ON folder_tags(folder_id);
+
+	CREATE TABLE IF NOT EXISTS folder_notes (
+		folder_id INTEGER PRIMARY KEY,
+		note TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE CASCADE
+	);
 	`
 
 	_, err := db.Exec(schema)
@@ -110,5 +150,95 @@ func createTables() error {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
-	return nil
+	return migrate(db)
+}
+
+// migrations alter tables created by earlier versions. The database's
+// user_version records how many have been applied; append new migrations
+// to the end and never edit or reorder existing ones.
+var migrations = []string{
+	// 1: folders can be remote locations (see internal/location)
+	`ALTER TABLE folders ADD COLUMN kind TEXT NOT NULL DEFAULT 'local'`,
+	// 2: go, each and edit can target a subdirectory of a folder
+	`ALTER TABLE folders ADD COLUMN subdir TEXT NOT NULL DEFAULT ''`,
+	// 3: time spent in sessions and tagged folders (see internal/timetrack)
+	`CREATE TABLE time_spans (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		tag TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL DEFAULT '',
+		shell INTEGER NOT NULL DEFAULT 0,
+		started_at INTEGER NOT NULL,
+		ended_at INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_time_spans_started ON time_spans(started_at)`,
+	// 4: todos attached to folders
+	`CREATE TABLE folder_todos (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		folder_id INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		done_at INTEGER NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE CASCADE
+	);
+	CREATE INDEX idx_folder_todos_folder ON folder_todos(folder_id)`,
+	// 5: a tag's folders can be put in a chosen order (0 = unordered)
+	`ALTER TABLE folder_tags ADD COLUMN position INTEGER NOT NULL DEFAULT 0`,
+	// 6: metadata of folders, such as what scope enrich fetches from GitHub
+	`CREATE TABLE folder_meta (
+		folder_id INTEGER NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (folder_id, key),
+		FOREIGN KEY (folder_id) REFERENCES folders(id) ON DELETE CASCADE
+	)`,
+	// 7: repository details come from any forge, not only GitHub
+	`UPDATE folder_meta SET key = 'forge.' || substr(key, 8) WHERE key LIKE 'github.%'`,
+	// 8: named workspaces and the tags whose folders they link (see
+	// internal/session)
+	`CREATE TABLE workspaces (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT UNIQUE NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE TABLE workspace_tags (
+		workspace_id INTEGER NOT NULL,
+		tag TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (workspace_id, tag),
+		FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE
+	)`,
+	// 9: tags can be described, and shown in a color and with an icon
+	`ALTER TABLE tags ADD COLUMN description TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tags ADD COLUMN color TEXT NOT NULL DEFAULT '';
+	ALTER TABLE tags ADD COLUMN icon TEXT NOT NULL DEFAULT ''`,
+	// 10: deleted folders and tags go to the trash (0 = not deleted)
+	`ALTER TABLE folders ADD COLUMN deleted_at INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE tags ADD COLUMN deleted_at INTEGER NOT NULL DEFAULT 0`,
+}
+
+// migrate applies the migrations the database hasn't seen yet
+func migrate(database *sql.DB) error {
+	return WithTx(database, func(tx *sql.Tx) error {
+		var version int
+		if err := tx.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			return fmt.Errorf("failed to read schema version: %w", err)
+		}
+
+		for i := version; i < len(migrations); i++ {
+			if _, err := tx.Exec(migrations[i]); err != nil {
+				return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+			}
+		}
+		if version >= len(migrations) {
+			return nil
+		}
+
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations))); err != nil {
+			return fmt.Errorf("failed to update schema version: %w", err)
+		}
+		return nil
+	})
 }
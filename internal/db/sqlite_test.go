@@ -1,8 +1,11 @@
 package db
 
 import (
+	"database/sql"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -232,6 +235,292 @@ func TestDatabaseForeignKeys(t *testing.T) {
 	}
 }
 
+func TestWithTxConcurrentHandles(t *testing.T) {
+	tmpDir, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	// A second handle on the same file behaves like another scope process
+	other, err := open(filepath.Join(tmpDir, ".config", "scope", "scope.db"))
+	if err != nil {
+		t.Fatalf("Failed to open second handle: %v", err)
+	}
+	defer other.Close()
+
+	const perHandle = 25
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*perHandle)
+
+	for _, handle := range []*sql.DB{GetDB(), other} {
+		for i := 0; i < perHandle; i++ {
+			wg.Add(1)
+			go func(database *sql.DB) {
+				defer wg.Done()
+				errs <- WithTx(database, func(tx *sql.Tx) error {
+					// Read-modify-write: would lose updates without the write lock
+					var count int
+					if err := tx.QueryRow("SELECT COUNT(*) FROM tags").Scan(&count); err != nil {
+						return err
+					}
+					_, err := tx.Exec("INSERT INTO tags (name, created_at) VALUES (?, ?)",
+						fmt.Sprintf("tag-%d", count), 0)
+					return err
+				})
+			}(handle)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("WithTx failed: %v", err)
+		}
+	}
+
+	var count int
+	if err := GetDB().QueryRow("SELECT COUNT(*) FROM tags").Scan(&count); err != nil {
+		t.Fatalf("Failed to count tags: %v", err)
+	}
+	if count != 2*perHandle {
+		t.Errorf("Expected %d tags, got %d", 2*perHandle, count)
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	err := WithTx(GetDB(), func(tx *sql.Tx) error {
+		if _, err := tx.Exec("INSERT INTO tags (name, created_at) VALUES ('rolled-back', 0)"); err != nil {
+			return err
+		}
+		return fmt.Errorf("boom")
+	})
+	if err == nil {
+		t.Fatal("WithTx should return the error from fn")
+	}
+
+	var count int
+	GetDB().QueryRow("SELECT COUNT(*) FROM tags").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected rollback to leave 0 tags, got %d", count)
+	}
+}
+
+func TestIsBusy(t *testing.T) {
+	if IsBusy(nil) {
+		t.Error("IsBusy(nil) should be false")
+	}
+	if IsBusy(fmt.Errorf("some other error")) {
+		t.Error("IsBusy should be false for non-sqlite errors")
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	if _, err := GetDB().Exec("INSERT INTO tags (name, created_at) VALUES ('work', 0)"); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	_, err := GetDB().Exec("INSERT INTO tags (name, created_at) VALUES ('work', 0)")
+	if !IsUniqueViolation(err) {
+		t.Errorf("Expected a unique violation inserting a duplicate name, got %v", err)
+	}
+	if IsUniqueViolation(fmt.Errorf("some other error")) || IsBusy(err) {
+		t.Error("IsUniqueViolation should only match constraint failures")
+	}
+}
+
+func TestGetReadDBRejectsWrites(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	reader := GetReadDB()
+	if reader == nil {
+		t.Fatal("GetReadDB returned nil after InitDB")
+	}
+
+	if _, err := reader.Exec("INSERT INTO tags (name, created_at) VALUES ('nope', 0)"); err == nil {
+		t.Error("Write through the read-only pool should fail")
+	}
+
+	// Writes through the main pool are visible to the read pool
+	if _, err := GetDB().Exec("INSERT INTO tags (name, created_at) VALUES ('yes', 0)"); err != nil {
+		t.Fatalf("Write through the main pool failed: %v", err)
+	}
+	var count int
+	if err := reader.QueryRow("SELECT COUNT(*) FROM tags").Scan(&count); err != nil {
+		t.Fatalf("Read through the read-only pool failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected read pool to see 1 tag, got %d", count)
+	}
+}
+
+func TestConfigurePoolOptions(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	Configure(PoolOptions{MaxOpenConns: 3, MaxReadConns: 7})
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	if got := GetDB().Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("Expected MaxOpenConnections 3, got %d", got)
+	}
+	if got := GetReadDB().Stats().MaxOpenConnections; got != 7 {
+		t.Errorf("Expected read MaxOpenConnections 7, got %d", got)
+	}
+}
+
+func TestDefaultReadPoolLimit(t *testing.T) {
+	_, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB failed: %v", err)
+	}
+
+	if got := GetReadDB().Stats().MaxOpenConnections; got != defaultMaxReadConns {
+		t.Errorf("Expected default read MaxOpenConnections %d, got %d", defaultMaxReadConns, got)
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "scope.db")
+	store, err := Open(path, PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+
+	if store.Path() != path {
+		t.Errorf("Expected path %s, got %s", path, store.Path())
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Database file was not created at %s: %v", path, err)
+	}
+
+	var count int
+	if err := store.ReadDB().QueryRow("SELECT COUNT(*) FROM folders").Scan(&count); err != nil {
+		t.Errorf("Schema missing in opened store: %v", err)
+	}
+
+	// An explicit store is independent of the default one
+	if Default() == store {
+		t.Error("Open should not replace the default store")
+	}
+}
+
+func TestMigrateExistingDatabase(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "scope.db")
+
+	// A database created before migrations existed
+	old, err := open(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE folders (id INTEGER PRIMARY KEY AUTOINCREMENT, path TEXT UNIQUE NOT NULL, created_at INTEGER NOT NULL);
+		INSERT INTO folders (path, created_at) VALUES ('/old/project', 1);
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create old schema: %v", err)
+	}
+	old.Close()
+
+	// Opening twice must not re-apply migrations
+	for i := 0; i < 2; i++ {
+		store, err := Open(path, PoolOptions{})
+		if err != nil {
+			t.Fatalf("Open #%d failed: %v", i+1, err)
+		}
+
+		var kind string
+		if err := store.ReadDB().QueryRow("SELECT kind FROM folders WHERE path = '/old/project'").Scan(&kind); err != nil {
+			t.Fatalf("Failed to read migrated column: %v", err)
+		}
+		if kind != "local" {
+			t.Errorf("Expected existing folder to default to kind 'local', got %q", kind)
+		}
+
+		var version int
+		if err := store.ReadDB().QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+			t.Fatalf("Failed to read user_version: %v", err)
+		}
+		if version != len(migrations) {
+			t.Errorf("Expected user_version %d, got %d", len(migrations), version)
+		}
+		store.Close()
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "scope.db")
+
+	// A read-only store never creates the database
+	if _, err := Open(path, PoolOptions{ReadOnly: true}); err == nil {
+		t.Fatal("Open should fail for a missing read-only database")
+	}
+
+	store, err := Open(path, PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	store.Close()
+
+	if err := Lock(path); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	store, err = Open(path, PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open of locked database failed: %v", err)
+	}
+	if !store.ReadOnly() {
+		t.Error("A locked database should open read-only")
+	}
+	if _, err := store.DB().Exec("INSERT INTO tags (name, created_at) VALUES ('x', 0)"); err == nil {
+		t.Error("Writes should be rejected on a read-only store")
+	}
+	store.Close()
+
+	if err := Unlock(path); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	store, err = Open(path, PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+	if store.ReadOnly() {
+		t.Error("An unlocked database should open read-write")
+	}
+}
+
 func BenchmarkInitDB(b *testing.B) {
 	tmpDir, err := os.MkdirTemp("", "scope-db-bench-*")
 	if err != nil {
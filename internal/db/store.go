@@ -0,0 +1,176 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Store is an open scope database: a read-write pool and a read-only pool
+// on the same file. A Store is safe for concurrent use.
+type Store struct {
+	db       *sql.DB
+	reader   *sql.DB
+	path     string
+	readOnly bool
+}
+
+// PoolOptions configures the connection pools. Zero values keep the
+// database/sql defaults, except MaxReadConns which defaults to
+// defaultMaxReadConns.
+type PoolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	MaxReadConns    int
+
+	// ReadOnly opens the database without ever writing to it, not even to
+	// create the schema. A database locked with Lock is always opened
+	// read-only.
+	ReadOnly bool
+}
+
+// ErrReadOnly is returned by mutations on a read-only Store
+var ErrReadOnly = errors.New("database is read-only")
+
+// defaultMaxReadConns bounds the read pool when no limit is configured
+const defaultMaxReadConns = 4
+
+// DefaultPath returns the location of the user's database
+func DefaultPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "scope", "scope.db"), nil
+}
+
+// Open opens (creating if needed) the database at path and its schema
+func Open(path string, opts PoolOptions) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if opts.ReadOnly || IsLocked(path) {
+		return openReadOnlyStore(path, opts)
+	}
+
+	writer, err := open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	applyPool(writer, opts.MaxOpenConns, opts.MaxIdleConns, opts.ConnMaxLifetime)
+
+	if err := createTables(writer); err != nil {
+		_ = writer.Close()
+		return nil, err
+	}
+
+	// Open the read-only pool once the schema exists
+	reader, err := openReadOnly(path)
+	if err != nil {
+		_ = writer.Close()
+		return nil, fmt.Errorf("failed to open read-only database: %w", err)
+	}
+	maxRead := opts.MaxReadConns
+	if maxRead == 0 {
+		maxRead = defaultMaxReadConns
+	}
+	applyPool(reader, maxRead, opts.MaxIdleConns, opts.ConnMaxLifetime)
+
+	return &Store{db: writer, reader: reader, path: path}, nil
+}
+
+// openReadOnlyStore opens an existing database read-only. Both pools
+// reject writes, so nothing can modify the file even by mistake.
+func openReadOnlyStore(path string, opts PoolOptions) (*Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot open read-only database: %w", err)
+	}
+
+	handle, err := openReadOnly(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read-only database: %w", err)
+	}
+	maxRead := opts.MaxReadConns
+	if maxRead == 0 {
+		maxRead = defaultMaxReadConns
+	}
+	applyPool(handle, maxRead, opts.MaxIdleConns, opts.ConnMaxLifetime)
+
+	return &Store{db: handle, reader: handle, path: path, readOnly: true}, nil
+}
+
+// DB returns the read-write pool. On a read-only Store it rejects writes
+// like ReadDB; check ReadOnly before writing.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// ReadOnly reports whether the Store was opened read-only
+func (s *Store) ReadOnly() bool {
+	return s.readOnly
+}
+
+// ReadDB returns the read-only pool for queries
+func (s *Store) ReadDB() *sql.DB {
+	return s.reader
+}
+
+// Path returns the database file location
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Close closes both pools
+func (s *Store) Close() error {
+	if s.reader != s.db {
+		_ = s.reader.Close()
+	}
+	return s.db.Close()
+}
+
+// lockPath is the marker file that locks the database at path
+func lockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "readonly")
+}
+
+// IsLocked reports whether the database at path has been locked
+func IsLocked(path string) bool {
+	_, err := os.Stat(lockPath(path))
+	return err == nil
+}
+
+// Lock makes every later Open of the database at path read-only
+func Lock(path string) error {
+	f, err := os.OpenFile(lockPath(path), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to lock database: %w", err)
+	}
+	return f.Close()
+}
+
+// Unlock undoes Lock
+func Unlock(path string) error {
+	if err := os.Remove(lockPath(path)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to unlock database: %w", err)
+	}
+	return nil
+}
+
+// applyPool applies pool limits to a handle, leaving zero values at the
+// database/sql defaults
+func applyPool(handle *sql.DB, maxOpen, maxIdle int, lifetime time.Duration) {
+	if maxOpen > 0 {
+		handle.SetMaxOpenConns(maxOpen)
+	}
+	if maxIdle > 0 {
+		handle.SetMaxIdleConns(maxIdle)
+	}
+	if lifetime > 0 {
+		handle.SetConnMaxLifetime(lifetime)
+	}
+}
@@ -0,0 +1,217 @@
+// Package events publishes what scope does (tags added, sessions started,
+// prunes run) so other programs can react to it: window managers, status
+// bars, dashboards, time trackers.
+//
+// Every event is a JSON object. It is written as one line to a unix socket
+// or named pipe another program listens on, and POSTed to each configured
+// webhook URL. Delivery is best effort: a listener that isn't running is
+// skipped silently, and nothing waits longer than the configured timeout.
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/network"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// DefaultTimeout bounds a single delivery when no timeout is configured
+const DefaultTimeout = 2 * time.Second
+
+// Type names a kind of event
+type Type string
+
+const (
+	TagAdded        Type = "tag.added"        // Tag added to Path
+	TagRemoved      Type = "tag.removed"      // Tag removed from Path
+	TagDeleted      Type = "tag.deleted"      // Tag deleted from every folder
+	TagRenamed      Type = "tag.renamed"      // Tag renamed to NewTag
+	TagMerged       Type = "tag.merged"       // Tag merged into NewTag
+	FolderForgotten Type = "folder.forgotten" // Path and all its tags removed
+	FolderMoved     Type = "folder.moved"     // Path moved to NewPath
+	NoteChanged     Type = "note.changed"     // Note of Path set (empty when cleared)
+	SubdirChanged   Type = "subdir.changed"   // Working subdirectory of Path set
+	SessionStarted  Type = "session.started"  // Session for Tag opened in Workspace
+	SessionEnded    Type = "session.ended"    // Session for Tag closed
+	PruneRan        Type = "prune.ran"        // Folders removed by prune
+	ScanRan         Type = "scan.ran"         // Folders tagged by a scan of Path
+)
+
+// Event is one thing scope did
+type Event struct {
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	Path      string    `json:"path,omitempty"`
+	NewPath   string    `json:"new_path,omitempty"`
+	Tag       string    `json:"tag,omitempty"`
+	NewTag    string    `json:"new_tag,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	Subdir    string    `json:"subdir,omitempty"`
+	Workspace string    `json:"workspace,omitempty"`
+	Folders   []string  `json:"folders,omitempty"`
+}
+
+// changeTypes maps tag changes to the events they publish
+var changeTypes = map[tag.Op]Type{
+	tag.OpAdd:       TagAdded,
+	tag.OpRemove:    TagRemoved,
+	tag.OpDeleteTag: TagDeleted,
+	tag.OpRename:    TagRenamed,
+	tag.OpMerge:     TagMerged,
+	tag.OpForget:    FolderForgotten,
+	tag.OpMove:      FolderMoved,
+	tag.OpNote:      NoteChanged,
+	tag.OpSubdir:    SubdirChanged,
+}
+
+// FromChange converts a tag change to its event. The time is left for Emit
+// to fill in.
+func FromChange(c tag.Change) (Event, bool) {
+	t, ok := changeTypes[c.Op]
+	if !ok {
+		return Event{}, false
+	}
+	return Event{
+		Type:    t,
+		Path:    c.Path,
+		NewPath: c.NewPath,
+		Tag:     c.Tag,
+		NewTag:  c.NewTag,
+		Note:    c.Note,
+		Subdir:  c.Subdir,
+	}, true
+}
+
+// Bus delivers events to a socket or named pipe and to webhooks
+type Bus struct {
+	socket   string
+	webhooks []string
+	client   *http.Client
+	timeout  time.Duration
+}
+
+// New returns a Bus delivering to socket (a unix socket or named pipe; none
+// when empty) and webhooks. A zero timeout uses DefaultTimeout.
+func New(socket string, webhooks []string, timeout time.Duration) *Bus {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Bus{
+		socket:   socket,
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: timeout},
+		timeout:  timeout,
+	}
+}
+
+// Observe publishes every change made through m
+func (b *Bus) Observe(m *tag.Manager) {
+	m.Observe(func(c tag.Change) {
+		ev, ok := FromChange(c)
+		if !ok {
+			return
+		}
+		if err := b.Emit(ev); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to publish event: %v\n", err)
+		}
+	})
+}
+
+// Emit delivers ev to every destination, stamping it with the current time
+// if it has none. Destinations are tried concurrently; the errors of those
+// that failed are joined.
+func (b *Bus) Emit(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	deliver := func(fn func() error) {
+		defer wg.Done()
+		if err := fn(); err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}
+	}
+
+	if b.socket != "" {
+		wg.Add(1)
+		go deliver(func() error { return b.writeSocket(data) })
+	}
+	// Webhooks are best effort; offline they are skipped quietly
+	if !network.Offline() {
+		for _, url := range b.webhooks {
+			wg.Add(1)
+			go deliver(func() error { return b.post(url, data) })
+		}
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// writeSocket writes data as one line to the socket or named pipe. A
+// destination nobody is listening on is not an error.
+func (b *Bus) writeSocket(data []byte) error {
+	line := append(data, '\n')
+
+	if isPipe(b.socket) {
+		return writePipe(b.socket, line)
+	}
+	if _, err := os.Stat(b.socket); os.IsNotExist(err) {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", b.socket, b.timeout)
+	if err != nil {
+		if noListener(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to connect to %s: %w", b.socket, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(b.timeout))
+	if _, err := conn.Write(line); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", b.socket, err)
+	}
+	return nil
+}
+
+// post sends data to a webhook
+func (b *Bus) post(url string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid webhook %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "scope")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post to %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestFromChange(t *testing.T) {
+	tests := []struct {
+		change tag.Change
+		want   Event
+	}{
+		{tag.Change{Op: tag.OpAdd, Path: "/p", Tag: "work"}, Event{Type: TagAdded, Path: "/p", Tag: "work"}},
+		{tag.Change{Op: tag.OpRename, Tag: "a", NewTag: "b"}, Event{Type: TagRenamed, Tag: "a", NewTag: "b"}},
+		{tag.Change{Op: tag.OpForget, Path: "/p"}, Event{Type: FolderForgotten, Path: "/p"}},
+		{tag.Change{Op: tag.OpNote, Path: "/p", Note: "hi"}, Event{Type: NoteChanged, Path: "/p", Note: "hi"}},
+	}
+
+	for _, tt := range tests {
+		got, ok := FromChange(tt.change)
+		if !ok {
+			t.Errorf("FromChange(%+v) reported no event", tt.change)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FromChange(%+v) = %+v, want %+v", tt.change, got, tt.want)
+		}
+	}
+
+	if _, ok := FromChange(tag.Change{Op: "unknown"}); ok {
+		t.Error("Expected unknown ops to publish nothing")
+	}
+}
+
+// shortTempDir returns a temp dir short enough for a unix socket path
+func shortTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "scope-ev")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestEmitSocket(t *testing.T) {
+	socket := filepath.Join(shortTempDir(t), "events.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skipf("Unix sockets not supported: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	bus := New(socket, nil, time.Second)
+	if err := bus.Emit(Event{Type: SessionStarted, Tag: "work", Workspace: "/tmp/ws"}); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+
+	select {
+	case line := <-received:
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("Received invalid JSON %q: %v", line, err)
+		}
+		if ev.Type != SessionStarted || ev.Tag != "work" || ev.Workspace != "/tmp/ws" || ev.Time.IsZero() {
+			t.Errorf("Unexpected event %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Listener received nothing")
+	}
+}
+
+func TestEmitNoListener(t *testing.T) {
+	dir := shortTempDir(t)
+
+	// A missing socket and a stale one are skipped silently
+	stale := filepath.Join(dir, "stale.sock")
+	ln, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Skipf("Unix sockets not supported: %v", err)
+	}
+	if l, ok := ln.(*net.UnixListener); ok {
+		l.SetUnlinkOnClose(false)
+	}
+	_ = ln.Close()
+
+	for _, socket := range []string{filepath.Join(dir, "missing.sock"), stale} {
+		if err := New(socket, nil, time.Second).Emit(Event{Type: TagAdded}); err != nil {
+			t.Errorf("Emit to %s failed: %v", socket, err)
+		}
+	}
+}
+
+func TestEmitWebhooks(t *testing.T) {
+	received := make(chan Event, 1)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			t.Errorf("Webhook received invalid JSON: %v", err)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", ct)
+		}
+		received <- ev
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	bus := New("", []string{ok.URL, failing.URL}, time.Second)
+	err := bus.Emit(Event{Type: TagAdded, Path: "/p", Tag: "work"})
+	if err == nil || !strings.Contains(err.Error(), failing.URL) {
+		t.Errorf("Expected the failing webhook to be reported, got %v", err)
+	}
+
+	ev := <-received
+	if ev.Type != TagAdded || ev.Tag != "work" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev Event
+		_ = json.NewDecoder(r.Body).Decode(&ev)
+		received <- ev
+	}))
+	defer server.Close()
+
+	m := tag.NewManager(store)
+	New("", []string{server.URL}, time.Second).Observe(m)
+
+	if err := m.AddTag(tmpDir, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	ev := <-received
+	if ev.Type != TagAdded || ev.Path != tmpDir || ev.Tag != "work" {
+		t.Errorf("Unexpected event %+v", ev)
+	}
+}
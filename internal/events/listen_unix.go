@@ -0,0 +1,61 @@
+//go:build !windows
+
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// Listen receives the events scope processes write to the unix socket at
+// path, calling fn with each, until the returned listener is closed. A
+// socket file left by a listener that exited is replaced; one that another
+// program is listening on is an error.
+func Listen(path string, fn func(Event)) (io.Closer, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("another program is listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go receive(conn, fn)
+		}
+	}()
+	return ln, nil
+}
+
+// receive calls fn with each event on conn, one JSON object per line,
+// skipping lines that aren't events
+func receive(conn net.Conn, fn func(Event)) {
+	defer func() { _ = conn.Close() }()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var ev Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || ev.Type == "" {
+			continue
+		}
+		fn(ev)
+	}
+}
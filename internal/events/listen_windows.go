@@ -0,0 +1,14 @@
+//go:build windows
+
+package events
+
+import (
+	"errors"
+	"io"
+)
+
+// Listen is not supported on Windows, where scope publishes to named pipes
+// it can't create
+func Listen(path string, fn func(Event)) (io.Closer, error) {
+	return nil, errors.New("listening for events is not supported on Windows")
+}
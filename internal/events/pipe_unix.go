@@ -0,0 +1,40 @@
+//go:build !windows
+
+package events
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// isPipe reports whether path is a named pipe (FIFO)
+func isPipe(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeNamedPipe != 0
+}
+
+// writePipe writes line to a named pipe without blocking when no reader
+// has it open
+func writePipe(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		if noListener(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", path, err)
+	}
+	return nil
+}
+
+// noListener reports whether err means nothing is reading the socket or
+// pipe: a stale socket file, or a pipe without a reader
+func noListener(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENXIO) || errors.Is(err, os.ErrNotExist)
+}
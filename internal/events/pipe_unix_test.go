@@ -0,0 +1,78 @@
+//go:build !windows
+
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestEmitPipe(t *testing.T) {
+	pipe := filepath.Join(shortTempDir(t), "events.fifo")
+	if err := syscall.Mkfifo(pipe, 0600); err != nil {
+		t.Skipf("Named pipes not supported: %v", err)
+	}
+
+	bus := New(pipe, nil, time.Second)
+
+	// Without a reader the event is dropped instead of blocking
+	if err := bus.Emit(Event{Type: PruneRan}); err != nil {
+		t.Fatalf("Emit without a reader failed: %v", err)
+	}
+
+	f, err := os.OpenFile(pipe, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatalf("Failed to open pipe: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if err := bus.Emit(Event{Type: PruneRan, Folders: []string{"/gone"}}); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	buf := make([]byte, 512)
+	n, err := f.Read(buf)
+	if err != nil {
+		t.Fatalf("Failed to read pipe: %v", err)
+	}
+	if !strings.Contains(string(buf[:n]), `"folders":["/gone"]`) {
+		t.Errorf("Unexpected pipe contents %q", buf[:n])
+	}
+}
+
+func TestListen(t *testing.T) {
+	socket := filepath.Join(shortTempDir(t), "events.sock")
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(socket, func(Event) {}); err == nil {
+		t.Fatal("Expected an error for a file that is not a socket")
+	}
+	_ = os.Remove(socket)
+
+	received := make(chan Event, 1)
+	ln, err := Listen(socket, func(ev Event) { received <- ev })
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer func() { _ = ln.Close() }()
+
+	if _, err := Listen(socket, func(Event) {}); err == nil {
+		t.Error("Expected an error while another listener is running")
+	}
+
+	if err := New(socket, nil, time.Second).Emit(Event{Type: ScanRan, Path: "/src"}); err != nil {
+		t.Fatalf("Emit failed: %v", err)
+	}
+	select {
+	case ev := <-received:
+		if ev.Type != ScanRan || ev.Path != "/src" {
+			t.Errorf("Unexpected event %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the event")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package events
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// isPipe reports whether path names a pipe such as \\.\pipe\scope
+func isPipe(path string) bool {
+	return strings.HasPrefix(strings.ToLower(path), `\\.\pipe\`)
+}
+
+// writePipe writes line to a named pipe
+func writePipe(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		if noListener(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(line); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", path, err)
+	}
+	return nil
+}
+
+// noListener reports whether err means nothing is listening on the socket
+// or pipe
+func noListener(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist)
+}
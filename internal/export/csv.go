@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+)
+
+// WriteCSV writes the document's tags in long format: a folder,tag row for
+// each tag of each folder, sorted by folder then tag
+func WriteCSV(w io.Writer, data *Data) error {
+	folders, byFolder := folderTags(data)
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"folder", "tag"}); err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		for _, tagName := range byFolder[folder] {
+			if err := cw.Write([]string{folder, tagName}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteMatrixCSV writes the document's tags as a folders × tags matrix: a
+// column per tag, sorted by name, and a row per folder with 1 where the
+// folder has the tag and 0 where it doesn't, so columns can be summed
+func WriteMatrixCSV(w io.Writer, data *Data) error {
+	folders, byFolder := folderTags(data)
+	tags := make([]string, 0, len(data.Tags))
+	for tagName := range data.Tags {
+		tags = append(tags, tagName)
+	}
+	sort.Strings(tags)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"folder"}, tags...)); err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		has := make(map[string]bool, len(byFolder[folder]))
+		for _, tagName := range byFolder[folder] {
+			has[tagName] = true
+		}
+		row := make([]string, 0, len(tags)+1)
+		row = append(row, folder)
+		for _, tagName := range tags {
+			if has[tagName] {
+				row = append(row, "1")
+			} else {
+				row = append(row, "0")
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// folderTags inverts the document's tags: the sorted folders, and each
+// folder's sorted tags
+func folderTags(data *Data) ([]string, map[string][]string) {
+	byFolder := make(map[string][]string)
+	for tagName, folders := range data.Tags {
+		for _, folder := range folders {
+			byFolder[folder] = append(byFolder[folder], tagName)
+		}
+	}
+	folders := make([]string, 0, len(byFolder))
+	for folder, tags := range byFolder {
+		sort.Strings(tags)
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+	return folders, byFolder
+}
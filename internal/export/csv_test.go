@@ -0,0 +1,36 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	data := &Data{
+		Version: CurrentVersion,
+		Tags: map[string][]string{
+			"work":   {"/code/web", "/code/api"},
+			"go":     {"/code/api"},
+			"a,b":    {"/code/web"},
+			"unused": {},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, data); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	long := "folder,tag\n/code/api,go\n/code/api,work\n/code/web,\"a,b\"\n/code/web,work\n"
+	if buf.String() != long {
+		t.Errorf("WriteCSV =\n%s\nwant\n%s", buf.String(), long)
+	}
+
+	buf.Reset()
+	if err := WriteMatrixCSV(&buf, data); err != nil {
+		t.Fatalf("WriteMatrixCSV failed: %v", err)
+	}
+	matrix := "folder,\"a,b\",go,unused,work\n/code/api,0,1,0,1\n/code/web,1,0,0,1\n"
+	if buf.String() != matrix {
+		t.Errorf("WriteMatrixCSV =\n%s\nwant\n%s", buf.String(), matrix)
+	}
+}
@@ -0,0 +1,190 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// CurrentVersion is the export format version written by Build
+const CurrentVersion = 2
+
+// Data represents the structure of an exported document (format v2)
+//
+// Version 2 is a superset of version 1: the tags section is unchanged and
+// every other section is optional, so a v2 document without the extra
+// sections looks just like a v1 document with a bumped version number.
+type Data struct {
+	Version int                 `yaml:"version"`
+	Tags    map[string][]string `yaml:"tags"`
+	TagMeta map[string]TagMeta  `yaml:"tag_meta,omitempty"`
+	Notes   map[string]string   `yaml:"notes,omitempty"`
+	Subdirs map[string]string   `yaml:"subdirs,omitempty"`
+	Groups  map[string][]string `yaml:"groups,omitempty"`
+	Aliases map[string]string   `yaml:"aliases,omitempty"`
+}
+
+// TagMeta holds optional per-tag metadata
+type TagMeta struct {
+	Description string `yaml:"description,omitempty"`
+	Color       string `yaml:"color,omitempty"`
+	Icon        string `yaml:"icon,omitempty"`
+}
+
+// dataV1 is the original export format: tag name to folder paths
+type dataV1 struct {
+	Version int                 `yaml:"version"`
+	Tags    map[string][]string `yaml:"tags"`
+}
+
+// header is decoded first to find out which converter to use
+type header struct {
+	Version int `yaml:"version"`
+}
+
+// ErrUnsupportedVersion is returned for documents written by a newer scope
+type ErrUnsupportedVersion struct {
+	Version int
+}
+
+func (e *ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("export format version %d is newer than this scope supports (max %d); run 'scope update' and try again",
+		e.Version, CurrentVersion)
+}
+
+// Build collects all tags and folders managed by m into a current-version
+// document
+func Build(m *tag.Manager) (*Data, error) {
+	tags, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{
+		Version: CurrentVersion,
+		Tags:    make(map[string][]string),
+	}
+
+	for tagName := range tags {
+		folders, err := m.ListFoldersByExactTag(tagName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get folders for tag '%s': %w", tagName, err)
+		}
+		data.Tags[tagName] = folders
+	}
+
+	infos, err := m.ListTagInfo()
+	if err != nil {
+		return nil, err
+	}
+	for name, info := range infos {
+		if _, ok := data.Tags[name]; !ok {
+			continue
+		}
+		if data.TagMeta == nil {
+			data.TagMeta = make(map[string]TagMeta)
+		}
+		data.TagMeta[name] = TagMeta{Description: info.Description, Color: info.Color, Icon: info.Icon}
+	}
+
+	notes, err := m.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) > 0 {
+		data.Notes = notes
+	}
+
+	subdirs, err := m.ListSubdirs()
+	if err != nil {
+		return nil, err
+	}
+	if len(subdirs) > 0 {
+		data.Subdirs = subdirs
+	}
+
+	return data, nil
+}
+
+// Marshal encodes the document as YAML
+func Marshal(data *Data) ([]byte, error) {
+	output, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal to YAML: %w", err)
+	}
+	return output, nil
+}
+
+// Parse decodes a document of any supported version and upgrades it to the
+// current format
+func Parse(content []byte) (*Data, error) {
+	var h header
+	if err := yaml.Unmarshal(content, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	switch {
+	case h.Version > CurrentVersion:
+		return nil, &ErrUnsupportedVersion{Version: h.Version}
+	case h.Version == 2:
+		var data Data
+		if err := yaml.Unmarshal(content, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		return &data, nil
+	case h.Version == 1, h.Version == 0:
+		// Documents without a version field predate versioning and share
+		// the v1 layout
+		var v1 dataV1
+		if err := yaml.Unmarshal(content, &v1); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		return convertV1(&v1), nil
+	default:
+		return nil, fmt.Errorf("invalid export format version: %d", h.Version)
+	}
+}
+
+// convertV1 upgrades a v1 document to the current format
+func convertV1(v1 *dataV1) *Data {
+	tags := v1.Tags
+	if tags == nil {
+		tags = make(map[string][]string)
+	}
+	return &Data{
+		Version: CurrentVersion,
+		Tags:    tags,
+	}
+}
+
+// WriteFile writes an export to path with write. The export goes to a
+// temporary file that replaces path once complete, so programs reading the
+// file never see half of it.
+func WriteFile(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		// CreateTemp makes the file private; exports are as readable as any
+		// file the user writes
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package export
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/db/dbtest"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestParseV1(t *testing.T) {
+	content := []byte(`version: 1
+tags:
+  work:
+    - /path/to/project1
+    - /path/to/project2
+`)
+
+	data, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if data.Version != CurrentVersion {
+		t.Errorf("Expected version to be upgraded to %d, got %d", CurrentVersion, data.Version)
+	}
+
+	expected := []string{"/path/to/project1", "/path/to/project2"}
+	if !reflect.DeepEqual(data.Tags["work"], expected) {
+		t.Errorf("Expected folders %v, got %v", expected, data.Tags["work"])
+	}
+}
+
+func TestParseMissingVersion(t *testing.T) {
+	content := []byte(`tags:
+  personal:
+    - /path/to/blog
+`)
+
+	data, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(data.Tags["personal"]) != 1 {
+		t.Errorf("Expected 1 folder for 'personal', got %v", data.Tags["personal"])
+	}
+}
+
+func TestParseV2(t *testing.T) {
+	content := []byte(`version: 2
+tags:
+  work:
+    - /path/to/api
+tag_meta:
+  work:
+    description: Day job
+    color: blue
+notes:
+  /path/to/api: Main service
+groups:
+  backend:
+    - work
+aliases:
+  w: work
+`)
+
+	data, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if data.TagMeta["work"].Color != "blue" {
+		t.Errorf("Expected tag_meta color 'blue', got %q", data.TagMeta["work"].Color)
+	}
+	if data.Notes["/path/to/api"] != "Main service" {
+		t.Errorf("Expected note 'Main service', got %q", data.Notes["/path/to/api"])
+	}
+	if !reflect.DeepEqual(data.Groups["backend"], []string{"work"}) {
+		t.Errorf("Expected group backend [work], got %v", data.Groups["backend"])
+	}
+	if data.Aliases["w"] != "work" {
+		t.Errorf("Expected alias w -> work, got %q", data.Aliases["w"])
+	}
+}
+
+func TestParseNewerVersion(t *testing.T) {
+	content := []byte("version: 99\ntags: {}\n")
+
+	_, err := Parse(content)
+	if err == nil {
+		t.Fatal("Parse should fail for a newer format version")
+	}
+
+	var versionErr *ErrUnsupportedVersion
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("Expected ErrUnsupportedVersion, got %T: %v", err, err)
+	}
+	if versionErr.Version != 99 {
+		t.Errorf("Expected version 99 in error, got %d", versionErr.Version)
+	}
+}
+
+func TestParseInvalidVersion(t *testing.T) {
+	if _, err := Parse([]byte("version: -1\n")); err == nil {
+		t.Error("Parse should fail for a negative version")
+	}
+}
+
+func TestParseInvalidYAML(t *testing.T) {
+	if _, err := Parse([]byte("version: [unclosed")); err == nil {
+		t.Error("Parse should fail for invalid YAML")
+	}
+}
+
+func TestBuildRoundTrip(t *testing.T) {
+	// Tagged paths are stored with symlinks resolved
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer func() {
+		db.Close()
+		db.ResetForTesting()
+		os.Setenv("HOME", originalHome)
+	}()
+
+	if err := db.InitDB(); err != nil {
+		t.Fatalf("Failed to init database: %v", err)
+	}
+
+	folder := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := tag.AddTag(folder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := tag.SetNote(folder, "Main service"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	description, color := "Day job", "blue"
+	if err := tag.SetTagInfo("work", tag.InfoUpdate{Description: &description, Color: &color}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+
+	data, err := Build(tag.Default())
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	output, err := Marshal(data)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	parsed, err := Parse(output)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if parsed.Version != CurrentVersion {
+		t.Errorf("Expected version %d, got %d", CurrentVersion, parsed.Version)
+	}
+	if !reflect.DeepEqual(parsed.Tags["work"], []string{folder}) {
+		t.Errorf("Expected work -> [%s], got %v", folder, parsed.Tags["work"])
+	}
+	if parsed.Notes[folder] != "Main service" {
+		t.Errorf("Expected note for %s, got %v", folder, parsed.Notes)
+	}
+	if want := (TagMeta{Description: "Day job", Color: "blue"}); parsed.TagMeta["work"] != want {
+		t.Errorf("Expected tag_meta %+v for work, got %+v", want, parsed.TagMeta["work"])
+	}
+}
+
+func BenchmarkLargeExport(b *testing.B) {
+	if testing.Short() {
+		b.Skip("Skipping large database benchmark in short mode")
+	}
+
+	store, err := db.Open(filepath.Join(b.TempDir(), "scope.db"), db.PoolOptions{})
+	if err != nil {
+		b.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := dbtest.Seed(store, dbtest.Large); err != nil {
+		b.Fatalf("Seed failed: %v", err)
+	}
+	m := tag.NewManager(store)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := Build(m)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := Marshal(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
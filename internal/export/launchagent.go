@@ -0,0 +1,58 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/plist"
+)
+
+// LaunchAgentLabel is the launchd label of the agent written by
+// WriteLaunchAgent
+const LaunchAgentLabel = "io.github.gabssanto.scope.export"
+
+// LaunchAgent describes a macOS LaunchAgent that keeps an export file up to
+// date
+type LaunchAgent struct {
+	// Args are the command line of the export, starting with the scope
+	// executable
+	Args []string
+	// WatchPaths are the paths whose changes rerun the export, such as the
+	// database's directory
+	WatchPaths []string
+	// Interval reruns the export periodically as well; zero doesn't
+	Interval time.Duration
+	// Log is where the export's errors go; empty discards them
+	Log string
+}
+
+// WriteLaunchAgent writes a as a launchd property list, to be saved under
+// ~/Library/LaunchAgents. The export runs at login and then whenever a
+// watched path changes or the interval passes.
+func WriteLaunchAgent(w io.Writer, a *LaunchAgent) error {
+	if len(a.Args) == 0 {
+		return fmt.Errorf("launch agent has no command")
+	}
+	args := make([]any, len(a.Args))
+	for i, arg := range a.Args {
+		args[i] = arg
+	}
+	agent := plist.Dict{
+		{Key: "Label", Value: LaunchAgentLabel},
+		{Key: "ProgramArguments", Value: args},
+		{Key: "RunAtLoad", Value: true},
+	}
+	if len(a.WatchPaths) > 0 {
+		agent = append(agent, plist.Entry{Key: "WatchPaths", Value: a.WatchPaths})
+	}
+	if a.Interval > 0 {
+		agent = append(agent, plist.Entry{Key: "StartInterval", Value: int(a.Interval.Seconds())})
+	}
+	if a.Log != "" {
+		agent = append(agent, plist.Entry{Key: "StandardErrorPath", Value: a.Log})
+	}
+	// Exports can wait for idle resources
+	agent = append(agent, plist.Entry{Key: "ProcessType", Value: "Background"})
+	return plist.Write(w, agent)
+}
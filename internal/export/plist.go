@@ -0,0 +1,76 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/plist"
+)
+
+// WritePlist writes the document's tags as an XML property list, for
+// macOS tools and launchers: a folders array with each folder's path,
+// name, tags and note, and a tags dictionary of each tag's folders
+func WritePlist(w io.Writer, data *Data) error {
+	folders, byFolder := folderTags(data)
+	items := make([]any, 0, len(folders))
+	for _, folder := range folders {
+		item := plist.Dict{
+			{Key: "path", Value: folder},
+			{Key: "name", Value: folderName(folder)},
+			{Key: "tags", Value: byFolder[folder]},
+		}
+		if note := data.Notes[folder]; note != "" {
+			item = append(item, plist.Entry{Key: "note", Value: note})
+		}
+		items = append(items, item)
+	}
+
+	tags := make(plist.Dict, 0, len(data.Tags))
+	for _, tagName := range sortedTags(data) {
+		tags = append(tags, plist.Entry{Key: tagName, Value: data.Tags[tagName]})
+	}
+
+	return plist.Write(w, plist.Dict{
+		{Key: "version", Value: data.Version},
+		{Key: "folders", Value: items},
+		{Key: "tags", Value: tags},
+	})
+}
+
+// WriteLocate writes every local folder's path on a line of its own, sorted
+// and without duplicates, as locate database tools such as frcode expect
+func WriteLocate(w io.Writer, data *Data) error {
+	folders, _ := folderTags(data)
+	bw := bufio.NewWriter(w)
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		if _, err := fmt.Fprintln(bw, folder); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// folderName returns the name a folder is shown with
+func folderName(folder string) string {
+	if loc, ok := location.Parse(folder); ok {
+		return filepath.Base(loc.Path)
+	}
+	return filepath.Base(folder)
+}
+
+// sortedTags returns the document's tag names in order
+func sortedTags(data *Data) []string {
+	tags := make([]string, 0, len(data.Tags))
+	for tagName := range data.Tags {
+		tags = append(tags, tagName)
+	}
+	sort.Strings(tags)
+	return tags
+}
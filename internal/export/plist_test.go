@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePlist(t *testing.T) {
+	data := &Data{
+		Version: CurrentVersion,
+		Tags: map[string][]string{
+			"work": {"/code/web", "/code/api"},
+			"r&d":  {"/code/api"},
+		},
+		Notes: map[string]string{"/code/api": "Main <API>"},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlist(&buf, data); err != nil {
+		t.Fatalf("WritePlist failed: %v", err)
+	}
+	expected := `<plist version="1.0">
+<dict>
+	<key>version</key>
+	<integer>2</integer>
+	<key>folders</key>
+	<array>
+		<dict>
+			<key>path</key>
+			<string>/code/api</string>
+			<key>name</key>
+			<string>api</string>
+			<key>tags</key>
+			<array>
+				<string>r&amp;d</string>
+				<string>work</string>
+			</array>
+			<key>note</key>
+			<string>Main &lt;API&gt;</string>
+		</dict>
+		<dict>
+			<key>path</key>
+			<string>/code/web</string>
+			<key>name</key>
+			<string>web</string>
+			<key>tags</key>
+			<array>
+				<string>work</string>
+			</array>
+		</dict>
+	</array>
+	<key>tags</key>
+	<dict>
+		<key>r&amp;d</key>
+		<array>
+			<string>/code/api</string>
+		</array>
+		<key>work</key>
+		<array>
+			<string>/code/web</string>
+			<string>/code/api</string>
+		</array>
+	</dict>
+</dict>
+</plist>
+`
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("WritePlist =\n%s\nwant\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	data.Tags["work"] = append(data.Tags["work"], "ssh://box/srv/app")
+	if err := WriteLocate(&buf, data); err != nil {
+		t.Fatalf("WriteLocate failed: %v", err)
+	}
+	if buf.String() != "/code/api\n/code/web\n" {
+		t.Errorf("WriteLocate = %q", buf.String())
+	}
+}
+
+func TestWriteLaunchAgent(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteLaunchAgent(&buf, &LaunchAgent{
+		Args:       []string{"/usr/local/bin/scope", "export", "--output", "/Users/me/folders.plist"},
+		WatchPaths: []string{"/Users/me/.config/scope"},
+		Interval:   time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("WriteLaunchAgent failed: %v", err)
+	}
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>" + LaunchAgentLabel + "</string>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>StartInterval</key>\n\t<integer>3600</integer>",
+		"\t\t<string>/Users/me/.config/scope</string>",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Launch agent lacks %q:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "StandardErrorPath") {
+		t.Error("Launch agent without a log should not set StandardErrorPath")
+	}
+
+	if err := WriteLaunchAgent(&buf, &LaunchAgent{}); err == nil {
+		t.Error("WriteLaunchAgent should fail without a command")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tags.txt")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	failed := WriteFile(path, func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return os.ErrClosed
+	})
+	if failed == nil {
+		t.Fatal("WriteFile should report the write's error")
+	}
+	if content, _ := os.ReadFile(path); string(content) != "old" {
+		t.Errorf("A failed export replaced the file with %q", content)
+	}
+
+	if err := WriteFile(path, func(w io.Writer) error {
+		_, err := w.Write([]byte("new"))
+		return err
+	}); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "new" {
+		t.Errorf("File holds %q", content)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,79 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/gabssanto/Scope/internal/location"
+)
+
+// terminalProfile is a Windows Terminal profile. Terminal derives its GUID
+// from the name, so a profile keeps its settings across exports.
+type terminalProfile struct {
+	Name              string `json:"name"`
+	TabTitle          string `json:"tabTitle"`
+	StartingDirectory string `json:"startingDirectory,omitempty"`
+	Commandline       string `json:"commandline,omitempty"`
+}
+
+// WriteTerminalFragment writes a Windows Terminal fragment with a profile
+// per tag, opening in the tag's first folder. A remote first folder gets a
+// shell on its host instead; a local one uses the shell in Terminal's
+// profile defaults.
+func WriteTerminalFragment(w io.Writer, data *Data) error {
+	profiles := make([]terminalProfile, 0, len(data.Tags))
+	for _, tagName := range sortedTags(data) {
+		folders := data.Tags[tagName]
+		if len(folders) == 0 {
+			continue
+		}
+		p := terminalProfile{Name: "Scope: " + tagName, TabTitle: tagName}
+		if loc, ok := location.Parse(folders[0]); ok {
+			p.Commandline = loc.ShellCommand()
+		} else {
+			p.StartingDirectory = folders[0]
+		}
+		profiles = append(profiles, p)
+	}
+	return writeJSON(w, struct {
+		Profiles []terminalProfile `json:"profiles"`
+	}{profiles})
+}
+
+// launcherEntry is a folder as PowerToys Run plugins show results
+type launcherEntry struct {
+	Title    string   `json:"Title"`
+	SubTitle string   `json:"SubTitle"`
+	Path     string   `json:"Path"`
+	Tags     []string `json:"Tags"`
+	Note     string   `json:"Note,omitempty"`
+}
+
+// WritePowerToys writes every local folder as JSON for a PowerToys Run
+// plugin (or another launcher) to list: the folder's name as the title, its
+// path as the subtitle, and its tags and note to search by
+func WritePowerToys(w io.Writer, data *Data) error {
+	folders, byFolder := folderTags(data)
+	entries := make([]launcherEntry, 0, len(folders))
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		entries = append(entries, launcherEntry{
+			Title:    folderName(folder),
+			SubTitle: folder,
+			Path:     folder,
+			Tags:     byFolder[folder],
+			Note:     data.Notes[folder],
+		})
+	}
+	return writeJSON(w, entries)
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
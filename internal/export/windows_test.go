@@ -0,0 +1,66 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteTerminalFragment(t *testing.T) {
+	data := &Data{
+		Version: CurrentVersion,
+		Tags: map[string][]string{
+			"work":   {`C:\code\web`, `C:\code\api`},
+			"box":    {"ssh://box/srv/app"},
+			"unused": {},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTerminalFragment(&buf, data); err != nil {
+		t.Fatalf("WriteTerminalFragment failed: %v", err)
+	}
+	var fragment struct {
+		Profiles []terminalProfile `json:"profiles"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fragment); err != nil {
+		t.Fatalf("Invalid fragment: %v\n%s", err, buf.String())
+	}
+	if len(fragment.Profiles) != 2 {
+		t.Fatalf("Expected 2 profiles, got %+v", fragment.Profiles)
+	}
+	box, work := fragment.Profiles[0], fragment.Profiles[1]
+	if box.Name != "Scope: box" || box.StartingDirectory != "" || box.Commandline == "" {
+		t.Errorf("Unexpected remote profile %+v", box)
+	}
+	if work.Name != "Scope: work" || work.TabTitle != "work" || work.StartingDirectory != `C:\code\web` || work.Commandline != "" {
+		t.Errorf("Unexpected local profile %+v", work)
+	}
+}
+
+func TestWritePowerToys(t *testing.T) {
+	data := &Data{
+		Version: CurrentVersion,
+		Tags: map[string][]string{
+			"work": {"/code/web", "/code/api", "ssh://box/srv/app"},
+			"go":   {"/code/api"},
+		},
+		Notes: map[string]string{"/code/api": "Main API"},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePowerToys(&buf, data); err != nil {
+		t.Fatalf("WritePowerToys failed: %v", err)
+	}
+	var entries []launcherEntry
+	if err := json.Unmarshal(buf.Bytes(), &entries); err != nil {
+		t.Fatalf("Invalid listing: %v\n%s", err, buf.String())
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected the 2 local folders, got %+v", entries)
+	}
+	api := entries[0]
+	if api.Title != "api" || api.Path != "/code/api" || api.Note != "Main API" || len(api.Tags) != 2 {
+		t.Errorf("Unexpected entry %+v", api)
+	}
+}
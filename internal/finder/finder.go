@@ -0,0 +1,127 @@
+// Package finder reads and writes macOS Finder tags, so scope tags can be
+// shown in Finder and folders tagged in Finder can be picked up by scope.
+// Finder keeps a folder's tags in the com.apple.metadata:_kMDItemUserTags
+// extended attribute, as "Name\nColor" strings in a binary property list.
+package finder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Attr is the extended attribute Finder keeps tags in
+const Attr = "com.apple.metadata:_kMDItemUserTags"
+
+// ErrUnsupported is returned for Finder tags outside macOS
+var ErrUnsupported = errors.New("finder tags are only supported on macOS")
+
+// Color is one of Finder's tag colors
+type Color int
+
+// The colors in Finder's order
+const (
+	None Color = iota
+	Gray
+	Green
+	Purple
+	Blue
+	Yellow
+	Red
+	Orange
+)
+
+var colorNames = []string{"none", "gray", "green", "purple", "blue", "yellow", "red", "orange"}
+
+// String returns the color's name
+func (c Color) String() string {
+	if c < 0 || int(c) >= len(colorNames) {
+		return strconv.Itoa(int(c))
+	}
+	return colorNames[c]
+}
+
+// ParseColor returns the color called name; empty is None
+func ParseColor(name string) (Color, error) {
+	if name == "" {
+		return None, nil
+	}
+	for i, n := range colorNames {
+		if strings.EqualFold(name, n) || (n == "gray" && strings.EqualFold(name, "grey")) {
+			return Color(i), nil
+		}
+	}
+	return None, fmt.Errorf("unknown Finder color %q (expected one of %s)", name, strings.Join(colorNames, ", "))
+}
+
+// Tag is a Finder tag
+type Tag struct {
+	Name  string
+	Color Color
+}
+
+// label returns the tag as Finder stores it
+func (t Tag) label() string {
+	if t.Color == None {
+		return t.Name
+	}
+	return t.Name + "\n" + strconv.Itoa(int(t.Color))
+}
+
+// parseLabel reads a tag as Finder stores it
+func parseLabel(s string) Tag {
+	name, color, ok := strings.Cut(s, "\n")
+	if !ok {
+		return Tag{Name: s}
+	}
+	n, err := strconv.Atoi(color)
+	if err != nil || n < 0 || n >= len(colorNames) {
+		n = 0
+	}
+	return Tag{Name: name, Color: Color(n)}
+}
+
+// Read returns the Finder tags of path
+func Read(path string) ([]Tag, error) {
+	data, err := getxattr(path, Attr)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	labels, err := decodeStrings(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Finder tags of %s: %w", path, err)
+	}
+	tags := make([]Tag, len(labels))
+	for i, l := range labels {
+		tags[i] = parseLabel(l)
+	}
+	return tags, nil
+}
+
+// Write replaces the Finder tags of path, removing the attribute when tags
+// is empty
+func Write(path string, tags []Tag) error {
+	if len(tags) == 0 {
+		return removexattr(path, Attr)
+	}
+	labels := make([]string, len(tags))
+	for i, t := range tags {
+		labels[i] = t.label()
+	}
+	if err := setxattr(path, Attr, encodeStrings(labels)); err != nil {
+		return fmt.Errorf("failed to write Finder tags of %s: %w", path, err)
+	}
+	return nil
+}
+
+// index returns the position of the tag called name in tags, or -1.
+// Finder compares tag names without case.
+func index(tags []Tag, name string) int {
+	for i, t := range tags {
+		if strings.EqualFold(t.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,213 @@
+package finder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestDecodeStrings(t *testing.T) {
+	// ["Red\n6", "Café"] as written by Python's plistlib
+	data, _ := hex.DecodeString("62706c6973743030a20102555265640a366400430061006600e9080b11000000000000010100000000000000030000000000000000000000000000001a")
+	strs, err := decodeStrings(data)
+	if err != nil {
+		t.Fatalf("decodeStrings failed: %v", err)
+	}
+	if !reflect.DeepEqual(strs, []string{"Red\n6", "Café"}) {
+		t.Errorf("decodeStrings = %q", strs)
+	}
+
+	for _, bad := range [][]byte{nil, []byte("bplist00"), data[:len(data)-1], append([]byte("xplist00"), data[8:]...)} {
+		if _, err := decodeStrings(bad); err == nil {
+			t.Errorf("decodeStrings(%x) should fail", bad)
+		}
+	}
+}
+
+func TestEncodeStrings(t *testing.T) {
+	many := make([]string, 300)
+	for i := range many {
+		many[i] = fmt.Sprintf("tag %d", i)
+	}
+	tests := [][]string{
+		{},
+		{"Work"},
+		{"Red\n6", "Café", "日本語"},
+		{"a long tag name of more than fifteen characters"},
+		many,
+	}
+
+	for _, strs := range tests {
+		got, err := decodeStrings(encodeStrings(strs))
+		if err != nil {
+			t.Fatalf("decodeStrings failed for %d strings: %v", len(strs), err)
+		}
+		if len(strs) == 0 && len(got) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, strs) {
+			t.Errorf("Round trip = %q, want %q", got, strs)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		color Color
+		ok    bool
+	}{
+		{"", None, true},
+		{"red", Red, true},
+		{"Orange", Orange, true},
+		{"grey", Gray, true},
+		{"pink", None, false},
+	}
+
+	for _, tt := range tests {
+		color, err := ParseColor(tt.name)
+		if (err == nil) != tt.ok || color != tt.color {
+			t.Errorf("ParseColor(%q) = %v, %v", tt.name, color, err)
+		}
+	}
+
+	if got := parseLabel("Work\n6"); got != (Tag{Name: "Work", Color: Red}) {
+		t.Errorf("parseLabel = %+v", got)
+	}
+	if got := (Tag{Name: "Work", Color: Red}).label(); got != "Work\n6" {
+		t.Errorf("label = %q", got)
+	}
+}
+
+// fakeFinder replaces the extended attributes and Spotlight with a map
+func fakeFinder(t *testing.T) map[string][]Tag {
+	attrs := make(map[string][]Tag)
+	oldRead, oldWrite, oldSearch := readTags, writeTags, search
+	readTags = func(path string) ([]Tag, error) {
+		return append([]Tag(nil), attrs[path]...), nil
+	}
+	writeTags = func(path string, tags []Tag) error {
+		attrs[path] = tags
+		return nil
+	}
+	search = func(name string) ([]string, error) {
+		var found []string
+		for path, tags := range attrs {
+			if index(tags, name) >= 0 {
+				found = append(found, path)
+			}
+		}
+		return found, nil
+	}
+	t.Cleanup(func() { readTags, writeTags, search = oldRead, oldWrite, oldSearch })
+	return attrs
+}
+
+func TestSync(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	api, web, docs := filepath.Join(root, "api"), filepath.Join(root, "web"), filepath.Join(root, "docs")
+	for _, dir := range []string{api, web, docs} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+	}
+
+	store, err := db.Open(filepath.Join(root, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := tag.NewManager(store)
+	if err := m.AddTag(api, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.AddTag(web, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	attrs := fakeFinder(t)
+	attrs[web] = []Tag{{Name: "work", Color: Blue}, {Name: "Other"}}
+	attrs[docs] = []Tag{{Name: "Reading", Color: Green}}
+
+	mapping := Mapping{"work": {Name: "Work", Color: Red}, "reading": {Name: "Reading", Color: Green}}
+
+	result, err := Sync(m, mapping, true)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.ToFinder) != 2 || len(result.ToScope) != 1 || result.ToScope[0].Path != docs {
+		t.Fatalf("Unexpected dry run result %+v", result)
+	}
+	if len(attrs[api]) != 0 {
+		t.Error("Dry run should not write Finder tags")
+	}
+	if folders, _ := m.ListFoldersByTag("reading"); len(folders) != 0 {
+		t.Error("Dry run should not tag folders")
+	}
+
+	if _, err := Sync(m, mapping, false); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !reflect.DeepEqual(attrs[api], []Tag{{Name: "Work", Color: Red}}) {
+		t.Errorf("Finder tags of api = %+v", attrs[api])
+	}
+	// The existing tag is recolored and keeps its name
+	if !reflect.DeepEqual(attrs[web], []Tag{{Name: "work", Color: Red}, {Name: "Other"}}) {
+		t.Errorf("Finder tags of web = %+v", attrs[web])
+	}
+	if folders, _ := m.ListFoldersByTag("reading"); !reflect.DeepEqual(folders, []string{docs}) {
+		t.Errorf("Folders tagged reading = %v", folders)
+	}
+
+	// In step now
+	result, err = Sync(m, mapping, false)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if len(result.ToFinder) != 0 || len(result.ToScope) != 0 {
+		t.Errorf("Second sync changed %+v", result)
+	}
+}
+
+func TestObserve(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(root, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := tag.NewManager(store)
+
+	attrs := fakeFinder(t)
+	attrs[root] = []Tag{{Name: "Other"}}
+	Observe(m, Mapping{"work": {Name: "Work", Color: Red}})
+
+	if err := m.AddTag(root, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.AddTag(root, "unmapped"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if !reflect.DeepEqual(attrs[root], []Tag{{Name: "Other"}, {Name: "Work", Color: Red}}) {
+		t.Errorf("Finder tags after tagging = %+v", attrs[root])
+	}
+
+	if err := m.RemoveTag(root, "work"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if !reflect.DeepEqual(attrs[root], []Tag{{Name: "Other"}}) {
+		t.Errorf("Finder tags after untagging = %+v", attrs[root])
+	}
+}
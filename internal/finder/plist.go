@@ -0,0 +1,231 @@
+package finder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+)
+
+// The extended attribute holding Finder tags is a binary property list of
+// an array of strings. Only that much of the format is implemented.
+
+const plistMagic = "bplist00"
+
+const (
+	plistInt    = 0x10
+	plistASCII  = 0x50
+	plistUTF16  = 0x60
+	plistArray  = 0xA0
+	trailerSize = 32
+)
+
+var errBadPlist = errors.New("malformed binary property list")
+
+// encodeStrings returns a binary property list of an array of strs
+func encodeStrings(strs []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(plistMagic)
+
+	count := len(strs) + 1
+	refSize := byteSize(uint64(count))
+	offsets := make([]uint64, 0, count)
+
+	// The array is object 0, its strings objects 1 to n
+	offsets = append(offsets, uint64(buf.Len()))
+	writeMarker(&buf, plistArray, len(strs))
+	for i := range strs {
+		writeUint(&buf, uint64(i+1), refSize)
+	}
+	for _, s := range strs {
+		offsets = append(offsets, uint64(buf.Len()))
+		if isASCII(s) {
+			writeMarker(&buf, plistASCII, len(s))
+			buf.WriteString(s)
+			continue
+		}
+		units := utf16.Encode([]rune(s))
+		writeMarker(&buf, plistUTF16, len(units))
+		for _, u := range units {
+			_ = binary.Write(&buf, binary.BigEndian, u)
+		}
+	}
+
+	tableOffset := uint64(buf.Len())
+	offsetSize := byteSize(tableOffset)
+	for _, off := range offsets {
+		writeUint(&buf, off, offsetSize)
+	}
+
+	var trailer [trailerSize]byte
+	trailer[6] = byte(offsetSize)
+	trailer[7] = byte(refSize)
+	binary.BigEndian.PutUint64(trailer[8:], uint64(count))
+	binary.BigEndian.PutUint64(trailer[16:], 0)
+	binary.BigEndian.PutUint64(trailer[24:], tableOffset)
+	buf.Write(trailer[:])
+	return buf.Bytes()
+}
+
+// decodeStrings reads a binary property list holding an array of strings
+func decodeStrings(data []byte) ([]string, error) {
+	if len(data) < len(plistMagic)+trailerSize || string(data[:6]) != plistMagic[:6] {
+		return nil, errBadPlist
+	}
+	trailer := data[len(data)-trailerSize:]
+	offsetSize, refSize := int(trailer[6]), int(trailer[7])
+	count := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:])
+	if offsetSize < 1 || offsetSize > 8 || refSize < 1 || refSize > 8 || top >= count ||
+		tableOffset > uint64(len(data)) || count > (uint64(len(data))-tableOffset)/uint64(offsetSize) {
+		return nil, errBadPlist
+	}
+
+	offset := func(ref uint64) (int, error) {
+		if ref >= count {
+			return 0, errBadPlist
+		}
+		start := int(tableOffset) + int(ref)*offsetSize
+		off := readUint(data[start : start+offsetSize])
+		if off >= tableOffset {
+			return 0, errBadPlist
+		}
+		return int(off), nil
+	}
+
+	pos, err := offset(top)
+	if err != nil {
+		return nil, err
+	}
+	marker := data[pos]
+	if marker&0xF0 != plistArray {
+		return nil, fmt.Errorf("%w: expected an array", errBadPlist)
+	}
+	n, pos, err := readLength(data, pos)
+	if err != nil {
+		return nil, err
+	}
+	if pos+n*refSize > int(tableOffset) {
+		return nil, errBadPlist
+	}
+
+	strs := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		start := pos + i*refSize
+		at, err := offset(readUint(data[start : start+refSize]))
+		if err != nil {
+			return nil, err
+		}
+		s, err := readString(data[:tableOffset], at)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, s)
+	}
+	return strs, nil
+}
+
+// readString reads the string object at pos
+func readString(data []byte, pos int) (string, error) {
+	kind := data[pos] & 0xF0
+	n, pos, err := readLength(data, pos)
+	if err != nil {
+		return "", err
+	}
+	switch kind {
+	case plistASCII:
+		if pos+n > len(data) {
+			return "", errBadPlist
+		}
+		return string(data[pos : pos+n]), nil
+	case plistUTF16:
+		if pos+2*n > len(data) {
+			return "", errBadPlist
+		}
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(data[pos+2*i:])
+		}
+		return string(utf16.Decode(units)), nil
+	}
+	return "", fmt.Errorf("%w: expected a string", errBadPlist)
+}
+
+// readLength reads the length of the object at pos, which is in the low
+// bits of its marker or, when those are all set, an integer following it.
+// It returns the length and the position of the object's contents.
+func readLength(data []byte, pos int) (int, int, error) {
+	n := int(data[pos] & 0x0F)
+	pos++
+	if n != 0x0F {
+		return n, pos, nil
+	}
+	if pos >= len(data) || data[pos]&0xF0 != plistInt {
+		return 0, 0, errBadPlist
+	}
+	size := 1 << (data[pos] & 0x0F)
+	pos++
+	if size > 8 || pos+size > len(data) {
+		return 0, 0, errBadPlist
+	}
+	length := readUint(data[pos : pos+size])
+	if length > uint64(len(data)) {
+		return 0, 0, errBadPlist
+	}
+	return int(length), pos + size, nil
+}
+
+// writeMarker writes an object marker with length n
+func writeMarker(buf *bytes.Buffer, kind byte, n int) {
+	if n < 0x0F {
+		buf.WriteByte(kind | byte(n))
+		return
+	}
+	buf.WriteByte(kind | 0x0F)
+	size := byteSize(uint64(n))
+	// Integer sizes are powers of two
+	exp := 0
+	for 1<<exp < size {
+		exp++
+	}
+	buf.WriteByte(plistInt | byte(exp))
+	writeUint(buf, uint64(n), 1<<exp)
+}
+
+// byteSize returns the number of bytes needed to hold n
+func byteSize(n uint64) int {
+	size := 1
+	for n > 0xFF {
+		n >>= 8
+		size++
+	}
+	return size
+}
+
+// writeUint writes n big endian in size bytes
+func writeUint(buf *bytes.Buffer, n uint64, size int) {
+	for i := size - 1; i >= 0; i-- {
+		buf.WriteByte(byte(n >> (8 * i)))
+	}
+}
+
+// readUint reads a big endian unsigned integer
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// isASCII reports whether s is plain ASCII
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
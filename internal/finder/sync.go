@@ -0,0 +1,228 @@
+package finder
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// Mapping maps scope tags to the Finder tags they are shown as. Only
+// mapped tags are synced.
+type Mapping map[string]Tag
+
+// Update is a tag added to a folder by Sync
+type Update struct {
+	Path string
+	// Tag is the scope tag
+	Tag string
+	// Finder is the Finder tag
+	Finder Tag
+}
+
+// SyncResult lists the tags Sync added on each side
+type SyncResult struct {
+	// ToFinder are Finder tags added to, or recolored on, folders tagged in
+	// scope
+	ToFinder []Update
+	// ToScope are scope tags added to folders tagged in Finder
+	ToScope []Update
+}
+
+// Supported reports whether Finder tags can be read and written here
+func Supported() bool {
+	return supported
+}
+
+// readTags, writeTags and search reach the file system and Spotlight;
+// tests may replace them
+var (
+	readTags  = Read
+	writeTags = Write
+	search    = spotlight
+)
+
+// Sync brings scope and Finder tags together for the mapped tags. Folders
+// tagged in scope get the Finder tag, in the mapped color; folders with the
+// Finder tag, found by Spotlight or among the folders scope knows, get the
+// scope tag. Tags are only ever added: a tag missing on one side can't be
+// told apart from one removed on the other, so removals are left to
+// whichever side they were made on (see Observe). With dryRun nothing is
+// changed.
+func Sync(m *tag.Manager, mapping Mapping, dryRun bool) (*SyncResult, error) {
+	folderTags, err := m.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+
+	// Scope to Finder
+	for _, folder := range sortedKeys(folderTags) {
+		if location.IsRemote(folder) {
+			continue
+		}
+		var wanted []string
+		for _, tagName := range folderTags[folder] {
+			if _, ok := mapping[tagName]; ok {
+				wanted = append(wanted, tagName)
+			}
+		}
+		if len(wanted) == 0 {
+			continue
+		}
+		current, err := readTags(folder)
+		if err != nil {
+			return nil, err
+		}
+		changed := false
+		for _, tagName := range wanted {
+			ft := mapping[tagName]
+			switch i := index(current, ft.Name); {
+			case i < 0:
+				current = append(current, ft)
+			case current[i].Color != ft.Color:
+				current[i].Color = ft.Color
+			default:
+				continue
+			}
+			changed = true
+			result.ToFinder = append(result.ToFinder, Update{Path: folder, Tag: tagName, Finder: ft})
+		}
+		if changed && !dryRun {
+			if err := writeTags(folder, current); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Finder to scope
+	for _, tagName := range sortedKeys(mapping) {
+		ft := mapping[tagName]
+		candidates, err := search(ft.Name)
+		if err != nil {
+			return nil, err
+		}
+		for folder := range folderTags {
+			if !location.IsRemote(folder) {
+				candidates = append(candidates, folder)
+			}
+		}
+		sort.Strings(candidates)
+
+		var folders []string
+		seen := make(map[string]bool)
+		for _, folder := range candidates {
+			if seen[folder] || slices.Contains(folderTags[folder], tagName) {
+				continue
+			}
+			seen[folder] = true
+			if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+				continue
+			}
+			current, err := readTags(folder)
+			if err != nil {
+				return nil, err
+			}
+			if index(current, ft.Name) >= 0 {
+				folders = append(folders, folder)
+			}
+		}
+		if len(folders) == 0 {
+			continue
+		}
+		if !dryRun {
+			if folders, err = m.TagFolders(folders, tagName); err != nil {
+				return nil, err
+			}
+		}
+		for _, folder := range folders {
+			result.ToScope = append(result.ToScope, Update{Path: folder, Tag: tagName, Finder: ft})
+		}
+	}
+	return result, nil
+}
+
+// Observe keeps Finder tags in step with changes made through m: a mapped
+// tag added to or removed from a folder is added to or removed from its
+// Finder tags. Failures are reported as warnings, since the scope change
+// has already been made.
+func Observe(m *tag.Manager, mapping Mapping) {
+	m.Observe(func(c tag.Change) {
+		var err error
+		switch c.Op {
+		case tag.OpAdd:
+			if ft, ok := mapping[c.Tag]; ok && !location.IsRemote(c.Path) {
+				err = update(c.Path, func(tags []Tag) []Tag {
+					if i := index(tags, ft.Name); i >= 0 {
+						tags[i].Color = ft.Color
+						return tags
+					}
+					return append(tags, ft)
+				})
+			}
+		case tag.OpRemove:
+			if ft, ok := mapping[c.Tag]; ok && !location.IsRemote(c.Path) {
+				err = update(c.Path, func(tags []Tag) []Tag {
+					if i := index(tags, ft.Name); i >= 0 {
+						return append(tags[:i], tags[i+1:]...)
+					}
+					return tags
+				})
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	})
+}
+
+// update rewrites the Finder tags of path with fn, if that changes them
+func update(path string, fn func([]Tag) []Tag) error {
+	current, err := readTags(path)
+	if err != nil {
+		return err
+	}
+	before := append([]Tag(nil), current...)
+	after := fn(current)
+	if slices.Equal(before, after) {
+		return nil
+	}
+	return writeTags(path, after)
+}
+
+// spotlight returns the folders Spotlight knows to have the Finder tag
+// called name, or nothing when Spotlight isn't available
+func spotlight(name string) ([]string, error) {
+	mdfind, err := exec.LookPath("mdfind")
+	if err != nil {
+		return nil, nil
+	}
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(name)
+	out, err := exec.Command(mdfind, "-0", `kMDItemUserTags == "`+quoted+`"c && kMDItemContentType == "public.folder"`).Output()
+	if err != nil {
+		return nil, fmt.Errorf("mdfind failed: %w", err)
+	}
+	var folders []string
+	for _, path := range bytes.Split(out, []byte{0}) {
+		if len(path) > 0 {
+			folders = append(folders, string(path))
+		}
+	}
+	return folders, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,51 @@
+//go:build darwin
+
+package finder
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// supported is true: Finder tags live on macOS
+const supported = true
+
+// getxattr returns the attribute of path, or nil when it isn't set
+func getxattr(path, attr string) ([]byte, error) {
+	for {
+		size, err := unix.Getxattr(path, attr, nil)
+		if errors.Is(err, unix.ENOATTR) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := unix.Getxattr(path, attr, buf)
+		// The attribute grew in between
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if errors.Is(err, unix.ENOATTR) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// setxattr sets the attribute of path
+func setxattr(path, attr string, data []byte) error {
+	return unix.Setxattr(path, attr, data, 0)
+}
+
+// removexattr removes the attribute of path if it is set
+func removexattr(path, attr string) error {
+	if err := unix.Removexattr(path, attr); err != nil && !errors.Is(err, unix.ENOATTR) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !darwin
+
+package finder
+
+// supported is false: there is no Finder outside macOS
+const supported = false
+
+// getxattr is unsupported outside macOS, where Finder tags don't exist
+func getxattr(path, attr string) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+// setxattr is unsupported outside macOS
+func setxattr(path, attr string, data []byte) error {
+	return ErrUnsupported
+}
+
+// removexattr is unsupported outside macOS
+func removexattr(path, attr string) error {
+	return ErrUnsupported
+}
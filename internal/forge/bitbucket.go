@@ -0,0 +1,154 @@
+package forge
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bitbucket talks to the Bitbucket Cloud REST API (2.0)
+type bitbucket struct {
+	host string
+	api  *api
+}
+
+func newBitbucket(host, token string) *bitbucket {
+	a := newAPI("Bitbucket", "https://api.bitbucket.org/2.0", token)
+	// App passwords are given as user:password
+	a.basic = strings.Contains(token, ":")
+	return &bitbucket{host: host, api: a}
+}
+
+func (b *bitbucket) Kind() Kind          { return Bitbucket }
+func (b *bitbucket) Authenticated() bool { return b.api.token != "" }
+
+// repository returns the API path of repo: its workspace and slug
+func (b *bitbucket) repository(repo Repo) string {
+	return "/repositories/" + url.PathEscape(repo.Owner) + "/" + url.PathEscape(repo.Name)
+}
+
+func (b *bitbucket) Details(repo Repo) (*Details, error) {
+	var info struct {
+		Description string `json:"description"`
+		MainBranch  struct {
+			Name string `json:"name"`
+		} `json:"mainbranch"`
+	}
+	if err := b.api.get(b.repository(repo), nil, &info); err != nil {
+		return nil, err
+	}
+	// Bitbucket has neither topics nor archived repositories
+	return &Details{Description: info.Description, DefaultBranch: info.MainBranch.Name}, nil
+}
+
+func (b *bitbucket) LatestRun(repo Repo, branch string) (*Run, error) {
+	if branch == "" {
+		details, err := b.Details(repo)
+		if err != nil {
+			return nil, err
+		}
+		branch = details.DefaultBranch
+	}
+	var page struct {
+		Values []struct {
+			BuildNumber int `json:"build_number"`
+			State       struct {
+				Name   string `json:"name"`
+				Result struct {
+					Name string `json:"name"`
+				} `json:"result"`
+			} `json:"state"`
+			Target struct {
+				RefName string `json:"ref_name"`
+			} `json:"target"`
+			CreatedOn   time.Time `json:"created_on"`
+			CompletedOn time.Time `json:"completed_on"`
+		} `json:"values"`
+	}
+	query := url.Values{"sort": {"-created_on"}, "pagelen": {"1"}, "target.branch": {branch}}
+	if err := b.api.get(b.repository(repo)+"/pipelines/", query, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+	p := page.Values[0]
+	updated := p.CompletedOn
+	if updated.IsZero() {
+		updated = p.CreatedOn
+	}
+	return &Run{
+		Name:      "pipeline #" + strconv.Itoa(p.BuildNumber),
+		Branch:    p.Target.RefName,
+		State:     bitbucketState(p.State.Name, p.State.Result.Name),
+		URL:       b.WebURL(repo) + "/pipelines/results/" + strconv.Itoa(p.BuildNumber),
+		UpdatedAt: updated,
+	}, nil
+}
+
+// bitbucketState maps a pipeline state and result to a run state
+func bitbucketState(state, result string) string {
+	if state != "COMPLETED" {
+		return StateRunning
+	}
+	switch result {
+	case "SUCCESSFUL":
+		return StatePassed
+	case "FAILED", "ERROR":
+		return StateFailed
+	default:
+		// STOPPED, EXPIRED
+		return StateOther
+	}
+}
+
+// CurrentUser returns the account's UUID, which pull requests refer to
+// their author and reviewers by
+func (b *bitbucket) CurrentUser() (string, error) {
+	var user bitbucketUser
+	if err := b.api.get("/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.UUID, nil
+}
+
+type bitbucketUser struct {
+	UUID string `json:"uuid"`
+}
+
+func (b *bitbucket) OpenPullRequests(repo Repo) ([]PullRequest, error) {
+	var page struct {
+		Values []struct {
+			ID    int    `json:"id"`
+			Title string `json:"title"`
+			Draft bool   `json:"draft"`
+			Links struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+			Author    bitbucketUser   `json:"author"`
+			Reviewers []bitbucketUser `json:"reviewers"`
+			UpdatedOn time.Time       `json:"updated_on"`
+		} `json:"values"`
+	}
+	query := url.Values{"state": {"OPEN"}, "sort": {"-updated_on"}, "pagelen": {"50"}}
+	if err := b.api.get(b.repository(repo)+"/pullrequests", query, &page); err != nil {
+		return nil, err
+	}
+
+	pulls := make([]PullRequest, 0, len(page.Values))
+	for _, v := range page.Values {
+		pr := PullRequest{Number: v.ID, Title: v.Title, URL: v.Links.HTML.Href, Draft: v.Draft, Author: v.Author.UUID, UpdatedAt: v.UpdatedOn}
+		for _, r := range v.Reviewers {
+			pr.Assignees = append(pr.Assignees, r.UUID)
+		}
+		pulls = append(pulls, pr)
+	}
+	return pulls, nil
+}
+
+func (b *bitbucket) WebURL(repo Repo) string {
+	return "https://" + b.host + "/" + repo.String()
+}
@@ -0,0 +1,63 @@
+package forge
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBitbucket(t *testing.T) {
+	b := newBitbucket("bitbucket.org", "ana:app-password")
+	testAPI(t, b.api, func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "ana" || password != "app-password" {
+			t.Errorf("Expected basic auth, got %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/repositories/acme/api":
+			_, _ = w.Write([]byte(`{"description": "API", "mainbranch": {"name": "develop"}}`))
+		case "/repositories/acme/api/pipelines/":
+			if r.URL.Query().Get("target.branch") != "develop" {
+				t.Errorf("Expected the main branch, got %q", r.URL.Query().Get("target.branch"))
+			}
+			_, _ = w.Write([]byte(`{"values": [{"build_number": 9, "state": {"name": "COMPLETED", "result": {"name": "SUCCESSFUL"}}, "target": {"ref_name": "develop"}}]}`))
+		case "/repositories/acme/api/pullrequests":
+			_, _ = w.Write([]byte(`{"values": [{"id": 3, "title": "Fix", "links": {"html": {"href": "https://bitbucket.org/acme/api/pull-requests/3"}}, "author": {"uuid": "{a}"}, "reviewers": [{"uuid": "{b}"}]}]}`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repo := Repo{"bitbucket.org", "acme", "api"}
+
+	run, err := b.LatestRun(repo, "")
+	if err != nil {
+		t.Fatalf("LatestRun failed: %v", err)
+	}
+	if run.State != StatePassed || run.URL != "https://bitbucket.org/acme/api/pipelines/results/9" {
+		t.Errorf("Unexpected run %+v", run)
+	}
+
+	pulls, err := b.OpenPullRequests(repo)
+	if err != nil {
+		t.Fatalf("OpenPullRequests failed: %v", err)
+	}
+	if len(pulls) != 1 || !pulls[0].AuthoredBy("{a}") || !pulls[0].AssignedTo("{b}") {
+		t.Errorf("Unexpected pull requests %+v", pulls)
+	}
+}
+
+func TestBitbucketState(t *testing.T) {
+	tests := []struct {
+		state, result, expected string
+	}{
+		{"COMPLETED", "SUCCESSFUL", StatePassed},
+		{"COMPLETED", "FAILED", StateFailed},
+		{"COMPLETED", "STOPPED", StateOther},
+		{"IN_PROGRESS", "", StateRunning},
+		{"PENDING", "", StateRunning},
+	}
+	for _, tt := range tests {
+		if got := bitbucketState(tt.state, tt.result); got != tt.expected {
+			t.Errorf("bitbucketState(%s, %s) = %s; expected %s", tt.state, tt.result, got, tt.expected)
+		}
+	}
+}
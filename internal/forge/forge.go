@@ -0,0 +1,232 @@
+// Package forge gives the remote-aware commands (ci, prs, enrich, open
+// --web) one interface over the services that host repositories: GitHub,
+// GitLab and Bitbucket, chosen by the host of a folder's git remote.
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Kind names a forge implementation
+type Kind string
+
+const (
+	GitHub    Kind = "github"
+	GitLab    Kind = "gitlab"
+	Bitbucket Kind = "bitbucket"
+)
+
+// ParseKind parses a forge kind as written in the config
+func ParseKind(s string) (Kind, error) {
+	switch Kind(s) {
+	case GitHub, GitLab, Bitbucket:
+		return Kind(s), nil
+	default:
+		return "", fmt.Errorf("unknown forge %q (expected github, gitlab or bitbucket)", s)
+	}
+}
+
+// knownHosts are the public forges, recognized without configuration
+var knownHosts = map[string]Kind{
+	"github.com":    GitHub,
+	"gitlab.com":    GitLab,
+	"bitbucket.org": Bitbucket,
+}
+
+// Repo identifies a repository on a forge
+type Repo struct {
+	Host string
+	// Owner is the user or organization; on GitLab it may be a group
+	// path with subgroups (acme/platform)
+	Owner string
+	Name  string
+}
+
+// String returns owner/name
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// ParseRemote extracts the repository from a git remote URL in any of the
+// forms git accepts: https://host/o/r.git, git@host:o/r.git or
+// ssh://git@host/o/r
+func ParseRemote(remote string) (Repo, bool) {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	switch {
+	case strings.Contains(remote, "://"):
+		u, err := url.Parse(remote)
+		if err != nil || u.Hostname() == "" {
+			return Repo{}, false
+		}
+		host, path = u.Hostname(), u.Path
+	case strings.Contains(remote, ":"):
+		// scp-like syntax: [user@]host:path
+		var ok bool
+		host, path, ok = strings.Cut(remote, ":")
+		if !ok || strings.Contains(host, "/") {
+			return Repo{}, false
+		}
+		if _, h, found := strings.Cut(host, "@"); found {
+			host = h
+		}
+	default:
+		return Repo{}, false
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	i := strings.LastIndex(path, "/")
+	if host == "" || i <= 0 || i == len(path)-1 {
+		return Repo{}, false
+	}
+	return Repo{Host: strings.ToLower(host), Owner: path[:i], Name: path[i+1:]}, true
+}
+
+// States of a CI run
+const (
+	StatePassed  = "passed"
+	StateFailed  = "failed"
+	StateRunning = "running"
+	StateOther   = "other"
+)
+
+// Run is the latest CI run of a branch: a GitHub Actions workflow run, a
+// GitLab pipeline or a Bitbucket pipeline
+type Run struct {
+	Name      string
+	Branch    string
+	State     string
+	URL       string
+	UpdatedAt time.Time
+}
+
+// PullRequest is an open pull (or merge) request. Author and Assignees are
+// user IDs comparable with what CurrentUser returns.
+type PullRequest struct {
+	Number    int
+	Title     string
+	URL       string
+	Draft     bool
+	Author    string
+	Assignees []string
+	UpdatedAt time.Time
+}
+
+// AuthoredBy reports whether user opened the pull request
+func (p PullRequest) AuthoredBy(user string) bool {
+	return strings.EqualFold(p.Author, user)
+}
+
+// AssignedTo reports whether user is one of the pull request's assignees
+// (reviewers, on Bitbucket)
+func (p PullRequest) AssignedTo(user string) bool {
+	for _, a := range p.Assignees {
+		if strings.EqualFold(a, user) {
+			return true
+		}
+	}
+	return false
+}
+
+// Details is what a forge knows about a repository
+type Details struct {
+	Description   string
+	DefaultBranch string
+	Archived      bool
+	Topics        []string
+}
+
+// Folder metadata keys set from Details by scope enrich
+const (
+	MetaDescription   = "forge.description"
+	MetaDefaultBranch = "forge.default_branch"
+	MetaArchived      = "forge.archived"
+	MetaTopics        = "forge.topics"
+)
+
+// Meta returns the details as folder metadata. Empty values clear what an
+// earlier fetch stored.
+func (d *Details) Meta() map[string]string {
+	archived := ""
+	if d.Archived {
+		archived = "true"
+	}
+	return map[string]string{
+		MetaDescription:   d.Description,
+		MetaDefaultBranch: d.DefaultBranch,
+		MetaArchived:      archived,
+		MetaTopics:        strings.Join(d.Topics, ","),
+	}
+}
+
+// Forge is a service hosting repositories
+type Forge interface {
+	// Kind returns which forge this is
+	Kind() Kind
+	// Authenticated reports whether requests carry a token
+	Authenticated() bool
+	// Details returns the description, default branch, archived status
+	// and topics of repo
+	Details(repo Repo) (*Details, error)
+	// LatestRun returns the latest CI run on branch (the default branch
+	// when empty), or nil if there is none
+	LatestRun(repo Repo, branch string) (*Run, error)
+	// CurrentUser returns the ID of the user the token belongs to
+	CurrentUser() (string, error)
+	// OpenPullRequests returns the open pull requests of repo, most
+	// recently updated first
+	OpenPullRequests(repo Repo) ([]PullRequest, error)
+	// WebURL returns the repository's page
+	WebURL(repo Repo) string
+}
+
+// Registry creates the forge of each host, once
+type Registry struct {
+	// Hosts are self-hosted forges by host name, added to the public ones
+	Hosts map[string]Kind
+	// Tokens returns the token for a forge; nil uses Token
+	Tokens func(kind Kind, host string) string
+
+	forges map[string]Forge
+}
+
+// For returns the forge repo is hosted on, or false for hosts that are not
+// a known forge
+func (r *Registry) For(repo Repo) (Forge, bool) {
+	if f, ok := r.forges[repo.Host]; ok {
+		return f, true
+	}
+	kind, ok := r.Hosts[repo.Host]
+	if !ok {
+		kind, ok = knownHosts[repo.Host]
+	}
+	if !ok {
+		return nil, false
+	}
+
+	tokens := r.Tokens
+	if tokens == nil {
+		tokens = Token
+	}
+	f := New(kind, repo.Host, tokens(kind, repo.Host))
+	if r.forges == nil {
+		r.forges = make(map[string]Forge)
+	}
+	r.forges[repo.Host] = f
+	return f, true
+}
+
+// New returns a client for the forge of kind at host
+func New(kind Kind, host, token string) Forge {
+	switch kind {
+	case GitLab:
+		return newGitLab(host, token)
+	case Bitbucket:
+		return newBitbucket(host, token)
+	default:
+		return newGitHub(host, token)
+	}
+}
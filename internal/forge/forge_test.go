@@ -0,0 +1,82 @@
+package forge
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		repo   Repo
+		ok     bool
+	}{
+		{"https://github.com/acme/api.git", Repo{"github.com", "acme", "api"}, true},
+		{"git@github.com:acme/api.git", Repo{"github.com", "acme", "api"}, true},
+		{"ssh://git@GitLab.com/acme/api", Repo{"gitlab.com", "acme", "api"}, true},
+		{"https://gitlab.com/acme/platform/api.git", Repo{"gitlab.com", "acme/platform", "api"}, true},
+		{"git@bitbucket.org:acme/api.git", Repo{"bitbucket.org", "acme", "api"}, true},
+		{"https://user:pw@git.acme.com:8443/team/api/", Repo{"git.acme.com", "team", "api"}, true},
+		{"https://github.com/acme", Repo{}, false},
+		{"/srv/git/api.git", Repo{}, false},
+		{"../api", Repo{}, false},
+	}
+	for _, tt := range tests {
+		repo, ok := ParseRemote(tt.remote)
+		if ok != tt.ok || repo != tt.repo {
+			t.Errorf("ParseRemote(%q) = %+v, %v; expected %+v, %v", tt.remote, repo, ok, tt.repo, tt.ok)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := &Registry{
+		Hosts:  map[string]Kind{"git.acme.com": GitLab},
+		Tokens: func(kind Kind, host string) string { return "" },
+	}
+	tests := []struct {
+		host string
+		kind Kind
+		ok   bool
+	}{
+		{"github.com", GitHub, true},
+		{"gitlab.com", GitLab, true},
+		{"bitbucket.org", Bitbucket, true},
+		{"git.acme.com", GitLab, true},
+		{"example.com", "", false},
+	}
+	for _, tt := range tests {
+		f, ok := r.For(Repo{Host: tt.host, Owner: "acme", Name: "api"})
+		if ok != tt.ok || (ok && f.Kind() != tt.kind) {
+			t.Errorf("For(%s) = %v, %v; expected %s, %v", tt.host, f, ok, tt.kind, tt.ok)
+		}
+	}
+
+	first, _ := r.For(Repo{Host: "git.acme.com"})
+	second, _ := r.For(Repo{Host: "git.acme.com"})
+	if first != second {
+		t.Error("Expected one forge per host")
+	}
+	if got := first.WebURL(Repo{"git.acme.com", "team/sub", "api"}); got != "https://git.acme.com/team/sub/api" {
+		t.Errorf("WebURL = %s", got)
+	}
+}
+
+func TestDetailsMeta(t *testing.T) {
+	meta := (&Details{Description: "API", DefaultBranch: "main", Archived: true, Topics: []string{"go", "payments"}}).Meta()
+	if meta[MetaDescription] != "API" || meta[MetaArchived] != "true" || meta[MetaTopics] != "go,payments" {
+		t.Errorf("Unexpected meta %v", meta)
+	}
+	if meta := (&Details{}).Meta(); meta[MetaArchived] != "" || meta[MetaTopics] != "" {
+		t.Errorf("Expected empty values to clear, got %v", meta)
+	}
+}
+
+// testAPI serves handler and points a's requests at it
+func testAPI(t *testing.T, a *api, handler http.HandlerFunc) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	a.baseURL = server.URL
+}
@@ -0,0 +1,77 @@
+package forge
+
+import "github.com/gabssanto/Scope/internal/github"
+
+// gitHub adapts the GitHub client to Forge
+type gitHub struct {
+	host   string
+	client *github.Client
+}
+
+// newGitHub returns a client for github.com, or for a GitHub Enterprise
+// server at host
+func newGitHub(host, token string) *gitHub {
+	client := github.NewClient(token)
+	if host != "github.com" {
+		client.BaseURL = "https://" + host + "/api/v3"
+	}
+	return &gitHub{host: host, client: client}
+}
+
+func (g *gitHub) Kind() Kind          { return GitHub }
+func (g *gitHub) Authenticated() bool { return g.client.Token != "" }
+
+func (g *gitHub) repo(r Repo) github.Repo {
+	return github.Repo{Owner: r.Owner, Name: r.Name}
+}
+
+func (g *gitHub) Details(repo Repo) (*Details, error) {
+	info, err := g.client.Repository(g.repo(repo))
+	if err != nil {
+		return nil, err
+	}
+	return &Details{
+		Description:   info.Description,
+		DefaultBranch: info.DefaultBranch,
+		Archived:      info.Archived,
+		Topics:        info.Topics,
+	}, nil
+}
+
+func (g *gitHub) LatestRun(repo Repo, branch string) (*Run, error) {
+	if branch == "" {
+		var err error
+		if branch, err = g.client.DefaultBranch(g.repo(repo)); err != nil {
+			return nil, err
+		}
+	}
+	run, err := g.client.LatestRun(g.repo(repo), branch)
+	if err != nil || run == nil {
+		return nil, err
+	}
+	return &Run{Name: run.Name, Branch: run.Branch, State: run.State(), URL: run.URL, UpdatedAt: run.UpdatedAt}, nil
+}
+
+func (g *gitHub) CurrentUser() (string, error) {
+	return g.client.CurrentUser()
+}
+
+func (g *gitHub) OpenPullRequests(repo Repo) ([]PullRequest, error) {
+	pulls, err := g.client.OpenPullRequests(g.repo(repo))
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]PullRequest, 0, len(pulls))
+	for _, p := range pulls {
+		pr := PullRequest{Number: p.Number, Title: p.Title, URL: p.URL, Draft: p.Draft, Author: p.User.Login, UpdatedAt: p.UpdatedAt}
+		for _, a := range p.Assignees {
+			pr.Assignees = append(pr.Assignees, a.Login)
+		}
+		converted = append(converted, pr)
+	}
+	return converted, nil
+}
+
+func (g *gitHub) WebURL(repo Repo) string {
+	return "https://" + g.host + "/" + repo.String()
+}
@@ -0,0 +1,142 @@
+package forge
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// gitLab talks to the GitLab REST API (v4) of gitlab.com or a self-hosted
+// instance
+type gitLab struct {
+	host string
+	api  *api
+}
+
+func newGitLab(host, token string) *gitLab {
+	return &gitLab{host: host, api: newAPI("GitLab", "https://"+host+"/api/v4", token)}
+}
+
+func (g *gitLab) Kind() Kind          { return GitLab }
+func (g *gitLab) Authenticated() bool { return g.api.token != "" }
+
+// project returns the API path of repo, whose ID is its URL-encoded path
+func (g *gitLab) project(repo Repo) string {
+	return "/projects/" + url.PathEscape(repo.String())
+}
+
+func (g *gitLab) Details(repo Repo) (*Details, error) {
+	var info struct {
+		Description   string   `json:"description"`
+		DefaultBranch string   `json:"default_branch"`
+		Archived      bool     `json:"archived"`
+		Topics        []string `json:"topics"`
+	}
+	if err := g.api.get(g.project(repo), nil, &info); err != nil {
+		return nil, err
+	}
+	return &Details{
+		Description:   info.Description,
+		DefaultBranch: info.DefaultBranch,
+		Archived:      info.Archived,
+		Topics:        info.Topics,
+	}, nil
+}
+
+func (g *gitLab) LatestRun(repo Repo, branch string) (*Run, error) {
+	if branch == "" {
+		details, err := g.Details(repo)
+		if err != nil {
+			return nil, err
+		}
+		branch = details.DefaultBranch
+	}
+	var pipelines []struct {
+		ID        int       `json:"id"`
+		Ref       string    `json:"ref"`
+		Status    string    `json:"status"`
+		Source    string    `json:"source"`
+		URL       string    `json:"web_url"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	query := url.Values{"ref": {branch}, "per_page": {"1"}}
+	if err := g.api.get(g.project(repo)+"/pipelines", query, &pipelines); err != nil {
+		return nil, err
+	}
+	if len(pipelines) == 0 {
+		return nil, nil
+	}
+	p := pipelines[0]
+	return &Run{
+		Name:      "pipeline #" + strconv.Itoa(p.ID),
+		Branch:    p.Ref,
+		State:     gitLabState(p.Status),
+		URL:       p.URL,
+		UpdatedAt: p.UpdatedAt,
+	}, nil
+}
+
+// gitLabState maps a pipeline status to a run state
+func gitLabState(status string) string {
+	switch status {
+	case "success":
+		return StatePassed
+	case "failed":
+		return StateFailed
+	case "created", "waiting_for_resource", "preparing", "pending", "running", "scheduled":
+		return StateRunning
+	default:
+		// canceled, skipped, manual
+		return StateOther
+	}
+}
+
+func (g *gitLab) CurrentUser() (string, error) {
+	var user struct {
+		Username string `json:"username"`
+	}
+	if err := g.api.get("/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+type gitLabUser struct {
+	Username string `json:"username"`
+}
+
+func (g *gitLab) OpenPullRequests(repo Repo) ([]PullRequest, error) {
+	var requests []struct {
+		IID       int          `json:"iid"`
+		Title     string       `json:"title"`
+		URL       string       `json:"web_url"`
+		Draft     bool         `json:"draft"`
+		Author    gitLabUser   `json:"author"`
+		Assignees []gitLabUser `json:"assignees"`
+		Reviewers []gitLabUser `json:"reviewers"`
+		UpdatedAt time.Time    `json:"updated_at"`
+	}
+	query := url.Values{
+		"state":    {"opened"},
+		"order_by": {"updated_at"},
+		"sort":     {"desc"},
+		"per_page": {"100"},
+	}
+	if err := g.api.get(g.project(repo)+"/merge_requests", query, &requests); err != nil {
+		return nil, err
+	}
+
+	pulls := make([]PullRequest, 0, len(requests))
+	for _, r := range requests {
+		pr := PullRequest{Number: r.IID, Title: r.Title, URL: r.URL, Draft: r.Draft, Author: r.Author.Username, UpdatedAt: r.UpdatedAt}
+		for _, a := range append(r.Assignees, r.Reviewers...) {
+			pr.Assignees = append(pr.Assignees, a.Username)
+		}
+		pulls = append(pulls, pr)
+	}
+	return pulls, nil
+}
+
+func (g *gitLab) WebURL(repo Repo) string {
+	return "https://" + g.host + "/" + repo.String()
+}
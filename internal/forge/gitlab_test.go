@@ -0,0 +1,80 @@
+package forge
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGitLab(t *testing.T) {
+	g := newGitLab("gitlab.com", "secret")
+	testAPI(t, g.api, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("Missing token, got %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.EscapedPath() {
+		case "/projects/acme%2Fplatform%2Fapi":
+			_, _ = w.Write([]byte(`{"description": "API", "default_branch": "trunk", "archived": true, "topics": ["go"]}`))
+		case "/projects/acme%2Fplatform%2Fapi/pipelines":
+			if r.URL.Query().Get("ref") != "trunk" {
+				t.Errorf("Expected the default branch, got %q", r.URL.Query().Get("ref"))
+			}
+			_, _ = w.Write([]byte(`[{"id": 42, "ref": "trunk", "status": "failed", "web_url": "https://gitlab.com/p/42"}]`))
+		case "/projects/acme%2Fplatform%2Fapi/merge_requests":
+			_, _ = w.Write([]byte(`[{"iid": 7, "title": "Fix", "author": {"username": "ana"}, "reviewers": [{"username": "bo"}]}]`))
+		case "/user":
+			_, _ = w.Write([]byte(`{"username": "ana"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	repo := Repo{"gitlab.com", "acme/platform", "api"}
+
+	details, err := g.Details(repo)
+	if err != nil {
+		t.Fatalf("Details failed: %v", err)
+	}
+	if details.DefaultBranch != "trunk" || !details.Archived || len(details.Topics) != 1 {
+		t.Errorf("Unexpected details %+v", details)
+	}
+
+	run, err := g.LatestRun(repo, "")
+	if err != nil {
+		t.Fatalf("LatestRun failed: %v", err)
+	}
+	if run.State != StateFailed || run.Name != "pipeline #42" {
+		t.Errorf("Unexpected run %+v", run)
+	}
+
+	pulls, err := g.OpenPullRequests(repo)
+	if err != nil {
+		t.Fatalf("OpenPullRequests failed: %v", err)
+	}
+	if len(pulls) != 1 || !pulls[0].AuthoredBy("ana") || !pulls[0].AssignedTo("bo") {
+		t.Errorf("Unexpected merge requests %+v", pulls)
+	}
+
+	if user, err := g.CurrentUser(); err != nil || user != "ana" {
+		t.Errorf("CurrentUser = %q, %v; expected ana", user, err)
+	}
+
+	if _, err := g.Details(Repo{"gitlab.com", "acme", "missing"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+}
+
+func TestGitLabState(t *testing.T) {
+	tests := map[string]string{
+		"success":  StatePassed,
+		"failed":   StateFailed,
+		"pending":  StateRunning,
+		"running":  StateRunning,
+		"canceled": StateOther,
+		"manual":   StateOther,
+	}
+	for status, expected := range tests {
+		if got := gitLabState(status); got != expected {
+			t.Errorf("gitLabState(%s) = %s; expected %s", status, got, expected)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package forge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/github"
+	"github.com/gabssanto/Scope/internal/httpcache"
+)
+
+// Token returns the token for the forge of kind from the environment:
+// $GITHUB_TOKEN, $GH_TOKEN or gh's login for GitHub, $GITLAB_TOKEN for
+// GitLab and $BITBUCKET_TOKEN (an access token, or user:app-password) for
+// Bitbucket. It is "" if there is none.
+func Token(kind Kind, host string) string {
+	switch kind {
+	case GitLab:
+		return os.Getenv("GITLAB_TOKEN")
+	case Bitbucket:
+		return os.Getenv("BITBUCKET_TOKEN")
+	default:
+		return github.Token()
+	}
+}
+
+// api makes authenticated JSON requests to a forge's REST API
+type api struct {
+	name    string
+	baseURL string
+	token   string
+	// basic sends the token, user:password, as basic auth
+	basic bool
+	http  *httpcache.Client
+}
+
+func newAPI(name, baseURL, token string) *api {
+	return &api{name: name, baseURL: baseURL, token: token, http: httpcache.Default()}
+}
+
+// get fetches path and decodes the JSON response into v
+func (a *api) get(path string, query url.Values, v any) error {
+	u := strings.TrimRight(a.baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case a.token == "":
+	case a.basic:
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(a.token)))
+	default:
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.http.Get(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", a.name, err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s API: %s not found (private repositories need a token)", a.name, path)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%s API: bad credentials", a.name)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s API returned status %d", a.name, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// TokenHint tells how to give scope a token for the forge of kind
+func TokenHint(kind Kind) string {
+	switch kind {
+	case GitLab:
+		return "set GITLAB_TOKEN"
+	case Bitbucket:
+		return "set BITBUCKET_TOKEN"
+	default:
+		return "set GITHUB_TOKEN or run 'gh auth login'"
+	}
+}
+
+// Name returns the forge's display name
+func (k Kind) Name() string {
+	switch k {
+	case GitLab:
+		return "GitLab"
+	case Bitbucket:
+		return "Bitbucket"
+	default:
+		return "GitHub"
+	}
+}
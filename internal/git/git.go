@@ -0,0 +1,131 @@
+// Package git reads repository state straight from the .git directory,
+// which is much faster than running git for every folder in a session.
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gitDir returns the git directory of the repository rooted at dir,
+// following the "gitdir:" indirection used by worktrees and submodules
+func gitDir(dir string) (string, error) {
+	path := filepath.Join(dir, ".git")
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return path, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(content))
+	target, ok := strings.CutPrefix(line, "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("unrecognized .git file in %s", dir)
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	return target, nil
+}
+
+// IsRepo reports whether dir is the root of a git repository
+func IsRepo(dir string) bool {
+	_, err := gitDir(dir)
+	return err == nil
+}
+
+// Branch returns the checked-out branch of the repository rooted at dir.
+// A detached HEAD is returned as its abbreviated commit hash.
+func Branch(dir string) (string, error) {
+	gd, err := gitDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+
+	head, err := os.ReadFile(filepath.Join(gd, "HEAD"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	ref := strings.TrimSpace(string(head))
+	if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+		return branch, nil
+	}
+	if len(ref) >= 7 {
+		return ref[:7], nil
+	}
+	return ref, nil
+}
+
+// commonDir returns the directory holding the config and refs shared by
+// all worktrees of the repository whose git directory is gd
+func commonDir(gd string) string {
+	content, err := os.ReadFile(filepath.Join(gd, "commondir"))
+	if err != nil {
+		return gd
+	}
+	dir := strings.TrimSpace(string(content))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gd, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// RemoteURL returns the URL of the named remote of the repository rooted
+// at dir, as set in its config
+func RemoteURL(dir, remote string) (string, error) {
+	gd, err := gitDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+
+	content, err := os.ReadFile(filepath.Join(commonDir(gd), "config"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read git config: %w", err)
+	}
+
+	section := fmt.Sprintf(`[remote "%s"]`, remote)
+	inSection := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inSection = line == section
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.TrimSpace(key) == "url" {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", fmt.Errorf("no remote '%s' in %s", remote, dir)
+}
+
+// RemoteHead returns the default branch of the named remote as last
+// fetched (refs/remotes/<remote>/HEAD), or "" if it isn't known
+func RemoteHead(dir, remote string) string {
+	gd, err := gitDir(dir)
+	if err != nil {
+		return ""
+	}
+	content, err := os.ReadFile(filepath.Join(commonDir(gd), "refs", "remotes", remote, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	branch, _ := strings.CutPrefix(strings.TrimSpace(string(content)), "ref: refs/remotes/"+remote+"/")
+	if strings.HasPrefix(branch, "ref:") {
+		return ""
+	}
+	return branch
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+}
+
+func TestBranch(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, "repo", ".git", "HEAD"), "ref: refs/heads/feature/login\n")
+	writeFile(t, filepath.Join(root, "detached", ".git", "HEAD"), "3f2a1b9c0d8e7f6a5b4c3d2e1f0a9b8c7d6e5f4a\n")
+	writeFile(t, filepath.Join(root, "main-gitdir", "HEAD"), "ref: refs/heads/wt-branch\n")
+	writeFile(t, filepath.Join(root, "worktree", ".git"), "gitdir: ../main-gitdir\n")
+
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{"repo", "feature/login"},
+		{"detached", "3f2a1b9"},
+		{"worktree", "wt-branch"},
+	}
+
+	for _, tt := range tests {
+		got, err := Branch(filepath.Join(root, tt.dir))
+		if err != nil {
+			t.Errorf("Branch(%s) failed: %v", tt.dir, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Branch(%s) = %q, expected %q", tt.dir, got, tt.expected)
+		}
+	}
+
+	if _, err := Branch(root); err == nil {
+		t.Error("Branch should fail outside a repository")
+	}
+	if IsRepo(root) || !IsRepo(filepath.Join(root, "repo")) {
+		t.Error("IsRepo gave the wrong answer")
+	}
+}
+
+func TestRemoteURL(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, "repo", ".git", "config"), `[core]
+	bare = false
+[remote "upstream"]
+	url = https://github.com/acme/app.git
+[remote "origin"]
+	url = git@github.com:me/app.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+`)
+	writeFile(t, filepath.Join(root, "repo", ".git", "refs", "remotes", "origin", "HEAD"), "ref: refs/remotes/origin/main\n")
+	// Worktrees share the main repository's config through commondir
+	writeFile(t, filepath.Join(root, "repo", ".git", "worktrees", "wt", "commondir"), "../..\n")
+	writeFile(t, filepath.Join(root, "wt", ".git"), "gitdir: "+filepath.Join(root, "repo", ".git", "worktrees", "wt")+"\n")
+
+	for _, dir := range []string{"repo", "wt"} {
+		url, err := RemoteURL(filepath.Join(root, dir), "origin")
+		if err != nil {
+			t.Errorf("RemoteURL(%s) failed: %v", dir, err)
+		} else if url != "git@github.com:me/app.git" {
+			t.Errorf("RemoteURL(%s) = %q", dir, url)
+		}
+		if head := RemoteHead(filepath.Join(root, dir), "origin"); head != "main" {
+			t.Errorf("RemoteHead(%s) = %q, expected main", dir, head)
+		}
+	}
+
+	if url, _ := RemoteURL(filepath.Join(root, "repo"), "upstream"); url != "https://github.com/acme/app.git" {
+		t.Errorf("Unexpected upstream URL %q", url)
+	}
+	if _, err := RemoteURL(filepath.Join(root, "repo"), "fork"); err == nil {
+		t.Error("RemoteURL should fail for a missing remote")
+	}
+	if head := RemoteHead(filepath.Join(root, "repo"), "upstream"); head != "" {
+		t.Errorf("Expected no upstream HEAD, got %q", head)
+	}
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is one entry of a repository's history
+type Commit struct {
+	Hash    string
+	Author  string
+	Time    time.Time
+	Subject string
+}
+
+// LogOptions filter the commits returned by Log
+type LogOptions struct {
+	Since time.Time
+	Until time.Time
+	// Author matches the author name or email, as git log --author does;
+	// empty matches everyone
+	Author string
+}
+
+// Log returns the commits reachable from any branch that touch dir, newest
+// first. Unlike the rest of the package it runs git, since walking history
+// means reading packed objects.
+func Log(dir string, opts LogOptions) ([]Commit, error) {
+	args := []string{"-C", dir, "log", "--branches", "--no-merges", "--format=%h%x1f%an%x1f%ct%x1f%s"}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since="+strconv.FormatInt(opts.Since.Unix(), 10))
+	}
+	if !opts.Until.IsZero() {
+		args = append(args, "--until="+strconv.FormatInt(opts.Until.Unix(), 10))
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	// Only commits touching the folder, when it is inside a larger repository
+	args = append(args, "--", ".")
+
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log failed in %s: %w", dir, err)
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Time:    time.Unix(seconds, 0),
+			Subject: fields[3],
+		})
+	}
+	return commits, nil
+}
+
+// UserEmail returns the user.email git uses in dir, or "" if none is set
+func UserEmail(dir string) string {
+	output, err := exec.Command("git", "-C", dir, "config", "user.email").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// LastCommit returns the time of the latest commit on HEAD touching dir,
+// or the zero time if there is none
+func LastCommit(dir string) (time.Time, error) {
+	output, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%ct", "--", ".").Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git log failed in %s: %w", dir, err)
+	}
+	text := strings.TrimSpace(string(output))
+	if text == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unexpected git log output in %s: %q", dir, text)
+	}
+	return time.Unix(seconds, 0), nil
+}
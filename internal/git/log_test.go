@@ -0,0 +1,78 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// commitAt commits a change to file in repo as author at t
+func commitAt(t *testing.T, repo, file, author, subject string, at time.Time) {
+	t.Helper()
+	writeFile(t, filepath.Join(repo, file), subject)
+
+	date := at.Format(time.RFC3339)
+	for _, args := range [][]string{
+		{"add", "."},
+		{"commit", "-q", "-m", subject, "--author", author + " <" + author + "@example.com>"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+}
+
+func TestLog(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	commitAt(t, repo, "a.txt", "ana", "Old change", day.Add(-48*time.Hour))
+	commitAt(t, repo, "api/b.txt", "ana", "Fix login", day.Add(10*time.Hour))
+	commitAt(t, repo, "c.txt", "bo", "Update docs", day.Add(11*time.Hour))
+
+	commits, err := Log(repo, LogOptions{Since: day, Until: day.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "Update docs" || commits[1].Subject != "Fix login" {
+		t.Fatalf("Expected the day's two commits, newest first, got %+v", commits)
+	}
+	if commits[1].Author != "ana" || !commits[1].Time.Equal(day.Add(10*time.Hour)) || commits[1].Hash == "" {
+		t.Errorf("Unexpected commit %+v", commits[1])
+	}
+
+	commits, err = Log(repo, LogOptions{Since: day, Author: "ana@"})
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "Fix login" {
+		t.Errorf("Expected only ana's commit, got %+v", commits)
+	}
+
+	// A folder inside the repository only sees its own commits
+	commits, err = Log(filepath.Join(repo, "api"), LogOptions{})
+	if err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Subject != "Fix login" {
+		t.Errorf("Expected only the commit touching api, got %+v", commits)
+	}
+
+	if _, err := Log(t.TempDir(), LogOptions{}); err == nil {
+		t.Error("Log should fail outside a repository")
+	}
+}
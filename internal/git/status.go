@@ -0,0 +1,75 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/network"
+)
+
+// Status summarizes a working tree and how its branch compares with its
+// upstream
+type Status struct {
+	// Branch is the checked-out branch, or empty with a detached HEAD
+	Branch string
+	// Upstream is the branch's upstream, such as origin/main, or empty
+	// when it has none
+	Upstream string
+	// Ahead and Behind count the commits only on the branch and only on
+	// its upstream
+	Ahead, Behind int
+	// Dirty counts the changed and untracked files
+	Dirty int
+	// Stashes counts the stash entries
+	Stashes int
+}
+
+// GetStatus returns the status of the repository at dir. The comparison
+// with the upstream is only as recent as the last fetch.
+func GetStatus(dir string) (*Status, error) {
+	output, err := run(dir, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	for _, line := range strings.Split(output, "\n") {
+		header, ok := strings.CutPrefix(line, "# ")
+		if !ok {
+			if line != "" {
+				status.Dirty++
+			}
+			continue
+		}
+		key, value, _ := strings.Cut(header, " ")
+		switch key {
+		case "branch.head":
+			if value != "(detached)" {
+				status.Branch = value
+			}
+		case "branch.upstream":
+			status.Upstream = value
+		case "branch.ab":
+			// +ahead -behind
+			ahead, behind, _ := strings.Cut(value, " ")
+			status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(ahead, "+"))
+			status.Behind, _ = strconv.Atoi(strings.TrimPrefix(behind, "-"))
+		}
+	}
+
+	stashes, err := run(dir, "stash", "list")
+	if err != nil {
+		return nil, err
+	}
+	status.Stashes = strings.Count(stashes, "\n")
+	return status, nil
+}
+
+// Fetch updates the remote-tracking branches of every remote
+func Fetch(dir string) error {
+	if err := network.Check("git fetch"); err != nil {
+		return err
+	}
+	_, err := run(dir, "fetch", "--quiet", "--all")
+	return err
+}
@@ -0,0 +1,60 @@
+package git
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGetStatus(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	root := t.TempDir()
+	origin, clone := filepath.Join(root, "origin"), filepath.Join(root, "clone")
+	if output, err := exec.Command("git", "init", "-q", "-b", "main", origin).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	commitAt(t, origin, "a.txt", "ana", "First", day)
+	if output, err := exec.Command("git", "clone", "-q", origin, clone).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v\n%s", err, output)
+	}
+
+	status, err := GetStatus(clone)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if *status != (Status{Branch: "main", Upstream: "origin/main"}) {
+		t.Errorf("Fresh clone status = %+v", status)
+	}
+
+	commitAt(t, origin, "b.txt", "ana", "Upstream change", day.Add(time.Hour))
+	commitAt(t, clone, "c.txt", "bo", "Local change", day.Add(2*time.Hour))
+	commitAt(t, clone, "d.txt", "bo", "Another local change", day.Add(3*time.Hour))
+	if err := Fetch(clone); err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	writeFile(t, filepath.Join(clone, "a.txt"), "stashed")
+	stash := exec.Command("git", "-C", clone, "-c", "user.name=test", "-c", "user.email=test@example.com", "stash", "-q")
+	if output, err := stash.CombinedOutput(); err != nil {
+		t.Fatalf("git stash failed: %v\n%s", err, output)
+	}
+	writeFile(t, filepath.Join(clone, "c.txt"), "changed")
+	writeFile(t, filepath.Join(clone, "new.txt"), "untracked")
+
+	status, err = GetStatus(clone)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	want := Status{Branch: "main", Upstream: "origin/main", Ahead: 2, Behind: 1, Dirty: 2, Stashes: 1}
+	if *status != want {
+		t.Errorf("GetStatus = %+v, want %+v", *status, want)
+	}
+
+	if _, err := GetStatus(root); err == nil {
+		t.Error("GetStatus should fail outside a repository")
+	}
+}
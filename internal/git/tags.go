@@ -0,0 +1,48 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/network"
+)
+
+// Tags returns the tags reachable from HEAD in the repository at dir
+func Tags(dir string) ([]string, error) {
+	output, err := exec.Command("git", "-C", dir, "tag", "--merged", "HEAD").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git tag failed in %s: %w", dir, err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// CommitsSince counts the commits on HEAD after ref; an empty ref counts
+// all of them
+func CommitsSince(dir, ref string) (int, error) {
+	rev := "HEAD"
+	if ref != "" {
+		rev = ref + "..HEAD"
+	}
+	output, err := exec.Command("git", "-C", dir, "rev-list", "--count", rev).Output()
+	if err != nil {
+		return 0, fmt.Errorf("git rev-list failed in %s: %w", dir, err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// CreateTag creates an annotated tag on HEAD
+func CreateTag(dir, name, message string) error {
+	_, err := run(dir, "tag", "-a", name, "-m", message)
+	return err
+}
+
+// PushTag pushes a tag to remote
+func PushTag(dir, remote, name string) error {
+	if err := network.Check("git push"); err != nil {
+		return err
+	}
+	_, err := run(dir, "push", remote, "refs/tags/"+name)
+	return err
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/network"
+)
+
+// run runs git in dir, returning its output or an error carrying git's
+// message
+func run(dir string, args ...string) (string, error) {
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s failed in %s: %s", args[0], dir, msg)
+	}
+	return string(output), nil
+}
+
+// Changes returns the paths with uncommitted changes, including untracked
+// files
+func Changes(dir string) ([]string, error) {
+	output, err := run(dir, "status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) > 3 {
+			paths = append(paths, line[3:])
+		}
+	}
+	return paths, nil
+}
+
+// CreateBranch creates a branch at HEAD and switches to it
+func CreateBranch(dir, name string) error {
+	_, err := run(dir, "switch", "-c", name)
+	return err
+}
+
+// SwitchBranch switches to an existing branch
+func SwitchBranch(dir, name string) error {
+	_, err := run(dir, "switch", name)
+	return err
+}
+
+// DeleteBranch deletes a branch, merged or not
+func DeleteBranch(dir, name string) error {
+	_, err := run(dir, "branch", "-D", name)
+	return err
+}
+
+// DeleteMergedBranch deletes a branch, refusing when it has commits not
+// merged into its upstream or HEAD
+func DeleteMergedBranch(dir, name string) error {
+	_, err := run(dir, "branch", "-d", name)
+	return err
+}
+
+// Branches returns the names of the local branches
+func Branches(dir string) ([]string, error) {
+	output, err := run(dir, "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+// CommitAll stages every change and commits it
+func CommitAll(dir, message string) error {
+	if _, err := run(dir, "add", "-A"); err != nil {
+		return err
+	}
+	_, err := run(dir, "commit", "-q", "-m", message)
+	return err
+}
+
+// Clone clones url into dir, creating missing parent directories
+func Clone(url, dir string) error {
+	if err := network.Check("git clone"); err != nil {
+		return err
+	}
+	output, err := exec.Command("git", "clone", "--", url, dir).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("git clone failed for %s: %s", url, msg)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBranches(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	if output, err := exec.Command("git", "init", "-q", "-b", "main", repo).CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v\n%s", err, output)
+	}
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	commitAt(t, repo, "a.txt", "ana", "First", day)
+
+	for _, name := range []string{"merged", "feature/x"} {
+		if err := CreateBranch(repo, name); err != nil {
+			t.Fatalf("CreateBranch failed: %v", err)
+		}
+	}
+	// On feature/x
+	commitAt(t, repo, "b.txt", "ana", "Unmerged", day.Add(time.Hour))
+	if err := SwitchBranch(repo, "main"); err != nil {
+		t.Fatalf("SwitchBranch failed: %v", err)
+	}
+
+	branches, err := Branches(repo)
+	if err != nil || !reflect.DeepEqual(branches, []string{"feature/x", "main", "merged"}) {
+		t.Errorf("Branches = %v, %v", branches, err)
+	}
+
+	if err := DeleteMergedBranch(repo, "merged"); err != nil {
+		t.Errorf("DeleteMergedBranch of a merged branch failed: %v", err)
+	}
+	if err := DeleteMergedBranch(repo, "feature/x"); err == nil {
+		t.Error("DeleteMergedBranch should refuse an unmerged branch")
+	}
+	if branches, _ := Branches(repo); !reflect.DeepEqual(branches, []string{"feature/x", "main"}) {
+		t.Errorf("Branches after deleting = %v", branches)
+	}
+}
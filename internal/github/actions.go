@@ -0,0 +1,59 @@
+package github
+
+import (
+	"net/url"
+	"time"
+)
+
+// Run is a GitHub Actions workflow run
+type Run struct {
+	Name       string    `json:"name"`
+	Branch     string    `json:"head_branch"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	URL        string    `json:"html_url"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// States of a run, as reported by State
+const (
+	StatePassed  = "passed"
+	StateFailed  = "failed"
+	StateRunning = "running"
+	StateOther   = "other"
+)
+
+// State summarizes the run: passed, failed, running, or other for
+// conclusions that are neither (cancelled, skipped, neutral)
+func (r Run) State() string {
+	if r.Status != "completed" {
+		return StateRunning
+	}
+	switch r.Conclusion {
+	case "success":
+		return StatePassed
+	case "failure", "timed_out", "startup_failure", "action_required":
+		return StateFailed
+	default:
+		return StateOther
+	}
+}
+
+// LatestRun returns the most recent workflow run on branch, or nil if the
+// repository has none
+func (c *Client) LatestRun(repo Repo, branch string) (*Run, error) {
+	query := url.Values{"per_page": {"1"}}
+	if branch != "" {
+		query.Set("branch", branch)
+	}
+	var page struct {
+		Runs []Run `json:"workflow_runs"`
+	}
+	if err := c.get("/repos/"+repo.String()+"/actions/runs", query, &page); err != nil {
+		return nil, err
+	}
+	if len(page.Runs) == 0 {
+		return nil, nil
+	}
+	return &page.Runs[0], nil
+}
@@ -0,0 +1,51 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestLatestRun(t *testing.T) {
+	c := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api/actions/runs" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("branch") {
+		case "main":
+			_, _ = w.Write([]byte(`{"workflow_runs": [{"name": "CI", "head_branch": "main", "status": "completed", "conclusion": "failure", "html_url": "https://github.com/acme/api/actions/runs/1", "updated_at": "2026-10-14T10:00:00Z"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"workflow_runs": []}`))
+		}
+	})
+
+	run, err := c.LatestRun(Repo{"acme", "api"}, "main")
+	if err != nil {
+		t.Fatalf("LatestRun failed: %v", err)
+	}
+	if run == nil || run.Name != "CI" || run.State() != StateFailed || run.UpdatedAt.IsZero() {
+		t.Errorf("Unexpected run %+v", run)
+	}
+
+	run, err = c.LatestRun(Repo{"acme", "api"}, "gh-pages")
+	if err != nil || run != nil {
+		t.Errorf("Expected no run, got %+v, %v", run, err)
+	}
+}
+
+func TestRunState(t *testing.T) {
+	tests := []struct {
+		status, conclusion, state string
+	}{
+		{"completed", "success", StatePassed},
+		{"completed", "failure", StateFailed},
+		{"completed", "timed_out", StateFailed},
+		{"completed", "cancelled", StateOther},
+		{"in_progress", "", StateRunning},
+		{"queued", "", StateRunning},
+	}
+	for _, tt := range tests {
+		if got := (Run{Status: tt.status, Conclusion: tt.conclusion}).State(); got != tt.state {
+			t.Errorf("State(%s, %s) = %s, expected %s", tt.status, tt.conclusion, got, tt.state)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package github talks to the GitHub REST API for the folders whose git
+// remote points at GitHub.
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/httpcache"
+)
+
+// DefaultBaseURL is the public GitHub API
+const DefaultBaseURL = "https://api.github.com"
+
+// Repo identifies a repository on GitHub
+type Repo struct {
+	Owner string
+	Name  string
+}
+
+// String returns owner/name
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// ParseRemote extracts the repository from a GitHub remote URL in any of
+// the forms git accepts: https://github.com/o/r.git, git@github.com:o/r.git
+// or ssh://git@github.com/o/r
+func ParseRemote(remote string) (Repo, bool) {
+	remote = strings.TrimSpace(remote)
+	var path string
+	switch {
+	case strings.HasPrefix(remote, "git@github.com:"):
+		path = strings.TrimPrefix(remote, "git@github.com:")
+	case strings.Contains(remote, "://"):
+		u, err := url.Parse(remote)
+		if err != nil || !strings.EqualFold(u.Hostname(), "github.com") {
+			return Repo{}, false
+		}
+		path = u.Path
+	default:
+		return Repo{}, false
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	owner, name, ok := strings.Cut(path, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return Repo{}, false
+	}
+	return Repo{Owner: owner, Name: name}, true
+}
+
+// Token returns the API token from $GITHUB_TOKEN or $GH_TOKEN, falling back
+// to the gh CLI's login; "" if there is none
+func Token() string {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+	output, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// Client makes authenticated API requests
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *httpcache.Client
+}
+
+// NewClient returns a client for the public API; token may be empty for
+// public repositories, at a much lower rate limit
+func NewClient(token string) *Client {
+	return &Client{
+		BaseURL: DefaultBaseURL,
+		Token:   token,
+		HTTP:    httpcache.Default(),
+	}
+}
+
+// get fetches path and decodes the JSON response into v
+func (c *Client) get(path string, query url.Values, v any) error {
+	u := strings.TrimRight(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Get(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("GitHub API: %s not found (private repositories need a token)", path)
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("GitHub API: bad credentials")
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(resp.Body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// DefaultBranch returns the repository's default branch
+func (c *Client) DefaultBranch(repo Repo) (string, error) {
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := c.get("/repos/"+repo.String(), nil, &info); err != nil {
+		return "", err
+	}
+	return info.DefaultBranch, nil
+}
+
+// Repository is what GitHub knows about a repository
+type Repository struct {
+	Description   string   `json:"description"`
+	DefaultBranch string   `json:"default_branch"`
+	Archived      bool     `json:"archived"`
+	Topics        []string `json:"topics"`
+}
+
+// Repository returns the description, default branch, archived status and
+// topics of repo
+func (c *Client) Repository(repo Repo) (*Repository, error) {
+	var info Repository
+	if err := c.get("/repos/"+repo.String(), nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
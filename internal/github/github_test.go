@@ -0,0 +1,97 @@
+package github
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	tests := []struct {
+		remote string
+		repo   string
+		ok     bool
+	}{
+		{"https://github.com/acme/api.git", "acme/api", true},
+		{"https://github.com/acme/api", "acme/api", true},
+		{"git@github.com:acme/api.git", "acme/api", true},
+		{"ssh://git@github.com/acme/api.git", "acme/api", true},
+		{"https://user:pw@GitHub.com/acme/api/", "acme/api", true},
+		{"https://gitlab.com/acme/api.git", "", false},
+		{"git@bitbucket.org:acme/api.git", "", false},
+		{"https://github.com/acme", "", false},
+		{"/srv/git/api.git", "", false},
+	}
+	for _, tt := range tests {
+		repo, ok := ParseRemote(tt.remote)
+		if ok != tt.ok || (ok && repo.String() != tt.repo) {
+			t.Errorf("ParseRemote(%q) = %v, %v; expected %q, %v", tt.remote, repo, ok, tt.repo, tt.ok)
+		}
+	}
+}
+
+// testServer serves handler and returns a client for it
+func testServer(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c := NewClient("secret")
+	c.BaseURL = server.URL
+	return c
+}
+
+func TestClientErrors(t *testing.T) {
+	tests := []struct {
+		status  int
+		header  map[string]string
+		message string
+	}{
+		{http.StatusNotFound, nil, "not found"},
+		{http.StatusUnauthorized, nil, "bad credentials"},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, "rate limit"},
+		{http.StatusBadGateway, nil, "status 502"},
+	}
+	for _, tt := range tests {
+		c := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				t.Errorf("Missing token, got %q", r.Header.Get("Authorization"))
+			}
+			for k, v := range tt.header {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(tt.status)
+		})
+		_, err := c.DefaultBranch(Repo{"acme", "api"})
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Status %d: expected an error mentioning %q, got %v", tt.status, tt.message, err)
+		}
+	}
+
+	c := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"default_branch": "trunk"}`))
+	})
+	if branch, err := c.DefaultBranch(Repo{"acme", "api"}); err != nil || branch != "trunk" {
+		t.Errorf("DefaultBranch = %q, %v; expected trunk", branch, err)
+	}
+}
+
+func TestRepository(t *testing.T) {
+	c := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/acme/api" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"description": "Public API", "default_branch": "main", "archived": true, "topics": ["go", "payments"]}`))
+	})
+
+	info, err := c.Repository(Repo{"acme", "api"})
+	if err != nil {
+		t.Fatalf("Repository failed: %v", err)
+	}
+	if info.Description != "Public API" || info.DefaultBranch != "main" || !info.Archived || len(info.Topics) != 2 {
+		t.Errorf("Unexpected repository %+v", info)
+	}
+}
@@ -0,0 +1,63 @@
+package github
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// User is a GitHub account
+type User struct {
+	Login string `json:"login"`
+}
+
+// PullRequest is an open pull request
+type PullRequest struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	URL       string    `json:"html_url"`
+	Draft     bool      `json:"draft"`
+	User      User      `json:"user"`
+	Assignees []User    `json:"assignees"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AuthoredBy reports whether login opened the pull request
+func (p PullRequest) AuthoredBy(login string) bool {
+	return strings.EqualFold(p.User.Login, login)
+}
+
+// AssignedTo reports whether login is one of the pull request's assignees
+func (p PullRequest) AssignedTo(login string) bool {
+	for _, a := range p.Assignees {
+		if strings.EqualFold(a.Login, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentUser returns the login the token belongs to
+func (c *Client) CurrentUser() (string, error) {
+	var user User
+	if err := c.get("/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// OpenPullRequests returns the repository's open pull requests, most
+// recently updated first (at most 100)
+func (c *Client) OpenPullRequests(repo Repo) ([]PullRequest, error) {
+	query := url.Values{
+		"state":     {"open"},
+		"sort":      {"updated"},
+		"direction": {"desc"},
+		"per_page":  {"100"},
+	}
+	var pulls []PullRequest
+	if err := c.get("/repos/"+repo.String()+"/pulls", query, &pulls); err != nil {
+		return nil, err
+	}
+	return pulls, nil
+}
@@ -0,0 +1,55 @@
+package github
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestOpenPullRequests(t *testing.T) {
+	c := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user":
+			_, _ = w.Write([]byte(`{"login": "Ana"}`))
+		case "/repos/acme/api/pulls":
+			if r.URL.Query().Get("state") != "open" {
+				t.Errorf("Expected open pull requests, got %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`[
+				{"number": 12, "title": "Fix login", "html_url": "https://github.com/acme/api/pull/12", "user": {"login": "ana"}, "assignees": []},
+				{"number": 11, "title": "Bump deps", "draft": true, "user": {"login": "bot"}, "assignees": [{"login": "ana"}]},
+				{"number": 9, "title": "Docs", "user": {"login": "bo"}, "assignees": [{"login": "bo"}]}
+			]`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	})
+
+	login, err := c.CurrentUser()
+	if err != nil || login != "Ana" {
+		t.Fatalf("CurrentUser = %q, %v", login, err)
+	}
+
+	pulls, err := c.OpenPullRequests(Repo{"acme", "api"})
+	if err != nil {
+		t.Fatalf("OpenPullRequests failed: %v", err)
+	}
+	if len(pulls) != 3 {
+		t.Fatalf("Expected 3 pull requests, got %+v", pulls)
+	}
+
+	tests := []struct {
+		authored, assigned bool
+	}{
+		{true, false},
+		{false, true},
+		{false, false},
+	}
+	for i, tt := range tests {
+		if pulls[i].AuthoredBy(login) != tt.authored || pulls[i].AssignedTo(login) != tt.assigned {
+			t.Errorf("#%d: expected authored %v, assigned %v", pulls[i].Number, tt.authored, tt.assigned)
+		}
+	}
+	if !pulls[1].Draft {
+		t.Error("Expected #11 to be a draft")
+	}
+}
@@ -0,0 +1,226 @@
+// Package graph renders how folders and tags relate as Graphviz DOT or
+// Mermaid diagrams.
+package graph
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Format is an output diagram language
+type Format string
+
+const (
+	// DOT is the Graphviz language, renderable with `dot -Tsvg`
+	DOT Format = "dot"
+	// Mermaid renders in GitHub markdown and many editors
+	Mermaid Format = "mermaid"
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case DOT, Mermaid:
+		return Format(name), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format: %s (supported: dot, mermaid)", name)
+	}
+}
+
+// Options control what is rendered
+type Options struct {
+	Format Format
+	// CoOccurrence renders only tags, linked by how many folders share them
+	CoOccurrence bool
+}
+
+// edge connects two nodes, optionally with a weight label
+type edge struct {
+	from, to string
+	weight   int
+}
+
+// node is a graph vertex
+type node struct {
+	id, label, tooltip string
+	tag                bool
+}
+
+// diagram is a format-independent graph
+type diagram struct {
+	nodes []node
+	edges []edge
+}
+
+// Render draws the folder/tag relationships in folderTags
+func Render(folderTags map[string][]string, opts Options) (string, error) {
+	var d *diagram
+	if opts.CoOccurrence {
+		d = coOccurrence(folderTags)
+	} else {
+		d = bipartite(folderTags)
+	}
+
+	switch opts.Format {
+	case DOT, "":
+		return d.dot(), nil
+	case Mermaid:
+		return d.mermaid(), nil
+	default:
+		return "", fmt.Errorf("unsupported graph format: %s", opts.Format)
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tagIDs assigns stable ids to every tag in folderTags
+func tagIDs(folderTags map[string][]string) ([]string, map[string]string) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, tags := range folderTags {
+		for _, name := range tags {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	ids := make(map[string]string, len(names))
+	for i, name := range names {
+		ids[name] = fmt.Sprintf("t%d", i)
+	}
+	return names, ids
+}
+
+// bipartite links every folder to each of its tags
+func bipartite(folderTags map[string][]string) *diagram {
+	d := &diagram{}
+
+	names, ids := tagIDs(folderTags)
+	for _, name := range names {
+		d.nodes = append(d.nodes, node{id: ids[name], label: name, tag: true})
+	}
+
+	for i, folder := range sortedKeys(folderTags) {
+		id := fmt.Sprintf("f%d", i)
+		d.nodes = append(d.nodes, node{id: id, label: filepath.Base(folder), tooltip: folder})
+		tags := append([]string(nil), folderTags[folder]...)
+		sort.Strings(tags)
+		for _, name := range tags {
+			d.edges = append(d.edges, edge{from: id, to: ids[name]})
+		}
+	}
+
+	return d
+}
+
+// coOccurrence links tags that share folders, weighted by how many
+func coOccurrence(folderTags map[string][]string) *diagram {
+	d := &diagram{}
+
+	names, ids := tagIDs(folderTags)
+	for _, name := range names {
+		d.nodes = append(d.nodes, node{id: ids[name], label: name, tag: true})
+	}
+
+	type pair struct{ a, b string }
+	counts := make(map[pair]int)
+	for _, tags := range folderTags {
+		sorted := append([]string(nil), tags...)
+		sort.Strings(sorted)
+		for i := range sorted {
+			for j := i + 1; j < len(sorted); j++ {
+				counts[pair{sorted[i], sorted[j]}]++
+			}
+		}
+	}
+
+	pairs := make([]pair, 0, len(counts))
+	for p := range counts {
+		pairs = append(pairs, p)
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].a != pairs[j].a {
+			return pairs[i].a < pairs[j].a
+		}
+		return pairs[i].b < pairs[j].b
+	})
+	for _, p := range pairs {
+		d.edges = append(d.edges, edge{from: ids[p.a], to: ids[p.b], weight: counts[p]})
+	}
+
+	return d
+}
+
+// dotQuote quotes s as a DOT string
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func (d *diagram) dot() string {
+	var b strings.Builder
+	b.WriteString("graph scope {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\"];\n")
+
+	for _, n := range d.nodes {
+		if n.tag {
+			fmt.Fprintf(&b, "  %s [label=%s, shape=ellipse, style=filled, fillcolor=\"#dbeafe\"];\n", n.id, dotQuote(n.label))
+		} else {
+			fmt.Fprintf(&b, "  %s [label=%s, tooltip=%s, shape=box];\n", n.id, dotQuote(n.label), dotQuote(n.tooltip))
+		}
+	}
+
+	for _, e := range d.edges {
+		if e.weight > 0 {
+			fmt.Fprintf(&b, "  %s -- %s [label=\"%d\", penwidth=%d];\n", e.from, e.to, e.weight, min(e.weight, 8))
+		} else {
+			fmt.Fprintf(&b, "  %s -- %s;\n", e.from, e.to)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// mermaidQuote escapes s for a quoted Mermaid label
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
+
+func (d *diagram) mermaid() string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+
+	for _, n := range d.nodes {
+		if n.tag {
+			fmt.Fprintf(&b, "  %s((%s))\n", n.id, mermaidQuote(n.label))
+		} else {
+			fmt.Fprintf(&b, "  %s[%s]\n", n.id, mermaidQuote(n.label))
+		}
+	}
+
+	for _, e := range d.edges {
+		if e.weight > 0 {
+			fmt.Fprintf(&b, "  %s ---|%d| %s\n", e.from, e.weight, e.to)
+		} else {
+			fmt.Fprintf(&b, "  %s --- %s\n", e.from, e.to)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+)
+
+var folderTags = map[string][]string{
+	"/src/api":   {"work", "go"},
+	"/src/web":   {"work", "node"},
+	"/src/tools": {"go", "work"},
+}
+
+func TestRenderDOT(t *testing.T) {
+	out, err := Render(folderTags, Options{Format: DOT})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"graph scope {",
+		`t0 [label="go"`,
+		`f0 [label="api", tooltip="/src/api"`,
+		"f0 -- t0;",
+		"f0 -- t2;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected DOT output to contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderMermaidCoOccurrence(t *testing.T) {
+	out, err := Render(folderTags, Options{Format: Mermaid, CoOccurrence: true})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	// go (t0) and work (t2) share two folders; node (t1) and work share one
+	for _, want := range []string{"graph LR", `t0(("go"))`, "t0 ---|2| t2", "t1 ---|1| t2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected Mermaid output to contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "f0") {
+		t.Errorf("Co-occurrence graph should not contain folders:\n%s", out)
+	}
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	first, _ := Render(folderTags, Options{Format: DOT, CoOccurrence: true})
+	for i := 0; i < 10; i++ {
+		again, _ := Render(folderTags, Options{Format: DOT, CoOccurrence: true})
+		if again != first {
+			t.Fatal("Render output must not depend on map iteration order")
+		}
+	}
+}
+
+func TestRenderEscapesLabels(t *testing.T) {
+	out, err := Render(map[string][]string{`/src/say "hi"`: {`a"b`}}, Options{Format: DOT})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(out, `label="say \"hi\""`) || !strings.Contains(out, `label="a\"b"`) {
+		t.Errorf("Expected quotes to be escaped:\n%s", out)
+	}
+
+	out, _ = Render(map[string][]string{"/x": {`a"b`}}, Options{Format: Mermaid})
+	if !strings.Contains(out, "a#quot;b") {
+		t.Errorf("Expected Mermaid quote entity:\n%s", out)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if _, err := ParseFormat("svg"); err == nil {
+		t.Error("ParseFormat should reject unknown formats")
+	}
+	if f, err := ParseFormat("mermaid"); err != nil || f != Mermaid {
+		t.Errorf("ParseFormat(mermaid) = %q, %v", f, err)
+	}
+}
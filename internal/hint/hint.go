@@ -0,0 +1,189 @@
+// Package hint suggests tagging untagged repositories as the user moves
+// into them. It is driven by the shell hook that 'scope init' installs, so
+// it stays quiet unless it has something new to say: each repository is
+// hinted about once, and hints are spaced out by an interval.
+package hint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/suggest"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// DefaultInterval is the minimum time between two hints
+const DefaultInterval = time.Hour
+
+// Options control when a hint is given
+type Options struct {
+	// Roots are the directories whose repositories are hinted about. When
+	// empty, the parent directories of tagged folders are used, since new
+	// projects tend to appear next to the existing ones.
+	Roots []string
+
+	// Interval is the minimum time between two hints (default DefaultInterval)
+	Interval time.Duration
+
+	// StateFile records which repositories were hinted about and when
+	StateFile string
+
+	// Now returns the current time (default time.Now)
+	Now func() time.Time
+}
+
+// state is the content of the state file
+type state struct {
+	Off   bool                 `json:"off,omitempty"`
+	Last  time.Time            `json:"last"`
+	Shown map[string]time.Time `json:"shown"`
+}
+
+// For returns the hint for dir, or "" if there is none. A returned hint is
+// recorded in the state file so it isn't repeated.
+func For(m *tag.Manager, dir string, opts Options) (string, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	st, err := readState(opts.StateFile)
+	if err != nil {
+		return "", err
+	}
+	now := opts.Now()
+	if st.Off || now.Sub(st.Last) < opts.Interval {
+		return "", nil
+	}
+	if _, shown := st.Shown[dir]; shown {
+		return "", nil
+	}
+
+	if !git.IsRepo(dir) {
+		return "", nil
+	}
+	tags, err := m.GetTagsForFolder(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(tags) > 0 {
+		return "", nil
+	}
+
+	roots := opts.Roots
+	if len(roots) == 0 {
+		roots, err = TaggedParents(m)
+		if err != nil {
+			return "", err
+		}
+	}
+	if !under(dir, roots) {
+		return "", nil
+	}
+
+	suggestions, err := suggest.For(m, dir)
+	if err != nil {
+		return "", err
+	}
+	tagName := "<tag>"
+	if len(suggestions) > 0 {
+		tagName = suggestions[0].Tag
+	}
+
+	st.Last = now
+	st.Shown[dir] = now
+	if err := writeState(opts.StateFile, st); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s isn't tagged yet. Tag it with: scope tag . %s\n(shown once per repository; 'scope hint --off' to stop)",
+		filepath.Base(dir), tagName), nil
+}
+
+// SetOff turns hints off, or back on
+func SetOff(stateFile string, off bool) error {
+	st, err := readState(stateFile)
+	if err != nil {
+		return err
+	}
+	st.Off = off
+	return writeState(stateFile, st)
+}
+
+// TaggedParents returns the parent directories of the tagged local folders,
+// where new projects are likely to appear
+func TaggedParents(m *tag.Manager) ([]string, error) {
+	folders, err := m.ListAllFolders()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var parents []string
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		parent := filepath.Dir(folder)
+		if !seen[parent] {
+			seen[parent] = true
+			parents = append(parents, parent)
+		}
+	}
+	return parents, nil
+}
+
+// under reports whether dir is strictly inside one of roots
+func under(dir string, roots []string) bool {
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readState loads the state file; a missing file is an empty state
+func readState(path string) (*state, error) {
+	st := &state{Shown: make(map[string]time.Time)}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hint state: %w", err)
+	}
+	if err := json.Unmarshal(content, st); err != nil {
+		// A corrupt state file only costs a repeated hint
+		return &state{Shown: make(map[string]time.Time)}, nil
+	}
+	if st.Shown == nil {
+		st.Shown = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+// writeState saves the state file
+func writeState(path string, st *state) error {
+	content, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("failed to encode hint state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return fmt.Errorf("failed to write hint state: %w", err)
+	}
+	return nil
+}
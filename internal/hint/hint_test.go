@@ -0,0 +1,138 @@
+package hint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// setupTestEnv creates a store, a tagged project and an untagged git
+// repository next to it
+func setupTestEnv(t *testing.T) (*tag.Manager, string, Options) {
+	t.Helper()
+
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := tag.NewManager(store)
+
+	for _, dir := range []string{"code/api", "code/web/.git", "elsewhere/tool/.git"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	if err := m.AddTag(filepath.Join(tmpDir, "code", "api"), "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	opts := Options{
+		StateFile: filepath.Join(tmpDir, "hints.json"),
+		Now:       func() time.Time { return now },
+	}
+	return m, tmpDir, opts
+}
+
+func TestForUntaggedRepo(t *testing.T) {
+	m, tmpDir, opts := setupTestEnv(t)
+	web := filepath.Join(tmpDir, "code", "web")
+
+	got, err := For(m, web, opts)
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	if !strings.Contains(got, "scope tag . work") {
+		t.Errorf("Expected a hint suggesting 'work', got %q", got)
+	}
+
+	// Shown once per repository, even after the interval
+	opts.Now = func() time.Time { return time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC) }
+	if got, _ := For(m, web, opts); got != "" {
+		t.Errorf("Expected no repeated hint, got %q", got)
+	}
+}
+
+func TestForSkips(t *testing.T) {
+	m, tmpDir, opts := setupTestEnv(t)
+
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{"tagged", filepath.Join(tmpDir, "code", "api")},
+		{"not a repository", filepath.Join(tmpDir, "code")},
+		{"outside the roots", filepath.Join(tmpDir, "elsewhere", "tool")},
+	}
+	for _, tt := range tests {
+		got, err := For(m, tt.dir, opts)
+		if err != nil {
+			t.Fatalf("%s: For failed: %v", tt.name, err)
+		}
+		if got != "" {
+			t.Errorf("%s: expected no hint, got %q", tt.name, got)
+		}
+	}
+}
+
+func TestForRoots(t *testing.T) {
+	m, tmpDir, opts := setupTestEnv(t)
+	opts.Roots = []string{filepath.Join(tmpDir, "elsewhere")}
+
+	if got, _ := For(m, filepath.Join(tmpDir, "code", "web"), opts); got != "" {
+		t.Errorf("Expected no hint outside the configured roots, got %q", got)
+	}
+	if got, _ := For(m, filepath.Join(tmpDir, "elsewhere", "tool"), opts); got == "" {
+		t.Error("Expected a hint under a configured root")
+	}
+}
+
+func TestForThrottled(t *testing.T) {
+	m, tmpDir, opts := setupTestEnv(t)
+	if err := os.MkdirAll(filepath.Join(tmpDir, "code", "cli", ".git"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	if got, _ := For(m, filepath.Join(tmpDir, "code", "web"), opts); got == "" {
+		t.Fatal("Expected a first hint")
+	}
+	if got, _ := For(m, filepath.Join(tmpDir, "code", "cli"), opts); got != "" {
+		t.Errorf("Expected the second hint to wait for the interval, got %q", got)
+	}
+
+	later := opts.Now().Add(DefaultInterval)
+	opts.Now = func() time.Time { return later }
+	if got, _ := For(m, filepath.Join(tmpDir, "code", "cli"), opts); got == "" {
+		t.Error("Expected a hint once the interval passed")
+	}
+}
+
+func TestSetOff(t *testing.T) {
+	m, tmpDir, opts := setupTestEnv(t)
+	web := filepath.Join(tmpDir, "code", "web")
+
+	if err := SetOff(opts.StateFile, true); err != nil {
+		t.Fatalf("SetOff failed: %v", err)
+	}
+	if got, _ := For(m, web, opts); got != "" {
+		t.Errorf("Expected no hint when off, got %q", got)
+	}
+
+	if err := SetOff(opts.StateFile, false); err != nil {
+		t.Fatalf("SetOff failed: %v", err)
+	}
+	if got, _ := For(m, web, opts); got == "" {
+		t.Error("Expected a hint when back on")
+	}
+}
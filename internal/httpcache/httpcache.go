@@ -0,0 +1,285 @@
+// Package httpcache is the HTTP layer under every forge API call (ci, prs,
+// enrich and the update check). It keeps successful responses on disk and
+// revalidates them with their ETag, so unchanged data costs no rate limit,
+// and it waits out short rate limits while failing fast on long ones, so a
+// command over a hundred repositories neither exhausts a quota nor hangs.
+package httpcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/network"
+)
+
+// Options control caching and rate limit handling
+type Options struct {
+	// Dir keeps cached responses; caching is off when it is empty
+	Dir string
+	// MaxAge is how long a cached response is used without asking the
+	// server again. Older responses are revalidated.
+	MaxAge time.Duration
+	// MaxWait is the longest to wait for a rate limit to reset before
+	// giving up (default 1m)
+	MaxWait time.Duration
+}
+
+// DefaultMaxWait is the longest a request waits for a rate limit by default
+const DefaultMaxWait = time.Minute
+
+// maxAttempts bounds the requests made for one call when rate limited
+const maxAttempts = 3
+
+// Client makes GET requests through the cache
+type Client struct {
+	HTTP *http.Client
+
+	opts  Options
+	sleep func(time.Duration)
+
+	mu sync.Mutex
+	// limited holds the hosts whose rate limit was exhausted, and when it
+	// resets; requests to them fail without being sent
+	limited map[string]time.Time
+}
+
+// New returns a client with opts
+func New(opts Options) *Client {
+	if opts.MaxWait == 0 {
+		opts.MaxWait = DefaultMaxWait
+	}
+	return &Client{
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+		opts:    opts,
+		sleep:   time.Sleep,
+		limited: make(map[string]time.Time),
+	}
+}
+
+// std is the client shared by the API packages
+var std = New(Options{})
+
+// Configure sets the options of the shared client. It must be called before
+// the first request to take effect.
+func Configure(opts Options) {
+	std = New(opts)
+}
+
+// Default returns the shared client
+func Default() *Client {
+	return std
+}
+
+// Response is a response read in full
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Cached is set when the body came from the cache, unchanged since it
+	// was stored
+	Cached bool
+}
+
+// RateLimitError is returned for a host whose rate limit is exhausted for
+// longer than the client waits
+type RateLimitError struct {
+	Host  string
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Reset.IsZero() {
+		return fmt.Sprintf("%s rate limit exceeded", e.Host)
+	}
+	return fmt.Sprintf("%s rate limit exceeded (resets at %s)", e.Host, e.Reset.Local().Format("15:04"))
+}
+
+// entry is a cached response
+type entry struct {
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	Fetched      time.Time   `json:"fetched"`
+}
+
+// cachedHeaders are the response headers kept with a cached body
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified", "Link"}
+
+// Get sends a GET request, answering it from the cache when the cached
+// response is fresh or the server says it hasn't changed. Only 200
+// responses are cached. Offline, any cached response is used, however old,
+// and requests without one fail with network.ErrOffline. Rate limited requests are retried when the limit
+// resets within MaxWait; otherwise they fail with a *RateLimitError.
+func (c *Client) Get(req *http.Request) (*Response, error) {
+	host := req.URL.Host
+	cached, path := c.load(req)
+	if cached != nil && (time.Since(cached.Fetched) < c.opts.MaxAge || network.Offline()) {
+		return cached.response(), nil
+	}
+	if err := network.Check(host); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	reset, limited := c.limited[host]
+	c.mu.Unlock()
+	if limited {
+		if time.Now().Before(reset) {
+			return nil, &RateLimitError{Host: host, Reset: reset}
+		}
+		c.mu.Lock()
+		delete(c.limited, host)
+		c.mu.Unlock()
+	}
+
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			cached.Fetched = time.Now()
+			c.store(path, cached)
+			return cached.response(), nil
+		}
+
+		if wait, ok := rateLimited(resp); ok {
+			if wait > 0 && wait <= c.opts.MaxWait && attempt < maxAttempts {
+				c.sleep(wait)
+				continue
+			}
+			var reset time.Time
+			if wait > 0 {
+				reset = time.Now().Add(wait)
+				c.mu.Lock()
+				c.limited[host] = reset
+				c.mu.Unlock()
+			}
+			return nil, &RateLimitError{Host: host, Reset: reset}
+		}
+
+		if resp.StatusCode == http.StatusOK && path != "" {
+			e := &entry{
+				URL:          req.URL.String(),
+				Header:       make(http.Header),
+				Body:         body,
+				ETag:         resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"),
+				Fetched:      time.Now(),
+			}
+			for _, name := range cachedHeaders {
+				if v := resp.Header.Values(name); len(v) > 0 {
+					e.Header[name] = v
+				}
+			}
+			c.store(path, e)
+		}
+		return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+	}
+}
+
+// rateLimited reports whether resp says the rate limit is exhausted, and
+// how long until it resets; 0 when the server doesn't say, which isn't
+// worth retrying
+func rateLimited(resp *http.Response) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden &&
+		(resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""):
+		// GitHub answers 403 for both its primary and secondary limits
+	default:
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	// GitHub sends X-RateLimit-Reset, GitLab RateLimit-Reset, both as
+	// Unix times
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if unix, err := strconv.ParseInt(resp.Header.Get(name), 10, 64); err == nil {
+			return max(time.Until(time.Unix(unix, 0)), time.Second), true
+		}
+	}
+	return 0, true
+}
+
+// load returns the cached response to req, if any, and the file it is
+// kept in; "" when caching is off
+func (c *Client) load(req *http.Request) (*entry, string) {
+	if c.opts.Dir == "" {
+		return nil, ""
+	}
+	// Responses differ by credentials, so the token is part of the key
+	sum := sha256.Sum256([]byte(req.URL.String() + "\n" + req.Header.Get("Authorization") + "\n" + req.Header.Get("Accept")))
+	path := filepath.Join(c.opts.Dir, hex.EncodeToString(sum[:16])+".json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != req.URL.String() {
+		return nil, path
+	}
+	return &e, path
+}
+
+// store writes e to path. The cache is an optimization, so failures are
+// ignored.
+func (c *Client) store(path string, e *entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	// Cached responses may hold private repository data
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	// Write a temporary file and rename it, so concurrent requests never
+	// read a partial response
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".response-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (e *entry) response() *Response {
+	return &Response{StatusCode: http.StatusOK, Header: e.Header, Body: e.Body, Cached: true}
+}
@@ -0,0 +1,184 @@
+package httpcache
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/network"
+)
+
+func get(t *testing.T, c *Client, url string) (*Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	return c.Get(req)
+}
+
+func TestGetRevalidatesWithETag(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	c := New(Options{Dir: t.TempDir()})
+	first, err := get(t, c, server.URL+"/repos/acme/api")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if first.Cached || string(first.Body) != `{"ok": true}` {
+		t.Errorf("Unexpected first response %+v", first)
+	}
+
+	second, err := get(t, c, server.URL+"/repos/acme/api")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !second.Cached || second.StatusCode != http.StatusOK || string(second.Body) != `{"ok": true}` {
+		t.Errorf("Expected the cached body after a 304, got %+v", second)
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("Expected one revalidation, got %d requests, %d not modified", requests.Load(), notModified.Load())
+	}
+
+	// Within MaxAge the server isn't asked at all
+	c = New(Options{Dir: c.opts.Dir, MaxAge: time.Hour})
+	if resp, err := get(t, c, server.URL+"/repos/acme/api"); err != nil || !resp.Cached {
+		t.Errorf("Expected a fresh cached response, got %+v, %v", resp, err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("Expected no request for a fresh response, got %d", requests.Load())
+	}
+}
+
+func TestGetWithoutCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Error("Unexpected conditional request without a cache")
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New(Options{})
+	for range 2 {
+		if resp, err := get(t, c, server.URL); err != nil || resp.Cached {
+			t.Errorf("Expected an uncached response, got %+v, %v", resp, err)
+		}
+	}
+}
+
+func TestGetRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		switch {
+		case r.URL.Path == "/short" && n == 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case r.URL.Path == "/long":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	c := New(Options{MaxWait: time.Minute})
+	c.sleep = func(d time.Duration) { slept = append(slept, d) }
+
+	resp, err := get(t, c, server.URL+"/short")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the retry to succeed, got %+v, %v", resp, err)
+	}
+	if len(slept) != 1 || slept[0] != 2*time.Second {
+		t.Errorf("Expected one 2s wait, got %v", slept)
+	}
+
+	requests.Store(0)
+	_, err = get(t, c, server.URL+"/long")
+	var limit *RateLimitError
+	if !errors.As(err, &limit) || limit.Reset.IsZero() {
+		t.Fatalf("Expected a rate limit error, got %v", err)
+	}
+	if len(slept) != 1 {
+		t.Errorf("Expected no wait for a limit beyond MaxWait, got %v", slept)
+	}
+
+	// Later requests to the host fail without being sent
+	if _, err := get(t, c, server.URL+"/other"); !errors.As(err, &limit) {
+		t.Errorf("Expected the host to stay limited, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected 1 request, got %d", requests.Load())
+	}
+}
+
+func TestCacheFilesArePrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir() + "/cache"
+	if _, err := get(t, New(Options{Dir: dir}), server.URL); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected one cached response, got %v, %v", entries, err)
+	}
+	info, err := entries[0].Info()
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestGetOffline(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	c := New(Options{Dir: t.TempDir()})
+	if _, err := get(t, c, server.URL+"/cached"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	network.SetOffline(true)
+	defer network.SetOffline(false)
+
+	if resp, err := get(t, c, server.URL+"/cached"); err != nil || !resp.Cached {
+		t.Errorf("Expected the cached response offline, got %+v, %v", resp, err)
+	}
+	if _, err := get(t, c, server.URL+"/uncached"); !errors.Is(err, network.ErrOffline) {
+		t.Errorf("Expected ErrOffline, got %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Expected no requests offline, got %d", requests.Load()-1)
+	}
+}
@@ -0,0 +1,156 @@
+// Package incident runs on-call incidents as temporary tags: the services
+// involved are found among the tagged folders (or cloned), tagged with a
+// fresh incident tag for a session, and when the incident ends the tag is
+// archived together with the commands run in its sessions.
+package incident
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/session"
+)
+
+// Prefix starts the name of every incident tag
+const Prefix = "incident-"
+
+// Options control where services that aren't tagged anywhere come from
+type Options struct {
+	// CloneURL is the URL to clone a missing service from, with {name}
+	// standing for the service; empty means missing services are an error
+	CloneURL string
+	// Dir is where missing services are cloned
+	Dir string
+}
+
+// Service is a service of an incident and the folder it is in
+type Service struct {
+	Name   string
+	Folder string
+	// Missing is set when no folder holds the service; Folder is then
+	// where it would be cloned
+	Missing bool
+}
+
+// IsTag reports whether tagName is an incident tag
+func IsTag(tagName string) bool {
+	return strings.HasPrefix(tagName, Prefix)
+}
+
+// NewTag returns an unused tag name for an incident started at now
+func NewTag(tags map[string]int, now time.Time) string {
+	base := Prefix + now.Format("20060102-1504")
+	name := base
+	for i := 2; tags[name] > 0; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// Resolve finds the folder of each service: a known folder named after
+// it, or the service itself when it is a path to a directory. Services
+// that aren't found, or whose folder is gone, are returned as missing.
+func Resolve(services, folders []string, opts Options) ([]Service, error) {
+	byName := make(map[string][]string)
+	for _, folder := range folders {
+		if location.IsRemote(folder) {
+			continue
+		}
+		name := filepath.Base(folder)
+		byName[name] = append(byName[name], folder)
+	}
+
+	resolved := make([]Service, 0, len(services))
+	for _, name := range services {
+		if strings.ContainsRune(name, filepath.Separator) {
+			abs, err := filepath.Abs(name)
+			if err != nil {
+				return nil, err
+			}
+			if !isDir(abs) {
+				return nil, fmt.Errorf("service %s is not a directory", name)
+			}
+			resolved = append(resolved, Service{Name: filepath.Base(abs), Folder: abs})
+			continue
+		}
+
+		var present []string
+		for _, folder := range byName[name] {
+			if isDir(folder) {
+				present = append(present, folder)
+			}
+		}
+		switch len(present) {
+		case 0:
+			resolved = append(resolved, Service{Name: name, Folder: filepath.Join(opts.Dir, name), Missing: true})
+		case 1:
+			resolved = append(resolved, Service{Name: name, Folder: present[0]})
+		default:
+			return nil, fmt.Errorf("service %s is ambiguous (%s); pass its path instead", name, strings.Join(present, ", "))
+		}
+	}
+	return resolved, nil
+}
+
+// Clone clones a missing service into its folder
+func Clone(svc Service, opts Options) error {
+	if opts.CloneURL == "" {
+		return fmt.Errorf("service %s not found (tag its folder or set incident.clone in the config)", svc.Name)
+	}
+	if isDir(svc.Folder) {
+		return fmt.Errorf("service %s not found, and %s already exists", svc.Name, svc.Folder)
+	}
+	return git.Clone(strings.ReplaceAll(opts.CloneURL, "{name}", svc.Name), svc.Folder)
+}
+
+// Archive writes a Markdown record of an incident to dir: its folders and
+// the commands run in each of its recorded sessions. It returns the path
+// written.
+func Archive(dir, tagName string, folders []string, recordings []session.Recording) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", tagName)
+	fmt.Fprintf(&b, "Archived %s.\n\n", time.Now().Format("2006-01-02 15:04"))
+
+	b.WriteString("## Services\n\n")
+	for _, folder := range folders {
+		fmt.Fprintf(&b, "- %s\n", folder)
+	}
+
+	recordings = slices.DeleteFunc(slices.Clone(recordings), func(r session.Recording) bool { return r.Tag != tagName })
+	for _, recording := range recordings {
+		commands, err := session.ReadHistory(recording.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read session history: %w", err)
+		}
+		fmt.Fprintf(&b, "\n## Session %s\n\n", recording.Started.Format("2006-01-02 15:04"))
+		if len(commands) == 0 {
+			b.WriteString("No commands recorded.\n")
+			continue
+		}
+		b.WriteString("```sh\n")
+		for _, command := range commands {
+			b.WriteString(command + "\n")
+		}
+		b.WriteString("```\n")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, tagName+".md")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
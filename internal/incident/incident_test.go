@@ -0,0 +1,101 @@
+package incident
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/session"
+)
+
+func TestNewTag(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	if got := NewTag(nil, now); got != "incident-20260301-0930" {
+		t.Errorf("Unexpected tag %q", got)
+	}
+	tags := map[string]int{"incident-20260301-0930": 2, "incident-20260301-0930-2": 1}
+	if got := NewTag(tags, now); got != "incident-20260301-0930-3" {
+		t.Errorf("Expected a suffix for a taken name, got %q", got)
+	}
+	if !IsTag(NewTag(nil, now)) || IsTag("work") {
+		t.Error("IsTag should recognize incident tags only")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/api", "a/web", "b/web", "c/db"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	folders := []string{
+		filepath.Join(root, "a/api"),
+		filepath.Join(root, "a/web"),
+		filepath.Join(root, "b/web"),
+		filepath.Join(root, "gone/worker"),
+		"me@box:/srv/api",
+	}
+	opts := Options{Dir: filepath.Join(root, "clones")}
+
+	resolved, err := Resolve([]string{"api", "worker", filepath.Join(root, "c/db")}, folders, opts)
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	expected := []Service{
+		{Name: "api", Folder: filepath.Join(root, "a/api")},
+		{Name: "worker", Folder: filepath.Join(root, "clones/worker"), Missing: true},
+		{Name: "db", Folder: filepath.Join(root, "c/db")},
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, resolved)
+	}
+	for i := range expected {
+		if resolved[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], resolved[i])
+		}
+	}
+
+	if _, err := Resolve([]string{"web"}, folders, opts); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Expected an ambiguity error, got %v", err)
+	}
+	if err := Clone(resolved[1], opts); err == nil {
+		t.Error("Expected Clone to fail without a clone URL")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	history := session.HistoryFile(dir, "incident-1", started)
+	if err := os.WriteFile(history, []byte("kubectl get pods\ngit log -3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	other := session.HistoryFile(dir, "work", started)
+	if err := os.WriteFile(other, []byte("make\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	recordings, err := session.ListRecordings(dir)
+	if err != nil {
+		t.Fatalf("ListRecordings failed: %v", err)
+	}
+
+	path, err := Archive(filepath.Join(dir, "archive"), "incident-1", []string{"/code/api"}, recordings)
+	if err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read archive: %v", err)
+	}
+	for _, want := range []string{"# incident-1", "- /code/api", "## Session 2026-03-01 09:30", "kubectl get pods\ngit log -3\n"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in archive:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), "make") {
+		t.Errorf("Expected other tags' sessions left out:\n%s", content)
+	}
+}
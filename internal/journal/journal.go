@@ -0,0 +1,419 @@
+// Package journal keeps an append-only log of tag changes in a directory
+// synced between machines (Dropbox, iCloud Drive, Syncthing...).
+//
+// Syncing the SQLite database itself corrupts it or produces conflicted
+// copies. Instead every machine appends the changes it makes to its own
+// <machine>.jsonl file, one JSON object per line, and replays the files of
+// the other machines. Since each file has a single writer, sync tools never
+// see conflicting edits, and machines converge once they have replayed
+// each other's changes.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/paths"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// Entry is one line of a journal
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Machine string    `json:"machine"`
+	Op      tag.Op    `json:"op"`
+	Path    string    `json:"path,omitempty"`
+	NewPath string    `json:"new_path,omitempty"`
+	Tag     string    `json:"tag,omitempty"`
+	NewTag  string    `json:"new_tag,omitempty"`
+	Note    string    `json:"note,omitempty"`
+	Subdir  string    `json:"subdir,omitempty"`
+}
+
+// Journal appends this machine's changes to dir and replays the others
+type Journal struct {
+	dir     string
+	machine string
+	home    string
+
+	// replaying is set while Replay applies changes, so they are not
+	// journaled a second time
+	replaying bool
+}
+
+// New returns the journal in dir for machine. An empty machine uses the
+// host name.
+func New(dir, machine string) (*Journal, error) {
+	if machine == "" {
+		host, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get host name: %w", err)
+		}
+		machine = host
+	}
+	machine = sanitize(machine)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	return &Journal{dir: dir, machine: machine, home: home}, nil
+}
+
+// Machine returns the name this machine journals under
+func (j *Journal) Machine() string {
+	return j.machine
+}
+
+// sanitize makes a machine name safe to use as a file name
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+// file returns the journal file of machine
+func (j *Journal) file(machine string) string {
+	return filepath.Join(j.dir, machine+".jsonl")
+}
+
+// stateFile records how much of each other machine's journal has been
+// replayed here. Only this machine writes it.
+func (j *Journal) stateFile() string {
+	return filepath.Join(j.dir, "."+j.machine+".replayed.json")
+}
+
+// portable rewrites paths under the home directory as ~/..., since home
+// directories differ between machines
+func (j *Journal) portable(path string) string {
+	if path == "" || location.IsRemote(path) {
+		return path
+	}
+	if path == j.home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, j.home+string(filepath.Separator)); ok {
+		return "~/" + filepath.ToSlash(rest)
+	}
+	return path
+}
+
+// local converts a portable path back to this machine's form
+func (j *Journal) local(path string) (string, error) {
+	if path == "" || location.IsRemote(path) {
+		return path, nil
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return paths.Resolve(filepath.FromSlash(path))
+	}
+	return path, nil
+}
+
+// Record journals every change made through m
+func (j *Journal) Record(m *tag.Manager) {
+	m.Observe(func(c tag.Change) {
+		if j.replaying {
+			return
+		}
+		if err := j.Append(c); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to journal change: %v\n", err)
+		}
+	})
+}
+
+// Append writes c to this machine's journal
+func (j *Journal) Append(c tag.Change) error {
+	return j.append([]tag.Change{c})
+}
+
+// append writes changes to this machine's journal in a single write
+func (j *Journal) append(changes []tag.Change) error {
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	now := time.Now().UTC()
+	var buf bytes.Buffer
+	for _, c := range changes {
+		line, err := json.Marshal(Entry{
+			Time:    now,
+			Machine: j.machine,
+			Op:      c.Op,
+			Path:    j.portable(c.Path),
+			NewPath: j.portable(c.NewPath),
+			Tag:     c.Tag,
+			NewTag:  c.NewTag,
+			Note:    c.Note,
+			Subdir:  c.Subdir,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode journal entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	f, err := os.OpenFile(j.file(j.machine), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return f.Close()
+}
+
+// Seed journals the current state of m (every folder tag, note and subdir), so
+// machines that start syncing later receive what already exists
+func (j *Journal) Seed(m *tag.Manager) (int, error) {
+	folderTags, err := m.ListFolderTags()
+	if err != nil {
+		return 0, err
+	}
+	notes, err := m.ListNotes()
+	if err != nil {
+		return 0, err
+	}
+	subdirs, err := m.ListSubdirs()
+	if err != nil {
+		return 0, err
+	}
+
+	folders := make([]string, 0, len(folderTags))
+	for folder := range folderTags {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+
+	var changes []tag.Change
+	for _, folder := range folders {
+		for _, name := range folderTags[folder] {
+			changes = append(changes, tag.Change{Op: tag.OpAdd, Path: folder, Tag: name})
+		}
+		if note := notes[folder]; note != "" {
+			changes = append(changes, tag.Change{Op: tag.OpNote, Path: folder, Note: note})
+		}
+		if subdir := subdirs[folder]; subdir != "" {
+			changes = append(changes, tag.Change{Op: tag.OpSubdir, Path: folder, Subdir: subdir})
+		}
+	}
+
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	return len(changes), j.append(changes)
+}
+
+// ReplayResult summarizes a Replay
+type ReplayResult struct {
+	// Applied is the number of entries applied
+	Applied int
+	// Skipped entries could not be applied here (e.g. the folder doesn't
+	// exist on this machine) and will not be retried
+	Skipped []SkippedEntry
+	// Invalid is the number of lines that could not be parsed
+	Invalid int
+}
+
+// SkippedEntry is an entry Replay could not apply
+type SkippedEntry struct {
+	Entry Entry
+	Err   error
+}
+
+// Replay applies the entries other machines have journaled since the last
+// replay, oldest first
+func (j *Journal) Replay(m *tag.Manager) (*ReplayResult, error) {
+	state, err := j.readState()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(j.dir, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journals: %w", err)
+	}
+
+	result := &ReplayResult{}
+	var queue []Entry
+	consumed := make(map[string]int)
+	for _, file := range files {
+		machine := strings.TrimSuffix(filepath.Base(file), ".jsonl")
+		if machine == j.machine {
+			continue
+		}
+
+		entries, lines, invalid, err := readEntries(file, state[machine])
+		if err != nil {
+			return nil, err
+		}
+		result.Invalid += invalid
+		consumed[machine] = lines
+		queue = append(queue, entries...)
+	}
+
+	sort.SliceStable(queue, func(a, b int) bool {
+		return queue[a].Time.Before(queue[b].Time)
+	})
+
+	j.replaying = true
+	defer func() { j.replaying = false }()
+
+	for _, e := range queue {
+		if err := j.apply(m, e); err != nil {
+			result.Skipped = append(result.Skipped, SkippedEntry{Entry: e, Err: err})
+			continue
+		}
+		result.Applied++
+	}
+
+	for machine, lines := range consumed {
+		state[machine] = lines
+	}
+	if err := j.writeState(state); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// readEntries parses the lines of file after the first skip. Only complete
+// lines are read: a final line without a newline may still be syncing. It
+// returns the entries, the number of lines consumed in total and the
+// number of lines that failed to parse.
+func readEntries(file string, skip int) ([]Entry, int, int, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	var entries []Entry
+	lines, invalid := 0, 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	complete := bytes.Count(content, []byte("\n"))
+	for scanner.Scan() && lines < complete {
+		lines++
+		if lines <= skip {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.Op == "" {
+			invalid++
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to read journal %s: %w", file, err)
+	}
+
+	// A journal that shrank was rewritten; start over rather than stall
+	if lines < skip {
+		return readEntries(file, 0)
+	}
+	return entries, lines, invalid, nil
+}
+
+// apply performs the change an entry describes. Changes that are already
+// in effect here (removing a tag that is gone) are not errors.
+func (j *Journal) apply(m *tag.Manager, e Entry) error {
+	path, err := j.local(e.Path)
+	if err != nil {
+		return err
+	}
+
+	switch e.Op {
+	case tag.OpAdd:
+		return m.AddTag(path, e.Tag)
+	case tag.OpRemove:
+		if err := m.RemoveTag(path, e.Tag); err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		return nil
+	case tag.OpDeleteTag:
+		if err := m.DeleteTag(e.Tag); err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		return nil
+	case tag.OpRename:
+		return m.RenameTag(e.Tag, e.NewTag)
+	case tag.OpMerge:
+		if err := m.MergeTag(e.Tag, e.NewTag); err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		return nil
+	case tag.OpForget:
+		if err := m.RemoveFolder(path); err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+		return nil
+	case tag.OpMove:
+		// The folder may not have moved here, or not be tagged here
+		newPath, err := j.local(e.NewPath)
+		if err != nil {
+			return err
+		}
+		if err := m.MoveFolder(path, newPath); err != nil &&
+			!strings.Contains(err.Error(), "not tagged") && !strings.Contains(err.Error(), "does not exist") {
+			return err
+		}
+		return nil
+	case tag.OpNote:
+		return m.SetNote(path, e.Note)
+	case tag.OpSubdir:
+		return m.SetSubdir(path, e.Subdir)
+	default:
+		return fmt.Errorf("unknown operation %q", e.Op)
+	}
+}
+
+// readState returns the number of lines replayed from each machine
+func (j *Journal) readState() (map[string]int, error) {
+	state := make(map[string]int)
+	content, err := os.ReadFile(j.stateFile())
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay state: %w", err)
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse replay state %s: %w", j.stateFile(), err)
+	}
+	return state, nil
+}
+
+// writeState saves the number of lines replayed from each machine
+func (j *Journal) writeState(state map[string]int) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode replay state: %w", err)
+	}
+	if err := os.MkdirAll(j.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a truncated state
+	tmp := j.stateFile() + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write replay state: %w", err)
+	}
+	if err := os.Rename(tmp, j.stateFile()); err != nil {
+		return fmt.Errorf("failed to write replay state: %w", err)
+	}
+	return nil
+}
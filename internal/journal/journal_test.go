@@ -0,0 +1,177 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// newMachine returns a Manager on its own database, journaling to dir
+func newMachine(t *testing.T, dir, name string) (*tag.Manager, *Journal) {
+	t.Helper()
+
+	store, err := db.Open(filepath.Join(t.TempDir(), "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	m := tag.NewManager(store)
+	j, err := New(dir, name)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	j.Record(m)
+	return m, j
+}
+
+func TestReplay(t *testing.T) {
+	home, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	t.Setenv("HOME", home)
+
+	project := filepath.Join(home, "src", "api")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "journal")
+	laptop, laptopJournal := newMachine(t, dir, "laptop")
+	desktop, desktopJournal := newMachine(t, dir, "desktop")
+
+	if err := laptop.AddTag(project, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := laptop.SetNote(project, "main service"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	if err := laptop.AddTag(filepath.Join(home, "src"), "gone"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := laptop.RenameTag("gone", "old"); err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+
+	// Paths under the home directory are journaled portably
+	content, err := os.ReadFile(filepath.Join(dir, "laptop.jsonl"))
+	if err != nil {
+		t.Fatalf("Failed to read journal: %v", err)
+	}
+	if !strings.Contains(string(content), `"path":"~/src/api"`) {
+		t.Errorf("Expected a home-relative path in the journal:\n%s", content)
+	}
+
+	result, err := desktopJournal.Replay(desktop)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Applied != 4 || len(result.Skipped) != 0 {
+		t.Errorf("Expected 4 applied, got %+v", result)
+	}
+
+	tags, err := desktop.GetTagsForFolder(project)
+	if err != nil {
+		t.Fatalf("GetTagsForFolder failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"work"}) {
+		t.Errorf("Expected [work], got %v", tags)
+	}
+	if note, _ := desktop.GetNote(project); note != "main service" {
+		t.Errorf("Expected replayed note, got %q", note)
+	}
+	if folders, _ := desktop.ListFoldersByTag("old"); len(folders) != 1 {
+		t.Errorf("Expected the renamed tag to be replayed, got %v", folders)
+	}
+
+	// Replayed changes are not journaled again by the desktop
+	if _, err := os.Stat(filepath.Join(dir, "desktop.jsonl")); !os.IsNotExist(err) {
+		t.Error("Replay should not write to the replaying machine's journal")
+	}
+
+	// A second replay has nothing new
+	result, err = desktopJournal.Replay(desktop)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Applied != 0 {
+		t.Errorf("Expected nothing to replay, got %+v", result)
+	}
+
+	// Changes flow back the other way
+	if err := desktop.RemoveTag(project, "work"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if _, err := laptopJournal.Replay(laptop); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if tags, _ := laptop.GetTagsForFolder(project); len(tags) != 0 {
+		t.Errorf("Expected the removal to be replayed, got %v", tags)
+	}
+}
+
+func TestReplaySkipsIncompleteAndInvalidLines(t *testing.T) {
+	dir := t.TempDir()
+	m, j := newMachine(t, dir, "here")
+	folder := t.TempDir()
+
+	journal := `{"time":"2024-01-01T00:00:00Z","machine":"there","op":"add","path":"` + folder + `","tag":"a"}
+not json
+{"time":"2024-01-02T00:00:00Z","machine":"there","op":"add","path":"/does/not/exist","tag":"b"}
+{"time":"2024-01-03T00:00:00Z","machine":"there","op":"add","path":"` + folder + `","tag":"c"`
+	if err := os.WriteFile(filepath.Join(dir, "there.jsonl"), []byte(journal), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	result, err := j.Replay(m)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Applied != 1 || len(result.Skipped) != 1 || result.Invalid != 1 {
+		t.Errorf("Expected 1 applied, 1 skipped, 1 invalid, got %+v", result)
+	}
+
+	// Once the last line is complete it is picked up
+	f, err := os.OpenFile(filepath.Join(dir, "there.jsonl"), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	f.WriteString("}\n")
+	f.Close()
+
+	result, err = j.Replay(m)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if result.Applied != 1 {
+		t.Errorf("Expected the completed line to be applied, got %+v", result)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	dir := t.TempDir()
+	m, _ := newMachine(t, dir, "here")
+	folder := t.TempDir()
+
+	if err := m.AddTag(folder, "a"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := os.Remove(filepath.Join(dir, "here.jsonl")); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	_, j := newMachine(t, dir, "here")
+	n, err := j.Seed(m)
+	if err != nil {
+		t.Fatalf("Seed failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 seeded entry, got %d", n)
+	}
+}
@@ -0,0 +1,335 @@
+// Package location recognizes folders that live somewhere other than this
+// machine's filesystem and knows how to reach them.
+//
+// Remote folders are written either scp-style (user@host:/path, host:path)
+// or as sftp:// or ssh:// URIs (sftp://user@host:2222/path). Folders inside
+// a Docker container are written docker://container/path, and folders in a
+// DevPod workspace devpod://workspace[/path]. All are stored in the
+// normalized form returned by Location.String.
+package location
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/shell"
+)
+
+// Kind is the type of a stored folder
+type Kind string
+
+const (
+	// Local folders are directories on this machine
+	Local Kind = "local"
+
+	// SSH folders are reached over ssh
+	SSH Kind = "ssh"
+
+	// Docker folders live inside a named container
+	Docker Kind = "docker"
+
+	// DevPod folders live inside a DevPod workspace
+	DevPod Kind = "devpod"
+)
+
+// KindOf returns the kind of a stored folder path
+func KindOf(s string) Kind {
+	if loc, ok := Parse(s); ok {
+		return loc.Kind
+	}
+	return Local
+}
+
+// Location is a folder that is not on the local filesystem. For Docker and
+// DevPod folders Host is the container or workspace name.
+type Location struct {
+	Kind Kind
+	User string
+	Host string
+	Port string
+	Path string
+}
+
+// IsRemote reports whether s names a folder that is not local
+func IsRemote(s string) bool {
+	_, ok := Parse(s)
+	return ok
+}
+
+// Parse recognizes a non-local folder. It returns false for local paths,
+// including Windows drive paths such as C:\src.
+func Parse(s string) (*Location, bool) {
+	switch {
+	case strings.HasPrefix(s, "sftp://"), strings.HasPrefix(s, "ssh://"):
+		return parseURI(s)
+	case strings.HasPrefix(s, "docker://"):
+		return parseContainer(s, Docker)
+	case strings.HasPrefix(s, "devpod://"):
+		return parseContainer(s, DevPod)
+	}
+	return parseSCP(s)
+}
+
+// parseContainer parses docker://name/path and devpod://name[/path]. The
+// path is optional: without one, commands run in the container's working
+// directory.
+func parseContainer(s string, kind Kind) (*Location, bool) {
+	rest := strings.TrimPrefix(s, string(kind)+"://")
+	name, p, _ := strings.Cut(rest, "/")
+	if name == "" {
+		return nil, false
+	}
+	if p != "" {
+		p = path.Clean("/" + p)
+	}
+	return &Location{Kind: kind, Host: name, Path: p}, true
+}
+
+// parseURI parses sftp://[user@]host[:port]/path
+func parseURI(s string) (*Location, bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Hostname() == "" {
+		return nil, false
+	}
+
+	p := u.Path
+	if p == "" {
+		p = "."
+	}
+	// sftp://host/~/src is relative to the remote home directory
+	if strings.HasPrefix(p, "/~/") || p == "/~" {
+		p = p[1:]
+	}
+
+	return &Location{
+		Kind: SSH,
+		User: u.User.Username(),
+		Host: u.Hostname(),
+		Port: u.Port(),
+		Path: p,
+	}, true
+}
+
+// parseSCP parses [user@]host:path. Like scp, a colon only separates a host
+// when no slash comes before it.
+func parseSCP(s string) (*Location, bool) {
+	colon := strings.IndexByte(s, ':')
+	if colon < 0 {
+		return nil, false
+	}
+	if slash := strings.IndexAny(s, `/\`); slash >= 0 && slash < colon {
+		return nil, false
+	}
+
+	dest, p := s[:colon], s[colon+1:]
+	if p == "" {
+		return nil, false
+	}
+
+	loc := &Location{Kind: SSH, Host: dest, Path: p}
+	if at := strings.LastIndexByte(dest, '@'); at >= 0 {
+		loc.User, loc.Host = dest[:at], dest[at+1:]
+	}
+	// A single letter is a drive, not a host
+	if len(loc.Host) < 2 || strings.ContainsAny(loc.Host, " \t") {
+		return nil, false
+	}
+	return loc, true
+}
+
+// Destination is the [user@]host argument passed to ssh
+func (l *Location) Destination() string {
+	if l.User == "" {
+		return l.Host
+	}
+	return l.User + "@" + l.Host
+}
+
+// String returns the normalized form scope stores: scp-style for ssh, or
+// an sftp:// URI when a port is needed
+func (l *Location) String() string {
+	switch l.Kind {
+	case Docker, DevPod:
+		return string(l.Kind) + "://" + l.Host + l.Path
+	}
+
+	if l.Port == "" {
+		return l.Destination() + ":" + l.Path
+	}
+	p := l.Path
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return fmt.Sprintf("sftp://%s:%s%s", l.Destination(), l.Port, p)
+}
+
+// Base returns the last element of the path, or the host when there is no
+// path
+func (l *Location) Base() string {
+	if l.Path == "" || l.Path == "/" {
+		return l.Host
+	}
+	return path.Base(l.Path)
+}
+
+// Parents returns the directories above the folder, nearest first
+func (l *Location) Parents() []string {
+	var parents []string
+	if l.Path == "" {
+		return parents
+	}
+	for dir := path.Dir(l.Path); dir != "/" && dir != "." && dir != "~"; dir = path.Dir(dir) {
+		parents = append(parents, path.Base(dir))
+	}
+	return parents
+}
+
+// SSHArgs returns the arguments to ssh that run command in the remote
+// folder. Set tty to allocate a terminal for interactive commands.
+func (l *Location) SSHArgs(command string, tty bool) []string {
+	var args []string
+	if tty {
+		args = append(args, "-t")
+	}
+	if l.Port != "" {
+		args = append(args, "-p", l.Port)
+	}
+	return append(args, l.Destination(), l.inDir(command))
+}
+
+// inDir prefixes command with a cd to the folder, if it has a path
+func (l *Location) inDir(command string) string {
+	if l.Path == "" {
+		return command
+	}
+	return "cd " + quotePath(l.Path) + " && " + command
+}
+
+// shellArgs returns the command line that opens an interactive shell in
+// the folder
+func (l *Location) shellArgs() []string {
+	switch l.Kind {
+	case Docker:
+		args := []string{"docker", "exec", "-it"}
+		if l.Path != "" {
+			args = append(args, "-w", l.Path)
+		}
+		return append(args, l.Host, "sh", "-c", "exec ${SHELL:-sh} -l")
+	case DevPod:
+		args := []string{"devpod", "ssh", l.Host}
+		if l.Path != "" {
+			args = append(args, "--workdir", l.Path)
+		}
+		return args
+	}
+	return append([]string{"ssh"}, l.SSHArgs("exec $SHELL -l", true)...)
+}
+
+// ShellCommand returns a command line that opens an interactive shell in
+// the folder, suitable for printing or passing to eval
+func (l *Location) ShellCommand() string {
+	return shell.Join(l.shellArgs())
+}
+
+// Command returns an *exec.Cmd running command in the folder: over ssh,
+// with docker exec, or with devpod ssh
+func (l *Location) Command(command string) *exec.Cmd {
+	switch l.Kind {
+	case Docker:
+		args := []string{"exec"}
+		if l.Path != "" {
+			args = append(args, "-w", l.Path)
+		}
+		return exec.Command("docker", append(args, l.Host, "sh", "-c", command)...)
+	case DevPod:
+		return exec.Command("devpod", "ssh", l.Host, "--command", l.inDir(command))
+	}
+	return exec.Command("ssh", l.SSHArgs(command, false)...)
+}
+
+// EditCommand returns the command that opens the folder in editor. DevPod
+// workspaces are opened with devpod up, which starts the workspace and its
+// configured IDE; other kinds need VS Code's remote support.
+func (l *Location) EditCommand(editor string) (*exec.Cmd, error) {
+	if l.Kind == DevPod {
+		return exec.Command("devpod", "up", l.Host), nil
+	}
+
+	name := strings.Fields(editor)
+	if len(name) == 0 || (name[0] != "code" && name[0] != "code-insiders") {
+		return nil, fmt.Errorf("%s folders can only be opened with VS Code (code), not %s", l.Kind, editor)
+	}
+
+	var authority string
+	switch l.Kind {
+	case Docker:
+		config := fmt.Sprintf(`{"containerName":"/%s"}`, l.Host)
+		authority = "attached-container+" + hex.EncodeToString([]byte(config))
+	default:
+		// VS Code takes a non-default port from ~/.ssh/config
+		authority = "ssh-remote+" + l.Destination()
+	}
+
+	dir := l.Path
+	if dir == "" {
+		dir = "/"
+	}
+	return exec.Command(name[0], "--remote", authority, dir), nil
+}
+
+// SSHFSAvailable reports whether remote folders can be mounted
+func SSHFSAvailable() bool {
+	_, err := exec.LookPath("sshfs")
+	return err == nil
+}
+
+// CanMount reports whether the folder can be mounted with Mount
+func (l *Location) CanMount() bool {
+	return l.Kind == SSH
+}
+
+// Mount mounts a remote ssh folder at dir, which must be an empty directory
+func (l *Location) Mount(dir string) error {
+	args := []string{l.Destination() + ":" + l.Path, dir}
+	if l.Port != "" {
+		args = append(args, "-p", l.Port)
+	}
+	cmd := exec.Command("sshfs", args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("sshfs failed for %s: %w", l, err)
+	}
+	return nil
+}
+
+// Unmount unmounts a directory mounted by Mount
+func Unmount(dir string) error {
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("fusermount"); err == nil && runtime.GOOS == "linux" {
+		cmd = exec.Command("fusermount", "-u", dir)
+	} else {
+		cmd = exec.Command("umount", dir)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to unmount %s: %v: %s", dir, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// quotePath quotes a remote path, leaving a leading ~/ for the remote
+// shell to expand
+func quotePath(p string) string {
+	if p == "~" {
+		return p
+	}
+	if rest, ok := strings.CutPrefix(p, "~/"); ok {
+		return "~/" + shell.Quote(rest)
+	}
+	return shell.Quote(p)
+}
@@ -0,0 +1,161 @@
+package location
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected *Location
+	}{
+		{"user@host:/srv/app", &Location{Kind: SSH, User: "user", Host: "host", Path: "/srv/app"}},
+		{"build-box:src/api", &Location{Kind: SSH, Host: "build-box", Path: "src/api"}},
+		{"sftp://me@example.com:2222/srv/app", &Location{Kind: SSH, User: "me", Host: "example.com", Port: "2222", Path: "/srv/app"}},
+		{"ssh://example.com/~/src", &Location{Kind: SSH, Host: "example.com", Path: "~/src"}},
+		{"docker://api-dev/workspace/api", &Location{Kind: Docker, Host: "api-dev", Path: "/workspace/api"}},
+		{"devpod://shop", &Location{Kind: DevPod, Host: "shop"}},
+		{"devpod://shop/workspaces/shop/web", &Location{Kind: DevPod, Host: "shop", Path: "/workspaces/shop/web"}},
+		{"docker:///nohost", nil},
+		{"/home/me/app", nil},
+		{"./odd:name", nil},
+		{"relative/dir", nil},
+		{`C:\src\app`, nil},
+		{"C:/src/app", nil},
+		{"host:", nil},
+		{"sftp:///nohost", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := Parse(tt.input)
+		if tt.expected == nil {
+			if ok {
+				t.Errorf("Parse(%q) = %+v, expected a local path", tt.input, got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("Parse(%q) did not recognize a remote location", tt.input)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Parse(%q) = %+v, expected %+v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"user@host:/srv/app", "user@host:/srv/app"},
+		{"sftp://user@host/srv/app", "user@host:/srv/app"},
+		{"sftp://user@host:2222/srv/app", "sftp://user@host:2222/srv/app"},
+		{"docker://api-dev/workspace//api/", "docker://api-dev/workspace/api"},
+		{"devpod://shop", "devpod://shop"},
+	}
+
+	for _, tt := range tests {
+		loc, ok := Parse(tt.input)
+		if !ok {
+			t.Fatalf("Parse(%q) failed", tt.input)
+		}
+		if got := loc.String(); got != tt.expected {
+			t.Errorf("String() of %q = %q, expected %q", tt.input, got, tt.expected)
+		}
+		// The normalized form parses back to the same location
+		again, ok := Parse(loc.String())
+		if !ok || !reflect.DeepEqual(again, loc) {
+			t.Errorf("Round trip of %q gave %+v", tt.input, again)
+		}
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	loc := &Location{Kind: SSH, User: "me", Host: "box", Port: "2222", Path: "/srv/my app"}
+
+	got := loc.SSHArgs("git status", false)
+	expected := []string{"-p", "2222", "me@box", "cd '/srv/my app' && git status"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("SSHArgs = %q, expected %q", got, expected)
+	}
+
+	home := &Location{Kind: SSH, Host: "box", Path: "~/src"}
+	if got := home.ShellCommand(); got != `ssh -t box 'cd ~/src && exec $SHELL -l'` {
+		t.Errorf("ShellCommand = %s", got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"me@box:/srv/app", []string{"ssh", "me@box", "cd /srv/app && make"}},
+		{"docker://api-dev/app", []string{"docker", "exec", "-w", "/app", "api-dev", "sh", "-c", "make"}},
+		{"devpod://shop/web", []string{"devpod", "ssh", "shop", "--command", "cd /web && make"}},
+		{"devpod://shop", []string{"devpod", "ssh", "shop", "--command", "make"}},
+	}
+
+	for _, tt := range tests {
+		loc, _ := Parse(tt.input)
+		cmd := loc.Command("make")
+		got := append([]string{cmd.Args[0]}, cmd.Args[1:]...)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Command for %s = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+
+	docker, _ := Parse("docker://api-dev/app")
+	if got := docker.ShellCommand(); got != `docker exec -it -w /app api-dev sh -c 'exec ${SHELL:-sh} -l'` {
+		t.Errorf("ShellCommand = %s", got)
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	devpod, _ := Parse("devpod://shop")
+	cmd, err := devpod.EditCommand("vim")
+	if err != nil {
+		t.Fatalf("EditCommand failed: %v", err)
+	}
+	if !reflect.DeepEqual(cmd.Args, []string{"devpod", "up", "shop"}) {
+		t.Errorf("Expected devpod up, got %q", cmd.Args)
+	}
+
+	ssh, _ := Parse("me@box:/srv/app")
+	cmd, err = ssh.EditCommand("code --wait")
+	if err != nil {
+		t.Fatalf("EditCommand failed: %v", err)
+	}
+	if !reflect.DeepEqual(cmd.Args, []string{"code", "--remote", "ssh-remote+me@box", "/srv/app"}) {
+		t.Errorf("Unexpected ssh edit command %q", cmd.Args)
+	}
+
+	docker, _ := Parse("docker://api-dev/app")
+	cmd, err = docker.EditCommand("code")
+	if err != nil {
+		t.Fatalf("EditCommand failed: %v", err)
+	}
+	// hex of {"containerName":"/api-dev"}
+	if cmd.Args[2] != "attached-container+7b22636f6e7461696e65724e616d65223a222f6170692d646576227d" {
+		t.Errorf("Unexpected container authority %s", cmd.Args[2])
+	}
+
+	if _, err := docker.EditCommand("vim"); err == nil {
+		t.Error("EditCommand should refuse editors without remote support")
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	if KindOf("/home/me") != Local {
+		t.Error("Expected local kind for an absolute path")
+	}
+	if KindOf("me@box:/srv") != SSH {
+		t.Error("Expected ssh kind for a remote location")
+	}
+	if KindOf("docker://api-dev/app") != Docker {
+		t.Error("Expected docker kind for a container folder")
+	}
+}
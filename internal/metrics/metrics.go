@@ -0,0 +1,155 @@
+// Package metrics writes metrics in the Prometheus text format, for
+// monitoring scope's long-running commands without a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets in seconds, for request latencies
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Label is a metric label
+type Label struct {
+	Name, Value string
+}
+
+// Sample is a value with its labels
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// WriteGauge writes a gauge with one value per sample
+func WriteGauge(w io.Writer, name, help string, samples ...Sample) error {
+	return writeSamples(w, name, help, "gauge", samples)
+}
+
+// WriteCounter writes a counter, a total that only grows, with one value
+// per sample. Its name should end in _total.
+func WriteCounter(w io.Writer, name, help string, samples ...Sample) error {
+	return writeSamples(w, name, help, "counter", samples)
+}
+
+// writeSamples writes a metric of kind with one value per sample
+func writeSamples(w io.Writer, name, help, kind string, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	writeHeader(bw, name, help, kind)
+	for _, s := range samples {
+		fmt.Fprintf(bw, "%s%s %s\n", name, formatLabels(s.Labels), formatValue(s.Value))
+	}
+	return bw.Flush()
+}
+
+// Histogram counts observations in buckets, per combination of label values
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is a histogram's observations for one combination of label values
+type series struct {
+	values []string
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram returns a histogram with the given upper bounds, in
+// ascending order, and label names
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*series)}
+}
+
+// Observe records v for the label values, given in the order of the
+// histogram's label names
+func (h *Histogram) Observe(v float64, values ...string) {
+	key := strings.Join(values, "\x00")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &series{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Write writes the histogram's buckets, sums and counts, with the series
+// sorted by label values
+func (h *Histogram) Write(w io.Writer) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	writeHeader(bw, h.name, h.help, "histogram")
+	for _, key := range keys {
+		s := h.series[key]
+		labels := make([]Label, len(h.labels), len(h.labels)+1)
+		for i, name := range h.labels {
+			labels[i] = Label{Name: name, Value: s.values[i]}
+		}
+		for i, le := range h.buckets {
+			bucket := append(labels, Label{Name: "le", Value: formatValue(le)})
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", h.name, formatLabels(bucket), s.counts[i])
+		}
+		bucket := append(labels, Label{Name: "le", Value: "+Inf"})
+		fmt.Fprintf(bw, "%s_bucket%s %d\n", h.name, formatLabels(bucket), s.count)
+		fmt.Fprintf(bw, "%s_sum%s %s\n", h.name, formatLabels(labels), formatValue(s.sum))
+		fmt.Fprintf(bw, "%s_count%s %d\n", h.name, formatLabels(labels), s.count)
+	}
+	return bw.Flush()
+}
+
+// writeHeader writes a metric's HELP and TYPE lines
+func writeHeader(w io.Writer, name, help, kind string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// formatLabels formats labels as {name="value",...}, or nothing without any
+func formatLabels(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.Name + `="` + escape.Replace(l.Value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatValue formats a sample value
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
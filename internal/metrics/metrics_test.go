@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteGauge(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteGauge(&buf, "scope_tag_folders", "Folders per tag.",
+		Sample{Labels: []Label{{Name: "tag", Value: `a "b"`}}, Value: 3},
+		Sample{Labels: []Label{{Name: "tag", Value: "c"}}, Value: 0.5},
+	)
+	if err != nil {
+		t.Fatalf("WriteGauge failed: %v", err)
+	}
+	want := `# HELP scope_tag_folders Folders per tag.
+# TYPE scope_tag_folders gauge
+scope_tag_folders{tag="a \"b\""} 3
+scope_tag_folders{tag="c"} 0.5
+`
+	if buf.String() != want {
+		t.Errorf("WriteGauge wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteCounter(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCounter(&buf, "scope_changes_total", "Changes.", Sample{Value: 2}); err != nil {
+		t.Fatalf("WriteCounter failed: %v", err)
+	}
+	want := "# HELP scope_changes_total Changes.\n# TYPE scope_changes_total counter\nscope_changes_total 2\n"
+	if buf.String() != want {
+		t.Errorf("WriteCounter wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/b")
+	h.Observe(0.5, "/b")
+	h.Observe(2, "/b")
+	h.Observe(1, "/a")
+
+	var buf bytes.Buffer
+	if err := h.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	want := `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 0
+latency_seconds_bucket{route="/a",le="1"} 1
+latency_seconds_bucket{route="/a",le="+Inf"} 1
+latency_seconds_sum{route="/a"} 1
+latency_seconds_count{route="/a"} 1
+latency_seconds_bucket{route="/b",le="0.1"} 1
+latency_seconds_bucket{route="/b",le="1"} 2
+latency_seconds_bucket{route="/b",le="+Inf"} 3
+latency_seconds_sum{route="/b"} 2.55
+latency_seconds_count{route="/b"} 3
+`
+	if buf.String() != want {
+		t.Errorf("Write wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,178 @@
+// Package migrate moves a scope setup to a new machine: a bundle holds the
+// tags, the git remotes of the tagged repositories and the config, and
+// applying it clones what's missing and recreates the tags.
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gabssanto/Scope/internal/export"
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/project"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// CurrentVersion is the bundle format version written by Build
+const CurrentVersion = 1
+
+// Bundle is everything needed to recreate a scope setup elsewhere. Paths
+// under the home directory are stored as ~/..., so they land in the new
+// machine's home whatever the user name.
+type Bundle struct {
+	Version int       `yaml:"version"`
+	Created time.Time `yaml:"created"`
+	// Machine is the host the bundle was exported from
+	Machine string       `yaml:"machine,omitempty"`
+	Data    *export.Data `yaml:"data"`
+	Repos   []Repo       `yaml:"repos,omitempty"`
+	// Config is the content of config.yml
+	Config string `yaml:"config,omitempty"`
+}
+
+// Repo is a tagged git repository and where to clone it from
+type Repo struct {
+	Path   string `yaml:"path"`
+	Remote string `yaml:"remote"`
+	// Branch was checked out when the bundle was made
+	Branch string `yaml:"branch,omitempty"`
+}
+
+// Build collects the tags managed by m, the origin of each tagged
+// repository and the config file at configPath (if there is one)
+func Build(m *tag.Manager, configPath, home string) (*Bundle, error) {
+	data, err := export.Build(m)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{Version: CurrentVersion, Created: time.Now().UTC(), Data: data}
+	b.Machine, _ = os.Hostname()
+
+	if content, err := os.ReadFile(configPath); err == nil {
+		b.Config = string(content)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, folders := range data.Tags {
+		for _, folder := range folders {
+			if location.IsRemote(folder) {
+				continue
+			}
+			root, ok := project.RepoRoot(folder)
+			if !ok || seen[root] {
+				continue
+			}
+			seen[root] = true
+			remote, err := git.RemoteURL(root, "origin")
+			if err != nil {
+				continue
+			}
+			branch, _ := git.Branch(root)
+			b.Repos = append(b.Repos, Repo{Path: root, Remote: remote, Branch: branch})
+		}
+	}
+	sort.Slice(b.Repos, func(i, j int) bool { return b.Repos[i].Path < b.Repos[j].Path })
+
+	b.relativize(home)
+	return b, nil
+}
+
+// relativize rewrites every path under home as ~/...
+func (b *Bundle) relativize(home string) {
+	if home == "" {
+		return
+	}
+	rel := func(p string) string { return HomeRelative(p, home) }
+
+	for name, folders := range b.Data.Tags {
+		for i, folder := range folders {
+			folders[i] = rel(folder)
+		}
+		b.Data.Tags[name] = folders
+	}
+	b.Data.Notes = relativeKeys(b.Data.Notes, rel)
+	b.Data.Subdirs = relativeKeys(b.Data.Subdirs, rel)
+	for i := range b.Repos {
+		b.Repos[i].Path = rel(b.Repos[i].Path)
+	}
+}
+
+// relativeKeys returns m with its keys rewritten by rel
+func relativeKeys(m map[string]string, rel func(string) string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[rel(k)] = v
+	}
+	return result
+}
+
+// HomeRelative returns path as ~/... when it is inside home, and unchanged
+// otherwise
+func HomeRelative(path, home string) string {
+	if path == home {
+		return "~"
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// Marshal encodes the bundle as YAML
+func Marshal(b *Bundle) ([]byte, error) {
+	output, err := yaml.Marshal(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	return output, nil
+}
+
+// Parse decodes a bundle
+func Parse(content []byte) (*Bundle, error) {
+	var b Bundle
+	if err := yaml.Unmarshal(content, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	switch {
+	case b.Version > CurrentVersion:
+		return nil, fmt.Errorf("bundle format version %d is newer than this scope supports (max %d); run 'scope update' and try again",
+			b.Version, CurrentVersion)
+	case b.Version < 1 || b.Data == nil:
+		return nil, fmt.Errorf("not a scope migration bundle (create one with 'scope migrate export')")
+	}
+	if b.Data.Tags == nil {
+		b.Data.Tags = make(map[string][]string)
+	}
+	return &b, nil
+}
+
+// Clone clones the repository to dir and checks out the branch it was
+// on, when that branch exists upstream
+func Clone(r Repo, dir string) error {
+	if err := git.Clone(r.Remote, dir); err != nil {
+		return err
+	}
+	if r.Branch == "" {
+		return nil
+	}
+	if current, err := git.Branch(dir); err == nil && current == r.Branch {
+		return nil
+	}
+	if err := git.SwitchBranch(dir, r.Branch); err != nil {
+		return fmt.Errorf("cloned %s, but branch %s is not on the remote", dir, r.Branch)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package migrate
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/selfcheck"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=me", "GIT_AUTHOR_EMAIL=me@example.com",
+		"GIT_COMMITTER_NAME=me", "GIT_COMMITTER_EMAIL=me@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestBuildParseClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	home := t.TempDir()
+	remote := filepath.Join(t.TempDir(), "api.git")
+	repo := filepath.Join(home, "code", "api")
+	if err := os.MkdirAll(filepath.Join(repo, "cmd"), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	runGit(t, home, "init", "-q", "--bare", remote)
+	runGit(t, repo, "init", "-q", "-b", "main")
+	runGit(t, repo, "remote", "add", "origin", remote)
+	runGit(t, repo, "commit", "-q", "--allow-empty", "-m", "Initial")
+	runGit(t, repo, "push", "-q", "origin", "main")
+
+	store, err := db.Open(filepath.Join(t.TempDir(), "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+	m := tag.NewManager(store)
+	outside := t.TempDir()
+	for _, folder := range []string{repo, filepath.Join(repo, "cmd"), outside} {
+		if err := m.AddTag(folder, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := m.SetNote(repo, "main service"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+
+	configPath := filepath.Join(home, "config.yml")
+	if err := os.WriteFile(configPath, []byte("hints:\n  disabled: true\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	b, err := Build(m, configPath, home)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	content, err := Marshal(b)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	b, err = Parse(content)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(b.Repos) != 1 || b.Repos[0].Path != "~/code/api" || b.Repos[0].Remote != remote || b.Repos[0].Branch != "main" {
+		t.Errorf("Unexpected repos %+v", b.Repos)
+	}
+	if got := strings.Join(b.Data.Tags["work"], ","); !strings.Contains(got, "~/code/api/cmd") || !strings.Contains(got, outside) {
+		t.Errorf("Unexpected folders %s", got)
+	}
+	if b.Data.Notes["~/code/api"] != "main service" {
+		t.Errorf("Expected note keyed by the home-relative path, got %v", b.Data.Notes)
+	}
+	if !strings.Contains(b.Config, "disabled: true") {
+		t.Errorf("Expected config in the bundle, got %q", b.Config)
+	}
+
+	clone := filepath.Join(t.TempDir(), "new", "api")
+	if err := Clone(b.Repos[0], clone); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, ".git")); err != nil {
+		t.Errorf("Expected a clone at %s: %v", clone, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, content := range []string{"version: 2\ndata:\n  tags: {}\n", "version: 1\n", "tags:\n  work: [/a]\n"} {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Errorf("Parse should reject %q", content)
+		}
+	}
+}
+
+func TestHomeRelative(t *testing.T) {
+	home := filepath.FromSlash("/home/me")
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/home/me", "~"},
+		{"/home/me/code/api", "~/code/api"},
+		{"/home/meg/code", "/home/meg/code"},
+		{"/srv/app", "/srv/app"},
+	}
+	for _, tt := range tests {
+		if got := HomeRelative(filepath.FromSlash(tt.path), home); got != filepath.FromSlash(tt.expected) && got != tt.expected {
+			t.Errorf("HomeRelative(%q) = %q, expected %q", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestInstallShell(t *testing.T) {
+	home := t.TempDir()
+	env := &selfcheck.Env{Home: home, Shell: "/bin/zsh"}
+	rc := filepath.Join(home, ".zshrc")
+	if err := os.WriteFile(rc, []byte("eval \"$(scope completions zsh)\"\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	file, added, err := InstallShell(env)
+	if err != nil {
+		t.Fatalf("InstallShell failed: %v", err)
+	}
+	if file != rc || len(added) != 1 || added[0] != `eval "$(scope init zsh)"` {
+		t.Errorf("Unexpected install: %s %v", file, added)
+	}
+
+	// A second run has nothing to add
+	if _, added, err := InstallShell(env); err != nil || len(added) != 0 {
+		t.Errorf("Expected no changes, got %v, %v", added, err)
+	}
+
+	env.Shell = "/bin/tcsh"
+	if _, _, err := InstallShell(env); err == nil {
+		t.Error("InstallShell should reject unsupported shells")
+	}
+}
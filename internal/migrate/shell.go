@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/selfcheck"
+)
+
+// shellLines are the startup file lines that load completions and the
+// shell integration, with the text that shows each is already there
+func shellLines(shell string) [][2]string {
+	if shell == "fish" {
+		return [][2]string{
+			{"scope completions fish", "scope completions fish | source"},
+			{"scope init", "scope init fish | source"},
+		}
+	}
+	return [][2]string{
+		{"scope completions " + shell, fmt.Sprintf(`eval "$(scope completions %s)"`, shell)},
+		{"scope init", fmt.Sprintf(`eval "$(scope init %s)"`, shell)},
+	}
+}
+
+// InstallShell adds completions and the shell integration to the startup
+// file of env's shell, unless it already loads them. It returns the file
+// and the lines added.
+func InstallShell(env *selfcheck.Env) (string, []string, error) {
+	shell := filepath.Base(env.Shell)
+	files := selfcheck.ShellFiles(env, shell)
+	if files == nil {
+		return "", nil, fmt.Errorf("unsupported or unknown shell %q (scope supports bash, zsh and fish)", env.Shell)
+	}
+
+	var existing strings.Builder
+	for _, f := range files {
+		if content, err := os.ReadFile(f); err == nil {
+			existing.Write(content)
+		}
+	}
+
+	var added []string
+	for _, line := range shellLines(shell) {
+		if !strings.Contains(existing.String(), line[0]) {
+			added = append(added, line[1])
+		}
+	}
+	rcFile := files[0]
+	if len(added) == 0 {
+		return rcFile, nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rcFile), 0755); err != nil {
+		return "", nil, err
+	}
+	f, err := os.OpenFile(rcFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to open %s: %w", rcFile, err)
+	}
+	_, err = fmt.Fprintf(f, "\n# scope\n%s\n", strings.Join(added, "\n"))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to write %s: %w", rcFile, err)
+	}
+	return rcFile, added, nil
+}
@@ -0,0 +1,216 @@
+// Package mine finds the untagged projects a user works in most from their
+// shell history, for scope mine-history: the directories they cd into under
+// the project roots, ranked by how often.
+package mine
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/paths"
+	"github.com/gabssanto/Scope/internal/project"
+	"github.com/gabssanto/Scope/internal/session"
+	"github.com/gabssanto/Scope/internal/shell"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// Candidate is an untagged project directory and how often the history
+// visits it
+type Candidate struct {
+	Path   string
+	Visits int
+}
+
+// Options control where history is read from and which directories count
+type Options struct {
+	// Home is the user's home directory: where history files are looked
+	// for and where a bare cd goes
+	Home string
+	// Roots are the directories projects live in. Visits outside them are
+	// ignored.
+	Roots []string
+	// HistoryFiles are read instead of the shells' default files when set
+	HistoryFiles []string
+}
+
+// HistoryFiles returns the bash, zsh and fish history files that exist:
+// $HISTFILE, ~/.bash_history, ~/.zsh_history (or under $ZDOTDIR) and fish's
+// history under $XDG_DATA_HOME
+func HistoryFiles(home string) []string {
+	candidates := []string{os.Getenv("HISTFILE"), filepath.Join(home, ".bash_history"), filepath.Join(home, ".zsh_history")}
+	if zdot := os.Getenv("ZDOTDIR"); zdot != "" {
+		candidates = append(candidates, filepath.Join(zdot, ".zsh_history"))
+	}
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = filepath.Join(home, ".local", "share")
+	}
+	candidates = append(candidates, filepath.Join(data, "fish", "fish_history"))
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, path := range candidates {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// CdTargets returns the directories the commands cd (or pushd) into, as
+// absolute paths. Relative targets are resolved against the directory the
+// earlier commands moved to, starting from home, which is as close as a
+// history file without working directories gets.
+func CdTargets(commands []string, home string) []string {
+	var targets []string
+	cwd, prev := home, ""
+	for _, command := range commands {
+		for _, part := range splitCommands(command) {
+			words, err := shell.Split(part)
+			if err != nil || len(words) == 0 || (words[0] != "cd" && words[0] != "pushd") {
+				continue
+			}
+			args := words[1:]
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "-" {
+				args = args[1:]
+			}
+
+			var dir string
+			switch {
+			case len(args) == 0:
+				dir = home
+			case args[0] == "-":
+				if prev == "" {
+					continue
+				}
+				dir = prev
+			default:
+				dir = args[0]
+				if dir == "~" || strings.HasPrefix(dir, "~/") {
+					dir = home + dir[1:]
+				} else if expanded, err := paths.Expand(dir); err == nil {
+					dir = expanded
+				}
+				if !filepath.IsAbs(dir) {
+					dir = filepath.Join(cwd, dir)
+				}
+			}
+			dir = filepath.Clean(dir)
+			prev, cwd = cwd, dir
+			targets = append(targets, dir)
+		}
+	}
+	return targets
+}
+
+// splitCommands splits a command line at ;, &&, || and |, ignoring quoted
+// separators
+func splitCommands(line string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '\\':
+			i++
+		case c == ';' || c == '|' || c == '&':
+			parts = append(parts, line[start:i])
+			if i+1 < len(line) && (line[i+1] == c) {
+				i++
+			}
+			start = i + 1
+		}
+	}
+	return append(parts, line[start:])
+}
+
+// Rank reads the history and returns the untagged project directories
+// visited under the roots, most visited first. A visit anywhere inside a
+// project counts for the project: its repository root when it is in one
+// under a root, otherwise the root's subdirectory it is in. Directories
+// that no longer exist are left out.
+func Rank(m *tag.Manager, opts Options) ([]Candidate, error) {
+	files := opts.HistoryFiles
+	if files == nil {
+		files = HistoryFiles(opts.Home)
+	}
+	var commands []string
+	for _, file := range files {
+		fileCommands, err := session.ReadHistory(file)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, fileCommands...)
+	}
+
+	folders, err := m.ListAllFolders()
+	if err != nil {
+		return nil, err
+	}
+	tagged := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		if !location.IsRemote(folder) {
+			tagged[folder] = true
+		}
+	}
+
+	visits := make(map[string]int)
+	// Resolving the same directory over and over is wasted work
+	projects := make(map[string]string)
+	for _, dir := range CdTargets(commands, opts.Home) {
+		p, ok := projects[dir]
+		if !ok {
+			p = projectOf(dir, opts.Roots)
+			projects[dir] = p
+		}
+		if p != "" && !tagged[p] {
+			visits[p]++
+		}
+	}
+
+	candidates := make([]Candidate, 0, len(visits))
+	for path, count := range visits {
+		candidates = append(candidates, Candidate{Path: path, Visits: count})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Visits != candidates[j].Visits {
+			return candidates[i].Visits > candidates[j].Visits
+		}
+		return candidates[i].Path < candidates[j].Path
+	})
+	return candidates, nil
+}
+
+// projectOf returns the project dir belongs to, or "" when it isn't
+// strictly inside one of roots or no longer exists
+func projectOf(dir string, roots []string) string {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		rel, err := filepath.Rel(root, dir)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if repo, ok := project.RepoRoot(dir); ok && len(repo) > len(root) && strings.HasPrefix(repo, root+string(filepath.Separator)) {
+			return repo
+		}
+		return filepath.Join(root, strings.Split(rel, string(filepath.Separator))[0])
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package mine
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestCdTargets(t *testing.T) {
+	commands := []string{
+		"cd ~/code/api",
+		"git pull && cd ../web; ls",
+		"cd -",
+		"echo 'cd nowhere' | cat",
+		`cd "/srv/my app"`,
+		"pushd -q src",
+		"cd",
+		"ls -la",
+	}
+	expected := []string{
+		"/home/me/code/api",
+		"/home/me/code/web",
+		"/home/me/code/api",
+		"/srv/my app",
+		"/srv/my app/src",
+		"/home/me",
+	}
+	if got := CdTargets(commands, "/home/me"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("CdTargets =\n%q\nwant\n%q", got, expected)
+	}
+}
+
+func TestRank(t *testing.T) {
+	home, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	for _, dir := range []string{"code/api", "code/web/.git", "code/web/cmd", "code/tools/lint", "other/x"} {
+		if err := os.MkdirAll(filepath.Join(home, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+
+	store, err := db.Open(filepath.Join(home, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := tag.NewManager(store)
+	if err := m.AddTag(filepath.Join(home, "code", "api"), "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	history := filepath.Join(home, ".zsh_history")
+	content := ": 1700000000:0;cd ~/code/web/cmd\n" +
+		": 1700000001:0;cd ~/code/api\n" +
+		": 1700000002:0;cd ../web\n" +
+		": 1700000003:0;cd ~/code/tools/lint\n" +
+		": 1700000004:0;cd ~/other/x\n" +
+		": 1700000005:0;cd ~/code/gone\n"
+	if err := os.WriteFile(history, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	candidates, err := Rank(m, Options{Home: home, Roots: []string{filepath.Join(home, "code")}, HistoryFiles: []string{history}})
+	if err != nil {
+		t.Fatalf("Rank failed: %v", err)
+	}
+	// Visits inside a repository count for it; tagged folders, folders
+	// outside the roots and missing ones are left out
+	expected := []Candidate{
+		{Path: filepath.Join(home, "code", "web"), Visits: 2},
+		{Path: filepath.Join(home, "code", "tools"), Visits: 1},
+	}
+	if !reflect.DeepEqual(candidates, expected) {
+		t.Errorf("Rank = %+v, want %+v", candidates, expected)
+	}
+}
@@ -0,0 +1,56 @@
+package mine
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// Choice is a candidate offered for tagging with Tag
+type Choice struct {
+	Candidate
+	Tag string
+}
+
+// Label describes the choice in the selection list
+func (c Choice) Label() string {
+	return fmt.Sprintf("%s (%d visits) → %s", c.Path, c.Visits, c.Tag)
+}
+
+// SelectChoices presents a multi-select of the choices and returns those
+// picked
+func SelectChoices(choices []Choice) ([]Choice, error) {
+	if len(choices) == 0 {
+		return nil, nil
+	}
+	if err := ui.CanPrompt(); err != nil {
+		return nil, err
+	}
+
+	options := make([]huh.Option[int], len(choices))
+	for i, c := range choices {
+		options[i] = huh.NewOption(c.Label(), i)
+	}
+
+	var picked []int
+	form := ui.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Tag the folders you visit most").
+				Description("space: toggle, enter: confirm, /: filter").
+				Options(options...).
+				Value(&picked),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("selection canceled: %w", err)
+	}
+
+	selected := make([]Choice, 0, len(picked))
+	for _, i := range picked {
+		selected = append(selected, choices[i])
+	}
+	return selected, nil
+}
@@ -0,0 +1,35 @@
+// Package network holds the process-wide offline switch. In offline mode
+// every feature that would reach another machine (forge APIs, the update
+// check, webhooks, git clone, pull and push) stops short: it answers from
+// cached data where there is some and otherwise fails with ErrOffline.
+package network
+
+import (
+	"errors"
+	"fmt"
+)
+
+// offline is set once from the global flags and the config
+var offline bool
+
+// ErrOffline is returned by features that need the network in offline mode
+var ErrOffline = errors.New("network access is off (--offline)")
+
+// SetOffline turns offline mode on or off
+func SetOffline(v bool) {
+	offline = v
+}
+
+// Offline reports whether offline mode is on
+func Offline() bool {
+	return offline
+}
+
+// Check returns nil when what may use the network, and otherwise an error
+// naming it
+func Check(what string) error {
+	if offline {
+		return fmt.Errorf("%s needs the network: %w", what, ErrOffline)
+	}
+	return nil
+}
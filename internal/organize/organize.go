@@ -0,0 +1,232 @@
+// Package organize finds groups of tags that are probably the same tag
+// spelled differently (case variants, separators, plurals and typos) so
+// they can be merged in one pass.
+package organize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// Group is a set of similar tags and the one they should probably be
+// merged into
+type Group struct {
+	// Tags are the similar tags, most used first
+	Tags []string
+	// Counts is the number of folders of each tag
+	Counts map[string]int
+	// Target is the suggested tag to keep: the most used one
+	Target string
+}
+
+// Label describes the group for selection lists
+func (g Group) Label() string {
+	parts := make([]string, len(g.Tags))
+	for i, name := range g.Tags {
+		parts[i] = fmt.Sprintf("%s (%d)", name, g.Counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Merge is one tag to merge into another
+type Merge struct {
+	From string
+	Into string
+}
+
+// Find groups the tags of m that look alike
+func Find(m *tag.Manager) ([]Group, error) {
+	counts, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	return group(counts), nil
+}
+
+// group clusters names that are similar to each other, directly or through
+// another name
+func group(counts map[string]int) []Group {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parent := make([]int, len(names))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = normalize(name)
+	}
+	for i := range names {
+		for j := i + 1; j < len(names); j++ {
+			if keys[i] == keys[j] || (similar(keys[i], keys[j]) && !numbered(names[i], names[j])) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]string)
+	for i, name := range names {
+		root := find(i)
+		members[root] = append(members[root], name)
+	}
+
+	var groups []Group
+	for _, tags := range members {
+		if len(tags) < 2 {
+			continue
+		}
+		sort.Slice(tags, func(i, j int) bool {
+			if counts[tags[i]] != counts[tags[j]] {
+				return counts[tags[i]] > counts[tags[j]]
+			}
+			return tags[i] < tags[j]
+		})
+		g := Group{Tags: tags, Counts: make(map[string]int, len(tags)), Target: tags[0]}
+		for _, name := range tags {
+			g.Counts[name] = counts[name]
+		}
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Target < groups[j].Target })
+	return groups
+}
+
+// normalize folds the differences that never distinguish two tags: case,
+// separators and a plural ending
+func normalize(name string) string {
+	key := strings.ToLower(name)
+	key = strings.NewReplacer("-", "", "_", "", " ", "", ".", "").Replace(key)
+
+	switch {
+	case strings.HasSuffix(key, "ies") && len(key) > 4:
+		key = strings.TrimSuffix(key, "ies") + "y"
+	case strings.HasSuffix(key, "ss"):
+	case strings.HasSuffix(key, "s") && len(key) > 3:
+		key = strings.TrimSuffix(key, "s")
+	}
+	return key
+}
+
+// isSeparator reports whether r separates the words of a tag
+func isSeparator(r rune) bool {
+	return r == '-' || r == '_' || r == ':' || r == '/' || r == '.' || r == ' '
+}
+
+// numbered reports whether a and b are members of a series, such as
+// client-a and client-b or v1 and v2, whose small difference is deliberate:
+// they differ in exactly one word, and that word is short or has a digit
+func numbered(a, b string) bool {
+	wa := strings.FieldsFunc(strings.ToLower(a), isSeparator)
+	wb := strings.FieldsFunc(strings.ToLower(b), isSeparator)
+	if len(wa) != len(wb) {
+		return false
+	}
+
+	differing := -1
+	for i := range wa {
+		if wa[i] != wb[i] {
+			if differing >= 0 {
+				return false
+			}
+			differing = i
+		}
+	}
+	if differing < 0 {
+		return false
+	}
+
+	x, y := wa[differing], wb[differing]
+	if utf8.RuneCountInString(x) <= 2 || utf8.RuneCountInString(y) <= 2 {
+		return true
+	}
+	return strings.ContainsAny(x+y, "0123456789")
+}
+
+// similar reports whether two normalized names are within a typo of each
+// other. Short names must match exactly, since "api" and "app" are
+// different tags.
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	shorter := min(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	var allowed int
+	switch {
+	case shorter >= 8:
+		allowed = 2
+	case shorter >= 5:
+		allowed = 1
+	default:
+		return false
+	}
+	return distance(a, b) <= allowed
+}
+
+// distance is the Levenshtein edit distance between a and b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// Merges returns the merges that fold every tag of g into target; an empty
+// target keeps the tags separate
+func Merges(g Group, target string) []Merge {
+	if target == "" {
+		return nil
+	}
+	var merges []Merge
+	for _, name := range g.Tags {
+		if name != target {
+			merges = append(merges, Merge{From: name, Into: target})
+		}
+	}
+	return merges
+}
+
+// Apply performs the merges, continuing past failures. It returns the
+// merges that were applied and the errors of the rest.
+func Apply(m *tag.Manager, merges []Merge) ([]Merge, []error) {
+	var applied []Merge
+	var errs []error
+	for _, mg := range merges {
+		if err := m.MergeTag(mg.From, mg.Into); err != nil {
+			errs = append(errs, fmt.Errorf("merge '%s' into '%s': %w", mg.From, mg.Into, err))
+			continue
+		}
+		applied = append(applied, mg)
+	}
+	return applied, errs
+}
@@ -0,0 +1,119 @@
+package organize
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestGroup(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+		want   [][]string
+	}{
+		{"case", map[string]int{"Work": 1, "work": 3}, [][]string{{"work", "Work"}}},
+		{"separators", map[string]int{"front-end": 2, "front_end": 1, "frontend": 1}, [][]string{{"front-end", "front_end", "frontend"}}},
+		{"plural", map[string]int{"library": 1, "libraries": 2, "tool": 1, "tools": 1}, [][]string{{"libraries", "library"}, {"tool", "tools"}}},
+		{"typo", map[string]int{"backend": 4, "bakend": 1}, [][]string{{"backend", "bakend"}}},
+		{"short names", map[string]int{"api": 1, "app": 1, "go": 1}, nil},
+		{"series", map[string]int{"client-a": 1, "client-b": 1, "v1": 1, "v2": 1, "release-2023": 1, "release-2024": 1}, nil},
+		{"distinct", map[string]int{"work": 1, "personal": 1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][]string
+			for _, g := range group(tt.counts) {
+				got = append(got, g.Tags)
+				if g.Target != g.Tags[0] {
+					t.Errorf("Expected target %q, got %q", g.Tags[0], g.Target)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected groups %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"backend", "bakend", 1},
+		{"café", "cafe", 1},
+	}
+	for _, tt := range tests {
+		if got := distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMerges(t *testing.T) {
+	g := Group{Tags: []string{"work", "Work", "works"}}
+
+	got := Merges(g, "Work")
+	want := []Merge{{From: "work", Into: "Work"}, {From: "works", Into: "Work"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if Merges(g, "") != nil {
+		t.Error("An empty target should keep the tags separate")
+	}
+}
+
+func TestFindAndApply(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	m := tag.NewManager(store)
+
+	for folder, tags := range map[string][]string{"api": {"backend", "Backend"}, "web": {"Backend", "frontend"}} {
+		path := filepath.Join(tmpDir, folder)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		for _, name := range tags {
+			if err := m.AddTag(path, name); err != nil {
+				t.Fatalf("AddTag failed: %v", err)
+			}
+		}
+	}
+
+	groups, err := Find(m)
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Target != "Backend" {
+		t.Fatalf("Expected one group kept as 'Backend', got %+v", groups)
+	}
+
+	applied, errs := Apply(m, Merges(groups[0], "backend"))
+	if len(applied) != 1 || len(errs) != 0 {
+		t.Fatalf("Apply: %v applied, errors %v", applied, errs)
+	}
+
+	counts, err := m.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(counts, map[string]int{"backend": 2, "frontend": 1}) {
+		t.Errorf("Unexpected tags after merging: %v", counts)
+	}
+}
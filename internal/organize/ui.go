@@ -0,0 +1,49 @@
+package organize
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// SelectMerges asks, for each group, which tag to merge the others into,
+// or to leave the group alone
+func SelectMerges(groups []Group) ([]Merge, error) {
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	if err := ui.CanPrompt(); err != nil {
+		return nil, err
+	}
+
+	choices := make([]string, len(groups))
+	fields := make([]*huh.Group, len(groups))
+	for i, g := range groups {
+		options := make([]huh.Option[string], 0, len(g.Tags)+1)
+		for _, name := range g.Tags {
+			options = append(options, huh.NewOption(fmt.Sprintf("Merge into '%s'", name), name))
+		}
+		options = append(options, huh.NewOption("Keep separate", ""))
+
+		choices[i] = g.Target
+		fields[i] = huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("Similar tags (%d of %d)", i+1, len(groups))).
+				Description(g.Label()).
+				Options(options...).
+				Value(&choices[i]),
+		)
+	}
+
+	if err := ui.NewForm(fields...).Run(); err != nil {
+		return nil, fmt.Errorf("selection canceled: %w", err)
+	}
+
+	var merges []Merge
+	for i, g := range groups {
+		merges = append(merges, Merges(g, choices[i])...)
+	}
+	return merges, nil
+}
@@ -0,0 +1,208 @@
+package paths
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Resolve converts a user-supplied path (including ., ~, ~user and
+// environment variables) to a clean absolute path
+func Resolve(path string) (string, error) {
+	// Handle current directory
+	if path == "." {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		return cwd, nil
+	}
+
+	path, err := Expand(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Get absolute path
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	return absPath, nil
+}
+
+// Expand performs the shell-style expansions scope applies to every path it
+// accepts, without making the path absolute:
+//   - a leading ~ or ~/ becomes the current user's home directory
+//   - a leading ~user or ~user/ becomes that user's home directory
+//   - $VAR and ${VAR} are replaced by the environment variable's value
+//   - on Windows, %VAR% is replaced as well
+//
+// Like the shell, unknown users and unset variables are left untouched.
+func Expand(path string) (string, error) {
+	path, err := expandTilde(path)
+	if err != nil {
+		return "", err
+	}
+
+	path = expandVars(path, os.LookupEnv)
+	if runtime.GOOS == "windows" {
+		path = expandWindowsVars(path, os.LookupEnv)
+	}
+
+	return path, nil
+}
+
+// isSeparator reports whether c ends a ~user prefix
+func isSeparator(c byte) bool {
+	return c == '/' || (runtime.GOOS == "windows" && c == '\\')
+}
+
+// expandTilde expands a leading ~ or ~user
+func expandTilde(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	end := 1
+	for end < len(path) && !isSeparator(path[end]) {
+		end++
+	}
+	name, rest := path[1:end], path[end:]
+
+	if name == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return homeDir + rest, nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil || u.HomeDir == "" {
+		return path, nil
+	}
+	return u.HomeDir + rest, nil
+}
+
+// isVarChar reports whether c may appear in an environment variable name
+func isVarChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// expandVars replaces $VAR and ${VAR} with values from lookup
+func expandVars(s string, lookup func(string) (string, bool)) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		var name string
+		var end int
+		if s[i+1] == '{' {
+			closing := strings.IndexByte(s[i+2:], '}')
+			if closing < 0 {
+				b.WriteByte(s[i])
+				continue
+			}
+			name = s[i+2 : i+2+closing]
+			end = i + 2 + closing + 1
+		} else {
+			end = i + 1
+			for end < len(s) && isVarChar(s[end]) {
+				end++
+			}
+			name = s[i+1 : end]
+		}
+
+		value, ok := lookup(name)
+		if name == "" || !ok {
+			b.WriteByte(s[i])
+			continue
+		}
+		b.WriteString(value)
+		i = end - 1
+	}
+	return b.String()
+}
+
+// expandWindowsVars replaces %VAR% with values from lookup
+func expandWindowsVars(s string, lookup func(string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '%')
+		if start < 0 {
+			break
+		}
+		length := strings.IndexByte(s[start+1:], '%')
+		if length < 0 {
+			break
+		}
+
+		name := s[start+1 : start+1+length]
+		value, ok := lookup(name)
+		if name == "" || !ok {
+			// Keep the first % and retry from the second, which may open
+			// a valid reference
+			b.WriteString(s[:start+1])
+			s = s[start+1:]
+			continue
+		}
+
+		b.WriteString(s[:start])
+		b.WriteString(value)
+		s = s[start+1+length+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// SymlinkPolicy controls how a path that is (or passes through) a symlink
+// is stored when it is tagged
+type SymlinkPolicy string
+
+const (
+	// SymlinkResolve stores the symlink's target, so a folder tagged through
+	// a symlink and through its real path is one folder (the default)
+	SymlinkResolve SymlinkPolicy = "resolve"
+
+	// SymlinkKeep stores the path as given, treating each symlink as a
+	// folder of its own
+	SymlinkKeep SymlinkPolicy = "keep"
+)
+
+// ParseSymlinkPolicy validates a policy name; an empty name is the default
+func ParseSymlinkPolicy(name string) (SymlinkPolicy, error) {
+	switch SymlinkPolicy(name) {
+	case "", SymlinkResolve:
+		return SymlinkResolve, nil
+	case SymlinkKeep:
+		return SymlinkKeep, nil
+	default:
+		return "", fmt.Errorf("invalid symlink policy %q (expected %q or %q)", name, SymlinkResolve, SymlinkKeep)
+	}
+}
+
+// Canonical returns the path scope stores for an absolute path under
+// policy. Paths that cannot be resolved (e.g. they no longer exist) are
+// returned unchanged.
+func Canonical(path string, policy SymlinkPolicy) string {
+	if policy == SymlinkKeep {
+		return path
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+	return resolved
+}
@@ -0,0 +1,181 @@
+package paths
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("No home directory: %v", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{".", cwd},
+		{"~", homeDir},
+		{"~/projects", filepath.Join(homeDir, "projects")},
+		{"/tmp/a/../b", "/tmp/b"},
+		{"/tmp/a/", "/tmp/a"},
+		{"relative", filepath.Join(cwd, "relative")},
+		{"$SCOPE_TEST_ROOT/api", "/srv/code/api"},
+	}
+	t.Setenv("SCOPE_TEST_ROOT", "/srv/code")
+
+	for _, tt := range tests {
+		got, err := Resolve(tt.input)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Resolve(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("No home directory: %v", err)
+	}
+	t.Setenv("SCOPE_TEST_ROOT", "/srv/code")
+	t.Setenv("SCOPE_TEST_EMPTY", "")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"~", homeDir},
+		{"~/projects", homeDir + "/projects"},
+		{"$SCOPE_TEST_ROOT/api", "/srv/code/api"},
+		{"${SCOPE_TEST_ROOT}api", "/srv/codeapi"},
+		{"$SCOPE_TEST_EMPTY/api", "/api"},
+		{"$SCOPE_TEST_UNSET/api", "$SCOPE_TEST_UNSET/api"},
+		{"${SCOPE_TEST_UNSET}", "${SCOPE_TEST_UNSET}"},
+		{"${unclosed", "${unclosed"},
+		{"cost$", "cost$"},
+		{"~no-such-user-scope/x", "~no-such-user-scope/x"},
+		{"a/~/b", "a/~/b"},
+	}
+
+	for _, tt := range tests {
+		got, err := Expand(tt.input)
+		if err != nil {
+			t.Errorf("Expand(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Expand(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestExpandTildeUser(t *testing.T) {
+	u, err := user.Current()
+	if err != nil || u.HomeDir == "" || u.Username == "" {
+		t.Skip("Current user has no lookup entry")
+	}
+
+	got, err := Expand("~" + u.Username + "/src")
+	if err != nil {
+		t.Fatalf("Expand failed: %v", err)
+	}
+	if got != u.HomeDir+"/src" {
+		t.Errorf("Expected %q, got %q", u.HomeDir+"/src", got)
+	}
+}
+
+func TestExpandWindowsVars(t *testing.T) {
+	env := map[string]string{"USERPROFILE": `C:\Users\gab`, "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`%USERPROFILE%\code`, `C:\Users\gab\code`},
+		{`%EMPTY%x`, `x`},
+		{`%UNSET%\x`, `%UNSET%\x`},
+		{`100%`, `100%`},
+		{`50% off %USERPROFILE%`, `50% off C:\Users\gab`},
+		{`%%`, `%%`},
+	}
+
+	for _, tt := range tests {
+		if got := expandWindowsVars(tt.input, lookup); got != tt.expected {
+			t.Errorf("expandWindowsVars(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// checkCanonical asserts the properties every resolved path must have
+func checkCanonical(t *testing.T, input, resolved string) {
+	t.Helper()
+
+	if !filepath.IsAbs(resolved) {
+		t.Errorf("Resolve(%q) = %q is not absolute", input, resolved)
+	}
+	if filepath.Clean(resolved) != resolved {
+		t.Errorf("Resolve(%q) = %q is not clean", input, resolved)
+	}
+
+	// Canonicalization must be idempotent
+	again, err := Resolve(resolved)
+	if err != nil {
+		t.Errorf("Resolve(%q) failed on its own output: %v", resolved, err)
+		return
+	}
+	if again != resolved {
+		t.Errorf("Resolve is not idempotent: %q -> %q -> %q", input, resolved, again)
+	}
+}
+
+func TestResolveIdempotent(t *testing.T) {
+	inputs := []string{
+		".", "..", "~", "~/", "~/a/../b", "a//b", "./a/./b/", "/", "//", "/a/b/..",
+		"folder with spaces", "unicode-ünï/çødé", "~~", "a/~/b",
+		"$HOME/x", "${HOME}", "$", "${", "$UNSET_SCOPE_VAR", "~root", "%PATH%",
+	}
+
+	for _, input := range inputs {
+		resolved, err := Resolve(input)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", input, err)
+			continue
+		}
+		checkCanonical(t, input, resolved)
+	}
+}
+
+func FuzzResolve(f *testing.F) {
+	for _, seed := range []string{".", "~", "~/x", "../a", "/a//b/", "a b", "\t", "ü/ß", "$HOME", "${HOME}/x", "~root/x"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		// The OS rejects NUL bytes in paths, so they can never reach Resolve
+		if strings.ContainsRune(input, 0) {
+			t.Skip()
+		}
+
+		resolved, err := Resolve(input)
+		if err != nil {
+			return
+		}
+		checkCanonical(t, input, resolved)
+	})
+}
@@ -0,0 +1,68 @@
+package picker
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// tagColors are the lipgloss colors of the color names tags can be given
+// (see ui.Color), as ANSI colors so they follow the terminal's theme
+var tagColors = map[string]lipgloss.Color{
+	"red":     "1",
+	"green":   "2",
+	"yellow":  "3",
+	"blue":    "4",
+	"magenta": "5",
+	"cyan":    "6",
+	"white":   "7",
+}
+
+// tagStyle returns the style of a tag with info
+func tagStyle(info tag.Info) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	if color, ok := tagColors[info.Color]; ok {
+		style = style.Foreground(color)
+	}
+	if info.Color == "bold" {
+		style = style.Bold(true)
+	}
+	return style
+}
+
+// tagLabel returns a tag's name after its icon, in its color
+func tagLabel(name string, info tag.Info) string {
+	if info.Icon != "" {
+		name = info.Icon + " " + name
+	}
+	return tagStyle(info).Render(name)
+}
+
+// TagBadges returns Badges showing, for each folder, the icons of its tags
+// and a dot in the color of each colored one. Tags are read once, when
+// TagBadges is called.
+func TagBadges(m *tag.Manager) func(folder string) string {
+	infos, err := m.ListTagInfo()
+	if err != nil || len(infos) == 0 {
+		return nil
+	}
+	folderTags, err := m.ListFolderTags()
+	if err != nil {
+		return nil
+	}
+	return func(folder string) string {
+		var badges []string
+		for _, name := range folderTags[folder] {
+			info, ok := infos[name]
+			switch {
+			case !ok:
+			case info.Icon != "":
+				badges = append(badges, tagStyle(info).Render(info.Icon))
+			case info.Color != "":
+				badges = append(badges, tagStyle(info).Render("●"))
+			}
+		}
+		return strings.Join(badges, "")
+	}
+}
@@ -0,0 +1,52 @@
+package picker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestTagBadges(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	m := tag.NewManager(store)
+
+	api := filepath.Join(tmpDir, "api")
+	if err := os.Mkdir(api, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	for _, name := range []string{"work", "go", "old"} {
+		if err := m.AddTag(api, name); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	if badges := TagBadges(m); badges != nil {
+		t.Error("Expected no badges without described tags")
+	}
+
+	icon, color := "💼", "blue"
+	if err := m.SetTagInfo("work", tag.InfoUpdate{Icon: &icon}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+	if err := m.SetTagInfo("go", tag.InfoUpdate{Color: &color}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+	// Without a terminal, styles render as plain text
+	if got := TagBadges(m)(api); got != "●💼" {
+		t.Errorf("Expected a dot for go and the icon of work, got %q", got)
+	}
+	if got := TagBadges(m)(filepath.Join(tmpDir, "web")); got != "" {
+		t.Errorf("Expected no badges for an untagged folder, got %q", got)
+	}
+}
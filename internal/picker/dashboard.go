@@ -0,0 +1,393 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/location"
+)
+
+// DashboardStore is what the dashboard reads and changes; *tag.Manager
+// implements it
+type DashboardStore interface {
+	TagStore
+	ListFoldersByTag(tagName string) ([]string, error)
+}
+
+// ActionKind is what the user left the dashboard to do
+type ActionKind int
+
+const (
+	// ActionNone means the dashboard was closed without choosing anything
+	ActionNone ActionKind = iota
+	// ActionJump means go to Action.Folder
+	ActionJump
+	// ActionStart means start a session of Action.Tag
+	ActionStart
+)
+
+// Action is what the dashboard was left with
+type Action struct {
+	Kind   ActionKind
+	Tag    string
+	Folder string
+}
+
+// pane is the half of the dashboard that has the focus
+type pane int
+
+const (
+	tagsPane pane = iota
+	foldersPane
+)
+
+// statusMsg carries the git status of a folder, loaded in the background
+type statusMsg struct {
+	folder string
+	text   string
+}
+
+// dashboard is the bubbletea model of 'scope ui': tags on the left, the
+// highlighted tag's folders on the right
+type dashboard struct {
+	store      DashboardStore
+	tags       []string
+	counts     map[string]int
+	folders    []string
+	tagList    listState
+	folderList listState
+	focus      pane
+	showGit    bool
+	statuses   map[string]string
+	editor     *tagEditor
+	status     string
+	width      int
+	height     int
+	action     Action
+	done       bool
+}
+
+// listState is the cursor and scroll position of one pane
+type listState struct {
+	cursor int
+	offset int
+}
+
+// move moves the cursor by delta within n items, scrolling to keep it in
+// the rows visible
+func (l *listState) move(delta, n, rows int) {
+	l.cursor = min(max(l.cursor+delta, 0), max(n-1, 0))
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+rows {
+		l.offset = l.cursor - rows + 1
+	}
+}
+
+func newDashboard(store DashboardStore) dashboard {
+	d := dashboard{
+		store:    store,
+		width:    100,
+		height:   24,
+		statuses: make(map[string]string),
+	}
+	d.reload()
+	return d
+}
+
+// Dashboard shows the full-screen dashboard on stderr until the user
+// quits, jumps to a folder or starts a session, and returns which
+func Dashboard(store DashboardStore) (Action, error) {
+	final, err := tea.NewProgram(newDashboard(store), tea.WithOutput(os.Stderr), tea.WithAltScreen()).Run()
+	if err != nil {
+		return Action{}, fmt.Errorf("dashboard failed: %w", err)
+	}
+	return final.(dashboard).action, nil
+}
+
+// reload reads the tags again, keeping the highlighted tag when it still
+// exists, and then its folders
+func (d *dashboard) reload() {
+	counts, err := d.store.ListTags()
+	if err != nil {
+		d.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	selected := d.selectedTag()
+	d.counts = counts
+	d.tags = make([]string, 0, len(counts))
+	for name := range counts {
+		d.tags = append(d.tags, name)
+	}
+	sort.Strings(d.tags)
+	d.tagList = listState{}
+	if i := sort.SearchStrings(d.tags, selected); selected != "" && i < len(d.tags) && d.tags[i] == selected {
+		d.tagList.move(i, len(d.tags), d.rows())
+	}
+	d.loadFolders()
+}
+
+// loadFolders reads the folders of the highlighted tag
+func (d *dashboard) loadFolders() {
+	d.folders = nil
+	if name := d.selectedTag(); name != "" {
+		folders, err := d.store.ListFoldersByTag(name)
+		if err != nil {
+			d.status = fmt.Sprintf("Error: %v", err)
+		}
+		d.folders = folders
+	}
+	d.folderList.move(0, len(d.folders), d.rows())
+}
+
+func (d dashboard) selectedTag() string {
+	if d.tagList.cursor < len(d.tags) {
+		return d.tags[d.tagList.cursor]
+	}
+	return ""
+}
+
+func (d dashboard) selectedFolder() string {
+	if d.folderList.cursor < len(d.folders) {
+		return d.folders[d.folderList.cursor]
+	}
+	return ""
+}
+
+// rows is the number of list rows that fit in a pane
+func (d dashboard) rows() int {
+	// Title, pane borders, status and help lines
+	return max(d.height-6, 1)
+}
+
+func (d dashboard) Init() tea.Cmd {
+	return nil
+}
+
+func (d dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.width, d.height = msg.Width, msg.Height
+		d.tagList.move(0, len(d.tags), d.rows())
+		d.folderList.move(0, len(d.folders), d.rows())
+		return d, nil
+
+	case statusMsg:
+		d.statuses[msg.folder] = msg.text
+		return d, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			d.done = true
+			return d, tea.Quit
+		}
+		if d.editor != nil {
+			cmd := d.editor.update(msg)
+			if d.editor.done {
+				d.editor = nil
+				d.reload()
+			}
+			return d, cmd
+		}
+		return d.updateKeys(msg)
+	}
+	return d, nil
+}
+
+// updateKeys handles keys outside the tag editor
+func (d dashboard) updateKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	d.status = ""
+	switch msg.String() {
+	case "esc", "q":
+		d.done = true
+		return d, tea.Quit
+	case "tab", "left", "right", "h", "l":
+		if d.focus == tagsPane && len(d.folders) > 0 {
+			d.focus = foldersPane
+		} else {
+			d.focus = tagsPane
+		}
+	case "up", "k", "ctrl+p":
+		return d.move(-1)
+	case "down", "j", "ctrl+n":
+		return d.move(1)
+	case "enter":
+		if d.focus == tagsPane {
+			if len(d.folders) > 0 {
+				d.focus = foldersPane
+			}
+			return d, nil
+		}
+		if folder := d.selectedFolder(); folder != "" {
+			d.action = Action{Kind: ActionJump, Tag: d.selectedTag(), Folder: folder}
+			d.done = true
+			return d, tea.Quit
+		}
+	case "s":
+		if name := d.selectedTag(); name != "" {
+			d.action = Action{Kind: ActionStart, Tag: name}
+			d.done = true
+			return d, tea.Quit
+		}
+	case "t":
+		folder := d.selectedFolder()
+		if d.focus != foldersPane || folder == "" {
+			return d, nil
+		}
+		editor, err := newTagEditor(d.store, folder)
+		if err != nil {
+			d.status = fmt.Sprintf("Error: %v", err)
+			return d, nil
+		}
+		d.editor = editor
+	case "u":
+		folder, name := d.selectedFolder(), d.selectedTag()
+		if d.focus != foldersPane || folder == "" {
+			return d, nil
+		}
+		if err := d.store.RemoveTag(folder, name); err != nil {
+			d.status = fmt.Sprintf("Error: %v", err)
+			return d, nil
+		}
+		d.reload()
+		d.status = fmt.Sprintf("Removed '%s' from %s", name, folder)
+	case "g":
+		d.showGit = !d.showGit
+		return d, d.loadStatuses()
+	case "r":
+		d.statuses = make(map[string]string)
+		d.reload()
+		return d, d.loadStatuses()
+	}
+	return d, nil
+}
+
+// move moves the cursor of the focused pane
+func (d dashboard) move(delta int) (tea.Model, tea.Cmd) {
+	if d.focus == foldersPane {
+		d.folderList.move(delta, len(d.folders), d.rows())
+		return d, nil
+	}
+	before := d.tagList.cursor
+	d.tagList.move(delta, len(d.tags), d.rows())
+	if d.tagList.cursor != before {
+		d.folderList = listState{}
+		d.loadFolders()
+	}
+	return d, d.loadStatuses()
+}
+
+// loadStatuses reads the git status of the folders shown, in the
+// background, when the git column is on
+func (d dashboard) loadStatuses() tea.Cmd {
+	if !d.showGit {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, folder := range d.folders {
+		if _, ok := d.statuses[folder]; ok {
+			continue
+		}
+		// Marks the folder as loading
+		d.statuses[folder] = ""
+		cmds = append(cmds, func() tea.Msg {
+			return statusMsg{folder: folder, text: folderStatus(folder)}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+// folderStatus describes the git status of a folder for the dashboard
+func folderStatus(folder string) string {
+	switch {
+	case location.IsRemote(folder):
+		return "remote"
+	case !git.IsRepo(folder):
+		return "not a repository"
+	default:
+		return gitSummary(folder)
+	}
+}
+
+func (d dashboard) View() string {
+	if d.done {
+		return ""
+	}
+	if d.editor != nil {
+		return d.editor.view(d.height)
+	}
+
+	if len(d.tags) == 0 {
+		return titleStyle.Render("Scope") + "\n\n" +
+			dimStyle.Render("No tags yet. Tag a folder with 'scope tag <path> <tag>'.") + "\n\n" +
+			dimStyle.Render("q quit")
+	}
+
+	tagWidth := min(max(d.width/3, 16), 32)
+	folderWidth := max(d.width-tagWidth-4, 10)
+	rows := d.rows()
+
+	var tags strings.Builder
+	end := min(d.tagList.offset+rows, len(d.tags))
+	for i := d.tagList.offset; i < end; i++ {
+		name := d.tags[i]
+		count := fmt.Sprintf(" %d", d.counts[name])
+		line := truncate(name, tagWidth-4-len(count)) + dimStyle.Render(count)
+		tags.WriteString(d.row(line, i == d.tagList.cursor, d.focus == tagsPane) + "\n")
+	}
+
+	var folders strings.Builder
+	end = min(d.folderList.offset+rows, len(d.folders))
+	for i := d.folderList.offset; i < end; i++ {
+		folder := d.folders[i]
+		line := filepath.Base(folder) + "  " + dimStyle.Render(folder)
+		if d.showGit {
+			status := d.statuses[folder]
+			if status == "" {
+				status = "..."
+			}
+			line = filepath.Base(folder) + "  " + status + "  " + dimStyle.Render(folder)
+		}
+		folders.WriteString(d.row(truncate(line, folderWidth-4), i == d.folderList.cursor, d.focus == foldersPane) + "\n")
+	}
+	if len(d.folders) == 0 {
+		folders.WriteString(dimStyle.Render("  no folders") + "\n")
+	}
+
+	left := paneStyle(d.focus == tagsPane).Width(tagWidth).Height(rows).Render(strings.TrimRight(tags.String(), "\n"))
+	right := paneStyle(d.focus == foldersPane).Width(folderWidth).Height(rows).Render(strings.TrimRight(folders.String(), "\n"))
+
+	help := dimStyle.Render("tab switch  enter jump  s start session  t tags  u untag  g git status  r refresh  q quit")
+	if d.status != "" {
+		help = d.status + "\n" + help
+	}
+	return titleStyle.Render("Scope") + "\n" + lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n" + help
+}
+
+// row renders one list row, marking the cursor of the focused pane
+func (d dashboard) row(line string, cursor, focused bool) string {
+	switch {
+	case cursor && focused:
+		return selectedStyle.Render("> ") + line
+	case cursor:
+		return "> " + line
+	default:
+		return "  " + line
+	}
+}
+
+// paneStyle is the border of a pane, brighter when it has the focus
+func paneStyle(focused bool) lipgloss.Style {
+	if focused {
+		return previewStyle.BorderForeground(lipgloss.Color("212"))
+	}
+	return previewStyle
+}
@@ -0,0 +1,130 @@
+package picker
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (f *fakeTags) ListFoldersByTag(tagName string) ([]string, error) {
+	var folders []string
+	for folder, tags := range f.folders {
+		for _, t := range tags {
+			if t == tagName {
+				folders = append(folders, folder)
+			}
+		}
+	}
+	sort.Strings(folders)
+	return folders, nil
+}
+
+// updateDashboard sends msg to d and returns the resulting model
+func updateDashboard(d dashboard, msg tea.Msg) dashboard {
+	next, _ := d.Update(msg)
+	return next.(dashboard)
+}
+
+func TestDashboardNavigation(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{
+		"/code/api":   {"work"},
+		"/code/web":   {"frontend", "work"},
+		"/notes/wiki": {"home"},
+	}}
+	d := newDashboard(store)
+
+	if !reflect.DeepEqual(d.tags, []string{"frontend", "home", "work"}) {
+		t.Fatalf("Expected every tag, sorted, got %v", d.tags)
+	}
+	if !reflect.DeepEqual(d.folders, []string{"/code/web"}) {
+		t.Errorf("Expected the first tag's folders, got %v", d.folders)
+	}
+
+	// Moving through the tags shows each one's folders
+	d = updateDashboard(d, key("down"))
+	d = updateDashboard(d, key("down"))
+	if d.selectedTag() != "work" || !reflect.DeepEqual(d.folders, []string{"/code/api", "/code/web"}) {
+		t.Errorf("Expected work's folders, got %q: %v", d.selectedTag(), d.folders)
+	}
+	view := d.View()
+	for _, want := range []string{"work 2", "/code/api", "/code/web"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the view:\n%s", want, view)
+		}
+	}
+
+	// enter moves to the folders, and again jumps to the highlighted one
+	d = updateDashboard(d, key("enter"))
+	d = updateDashboard(d, key("down"))
+	d = updateDashboard(d, key("enter"))
+	if !d.done || d.action != (Action{Kind: ActionJump, Tag: "work", Folder: "/code/web"}) {
+		t.Errorf("Expected a jump to /code/web, got %+v", d.action)
+	}
+}
+
+func TestDashboardStartAndQuit(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{"/code/api": {"work"}}}
+
+	d := updateDashboard(newDashboard(store), key("s"))
+	if d.action != (Action{Kind: ActionStart, Tag: "work"}) {
+		t.Errorf("Expected 's' to start a session of work, got %+v", d.action)
+	}
+
+	d = updateDashboard(newDashboard(store), key("q"))
+	if !d.done || d.action.Kind != ActionNone {
+		t.Errorf("Expected 'q' to quit without an action, got %+v", d.action)
+	}
+}
+
+func TestDashboardUntagAndEdit(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{
+		"/code/api": {"work"},
+		"/code/web": {"work"},
+	}}
+	d := newDashboard(store)
+	d = updateDashboard(d, key("tab"))
+
+	d = updateDashboard(d, key("u"))
+	if got, _ := store.GetTagsForFolder("/code/api"); len(got) != 0 {
+		t.Errorf("Expected 'u' to untag /code/api, got %v", got)
+	}
+	if !reflect.DeepEqual(d.folders, []string{"/code/web"}) {
+		t.Errorf("Expected the list to be refreshed, got %v", d.folders)
+	}
+
+	// The tag editor adds a new tag, which shows once it is closed
+	d = updateDashboard(d, key("t"))
+	if d.editor == nil {
+		t.Fatal("Expected 't' to open the tag editor")
+	}
+	d = updateDashboard(d, key("n"))
+	for _, r := range "web" {
+		d = updateDashboard(d, key(string(r)))
+	}
+	d = updateDashboard(d, key("enter"))
+	d = updateDashboard(d, key("esc"))
+	if d.editor != nil || !reflect.DeepEqual(d.tags, []string{"web", "work"}) {
+		t.Errorf("Expected the new tag in the list, got %v", d.tags)
+	}
+}
+
+func TestDashboardGitStatus(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{"me@box:/srv/app": {"servers"}}}
+	d := newDashboard(store)
+
+	next, cmd := d.Update(key("g"))
+	d = next.(dashboard)
+	if cmd == nil {
+		t.Fatal("Expected 'g' to load the git status")
+	}
+	if !strings.Contains(d.View(), "...") {
+		t.Error("Expected a placeholder while the status loads")
+	}
+	d = updateDashboard(d, cmd())
+	if !strings.Contains(d.View(), "remote") {
+		t.Errorf("Expected the status in the view:\n%s", d.View())
+	}
+}
@@ -0,0 +1,122 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// orderModel is the bubbletea model of Reorder
+type orderModel struct {
+	title    string
+	folders  []string
+	cursor   int
+	offset   int
+	height   int
+	done     bool
+	canceled bool
+}
+
+// Reorder shows the folders on stderr and lets the user move them up and
+// down, returning them in the chosen order. With accessible output it asks
+// for the order as a list of numbers instead.
+func Reorder(title string, folders []string) ([]string, error) {
+	if ui.Accessible() {
+		return reorderPlain(title, folders)
+	}
+	m := orderModel{title: title, folders: append([]string(nil), folders...), height: 24}
+	final, err := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, fmt.Errorf("reorder failed: %w", err)
+	}
+
+	m = final.(orderModel)
+	if m.canceled || !m.done {
+		return nil, ErrCanceled
+	}
+	return m.folders, nil
+}
+
+func (m orderModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m orderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			m.canceled = true
+			return m, tea.Quit
+		case "enter":
+			m.done = true
+			return m, tea.Quit
+		case "up", "k":
+			m.cursor = max(m.cursor-1, 0)
+		case "down", "j":
+			m.cursor = min(m.cursor+1, len(m.folders)-1)
+		case "shift+up", "K":
+			m.move(-1)
+		case "shift+down", "J":
+			m.move(1)
+		case "home", "g":
+			m.cursor = 0
+		case "end", "G":
+			m.cursor = len(m.folders) - 1
+		}
+	}
+	m.scroll()
+	return m, nil
+}
+
+// move swaps the highlighted folder with its neighbour, keeping it
+// highlighted
+func (m *orderModel) move(delta int) {
+	to := m.cursor + delta
+	if to < 0 || to >= len(m.folders) {
+		return
+	}
+	m.folders[m.cursor], m.folders[to] = m.folders[to], m.folders[m.cursor]
+	m.cursor = to
+}
+
+// visible is the number of folders that fit below the title and help
+func (m orderModel) visible() int {
+	return max(m.height-4, 1)
+}
+
+// scroll keeps the cursor on screen
+func (m *orderModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.visible() {
+		m.offset = m.cursor - m.visible() + 1
+	}
+}
+
+func (m orderModel) View() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.title))
+	b.WriteString("\n\n")
+
+	end := min(m.offset+m.visible(), len(m.folders))
+	for i := m.offset; i < end; i++ {
+		line := fmt.Sprintf("%3d. %s", i+1, m.folders[i])
+		if i == m.cursor {
+			b.WriteString(selectedStyle.Render("> " + line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(dimStyle.Render("\n↑/↓ select · shift+↑/↓ or K/J move · enter save · esc cancel"))
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package picker
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOrderModel(t *testing.T) {
+	var m tea.Model = orderModel{folders: []string{"/a", "/b", "/c"}, height: 24}
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyDown},
+		{Type: tea.KeyDown},
+		{Type: tea.KeyRunes, Runes: []rune{'K'}}, // c moves above b
+		{Type: tea.KeyRunes, Runes: []rune{'K'}}, // and above a
+		{Type: tea.KeyRunes, Runes: []rune{'K'}}, // already first
+		{Type: tea.KeyDown},
+		{Type: tea.KeyShiftDown}, // a moves below b
+		{Type: tea.KeyEnter},
+	} {
+		m, _ = m.Update(key)
+	}
+
+	om := m.(orderModel)
+	if !om.done || om.canceled {
+		t.Fatalf("Expected the order to be saved, got %+v", om)
+	}
+	if expected := []string{"/c", "/b", "/a"}; !reflect.DeepEqual(om.folders, expected) {
+		t.Errorf("Expected %v, got %v", expected, om.folders)
+	}
+
+	m, _ = om.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.(orderModel).canceled {
+		t.Error("esc should cancel")
+	}
+}
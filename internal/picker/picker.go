@@ -0,0 +1,371 @@
+// Package picker is the interactive folder picker behind 'scope pick': a
+// filterable list of folders with a preview of the highlighted one, and an
+// inline editor for its tags. It also holds the 'scope ui' dashboard,
+// which shares the tag editor.
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// ErrCanceled is returned when the user leaves the picker without choosing
+var ErrCanceled = errors.New("selection canceled")
+
+// minPreviewWidth is the terminal width below which the preview is hidden
+const minPreviewWidth = 80
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+	dimStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	previewStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
+)
+
+// Options configure a picker
+type Options struct {
+	// Title is shown above the list
+	Title string
+
+	// Preview describes a folder for the preview pane; nil hides the pane
+	Preview func(folder string) string
+
+	// Badges are shown after a folder's name in the list, such as the
+	// icons and colors of its tags (see TagBadges)
+	Badges func(folder string) string
+
+	// Tags enables the tag editor ('t'); nil disables it
+	Tags TagStore
+}
+
+// model is the bubbletea model of the picker
+type model struct {
+	folders  []string
+	opts     Options
+	filter   textinput.Model
+	matches  []int // indexes into folders, in display order
+	cursor   int   // index into matches
+	offset   int   // first visible match
+	width    int
+	height   int
+	previews map[string]string
+	loading  map[string]bool
+	editor   *tagEditor
+	status   string
+	chosen   string
+	canceled bool
+}
+
+func newModel(folders []string, opts Options) model {
+	filter := textinput.New()
+	filter.Prompt = "/ "
+	filter.Placeholder = "press / to filter"
+
+	m := model{
+		folders:  folders,
+		opts:     opts,
+		filter:   filter,
+		width:    100,
+		height:   24,
+		previews: make(map[string]string),
+		loading:  make(map[string]bool),
+	}
+	m.applyFilter()
+	return m
+}
+
+// Pick shows the picker on stderr and returns the chosen folder, keeping
+// stdout free for the result
+func Pick(folders []string, opts Options) (string, error) {
+	if len(folders) == 0 {
+		return "", fmt.Errorf("no folders to pick from")
+	}
+	if ui.Accessible() {
+		title := opts.Title
+		if title == "" {
+			title = "Folders:"
+		}
+		i, err := Choose(title, folders)
+		if err != nil {
+			return "", err
+		}
+		return folders[i], nil
+	}
+
+	program := tea.NewProgram(newModel(folders, opts), tea.WithOutput(os.Stderr), tea.WithAltScreen())
+	final, err := program.Run()
+	if err != nil {
+		return "", fmt.Errorf("picker failed: %w", err)
+	}
+
+	m := final.(model)
+	if m.canceled || m.chosen == "" {
+		return "", ErrCanceled
+	}
+	return m.chosen, nil
+}
+
+// previewMsg carries a preview built in the background
+type previewMsg struct {
+	folder string
+	text   string
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.loadPreview())
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+		return m, m.loadPreview()
+
+	case previewMsg:
+		m.previews[msg.folder] = msg.text
+		delete(m.loading, msg.folder)
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.canceled = true
+			return m, tea.Quit
+		}
+		var next tea.Model
+		var cmd tea.Cmd
+		switch {
+		case m.editor != nil:
+			next, cmd = m.updateEditor(msg)
+		case m.filter.Focused():
+			next, cmd = m.updateFilter(msg)
+		default:
+			next, cmd = m.updateList(msg)
+		}
+		// The highlighted folder may have changed
+		return next, tea.Batch(cmd, next.(model).loadPreview())
+	}
+
+	return m, nil
+}
+
+// loadPreview builds the preview of the highlighted folder in the
+// background, unless it is cached, already loading or not shown, so that
+// slow folders don't hold up moving through the list
+func (m model) loadPreview() tea.Cmd {
+	if m.opts.Preview == nil || m.width < minPreviewWidth || len(m.matches) == 0 || m.chosen != "" || m.canceled {
+		return nil
+	}
+	folder := m.folders[m.matches[m.cursor]]
+	if _, ok := m.previews[folder]; ok || m.loading[folder] {
+		return nil
+	}
+	m.loading[folder] = true
+	preview := m.opts.Preview
+	return func() tea.Msg {
+		return previewMsg{folder: folder, text: preview(folder)}
+	}
+}
+
+// updateList handles keys while moving through the list
+func (m model) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.status = ""
+	switch msg.String() {
+	case "esc", "q":
+		m.canceled = true
+		return m, tea.Quit
+	case "enter":
+		if len(m.matches) > 0 {
+			m.chosen = m.folders[m.matches[m.cursor]]
+			return m, tea.Quit
+		}
+	case "up", "k", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+		m.scroll()
+	case "down", "j", "ctrl+n":
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+		m.scroll()
+	case "/":
+		return m, m.filter.Focus()
+	case "t":
+		if m.opts.Tags == nil || len(m.matches) == 0 {
+			return m, nil
+		}
+		editor, err := newTagEditor(m.opts.Tags, m.folders[m.matches[m.cursor]])
+		if err != nil {
+			m.status = fmt.Sprintf("Error: %v", err)
+			return m, nil
+		}
+		m.editor = editor
+	}
+	return m, nil
+}
+
+// updateFilter handles keys while typing in the filter
+func (m model) updateFilter(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filter.SetValue("")
+		m.filter.Blur()
+		m.applyFilter()
+		return m, nil
+	case "enter", "up", "down":
+		// Leave the filter, keeping its text, and act on the list
+		m.filter.Blur()
+		return m.updateList(msg)
+	}
+
+	var cmd tea.Cmd
+	before := m.filter.Value()
+	m.filter, cmd = m.filter.Update(msg)
+	if m.filter.Value() != before {
+		m.applyFilter()
+	}
+	return m, cmd
+}
+
+// updateEditor passes keys to the tag editor, closing it when done
+func (m model) updateEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cmd := m.editor.update(msg)
+	if m.editor.done {
+		// The preview shows the folder's tags
+		delete(m.previews, m.editor.folder)
+		m.editor = nil
+	}
+	return m, cmd
+}
+
+// applyFilter recomputes the matches for the filter text and resets the
+// cursor
+func (m *model) applyFilter() {
+	query := strings.ToLower(strings.TrimSpace(m.filter.Value()))
+	m.matches = m.matches[:0]
+	for i, folder := range m.folders {
+		if matches(strings.ToLower(folder), query) {
+			m.matches = append(m.matches, i)
+		}
+	}
+	m.cursor = 0
+	m.offset = 0
+}
+
+// matches reports whether every space-separated word of query occurs in s
+func matches(s, query string) bool {
+	for _, word := range strings.Fields(query) {
+		if !strings.Contains(s, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// listHeight is the number of list rows that fit on screen
+func (m model) listHeight() int {
+	// Title, filter, blank line and help line
+	return max(m.height-4, 1)
+}
+
+// scroll keeps the cursor inside the visible window
+func (m *model) scroll() {
+	rows := m.listHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+}
+
+// preview returns the preview of folder, or a placeholder while it loads
+func (m model) preview(folder string) string {
+	if p, ok := m.previews[folder]; ok {
+		return p
+	}
+	return dimStyle.Render("Loading...")
+}
+
+func (m model) View() string {
+	if m.chosen != "" || m.canceled {
+		return ""
+	}
+	if m.editor != nil {
+		return m.editor.view(m.height)
+	}
+
+	title := m.opts.Title
+	if title == "" {
+		title = "Select a folder"
+	}
+
+	showPreview := m.opts.Preview != nil && m.width >= minPreviewWidth
+	listWidth := m.width
+	if showPreview {
+		listWidth = m.width * 2 / 5
+	}
+
+	var list strings.Builder
+	end := min(m.offset+m.listHeight(), len(m.matches))
+	for i := m.offset; i < end; i++ {
+		folder := m.folders[m.matches[i]]
+		name := filepath.Base(folder)
+		if m.opts.Badges != nil {
+			if badges := m.opts.Badges(folder); badges != "" {
+				name += " " + badges
+			}
+		}
+		line := truncate(fmt.Sprintf("%s  %s", name, dimStyle.Render(folder)), listWidth-2)
+		if i == m.cursor {
+			list.WriteString(selectedStyle.Render("> ") + line)
+		} else {
+			list.WriteString("  " + line)
+		}
+		list.WriteString("\n")
+	}
+	if len(m.matches) == 0 {
+		list.WriteString(dimStyle.Render("  no matches") + "\n")
+	}
+
+	body := lipgloss.NewStyle().Width(listWidth).Render(list.String())
+	if showPreview && len(m.matches) > 0 {
+		folder := m.folders[m.matches[m.cursor]]
+		pane := previewStyle.
+			Width(m.width - listWidth - 4).
+			MaxHeight(m.listHeight()).
+			Render(strings.TrimRight(m.preview(folder), "\n"))
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, pane)
+	}
+
+	keys := "↑/↓ move  / filter  enter select  esc cancel"
+	if m.opts.Tags != nil {
+		keys = "↑/↓ move  / filter  t tags  enter select  esc cancel"
+	}
+	help := dimStyle.Render(fmt.Sprintf("%d/%d  %s", len(m.matches), len(m.folders), keys))
+	if m.status != "" {
+		help = m.status + "\n" + help
+	}
+	return titleStyle.Render(title) + "\n" + m.filter.View() + "\n\n" + body + "\n" + help
+}
+
+// truncate cuts s to width terminal cells
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if lipgloss.Width(s) <= width {
+		return s
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
@@ -0,0 +1,166 @@
+package picker
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// update sends msg to m and returns the resulting model
+func update(m model, msg tea.Msg) model {
+	next, _ := m.Update(msg)
+	return next.(model)
+}
+
+// typeText opens the filter if needed and types text into it
+func typeText(m model, text string) model {
+	if !m.filter.Focused() {
+		m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	}
+	for _, r := range text {
+		m = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	return m
+}
+
+func TestModelFilter(t *testing.T) {
+	folders := []string{"/code/api", "/code/web", "/notes/api-docs"}
+	m := newModel(folders, Options{})
+
+	if len(m.matches) != 3 {
+		t.Fatalf("Expected all folders to match, got %d", len(m.matches))
+	}
+
+	m = typeText(m, "api")
+	if len(m.matches) != 2 {
+		t.Errorf("Expected 2 matches for 'api', got %d", len(m.matches))
+	}
+
+	m = typeText(m, " code")
+	if len(m.matches) != 1 || folders[m.matches[0]] != "/code/api" {
+		t.Errorf("Expected only /code/api to match 'api code', got %v", m.matches)
+	}
+
+	// Enter picks the top match
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != "/code/api" {
+		t.Errorf("Expected /code/api to be chosen, got %q", m.chosen)
+	}
+}
+
+func TestModelFilterEsc(t *testing.T) {
+	m := newModel([]string{"/code/api", "/code/web"}, Options{})
+	m = typeText(m, "web")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.filter.Focused() || m.filter.Value() != "" || len(m.matches) != 2 {
+		t.Errorf("Expected esc to clear the filter, got %q with %d matches", m.filter.Value(), len(m.matches))
+	}
+	if m.canceled {
+		t.Error("Esc in the filter should not cancel the picker")
+	}
+}
+
+func TestModelSelect(t *testing.T) {
+	folders := []string{"/code/api", "/code/web"}
+	m := newModel(folders, Options{})
+
+	m = update(m, tea.KeyMsg{Type: tea.KeyDown})
+	m = update(m, tea.KeyMsg{Type: tea.KeyDown}) // stays on the last item
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != "/code/web" {
+		t.Errorf("Expected /code/web to be chosen, got %q", m.chosen)
+	}
+}
+
+func TestModelCancel(t *testing.T) {
+	m := newModel([]string{"/code/api"}, Options{})
+	m = update(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.canceled || m.chosen != "" {
+		t.Errorf("Expected the picker to be canceled, got chosen %q", m.chosen)
+	}
+
+	// Enter with no matches chooses nothing
+	m = newModel([]string{"/code/api"}, Options{})
+	m = typeText(m, "zzz")
+	m = update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.chosen != "" {
+		t.Errorf("Expected nothing to be chosen, got %q", m.chosen)
+	}
+}
+
+func TestModelScroll(t *testing.T) {
+	var folders []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		folders = append(folders, "/code/"+name)
+	}
+	m := newModel(folders, Options{})
+	m = update(m, tea.WindowSizeMsg{Width: 60, Height: 7})
+
+	for range folders {
+		m = update(m, tea.KeyMsg{Type: tea.KeyDown})
+	}
+	if m.cursor != len(folders)-1 {
+		t.Fatalf("Expected cursor on the last item, got %d", m.cursor)
+	}
+	if m.cursor < m.offset || m.cursor >= m.offset+m.listHeight() {
+		t.Errorf("Cursor %d outside the visible window [%d, %d)", m.cursor, m.offset, m.offset+m.listHeight())
+	}
+	if !strings.Contains(m.View(), "/code/h") {
+		t.Error("Expected the selected folder to be rendered")
+	}
+}
+
+// runPreviews runs the preview loads among the commands cmd returns and
+// feeds their results to m
+func runPreviews(m model, cmd tea.Cmd) model {
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case previewMsg:
+		return update(m, msg)
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = runPreviews(m, c)
+		}
+	}
+	return m
+}
+
+func TestModelPreview(t *testing.T) {
+	calls := 0
+	opts := Options{Preview: func(folder string) string {
+		calls++
+		return "preview of " + folder
+	}}
+	m := newModel([]string{"/code/api", "/code/web"}, opts)
+	next, cmd := m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	m = next.(model)
+
+	// The preview loads in the background
+	if !strings.Contains(m.View(), "Loading") {
+		t.Error("Expected a placeholder while the preview loads")
+	}
+	m = runPreviews(m, cmd)
+	if !strings.Contains(m.View(), "preview of /code/api") {
+		t.Error("Expected the preview pane in a wide terminal")
+	}
+
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = runPreviews(next.(model), cmd)
+	if !strings.Contains(m.View(), "preview of /code/web") {
+		t.Error("Expected the preview to follow the cursor")
+	}
+	next, cmd = m.Update(tea.KeyMsg{Type: tea.KeyUp})
+	m = runPreviews(next.(model), cmd)
+	m.View()
+	if calls != 2 {
+		t.Errorf("Expected the previews to be cached, got %d calls", calls)
+	}
+
+	m = update(m, tea.WindowSizeMsg{Width: 60, Height: 20})
+	if strings.Contains(m.View(), "preview of") {
+		t.Error("Expected no preview pane in a narrow terminal")
+	}
+}
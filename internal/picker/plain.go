@@ -0,0 +1,89 @@
+package picker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// stdin is where plain prompts read answers; tests may replace it
+var stdin io.Reader = os.Stdin
+
+// Choose lists the folders, numbered, on stderr and asks for one by
+// number. It is the picker for accessible output and dumb terminals.
+func Choose(title string, folders []string) (int, error) {
+	return choose(title, "folder", folders)
+}
+
+// ChooseTag is Choose for tag names
+func ChooseTag(title string, tags []string) (int, error) {
+	return choose(title, "tag", tags)
+}
+
+// choose lists items, numbered, on stderr and asks for one of them, called
+// noun, by number
+func choose(title, noun string, items []string) (int, error) {
+	fmt.Fprintln(os.Stderr, title)
+	for i, item := range items {
+		fmt.Fprintf(os.Stderr, "  [%d] %s\n", i+1, item)
+	}
+	fmt.Fprintf(os.Stderr, "\nSelect %s (1-%d): ", noun, len(items))
+
+	input, err := readLine()
+	if err != nil {
+		return 0, err
+	}
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > len(items) {
+		return 0, fmt.Errorf("invalid selection: %s", input)
+	}
+	return choice - 1, nil
+}
+
+// reorderPlain asks for a new order as folder numbers. Folders left out
+// follow the given ones in their current order; an empty answer cancels.
+func reorderPlain(title string, folders []string) ([]string, error) {
+	fmt.Fprintln(os.Stderr, title)
+	for i, folder := range folders {
+		fmt.Fprintf(os.Stderr, "  [%d] %s\n", i+1, folder)
+	}
+	fmt.Fprint(os.Stderr, "\nFolder numbers in the new order, e.g. 3 1 (empty to cancel): ")
+
+	input, err := readLine()
+	if err != nil {
+		return nil, err
+	}
+	if input == "" {
+		return nil, ErrCanceled
+	}
+
+	var order []string
+	for _, field := range strings.FieldsFunc(input, func(r rune) bool { return r == ' ' || r == ',' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(folders) {
+			return nil, fmt.Errorf("invalid folder number: %s", field)
+		}
+		if !slices.Contains(order, folders[n-1]) {
+			order = append(order, folders[n-1])
+		}
+	}
+	for _, folder := range folders {
+		if !slices.Contains(order, folder) {
+			order = append(order, folder)
+		}
+	}
+	return order, nil
+}
+
+// readLine reads one answer from stdin
+func readLine() (string, error) {
+	input, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && (err != io.EOF || input == "") {
+		return "", fmt.Errorf("failed to read input: %w", err)
+	}
+	return strings.TrimSpace(input), nil
+}
@@ -0,0 +1,58 @@
+package picker
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChoose(t *testing.T) {
+	defer func() { stdin = os.Stdin }()
+	folders := []string{"/code/api", "/code/web"}
+
+	tests := []struct {
+		input    string
+		expected int
+		valid    bool
+	}{
+		{"2\n", 1, true},
+		{"1", 0, true},
+		{"3\n", 0, false},
+		{"web\n", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		stdin = strings.NewReader(tt.input)
+		got, err := Choose("Folders:", folders)
+		if tt.valid && (err != nil || got != tt.expected) {
+			t.Errorf("Choose(%q) = %d, %v, expected %d", tt.input, got, err, tt.expected)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Choose(%q) should fail", tt.input)
+		}
+	}
+}
+
+func TestReorderPlain(t *testing.T) {
+	defer func() { stdin = os.Stdin }()
+	folders := []string{"/a", "/b", "/c"}
+
+	stdin = strings.NewReader("3, 1\n")
+	order, err := reorderPlain("Order:", folders)
+	if err != nil {
+		t.Fatalf("reorderPlain failed: %v", err)
+	}
+	if strings.Join(order, " ") != "/c /a /b" {
+		t.Errorf("Unexpected order %v", order)
+	}
+
+	stdin = strings.NewReader("\n")
+	if _, err := reorderPlain("Order:", folders); err != ErrCanceled {
+		t.Errorf("Expected ErrCanceled for an empty answer, got %v", err)
+	}
+
+	stdin = strings.NewReader("4\n")
+	if _, err := reorderPlain("Order:", folders); err == nil {
+		t.Error("reorderPlain should reject numbers out of range")
+	}
+}
@@ -0,0 +1,198 @@
+package picker
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/project"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+const (
+	// previewEntries is the number of top-level entries shown
+	previewEntries = 12
+
+	// previewReadmeLines is the number of README lines shown
+	previewReadmeLines = 6
+)
+
+// readmeNames are the README files looked for, in order
+var readmeNames = []string{"README.md", "README", "README.txt", "README.rst", "readme.md"}
+
+// Preview describes a folder for the preview pane: its tags, note and open
+// todos, git branch, status and last commit, health, top-level entries and
+// the first lines of its README. It can be slow on large repositories, so
+// the picker runs it in the background.
+func Preview(m *tag.Manager, folder string) string {
+	var b strings.Builder
+
+	if tags, err := m.GetTagsForFolder(folder); err == nil && len(tags) > 0 {
+		infos, _ := m.ListTagInfo()
+		labels := make([]string, len(tags))
+		for i, name := range tags {
+			labels[i] = tagLabel(name, infos[name])
+		}
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(labels, ", "))
+	}
+	if note, err := m.GetNote(folder); err == nil && note != "" {
+		fmt.Fprintf(&b, "Note: %s\n", note)
+	}
+	if todos, err := m.ListTodos(folder, false); err == nil {
+		for _, todo := range todos {
+			fmt.Fprintf(&b, "Todo: %s\n", todo.Text)
+		}
+	}
+
+	if loc, ok := location.Parse(folder); ok {
+		fmt.Fprintf(&b, "\nRemote folder on %s (%s)\n", loc.Host, loc.Kind)
+		return b.String()
+	}
+
+	if git.IsRepo(folder) {
+		fmt.Fprintf(&b, "Git:  %s\n", gitSummary(folder))
+		if last, err := git.LastCommit(folder); err == nil && !last.IsZero() {
+			fmt.Fprintf(&b, "Last commit: %s\n", age(last, time.Now()))
+		}
+	}
+	fmt.Fprintf(&b, "Health: %s\n", project.CheckHealth(folder).Summary(time.Now()))
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		fmt.Fprintf(&b, "\n%v\n", err)
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	b.WriteString(listEntries(entries))
+
+	if readme := readmeHead(folder, entries); readme != "" {
+		b.WriteString("\n")
+		b.WriteString(readme)
+	}
+
+	return b.String()
+}
+
+// gitSummary describes the repository at dir: its branch, changes,
+// position against its upstream and stashes
+func gitSummary(dir string) string {
+	st, err := git.GetStatus(dir)
+	if err != nil {
+		branch, err := git.Branch(dir)
+		if err != nil {
+			branch = "?"
+		}
+		return branch + ", status unavailable"
+	}
+	parts := []string{st.Branch}
+	if st.Branch == "" {
+		parts[0] = "detached"
+	}
+	if st.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("%d changed", st.Dirty))
+	} else {
+		parts = append(parts, "clean")
+	}
+	if st.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("%d ahead", st.Ahead))
+	}
+	if st.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("%d behind", st.Behind))
+	}
+	switch st.Stashes {
+	case 0:
+	case 1:
+		parts = append(parts, "1 stash")
+	default:
+		parts = append(parts, fmt.Sprintf("%d stashes", st.Stashes))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// age describes how long before now t was, in days
+func age(t, now time.Time) string {
+	days := int(now.Sub(t).Hours() / 24)
+	switch {
+	case days < 1:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	case days < 60:
+		return fmt.Sprintf("%d days ago", days)
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// listEntries lists directories first, then files, hiding dotfiles
+func listEntries(entries []os.DirEntry) string {
+	var dirs, files []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if e.IsDir() {
+			dirs = append(dirs, e.Name()+"/")
+		} else {
+			files = append(files, e.Name())
+		}
+	}
+	sort.Strings(dirs)
+	sort.Strings(files)
+	names := append(dirs, files...)
+
+	if len(names) == 0 {
+		return "(empty)\n"
+	}
+
+	var b strings.Builder
+	for i, name := range names {
+		if i == previewEntries {
+			fmt.Fprintf(&b, "... %d more\n", len(names)-previewEntries)
+			break
+		}
+		fmt.Fprintf(&b, "%s\n", name)
+	}
+	return b.String()
+}
+
+// readmeHead returns the first non-empty lines of the folder's README
+func readmeHead(folder string, entries []os.DirEntry) string {
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		present[e.Name()] = true
+	}
+
+	for _, name := range readmeNames {
+		if !present[name] {
+			continue
+		}
+		f, err := os.Open(filepath.Join(folder, name))
+		if err != nil {
+			return ""
+		}
+		defer func() { _ = f.Close() }()
+
+		var b strings.Builder
+		lines := 0
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() && lines < previewReadmeLines {
+			line := strings.TrimRight(scanner.Text(), " \t\r")
+			if line == "" {
+				continue
+			}
+			b.WriteString(line)
+			b.WriteString("\n")
+			lines++
+		}
+		return b.String()
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package picker
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestPreview(t *testing.T) {
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	m := tag.NewManager(store)
+
+	folder := filepath.Join(tmpDir, "api")
+	for _, dir := range []string{"cmd", "internal", ".git/refs"} {
+		if err := os.MkdirAll(filepath.Join(folder, dir), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	files := map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"README.md": "# API\n\nServes the public API.\n",
+		"go.mod":    "module api\n",
+		".env":      "SECRET=1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	if err := m.AddTag(folder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.SetNote(folder, "Main service"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	if _, err := m.AddTodo(folder, "Rotate keys"); err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+
+	preview := Preview(m, folder)
+	for _, want := range []string{"Tags: work", "Note: Main service", "Todo: Rotate keys", "main", "cmd/\ninternal/\nREADME.md\ngo.mod", "# API\nServes the public API."} {
+		if !strings.Contains(preview, want) {
+			t.Errorf("Expected %q in preview:\n%s", want, preview)
+		}
+	}
+	if strings.Contains(preview, ".env") {
+		t.Errorf("Dotfiles should be hidden:\n%s", preview)
+	}
+}
+
+func TestPreviewRemote(t *testing.T) {
+	store, err := db.Open(filepath.Join(t.TempDir(), "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	preview := Preview(tag.NewManager(store), "me@box:/srv/app")
+	if !strings.Contains(preview, "Remote folder on box") {
+		t.Errorf("Expected a remote description, got:\n%s", preview)
+	}
+}
+
+func TestGitSummary(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"add", "."}, {"commit", "-q", "-m", "Initial"}} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=me", "GIT_AUTHOR_EMAIL=me@example.com",
+			"GIT_COMMITTER_NAME=me", "GIT_COMMITTER_EMAIL=me@example.com",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	if got := gitSummary(repo); got != "main, clean" {
+		t.Errorf("Expected a clean main, got %q", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package app\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if got := gitSummary(repo); got != "main, 1 changed" {
+		t.Errorf("Expected one change, got %q", got)
+	}
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for t0, want := range map[time.Time]string{
+		now.Add(-time.Hour):                         "today",
+		now.Add(-30 * time.Hour):                    "yesterday",
+		now.AddDate(0, 0, -5):                       "5 days ago",
+		time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC): "2026-01-02",
+	} {
+		if got := age(t0, now); got != want {
+			t.Errorf("age(%v) = %q, expected %q", t0, got, want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package picker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TagStore is what the tag editor reads and changes; *tag.Manager
+// implements it
+type TagStore interface {
+	ListTags() (map[string]int, error)
+	GetTagsForFolder(path string) ([]string, error)
+	AddTag(path, tagName string) error
+	RemoveTag(path, tagName string) error
+}
+
+// tagEditor is the inline multi-select of tags opened with 't'. Every
+// toggle is written to the store immediately.
+type tagEditor struct {
+	store    TagStore
+	folder   string
+	names    []string // every tag, sorted, including ones created here
+	selected map[string]bool
+	cursor   int
+	input    textinput.Model // name of a new tag, while creating
+	creating bool
+	status   string
+	done     bool
+}
+
+func newTagEditor(store TagStore, folder string) (*tagEditor, error) {
+	counts, err := store.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	current, err := store.GetTagsForFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &tagEditor{
+		store:    store,
+		folder:   folder,
+		selected: make(map[string]bool, len(current)),
+	}
+	for name := range counts {
+		e.names = append(e.names, name)
+	}
+	for _, name := range current {
+		e.selected[name] = true
+	}
+	sort.Strings(e.names)
+
+	e.input = textinput.New()
+	e.input.Prompt = "new tag: "
+	return e, nil
+}
+
+func (e *tagEditor) update(msg tea.KeyMsg) tea.Cmd {
+	if e.creating {
+		switch msg.String() {
+		case "esc":
+			e.creating = false
+			e.input.Blur()
+			return nil
+		case "enter":
+			name := strings.TrimSpace(e.input.Value())
+			e.creating = false
+			e.input.Blur()
+			e.input.SetValue("")
+			if name != "" {
+				e.create(name)
+			}
+			return nil
+		}
+		var cmd tea.Cmd
+		e.input, cmd = e.input.Update(msg)
+		return cmd
+	}
+
+	switch msg.String() {
+	case "esc", "enter", "q", "t":
+		e.done = true
+	case "up", "k", "ctrl+p":
+		if e.cursor > 0 {
+			e.cursor--
+		}
+	case "down", "j", "ctrl+n":
+		if e.cursor < len(e.names)-1 {
+			e.cursor++
+		}
+	case " ", "x":
+		if len(e.names) > 0 {
+			e.toggle(e.names[e.cursor])
+		}
+	case "n", "+":
+		e.creating = true
+		return e.input.Focus()
+	}
+	return nil
+}
+
+// toggle adds or removes a tag from the folder
+func (e *tagEditor) toggle(name string) {
+	if e.selected[name] {
+		if err := e.store.RemoveTag(e.folder, name); err != nil {
+			e.status = fmt.Sprintf("Error: %v", err)
+			return
+		}
+		delete(e.selected, name)
+		e.status = fmt.Sprintf("Removed '%s'", name)
+		return
+	}
+
+	if err := e.store.AddTag(e.folder, name); err != nil {
+		e.status = fmt.Sprintf("Error: %v", err)
+		return
+	}
+	e.selected[name] = true
+	e.status = fmt.Sprintf("Added '%s'", name)
+}
+
+// create adds a tag that may not exist yet and moves the cursor to it
+func (e *tagEditor) create(name string) {
+	i := sort.SearchStrings(e.names, name)
+	if i == len(e.names) || e.names[i] != name {
+		e.names = append(e.names, "")
+		copy(e.names[i+1:], e.names[i:])
+		e.names[i] = name
+	}
+	e.cursor = i
+	if !e.selected[name] {
+		e.toggle(name)
+	}
+}
+
+func (e *tagEditor) view(height int) string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Tags for "+e.folder) + "\n\n")
+
+	rows := max(height-6, 1)
+	start := 0
+	if e.cursor >= rows {
+		start = e.cursor - rows + 1
+	}
+	end := min(start+rows, len(e.names))
+	for i := start; i < end; i++ {
+		box := "[ ]"
+		if e.selected[e.names[i]] {
+			box = "[x]"
+		}
+		line := fmt.Sprintf("%s %s", box, e.names[i])
+		if i == e.cursor {
+			b.WriteString(selectedStyle.Render("> "+line) + "\n")
+		} else {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	if len(e.names) == 0 {
+		b.WriteString(dimStyle.Render("  no tags yet, press n to create one") + "\n")
+	}
+
+	b.WriteString("\n")
+	switch {
+	case e.creating:
+		b.WriteString(e.input.View())
+	case e.status != "":
+		b.WriteString(e.status)
+	}
+	b.WriteString("\n" + dimStyle.Render("space toggle  n new tag  enter/esc done"))
+	return b.String()
+}
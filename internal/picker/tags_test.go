@@ -0,0 +1,141 @@
+package picker
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeTags is an in-memory TagStore
+type fakeTags struct {
+	folders map[string][]string
+	err     error
+}
+
+func (f *fakeTags) ListTags() (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, tags := range f.folders {
+		for _, t := range tags {
+			counts[t]++
+		}
+	}
+	return counts, nil
+}
+
+func (f *fakeTags) GetTagsForFolder(path string) ([]string, error) {
+	tags := append([]string(nil), f.folders[path]...)
+	sort.Strings(tags)
+	return tags, nil
+}
+
+func (f *fakeTags) AddTag(path, tagName string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.folders[path] = append(f.folders[path], tagName)
+	return nil
+}
+
+func (f *fakeTags) RemoveTag(path, tagName string) error {
+	if f.err != nil {
+		return f.err
+	}
+	var kept []string
+	for _, t := range f.folders[path] {
+		if t != tagName {
+			kept = append(kept, t)
+		}
+	}
+	f.folders[path] = kept
+	return nil
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case " ":
+		return tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestTagEditorToggle(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{
+		"/code/api": {"work"},
+		"/code/web": {"frontend", "work"},
+	}}
+	m := newModel([]string{"/code/api", "/code/web"}, Options{Tags: store})
+
+	m = update(m, key("t"))
+	if m.editor == nil {
+		t.Fatal("Expected 't' to open the tag editor")
+	}
+	if !reflect.DeepEqual(m.editor.names, []string{"frontend", "work"}) {
+		t.Errorf("Expected every tag to be offered, got %v", m.editor.names)
+	}
+
+	// Toggle frontend on and work off; each is written immediately
+	m = update(m, key(" "))
+	m = update(m, key("down"))
+	m = update(m, key(" "))
+	if got, _ := store.GetTagsForFolder("/code/api"); !reflect.DeepEqual(got, []string{"frontend"}) {
+		t.Errorf("Expected /code/api to be tagged [frontend], got %v", got)
+	}
+
+	m = update(m, key("enter"))
+	if m.editor != nil {
+		t.Error("Expected enter to close the tag editor")
+	}
+	if m.chosen != "" || m.canceled {
+		t.Error("Closing the editor should return to the list")
+	}
+}
+
+func TestTagEditorCreate(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{"/code/api": {"work"}}}
+	m := newModel([]string{"/code/api"}, Options{Tags: store})
+
+	m = update(m, key("t"))
+	m = update(m, key("n"))
+	for _, r := range "backend" {
+		m = update(m, key(string(r)))
+	}
+	m = update(m, key("enter"))
+
+	if got, _ := store.GetTagsForFolder("/code/api"); !reflect.DeepEqual(got, []string{"backend", "work"}) {
+		t.Errorf("Expected the new tag to be added, got %v", got)
+	}
+	if m.editor == nil || m.editor.names[m.editor.cursor] != "backend" {
+		t.Error("Expected the editor to stay open on the new tag")
+	}
+}
+
+func TestTagEditorError(t *testing.T) {
+	store := &fakeTags{folders: map[string][]string{"/code/api": {"work"}}, err: errors.New("read-only")}
+	m := newModel([]string{"/code/api"}, Options{Tags: store})
+
+	m = update(m, key("t"))
+	m = update(m, key(" "))
+	if m.editor.status != "Error: read-only" {
+		t.Errorf("Expected the error in the status line, got %q", m.editor.status)
+	}
+	if !m.editor.selected["work"] {
+		t.Error("A failed toggle should leave the selection unchanged")
+	}
+}
+
+func TestTagEditorDisabled(t *testing.T) {
+	m := newModel([]string{"/code/api"}, Options{})
+	m = update(m, key("t"))
+	if m.editor != nil {
+		t.Error("The tag editor needs a TagStore")
+	}
+}
@@ -0,0 +1,89 @@
+// Package plist writes XML property lists, the format of macOS launchd jobs
+// and of the files many macOS tools read
+package plist
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Entry is a key and value of a dictionary
+type Entry struct {
+	Key   string
+	Value any
+}
+
+// Dict is a dictionary, written in the order given. Values are strings,
+// ints, bools, []string, []any and Dicts.
+type Dict []Entry
+
+// Write writes root as an XML property list document
+func Write(w io.Writer, root any) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	bw.WriteString(`<plist version="1.0">` + "\n")
+	if err := writeValue(bw, root, 0); err != nil {
+		return err
+	}
+	bw.WriteString("</plist>\n")
+	return bw.Flush()
+}
+
+// writeValue writes v indented by depth tabs
+func writeValue(w *bufio.Writer, v any, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	switch v := v.(type) {
+	case string:
+		w.WriteString(indent + "<string>")
+		if err := xml.EscapeText(w, []byte(v)); err != nil {
+			return err
+		}
+		w.WriteString("</string>\n")
+	case int:
+		fmt.Fprintf(w, "%s<integer>%d</integer>\n", indent, v)
+	case bool:
+		fmt.Fprintf(w, "%s<%t/>\n", indent, v)
+	case []string:
+		items := make([]any, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return writeValue(w, items, depth)
+	case []any:
+		if len(v) == 0 {
+			w.WriteString(indent + "<array/>\n")
+			return nil
+		}
+		w.WriteString(indent + "<array>\n")
+		for _, item := range v {
+			if err := writeValue(w, item, depth+1); err != nil {
+				return err
+			}
+		}
+		w.WriteString(indent + "</array>\n")
+	case Dict:
+		if len(v) == 0 {
+			w.WriteString(indent + "<dict/>\n")
+			return nil
+		}
+		w.WriteString(indent + "<dict>\n")
+		for _, e := range v {
+			w.WriteString(indent + "\t<key>")
+			if err := xml.EscapeText(w, []byte(e.Key)); err != nil {
+				return err
+			}
+			w.WriteString("</key>\n")
+			if err := writeValue(w, e.Value, depth+1); err != nil {
+				return err
+			}
+		}
+		w.WriteString(indent + "</dict>\n")
+	default:
+		return fmt.Errorf("unsupported property list value %T", v)
+	}
+	return nil
+}
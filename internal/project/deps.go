@@ -0,0 +1,131 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dependencies is the number of direct dependencies a manifest declares
+type Dependencies struct {
+	Manifest string
+	Count    int
+}
+
+// CountDependencies returns the direct dependencies declared by each
+// manifest in dir (go.mod, package.json, Cargo.toml, composer.json and
+// requirements.txt). Development-only dependencies aren't counted.
+func CountDependencies(dir string) []Dependencies {
+	var found []Dependencies
+	for _, c := range []struct {
+		manifest string
+		count    func([]byte) (int, bool)
+	}{
+		{"go.mod", countGoMod},
+		{"package.json", countPackageJSON},
+		{"Cargo.toml", countCargo},
+		{"composer.json", countComposer},
+		{"requirements.txt", countRequirements},
+	} {
+		content, err := os.ReadFile(filepath.Join(dir, c.manifest))
+		if err != nil {
+			continue
+		}
+		if n, ok := c.count(content); ok {
+			found = append(found, Dependencies{Manifest: c.manifest, Count: n})
+		}
+	}
+	return found
+}
+
+// countGoMod counts require directives not marked // indirect
+func countGoMod(content []byte) (int, bool) {
+	count := 0
+	inBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "require (":
+			inBlock = true
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case strings.HasPrefix(line, "require "):
+			line = strings.TrimPrefix(line, "require ")
+		case !inBlock:
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "//") || strings.Contains(line, "// indirect") {
+			continue
+		}
+		count++
+	}
+	return count, true
+}
+
+// countPackageJSON counts dependencies
+func countPackageJSON(content []byte) (int, bool) {
+	var manifest struct {
+		Dependencies map[string]any `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return 0, false
+	}
+	return len(manifest.Dependencies), true
+}
+
+// countComposer counts require entries that are packages, not the PHP
+// version or extensions
+func countComposer(content []byte) (int, bool) {
+	var manifest struct {
+		Require map[string]any `json:"require"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return 0, false
+	}
+	count := 0
+	for name := range manifest.Require {
+		if strings.Contains(name, "/") {
+			count++
+		}
+	}
+	return count, true
+}
+
+// countCargo counts the keys of the [dependencies] table
+func countCargo(content []byte) (int, bool) {
+	count := 0
+	inTable := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inTable = line == "[dependencies]"
+			continue
+		}
+		if inTable && line != "" && !strings.HasPrefix(line, "#") && strings.Contains(line, "=") {
+			count++
+		}
+	}
+	return count, true
+}
+
+// countRequirements counts requirement lines, skipping comments and pip
+// options
+func countRequirements(content []byte) (int, bool) {
+	count := 0
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		count++
+	}
+	return count, true
+}
@@ -0,0 +1,38 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCountDependencies(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":           "module x\n\ngo 1.24\n\nrequire github.com/a/b v1.0.0\n\nrequire (\n\tgithub.com/c/d v1.2.0\n\tgithub.com/e/f v0.1.0 // indirect\n)\n",
+		"package.json":     `{"dependencies": {"react": "^18", "zod": "^3"}, "devDependencies": {"vitest": "^1"}}`,
+		"Cargo.toml":       "[package]\nname = \"x\"\n\n[dependencies]\nserde = \"1\"\ntokio = { version = \"1\" }\n\n[dev-dependencies]\ninsta = \"1\"\n",
+		"composer.json":    `{"require": {"php": ">=8.1", "ext-json": "*", "laravel/framework": "^11"}}`,
+		"requirements.txt": "# runtime\nrequests==2.31\n-r base.txt\nflask\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	expected := map[string]int{"go.mod": 2, "package.json": 2, "Cargo.toml": 2, "composer.json": 1, "requirements.txt": 2}
+	found := CountDependencies(dir)
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %d manifests, got %+v", len(expected), found)
+	}
+	for _, d := range found {
+		if d.Count != expected[d.Manifest] {
+			t.Errorf("%s: expected %d dependencies, got %d", d.Manifest, expected[d.Manifest], d.Count)
+		}
+	}
+
+	if found := CountDependencies(t.TempDir()); len(found) != 0 {
+		t.Errorf("Expected no manifests, got %+v", found)
+	}
+}
@@ -0,0 +1,141 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/git"
+)
+
+// RecentWindow is how old the latest commit may be for a folder to count
+// as recently active
+const RecentWindow = 90 * 24 * time.Hour
+
+// readmePatterns and ciPatterns are globs matched in the folder and at its
+// repository root
+var (
+	readmePatterns = []string{"README", "README.*", "readme.*", "Readme.*"}
+	ciPatterns     = []string{
+		".github/workflows/*.yml", ".github/workflows/*.yaml",
+		".gitlab-ci.yml", ".circleci/config.yml", ".travis.yml",
+		"Jenkinsfile", "azure-pipelines.yml", "bitbucket-pipelines.yml",
+		".drone.yml", ".buildkite/pipeline.yml", ".woodpecker.yml",
+	}
+)
+
+// Health is a quick indicator of how well kept a folder is
+type Health struct {
+	Readme bool
+	CI     bool
+	// Repo is false for folders outside a git repository, which have no
+	// commits or status to check
+	Repo       bool
+	LastCommit time.Time
+	Clean      bool
+}
+
+// HealthCheck is one criterion of a folder's health
+type HealthCheck struct {
+	Name string
+	Icon string
+	OK   bool
+	// Problem describes the check failing
+	Problem string
+}
+
+// CheckHealth inspects a folder: README and CI configuration (in the
+// folder or its repository root), a commit within RecentWindow and a
+// clean working tree
+func CheckHealth(dir string) Health {
+	h := Health{}
+	dirs := []string{dir}
+	root, inRepo := RepoRoot(dir)
+	if inRepo && root != dir {
+		dirs = append(dirs, root)
+	}
+	h.Readme = anyMatch(dirs, readmePatterns)
+	h.CI = anyMatch(dirs, ciPatterns)
+
+	if !inRepo {
+		return h
+	}
+	h.Repo = true
+	h.LastCommit, _ = git.LastCommit(dir)
+	if changes, err := git.Changes(dir); err == nil {
+		h.Clean = len(changes) == 0
+	}
+	return h
+}
+
+// anyMatch reports whether a regular file matches one of the patterns in
+// any of dirs
+func anyMatch(dirs, patterns []string) bool {
+	for _, dir := range dirs {
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+			for _, m := range matches {
+				if info, err := os.Stat(m); err == nil && !info.IsDir() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Recent reports whether the folder had a commit within RecentWindow of now
+func (h Health) Recent(now time.Time) bool {
+	return !h.LastCommit.IsZero() && now.Sub(h.LastCommit) <= RecentWindow
+}
+
+// Checks lists the criteria in display order
+func (h Health) Checks(now time.Time) []HealthCheck {
+	return []HealthCheck{
+		{Name: "README", Icon: "≡", OK: h.Readme, Problem: "no README"},
+		{Name: "CI", Icon: "⚙", OK: h.CI, Problem: "no CI"},
+		{Name: "recent commits", Icon: "↻", OK: h.Recent(now), Problem: "no recent commits"},
+		{Name: "clean", Icon: "✓", OK: h.Clean, Problem: "uncommitted changes"},
+	}
+}
+
+// Score is the number of criteria met, out of len(Checks)
+func (h Health) Score(now time.Time) int {
+	score := 0
+	for _, c := range h.Checks(now) {
+		if c.OK {
+			score++
+		}
+	}
+	return score
+}
+
+// Problems describes the criteria that aren't met
+func (h Health) Problems(now time.Time) string {
+	var problems []string
+	checks := h.Checks(now)
+	if !h.Repo {
+		// Commits and status don't apply outside a repository
+		checks = checks[:2]
+	}
+	for _, c := range checks {
+		if !c.OK {
+			problems = append(problems, c.Problem)
+		}
+	}
+	if !h.Repo {
+		problems = append(problems, "not a git repository")
+	}
+	return strings.Join(problems, ", ")
+}
+
+// Summary is a one-line description of the health, for previews
+func (h Health) Summary(now time.Time) string {
+	checks := h.Checks(now)
+	if problems := h.Problems(now); problems != "" {
+		return fmt.Sprintf("%d/%d (%s)", h.Score(now), len(checks), problems)
+	}
+	return fmt.Sprintf("%d/%d", h.Score(now), len(checks))
+}
@@ -0,0 +1,60 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckHealth(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := t.TempDir()
+	pkg := filepath.Join(repo, "pkg")
+	for _, f := range []string{"README.md", ".github/workflows/ci.yml", "pkg/main.go"} {
+		path := filepath.Join(repo, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", "Initial"}} {
+		cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=me", "GIT_AUTHOR_EMAIL=me@example.com",
+			"GIT_COMMITTER_NAME=me", "GIT_COMMITTER_EMAIL=me@example.com",
+		)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	now := time.Now()
+
+	// A package inherits the README and CI of its repository
+	h := CheckHealth(pkg)
+	if h.Score(now) != 4 || h.Problems(now) != "" {
+		t.Errorf("Expected a healthy package, got %+v (%s)", h, h.Problems(now))
+	}
+
+	if err := os.WriteFile(filepath.Join(pkg, "new.go"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if h := CheckHealth(pkg); h.Clean || h.Problems(now) != "uncommitted changes" {
+		t.Errorf("Expected uncommitted changes, got %+v", h)
+	}
+	if h := CheckHealth(repo); h.Recent(now.Add(RecentWindow + time.Hour)) {
+		t.Error("A commit older than RecentWindow shouldn't be recent")
+	}
+
+	plain := CheckHealth(t.TempDir())
+	if plain.Repo || plain.Score(now) != 0 || plain.Problems(now) != "no README, no CI, not a git repository" {
+		t.Errorf("Unexpected health outside a repository: %+v (%s)", plain, plain.Problems(now))
+	}
+}
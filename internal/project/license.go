@@ -0,0 +1,161 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// License is the license a project declares
+type License struct {
+	// ID is the SPDX identifier or expression, e.g. "MIT" or
+	// "MIT OR Apache-2.0"; empty if none was found
+	ID string
+	// Source is the file it was read from, relative to the project
+	Source string
+	// Unrecognized is set when a license file exists but its text didn't
+	// match a known license
+	Unrecognized bool
+}
+
+// Copyleft reports whether the license requires derived work to be shared
+// under the same terms. An expression offering a permissive alternative
+// ("MIT OR GPL-3.0") isn't copyleft.
+func (l License) Copyleft() bool {
+	if l.ID == "" {
+		return false
+	}
+	for _, alt := range strings.Split(l.ID, " OR ") {
+		if !copyleftID.MatchString(alt) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyleftID matches the SPDX identifiers of copyleft licenses, strong
+// and weak
+var copyleftID = regexp.MustCompile(`(?i)\b(?:A?GPL|LGPL|MPL|EPL|EUPL|OSL|CDDL|CC-BY-SA)-`)
+
+// licenseFiles are the names a license file goes by, compared case-insensitively
+var licenseFiles = []string{"license", "license.md", "license.txt", "licence", "licence.md", "licence.txt", "copying", "copying.md", "copying.txt"}
+
+// licenseTexts identify a license file by phrases from its text, checked
+// in order so the more specific GNU variants win
+var licenseTexts = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"EPL-2.0", []string{"eclipse public license", "2.0"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MIT", []string{"permission is hereby granted, free of charge"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"ISC", []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+}
+
+// manifestLicense matches a license = "..." line in Cargo.toml or
+// pyproject.toml
+var manifestLicense = regexp.MustCompile(`(?m)^\s*license\s*=\s*(?:\{\s*text\s*=\s*)?"([^"]+)"`)
+
+// DetectLicense finds the license of the project in dir: from a license
+// file when its text is recognized, otherwise from the license field of a
+// package manifest
+func DetectLicense(dir string) License {
+	found := License{}
+	if name, text, ok := readLicenseFile(dir); ok {
+		found.Source = name
+		if id := identifyLicense(text); id != "" {
+			found.ID = id
+			return found
+		}
+		found.Unrecognized = true
+	}
+
+	for _, manifest := range []string{"package.json", "composer.json", "Cargo.toml", "pyproject.toml"} {
+		content, err := os.ReadFile(filepath.Join(dir, manifest))
+		if err != nil {
+			continue
+		}
+		if id := manifestLicenseID(manifest, content); id != "" {
+			return License{ID: id, Source: manifest}
+		}
+	}
+
+	return found
+}
+
+// readLicenseFile returns the name and text of the license file in dir
+func readLicenseFile(dir string) (string, string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", false
+	}
+	for _, candidate := range licenseFiles {
+		for _, e := range entries {
+			if e.IsDir() || !strings.EqualFold(e.Name(), candidate) {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				continue
+			}
+			return e.Name(), string(content), true
+		}
+	}
+	return "", "", false
+}
+
+// identifyLicense returns the SPDX id whose phrases all appear in text
+func identifyLicense(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, l := range licenseTexts {
+		matched := true
+		for _, phrase := range l.phrases {
+			if !strings.Contains(text, phrase) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return l.id
+		}
+	}
+	return ""
+}
+
+// manifestLicenseID reads the license field of a manifest
+func manifestLicenseID(name string, content []byte) string {
+	if strings.HasSuffix(name, ".json") {
+		var manifest struct {
+			License json.RawMessage `json:"license"`
+		}
+		if err := json.Unmarshal(content, &manifest); err != nil || len(manifest.License) == 0 {
+			return ""
+		}
+		// composer.json allows a list of alternatives
+		var id string
+		if err := json.Unmarshal(manifest.License, &id); err == nil {
+			return strings.TrimSpace(id)
+		}
+		var ids []string
+		if err := json.Unmarshal(manifest.License, &ids); err == nil {
+			return strings.Join(ids, " OR ")
+		}
+		return ""
+	}
+
+	if m := manifestLicense.FindSubmatch(content); m != nil {
+		return strings.TrimSpace(string(m[1]))
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectLicense(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		id       string
+		source   string
+		copyleft bool
+	}{
+		{"mit file", map[string]string{"LICENSE": "MIT License\n\nPermission is hereby granted, free of\ncharge, to any person"}, "MIT", "LICENSE", false},
+		{"gpl file", map[string]string{"COPYING": "GNU GENERAL PUBLIC LICENSE\n   Version 3, 29 June 2007"}, "GPL-3.0", "COPYING", true},
+		{"lgpl file", map[string]string{"license.md": "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3"}, "LGPL-3.0", "license.md", true},
+		{"package.json", map[string]string{"package.json": `{"name": "app", "license": "Apache-2.0"}`}, "Apache-2.0", "package.json", false},
+		{"composer list", map[string]string{"composer.json": `{"license": ["MIT", "GPL-3.0-or-later"]}`}, "MIT OR GPL-3.0-or-later", "composer.json", false},
+		{"cargo", map[string]string{"Cargo.toml": "[package]\nname = \"x\"\nlicense = \"AGPL-3.0-only\"\n"}, "AGPL-3.0-only", "Cargo.toml", true},
+		{"unknown file falls back to manifest", map[string]string{"LICENSE": "All rights reserved.", "Cargo.toml": "license = \"MPL-2.0\"\n"}, "MPL-2.0", "Cargo.toml", true},
+		{"unknown file", map[string]string{"LICENSE": "All rights reserved."}, "", "LICENSE", false},
+		{"none", map[string]string{"README.md": "# x"}, "", "", false},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for name, content := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+
+		l := DetectLicense(dir)
+		if l.ID != tt.id || l.Source != tt.source || l.Copyleft() != tt.copyleft {
+			t.Errorf("%s: got %+v (copyleft %v), expected %q from %q (copyleft %v)", tt.name, l, l.Copyleft(), tt.id, tt.source, tt.copyleft)
+		}
+		if tt.name == "unknown file" && !l.Unrecognized {
+			t.Errorf("%s: expected the license to be unrecognized", tt.name)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package project
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// manifestName matches the first name = "..." line in Cargo.toml or
+// pyproject.toml, which is the package's
+var manifestName = regexp.MustCompile(`(?m)^\s*name\s*=\s*"([^"]+)"`)
+
+// goModule matches the module directive of go.mod
+var goModule = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+
+// Name returns the name the project in dir gives itself in its manifest
+// (go.mod, package.json, Cargo.toml, composer.json or pyproject.toml), or
+// "" when it has none
+func Name(dir string) string {
+	for _, manifest := range []string{"go.mod", "package.json", "Cargo.toml", "composer.json", "pyproject.toml"} {
+		content, err := os.ReadFile(filepath.Join(dir, manifest))
+		if err != nil {
+			continue
+		}
+		if name := manifestNameOf(manifest, content); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// manifestNameOf reads the name of a manifest
+func manifestNameOf(manifest string, content []byte) string {
+	var m [][]byte
+	switch {
+	case strings.HasSuffix(manifest, ".json"):
+		var fields struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(content, &fields); err != nil {
+			return ""
+		}
+		return strings.TrimSpace(fields.Name)
+	case manifest == "go.mod":
+		m = goModule.FindSubmatch(content)
+	default:
+		m = manifestName.FindSubmatch(content)
+	}
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(m[1]))
+}
+
+// MatchesName reports whether query names the project called name: the
+// whole name, or its last path element, so that api matches
+// github.com/acme/api, @acme/api and acme/api
+func MatchesName(name, query string) bool {
+	if name == "" || query == "" {
+		return false
+	}
+	return name == query || name[strings.LastIndex(name, "/")+1:] == query
+}
@@ -0,0 +1,54 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"go.mod", map[string]string{"go.mod": "module github.com/acme/api\n\ngo 1.24\n"}, "github.com/acme/api"},
+		{"package.json", map[string]string{"package.json": `{"name": "@acme/web", "version": "1.0.0"}`}, "@acme/web"},
+		{"cargo", map[string]string{"Cargo.toml": "[package]\nname = \"ledger\"\n\n[dependencies]\nserde = \"1\"\n"}, "ledger"},
+		{"pyproject", map[string]string{"pyproject.toml": "[project]\nname = \"billing\"\n"}, "billing"},
+		{"go.mod first", map[string]string{"go.mod": "module gateway\n", "package.json": `{"name": "gateway-ui"}`}, "gateway"},
+		{"nameless manifest", map[string]string{"package.json": `{"private": true}`}, ""},
+		{"none", map[string]string{"README.md": "# x"}, ""},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for name, content := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+		if got := Name(dir); got != tt.want {
+			t.Errorf("%s: Name() = %q, expected %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchesName(t *testing.T) {
+	tests := []struct {
+		name, query string
+		want        bool
+	}{
+		{"github.com/acme/api", "api", true},
+		{"github.com/acme/api", "github.com/acme/api", true},
+		{"@acme/web", "web", true},
+		{"ledger", "ledger", true},
+		{"api-gateway", "api", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := MatchesName(tt.name, tt.query); got != tt.want {
+			t.Errorf("MatchesName(%q, %q) = %v, expected %v", tt.name, tt.query, got, tt.want)
+		}
+	}
+}
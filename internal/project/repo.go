@@ -0,0 +1,86 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// RepoRoot returns the root of the git repository containing path: the
+// nearest ancestor (or path itself) with a .git directory or file.
+// Worktrees and submodules use a .git file, so both count.
+func RepoRoot(path string) (string, bool) {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Package is a tagged folder and the repository it belongs to
+type Package struct {
+	Path string
+	// Repo is the repository root, empty if the folder isn't in one
+	Repo string
+	// Rel is Path relative to Repo ("." for the root itself)
+	Rel string
+}
+
+// IsPackage reports whether the folder is a subdirectory of its repository
+func (p Package) IsPackage() bool {
+	return p.Repo != "" && p.Rel != "."
+}
+
+// Locate finds the repository of path
+func Locate(path string) Package {
+	p := Package{Path: path}
+	root, ok := RepoRoot(path)
+	if !ok {
+		return p
+	}
+	p.Repo = root
+	if rel, err := filepath.Rel(root, path); err == nil {
+		p.Rel = rel
+	}
+	return p
+}
+
+// RepoGroup is a repository and its tagged folders
+type RepoGroup struct {
+	Repo     string
+	Packages []Package
+}
+
+// GroupByRepo groups folders by containing repository, preserving the
+// order in which repositories are first seen. Folders outside any
+// repository are collected in a final group with an empty Repo.
+func GroupByRepo(folders []string) []RepoGroup {
+	var groups []RepoGroup
+	index := make(map[string]int)
+	var loose []Package
+
+	for _, folder := range folders {
+		p := Locate(folder)
+		if p.Repo == "" {
+			loose = append(loose, p)
+			continue
+		}
+		i, ok := index[p.Repo]
+		if !ok {
+			i = len(groups)
+			index[p.Repo] = i
+			groups = append(groups, RepoGroup{Repo: p.Repo})
+		}
+		groups[i].Packages = append(groups[i].Packages, p)
+	}
+
+	if len(loose) > 0 {
+		groups = append(groups, RepoGroup{Packages: loose})
+	}
+	return groups
+}
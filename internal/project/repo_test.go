@@ -0,0 +1,72 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTree creates dirs (and .git markers) under a temp root
+func makeTree(t *testing.T, dirs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	return root
+}
+
+func TestRepoRoot(t *testing.T) {
+	root := makeTree(t, "mono/.git", "mono/packages/api", "plain/dir", "wt")
+	// Worktrees have a .git file instead of a directory
+	if err := os.WriteFile(filepath.Join(root, "wt", ".git"), []byte("gitdir: /elsewhere\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{filepath.Join(root, "mono/packages/api"), filepath.Join(root, "mono"), true},
+		{filepath.Join(root, "mono"), filepath.Join(root, "mono"), true},
+		{filepath.Join(root, "wt"), filepath.Join(root, "wt"), true},
+		{filepath.Join(root, "plain/dir"), "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := RepoRoot(tt.path)
+		if ok != tt.ok || got != tt.expected {
+			t.Errorf("RepoRoot(%s) = %q, %v; expected %q, %v", tt.path, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestGroupByRepo(t *testing.T) {
+	root := makeTree(t, "shop/.git", "shop/services/api", "shop/web", "blog/.git", "notes")
+
+	groups := GroupByRepo([]string{
+		filepath.Join(root, "shop/services/api"),
+		filepath.Join(root, "notes"),
+		filepath.Join(root, "blog"),
+		filepath.Join(root, "shop/web"),
+	})
+
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %+v", groups)
+	}
+	if groups[0].Repo != filepath.Join(root, "shop") || len(groups[0].Packages) != 2 {
+		t.Errorf("Unexpected first group: %+v", groups[0])
+	}
+	if rel := groups[0].Packages[0].Rel; rel != filepath.Join("services", "api") {
+		t.Errorf("Expected rel services/api, got %q", rel)
+	}
+	if groups[1].Packages[0].IsPackage() {
+		t.Error("A repository root is not a package")
+	}
+	if groups[2].Repo != "" || len(groups[2].Packages) != 1 {
+		t.Errorf("Expected loose folders last, got %+v", groups[2])
+	}
+}
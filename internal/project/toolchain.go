@@ -0,0 +1,68 @@
+// Package project inspects folders on disk to learn what kind of project
+// they hold.
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Toolchain is a language or tool detected in a folder
+type Toolchain struct {
+	Name   string
+	Marker string
+}
+
+// markers maps files (or glob patterns) at a project's root to the
+// toolchain they indicate
+var markers = []struct {
+	pattern string
+	name    string
+}{
+	{"go.mod", "go"},
+	{"package.json", "node"},
+	{"deno.json", "deno"},
+	{"Cargo.toml", "rust"},
+	{"pyproject.toml", "python"},
+	{"requirements.txt", "python"},
+	{"setup.py", "python"},
+	{"Gemfile", "ruby"},
+	{"pom.xml", "java"},
+	{"build.gradle", "java"},
+	{"build.gradle.kts", "kotlin"},
+	{"composer.json", "php"},
+	{"mix.exs", "elixir"},
+	{"Package.swift", "swift"},
+	{"*.csproj", "dotnet"},
+	{"*.sln", "dotnet"},
+	{"CMakeLists.txt", "cpp"},
+	{"Dockerfile", "docker"},
+	{"docker-compose.yml", "docker"},
+	{"*.tf", "terraform"},
+}
+
+// DetectToolchains returns the toolchains whose marker files exist in dir,
+// one entry per toolchain, sorted by name
+func DetectToolchains(dir string) []Toolchain {
+	seen := make(map[string]bool)
+	var found []Toolchain
+
+	for _, m := range markers {
+		if seen[m.name] {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, m.pattern))
+		if err != nil || len(matches) == 0 {
+			continue
+		}
+		if info, err := os.Stat(matches[0]); err != nil || info.IsDir() {
+			continue
+		}
+		seen[m.name] = true
+		found = append(found, Toolchain{Name: m.name, Marker: filepath.Base(matches[0])})
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
@@ -0,0 +1,37 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDetectToolchains(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"go.mod", "Dockerfile", "requirements.txt", "setup.py", "main.tf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	// A directory named like a marker doesn't count
+	if err := os.Mkdir(filepath.Join(dir, "package.json"), 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+
+	var names []string
+	for _, tc := range DetectToolchains(dir) {
+		names = append(names, tc.Name)
+	}
+
+	expected := []string{"docker", "go", "python", "terraform"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestDetectToolchainsEmpty(t *testing.T) {
+	if found := DetectToolchains(t.TempDir()); len(found) != 0 {
+		t.Errorf("Expected no toolchains, got %v", found)
+	}
+}
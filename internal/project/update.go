@@ -0,0 +1,47 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Updater is the command that updates a folder's dependencies for one
+// toolchain
+type Updater struct {
+	Toolchain string
+	Command   string
+}
+
+// updaters maps toolchains to their update commands. Where an ecosystem
+// has several package managers, the lockfile present picks one; the first
+// entry without a lockfile is the fallback.
+var updaters = map[string][]struct {
+	lockfile string
+	command  string
+}{
+	"go":     {{"", "go get -u ./... && go mod tidy"}},
+	"node":   {{"pnpm-lock.yaml", "pnpm update"}, {"yarn.lock", "yarn upgrade"}, {"bun.lockb", "bun update"}, {"", "npm update"}},
+	"rust":   {{"", "cargo update"}},
+	"python": {{"poetry.lock", "poetry update"}, {"uv.lock", "uv lock --upgrade"}},
+	"ruby":   {{"", "bundle update"}},
+	"php":    {{"", "composer update"}},
+}
+
+// Updaters returns the dependency update commands for the toolchains
+// detected in dir, sorted by toolchain. Toolchains without a known
+// updater (or, for python, without a lockfile scope knows) are skipped.
+func Updaters(dir string) []Updater {
+	var found []Updater
+	for _, tc := range DetectToolchains(dir) {
+		for _, u := range updaters[tc.Name] {
+			if u.lockfile != "" {
+				if _, err := os.Stat(filepath.Join(dir, u.lockfile)); err != nil {
+					continue
+				}
+			}
+			found = append(found, Updater{Toolchain: tc.Name, Command: u.command})
+			break
+		}
+	}
+	return found
+}
@@ -0,0 +1,37 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdaters(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string
+		expected []Updater
+	}{
+		{"go and npm", []string{"go.mod", "package.json"}, []Updater{
+			{"go", "go get -u ./... && go mod tidy"},
+			{"node", "npm update"},
+		}},
+		{"pnpm", []string{"package.json", "pnpm-lock.yaml"}, []Updater{{"node", "pnpm update"}}},
+		{"poetry", []string{"pyproject.toml", "poetry.lock"}, []Updater{{"python", "poetry update"}}},
+		{"plain pip", []string{"requirements.txt"}, nil},
+		{"docker only", []string{"Dockerfile"}, nil},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for _, f := range tt.files {
+			if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+		}
+		if got := Updaters(dir); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: Updaters = %+v, expected %+v", tt.name, got, tt.expected)
+		}
+	}
+}
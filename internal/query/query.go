@@ -0,0 +1,237 @@
+// Package query runs the read-only SQL of scope query, an escape hatch for
+// questions the other commands don't answer, and formats its results as a
+// table, JSON or CSV.
+package query
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Result is the columns and rows returned by a query
+type Result struct {
+	Columns []string
+	// Rows hold nil, int64, float64 or string values
+	Rows [][]any
+}
+
+// Check returns an error unless stmt is a single SELECT statement (WITH
+// clauses included)
+func Check(stmt string) error {
+	code := strip(stmt)
+	fields := strings.Fields(code)
+	if len(fields) == 0 {
+		return fmt.Errorf("empty query")
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH":
+	default:
+		return fmt.Errorf("only SELECT queries are allowed, not %s", strings.ToUpper(fields[0]))
+	}
+	if i := strings.Index(code, ";"); i >= 0 && strings.TrimSpace(code[i+1:]) != "" {
+		return fmt.Errorf("only a single statement is allowed")
+	}
+	return nil
+}
+
+// strip returns stmt with its comments and the contents of its quoted
+// strings and identifiers removed, leaving only the code to check
+func strip(stmt string) string {
+	var b strings.Builder
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		switch {
+		case c == '-' && strings.HasPrefix(stmt[i:], "--"):
+			end := strings.IndexByte(stmt[i:], '\n')
+			if end < 0 {
+				return b.String()
+			}
+			i += end
+			b.WriteByte(' ')
+		case c == '/' && strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			b.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(stmt[i+1:], closing)
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 1
+			b.WriteString(" x ")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// Run checks stmt and runs it on database, which should be a read-only
+// handle: the check rejects other statements, the handle anything that
+// slips past it (such as a WITH clause ahead of a DELETE).
+func Run(database *sql.DB, stmt string) (*Result, error) {
+	if err := Check(stmt); err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(stmt)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	result := &Result{Columns: columns}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("query failed: %w", err)
+		}
+		for i, v := range values {
+			if data, ok := v.([]byte); ok {
+				values[i] = string(data)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return result, nil
+}
+
+// format returns v as text; NULL is ""
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// WriteTable writes the result as aligned columns under a header, with NULL
+// shown as NULL
+func WriteTable(w io.Writer, r *Result) error {
+	cells := make([][]string, 0, len(r.Rows)+1)
+	cells = append(cells, r.Columns)
+	for _, row := range r.Rows {
+		line := make([]string, len(row))
+		for i, v := range row {
+			line[i] = format(v)
+			if v == nil {
+				line[i] = "NULL"
+			}
+			// Keep one row per line
+			line[i] = strings.NewReplacer("\n", `\n`, "\t", `\t`).Replace(line[i])
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, len(r.Columns))
+	for _, line := range cells {
+		for i, cell := range line {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	rule := make([]string, len(widths))
+	for i, width := range widths {
+		rule[i] = strings.Repeat("-", width)
+	}
+	cells = append(cells[:1], append([][]string{rule}, cells[1:]...)...)
+
+	for _, line := range cells {
+		var b strings.Builder
+		for i, cell := range line {
+			if i < len(line)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)) + "  "
+			}
+			b.WriteString(cell)
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(b.String(), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the result as an array of objects, one per row, with
+// keys in column order
+func WriteJSON(w io.Writer, r *Result) error {
+	var b strings.Builder
+	b.WriteString("[")
+	for n, row := range r.Rows {
+		if n > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, v := range row {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			key, err := json.Marshal(r.Columns[i])
+			if err != nil {
+				return err
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			b.Write(key)
+			b.WriteString(": ")
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	if len(r.Rows) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteCSV writes the result with a header row; NULL is an empty field
+func WriteCSV(w io.Writer, r *Result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = format(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
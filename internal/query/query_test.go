@@ -0,0 +1,91 @@
+package query
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		stmt string
+		ok   bool
+	}{
+		{"SELECT * FROM tags", true},
+		{"  select name from tags;  ", true},
+		{"-- tags\nSELECT name FROM tags", true},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", true},
+		{"SELECT 'a; DROP TABLE tags' AS s", true},
+		{"SELECT 1 /* ; DELETE */", true},
+		{"", false},
+		{"-- nothing", false},
+		{"DELETE FROM tags", false},
+		{"PRAGMA query_only = 0", false},
+		{"/* SELECT */ UPDATE tags SET name = 'x'", false},
+		{"SELECT 1; DELETE FROM tags", false},
+		{"ATTACH DATABASE 'x.db' AS x", false},
+	}
+
+	for _, tt := range tests {
+		err := Check(tt.stmt)
+		if (err == nil) != tt.ok {
+			t.Errorf("Check(%q) = %v, want ok %v", tt.stmt, err, tt.ok)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	store, err := db.Open(filepath.Join(t.TempDir(), "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+	if _, err := store.DB().Exec(`INSERT INTO tags (name, created_at) VALUES ('work', 1), ('api', 2)`); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	r, err := Run(store.ReadDB(), "SELECT name, created_at, NULL AS note FROM tags ORDER BY name")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if strings.Join(r.Columns, ",") != "name,created_at,note" || len(r.Rows) != 2 {
+		t.Fatalf("Unexpected result %v %v", r.Columns, r.Rows)
+	}
+	if r.Rows[0][0] != "api" || r.Rows[0][1] != int64(2) || r.Rows[0][2] != nil {
+		t.Errorf("Unexpected first row %v", r.Rows[0])
+	}
+
+	// The read-only handle stops writes the check lets through
+	if _, err := Run(store.ReadDB(), "WITH t AS (SELECT 1) DELETE FROM tags"); err == nil {
+		t.Error("Run should fail for a write")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, r); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+	table := "name  created_at  note\n----  ----------  ----\napi   2           NULL\nwork  1           NULL\n"
+	if buf.String() != table {
+		t.Errorf("WriteTable = %q, want %q", buf.String(), table)
+	}
+
+	buf.Reset()
+	if err := WriteJSON(&buf, r); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	json := "[\n  {\"name\": \"api\", \"created_at\": 2, \"note\": null},\n  {\"name\": \"work\", \"created_at\": 1, \"note\": null}\n]\n"
+	if buf.String() != json {
+		t.Errorf("WriteJSON = %q, want %q", buf.String(), json)
+	}
+
+	buf.Reset()
+	if err := WriteCSV(&buf, r); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+	if buf.String() != "name,created_at,note\napi,2,\nwork,1,\n" {
+		t.Errorf("WriteCSV = %q", buf.String())
+	}
+}
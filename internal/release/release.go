@@ -0,0 +1,77 @@
+// Package release tags releases across the repositories of a tag: for each
+// it finds the last version tag, proposes the next one and creates and
+// pushes it as an annotated tag.
+package release
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/gabssanto/Scope/internal/git"
+)
+
+// Initial is the version proposed for a repository with no version tags
+var Initial = Version{Prefix: "v", Minor: 1}
+
+// Plan is the release proposed for one repository
+type Plan struct {
+	Repo string
+	// Last is the latest version tag reachable from HEAD, "" if none
+	Last string
+	Next Version
+	// Commits is how many commits HEAD is ahead of Last
+	Commits int
+}
+
+// Pending reports whether there is anything to release
+func (p Plan) Pending() bool {
+	return p.Commits > 0
+}
+
+// Label describes the plan for display
+func (p Plan) Label() string {
+	last := p.Last
+	if last == "" {
+		last = "(none)"
+	}
+	return fmt.Sprintf("%-24s %-12s -> %-12s %d commits", filepath.Base(p.Repo), last, p.Next, p.Commits)
+}
+
+// Propose works out the next release of the repository at root
+func Propose(root string, bump Bump) (Plan, error) {
+	tags, err := git.Tags(root)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	plan := Plan{Repo: root, Next: Initial}
+	if last, name, ok := Latest(tags); ok {
+		plan.Last = name
+		plan.Next = last.Next(bump)
+	}
+
+	plan.Commits, err = git.CommitsSince(root, plan.Last)
+	if err != nil {
+		return Plan{}, err
+	}
+	return plan, nil
+}
+
+// Apply creates the planned tag with message and, unless remote is empty,
+// pushes it there
+func Apply(p Plan, message, remote string) error {
+	name := p.Next.String()
+	if message == "" {
+		message = "Release " + name
+	}
+	if err := git.CreateTag(p.Repo, name, message); err != nil {
+		return err
+	}
+	if remote == "" {
+		return nil
+	}
+	if err := git.PushTag(p.Repo, remote, name); err != nil {
+		return fmt.Errorf("created %s but %w", name, err)
+	}
+	return nil
+}
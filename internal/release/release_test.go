@@ -0,0 +1,92 @@
+package release
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runGit runs git in dir with a fixed identity
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=me", "GIT_AUTHOR_EMAIL=me@example.com",
+		"GIT_COMMITTER_NAME=me", "GIT_COMMITTER_EMAIL=me@example.com",
+	)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return string(output)
+}
+
+// commit commits a change to file
+func commit(t *testing.T, dir, file string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(file), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "Change "+file)
+}
+
+func TestProposeAndApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	remote := t.TempDir()
+	runGit(t, remote, "init", "-q", "--bare")
+
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "remote", "add", "origin", remote)
+	// Annotated tags are made by Apply, outside runGit's environment
+	runGit(t, repo, "config", "user.name", "me")
+	runGit(t, repo, "config", "user.email", "me@example.com")
+
+	commit(t, repo, "a")
+	plan, err := Propose(repo, Patch)
+	if err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+	if plan.Last != "" || plan.Next.String() != "v0.1.0" || plan.Commits != 1 {
+		t.Errorf("Expected an initial v0.1.0, got %+v", plan)
+	}
+
+	runGit(t, repo, "tag", "v1.4.2")
+	plan, err = Propose(repo, Minor)
+	if err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+	if plan.Pending() {
+		t.Errorf("Expected nothing to release right after a tag, got %+v", plan)
+	}
+
+	commit(t, repo, "b")
+	commit(t, repo, "c")
+	plan, err = Propose(repo, Minor)
+	if err != nil {
+		t.Fatalf("Propose failed: %v", err)
+	}
+	if plan.Last != "v1.4.2" || plan.Next.String() != "v1.5.0" || plan.Commits != 2 {
+		t.Errorf("Expected v1.4.2 -> v1.5.0 with 2 commits, got %+v", plan)
+	}
+
+	if err := Apply(plan, "", "origin"); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if got := runGit(t, repo, "tag", "-l", "v1.5.0", "--format=%(objecttype) %(contents:subject)"); got != "tag Release v1.5.0\n" {
+		t.Errorf("Expected an annotated tag, got %q", got)
+	}
+	if got := runGit(t, remote, "tag", "-l"); got != "v1.5.0\n" {
+		t.Errorf("Expected only v1.5.0 to be pushed, remote has %q", got)
+	}
+
+	// The tag exists now
+	if err := Apply(plan, "again", ""); err == nil {
+		t.Error("Apply should fail when the tag exists")
+	}
+}
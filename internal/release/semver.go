@@ -0,0 +1,124 @@
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Bump is the part of a version a release increments
+type Bump string
+
+const (
+	Major Bump = "major"
+	Minor Bump = "minor"
+	Patch Bump = "patch"
+)
+
+// ParseBump validates a bump name
+func ParseBump(s string) (Bump, error) {
+	switch b := Bump(s); b {
+	case Major, Minor, Patch:
+		return b, nil
+	}
+	return "", fmt.Errorf("invalid bump %q (expected major, minor or patch)", s)
+}
+
+// Version is a semantic version as used in git tags, e.g. v1.4.2 or
+// 2.0.0-rc.1. Build metadata is dropped.
+type Version struct {
+	// Prefix is "v" or "", kept so the next tag is spelled like the last
+	Prefix              string
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+var versionPattern = regexp.MustCompile(`^(v?)(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// ParseVersion parses a tag name as a version
+func ParseVersion(s string) (Version, bool) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, false
+	}
+	v := Version{Prefix: m[1], Pre: m[5]}
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	return v, true
+}
+
+// String formats the version as a tag name
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Less reports whether v precedes w. A prerelease precedes its release;
+// prereleases of the same version compare as strings.
+func (v Version) Less(w Version) bool {
+	if v.Major != w.Major {
+		return v.Major < w.Major
+	}
+	if v.Minor != w.Minor {
+		return v.Minor < w.Minor
+	}
+	if v.Patch != w.Patch {
+		return v.Patch < w.Patch
+	}
+	switch {
+	case v.Pre == w.Pre:
+		return false
+	case v.Pre == "":
+		return false
+	case w.Pre == "":
+		return true
+	}
+	return v.Pre < w.Pre
+}
+
+// Next returns the version after v. Bumping a prerelease releases it when
+// the bump doesn't go past it: 1.3.0-rc.1 becomes 1.3.0 for a minor or
+// patch bump.
+func (v Version) Next(b Bump) Version {
+	next := Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+	pre := v.Pre != ""
+	switch b {
+	case Major:
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			next.Major++
+		}
+		next.Minor, next.Patch = 0, 0
+	case Minor:
+		if !pre || v.Patch != 0 {
+			next.Minor++
+		}
+		next.Patch = 0
+	case Patch:
+		if !pre {
+			next.Patch++
+		}
+	}
+	return next
+}
+
+// Latest returns the highest version among tags, ignoring tags that
+// aren't versions
+func Latest(tags []string) (Version, string, bool) {
+	var best Version
+	var bestTag string
+	found := false
+	for _, t := range tags {
+		v, ok := ParseVersion(t)
+		if !ok {
+			continue
+		}
+		if !found || best.Less(v) {
+			best, bestTag, found = v, t, true
+		}
+	}
+	return best, bestTag, found
+}
@@ -0,0 +1,51 @@
+package release
+
+import "testing"
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		from string
+		bump Bump
+		want string
+	}{
+		{"v1.2.3", Patch, "v1.2.4"},
+		{"v1.2.3", Minor, "v1.3.0"},
+		{"v1.2.3", Major, "v2.0.0"},
+		{"0.9.1", Minor, "0.10.0"},
+		{"v1.3.0-rc.1", Patch, "v1.3.0"},
+		{"v1.3.0-rc.1", Minor, "v1.3.0"},
+		{"v1.3.0-rc.1", Major, "v2.0.0"},
+		{"v2.0.0-beta", Major, "v2.0.0"},
+		{"v1.2.3+build.5", Patch, "v1.2.4"},
+	}
+	for _, tt := range tests {
+		v, ok := ParseVersion(tt.from)
+		if !ok {
+			t.Fatalf("ParseVersion(%q) failed", tt.from)
+		}
+		if got := v.Next(tt.bump).String(); got != tt.want {
+			t.Errorf("%s %s bump = %s, want %s", tt.from, tt.bump, got, tt.want)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tags := []string{"v1.9.0", "nightly", "v1.10.0-rc.1", "v1.10.0", "v1.2.0", "release-3", "v01.2.3"}
+	v, name, ok := Latest(tags)
+	if !ok || name != "v1.10.0" || v.Minor != 10 {
+		t.Errorf("Latest = %v, %q, %v; expected v1.10.0", v, name, ok)
+	}
+
+	if _, _, ok := Latest([]string{"nightly", "latest"}); ok {
+		t.Error("Expected no version among non-version tags")
+	}
+}
+
+func TestParseBump(t *testing.T) {
+	if b, err := ParseBump("minor"); err != nil || b != Minor {
+		t.Errorf("ParseBump(minor) = %v, %v", b, err)
+	}
+	if _, err := ParseBump("huge"); err == nil {
+		t.Error("ParseBump should reject unknown bumps")
+	}
+}
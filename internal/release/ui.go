@@ -0,0 +1,41 @@
+package release
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// Select asks which of the plans to release; all are selected to start
+func Select(plans []Plan) ([]Plan, error) {
+	if len(plans) == 0 {
+		return nil, nil
+	}
+	if err := ui.CanPrompt(); err != nil {
+		return nil, err
+	}
+
+	options := make([]huh.Option[int], len(plans))
+	for i, p := range plans {
+		options[i] = huh.NewOption(p.Label(), i).Selected(true)
+	}
+
+	var chosen []int
+	err := ui.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[int]().
+			Title("Create and push these tags?").
+			Options(options...).
+			Value(&chosen),
+	)).Run()
+	if err != nil {
+		return nil, fmt.Errorf("selection canceled: %w", err)
+	}
+
+	selected := make([]Plan, 0, len(chosen))
+	for _, i := range chosen {
+		selected = append(selected, plans[i])
+	}
+	return selected, nil
+}
@@ -15,6 +15,11 @@ func ParseScopeFile(filePath string) (*ScopeConfig, error) {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
+	return ParseScopeConfig(data)
+}
+
+// ParseScopeConfig parses the contents of a .scope file
+func ParseScopeConfig(data []byte) (*ScopeConfig, error) {
 	var config ScopeConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
@@ -0,0 +1,87 @@
+package scan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseScopeConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		wantErr  bool
+	}{
+		{"list", "tags:\n  - work\n  - backend\n", []string{"work", "backend"}, false},
+		{"flow list", "tags: [work, api]\n", []string{"work", "api"}, false},
+		{"trims whitespace", "tags:\n  - '  work  '\n", []string{"work"}, false},
+		{"drops empty", "tags:\n  - ''\n  - ' '\n  - go\n", []string{"go"}, false},
+		{"no tags", "other: value\n", []string{}, false},
+		{"empty file", "", []string{}, false},
+		{"scalar tags", "tags: work\n", nil, true},
+		{"invalid yaml", "tags: [unclosed\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseScopeConfig([]byte(tt.input))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got tags %v", config.Tags)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseScopeConfig failed: %v", err)
+			}
+			if !reflect.DeepEqual(config.Tags, tt.expected) {
+				t.Errorf("Expected tags %v, got %v", tt.expected, config.Tags)
+			}
+		})
+	}
+}
+
+func FuzzParseScopeConfig(f *testing.F) {
+	seeds := []string{
+		"tags:\n  - work\n",
+		"tags: [a, b, c]\n",
+		"tags:\n  - ' padded '\n  - ''\n",
+		"tags: ~\n",
+		"tags: {}\n",
+		"&a [*a]",
+		"---\ntags: [x]\n---\ntags: [y]\n",
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		config, err := ParseScopeConfig(data)
+		if err != nil {
+			return
+		}
+
+		// Every parsed tag is trimmed and non-empty
+		for _, tag := range config.Tags {
+			if tag == "" || tag != strings.TrimSpace(tag) {
+				t.Fatalf("Parsed tag %q is not clean", tag)
+			}
+		}
+
+		// Writing the config back out and parsing it again is stable
+		out, err := yaml.Marshal(config)
+		if err != nil {
+			t.Fatalf("Failed to marshal parsed config: %v", err)
+		}
+		again, err := ParseScopeConfig(out)
+		if err != nil {
+			t.Fatalf("Failed to reparse %q: %v", out, err)
+		}
+		if !reflect.DeepEqual(again.Tags, config.Tags) {
+			t.Fatalf("Round trip changed tags: %v -> %v", config.Tags, again.Tags)
+		}
+	})
+}
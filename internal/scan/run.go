@@ -2,14 +2,42 @@ package scan
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/gabssanto/Scope/internal/db"
 	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/ui"
 )
 
-// RunScan orchestrates the entire scan operation
-func RunScan(rootPath string) error {
+// Scanner applies discovered .scope files to a Store
+type Scanner struct {
+	tags *tag.Manager
+
+	// All applies every discovered .scope file without asking
+	All bool
+
+	// Applied lists the folders Run tagged
+	Applied []string
+}
+
+// NewScanner returns a Scanner for store. A nil store uses the default
+// store opened by db.InitDB.
+func NewScanner(store *db.Store) *Scanner {
+	return &Scanner{tags: tag.NewManager(store)}
+}
+
+// RunScan orchestrates the entire scan operation using the default store.
+// With all, every discovered .scope file is applied without asking.
+func RunScan(rootPath string, all bool) error {
+	s := NewScanner(nil)
+	s.All = all
+	return s.Run(rootPath)
+}
+
+// Run orchestrates the entire scan operation
+func (s *Scanner) Run(rootPath string) error {
 	// Step 1: Scan for .scope files
-	fmt.Printf("Scanning %s for .scope files...\n\n", rootPath)
+	ui.Infof("Scanning %s for .scope files...\n\n", rootPath)
 
 	result, err := Scan(rootPath)
 	if err != nil {
@@ -17,7 +45,7 @@ func RunScan(rootPath string) error {
 	}
 
 	if len(result.Scopes) == 0 {
-		fmt.Println("No .scope files found.")
+		ui.Infoln("No .scope files found.")
 		return nil
 	}
 
@@ -25,29 +53,37 @@ func RunScan(rootPath string) error {
 	ShowScanSummary(result)
 
 	// Step 3: Interactive scope selection
-	selectedScopes, err := SelectScopes(result.Scopes)
-	if err != nil {
-		return err
+	selectedScopes := result.Scopes
+	if !s.All {
+		selectedScopes, err = SelectScopes(result.Scopes)
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(selectedScopes) == 0 {
-		fmt.Println("No folders selected. Nothing to apply.")
+		ui.Infoln("No folders selected. Nothing to apply.")
 		return nil
 	}
 
 	// Step 4: Apply tags for selected scopes
 	appliedCount := 0
 	for _, scope := range selectedScopes {
+		tagged := false
 		for _, t := range scope.Tags {
-			if err := tag.AddTag(scope.FolderPath, t); err != nil {
-				fmt.Printf("Warning: failed to add tag '%s' to %s: %v\n",
+			if err := s.tags.AddTag(scope.FolderPath, t); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to add tag '%s' to %s: %v\n",
 					t, scope.FolderPath, err)
 				continue
 			}
 			appliedCount++
+			tagged = true
+		}
+		if tagged {
+			s.Applied = append(s.Applied, scope.FolderPath)
 		}
 	}
 
-	fmt.Printf("\nApplied %d tag assignments.\n", appliedCount)
+	ui.Infof("\nApplied %d tag assignments.\n", appliedCount)
 	return nil
 }
@@ -4,10 +4,31 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const scopeFileName = ".scope"
 
+// SkipDirs are directories never looked inside, on top of hidden ones:
+// dependencies, build output and caches hold no projects of their own
+var SkipDirs = map[string]bool{
+	"node_modules":     true,
+	"bower_components": true,
+	"vendor":           true,
+	"target":           true,
+	"dist":             true,
+	"build":            true,
+	"venv":             true,
+	"__pycache__":      true,
+	"Pods":             true,
+}
+
+// Skip reports whether the directory named name is never looked inside:
+// it is hidden or one of SkipDirs
+func Skip(name string) bool {
+	return strings.HasPrefix(name, ".") || SkipDirs[name]
+}
+
 // Scan walks the directory tree starting from rootPath and discovers all .scope files
 func Scan(rootPath string) (*ScanResult, error) {
 	result := &ScanResult{
@@ -24,11 +45,9 @@ func Scan(rootPath string) (*ScanResult, error) {
 			return nil
 		}
 
-		// Skip hidden directories (except the root if it's hidden)
-		if d.IsDir() && path != rootPath {
-			if len(d.Name()) > 0 && d.Name()[0] == '.' {
-				return filepath.SkipDir
-			}
+		// Skip hidden and dependency directories (except the root)
+		if d.IsDir() && path != rootPath && Skip(d.Name()) {
+			return filepath.SkipDir
 		}
 
 		// Check for .scope file
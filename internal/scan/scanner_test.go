@@ -0,0 +1,28 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanSkipsDependencies(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"api", "node_modules/dep", "vendor/lib", ".cache/x"} {
+		path := filepath.Join(root, dir)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(path, ".scope"), []byte("tags: [work]\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	result, err := Scan(root)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(result.Scopes) != 1 || result.Scopes[0].FolderPath != filepath.Join(root, "api") {
+		t.Errorf("Expected only the api folder, got %+v", result.Scopes)
+	}
+}
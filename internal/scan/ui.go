@@ -2,14 +2,17 @@ package scan
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
 )
 
 // ShowScanSummary displays what was found during the scan
 func ShowScanSummary(result *ScanResult) {
-	fmt.Printf("Found %d .scope files:\n\n", len(result.Scopes))
+	ui.Infof("Found %d .scope files:\n\n", len(result.Scopes))
 
 	for _, scope := range result.Scopes {
 		fmt.Printf("  %s\n", scope.FolderPath)
@@ -17,13 +20,13 @@ func ShowScanSummary(result *ScanResult) {
 	}
 
 	if len(result.Errors) > 0 {
-		fmt.Printf("\nWarnings (%d files had parsing errors):\n", len(result.Errors))
+		fmt.Fprintf(os.Stderr, "\nWarnings (%d files had parsing errors):\n", len(result.Errors))
 		for _, e := range result.Errors {
-			fmt.Printf("  %s: %v\n", e.FilePath, e.Err)
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", e.FilePath, e.Err)
 		}
 	}
 
-	fmt.Println()
+	ui.Infoln()
 }
 
 // SelectScopes presents an interactive multi-select UI for selecting which scopes to apply
@@ -31,6 +34,9 @@ func SelectScopes(scopes []DiscoveredScope) ([]DiscoveredScope, error) {
 	if len(scopes) == 0 {
 		return nil, nil
 	}
+	if err := ui.CanPrompt(); err != nil {
+		return nil, err
+	}
 
 	// Build options for the multi-select
 	options := make([]huh.Option[int], len(scopes))
@@ -41,7 +47,7 @@ func SelectScopes(scopes []DiscoveredScope) ([]DiscoveredScope, error) {
 
 	var selectedIndices []int
 
-	form := huh.NewForm(
+	form := ui.NewForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[int]().
 				Title("Select folders to tag (all selected by default)").
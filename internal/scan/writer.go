@@ -0,0 +1,203 @@
+package scan
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScopeFilePath returns the path of the .scope file in folder
+func ScopeFilePath(folder string) string {
+	return filepath.Join(folder, scopeFileName)
+}
+
+// MergeScopeFile adds tags to the .scope file in folder, creating it if
+// needed. Tags already in the file keep their order, and other keys and
+// comments in an existing file are preserved. It returns the tags that were
+// added; nothing is written when there are none, or when dryRun is set.
+func MergeScopeFile(folder string, tags []string, dryRun bool) ([]string, error) {
+	path := ScopeFilePath(folder)
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	merged, added, err := mergeScopeConfig(content, tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge %s: %w", path, err)
+	}
+	if len(added) == 0 || dryRun {
+		return added, nil
+	}
+
+	if err := os.WriteFile(path, merged, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return added, nil
+}
+
+// mergeScopeConfig returns the contents of a .scope file with tags added to
+// its tags list, and the tags that were missing from it
+func mergeScopeConfig(content []byte, tags []string) ([]byte, []string, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(content)) > 0 {
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("expected a mapping at the top level")
+	}
+
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "tags" {
+			list = root.Content[i+1]
+			break
+		}
+	}
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "tags"}, list)
+	}
+	if list.Kind == yaml.ScalarNode && list.Tag == "!!null" {
+		*list = yaml.Node{Kind: yaml.SequenceNode}
+	}
+	if list.Kind != yaml.SequenceNode {
+		return nil, nil, fmt.Errorf("expected tags to be a list")
+	}
+	// Block style reads better in a file meant to be edited by hand
+	list.Style = 0
+
+	existing := make(map[string]bool, len(list.Content))
+	for _, item := range list.Content {
+		existing[strings.TrimSpace(item.Value)] = true
+	}
+
+	var added []string
+	for _, t := range tags {
+		if existing[t] {
+			continue
+		}
+		existing[t] = true
+		added = append(added, t)
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: t})
+	}
+
+	out, err := encodeScopeConfig(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, added, nil
+}
+
+// RenameInScopeFile replaces the tag from with into in the .scope file in
+// folder, dropping from instead when into is already listed. Other keys and
+// comments are preserved. It reports whether the file references from;
+// nothing is written when it does not, or when dryRun is set.
+func RenameInScopeFile(folder, from, into string, dryRun bool) (bool, error) {
+	path := ScopeFilePath(folder)
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	renamed, changed, err := renameScopeConfig(content, from, into)
+	if err != nil {
+		return false, fmt.Errorf("failed to rewrite %s: %w", path, err)
+	}
+	if !changed || dryRun {
+		return changed, nil
+	}
+
+	if err := os.WriteFile(path, renamed, 0644); err != nil {
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// renameScopeConfig returns the contents of a .scope file with from renamed
+// to into in its tags list, and whether anything changed
+func renameScopeConfig(content []byte, from, into string) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, false, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if doc.Kind == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, false, nil
+	}
+
+	root := doc.Content[0]
+	var list *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "tags" {
+			list = root.Content[i+1]
+			break
+		}
+	}
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil, false, nil
+	}
+
+	hasInto := false
+	for _, item := range list.Content {
+		if strings.TrimSpace(item.Value) == into {
+			hasInto = true
+			break
+		}
+	}
+
+	changed := false
+	items := list.Content[:0]
+	for _, item := range list.Content {
+		if strings.TrimSpace(item.Value) != from {
+			items = append(items, item)
+			continue
+		}
+		changed = true
+		if hasInto {
+			continue
+		}
+		hasInto = true
+		item.Value = into
+		items = append(items, item)
+	}
+	list.Content = items
+	if !changed {
+		return nil, false, nil
+	}
+
+	out, err := encodeScopeConfig(&doc)
+	if err != nil {
+		return nil, false, err
+	}
+	return out, true, nil
+}
+
+// encodeScopeConfig marshals a .scope document with two-space indentation
+func encodeScopeConfig(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
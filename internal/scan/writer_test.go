@@ -0,0 +1,177 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeScopeConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		tags  []string
+		added []string
+		want  []string
+	}{
+		{"new file", "", []string{"work", "api"}, []string{"work", "api"}, []string{"work", "api"}},
+		{"merge", "tags:\n  - work\n", []string{"api", "work"}, []string{"api"}, []string{"work", "api"}},
+		{"flow list", "tags: [work]\n", []string{"go"}, []string{"go"}, []string{"work", "go"}},
+		{"padded existing", "tags:\n  - ' work '\n", []string{"work"}, nil, []string{"work"}},
+		{"no tags key", "other: value\n", []string{"work"}, []string{"work"}, []string{"work"}},
+		{"null tags", "tags:\n", []string{"work"}, []string{"work"}, []string{"work"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, added, err := mergeScopeConfig([]byte(tt.input), tt.tags)
+			if err != nil {
+				t.Fatalf("mergeScopeConfig failed: %v", err)
+			}
+			if !reflect.DeepEqual(added, tt.added) {
+				t.Errorf("Expected added %v, got %v", tt.added, added)
+			}
+			config, err := ParseScopeConfig(merged)
+			if err != nil {
+				t.Fatalf("Merged file does not parse: %v\n%s", err, merged)
+			}
+			if !reflect.DeepEqual(config.Tags, tt.want) {
+				t.Errorf("Expected tags %v, got %v", tt.want, config.Tags)
+			}
+		})
+	}
+}
+
+func TestMergeScopeConfigPreservesContent(t *testing.T) {
+	input := "# Shared with the team\ntags:\n  - work # main tag\nowner: platform\n"
+
+	merged, _, err := mergeScopeConfig([]byte(input), []string{"api"})
+	if err != nil {
+		t.Fatalf("mergeScopeConfig failed: %v", err)
+	}
+	for _, want := range []string{"# Shared with the team", "# main tag", "owner: platform", "- api"} {
+		if !strings.Contains(string(merged), want) {
+			t.Errorf("Expected %q in merged file:\n%s", want, merged)
+		}
+	}
+}
+
+func TestMergeScopeConfigInvalid(t *testing.T) {
+	for _, input := range []string{"tags: work\n", "- a\n- b\n", "tags: [unclosed\n"} {
+		if _, _, err := mergeScopeConfig([]byte(input), []string{"x"}); err == nil {
+			t.Errorf("Expected an error merging into %q", input)
+		}
+	}
+}
+
+func TestMergeScopeFile(t *testing.T) {
+	dir := t.TempDir()
+
+	added, err := MergeScopeFile(dir, []string{"work"}, true)
+	if err != nil {
+		t.Fatalf("MergeScopeFile failed: %v", err)
+	}
+	if len(added) != 1 {
+		t.Errorf("Expected one tag to add, got %v", added)
+	}
+	if _, err := os.Stat(ScopeFilePath(dir)); !os.IsNotExist(err) {
+		t.Error("A dry run should not write the file")
+	}
+
+	if _, err := MergeScopeFile(dir, []string{"work"}, false); err != nil {
+		t.Fatalf("MergeScopeFile failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, ".scope"))
+	if err != nil {
+		t.Fatalf("Expected .scope to be written: %v", err)
+	}
+
+	// Nothing to add leaves the file alone
+	added, err = MergeScopeFile(dir, []string{"work"}, false)
+	if err != nil {
+		t.Fatalf("MergeScopeFile failed: %v", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("Expected nothing to add, got %v", added)
+	}
+	again, _ := os.Stat(filepath.Join(dir, ".scope"))
+	if !again.ModTime().Equal(info.ModTime()) {
+		t.Error("File should not be rewritten when nothing changes")
+	}
+}
+
+func TestRenameScopeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		changed bool
+		want    []string
+	}{
+		{"rename", "tags:\n  - work\n  - backend\n", true, []string{"work", "server"}},
+		{"already has target", "tags:\n  - backend\n  - server\n", true, []string{"server"}},
+		{"flow list", "tags: [backend, go]\n", true, []string{"server", "go"}},
+		{"not referenced", "tags:\n  - work\n", false, nil},
+		{"no tags key", "owner: platform\n", false, nil},
+		{"empty", "", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renamed, changed, err := renameScopeConfig([]byte(tt.input), "backend", "server")
+			if err != nil {
+				t.Fatalf("renameScopeConfig failed: %v", err)
+			}
+			if changed != tt.changed {
+				t.Fatalf("Expected changed=%v, got %v", tt.changed, changed)
+			}
+			if !changed {
+				return
+			}
+			config, err := ParseScopeConfig(renamed)
+			if err != nil {
+				t.Fatalf("Renamed file does not parse: %v\n%s", err, renamed)
+			}
+			if !reflect.DeepEqual(config.Tags, tt.want) {
+				t.Errorf("Expected tags %v, got %v", tt.want, config.Tags)
+			}
+		})
+	}
+}
+
+func TestRenameInScopeFile(t *testing.T) {
+	dir := t.TempDir()
+	input := "# Shared with the team\ntags:\n  - backend # main tag\n"
+	if err := os.WriteFile(ScopeFilePath(dir), []byte(input), 0644); err != nil {
+		t.Fatalf("Failed to write .scope: %v", err)
+	}
+
+	changed, err := RenameInScopeFile(dir, "backend", "server", true)
+	if err != nil {
+		t.Fatalf("RenameInScopeFile failed: %v", err)
+	}
+	if !changed {
+		t.Error("Expected the dry run to report a change")
+	}
+	content, _ := os.ReadFile(ScopeFilePath(dir))
+	if string(content) != input {
+		t.Error("A dry run should not write the file")
+	}
+
+	if _, err := RenameInScopeFile(dir, "backend", "server", false); err != nil {
+		t.Fatalf("RenameInScopeFile failed: %v", err)
+	}
+	content, _ = os.ReadFile(ScopeFilePath(dir))
+	for _, want := range []string{"# Shared with the team", "- server # main tag"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected %q in renamed file:\n%s", want, content)
+		}
+	}
+
+	// A folder without a .scope file is left alone
+	changed, err = RenameInScopeFile(t.TempDir(), "backend", "server", false)
+	if err != nil || changed {
+		t.Errorf("Expected no change without a .scope file, got %v, %v", changed, err)
+	}
+}
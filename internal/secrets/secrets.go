@@ -0,0 +1,175 @@
+// Package secrets looks for credentials committed to tagged folders: API
+// keys, tokens and private keys matched by well-known patterns. It is a
+// quick audit, not a replacement for a dedicated scanner; matches are
+// reported redacted so the report itself is safe to share.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// maxFileSize is the largest file scanned; bigger files are rarely
+// hand-written config
+const maxFileSize = 1 << 20
+
+// skipDirs are directories never scanned: history, dependencies and caches
+var skipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+	"vendor":       true,
+	".venv":        true,
+	"__pycache__":  true,
+	".terraform":   true,
+}
+
+// Rule is a kind of secret and the pattern that finds it
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Rules are the patterns checked, most specific first so a line is
+// reported under the best match
+var Rules = []Rule{
+	{"private key", regexp.MustCompile(`-----BEGIN (?:RSA |DSA |EC |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"generic secret", regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|password|passwd|token)["']?\s*[:=]\s*["']([^"'\s$]{12,})["']`)},
+}
+
+// Finding is a possible secret
+type Finding struct {
+	// Path is the file, relative to the scanned folder
+	Path string
+	Line int
+	Rule string
+	// Match is the matched text, redacted
+	Match string
+}
+
+// Result is what a scan of one folder found
+type Result struct {
+	Folder   string
+	Files    int
+	Findings []Finding
+}
+
+// Scan checks every text file under folder, skipping dependency and VCS
+// directories, binaries and files over 1 MiB. Unreadable files are skipped.
+func Scan(folder string) (*Result, error) {
+	result := &Result{Folder: folder}
+
+	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != folder {
+				return filepath.SkipDir
+			}
+			if path == folder {
+				return err
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != folder && skipDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil || isBinary(content) {
+			return nil
+		}
+		result.Files++
+
+		rel, err := filepath.Rel(folder, path)
+		if err != nil {
+			rel = path
+		}
+		result.Findings = append(result.Findings, scanContent(rel, content)...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// scanContent returns the findings in one file, at most one per line
+func scanContent(path string, content []byte) []Finding {
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		for _, rule := range Rules {
+			match := rule.Pattern.FindStringSubmatch(text)
+			if match == nil {
+				continue
+			}
+			// Report the captured value when the rule has one
+			secret := match[len(match)-1]
+			findings = append(findings, Finding{Path: path, Line: line, Rule: rule.Name, Match: Redact(secret)})
+			break
+		}
+	}
+	return findings
+}
+
+// isBinary reports whether content looks binary: it has a NUL byte early on
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// Redact keeps the first four characters of a secret, enough to recognize
+// which one it is, and masks the rest
+func Redact(secret string) string {
+	if len(secret) <= 8 {
+		return "********"
+	}
+	return secret[:4] + "********"
+}
+
+// RuleCount is how many findings a rule had
+type RuleCount struct {
+	Rule  string
+	Count int
+}
+
+// Counts returns the number of findings per rule, sorted by rule name
+func Counts(results []*Result) []RuleCount {
+	byRule := make(map[string]int)
+	for _, r := range results {
+		for _, f := range r.Findings {
+			byRule[f.Rule]++
+		}
+	}
+	counts := make([]RuleCount, 0, len(byRule))
+	for rule, n := range byRule {
+		counts = append(counts, RuleCount{Rule: rule, Count: n})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Rule < counts[j].Rule })
+	return counts
+}
@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Fake credentials are assembled at runtime so the source itself doesn't
+// trip secret scanners
+var (
+	awsKey    = "AKIA" + "ABCDEFGHIJKLMNOP"
+	githubKey = "ghp" + "_" + strings.Repeat("a1B2", 9)
+	stripeKey = "sk" + "_live_" + strings.Repeat("x9", 12)
+)
+
+func TestScanContent(t *testing.T) {
+	tests := []struct {
+		line string
+		rule string
+	}{
+		{"aws_access_key_id = " + awsKey, "AWS access key"},
+		{"export GITHUB_TOKEN=" + githubKey, "GitHub token"},
+		{`stripe: "` + stripeKey + `"`, "Stripe key"},
+		{"-----BEGIN OPENSSH " + "PRIVATE KEY-----", "private key"},
+		{`"api_key": "0123456789abcdef"`, "generic secret"},
+		{`PASSWORD = 'correct-horse-battery'`, "generic secret"},
+		// Not secrets
+		{`password = ""`, ""},
+		{`token: "${API_TOKEN}"`, ""},
+		{`func parseToken(s string) error {`, ""},
+	}
+	for _, tt := range tests {
+		findings := scanContent("config", []byte(tt.line+"\n"))
+		if tt.rule == "" {
+			if len(findings) != 0 {
+				t.Errorf("%q: expected no findings, got %+v", tt.line, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 || findings[0].Rule != tt.rule || findings[0].Line != 1 {
+			t.Errorf("%q: expected a %s, got %+v", tt.line, tt.rule, findings)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	if got := Redact(awsKey); got != "AKIA********" {
+		t.Errorf("Redact(%q) = %q", awsKey, got)
+	}
+	if got := Redact("short"); got != "********" {
+		t.Errorf("Redact(short) = %q", got)
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"README.md":                "# App\n",
+		"config/prod.env":          "DEBUG=false\nAWS_KEY=" + awsKey + "\n",
+		".github/workflows/ci.yml": "token: " + githubKey + "\n",
+		"node_modules/x/index.js":  "const key = '" + awsKey + "'\n",
+		".git/config":              "token = " + githubKey + "\n",
+		"logo.png":                 "\x89PNG\x00" + awsKey,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	result, err := Scan(root)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if result.Files != 3 {
+		t.Errorf("Expected 3 files scanned, got %d", result.Files)
+	}
+
+	found := make(map[string]Finding)
+	for _, f := range result.Findings {
+		found[filepath.ToSlash(f.Path)] = f
+	}
+	if len(found) != 2 {
+		t.Fatalf("Expected findings in 2 files, got %+v", result.Findings)
+	}
+	if f := found["config/prod.env"]; f.Line != 2 || f.Rule != "AWS access key" || strings.Contains(f.Match, awsKey) {
+		t.Errorf("Unexpected finding %+v", f)
+	}
+	if _, ok := found[".github/workflows/ci.yml"]; !ok {
+		t.Error("Expected hidden folders other than VCS ones to be scanned")
+	}
+
+	counts := Counts([]*Result{result})
+	if len(counts) != 2 || counts[0].Rule != "AWS access key" || counts[0].Count != 1 {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	if _, err := Scan(filepath.Join(root, "missing")); err == nil {
+		t.Error("Scan should fail for a missing folder")
+	}
+}
@@ -0,0 +1,360 @@
+// Package selfcheck verifies that a scope installation is usable: the
+// config directory, the database, the shell integration and PATH.
+package selfcheck
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/completions"
+	"github.com/gabssanto/Scope/internal/config"
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Name   string
+	OK     bool
+	Detail string
+	Fix    string
+}
+
+// Env describes the installation being checked
+type Env struct {
+	Home       string
+	Shell      string
+	ZDotDir    string
+	Path       string
+	Executable string
+}
+
+// DefaultEnv returns the environment of the running process
+func DefaultEnv() (*Env, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable path: %w", err)
+	}
+
+	return &Env{
+		Home:       homeDir,
+		Shell:      os.Getenv("SHELL"),
+		ZDotDir:    os.Getenv("ZDOTDIR"),
+		Path:       os.Getenv("PATH"),
+		Executable: execPath,
+	}, nil
+}
+
+// Run performs every check and returns the results in display order
+func Run(env *Env) []Result {
+	configDir := filepath.Join(env.Home, ".config", "scope")
+
+	results := []Result{
+		checkConfigDir(configDir),
+		checkConfigFile(filepath.Join(configDir, "config.yml")),
+		checkDatabase(filepath.Join(configDir, "scope.db")),
+	}
+	results = append(results, checkShell(env)...)
+	results = append(results, checkPath(env))
+
+	return results
+}
+
+// Failed returns the number of failed results
+func Failed(results []Result) int {
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+		}
+	}
+	return failed
+}
+
+// checkConfigDir verifies the config directory exists and is writable
+func checkConfigDir(dir string) Result {
+	r := Result{Name: "Config directory writable", Detail: dir}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		r.Detail = fmt.Sprintf("cannot create %s: %v", dir, err)
+		r.Fix = fmt.Sprintf("check the permissions of %s", filepath.Dir(dir))
+		return r
+	}
+
+	f, err := os.CreateTemp(dir, ".selfcheck-*")
+	if err != nil {
+		r.Detail = fmt.Sprintf("cannot write to %s: %v", dir, err)
+		r.Fix = fmt.Sprintf("chown -R $USER %s", dir)
+		return r
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	r.OK = true
+	return r
+}
+
+// checkConfigFile verifies the config file, if any, parses
+func checkConfigFile(path string) Result {
+	r := Result{Name: "Config file valid", Detail: path}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		r.OK = true
+		r.Detail = "(none, using defaults)"
+		return r
+	}
+
+	if _, err := config.LoadFile(path); err != nil {
+		r.Detail = err.Error()
+		r.Fix = fmt.Sprintf("fix the syntax error or move %s aside", path)
+		return r
+	}
+
+	r.OK = true
+	return r
+}
+
+// checkDatabase verifies the database opens and passes a quick integrity
+// check. It opens the database read-only, so a missing or outdated one is
+// reported rather than created or migrated.
+func checkDatabase(path string) Result {
+	r := Result{Name: "Database opens", Detail: path}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		r.Detail = fmt.Sprintf("%s does not exist", path)
+		r.Fix = "tag a folder with 'scope tag <path> <tag>' to create it"
+		return r
+	}
+
+	store, err := db.Open(path, db.PoolOptions{ReadOnly: true})
+	if err != nil {
+		r.Detail = err.Error()
+		r.Fix = fmt.Sprintf("check the permissions of %s", path)
+		return r
+	}
+	defer func() { _ = store.Close() }()
+
+	var status string
+	if err := store.ReadDB().QueryRow("PRAGMA quick_check").Scan(&status); err != nil {
+		r.Detail = fmt.Sprintf("integrity check failed: %v", err)
+		r.Fix = fmt.Sprintf("restore %s from a backup or re-import an export", path)
+		return r
+	}
+	if status != "ok" {
+		r.Detail = fmt.Sprintf("integrity check reported: %s", status)
+		r.Fix = fmt.Sprintf("restore %s from a backup or re-import an export", path)
+		return r
+	}
+
+	r.OK = true
+	return r
+}
+
+// ShellFiles returns the startup files scope integration may live in for
+// shell, most common first
+func ShellFiles(env *Env, shell string) []string {
+	switch shell {
+	case "bash":
+		return []string{
+			filepath.Join(env.Home, ".bashrc"),
+			filepath.Join(env.Home, ".bash_profile"),
+			filepath.Join(env.Home, ".profile"),
+		}
+	case "zsh":
+		dir := env.ZDotDir
+		if dir == "" {
+			dir = env.Home
+		}
+		return []string{
+			filepath.Join(dir, ".zshrc"),
+			filepath.Join(dir, ".zprofile"),
+		}
+	case "fish":
+		return []string{filepath.Join(env.Home, ".config", "fish", "config.fish")}
+	}
+	return nil
+}
+
+// readShellFiles concatenates the existing startup files
+func readShellFiles(files []string) (string, []string) {
+	var content strings.Builder
+	var found []string
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		found = append(found, f)
+		content.Write(data)
+		content.WriteString("\n")
+	}
+	return content.String(), found
+}
+
+// checkShell verifies shell integration: the startup file references scope,
+// completions are loaded and the sg wrapper function is defined
+func checkShell(env *Env) []Result {
+	shell := filepath.Base(env.Shell)
+	files := ShellFiles(env, shell)
+	if files == nil {
+		return []Result{{
+			Name:   "Shell integration",
+			Detail: fmt.Sprintf("unsupported or unknown shell %q", env.Shell),
+			Fix:    "scope supports bash, zsh and fish; set $SHELL to one of them",
+		}}
+	}
+
+	content, found := readShellFiles(files)
+	rcFile := files[0]
+
+	integration := Result{Name: "Shell integration", Detail: strings.Join(found, ", ")}
+	if strings.Contains(content, "scope ") {
+		integration.OK = true
+	} else {
+		integration.Detail = fmt.Sprintf("scope is not referenced in %s", rcFile)
+		integration.Fix = fmt.Sprintf("add scope completions and the sg wrapper to %s (see below)", rcFile)
+	}
+
+	return []Result{
+		integration,
+		checkCompletions(env, shell, content, rcFile),
+		checkWrapper(env, shell, content, rcFile),
+	}
+}
+
+// checkCompletions verifies completions generate and are loaded by the shell
+func checkCompletions(env *Env, shell, content, rcFile string) Result {
+	r := Result{Name: "Completions"}
+
+	if _, err := completions.Generate(shell); err != nil {
+		r.Detail = err.Error()
+		return r
+	}
+
+	if shell == "fish" {
+		file := filepath.Join(env.Home, ".config", "fish", "completions", "scope.fish")
+		if _, err := os.Stat(file); err == nil {
+			r.OK = true
+			r.Detail = file
+			return r
+		}
+		if strings.Contains(content, "scope completions fish") {
+			r.OK = true
+			r.Detail = rcFile
+			return r
+		}
+		r.Detail = "fish completions are not installed"
+		r.Fix = fmt.Sprintf("scope completions fish > %s", file)
+		return r
+	}
+
+	if strings.Contains(content, "scope completions "+shell) {
+		r.OK = true
+		r.Detail = rcFile
+		return r
+	}
+
+	r.Detail = fmt.Sprintf("completions are not loaded in %s", rcFile)
+	r.Fix = fmt.Sprintf("echo 'eval \"$(scope completions %s)\"' >> %s", shell, rcFile)
+	return r
+}
+
+// checkWrapper verifies a cd wrapper around 'scope go' is defined
+func checkWrapper(env *Env, shell, content, rcFile string) Result {
+	r := Result{Name: "Wrapper function"}
+
+	if shell == "fish" {
+		file := filepath.Join(env.Home, ".config", "fish", "functions", "sg.fish")
+		if data, err := os.ReadFile(file); err == nil && strings.Contains(string(data), "scope go") {
+			r.OK = true
+			r.Detail = file
+			return r
+		}
+	}
+
+	if strings.Contains(content, "scope go") || strings.Contains(content, "scope init") {
+		r.OK = true
+		r.Detail = rcFile
+		return r
+	}
+
+	r.Detail = fmt.Sprintf("no function calling 'scope go' found in %s", rcFile)
+	if shell == "fish" {
+		r.Fix = fmt.Sprintf("echo 'scope init fish | source' >> %s", rcFile)
+	} else {
+		r.Fix = fmt.Sprintf("echo 'eval \"$(scope init %s)\"' >> %s", shell, rcFile)
+	}
+	return r
+}
+
+// checkPath verifies the scope found first on PATH is this binary
+func checkPath(env *Env) Result {
+	r := Result{Name: "PATH ordering"}
+
+	self := resolve(env.Executable)
+	found := lookAll(env.Path, executableName())
+
+	if len(found) == 0 {
+		r.Detail = "scope is not on PATH"
+		r.Fix = fmt.Sprintf("add %s to PATH", filepath.Dir(env.Executable))
+		return r
+	}
+
+	if resolve(found[0]) != self {
+		r.Detail = fmt.Sprintf("PATH resolves scope to %s, but this is %s", found[0], env.Executable)
+		r.Fix = fmt.Sprintf("remove %s or put %s earlier in PATH", found[0], filepath.Dir(env.Executable))
+		return r
+	}
+
+	r.OK = true
+	r.Detail = found[0]
+	if len(found) > 1 {
+		r.Detail += fmt.Sprintf(" (shadows %s)", strings.Join(found[1:], ", "))
+	}
+	return r
+}
+
+// executableName is the file name of the scope binary on this platform
+func executableName() string {
+	if runtime.GOOS == "windows" {
+		return "scope.exe"
+	}
+	return "scope"
+}
+
+// lookAll returns every executable named name in the PATH list, in order
+func lookAll(pathList, name string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" {
+			continue
+		}
+		candidate := filepath.Join(dir, name)
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() || (runtime.GOOS != "windows" && info.Mode()&0111 == 0) {
+			continue
+		}
+		if seen[resolve(candidate)] {
+			continue
+		}
+		seen[resolve(candidate)] = true
+		found = append(found, candidate)
+	}
+	return found
+}
+
+// resolve follows symlinks so different links to one binary compare equal
+func resolve(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
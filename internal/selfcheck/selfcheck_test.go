@@ -0,0 +1,205 @@
+package selfcheck
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// setupTestEnv creates a fake home with a scope binary on PATH
+func setupTestEnv(t *testing.T, shell string) *Env {
+	t.Helper()
+
+	home := t.TempDir()
+	binDir := filepath.Join(home, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("Failed to create bin dir: %v", err)
+	}
+	exe := filepath.Join(binDir, executableName())
+	if err := os.WriteFile(exe, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("Failed to write fake binary: %v", err)
+	}
+
+	return &Env{
+		Home:       home,
+		Shell:      "/bin/" + shell,
+		Path:       binDir,
+		Executable: exe,
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func findResult(t *testing.T, results []Result, name string) Result {
+	t.Helper()
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("No result named %q", name)
+	return Result{}
+}
+
+func TestRunHealthyInstall(t *testing.T) {
+	env := setupTestEnv(t, "bash")
+	store, err := db.Open(filepath.Join(env.Home, ".config", "scope", "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	_ = store.Close()
+	writeFile(t, filepath.Join(env.Home, ".bashrc"), `eval "$(scope completions bash)"
+sg() { cd "$(scope go "$@")" 2>/dev/null || scope go "$@"; }
+`)
+
+	results := Run(env)
+	for _, r := range results {
+		if !r.OK {
+			t.Errorf("Check %q failed: %s (fix: %s)", r.Name, r.Detail, r.Fix)
+		}
+	}
+	if Failed(results) != 0 {
+		t.Errorf("Expected 0 failures, got %d", Failed(results))
+	}
+}
+
+func TestRunMissingShellIntegration(t *testing.T) {
+	env := setupTestEnv(t, "zsh")
+
+	results := Run(env)
+
+	for _, name := range []string{"Shell integration", "Completions", "Wrapper function"} {
+		r := findResult(t, results, name)
+		if r.OK {
+			t.Errorf("Check %q should fail without a .zshrc", name)
+		}
+		if r.Fix == "" {
+			t.Errorf("Check %q should suggest a fix", name)
+		}
+	}
+
+	completions := findResult(t, results, "Completions")
+	if !strings.Contains(completions.Fix, "scope completions zsh") {
+		t.Errorf("Expected completions fix for zsh, got %q", completions.Fix)
+	}
+}
+
+func TestRunZDotDir(t *testing.T) {
+	env := setupTestEnv(t, "zsh")
+	env.ZDotDir = filepath.Join(env.Home, ".config", "zsh")
+	writeFile(t, filepath.Join(env.ZDotDir, ".zshrc"), `eval "$(scope completions zsh)"
+sg() { cd "$(scope go "$@")"; }
+`)
+
+	results := Run(env)
+	if r := findResult(t, results, "Wrapper function"); !r.OK {
+		t.Errorf("Wrapper in $ZDOTDIR/.zshrc not detected: %s", r.Detail)
+	}
+}
+
+func TestRunInitWrapper(t *testing.T) {
+	env := setupTestEnv(t, "bash")
+	writeFile(t, filepath.Join(env.Home, ".bashrc"), `eval "$(scope completions bash)"
+eval "$(scope init bash)"
+`)
+
+	if r := findResult(t, Run(env), "Wrapper function"); !r.OK {
+		t.Errorf("Wrapper from 'scope init' not detected: %s", r.Detail)
+	}
+}
+
+func TestRunFishCompletionsFile(t *testing.T) {
+	env := setupTestEnv(t, "fish")
+	writeFile(t, filepath.Join(env.Home, ".config", "fish", "completions", "scope.fish"), "complete -c scope\n")
+	writeFile(t, filepath.Join(env.Home, ".config", "fish", "functions", "sg.fish"), "function sg; cd (scope go $argv); end\n")
+
+	results := Run(env)
+	if r := findResult(t, results, "Completions"); !r.OK {
+		t.Errorf("Fish completions file not detected: %s", r.Detail)
+	}
+	if r := findResult(t, results, "Wrapper function"); !r.OK {
+		t.Errorf("Fish sg function not detected: %s", r.Detail)
+	}
+}
+
+func TestRunUnknownShell(t *testing.T) {
+	env := setupTestEnv(t, "tcsh")
+
+	r := findResult(t, Run(env), "Shell integration")
+	if r.OK {
+		t.Error("Unsupported shell should fail the shell integration check")
+	}
+}
+
+func TestCheckPathShadowed(t *testing.T) {
+	env := setupTestEnv(t, "bash")
+
+	otherDir := filepath.Join(env.Home, "other")
+	writeFile(t, filepath.Join(otherDir, executableName()), "#!/bin/sh\n")
+	if err := os.Chmod(filepath.Join(otherDir, executableName()), 0755); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	env.Path = otherDir + string(os.PathListSeparator) + env.Path
+
+	r := checkPath(env)
+	if r.OK {
+		t.Fatal("PATH check should fail when another scope comes first")
+	}
+	if !strings.Contains(r.Detail, otherDir) {
+		t.Errorf("Expected detail to name the shadowing binary, got %q", r.Detail)
+	}
+}
+
+func TestCheckPathMissing(t *testing.T) {
+	env := setupTestEnv(t, "bash")
+	env.Path = t.TempDir()
+
+	if r := checkPath(env); r.OK {
+		t.Error("PATH check should fail when scope is not on PATH")
+	}
+}
+
+func TestCheckConfigFileInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeFile(t, path, "database: [unclosed\n")
+
+	if r := checkConfigFile(path); r.OK {
+		t.Error("Config check should fail for invalid YAML")
+	}
+}
+
+func TestCheckDatabaseMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scope.db")
+
+	r := checkDatabase(path)
+	if r.OK || r.Fix == "" {
+		t.Errorf("Database check should fail with a fix for a missing file, got %+v", r)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Database check should not create the database, got %v", err)
+	}
+}
+
+func TestCheckDatabaseCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scope.db")
+	writeFile(t, path, "this is not a sqlite database")
+
+	r := checkDatabase(path)
+	if r.OK {
+		t.Error("Database check should fail for a corrupt file")
+	}
+	if r.Fix == "" {
+		t.Error("Database check should suggest a fix")
+	}
+}
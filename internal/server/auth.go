@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Scope is what a token may do
+type Scope string
+
+const (
+	// ScopeRead lists tags and folders
+	ScopeRead Scope = "read"
+	// ScopeWrite changes them as well
+	ScopeWrite Scope = "write"
+)
+
+// allows reports whether a token with scope s may use a route needing need
+func (s Scope) allows(need Scope) bool {
+	return s == ScopeWrite || s == need
+}
+
+// Tokens are the bearer tokens the API accepts, one per scope
+type Tokens map[Scope]string
+
+// LoadTokens reads the tokens file at path, generating the tokens missing
+// from it (all of them with reset) and saving them. The file is only
+// readable by its owner.
+func LoadTokens(path string, reset bool) (Tokens, error) {
+	tokens := make(Tokens)
+	if !reset {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			scope, token, ok := strings.Cut(line, " ")
+			if !ok || (Scope(scope) != ScopeRead && Scope(scope) != ScopeWrite) {
+				return nil, fmt.Errorf("invalid line in %s: %q", path, line)
+			}
+			tokens[Scope(scope)] = strings.TrimSpace(token)
+		}
+	}
+
+	changed := false
+	for _, scope := range []Scope{ScopeRead, ScopeWrite} {
+		if tokens[scope] != "" {
+			continue
+		}
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate a token: %w", err)
+		}
+		tokens[scope] = hex.EncodeToString(buf)
+		changed = true
+	}
+	if !changed {
+		return tokens, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	content := fmt.Sprintf("# Bearer tokens for scope serve: read tokens list, write tokens can also tag\nread %s\nwrite %s\n",
+		tokens[ScopeRead], tokens[ScopeWrite])
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return tokens, nil
+}
+
+// scopeOf returns the scope of the request's bearer token. Browsers can't
+// set headers on an EventSource, so the token may come in the access_token
+// query parameter instead.
+func (t Tokens) scopeOf(r *http.Request) (Scope, bool) {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		given = r.URL.Query().Get("access_token")
+	}
+	if given == "" {
+		return "", false
+	}
+	for _, scope := range []Scope{ScopeWrite, ScopeRead} {
+		if token := t[scope]; token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1 {
+			return scope, true
+		}
+	}
+	return "", false
+}
+
+// authorize wraps a route's handler to require a token allowing need
+func (s *Server) authorize(need Scope, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		scope, ok := s.opts.Tokens.scopeOf(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scope"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid bearer token"))
+			return
+		}
+		if !scope.allows(need) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("this route needs a %s token", need))
+			return
+		}
+		h(w, r)
+	}
+}
+
+// allowCORS answers preflight requests and adds the CORS headers for
+// allowed origins. Requests from other origins are refused outright, so
+// pages in a browser can't use the API unless configured to. It reports
+// whether the request still needs handling.
+func (s *Server) allowCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if !slices.Contains(s.opts.Origins, origin) && !slices.Contains(s.opts.Origins, "*") {
+		writeError(w, http.StatusForbidden, fmt.Errorf("origin %s is not allowed", origin))
+		return false
+	}
+
+	h := w.Header()
+	h.Set("Access-Control-Allow-Origin", origin)
+	h.Add("Vary", "Origin")
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		h.Set("Access-Control-Allow-Methods", "GET, POST, DELETE")
+		h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+		h.Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-tokens")
+
+	tokens, err := LoadTokens(path, false)
+	if err != nil {
+		t.Fatalf("LoadTokens failed: %v", err)
+	}
+	if len(tokens[ScopeRead]) != 64 || len(tokens[ScopeWrite]) != 64 || tokens[ScopeRead] == tokens[ScopeWrite] {
+		t.Fatalf("Unexpected generated tokens %v", tokens)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Tokens file should be private, got %v, %v", info.Mode(), err)
+	}
+
+	again, err := LoadTokens(path, false)
+	if err != nil || again[ScopeRead] != tokens[ScopeRead] || again[ScopeWrite] != tokens[ScopeWrite] {
+		t.Errorf("LoadTokens should keep the saved tokens, got %v, %v", again, err)
+	}
+	reset, err := LoadTokens(path, true)
+	if err != nil || reset[ScopeRead] == tokens[ScopeRead] || reset[ScopeWrite] == tokens[ScopeWrite] {
+		t.Errorf("LoadTokens with reset should replace the tokens, got %v, %v", reset, err)
+	}
+
+	if err := os.WriteFile(path, []byte("admin abc\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := LoadTokens(path, false); err == nil {
+		t.Error("LoadTokens should reject an unknown scope")
+	}
+}
+
+// request sends a request with token, if any, to s
+func request(s *Server, method, target, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthorization(t *testing.T) {
+	s, api, _ := newServer(t)
+
+	tests := []struct {
+		method, target, token string
+		code                  int
+	}{
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+		{http.MethodGet, "/api/tags", "", http.StatusUnauthorized},
+		{http.MethodGet, "/api/tags", "wrong", http.StatusUnauthorized},
+		{http.MethodGet, "/api/tags", "read-token", http.StatusOK},
+		{http.MethodGet, "/api/tags", "write-token", http.StatusOK},
+		{http.MethodGet, "/metrics", "", http.StatusUnauthorized},
+		{http.MethodPost, "/api/tags/new/folders", "read-token", http.StatusForbidden},
+		{http.MethodDelete, "/api/tags/work/folders?path=" + api, "read-token", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if rec := request(s, tt.method, tt.target, tt.token, ""); rec.Code != tt.code {
+			t.Errorf("%s %s with %q = %d, want %d", tt.method, tt.target, tt.token, rec.Code, tt.code)
+		}
+	}
+}
+
+func TestWriteRoutes(t *testing.T) {
+	s, api, _ := newServer(t)
+
+	if rec := request(s, http.MethodPost, "/api/tags/go/folders", "write-token", `{"path": "`+api+`"}`); rec.Code != http.StatusNoContent {
+		t.Fatalf("Tagging = %d: %s", rec.Code, rec.Body)
+	}
+	if folders, _ := s.tags.ListFoldersByTag("go"); len(folders) != 1 || folders[0] != api {
+		t.Errorf("Folders tagged go = %v", folders)
+	}
+	if rec := request(s, http.MethodPost, "/api/tags/go/folders", "write-token", `{"path": "relative"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Tagging a relative path = %d", rec.Code)
+	}
+
+	if rec := request(s, http.MethodDelete, "/api/tags/go/folders?path="+api, "write-token", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("Untagging = %d: %s", rec.Code, rec.Body)
+	}
+	if folders, _ := s.tags.ListFoldersByTag("go"); len(folders) != 0 {
+		t.Errorf("Folders still tagged go = %v", folders)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	s, _, _ := newServer(t)
+	s.opts.Origins = []string{"chrome-extension://dashboard"}
+
+	preflight := httptest.NewRequest(http.MethodOptions, "/api/tags", nil)
+	preflight.Header.Set("Origin", "chrome-extension://dashboard")
+	preflight.Header.Set("Access-Control-Request-Method", "GET")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, preflight)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Origin") != "chrome-extension://dashboard" ||
+		!strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("Preflight = %d, %v", rec.Code, rec.Header())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tags", nil)
+	req.Header.Set("Origin", "chrome-extension://dashboard")
+	req.Header.Set("Authorization", "Bearer read-token")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "chrome-extension://dashboard" {
+		t.Errorf("Allowed origin = %d, %v", rec.Code, rec.Header())
+	}
+
+	req.Header.Set("Origin", "https://evil.example")
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("Other origin = %d, %v", rec.Code, rec.Header())
+	}
+}
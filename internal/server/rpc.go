@@ -0,0 +1,342 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// JSON-RPC error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// maxRPCLine bounds a single request, which is far larger than any real one
+const maxRPCLine = 1 << 20
+
+// rpcRequest is a JSON-RPC 2.0 request; one without an id is a
+// notification, which gets no response
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response. The result is marshaled ahead
+// so a null one is still sent.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a failed request's error
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// invalidParams is the error for params a method can't use
+func invalidParams(format string, a ...any) *rpcError {
+	return &rpcError{Code: rpcInvalidParams, Message: fmt.Sprintf(format, a...)}
+}
+
+// GoResult is the answer to the go method: the tag the query resolved to,
+// after correcting a typo, and its folders
+type GoResult struct {
+	Tag     string   `json:"tag"`
+	Folders []Folder `json:"folders"`
+}
+
+// rpcMethods are the methods ServeRPC answers, by name
+var rpcMethods = map[string]func(s *Server, params json.RawMessage) (any, error){
+	"tags.list":      (*Server).rpcTags,
+	"folders.list":   (*Server).rpcFolders,
+	"folders.search": (*Server).rpcSearch,
+	"folders.recent": (*Server).rpcRecent,
+	"folders.lookup": (*Server).rpcLookup,
+	"go":             (*Server).rpcGo,
+	"tags.add":       (*Server).rpcAddTag,
+	"tags.remove":    (*Server).rpcRemoveTag,
+}
+
+// ServeRPC answers JSON-RPC 2.0 requests read from in, one per line, with
+// one response per line on out, until in ends. Requests are answered in
+// order; batches are not supported.
+func (s *Server) ServeRPC(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxRPCLine)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		res, ok := s.callRPC([]byte(line))
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(res); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read requests: %w", err)
+	}
+	return nil
+}
+
+// callRPC answers one request, reporting false for a notification
+func (s *Server) callRPC(line []byte) (rpcResponse, bool) {
+	res := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		res.Error = &rpcError{Code: rpcParseError, Message: "invalid JSON: " + err.Error()}
+		return res, true
+	}
+	notification := len(req.ID) == 0
+	if !notification {
+		res.ID = req.ID
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		res.Error = &rpcError{Code: rpcInvalidRequest, Message: `expected a "jsonrpc": "2.0" request with a method`}
+		return res, !notification
+	}
+
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		res.Error = &rpcError{Code: rpcMethodNotFound, Message: "unknown method " + req.Method}
+		return res, !notification
+	}
+	result, err := method(s, req.Params)
+	if err != nil {
+		var rerr *rpcError
+		if !errors.As(err, &rerr) {
+			rerr = &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+		res.Error = rerr
+		return res, !notification
+	}
+	if res.Result, err = json.Marshal(result); err != nil {
+		res.Error = &rpcError{Code: rpcServerError, Message: err.Error()}
+	}
+	return res, !notification
+}
+
+// decodeParams decodes a method's params, which may be left out
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return invalidParams("invalid params: %v", err)
+	}
+	return nil
+}
+
+// rpcTags lists the tags by name
+func (s *Server) rpcTags(params json.RawMessage) (any, error) {
+	return s.tagList()
+}
+
+// rpcFolders lists every folder, or those matching {"tag": <expr>}
+func (s *Server) rpcFolders(params json.RawMessage) (any, error) {
+	var p struct {
+		Tag string `json:"tag"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	var paths []string
+	var err error
+	if p.Tag != "" {
+		if paths, err = s.tags.SelectFolders(p.Tag); err != nil {
+			return nil, invalidParams("%v", err)
+		}
+	} else if paths, err = s.tags.ListAllFolders(); err != nil {
+		return nil, err
+	}
+	return s.folders(paths)
+}
+
+// rpcSearch finds the folders with every word of {"query"} in their path,
+// tags or note, up to {"limit"}, best matches first (see tag.Search)
+func (s *Server) rpcSearch(params json.RawMessage) (any, error) {
+	var p struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Limit < 0 {
+		return nil, invalidParams("invalid limit %d", p.Limit)
+	}
+	paths, err := s.tags.Search(p.Query)
+	if err != nil {
+		return nil, err
+	}
+	if p.Limit > 0 && len(paths) > p.Limit {
+		paths = paths[:p.Limit]
+	}
+	return s.folders(paths)
+}
+
+// rpcRecent lists the tagged folders visited most recently, up to
+// {"limit"} (default 10)
+func (s *Server) rpcRecent(params json.RawMessage) (any, error) {
+	p := struct {
+		Limit int `json:"limit"`
+	}{Limit: 10}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Limit < 1 {
+		return nil, invalidParams("invalid limit %d", p.Limit)
+	}
+	return s.recent(p.Limit)
+}
+
+// rpcLookup returns the tagged folder {"path"} is in, the innermost one
+// when they nest, or null when it is in none: what an editor shows for
+// the file being edited
+func (s *Server) rpcLookup(params json.RawMessage) (any, error) {
+	var p struct {
+		Path string `json:"path"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(p.Path) {
+		return nil, invalidParams("path must be absolute: %q", p.Path)
+	}
+	folderTags, err := s.tags.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	for dir := filepath.Clean(p.Path); ; dir = filepath.Dir(dir) {
+		if tags, ok := folderTags[dir]; ok {
+			note, err := s.tags.GetNote(dir)
+			if err != nil {
+				return nil, err
+			}
+			f := newFolder(dir, tags, note)
+			return &f, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return nil, nil
+		}
+	}
+}
+
+// rpcGo returns the folders {"tag"} (a tag or expression) selects, as
+// scope go does: a tag with no folders is taken as a typo for the one
+// closest to it, if any is
+func (s *Server) rpcGo(params json.RawMessage) (any, error) {
+	var p struct {
+		Tag string `json:"tag"`
+	}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if p.Tag == "" {
+		return nil, invalidParams("missing tag")
+	}
+	paths, err := s.tags.SelectFolders(p.Tag)
+	if err != nil {
+		return nil, invalidParams("%v", err)
+	}
+	if len(paths) == 0 && !tag.IsExpr(p.Tag) {
+		matches, err := s.tags.FuzzyTags(p.Tag)
+		if err != nil {
+			return nil, err
+		}
+		if closest := tag.Closest(matches); len(closest) == 1 {
+			p.Tag = closest[0].Tag
+			if paths, err = s.tags.SelectFolders(p.Tag); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no folders found with tag '%s'", p.Tag)
+	}
+	folders, err := s.folders(paths)
+	if err != nil {
+		return nil, err
+	}
+	return GoResult{Tag: p.Tag, Folders: folders}, nil
+}
+
+// tagParams are the params of tags.add and tags.remove
+type tagParams struct {
+	Path string `json:"path"`
+	Tag  string `json:"tag"`
+}
+
+// decodeTagParams decodes and checks tagParams
+func decodeTagParams(params json.RawMessage) (tagParams, error) {
+	var p tagParams
+	if err := decodeParams(params, &p); err != nil {
+		return p, err
+	}
+	if p.Tag == "" {
+		return p, invalidParams("missing tag")
+	}
+	if !absolute(p.Path) {
+		return p, invalidParams("path must be absolute: %q", p.Path)
+	}
+	return p, nil
+}
+
+// rpcAddTag tags {"path"} with {"tag"}, returning the folder
+func (s *Server) rpcAddTag(params json.RawMessage) (any, error) {
+	p, err := decodeTagParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tags.AddTag(p.Path, p.Tag); err != nil {
+		return nil, err
+	}
+	return s.folderOf(p.Path)
+}
+
+// rpcRemoveTag removes {"tag"} from {"path"}, returning the folder
+func (s *Server) rpcRemoveTag(params json.RawMessage) (any, error) {
+	p, err := decodeTagParams(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tags.RemoveTag(p.Path, p.Tag); err != nil {
+		return nil, err
+	}
+	return s.folderOf(p.Path)
+}
+
+// folderOf returns the API's view of one folder
+func (s *Server) folderOf(path string) (Folder, error) {
+	folders, err := s.folders([]string{path})
+	if err != nil {
+		return Folder{}, err
+	}
+	if folders[0].Tags == nil {
+		folders[0].Tags = []string{}
+	}
+	return folders[0], nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// rpc sends requests, one per line, and decodes the responses
+func rpc(t *testing.T, s *Server, requests ...string) []rpcResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.ServeRPC(strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatalf("ServeRPC failed: %v", err)
+	}
+	var responses []rpcResponse
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var res rpcResponse
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("Invalid response %q: %v", line, err)
+		}
+		responses = append(responses, res)
+	}
+	return responses
+}
+
+// result decodes a response's result into v
+func result(t *testing.T, res rpcResponse, v any) {
+	t.Helper()
+	if res.Error != nil {
+		t.Fatalf("Request %s failed: %d %s", res.ID, res.Error.Code, res.Error.Message)
+	}
+	if err := json.Unmarshal(res.Result, v); err != nil {
+		t.Fatalf("Invalid result %s: %v", res.Result, err)
+	}
+}
+
+func TestRPC(t *testing.T) {
+	s, api, web := newServer(t)
+
+	responses := rpc(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tags.list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"folders.list","params":{"tag":"work+!backend"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"folders.search","params":{"query":"site"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"go","params":{"tag":"bakend"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"folders.lookup","params":{"path":"`+filepath.Join(api, "cmd", "main.go")+`"}}`,
+		`{"jsonrpc":"2.0","id":"outside","method":"folders.lookup","params":{"path":"/"}}`,
+		`{"jsonrpc":"2.0","method":"tags.add","params":{"path":"`+web+`","tag":"frontend"}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tags.remove","params":{"path":"`+web+`","tag":"work"}}`,
+	)
+	if len(responses) != 7 {
+		t.Fatalf("Expected 7 responses (none for the notification), got %d", len(responses))
+	}
+
+	var tags []Tag
+	result(t, responses[0], &tags)
+	if len(tags) != 2 || tags[0] != (Tag{Name: "backend", Count: 1}) || tags[1] != (Tag{Name: "work", Count: 2}) {
+		t.Errorf("tags.list = %+v", tags)
+	}
+
+	var folders []Folder
+	result(t, responses[1], &folders)
+	if len(folders) != 1 || folders[0].Path != web {
+		t.Errorf("folders.list = %+v", folders)
+	}
+
+	result(t, responses[2], &folders)
+	if len(folders) != 1 || folders[0].Note != "the site" {
+		t.Errorf("folders.search = %+v", folders)
+	}
+
+	var gone GoResult
+	result(t, responses[3], &gone)
+	if gone.Tag != "backend" || len(gone.Folders) != 1 || gone.Folders[0].Path != api {
+		t.Errorf("go = %+v", gone)
+	}
+
+	var found Folder
+	result(t, responses[4], &found)
+	if found.Path != api {
+		t.Errorf("folders.lookup = %+v", found)
+	}
+
+	if res := responses[5]; res.Error != nil || string(res.Result) != "null" {
+		t.Errorf("folders.lookup outside any folder = %s %+v, want null", res.Result, res.Error)
+	}
+
+	result(t, responses[6], &found)
+	if found.Path != web || strings.Join(found.Tags, ",") != "frontend" {
+		t.Errorf("tags.remove = %+v", found)
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	s, _, _ := newServer(t)
+
+	responses := rpc(t, s,
+		`not json`,
+		`{"id":1,"method":"tags.list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"folders.lookup","params":{"path":"relative"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"go","params":{"tag":"zzzzzz"}}`,
+		`{"jsonrpc":"2.0","method":"nope"}`,
+	)
+	want := []struct {
+		id   string
+		code int
+	}{
+		{"null", rpcParseError},
+		{"1", rpcInvalidRequest},
+		{"2", rpcMethodNotFound},
+		{"3", rpcInvalidParams},
+		{"4", rpcServerError},
+	}
+	if len(responses) != len(want) {
+		t.Fatalf("Expected %d responses, got %d", len(want), len(responses))
+	}
+	for i, w := range want {
+		res := responses[i]
+		if string(res.ID) != w.id || res.Error == nil || res.Error.Code != w.code {
+			t.Errorf("Response %d = %s %+v, want id %s code %d", i, res.ID, res.Error, w.id, w.code)
+		}
+	}
+}
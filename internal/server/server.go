@@ -0,0 +1,394 @@
+// Package server is scope's local HTTP API, run by scope serve, for
+// dashboards, launchers and editor plugins that would rather not spawn a
+// process per request. Responses are JSON. The same data is also served as
+// JSON-RPC over stdio, by scope editor-rpc, for editors that start scope as
+// a child process.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/metrics"
+	"github.com/gabssanto/Scope/internal/shell"
+	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/timetrack"
+)
+
+// DefaultAddr is where scope serve listens unless configured otherwise:
+// loopback only, since the API exposes every tagged path
+const DefaultAddr = "127.0.0.1:7474"
+
+// Options configure a Server
+type Options struct {
+	// DBPath is the database file, whose size /metrics reports
+	DBPath string
+	// Tokens are the bearer tokens required by every route but /healthz
+	Tokens Tokens
+	// Origins are the browser origins allowed to call the API, such as a
+	// dashboard extension's; "*" allows any
+	Origins []string
+	// Tracker provides the recently visited folders; without one there
+	// are none
+	Tracker *timetrack.Tracker
+}
+
+// Server serves the API for the tags of a Manager
+type Server struct {
+	tags    *tag.Manager
+	opts    Options
+	mux     *http.ServeMux
+	latency *metrics.Histogram
+	hub     *hub
+}
+
+// New returns a Server for m
+func New(m *tag.Manager, opts Options) *Server {
+	s := &Server{
+		tags: m,
+		opts: opts,
+		mux:  http.NewServeMux(),
+		latency: metrics.NewHistogram("scope_http_request_duration_seconds",
+			"Time taken to answer HTTP requests, by route and status code.", metrics.DefaultBuckets, "route", "code"),
+		hub: newHub(),
+	}
+	s.mux.HandleFunc("GET /{$}", s.handleDashboard)
+	s.mux.Handle("GET /static/", http.FileServerFS(webFS))
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /metrics", s.authorize(ScopeRead, s.handleMetrics))
+	s.mux.HandleFunc("GET /api/tags", s.authorize(ScopeRead, s.handleTags))
+	s.mux.HandleFunc("GET /api/folders", s.authorize(ScopeRead, s.handleFolders))
+	s.mux.HandleFunc("GET /api/recent", s.authorize(ScopeRead, s.handleRecent))
+	s.mux.HandleFunc("GET /api/events", s.authorize(ScopeRead, s.handleEvents))
+	s.mux.HandleFunc("POST /api/tags/{tag}/folders", s.authorize(ScopeWrite, s.handleAddTag))
+	s.mux.HandleFunc("DELETE /api/tags/{tag}/folders", s.authorize(ScopeWrite, s.handleRemoveTag))
+	return s
+}
+
+// ServeHTTP implements http.Handler, timing each request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if s.allowCORS(rec, r) {
+		s.mux.ServeHTTP(rec, r)
+	}
+
+	// Unknown paths share a route so they can't grow the metrics unbounded
+	route := r.Pattern
+	if route == "" {
+		route = "other"
+	}
+	s.latency.Observe(time.Since(start).Seconds(), route, strconv.Itoa(rec.status))
+}
+
+// statusRecorder remembers the status code of a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// ListenAndServe serves on addr until ctx is done, then gives requests in
+// flight a few seconds to finish. ready, if not nil, is called with the
+// address once the server is listening.
+func (s *Server) ListenAndServe(ctx context.Context, addr string, ready func(net.Addr)) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	// Event streams never finish on their own, so end them on shutdown
+	srv.RegisterOnShutdown(s.hub.close)
+	if ready != nil {
+		ready(ln.Addr())
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Serve(ln) }()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handleHealth reports whether the database can be read
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.tags.ListTags(); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleMetrics writes the database's size and contents and the request
+// latencies in the Prometheus text format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	counts, err := s.tags.ListTags()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	folders, err := s.tags.ListAllFolders()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	perTag := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		perTag[i] = metrics.Sample{Labels: []metrics.Label{{Name: "tag", Value: name}}, Value: float64(counts[name])}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if s.opts.DBPath != "" {
+		if info, err := os.Stat(s.opts.DBPath); err == nil {
+			_ = metrics.WriteGauge(w, "scope_database_size_bytes", "Size of the database file.", metrics.Sample{Value: float64(info.Size())})
+		}
+	}
+	_ = metrics.WriteGauge(w, "scope_tags", "Number of tags.", metrics.Sample{Value: float64(len(counts))})
+	_ = metrics.WriteGauge(w, "scope_folders", "Number of tagged folders.", metrics.Sample{Value: float64(len(folders))})
+	_ = metrics.WriteGauge(w, "scope_tag_folders", "Number of folders with each tag.", perTag...)
+	_ = s.latency.Write(w)
+}
+
+// Tag is a tag and how many folders have it
+type Tag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Folder is a tagged folder
+type Folder struct {
+	Path string   `json:"path"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+	Note string   `json:"note,omitempty"`
+	// CD is a shell command going to the folder: cd for a local one, a
+	// shell on its host for a remote one
+	CD string `json:"cd"`
+}
+
+// RecentFolder is a tagged folder and when it was last visited
+type RecentFolder struct {
+	Folder
+	Visited time.Time `json:"visited"`
+}
+
+// handleTags lists the tags by name
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.tagList()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// tagList returns the tags sorted by name
+func (s *Server) tagList() ([]Tag, error) {
+	counts, err := s.tags.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, Tag{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// handleFolders lists the folders, all of them or those matching the tag
+// expression in ?tag=
+func (s *Server) handleFolders(w http.ResponseWriter, r *http.Request) {
+	var paths []string
+	var err error
+	if query := r.URL.Query().Get("tag"); query != "" {
+		if paths, err = s.tags.SelectFolders(query); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	} else if paths, err = s.tags.ListAllFolders(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	folders, err := s.folders(paths)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, folders)
+}
+
+// folders returns the API's view of paths, with their tags and notes
+func (s *Server) folders(paths []string) ([]Folder, error) {
+	folderTags, err := s.tags.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.tags.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	folders := make([]Folder, 0, len(paths))
+	for _, path := range paths {
+		folders = append(folders, newFolder(path, folderTags[path], notes[path]))
+	}
+	return folders, nil
+}
+
+// handleRecent lists the tagged folders visited most recently, up to
+// ?limit= (default 10)
+func (s *Server) handleRecent(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", value))
+			return
+		}
+		limit = n
+	}
+	recent, err := s.recent(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, recent)
+}
+
+// recent returns up to limit tagged folders, most recently visited first
+func (s *Server) recent(limit int) ([]RecentFolder, error) {
+	recent := []RecentFolder{}
+	if s.opts.Tracker == nil {
+		return recent, nil
+	}
+
+	visits, err := s.opts.Tracker.Recent(limit)
+	if err != nil {
+		return nil, err
+	}
+	folderTags, err := s.tags.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := s.tags.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range visits {
+		// Visits outlive the folder's tags
+		if tags, ok := folderTags[v.Path]; ok {
+			recent = append(recent, RecentFolder{Folder: newFolder(v.Path, tags, notes[v.Path]), Visited: v.Visited})
+		}
+	}
+	return recent, nil
+}
+
+// newFolder returns the API's view of a folder
+func newFolder(path string, tags []string, note string) Folder {
+	f := Folder{Path: path, Name: folderName(path), Tags: tags, Note: note, CD: "cd " + shell.Quote(path)}
+	if loc, ok := location.Parse(path); ok {
+		f.CD = loc.ShellCommand()
+	}
+	return f
+}
+
+// handleAddTag tags the folder given as {"path": ...} in the body
+func (s *Server) handleAddTag(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Path == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf(`expected a body of {"path": "<folder>"}`))
+		return
+	}
+	if !absolute(body.Path) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("path must be absolute: %s", body.Path))
+		return
+	}
+	if err := s.tags.AddTag(body.Path, r.PathValue("tag")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRemoveTag untags the folder given as ?path=
+func (s *Server) handleRemoveTag(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if !absolute(path) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("expected an absolute ?path="))
+		return
+	}
+	if err := s.tags.RemoveTag(path, r.PathValue("tag")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// absolute reports whether path names a folder regardless of the server's
+// working directory
+func absolute(path string) bool {
+	return filepath.IsAbs(path) || location.IsRemote(path)
+}
+
+// folderName returns the name a folder is shown with
+func folderName(path string) string {
+	if loc, ok := location.Parse(path); ok {
+		return loc.Base()
+	}
+	return filepath.Base(path)
+}
+
+// writeJSON writes v as the response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+}
+
+// writeError writes err as a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
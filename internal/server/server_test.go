@@ -0,0 +1,200 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/shell"
+	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/timetrack"
+)
+
+// newServer returns a Server for a fresh database with api tagged work and
+// backend, and web tagged work
+func newServer(t *testing.T) (*Server, string, string) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	api, web := filepath.Join(root, "api"), filepath.Join(root, "web")
+	for _, dir := range []string{api, web} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+	}
+
+	store, err := db.Open(filepath.Join(root, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	m := tag.NewManager(store)
+	for _, tt := range []struct{ path, tag string }{{api, "work"}, {api, "backend"}, {web, "work"}} {
+		if err := m.AddTag(tt.path, tt.tag); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := m.SetNote(web, "the site"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	return New(m, Options{
+		DBPath:  filepath.Join(root, "scope.db"),
+		Tokens:  testTokens,
+		Tracker: timetrack.NewTracker(store),
+	}), api, web
+}
+
+// testTokens are the tokens of the servers under test
+var testTokens = Tokens{ScopeRead: "read-token", ScopeWrite: "write-token"}
+
+// get requests target from s with the read token and decodes the JSON
+// response into v
+func get(t *testing.T, s *Server, target string, v any) int {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testTokens[ScopeRead])
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("GET %s Content-Type = %q", target, ct)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("GET %s returned invalid JSON %q: %v", target, rec.Body, err)
+	}
+	return rec.Code
+}
+
+func TestTags(t *testing.T) {
+	s, _, _ := newServer(t)
+
+	var tags []Tag
+	if code := get(t, s, "/api/tags", &tags); code != http.StatusOK {
+		t.Fatalf("GET /api/tags = %d", code)
+	}
+	want := []Tag{{Name: "backend", Count: 1}, {Name: "work", Count: 2}}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags = %+v, want %+v", tags, want)
+	}
+}
+
+func TestFolders(t *testing.T) {
+	s, api, web := newServer(t)
+
+	var folders []Folder
+	if code := get(t, s, "/api/folders", &folders); code != http.StatusOK {
+		t.Fatalf("GET /api/folders = %d", code)
+	}
+	want := []Folder{
+		{Path: api, Name: "api", Tags: []string{"backend", "work"}, CD: "cd " + shell.Quote(api)},
+		{Path: web, Name: "web", Tags: []string{"work"}, Note: "the site", CD: "cd " + shell.Quote(web)},
+	}
+	if !reflect.DeepEqual(folders, want) {
+		t.Errorf("Folders = %+v, want %+v", folders, want)
+	}
+
+	folders = nil
+	if code := get(t, s, "/api/folders?tag=work+!backend", &folders); code != http.StatusOK {
+		t.Fatalf("GET /api/folders?tag= = %d", code)
+	}
+	if len(folders) != 1 || folders[0].Path != web {
+		t.Errorf("Folders tagged work but not backend = %+v", folders)
+	}
+
+	var failure map[string]string
+	if code := get(t, s, "/api/folders?tag=(work", &failure); code != http.StatusBadRequest || failure["error"] == "" {
+		t.Errorf("Invalid expression = %d, %v", code, failure)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s, _, _ := newServer(t)
+
+	var health map[string]string
+	if code := get(t, s, "/healthz", &health); code != http.StatusOK || health["status"] != "ok" {
+		t.Errorf("GET /healthz = %d, %v", code, health)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	s, _, _ := newServer(t)
+	var tags []Tag
+	get(t, s, "/api/tags", &tags)
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer "+testTokens[ScopeRead])
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"\nscope_database_size_bytes ",
+		"\nscope_tags 2\n",
+		"\nscope_folders 2\n",
+		"\nscope_tag_folders{tag=\"work\"} 2\n",
+		"\nscope_http_request_duration_seconds_count{route=\"GET /api/tags\",code=\"200\"} 1\n",
+		"\nscope_http_request_duration_seconds_count{route=\"other\",code=\"404\"} 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Metrics are missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestRecent(t *testing.T) {
+	s, api, web := newServer(t)
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	for i, dir := range []string{web, api} {
+		if err := s.opts.Tracker.Visit(1, dir, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Visit failed: %v", err)
+		}
+	}
+	if err := s.tags.RemoveTag(web, "work"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+
+	var recent []RecentFolder
+	if code := get(t, s, "/api/recent", &recent); code != http.StatusOK {
+		t.Fatalf("GET /api/recent = %d", code)
+	}
+	// web is no longer tagged
+	if len(recent) != 1 || recent[0].Path != api || !recent[0].Visited.Equal(start.Add(time.Hour)) {
+		t.Errorf("Recent folders = %+v", recent)
+	}
+
+	var failure map[string]string
+	if code := get(t, s, "/api/recent?limit=0", &failure); code != http.StatusBadRequest {
+		t.Errorf("GET /api/recent?limit=0 = %d", code)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	s, _, _ := newServer(t)
+
+	// No token needed for the page and its assets
+	rec := request(s, http.MethodGet, "/", "", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") ||
+		!strings.Contains(rec.Header().Get("Content-Security-Policy"), "default-src 'self'") {
+		t.Fatalf("GET / = %d, %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Body.String(), `src="/static/app.js"`) {
+		t.Errorf("Dashboard doesn't load its script:\n%s", rec.Body)
+	}
+	for _, asset := range []string{"/static/app.js", "/static/style.css"} {
+		if rec := request(s, http.MethodGet, asset, "", ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d", asset, rec.Code)
+		}
+	}
+	if rec := request(s, http.MethodGet, "/index.html", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("GET /index.html = %d", rec.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/events"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// heartbeat is how often an idle event stream gets a comment, so proxies
+// and browsers don't time it out
+const heartbeat = 30 * time.Second
+
+// streamBuffer is how many events a slow client may fall behind by before
+// further ones are dropped for it
+const streamBuffer = 64
+
+// hub hands published events to every open stream
+type hub struct {
+	mu      sync.Mutex
+	streams map[chan events.Event]struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newHub() *hub {
+	return &hub{streams: make(map[chan events.Event]struct{}), closed: make(chan struct{})}
+}
+
+// subscribe returns a channel receiving published events and a function
+// to stop receiving them
+func (h *hub) subscribe() (chan events.Event, func()) {
+	ch := make(chan events.Event, streamBuffer)
+	h.mu.Lock()
+	h.streams[ch] = struct{}{}
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.streams, ch)
+		h.mu.Unlock()
+	}
+}
+
+// publish hands ev to every stream without waiting on any
+func (h *hub) publish(ev events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.streams {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// close ends every stream, for shutdown
+func (h *hub) close() {
+	h.once.Do(func() { close(h.closed) })
+}
+
+// Publish sends ev to every client of /api/events. Events without a time
+// are stamped now.
+func (s *Server) Publish(ev events.Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	s.hub.publish(ev)
+}
+
+// Observe publishes every change made through m
+func (s *Server) Observe(m *tag.Manager) {
+	m.Observe(func(c tag.Change) {
+		if ev, ok := events.FromChange(c); ok {
+			s.Publish(ev)
+		}
+	})
+}
+
+// handleEvents streams events as they happen, as server-sent events named
+// after their type with the event's JSON as data
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	ch, unsubscribe := s.hub.subscribe()
+	defer unsubscribe()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(w, ": scope events\n\n"); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.hub.closed:
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case ev := <-ch:
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/events"
+)
+
+func TestEvents(t *testing.T) {
+	s, api, _ := newServer(t)
+	s.Observe(s.tags)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	// EventSource can't send headers, so the token is a query parameter
+	res, err := http.Get(ts.URL + "/api/events?access_token=" + testTokens[ScopeRead])
+	if err != nil {
+		t.Fatalf("GET /api/events failed: %v", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+	if ct := res.Header.Get("Content-Type"); res.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("GET /api/events = %d %q", res.StatusCode, ct)
+	}
+
+	// The opening comment means the stream is subscribed
+	lines := bufio.NewScanner(res.Body)
+	if !lines.Scan() || !strings.HasPrefix(lines.Text(), ":") {
+		t.Fatalf("Expected an opening comment, got %q", lines.Text())
+	}
+
+	if err := s.tags.AddTag(api, "new"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	s.Publish(events.Event{Type: events.ScanRan, Path: api})
+
+	var got []events.Event
+	name := ""
+	for len(got) < 2 && lines.Scan() {
+		line := lines.Text()
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			name = v
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			var ev events.Event
+			if err := json.Unmarshal([]byte(v), &ev); err != nil {
+				t.Fatalf("Invalid event data %q: %v", v, err)
+			}
+			if string(ev.Type) != name {
+				t.Errorf("Event named %q has type %q", name, ev.Type)
+			}
+			if ev.Time.IsZero() {
+				t.Errorf("Event %s has no time", ev.Type)
+			}
+			got = append(got, ev)
+		}
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", got)
+	}
+	if got[0].Type != events.TagAdded || got[0].Path != api || got[0].Tag != "new" {
+		t.Errorf("Unexpected first event %+v", got[0])
+	}
+	if got[1].Type != events.ScanRan {
+		t.Errorf("Unexpected second event %+v", got[1])
+	}
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// webFS is the dashboard: index.html, and its scripts and styles under
+// static/
+var webFS, _ = fs.Sub(webFiles, "web")
+
+// handleDashboard serves the dashboard's page. It is public, like its
+// assets, and asks for a token before calling the API.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	// Only the dashboard's own files run, and no other site can frame it
+	h.Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Referrer-Policy", "no-referrer")
+	http.ServeFileFS(w, r, webFS, "index.html")
+}
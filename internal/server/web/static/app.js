@@ -0,0 +1,169 @@
+// The scope dashboard: tags, recently visited folders and a quick search
+// over every tagged folder, with buttons copying the command to go there.
+// It reloads as tags change, following the server's event stream.
+"use strict";
+
+const tokenKey = "scope-token";
+const $ = (id) => document.getElementById(id);
+
+let folders = [];
+let selectedTag = "";
+let stream = null;
+let reload = 0;
+
+// el creates an element with a class and text
+function el(tag, className, text) {
+  const node = document.createElement(tag);
+  if (className) node.className = className;
+  if (text !== undefined) node.textContent = text;
+  return node;
+}
+
+// api fetches a route with the saved token, showing the login form when
+// the token is missing or rejected
+async function api(path) {
+  const token = localStorage.getItem(tokenKey);
+  if (!token) throw new Error("login");
+  const res = await fetch(path, { headers: { Authorization: "Bearer " + token } });
+  if (res.status === 401) {
+    localStorage.removeItem(tokenKey);
+    throw new Error("login");
+  }
+  if (!res.ok) {
+    const body = await res.json().catch(() => ({}));
+    throw new Error(body.error || res.statusText);
+  }
+  return res.json();
+}
+
+// copy puts text on the clipboard and acknowledges it on button
+async function copy(text, button) {
+  try {
+    await navigator.clipboard.writeText(text);
+    button.textContent = "Copied";
+  } catch {
+    window.prompt("Copy the command:", text);
+  }
+  setTimeout(() => { button.textContent = "Copy cd"; }, 1500);
+}
+
+// folderItem renders a folder with its tags, note and copy button
+function folderItem(folder) {
+  const li = el("li");
+  const info = el("div", "folder");
+  const name = el("div", "name", folder.name);
+  for (const tag of folder.tags) name.append(el("span", "chip", tag));
+  info.append(name, el("div", "path", folder.path));
+  if (folder.note) info.append(el("div", "note", folder.note));
+
+  const button = el("button", "", "Copy cd");
+  button.title = folder.cd;
+  button.addEventListener("click", () => copy(folder.cd, button));
+  li.append(info, button);
+  return li;
+}
+
+// matches reports whether a folder has every word of the search in its
+// name, path, tags or note
+function matches(folder, words) {
+  const text = [folder.name, folder.path, folder.note || "", ...folder.tags].join(" ").toLowerCase();
+  return words.every((word) => text.includes(word));
+}
+
+// render shows the folders with the selected tag that match the search
+function render() {
+  const words = $("search").value.toLowerCase().split(/\s+/).filter(Boolean);
+  const shown = folders.filter((f) => (!selectedTag || f.tags.includes(selectedTag)) && matches(f, words));
+  $("folders").replaceChildren(...shown.map(folderItem));
+  $("folders-title").textContent = selectedTag ? "Tagged " + selectedTag : "Folders";
+  $("empty").hidden = shown.length > 0;
+  for (const button of $("tags").querySelectorAll("button")) {
+    button.classList.toggle("active", button.dataset.tag === selectedTag);
+  }
+}
+
+// tagItem renders a tag that filters the folders when clicked
+function tagItem(tag) {
+  const button = el("button");
+  button.dataset.tag = tag.name;
+  button.append(el("span", "", tag.name), el("span", "count", String(tag.count)));
+  button.addEventListener("click", () => {
+    selectedTag = selectedTag === tag.name ? "" : tag.name;
+    render();
+  });
+  const li = el("li");
+  li.append(button);
+  return li;
+}
+
+// follow opens the event stream, reloading shortly after changes so a
+// burst of them, as from a scan, reloads once
+function follow() {
+  if (stream) return;
+  const token = localStorage.getItem(tokenKey);
+  stream = new EventSource("/api/events?access_token=" + encodeURIComponent(token));
+  const changed = () => {
+    clearTimeout(reload);
+    reload = setTimeout(load, 300);
+  };
+  for (const type of ["tag.added", "tag.removed", "tag.deleted", "tag.renamed", "tag.merged",
+    "folder.forgotten", "note.changed", "scan.ran", "prune.ran"]) {
+    stream.addEventListener(type, changed);
+  }
+  // The browser reconnects on its own unless the token was refused
+  stream.addEventListener("error", () => {
+    if (stream.readyState === EventSource.CLOSED) stream = null;
+  });
+}
+
+async function load() {
+  try {
+    const [tags, all, recent] = await Promise.all([api("/api/tags"), api("/api/folders"), api("/api/recent?limit=5")]);
+    folders = all;
+    $("tags").replaceChildren(...tags.map(tagItem));
+    $("recent").replaceChildren(...recent.map(folderItem));
+    $("recent-section").hidden = recent.length === 0;
+    $("login").hidden = true;
+    $("dashboard").hidden = false;
+    $("search").hidden = false;
+    $("status").textContent = "";
+    render();
+    if (!stream) $("search").focus();
+    follow();
+  } catch (err) {
+    if (err.message === "login") {
+      if (stream) stream.close();
+      stream = null;
+      $("dashboard").hidden = true;
+      $("search").hidden = true;
+      $("login").hidden = false;
+      $("token").focus();
+    } else {
+      $("status").textContent = "Failed to load: " + err.message;
+    }
+  }
+}
+
+document.addEventListener("DOMContentLoaded", () => {
+  // A token may come in the URL fragment, which never reaches the server
+  const fragment = new URLSearchParams(location.hash.slice(1));
+  if (fragment.get("token")) {
+    localStorage.setItem(tokenKey, fragment.get("token"));
+    history.replaceState(null, "", location.pathname);
+  }
+
+  $("login").addEventListener("submit", async (event) => {
+    event.preventDefault();
+    localStorage.setItem(tokenKey, $("token").value.trim());
+    await load();
+    $("login-error").textContent = $("login").hidden ? "" : "That token was not accepted.";
+  });
+  $("search").addEventListener("input", render);
+  document.addEventListener("keydown", (event) => {
+    if (event.key === "/" && document.activeElement !== $("search") && !$("search").hidden) {
+      event.preventDefault();
+      $("search").focus();
+    }
+  });
+  load();
+});
@@ -0,0 +1,286 @@
+// Package service installs scope's long-running commands as user services
+// started at login: systemd user units on Linux and LaunchAgents on macOS.
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/plist"
+)
+
+// ErrUnsupported is returned on systems without a supported service manager
+var ErrUnsupported = errors.New("services are only supported with systemd or launchd")
+
+// Service is a scope command that runs in the background
+type Service struct {
+	// Name is what scope service calls it
+	Name string
+	// Description is shown by the service manager
+	Description string
+	// Args are the arguments scope runs with
+	Args []string
+}
+
+// services are the commands that can be installed, by name
+var services = []Service{
+	{Name: "serve", Description: "Scope HTTP API", Args: []string{"serve"}},
+	{Name: "watch", Description: "Scope folder watcher", Args: []string{"watch"}},
+}
+
+// Names returns the names of the services that can be installed
+func Names() []string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// Lookup returns the service with the given name
+func Lookup(name string) (Service, error) {
+	for _, s := range services {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return Service{}, fmt.Errorf("unknown service %q (expected one of %s)", name, strings.Join(Names(), ", "))
+}
+
+// goos is the system services are installed for; tests may replace it
+var goos = runtime.GOOS
+
+// run runs a service manager command and returns its combined output;
+// tests may replace it
+var run = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// Status is what the service manager reports about an installed service
+type Status struct {
+	// Path is the unit or agent file
+	Path string
+	// Installed reports whether the file exists
+	Installed bool
+	// Output is the service manager's report, empty when not installed
+	Output string
+}
+
+// Install writes the service's unit or agent file, running exe with the
+// service's arguments, then enables and starts it. It returns the file
+// written; installing again replaces the file and restarts the service.
+func Install(s Service, exe string) (string, error) {
+	path, err := filePath(s)
+	if err != nil {
+		return "", err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	switch goos {
+	case "linux":
+		err = writeUnit(&buf, s, exe)
+	case "darwin":
+		err = writeAgent(&buf, s, exe, filepath.Join(home, "Library", "Logs", "scope-"+s.Name+".log"))
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	switch goos {
+	case "linux":
+		if err := systemctl("daemon-reload"); err != nil {
+			return path, err
+		}
+		if err := systemctl("enable", unitName(s)); err != nil {
+			return path, err
+		}
+		return path, systemctl("restart", unitName(s))
+	default:
+		// Bootstrapping fails while an older copy is loaded
+		_, _ = run("launchctl", "bootout", target(s))
+		return path, launchctl("bootstrap", domain(), path)
+	}
+}
+
+// Uninstall stops and disables the service and removes its file. It
+// returns the file removed, or an empty path when it wasn't installed.
+func Uninstall(s Service) (string, error) {
+	path, err := filePath(s)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	switch goos {
+	case "linux":
+		if err := systemctl("disable", "--now", unitName(s)); err != nil {
+			return "", err
+		}
+	default:
+		// Not being loaded is fine; the file still goes
+		_, _ = run("launchctl", "bootout", target(s))
+	}
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	if goos == "linux" {
+		return path, systemctl("daemon-reload")
+	}
+	return path, nil
+}
+
+// GetStatus returns the service manager's report on the service
+func GetStatus(s Service) (*Status, error) {
+	path, err := filePath(s)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Path: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return status, nil
+	}
+	status.Installed = true
+
+	var out []byte
+	switch goos {
+	case "linux":
+		out, err = run("systemctl", "--user", "status", "--no-pager", unitName(s))
+	default:
+		out, err = run("launchctl", "print", target(s))
+	}
+	// Both exit non-zero for a stopped service, and still say so
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+	status.Output = strings.TrimRight(string(out), "\n")
+	return status, nil
+}
+
+// filePath returns where the service's unit or agent file goes
+func filePath(s Service) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	switch goos {
+	case "linux":
+		config := os.Getenv("XDG_CONFIG_HOME")
+		if config == "" {
+			config = filepath.Join(home, ".config")
+		}
+		return filepath.Join(config, "systemd", "user", unitName(s)), nil
+	case "darwin":
+		return filepath.Join(home, "Library", "LaunchAgents", label(s)+".plist"), nil
+	default:
+		return "", ErrUnsupported
+	}
+}
+
+// unitName returns the service's systemd unit name
+func unitName(s Service) string {
+	return "scope-" + s.Name + ".service"
+}
+
+// label returns the service's launchd label
+func label(s Service) string {
+	return "io.github.gabssanto.scope." + s.Name
+}
+
+// domain returns the launchd domain of the user's agents
+func domain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+// target returns the service's launchd service target
+func target(s Service) string {
+	return domain() + "/" + label(s)
+}
+
+// writeUnit writes s as a systemd user unit running exe
+func writeUnit(w io.Writer, s Service, exe string) error {
+	args := make([]string, 0, len(s.Args)+1)
+	for _, arg := range append([]string{exe}, s.Args...) {
+		args = append(args, quoteUnitArg(arg))
+	}
+	_, err := fmt.Fprintf(w, `[Unit]
+Description=%s
+
+[Service]
+ExecStart=%s
+Restart=on-failure
+
+[Install]
+WantedBy=default.target
+`, s.Description, strings.Join(args, " "))
+	return err
+}
+
+// quoteUnitArg quotes an ExecStart argument when systemd would otherwise
+// split or expand it
+func quoteUnitArg(arg string) string {
+	arg = strings.NewReplacer("%", "%%", "$", "$$").Replace(arg)
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// writeAgent writes s as a launchd LaunchAgent running exe at login and
+// again whenever it exits, logging to log
+func writeAgent(w io.Writer, s Service, exe, log string) error {
+	args := make([]any, 0, len(s.Args)+1)
+	for _, arg := range append([]string{exe}, s.Args...) {
+		args = append(args, arg)
+	}
+	return plist.Write(w, plist.Dict{
+		{Key: "Label", Value: label(s)},
+		{Key: "ProgramArguments", Value: args},
+		{Key: "RunAtLoad", Value: true},
+		{Key: "KeepAlive", Value: true},
+		{Key: "StandardOutPath", Value: log},
+		{Key: "StandardErrorPath", Value: log},
+	})
+}
+
+// systemctl runs a systemctl --user command
+func systemctl(args ...string) error {
+	return manage("systemctl", append([]string{"--user"}, args...)...)
+}
+
+// launchctl runs a launchctl command
+func launchctl(args ...string) error {
+	return manage("launchctl", args...)
+}
+
+// manage runs a service manager command, failing with its output
+func manage(name string, args ...string) error {
+	out, err := run(name, args...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s failed: %s", name, strings.Join(args, " "), msg)
+		}
+		return fmt.Errorf("%s %s failed: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
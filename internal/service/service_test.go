@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeSystem pretends to be goos, with HOME in a temp dir and service
+// manager commands recorded rather than run
+func fakeSystem(t *testing.T, system string) (string, *[]string) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	var commands []string
+	oldGoos, oldRun := goos, run
+	goos = system
+	run = func(name string, args ...string) ([]byte, error) {
+		commands = append(commands, name+" "+strings.Join(args, " "))
+		return []byte("active"), nil
+	}
+	t.Cleanup(func() { goos, run = oldGoos, oldRun })
+	return home, &commands
+}
+
+func TestLookup(t *testing.T) {
+	s, err := Lookup("serve")
+	if err != nil || !reflect.DeepEqual(s.Args, []string{"serve"}) {
+		t.Errorf("Lookup(serve) = %+v, %v", s, err)
+	}
+	if _, err := Lookup("nope"); err == nil || !strings.Contains(err.Error(), "serve") {
+		t.Errorf("Lookup(nope) should fail listing the services, got %v", err)
+	}
+}
+
+func TestWriteUnit(t *testing.T) {
+	var buf bytes.Buffer
+	s := Service{Name: "serve", Description: "Scope HTTP API", Args: []string{"serve", "--addr", "100%"}}
+	if err := writeUnit(&buf, s, "/opt/my apps/scope"); err != nil {
+		t.Fatalf("writeUnit failed: %v", err)
+	}
+	for _, want := range []string{
+		"Description=Scope HTTP API\n",
+		`ExecStart="/opt/my apps/scope" serve --addr 100%%` + "\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Unit is missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestInstallSystemd(t *testing.T) {
+	home, commands := fakeSystem(t, "linux")
+	s, _ := Lookup("serve")
+
+	path, err := Install(s, "/usr/local/bin/scope")
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if want := filepath.Join(home, ".config", "systemd", "user", "scope-serve.service"); path != want {
+		t.Errorf("Install wrote %s, want %s", path, want)
+	}
+	unit, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(unit), "ExecStart=/usr/local/bin/scope serve\n") {
+		t.Errorf("Unit = %q, %v", unit, err)
+	}
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable scope-serve.service",
+		"systemctl --user restart scope-serve.service",
+	}
+	if !reflect.DeepEqual(*commands, want) {
+		t.Errorf("Install ran %q, want %q", *commands, want)
+	}
+
+	status, err := GetStatus(s)
+	if err != nil || !status.Installed || status.Output != "active" {
+		t.Errorf("GetStatus = %+v, %v", status, err)
+	}
+
+	*commands = nil
+	if removed, err := Uninstall(s); err != nil || removed != path {
+		t.Fatalf("Uninstall = %q, %v", removed, err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Uninstall should remove the unit")
+	}
+	if (*commands)[0] != "systemctl --user disable --now scope-serve.service" {
+		t.Errorf("Uninstall ran %q", *commands)
+	}
+
+	// Already gone
+	if removed, err := Uninstall(s); err != nil || removed != "" {
+		t.Errorf("Second Uninstall = %q, %v", removed, err)
+	}
+	if status, err := GetStatus(s); err != nil || status.Installed {
+		t.Errorf("GetStatus after Uninstall = %+v, %v", status, err)
+	}
+}
+
+func TestInstallLaunchd(t *testing.T) {
+	home, commands := fakeSystem(t, "darwin")
+	s, _ := Lookup("serve")
+
+	path, err := Install(s, "/usr/local/bin/scope")
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if want := filepath.Join(home, "Library", "LaunchAgents", "io.github.gabssanto.scope.serve.plist"); path != want {
+		t.Errorf("Install wrote %s, want %s", path, want)
+	}
+	agent, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	for _, want := range []string{
+		"<key>Label</key>\n\t<string>io.github.gabssanto.scope.serve</string>",
+		"<string>/usr/local/bin/scope</string>\n\t\t<string>serve</string>",
+		"<key>KeepAlive</key>\n\t<true/>",
+	} {
+		if !strings.Contains(string(agent), want) {
+			t.Errorf("Agent is missing %q:\n%s", want, agent)
+		}
+	}
+	if last := (*commands)[len(*commands)-1]; !strings.HasPrefix(last, "launchctl bootstrap gui/") || !strings.HasSuffix(last, path) {
+		t.Errorf("Install ran %q", *commands)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	fakeSystem(t, "windows")
+	s, _ := Lookup("serve")
+	if _, err := Install(s, "scope.exe"); err != ErrUnsupported {
+		t.Errorf("Install on windows = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package session
+
+import "os"
+
+// deviceOf is unknown on Windows, where sessions don't mount folders
+func deviceOf(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,44 @@
+package session
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExitDir is where the shell integration takes the user when a session
+// ends
+type ExitDir string
+
+const (
+	// ExitOrigin returns to the directory the session was started from
+	ExitOrigin ExitDir = "origin"
+	// ExitFirst goes to the tag's first folder
+	ExitFirst ExitDir = "first"
+)
+
+// ExitFileEnv names the file the shell integration reads the exit
+// directory from; scope start only writes one when it is set
+const ExitFileEnv = "SCOPE_EXIT_FILE"
+
+// ParseExitDir parses an exit directory setting; empty means ExitOrigin
+func ParseExitDir(s string) (ExitDir, error) {
+	switch ExitDir(s) {
+	case "", ExitOrigin:
+		return ExitOrigin, nil
+	case ExitFirst:
+		return ExitFirst, nil
+	default:
+		return "", fmt.Errorf("unknown exit directory %q (expected origin or first)", s)
+	}
+}
+
+// WriteExitDir tells the shell integration to cd to dir once scope start
+// returns. Without the integration there is no one to tell, and it does
+// nothing.
+func WriteExitDir(dir string) error {
+	file := os.Getenv(ExitFileEnv)
+	if file == "" {
+		return nil
+	}
+	return os.WriteFile(file, []byte(dir+"\n"), 0600)
+}
@@ -0,0 +1,113 @@
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/gabssanto/Scope/internal/shell"
+)
+
+// File is a file generated at the workspace root when a session starts,
+// such as shared editor settings or a direnv file
+type File struct {
+	// Path is relative to the workspace, e.g. .vscode/settings.json
+	Path string
+	// Template is the content, as a Go text/template executed with
+	// TemplateData
+	Template string
+}
+
+// TemplateData is what workspace file templates can refer to
+type TemplateData struct {
+	Tag       string
+	Workspace string
+	Folders   []TemplateFolder
+}
+
+// TemplateFolder is a folder linked into the workspace
+type TemplateFolder struct {
+	// Name is the link's path inside the workspace
+	Name string
+	Path string
+}
+
+// templateFuncs quote values for the shell files a template writes, e.g.
+// export SCOPE_TAG={{shquote .Tag}} in a direnv file
+var templateFuncs = template.FuncMap{
+	"shquote":   shell.Quote,
+	"fishquote": shell.QuoteFish,
+}
+
+// ValidFilePath reports an error unless p stays inside the workspace
+func ValidFilePath(p string) error {
+	clean := filepath.Clean(filepath.FromSlash(p))
+	if p == "" || filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %q must be relative to the workspace", p)
+	}
+	return nil
+}
+
+// writeFiles renders each file into the workspace. A file whose first
+// path element is a link name is skipped rather than written into a
+// tagged folder.
+func (w *workspace) writeFiles(files []File, data TemplateData) error {
+	links := make(map[string]bool, len(w.names))
+	for _, name := range w.names {
+		if name != "" {
+			links[strings.SplitN(name, "/", 2)[0]] = true
+		}
+	}
+
+	var failed []string
+	for _, f := range files {
+		if err := w.writeFile(f, data, links); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", f.Path, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to write workspace files: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// writeFile renders one file
+func (w *workspace) writeFile(f File, data TemplateData, links map[string]bool) error {
+	if err := ValidFilePath(f.Path); err != nil {
+		return err
+	}
+	rel := filepath.Clean(filepath.FromSlash(f.Path))
+	if links[strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]] {
+		return fmt.Errorf("a linked folder is already named %s", strings.SplitN(filepath.ToSlash(rel), "/", 2)[0])
+	}
+
+	tmpl, err := template.New(f.Path).Funcs(templateFuncs).Option("missingkey=error").Parse(f.Template)
+	if err != nil {
+		return err
+	}
+	var content bytes.Buffer
+	if err := tmpl.Execute(&content, data); err != nil {
+		return err
+	}
+
+	path := filepath.Join(w.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content.Bytes(), 0644)
+}
+
+// templateData describes the workspace for file templates
+func (w *workspace) templateData(tagName string, folders []string) TemplateData {
+	data := TemplateData{Tag: tagName, Workspace: w.dir}
+	for i, folder := range folders {
+		if w.names[i] == "" {
+			continue
+		}
+		data.Folders = append(data.Folders, TemplateFolder{Name: w.names[i], Path: folder})
+	}
+	return data
+}
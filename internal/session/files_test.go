@@ -0,0 +1,94 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	ws := &workspace{dir: dir, names: []string{"api", "web", ""}}
+	data := ws.templateData("work", []string{"/code/api", "/code/web", "me@box:/srv/app"})
+
+	files := []File{
+		{Path: ".vscode/settings.json", Template: `{"scope.tag": "{{.Tag}}"}`},
+		{Path: ".envrc", Template: "{{range .Folders}}PATH_add {{.Name}}/bin\n{{end}}"},
+		{Path: "api/settings.json", Template: "{}"},
+		{Path: "../escape", Template: "x"},
+		{Path: "broken", Template: "{{.Missing}}"},
+	}
+	err := ws.writeFiles(files, data)
+	if err == nil {
+		t.Fatal("writeFiles should report the files it couldn't write")
+	}
+	for _, name := range []string{"api/settings.json", "../escape", "broken"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected %s in error %v", name, err)
+		}
+	}
+
+	settings, _ := os.ReadFile(filepath.Join(dir, ".vscode", "settings.json"))
+	if string(settings) != `{"scope.tag": "work"}` {
+		t.Errorf("Unexpected settings.json: %q", settings)
+	}
+	envrc, _ := os.ReadFile(filepath.Join(dir, ".envrc"))
+	if string(envrc) != "PATH_add api/bin\nPATH_add web/bin\n" {
+		t.Errorf("Unexpected .envrc: %q", envrc)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape")); !os.IsNotExist(err) {
+		t.Error("Files outside the workspace must not be written")
+	}
+}
+
+func TestWriteFilesQuoting(t *testing.T) {
+	dir := t.TempDir()
+	ws := &workspace{dir: dir, names: []string{"my api"}}
+	data := ws.templateData("it's $(rm -rf ~)", []string{"/code/my api"})
+
+	files := []File{
+		{Path: ".envrc", Template: "export SCOPE_TAG={{shquote .Tag}}\n{{range .Folders}}PATH_add {{shquote .Name}}/bin\n{{end}}"},
+		{Path: "env.fish", Template: "set -gx SCOPE_TAG {{fishquote .Tag}}\n"},
+	}
+	if err := ws.writeFiles(files, data); err != nil {
+		t.Fatalf("writeFiles failed: %v", err)
+	}
+
+	envrc, _ := os.ReadFile(filepath.Join(dir, ".envrc"))
+	if string(envrc) != "export SCOPE_TAG='it'\\''s $(rm -rf ~)'\nPATH_add 'my api'/bin\n" {
+		t.Errorf("Unexpected .envrc: %q", envrc)
+	}
+	fish, _ := os.ReadFile(filepath.Join(dir, "env.fish"))
+	if string(fish) != "set -gx SCOPE_TAG 'it\\'s $(rm -rf ~)'\n" {
+		t.Errorf("Unexpected env.fish: %q", fish)
+	}
+}
+
+func TestWriteExitDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "exit")
+	t.Setenv(ExitFileEnv, "")
+	if err := WriteExitDir("/code/api"); err != nil {
+		t.Fatalf("WriteExitDir failed: %v", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("Expected nothing written without the shell integration")
+	}
+
+	t.Setenv(ExitFileEnv, file)
+	if err := WriteExitDir("/code/api"); err != nil {
+		t.Fatalf("WriteExitDir failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "/code/api\n" {
+		t.Errorf("Unexpected exit file %q", content)
+	}
+
+	for _, s := range []string{"", "origin", "first"} {
+		if _, err := ParseExitDir(s); err != nil {
+			t.Errorf("ParseExitDir(%q) failed: %v", s, err)
+		}
+	}
+	if _, err := ParseExitDir("home"); err == nil {
+		t.Error("ParseExitDir should reject unknown values")
+	}
+}
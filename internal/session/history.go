@@ -0,0 +1,154 @@
+package session
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historyTime is the start time in a history file's name; names sort in
+// the order the sessions started
+const historyTime = "20060102-150405"
+
+// historyExt ends the name of every session history file
+const historyExt = ".history"
+
+// Recording is a session whose commands were recorded
+type Recording struct {
+	Tag     string
+	Started time.Time
+	Path    string
+}
+
+// HistoryFile returns the file in dir that a session of tagName started
+// at started records its commands in
+func HistoryFile(dir, tagName string, started time.Time) string {
+	return filepath.Join(dir, started.Format(historyTime)+"-"+url.PathEscape(tagName)+historyExt)
+}
+
+// ListRecordings returns the sessions recorded in dir, oldest first. A
+// missing dir has none.
+func ListRecordings(dir string) ([]Recording, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var recordings []Recording
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), historyExt)
+		if !ok || len(name) <= len(historyTime)+1 {
+			continue
+		}
+		started, err := time.ParseInLocation(historyTime, name[:len(historyTime)], time.Local)
+		if err != nil {
+			continue
+		}
+		tagName, err := url.PathUnescape(name[len(historyTime)+1:])
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, Recording{Tag: tagName, Started: started, Path: filepath.Join(dir, entry.Name())})
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].Started.Before(recordings[j].Started)
+	})
+	return recordings, nil
+}
+
+var (
+	// bashTimestamp is the line bash writes before a command when
+	// HISTTIMEFORMAT is set
+	bashTimestamp = regexp.MustCompile(`^#\d+$`)
+	// zshExtended is the prefix of a command in zsh's extended history
+	zshExtended = regexp.MustCompile(`^: \d+:\d+;`)
+	// fishUnsafe matches what fish doesn't allow in a history name
+	fishUnsafe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+// ReadHistory returns the commands in a history file written by bash, zsh
+// or fish, in the order they ran
+func ReadHistory(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var commands []string
+	fish := false
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "" || bashTimestamp.MatchString(line):
+		case zshExtended.MatchString(line):
+			commands = append(commands, zshExtended.ReplaceAllString(line, ""))
+		case strings.HasPrefix(line, "- cmd: ") && (fish || len(commands) == 0):
+			// fish escapes newlines and backslashes in commands
+			fish = true
+			cmd := strings.TrimPrefix(line, "- cmd: ")
+			commands = append(commands, strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(cmd))
+		case fish:
+			// fish's when: and paths: fields
+		default:
+			commands = append(commands, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return commands, nil
+}
+
+// historyEnv returns the environment that makes shell record its history
+// in file, and a function to call once the shell has exited
+func historyEnv(shell, file string) ([]string, func() error) {
+	if filepath.Base(shell) == "fish" {
+		// fish only takes a history name and keeps the file in its data
+		// directory; move it into place afterwards
+		name := fishUnsafe.ReplaceAllString("scope_"+strings.TrimSuffix(filepath.Base(file), historyExt), "_")
+		return []string{"fish_history=" + name}, func() error {
+			data := os.Getenv("XDG_DATA_HOME")
+			if data == "" {
+				home, err := os.UserHomeDir()
+				if err != nil {
+					return err
+				}
+				data = filepath.Join(home, ".local", "share")
+			}
+			err := os.Rename(filepath.Join(data, "fish", name+"_history"), file)
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	env := []string{"HISTFILE=" + file}
+	// bash writes the history on exit only; append after every command so
+	// it survives the shell being killed and shows in scope session log
+	promptCommand := "history -a"
+	if existing := os.Getenv("PROMPT_COMMAND"); existing != "" {
+		promptCommand += "; " + existing
+	}
+	env = append(env, "PROMPT_COMMAND="+promptCommand)
+	// zsh saves nothing unless SAVEHIST is set
+	if os.Getenv("SAVEHIST") == "" {
+		env = append(env, "SAVEHIST=10000")
+		if os.Getenv("HISTSIZE") == "" {
+			env = append(env, "HISTSIZE=10000")
+		}
+	}
+	return env, func() error { return nil }
+}
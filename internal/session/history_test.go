@@ -0,0 +1,71 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{"bash", "cd api\ngit pull\n", []string{"cd api", "git pull"}},
+		{"bash with timestamps", "#1700000000\ncd api\n#1700000005\nmake test\n", []string{"cd api", "make test"}},
+		{"zsh extended", ": 1700000000:0;cd api\n: 1700000003:2;git log\n", []string{"cd api", "git log"}},
+		{"fish", "- cmd: cd api\n  when: 1700000000\n- cmd: echo a\\\\nb\n  when: 1700000001\n  paths:\n    - api\n", []string{"cd api", "echo a\\nb"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "history")
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		commands, err := ReadHistory(path)
+		if err != nil {
+			t.Fatalf("%s: ReadHistory failed: %v", tt.name, err)
+		}
+		if strings.Join(commands, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("%s: got %q, expected %q", tt.name, commands, tt.expected)
+		}
+	}
+}
+
+func TestListRecordings(t *testing.T) {
+	dir := t.TempDir()
+	if recordings, err := ListRecordings(filepath.Join(dir, "missing")); err != nil || len(recordings) != 0 {
+		t.Fatalf("Expected no recordings in a missing dir, got %v, %v", recordings, err)
+	}
+
+	first := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	files := []string{
+		HistoryFile(dir, "client a/api", first.Add(time.Hour)),
+		HistoryFile(dir, "work", first),
+	}
+	for _, file := range files {
+		if err := os.WriteFile(file, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	recordings, err := ListRecordings(dir)
+	if err != nil {
+		t.Fatalf("ListRecordings failed: %v", err)
+	}
+	if len(recordings) != 2 {
+		t.Fatalf("Expected 2 recordings, got %v", recordings)
+	}
+	if recordings[0].Tag != "work" || !recordings[0].Started.Equal(first) || recordings[0].Path != files[1] {
+		t.Errorf("Unexpected first recording %+v", recordings[0])
+	}
+	if recordings[1].Tag != "client a/api" {
+		t.Errorf("Expected the tag to round trip, got %q", recordings[1].Tag)
+	}
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/git"
+)
+
+// IndexFile is the name of the overview written at the workspace root
+const IndexFile = "INDEX.md"
+
+// writeIndex writes IndexFile into the workspace, describing each link: the
+// folder it points to, the folder's tags, its git branch and its note.
+// Nothing is written if a link already uses the name.
+func (m *Manager) writeIndex(dir, tagName string, folders, names []string) error {
+	for _, name := range names {
+		if name == IndexFile {
+			return nil
+		}
+	}
+
+	folderTags, err := m.tags.ListFolderTags()
+	if err != nil {
+		return err
+	}
+	notes, err := m.tags.ListNotes()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Scope session: %s\n\n", tagName)
+	fmt.Fprintf(&b, "%d folders tagged '%s'.\n\n", len(folders), tagName)
+	b.WriteString("| Link | Path | Tags | Branch | Notes |\n")
+	b.WriteString("|------|------|------|--------|-------|\n")
+
+	for i, folder := range folders {
+		branch, err := git.Branch(folder)
+		if err != nil {
+			branch = ""
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+			cell(names[i]),
+			cell(folder),
+			cell(strings.Join(folderTags[folder], ", ")),
+			cell(branch),
+			cell(notes[folder]),
+		)
+	}
+
+	return os.WriteFile(filepath.Join(dir, IndexFile), []byte(b.String()), 0644)
+}
+
+// cell escapes s for use inside a Markdown table cell
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	return strings.ReplaceAll(s, "\n", " ")
+}
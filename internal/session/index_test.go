@@ -0,0 +1,67 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestWriteIndex(t *testing.T) {
+	_, testFolders, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, folder := range testFolders[:2] {
+		if err := tag.AddTag(folder, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := tag.AddTag(testFolders[0], "go"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := tag.SetNote(testFolders[0], "uses | pipes"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+
+	gitDir := filepath.Join(testFolders[0], ".git")
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	folders, err := tag.ListFoldersByTag("work")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	ws := &workspace{dir: dir}
+	if err := ws.populate(folders, false); err != nil {
+		t.Fatalf("populate failed: %v", err)
+	}
+
+	m := NewManager(nil)
+	if err := m.writeIndex(dir, "work", folders, ws.names); err != nil {
+		t.Fatalf("writeIndex failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	index := string(content)
+
+	expected := []string{
+		"# Scope session: work",
+		"| project1 | " + testFolders[0] + " | go, work | main | uses \\| pipes |",
+		"| project2 | " + testFolders[1] + " | work |  |  |",
+	}
+	for _, want := range expected {
+		if !strings.Contains(index, want) {
+			t.Errorf("Index missing %q:\n%s", want, index)
+		}
+	}
+}
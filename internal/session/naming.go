@@ -0,0 +1,157 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/project"
+)
+
+// linkSpec holds the pieces a folder's workspace name is built from
+type linkSpec struct {
+	// prefix is the repository name for monorepo packages
+	prefix string
+	// parents are the folder's ancestors nearest first, stopping at the
+	// repository root for packages
+	parents []string
+	base    string
+	// level is how many parents are included in the name
+	level int
+}
+
+// newLinkSpec returns the name pieces for folder. Packages inside a
+// repository (e.g. shop/services/api) are prefixed with the repository
+// name (shop-api) so the many "api" folders of a monorepo stay apart.
+// Remote folders are prefixed with their host.
+func newLinkSpec(folder string) *linkSpec {
+	if loc, ok := location.Parse(folder); ok {
+		return &linkSpec{prefix: loc.Host, parents: loc.Parents(), base: loc.Base()}
+	}
+
+	spec := &linkSpec{base: filepath.Base(folder)}
+
+	stop := ""
+	if p := project.Locate(folder); p.IsPackage() {
+		spec.prefix = filepath.Base(p.Repo)
+		stop = p.Repo
+	}
+
+	for dir := filepath.Dir(folder); dir != stop; dir = filepath.Dir(dir) {
+		name := filepath.Base(dir)
+		if name == string(filepath.Separator) || name == "." || filepath.Dir(dir) == dir {
+			break
+		}
+		spec.parents = append(spec.parents, name)
+	}
+
+	return spec
+}
+
+// canGrow reports whether another parent can be added to the name
+func (s *linkSpec) canGrow() bool {
+	return s.level < len(s.parents)
+}
+
+// name joins the pieces with sep ("-" for flat workspaces, "/" for nested)
+func (s *linkSpec) name(sep string) string {
+	parts := make([]string, 0, s.level+2)
+	if s.prefix != "" {
+		parts = append(parts, s.prefix)
+	}
+	for i := s.level - 1; i >= 0; i-- {
+		parts = append(parts, s.parents[i])
+	}
+	parts = append(parts, s.base)
+	return strings.Join(parts, sep)
+}
+
+// linkNames returns a unique workspace name for each folder, in order.
+// Names start as the folder's basename; folders whose names collide are
+// qualified with their parent directories (clientA-api, clientB-api) until
+// they differ. When nested is set, the qualifying parents become
+// directories instead (clientA/api, clientB/api).
+func linkNames(folders []string, nested bool) []string {
+	sep := "-"
+	if nested {
+		sep = "/"
+	}
+
+	specs := make([]*linkSpec, len(folders))
+	for i, folder := range folders {
+		specs[i] = newLinkSpec(folder)
+	}
+
+	names := make([]string, len(folders))
+	for {
+		for i, s := range specs {
+			names[i] = s.name(sep)
+		}
+
+		grew := false
+		for _, i := range conflicts(names, nested) {
+			if specs[i].canGrow() {
+				specs[i].level++
+				grew = true
+			}
+		}
+		if !grew {
+			break
+		}
+	}
+
+	// Folders that are still ambiguous (e.g. one path is a suffix of
+	// another) fall back to a counter
+	used := make(map[string]bool, len(names))
+	for _, name := range names {
+		used[name] = true
+	}
+	for _, i := range conflicts(names, nested) {
+		name := names[i]
+		used[name] = false
+		candidate := name
+		for counter := 1; used[candidate] || hasPrefixConflict(candidate, names, i, nested); counter++ {
+			candidate = fmt.Sprintf("%s-%d", name, counter)
+		}
+		used[candidate] = true
+		names[i] = candidate
+	}
+
+	return names
+}
+
+// conflicts returns the indices of names that clash with another name:
+// equal names, or (when nested) a name that would need to be both a link
+// and a directory holding other links
+func conflicts(names []string, nested bool) []int {
+	count := make(map[string]int, len(names))
+	for _, name := range names {
+		count[name]++
+	}
+
+	var clashing []int
+	for i, name := range names {
+		if count[name] > 1 || hasPrefixConflict(name, names, i, nested) {
+			clashing = append(clashing, i)
+		}
+	}
+	return clashing
+}
+
+// hasPrefixConflict reports whether, in a nested workspace, name is a
+// directory of another name or has another name as a directory
+func hasPrefixConflict(name string, names []string, self int, nested bool) bool {
+	if !nested {
+		return false
+	}
+	for j, other := range names {
+		if j == self {
+			continue
+		}
+		if strings.HasPrefix(other, name+"/") || strings.HasPrefix(name, other+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// makeDirs creates dirs under a temp root and returns it
+func makeDirs(t *testing.T, dirs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	return root
+}
+
+func joinAll(root string, rel ...string) []string {
+	folders := make([]string, len(rel))
+	for i, r := range rel {
+		folders[i] = filepath.Join(root, r)
+	}
+	return folders
+}
+
+func TestLinkNames(t *testing.T) {
+	root := makeDirs(t, "shop/.git", "shop/services/api", "shop/legacy/api", "shop/web", "blog/.git",
+		"clientA/api", "clientB/api", "solo")
+
+	tests := []struct {
+		name     string
+		folders  []string
+		nested   bool
+		expected []string
+	}{
+		{
+			name:     "unique basenames stay short",
+			folders:  []string{"solo", "blog"},
+			expected: []string{"solo", "blog"},
+		},
+		{
+			name:     "collisions are qualified by parent",
+			folders:  []string{"clientA/api", "clientB/api", "solo"},
+			expected: []string{"clientA-api", "clientB-api", "solo"},
+		},
+		{
+			name:     "packages are prefixed with the repository",
+			folders:  []string{"shop/services/api", "shop/web", "blog"},
+			expected: []string{"shop-api", "shop-web", "blog"},
+		},
+		{
+			name:     "colliding packages are qualified inside the repository",
+			folders:  []string{"shop/services/api", "shop/legacy/api"},
+			expected: []string{"shop-services-api", "shop-legacy-api"},
+		},
+		{
+			name:     "nested mirrors the distinguishing parents",
+			folders:  []string{"clientA/api", "clientB/api", "solo"},
+			nested:   true,
+			expected: []string{"clientA/api", "clientB/api", "solo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := linkNames(joinAll(root, tt.folders...), tt.nested)
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestLinkNamesRemote(t *testing.T) {
+	folders := []string{"me@box:/srv/api", "sftp://me@other:2222/srv/api", "me@box:/srv/web"}
+
+	names := linkNames(folders, false)
+	expected := []string{"box-api", "other-api", "box-web"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestPopulateSkipsRemoteWithoutSSHFS(t *testing.T) {
+	root := makeDirs(t, "local")
+	t.Setenv("PATH", t.TempDir())
+
+	ws := &workspace{dir: t.TempDir()}
+	if err := ws.populate([]string{filepath.Join(root, "local"), "me@box:/srv/api"}, false); err != nil {
+		t.Fatalf("populate failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(ws.names, []string{"local", ""}) {
+		t.Errorf("Expected the remote folder to be skipped, got %v", ws.names)
+	}
+	if _, err := os.Lstat(filepath.Join(ws.dir, "box-api")); !os.IsNotExist(err) {
+		t.Error("Nothing should be created for an unmounted remote folder")
+	}
+	if err := ws.cleanup(); err != nil {
+		t.Errorf("cleanup failed: %v", err)
+	}
+}
+
+func TestLinkNamesSuffixPaths(t *testing.T) {
+	root := makeDirs(t, "a/api", "x/a/api")
+
+	// a/api is a suffix of x/a/api, so qualification alone can't separate
+	// them once the shorter path runs out of parents
+	names := linkNames(joinAll(root, "a/api", "x/a/api"), false)
+	if names[0] == names[1] {
+		t.Errorf("Expected distinct names, got %v", names)
+	}
+}
+
+func TestLinkNamesNestedPrefixConflict(t *testing.T) {
+	root := makeDirs(t, "work/api", "home/work")
+
+	// Qualifying api gives work/api, which needs "work" to be a directory
+	// while home/work wants "work" to be a link
+	names := linkNames(joinAll(root, "work/api", "home/work", "other/api"), true)
+	for i, a := range names {
+		for j, b := range names {
+			if i != j && (a == b || filepath.Dir(b) == a) {
+				t.Errorf("Names %q and %q conflict: %v", a, b, names)
+			}
+		}
+	}
+}
+
+func TestPopulateWorkspaceNested(t *testing.T) {
+	root := makeDirs(t, "clientA/api", "clientB/api")
+	dir := t.TempDir()
+
+	ws := &workspace{dir: dir}
+	if err := ws.populate(joinAll(root, "clientA/api", "clientB/api"), true); err != nil {
+		t.Fatalf("populate failed: %v", err)
+	}
+
+	target, err := os.Readlink(filepath.Join(dir, "clientB", "api"))
+	if err != nil {
+		t.Fatalf("Expected nested symlink: %v", err)
+	}
+	if target != filepath.Join(root, "clientB/api") {
+		t.Errorf("Symlink points to %s", target)
+	}
+}
@@ -0,0 +1,159 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Nesting is what scope start does when run inside another session
+type Nesting string
+
+const (
+	// Nest starts a session inside the current one, named outer>inner
+	Nest Nesting = "nest"
+	// Deny refuses to start a session inside another
+	Deny Nesting = "deny"
+	// Replace ends the current session and starts the new one in its place
+	Replace Nesting = "replace"
+)
+
+// Environment variables describing the session a shell runs in
+const (
+	// SessionEnv is the session's name: its tag, or for nested sessions
+	// the tags from the outermost in, joined by >
+	SessionEnv = "SCOPE_SESSION"
+	// SessionTagEnv is the tag of the innermost session
+	SessionTagEnv = "SCOPE_SESSION_TAG"
+	// DepthEnv is 1 in a session, 2 in a session started inside it, ...
+	DepthEnv = "SCOPE_SESSION_DEPTH"
+	// WorkspaceEnv is the session's workspace directory
+	WorkspaceEnv = "SCOPE_WORKSPACE"
+	// controlEnv is the file a session started with Replace inside this
+	// one writes its arguments to
+	controlEnv = "SCOPE_SESSION_CONTROL"
+)
+
+// controlPoll is how often a session checks for a replace request
+const controlPoll = 250 * time.Millisecond
+
+// ParseNesting parses a nesting policy; empty means Nest
+func ParseNesting(s string) (Nesting, error) {
+	switch Nesting(s) {
+	case "", Nest:
+		return Nest, nil
+	case Deny, Replace:
+		return Nesting(s), nil
+	default:
+		return "", fmt.Errorf("unknown nesting policy %q (expected nest, deny or replace)", s)
+	}
+}
+
+// Current describes the session this process runs in
+type Current struct {
+	Name      string
+	Tag       string
+	Workspace string
+	Depth     int
+	control   string
+}
+
+// CurrentSession returns the session this process runs in, if any
+func CurrentSession() (Current, bool) {
+	name := os.Getenv(SessionEnv)
+	if name == "" {
+		return Current{}, false
+	}
+	depth, err := strconv.Atoi(os.Getenv(DepthEnv))
+	if err != nil || depth < 1 {
+		// Started by a scope that didn't track depth
+		depth = 1
+	}
+	tag := os.Getenv(SessionTagEnv)
+	if tag == "" {
+		tag = name
+	}
+	return Current{
+		Name:      name,
+		Tag:       tag,
+		Workspace: os.Getenv(WorkspaceEnv),
+		Depth:     depth,
+		control:   os.Getenv(controlEnv),
+	}, true
+}
+
+// Replaced is returned by StartSession when a session started inside it
+// with the Replace policy asked to take its place. Args are the arguments
+// of that scope command, to be run now that the session is over.
+type Replaced struct {
+	Args []string
+}
+
+func (r *Replaced) Error() string {
+	return "session replaced"
+}
+
+// requestReplace asks the current session to end and run args instead
+func (c Current) requestReplace(args []string) error {
+	if c.control == "" {
+		return fmt.Errorf("session '%s' can't be replaced (it was started by an older scope); exit it first", c.Name)
+	}
+	content, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.control, content, 0600)
+}
+
+// watchReplace ends the shell when a replace request shows up in the
+// control file. Stop the watch by closing done.
+func watchReplace(control string, shell *os.Process, done <-chan struct{}) {
+	ticker := time.NewTicker(controlPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if info, err := os.Stat(control); err == nil && info.Size() > 0 {
+				// Interactive shells exit on SIGHUP; where signals aren't
+				// supported, kill it
+				if shell.Signal(syscall.SIGHUP) != nil {
+					_ = shell.Kill()
+				}
+				return
+			}
+		}
+	}
+}
+
+// replaceRequest returns the request written to control, if any
+func replaceRequest(control string) *Replaced {
+	content, err := os.ReadFile(control)
+	if err != nil || len(content) == 0 {
+		return nil
+	}
+	var args []string
+	if err := json.Unmarshal(content, &args); err != nil || len(args) == 0 {
+		return nil
+	}
+	return &Replaced{Args: args}
+}
+
+// sessionEnv returns the environment of a session's shell
+func sessionEnv(tagName, workspace, control string, outer Current, nested bool) []string {
+	name, depth := tagName, 1
+	if nested {
+		name, depth = outer.Name+">"+tagName, outer.Depth+1
+	}
+	return append(os.Environ(),
+		SessionEnv+"="+name,
+		SessionTagEnv+"="+tagName,
+		DepthEnv+"="+strconv.Itoa(depth),
+		WorkspaceEnv+"="+workspace,
+		controlEnv+"="+control,
+	)
+}
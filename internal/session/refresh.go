@@ -0,0 +1,174 @@
+package session
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/location"
+)
+
+// Refreshed lists the folders a refresh linked into and removed from a
+// workspace
+type Refreshed struct {
+	Added   []string
+	Removed []string
+
+	// folders are all the folders of the workspace and names their link
+	// names, empty for those not linked
+	folders []string
+	names   []string
+}
+
+// RefreshSession updates a workspace using the default store
+func RefreshSession(dir, tagName string) (*Refreshed, error) {
+	return NewManager(nil).RefreshSession(dir, tagName)
+}
+
+// RefreshSession brings a running session's workspace up to date with the
+// tag: folders tagged since it started are linked in and links to folders
+// no longer tagged are removed. Existing links keep their names, so shells
+// inside them are undisturbed; new links follow the workspace's layout.
+// Remote folders are only mounted when a session starts.
+func (m *Manager) RefreshSession(dir, tagName string) (*Refreshed, error) {
+	// Sessions in a named workspace are named after the workspace
+	if w, err := m.GetWorkspace(tagName); err == nil && w.Dir == dir {
+		return m.SyncWorkspace(w)
+	}
+
+	folders, err := m.tags.SelectFolders(tagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return m.refresh(dir, tagName, folders)
+}
+
+// refresh links folders into the workspace at dir and removes the links
+// to any others, then rewrites its index under name
+func (m *Manager) refresh(dir, name string, folders []string) (*Refreshed, error) {
+	links, err := readLinks(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace %s: %w", dir, err)
+	}
+
+	nested := false
+	linked := make(map[string]string, len(links))
+	for name, folder := range links {
+		linked[folder] = name
+		nested = nested || strings.Contains(name, "/")
+	}
+
+	result := &Refreshed{}
+	tagged := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		tagged[folder] = true
+	}
+	for name, folder := range links {
+		if tagged[folder] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			return result, fmt.Errorf("failed to remove link %s: %w", name, err)
+		}
+		removeEmptyParents(dir, name)
+		result.Removed = append(result.Removed, folder)
+	}
+
+	names := linkNames(folders, nested)
+	for i, folder := range folders {
+		if name, ok := linked[folder]; ok {
+			names[i] = name
+			continue
+		}
+		if location.IsRemote(folder) {
+			// Mounted when the session started, or skipped then
+			if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(names[i]))); err != nil {
+				names[i] = ""
+			}
+			continue
+		}
+
+		linkPath := filepath.Join(dir, filepath.FromSlash(names[i]))
+		if _, err := os.Lstat(linkPath); err == nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s (%s is taken; restart the session to link it)\n", folder, names[i])
+			names[i] = ""
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+			return result, fmt.Errorf("failed to create directory for %s: %w", folder, err)
+		}
+		if err := os.Symlink(folder, linkPath); err != nil {
+			return result, fmt.Errorf("failed to create symlink for %s: %w", folder, err)
+		}
+		result.Added = append(result.Added, folder)
+	}
+
+	if err := m.writeIndex(dir, name, folders, names); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write %s: %v\n", IndexFile, err)
+	}
+	result.folders, result.names = folders, names
+	return result, nil
+}
+
+// readLinks returns the symlinks in a workspace, by slash-separated name,
+// with the folders they point to. Directories that aren't part of a
+// nested layout (generated files, remote mounts) are not descended into.
+func readLinks(dir string) (map[string]string, error) {
+	links := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || isMountPoint(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		links[filepath.ToSlash(rel)] = target
+		return nil
+	})
+	return links, err
+}
+
+// isMountPoint reports whether path is on a different device than its
+// parent, as sshfs mounts are. Where that can't be told, it isn't.
+func isMountPoint(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	parent, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	dev, ok := deviceOf(info)
+	parentDev, parentOk := deviceOf(parent)
+	return ok && parentOk && dev != parentDev
+}
+
+// removeEmptyParents removes the directories a nested link was in once
+// they are empty
+func removeEmptyParents(dir, name string) {
+	for parent := filepath.Dir(filepath.FromSlash(name)); parent != "."; parent = filepath.Dir(parent) {
+		if os.Remove(filepath.Join(dir, parent)) != nil {
+			return
+		}
+	}
+}
@@ -1,19 +1,65 @@
 package session
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"syscall"
 
+	"github.com/gabssanto/Scope/internal/db"
 	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/ui"
 )
 
+// Manager starts sessions from the folders in a Store
+type Manager struct {
+	store *db.Store
+	tags  *tag.Manager
+}
+
+// NewManager returns a Manager for store. A nil store uses the default
+// store opened by db.InitDB.
+func NewManager(store *db.Store) *Manager {
+	return &Manager{store: store, tags: tag.NewManager(store)}
+}
+
+// Options control how a session workspace is laid out
+type Options struct {
+	// Nested mirrors the distinguishing parent directories of colliding
+	// folders as real directories (clientA/api) instead of flattening them
+	// into the link name (clientA-api)
+	Nested bool
+
+	// Files are generated at the workspace root, e.g. shared editor
+	// settings for every folder in the session
+	Files []File
+
+	// OnStart, if set, is called with the workspace and the folders in it
+	// once the workspace is ready, before the shell starts
+	OnStart func(workspace string, folders []string)
+
+	// Nesting is what to do when started inside another session
+	Nesting Nesting
+
+	// HistoryFile, if set, is where the shell records the commands run in
+	// the session
+	HistoryFile string
+
+	// Args are the arguments scope was started with, which the outer
+	// session runs in place of itself when Nesting is Replace
+	Args []string
+}
+
+// StartSession creates a temporary workspace using the default store
+func StartSession(tagName string, opts Options) error {
+	return NewManager(nil).StartSession(tagName, opts)
+}
+
 // StartSession creates a temporary workspace with symlinks and spawns a shell
-func StartSession(tagName string) error {
+func (m *Manager) StartSession(tagName string, opts Options) error {
 	// Get all folders for the tag
-	folders, err := tag.ListFoldersByTag(tagName)
+	folders, err := m.tags.SelectFolders(tagName)
 	if err != nil {
 		return fmt.Errorf("failed to list folders: %w", err)
 	}
@@ -22,87 +68,151 @@ func StartSession(tagName string) error {
 		return fmt.Errorf("no folders found with tag: %s", tagName)
 	}
 
+	outer, nested, done, err := enter(tagName, opts)
+	if done || err != nil {
+		return err
+	}
+
 	// Create temp directory
 	tempDir, err := os.MkdirTemp("", fmt.Sprintf("scope-%s-", tagName))
 	if err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
 
+	ws := &workspace{dir: tempDir}
+
 	// Cleanup temp directory on exit
 	defer func() {
-		if err := os.RemoveAll(tempDir); err != nil {
+		if err := ws.cleanup(); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to cleanup temp directory %s: %v\n", tempDir, err)
 		}
 	}()
 
 	// Create symlinks for all folders
-	for _, folder := range folders {
-		// Use the basename of the folder as the symlink name
-		linkName := filepath.Base(folder)
-		linkPath := filepath.Join(tempDir, linkName)
-
-		// Handle name conflicts by appending a number
-		counter := 1
-		originalLinkPath := linkPath
-		for {
-			_, err := os.Lstat(linkPath)
-			if os.IsNotExist(err) {
-				break
-			}
-			linkPath = fmt.Sprintf("%s-%d", originalLinkPath, counter)
-			counter++
-		}
+	if err := ws.populate(folders, opts.Nested); err != nil {
+		return err
+	}
 
-		// Create symlink
-		if err := os.Symlink(folder, linkPath); err != nil {
-			return fmt.Errorf("failed to create symlink for %s: %w", folder, err)
+	// The index is a convenience; a session without one is still usable
+	if err := m.writeIndex(tempDir, tagName, folders, ws.names); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write %s: %v\n", IndexFile, err)
+	}
+	if err := ws.writeFiles(opts.Files, ws.templateData(tagName, folders)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if opts.OnStart != nil {
+		opts.OnStart(tempDir, folders)
+	}
+
+	ui.Infof("Scope session started with tag '%s'\n", tagName)
+	ui.Infof("Workspace: %s\n", tempDir)
+	ui.Infof("Folders: %d\n\n", len(folders))
+	if ui.Mode() == ui.Default {
+		// In CI the shell runs the commands piped to scope start and exits
+		ui.Infoln("Running the shell on standard input (no terminal)")
+	} else {
+		ui.Infoln("Type 'exit' to leave the scoped session")
+	}
+	ui.Infoln("---")
+
+	if err := runShell(tempDir, tagName, opts, outer, nested); err != nil {
+		var replaced *Replaced
+		if errors.As(err, &replaced) {
+			ui.Infoln("\nScope session replaced. Workspace cleaned up.")
 		}
+		// Cleanup happens here via defer before we potentially exit
+		return err
 	}
 
-	fmt.Printf("Scope session started with tag '%s'\n", tagName)
-	fmt.Printf("Workspace: %s\n", tempDir)
-	fmt.Printf("Folders: %d\n\n", len(folders))
-	fmt.Println("Type 'exit' to leave the scoped session")
-	fmt.Println("---")
+	ui.Infoln("\nScope session ended. Workspace cleaned up.")
+	return nil
+}
+
+// enter applies opts.Nesting when a session named name starts inside
+// another. done is set when there is nothing left to do, because the outer
+// session was asked to replace itself.
+func enter(name string, opts Options) (outer Current, nested, done bool, err error) {
+	outer, nested = CurrentSession()
+	if !nested {
+		return outer, false, false, nil
+	}
+	switch opts.Nesting {
+	case Deny:
+		return outer, true, true, fmt.Errorf("already in scope session '%s'; exit it first", outer.Name)
+	case Replace:
+		if err := outer.requestReplace(opts.Args); err != nil {
+			return outer, true, true, err
+		}
+		ui.Infof("Ending scope session '%s' to start '%s' in its place\n", outer.Name, name)
+		return outer, true, true, nil
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: already in scope session '%s'; starting '%s' inside it\n", outer.Name, name)
+		return outer, true, false, nil
+	}
+}
 
+// runShell runs the user's shell in dir as the session name until it
+// exits. It returns a *Replaced when a session started inside asked to
+// take its place; the shell's own exit status is not an error.
+func runShell(dir, name string, opts Options, outer Current, nested bool) error {
 	// Get user's shell
 	shell := os.Getenv("SHELL")
 	if shell == "" {
 		shell = "/bin/bash"
 	}
 
-	// Spawn shell in the temp directory
+	// Spawn shell in the workspace
 	cmd := exec.Command(shell)
-	cmd.Dir = tempDir
+	cmd.Dir = dir
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
+	// Sessions started inside this one with the replace policy ask for
+	// it through the control file
+	control, err := os.CreateTemp("", "scope-control-")
+	if err != nil {
+		return fmt.Errorf("failed to create control file: %w", err)
+	}
+	_ = control.Close()
+	defer func() { _ = os.Remove(control.Name()) }()
+
 	// Set environment variables
-	cmd.Env = append(os.Environ(),
-		fmt.Sprintf("SCOPE_SESSION=%s", tagName),
-		fmt.Sprintf("SCOPE_WORKSPACE=%s", tempDir),
-	)
+	cmd.Env = sessionEnv(name, dir, control.Name(), outer, nested)
+	saveHistory := func() error { return nil }
+	if opts.HistoryFile != "" {
+		var env []string
+		env, saveHistory = historyEnv(shell, opts.HistoryFile)
+		cmd.Env = append(cmd.Env, env...)
+	}
 
 	// Run the shell
-	shellErr := cmd.Run()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to run shell: %w", err)
+	}
+	done := make(chan struct{})
+	go watchReplace(control.Name(), cmd.Process, done)
+	shellErr := cmd.Wait()
+	close(done)
+	if err := saveHistory(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save session history: %v\n", err)
+	}
 
-	// Cleanup happens here via defer before we potentially exit
+	if replaced := replaceRequest(control.Name()); replaced != nil {
+		return replaced
+	}
 
 	if shellErr != nil {
 		// Check if it's an exit status error (user exited shell with non-zero)
 		if exitErr, ok := shellErr.(*exec.ExitError); ok {
 			if _, ok := exitErr.Sys().(syscall.WaitStatus); ok {
-				// Return nil - the shell exited normally (possibly with non-zero)
-				// The defer cleanup will run, then main() will exit with 0
-				// We don't propagate shell exit codes as errors
-				fmt.Println("\nScope session ended. Workspace cleaned up.")
+				// The shell exited normally (possibly with non-zero); we
+				// don't propagate shell exit codes as errors
 				return nil
 			}
 		}
 		return fmt.Errorf("failed to run shell: %w", shellErr)
 	}
-
-	fmt.Println("\nScope session ended. Workspace cleaned up.")
 	return nil
 }
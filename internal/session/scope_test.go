@@ -19,6 +19,11 @@ func setupTestEnv(t *testing.T) (string, []string, func()) {
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
 	}
+	// Tagged paths are stored with symlinks resolved
+	tmpDir, err = filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
 
 	// Create test folders
 	testFolders := []string{
@@ -62,7 +67,7 @@ func TestStartSessionNoFolders(t *testing.T) {
 	defer cleanup()
 
 	// Try to start session with tag that has no folders
-	err := StartSession("nonexistent")
+	err := StartSession("nonexistent", Options{})
 	if err == nil {
 		t.Error("StartSession should fail when no folders have the tag")
 	}
@@ -366,3 +371,130 @@ func BenchmarkSymlinkCreation(b *testing.B) {
 		os.RemoveAll(tempDir)
 	}
 }
+
+func TestNestedSessionEnv(t *testing.T) {
+	t.Setenv(SessionEnv, "")
+	if _, ok := CurrentSession(); ok {
+		t.Fatal("Expected no current session")
+	}
+
+	lookup := func(env []string, key string) string {
+		value := ""
+		for _, kv := range env {
+			if strings.HasPrefix(kv, key+"=") {
+				value = strings.TrimPrefix(kv, key+"=") // the last one wins
+			}
+		}
+		return value
+	}
+
+	env := sessionEnv("work", "/tmp/scope-work", "/tmp/control", Current{}, false)
+	for _, k := range []string{SessionEnv, SessionTagEnv, DepthEnv} {
+		t.Setenv(k, lookup(env, k))
+	}
+	outer, ok := CurrentSession()
+	if !ok || outer.Name != "work" || outer.Depth != 1 {
+		t.Fatalf("Unexpected outer session %+v", outer)
+	}
+
+	env = sessionEnv("api", "/tmp/scope-api", "/tmp/control2", outer, true)
+	if got := lookup(env, SessionEnv); got != "work>api" {
+		t.Errorf("Expected composed name work>api, got %q", got)
+	}
+	if got := lookup(env, SessionTagEnv); got != "api" {
+		t.Errorf("Expected tag api, got %q", got)
+	}
+	if got := lookup(env, DepthEnv); got != "2" {
+		t.Errorf("Expected depth 2, got %q", got)
+	}
+}
+
+func TestReplaceRequest(t *testing.T) {
+	control := filepath.Join(t.TempDir(), "control")
+	if err := os.WriteFile(control, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if replaceRequest(control) != nil {
+		t.Error("Expected no request in an empty control file")
+	}
+
+	current := Current{Name: "work", Depth: 1, control: control}
+	if err := current.requestReplace([]string{"start", "api"}); err != nil {
+		t.Fatalf("requestReplace failed: %v", err)
+	}
+	replaced := replaceRequest(control)
+	if replaced == nil || strings.Join(replaced.Args, " ") != "start api" {
+		t.Errorf("Unexpected request %+v", replaced)
+	}
+
+	if err := (Current{Name: "old"}).requestReplace([]string{"start", "api"}); err == nil {
+		t.Error("Expected an error for a session without a control file")
+	}
+
+	for _, s := range []string{"", "nest", "deny", "replace"} {
+		if _, err := ParseNesting(s); err != nil {
+			t.Errorf("ParseNesting(%q) failed: %v", s, err)
+		}
+	}
+	if _, err := ParseNesting("stack"); err == nil {
+		t.Error("ParseNesting should reject unknown values")
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	_, testFolders, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, folder := range testFolders[:2] {
+		if err := tag.AddTag(folder, "refresh"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	ws := &workspace{dir: t.TempDir()}
+	if err := ws.populate(testFolders[:2], false); err != nil {
+		t.Fatalf("populate failed: %v", err)
+	}
+
+	if err := tag.RemoveTag(testFolders[0], "refresh"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if err := tag.AddTag(testFolders[2], "refresh"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	refreshed, err := RefreshSession(ws.dir, "refresh")
+	if err != nil {
+		t.Fatalf("RefreshSession failed: %v", err)
+	}
+	if len(refreshed.Added) != 1 || refreshed.Added[0] != testFolders[2] {
+		t.Errorf("Expected %s added, got %v", testFolders[2], refreshed.Added)
+	}
+	if len(refreshed.Removed) != 1 || refreshed.Removed[0] != testFolders[0] {
+		t.Errorf("Expected %s removed, got %v", testFolders[0], refreshed.Removed)
+	}
+
+	links, err := readLinks(ws.dir)
+	if err != nil {
+		t.Fatalf("readLinks failed: %v", err)
+	}
+	expected := map[string]string{"project2": testFolders[1], "project3": testFolders[2]}
+	if len(links) != len(expected) {
+		t.Errorf("Expected links %v, got %v", expected, links)
+	}
+	for name, folder := range expected {
+		if links[name] != folder {
+			t.Errorf("Expected %s -> %s, got %q", name, folder, links[name])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(ws.dir, IndexFile)); err != nil {
+		t.Errorf("Expected %s to be written: %v", IndexFile, err)
+	}
+
+	refreshed, err = RefreshSession(ws.dir, "refresh")
+	if err != nil {
+		t.Fatalf("RefreshSession failed: %v", err)
+	}
+	if len(refreshed.Added)+len(refreshed.Removed) != 0 {
+		t.Errorf("Expected no changes on a second refresh, got %+v", refreshed)
+	}
+}
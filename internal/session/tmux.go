@@ -0,0 +1,83 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// TmuxSessionName returns the tmux session a tag opens in. tmux reserves
+// "." and ":" in target names, so they are replaced.
+func TmuxSessionName(tagName string) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(tagName)
+}
+
+// StartTmux opens the tag's folders in tmux using the default store
+func StartTmux(tagName string) error {
+	return NewManager(nil).StartTmux(tagName)
+}
+
+// StartTmux opens a tmux session named after the tag with one window per
+// folder, or attaches to it if it already exists. Inside tmux it switches
+// the client to the session instead of nesting tmux. Remote folders get a
+// window with a shell on their host.
+func (m *Manager) StartTmux(tagName string) error {
+	tmux, err := exec.LookPath("tmux")
+	if err != nil {
+		return fmt.Errorf("tmux not found on PATH")
+	}
+	name := TmuxSessionName(tagName)
+
+	if exec.Command(tmux, "has-session", "-t", "="+name).Run() != nil {
+		folders, err := m.tags.SelectFolders(tagName)
+		if err != nil {
+			return fmt.Errorf("failed to list folders: %w", err)
+		}
+		if len(folders) == 0 {
+			return fmt.Errorf("no folders found with tag: %s", tagName)
+		}
+
+		for _, args := range tmuxCommands(name, folders) {
+			if out, err := exec.Command(tmux, args...).CombinedOutput(); err != nil {
+				return fmt.Errorf("tmux %s failed: %s", args[0], strings.TrimSpace(string(out)))
+			}
+		}
+		ui.Infof("Started tmux session '%s' with %d windows\n", name, len(folders))
+	}
+
+	args := []string{"attach-session", "-t", "=" + name}
+	if os.Getenv("TMUX") != "" {
+		args = []string{"switch-client", "-t", "=" + name}
+	}
+	cmd := exec.Command(tmux, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// tmuxCommands returns the tmux commands creating session with a window
+// per folder, named like the folder's workspace link
+func tmuxCommands(session string, folders []string) [][]string {
+	names := linkNames(folders, false)
+	commands := make([][]string, 0, len(folders)+1)
+	for i, folder := range folders {
+		var args []string
+		if i == 0 {
+			args = []string{"new-session", "-d", "-s", session, "-n", names[i]}
+		} else {
+			args = []string{"new-window", "-t", "=" + session + ":", "-n", names[i]}
+		}
+		if loc, ok := location.Parse(folder); ok {
+			args = append(args, loc.ShellCommand())
+		} else {
+			args = append(args, "-c", folder)
+		}
+		commands = append(commands, args)
+	}
+	return append(commands, []string{"select-window", "-t", "=" + session + ":^"})
+}
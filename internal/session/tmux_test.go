@@ -0,0 +1,33 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTmuxSessionName(t *testing.T) {
+	tests := map[string]string{
+		"work":        "work",
+		"client.acme": "client_acme",
+		"team:api":    "team_api",
+	}
+	for tag, expected := range tests {
+		if got := TmuxSessionName(tag); got != expected {
+			t.Errorf("TmuxSessionName(%q) = %q, want %q", tag, got, expected)
+		}
+	}
+}
+
+func TestTmuxCommands(t *testing.T) {
+	folders := []string{"/code/clientA/api", "/code/clientB/api", "deploy@prod:/srv/app"}
+
+	expected := [][]string{
+		{"new-session", "-d", "-s", "work", "-n", "clientA-api", "-c", "/code/clientA/api"},
+		{"new-window", "-t", "=work:", "-n", "clientB-api", "-c", "/code/clientB/api"},
+		{"new-window", "-t", "=work:", "-n", "prod-app", "ssh -t deploy@prod 'cd /srv/app && exec $SHELL -l'"},
+		{"select-window", "-t", "=work:^"},
+	}
+	if got := tmuxCommands("work", folders); !reflect.DeepEqual(got, expected) {
+		t.Errorf("tmuxCommands =\n%q\nwant\n%q", got, expected)
+	}
+}
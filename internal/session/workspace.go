@@ -0,0 +1,83 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gabssanto/Scope/internal/location"
+)
+
+// workspace is the temporary directory a session runs in
+type workspace struct {
+	dir string
+	// names are the link names, in folder order; empty for remote folders
+	// that could not be mounted
+	names []string
+	// mounts are the sshfs mount points to unmount before removing dir
+	mounts []string
+}
+
+// populate creates a symlink in the workspace for every folder. Remote ssh
+// folders are mounted with sshfs when it is installed; other non-local
+// folders are skipped with a warning.
+func (w *workspace) populate(folders []string, nested bool) error {
+	w.names = linkNames(folders, nested)
+	for i, name := range w.names {
+		folder := folders[i]
+		linkPath := filepath.Join(w.dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", folder, err)
+		}
+
+		if loc, ok := location.Parse(folder); ok {
+			if !w.mount(loc, linkPath) {
+				w.names[i] = ""
+			}
+			continue
+		}
+
+		if err := os.Symlink(folder, linkPath); err != nil {
+			return fmt.Errorf("failed to create symlink for %s: %w", folder, err)
+		}
+	}
+	return nil
+}
+
+// mount mounts a remote folder at linkPath, reporting whether it did
+func (w *workspace) mount(loc *location.Location, linkPath string) bool {
+	if !loc.CanMount() {
+		fmt.Fprintf(os.Stderr, "Warning: skipping %s folder %s (it cannot be mounted)\n", loc.Kind, loc)
+		return false
+	}
+	if !location.SSHFSAvailable() {
+		fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s (install sshfs to mount it)\n", loc)
+		return false
+	}
+
+	if err := os.Mkdir(linkPath, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s: %v\n", loc, err)
+		return false
+	}
+	if err := loc.Mount(linkPath); err != nil {
+		_ = os.Remove(linkPath)
+		fmt.Fprintf(os.Stderr, "Warning: skipping remote folder %s: %v\n", loc, err)
+		return false
+	}
+
+	w.mounts = append(w.mounts, linkPath)
+	return true
+}
+
+// cleanup unmounts remote folders and removes the workspace. If anything
+// stays mounted the directory is left in place, since removing it would
+// delete the remote files.
+func (w *workspace) cleanup() error {
+	for _, mount := range w.mounts {
+		if err := location.Unmount(mount); err != nil {
+			return fmt.Errorf("%w; leaving %s in place", err, w.dir)
+		}
+	}
+	return os.RemoveAll(w.dir)
+}
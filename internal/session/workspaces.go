@@ -0,0 +1,318 @@
+package session
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// Workspace is a named, persistent workspace: a directory of links to the
+// folders of its tags that is kept between sessions, so editors and other
+// tools can refer to it
+type Workspace struct {
+	Name string
+	// Tags are the tags (or tag expressions) whose folders it links, in
+	// the order given
+	Tags []string
+	Dir  string
+}
+
+// Label returns the workspace's tags as one tag expression, for INDEX.md
+func (w *Workspace) Label() string {
+	return strings.Join(w.Tags, " | ")
+}
+
+// ErrWorkspaceNotFound is returned for a workspace name that isn't defined
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// WorkspacesDir returns where named workspaces are kept:
+// $XDG_DATA_HOME/scope/workspaces, by default ~/.local/share/scope/workspaces
+func WorkspacesDir() (string, error) {
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "scope", "workspaces"), nil
+}
+
+// validWorkspaceName returns an error unless name can be a directory name
+func validWorkspaceName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid workspace name %q", name)
+	}
+	return nil
+}
+
+// storeOrDefault resolves the store the Manager operates on
+func (m *Manager) storeOrDefault() (*db.Store, error) {
+	store := m.store
+	if store == nil {
+		store = db.Default()
+	}
+	if store == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return store, nil
+}
+
+// CreateWorkspace defines a workspace using the default store
+func CreateWorkspace(name string, tags []string) (*Workspace, *Refreshed, error) {
+	return NewManager(nil).CreateWorkspace(name, tags)
+}
+
+// CreateWorkspace defines a workspace holding the folders of tags and links
+// them into its directory. Redefining an existing workspace replaces its
+// tags and brings the directory up to date.
+func (m *Manager) CreateWorkspace(name string, tags []string) (*Workspace, *Refreshed, error) {
+	if err := validWorkspaceName(name); err != nil {
+		return nil, nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil, fmt.Errorf("a workspace needs at least one tag")
+	}
+	store, err := m.storeOrDefault()
+	if err != nil {
+		return nil, nil, err
+	}
+	if store.ReadOnly() {
+		return nil, nil, db.ErrReadOnly
+	}
+
+	now := time.Now().Unix()
+	err = db.WithTx(store.DB(), func(tx *sql.Tx) error {
+		var id int64
+		err := tx.QueryRow("SELECT id FROM workspaces WHERE name = ?", name).Scan(&id)
+		if err == sql.ErrNoRows {
+			result, err := tx.Exec("INSERT INTO workspaces (name, created_at) VALUES (?, ?)", name, now)
+			if err != nil {
+				return fmt.Errorf("failed to insert workspace: %w", err)
+			}
+			if id, err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get workspace ID: %w", err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("failed to query workspace: %w", err)
+		}
+
+		if _, err := tx.Exec("DELETE FROM workspace_tags WHERE workspace_id = ?", id); err != nil {
+			return fmt.Errorf("failed to replace workspace tags: %w", err)
+		}
+		for i, tagName := range tags {
+			if _, err := tx.Exec("INSERT OR IGNORE INTO workspace_tags (workspace_id, tag, position) VALUES (?, ?, ?)",
+				id, tagName, i); err != nil {
+				return fmt.Errorf("failed to insert workspace tag: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w, err := m.GetWorkspace(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	refreshed, err := m.SyncWorkspace(w)
+	return w, refreshed, err
+}
+
+// GetWorkspace returns a workspace using the default store
+func GetWorkspace(name string) (*Workspace, error) {
+	return NewManager(nil).GetWorkspace(name)
+}
+
+// GetWorkspace returns the workspace called name, or ErrWorkspaceNotFound
+func (m *Manager) GetWorkspace(name string) (*Workspace, error) {
+	workspaces, err := m.listWorkspaces(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, name)
+	}
+	return workspaces[0], nil
+}
+
+// ListWorkspaces returns the workspaces using the default store
+func ListWorkspaces() ([]*Workspace, error) {
+	return NewManager(nil).ListWorkspaces()
+}
+
+// ListWorkspaces returns every workspace, sorted by name
+func (m *Manager) ListWorkspaces() ([]*Workspace, error) {
+	return m.listWorkspaces("")
+}
+
+// listWorkspaces returns the workspace called name, or all of them when
+// name is empty
+func (m *Manager) listWorkspaces(name string) ([]*Workspace, error) {
+	store, err := m.storeOrDefault()
+	if err != nil {
+		return nil, err
+	}
+	root, err := WorkspacesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.ReadDB().Query(`
+		SELECT w.name, wt.tag
+		FROM workspaces w
+		JOIN workspace_tags wt ON wt.workspace_id = w.id
+		WHERE ? = '' OR w.name = ?
+		ORDER BY w.name, wt.position
+	`, name, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var workspaces []*Workspace
+	for rows.Next() {
+		var wsName, tagName string
+		if err := rows.Scan(&wsName, &tagName); err != nil {
+			return nil, fmt.Errorf("failed to scan workspace: %w", err)
+		}
+		if n := len(workspaces); n == 0 || workspaces[n-1].Name != wsName {
+			workspaces = append(workspaces, &Workspace{Name: wsName, Dir: filepath.Join(root, wsName)})
+		}
+		w := workspaces[len(workspaces)-1]
+		w.Tags = append(w.Tags, tagName)
+	}
+	return workspaces, rows.Err()
+}
+
+// DeleteWorkspace removes a workspace using the default store
+func DeleteWorkspace(name string) error {
+	return NewManager(nil).DeleteWorkspace(name)
+}
+
+// DeleteWorkspace forgets a workspace and removes its directory: the links
+// and INDEX.md, never the folders they point to. The directory is left in
+// place if anything else was put in it.
+func (m *Manager) DeleteWorkspace(name string) error {
+	w, err := m.GetWorkspace(name)
+	if err != nil {
+		return err
+	}
+	store, err := m.storeOrDefault()
+	if err != nil {
+		return err
+	}
+	if store.ReadOnly() {
+		return db.ErrReadOnly
+	}
+
+	if _, err := m.refresh(w.Dir, w.Label(), nil); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	_ = os.Remove(filepath.Join(w.Dir, IndexFile))
+	if err := os.Remove(w.Dir); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: leaving %s in place: %v\n", w.Dir, err)
+	}
+
+	if _, err := store.DB().Exec("DELETE FROM workspaces WHERE name = ?", name); err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+	return nil
+}
+
+// folders returns the folders of the workspace's tags, each once, in tag
+// order
+func (m *Manager) folders(w *Workspace) ([]string, error) {
+	var folders []string
+	seen := make(map[string]bool)
+	for _, tagName := range w.Tags {
+		tagged, err := m.tags.SelectFolders(tagName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list folders: %w", err)
+		}
+		for _, folder := range tagged {
+			if !seen[folder] {
+				seen[folder] = true
+				folders = append(folders, folder)
+			}
+		}
+	}
+	return folders, nil
+}
+
+// SyncWorkspace brings a workspace's directory up to date with its tags,
+// creating it if needed. Existing links keep their names, so paths an
+// editor has open stay valid. Remote folders are not linked, since a
+// persistent workspace can't keep them mounted.
+func (m *Manager) SyncWorkspace(w *Workspace) (*Refreshed, error) {
+	folders, err := m.folders(w)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(w.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	return m.refresh(w.Dir, w.Label(), folders)
+}
+
+// OpenWorkspace opens a workspace using the default store
+func OpenWorkspace(name string, opts Options) error {
+	return NewManager(nil).OpenWorkspace(name, opts)
+}
+
+// OpenWorkspace brings the workspace up to date and spawns a shell in it,
+// as a session named after the workspace. Unlike a session started with
+// StartSession, the directory stays when the shell exits.
+func (m *Manager) OpenWorkspace(name string, opts Options) error {
+	w, err := m.GetWorkspace(name)
+	if err != nil {
+		return err
+	}
+
+	outer, nested, done, err := enter(w.Name, opts)
+	if done || err != nil {
+		return err
+	}
+
+	refreshed, err := m.SyncWorkspace(w)
+	if err != nil {
+		return err
+	}
+	for _, folder := range refreshed.Added {
+		ui.Infof("%s %s\n", ui.Color("green", "+"), folder)
+	}
+	for _, folder := range refreshed.Removed {
+		ui.Infof("%s %s\n", ui.Color("red", "-"), folder)
+	}
+
+	folders := refreshed.folders
+	ws := &workspace{dir: w.Dir, names: refreshed.names}
+	if err := ws.writeFiles(opts.Files, ws.templateData(w.Label(), folders)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if opts.OnStart != nil {
+		opts.OnStart(w.Dir, folders)
+	}
+
+	ui.Infof("Opened workspace '%s' (%s)\n", w.Name, w.Label())
+	ui.Infof("Workspace: %s\n", w.Dir)
+	ui.Infof("Folders: %d\n\n", len(folders))
+	ui.Infoln("Type 'exit' to leave the workspace")
+	ui.Infoln("---")
+
+	if err := runShell(w.Dir, w.Name, opts, outer, nested); err != nil {
+		return err
+	}
+	ui.Infoln("\nLeft workspace. It stays in place for next time.")
+	return nil
+}
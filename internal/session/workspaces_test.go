@@ -0,0 +1,88 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+func TestWorkspaceLifecycle(t *testing.T) {
+	tmpDir, folders, cleanup := setupTestEnv(t)
+	defer cleanup()
+	t.Setenv("XDG_DATA_HOME", filepath.Join(tmpDir, "data"))
+
+	tagFolder := func(folder, tagName string) {
+		t.Helper()
+		if err := tag.AddTag(folder, tagName); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	tagFolder(folders[0], "work")
+	tagFolder(folders[1], "work")
+	tagFolder(folders[1], "api")
+	tagFolder(folders[2], "oss")
+
+	w, refreshed, err := CreateWorkspace("day-job", []string{"work", "api"})
+	if err != nil {
+		t.Fatalf("CreateWorkspace failed: %v", err)
+	}
+	if w.Dir != filepath.Join(tmpDir, "data", "scope", "workspaces", "day-job") {
+		t.Errorf("Unexpected workspace directory %s", w.Dir)
+	}
+	// Folders with several of the tags are linked once
+	if !reflect.DeepEqual(refreshed.Added, folders[:2]) {
+		t.Errorf("Expected %v linked, got %v", folders[:2], refreshed.Added)
+	}
+	if target, err := os.Readlink(filepath.Join(w.Dir, "project1")); err != nil || target != folders[0] {
+		t.Errorf("Expected project1 linked to %s, got %q %v", folders[0], target, err)
+	}
+
+	// Redefining replaces the tags and updates the links in place
+	_, refreshed, err = CreateWorkspace("day-job", []string{"api", "oss"})
+	if err != nil {
+		t.Fatalf("CreateWorkspace failed: %v", err)
+	}
+	if !reflect.DeepEqual(refreshed.Added, folders[2:]) || !reflect.DeepEqual(refreshed.Removed, folders[:1]) {
+		t.Errorf("Expected %v added and %v removed, got %+v", folders[2:], folders[:1], refreshed)
+	}
+
+	workspaces, err := ListWorkspaces()
+	if err != nil {
+		t.Fatalf("ListWorkspaces failed: %v", err)
+	}
+	if len(workspaces) != 1 || !reflect.DeepEqual(workspaces[0].Tags, []string{"api", "oss"}) {
+		t.Errorf("Unexpected workspaces %+v", workspaces)
+	}
+
+	if err := DeleteWorkspace("day-job"); err != nil {
+		t.Fatalf("DeleteWorkspace failed: %v", err)
+	}
+	if _, err := os.Stat(w.Dir); !os.IsNotExist(err) {
+		t.Errorf("Expected %s removed, got %v", w.Dir, err)
+	}
+	// The folders themselves are untouched
+	if _, err := os.Stat(filepath.Join(folders[1], "README.md")); err != nil {
+		t.Errorf("Expected the folder's files kept: %v", err)
+	}
+	if _, err := GetWorkspace("day-job"); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Errorf("Expected ErrWorkspaceNotFound, got %v", err)
+	}
+}
+
+func TestCreateWorkspaceInvalid(t *testing.T) {
+	_, _, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, name := range []string{"", "..", "a/b", "-x"} {
+		if _, _, err := CreateWorkspace(name, []string{"work"}); err == nil {
+			t.Errorf("CreateWorkspace(%q) should fail", name)
+		}
+	}
+	if _, _, err := CreateWorkspace("empty", nil); err == nil {
+		t.Error("CreateWorkspace should fail without tags")
+	}
+}
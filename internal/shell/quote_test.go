@@ -0,0 +1,128 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// hostile are names a tag or folder could have that break naive quoting
+var hostile = []string{
+	"simple/path",
+	"with space",
+	"it's",
+	`say "hi"`,
+	`back\slash`,
+	`\'`,
+	"$(touch pwned)",
+	"`id`",
+	"semi;colon && more",
+	"glob*?[a]",
+	"tab\there",
+	"new\nline",
+	"~user",
+	"-dash",
+	"ünïcode 名前",
+	"",
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"simple/path": "simple/path",
+		"with space":  "'with space'",
+		"it's":        `'it'\''s'`,
+		"":            "''",
+	}
+	for input, expected := range tests {
+		if got := Quote(input); got != expected {
+			t.Errorf("Quote(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+func TestQuoteFish(t *testing.T) {
+	tests := map[string]string{
+		"simple/path": "simple/path",
+		"with space":  "'with space'",
+		"it's":        `'it\'s'`,
+		`a\b`:         `'a\\b'`,
+		"":            "''",
+	}
+	for input, expected := range tests {
+		if got := QuoteFish(input); got != expected {
+			t.Errorf("QuoteFish(%q) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+// roundTrip has the shell print each quoted name back, so the test fails
+// if any name is split, expanded or run
+func roundTrip(t *testing.T, shell string, quote func(string) string) {
+	t.Helper()
+	if _, err := exec.LookPath(shell); err != nil {
+		t.Skipf("%s not installed", shell)
+	}
+	for _, name := range hostile {
+		output, err := exec.Command(shell, "-c", "printf '%s' "+quote(name)).Output()
+		if err != nil {
+			t.Errorf("%s failed on %q: %v", shell, name, err)
+			continue
+		}
+		if string(output) != name {
+			t.Errorf("%s printed %q for %q", shell, output, name)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, shell := range []string{"sh", "bash", "zsh"} {
+		t.Run(shell, func(t *testing.T) { roundTrip(t, shell, Quote) })
+	}
+	t.Run("fish", func(t *testing.T) { roundTrip(t, "fish", QuoteFish) })
+}
+
+func TestJoin(t *testing.T) {
+	got := Join([]string{"ssh", "-t", "me@box", "cd '/srv/my app' && ls"})
+	expected := `ssh -t me@box 'cd '\''/srv/my app'\'' && ls'`
+	if got != expected {
+		t.Errorf("Join = %s, expected %s", got, expected)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"status --dirty-only --json", []string{"status", "--dirty-only", "--json"}},
+		{"  each work  'git log -1'  ", []string{"each", "work", "git log -1"}},
+		{`note . "it's \"done\""`, []string{"note", ".", `it's "done"`}},
+		{`a\ b c`, []string{"a b", "c"}},
+		{`''`, []string{""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.input)
+		if err != nil {
+			t.Errorf("Split(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") || len(got) != len(tt.expected) {
+			t.Errorf("Split(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"'open", `"open`, `trailing\`} {
+		if _, err := Split(input); err == nil {
+			t.Errorf("Split(%q) should fail", input)
+		}
+	}
+
+	// Quote and Split round-trip
+	for _, name := range hostile {
+		got, err := Split(Quote(name) + " x")
+		if err != nil || len(got) != 2 || got[0] != name {
+			t.Errorf("Split(Quote(%q)) = %q, %v", name, got, err)
+		}
+	}
+}
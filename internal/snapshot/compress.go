@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// zstdCommand compresses snapshots when it is installed; there's no zstd
+// in the standard library, so snapshots fall back to gzip without it
+var zstdCommand = "zstd"
+
+// hasZstd reports whether the zstd command is available
+func hasZstd() bool {
+	if zstdCommand == "" {
+		return false
+	}
+	_, err := exec.LookPath(zstdCommand)
+	return err == nil
+}
+
+// extension is the file extension of a new snapshot
+func extension() string {
+	if hasZstd() {
+		return ".tar.zst"
+	}
+	return ".tar.gz"
+}
+
+// compress wraps file in the compressor matching the archive's
+// extension. finish flushes it and must be called once everything is
+// written.
+func compress(file *os.File, archive string) (io.Writer, func() error, error) {
+	if !strings.HasSuffix(archive, ".zst") {
+		gz := gzip.NewWriter(file)
+		return gz, gz.Close, nil
+	}
+
+	cmd := exec.Command(zstdCommand, "-q", "-c")
+	cmd.Stdout = file
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to run zstd: %w", err)
+	}
+	return stdin, func() error {
+		if err := stdin.Close(); err != nil {
+			return err
+		}
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("zstd failed: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// decompress reads file with the decompressor matching the archive's
+// extension. finish releases it.
+func decompress(file *os.File, archive string) (io.Reader, func(), error) {
+	if !strings.HasSuffix(archive, ".zst") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		return gz, func() { _ = gz.Close() }, nil
+	}
+
+	if !hasZstd() {
+		return nil, nil, fmt.Errorf("zstd is needed to restore %s", archive)
+	}
+	cmd := exec.Command(zstdCommand, "-q", "-d", "-c")
+	cmd.Stdin = file
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to run zstd: %w", err)
+	}
+	return stdout, func() {
+		_, _ = io.Copy(io.Discard, stdout)
+		_ = cmd.Wait()
+	}, nil
+}
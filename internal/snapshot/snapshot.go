@@ -0,0 +1,367 @@
+// Package snapshot archives the folders of a tag into a single timestamped
+// tarball, and restores them, as a safety net before changes that span
+// several projects.
+package snapshot
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// manifestName is the archive entry describing the snapshot
+const manifestName = "scope-snapshot.json"
+
+// timeLayout is how the creation time appears in snapshot names
+const timeLayout = "20060102-150405"
+
+// DefaultExclude are the build artifact and dependency directories left
+// out of every snapshot; they can be regenerated
+var DefaultExclude = []string{
+	"node_modules", "target", "dist", "build", ".next", ".nuxt",
+	".venv", "venv", "__pycache__", ".pytest_cache", ".mypy_cache",
+	".gradle", ".terraform", ".cache", "coverage",
+}
+
+// Folder is a folder stored in a snapshot
+type Folder struct {
+	// Path is where the folder was when the snapshot was taken
+	Path string `json:"path"`
+	// Name is the folder's directory inside the archive
+	Name string `json:"name"`
+}
+
+// Manifest describes a snapshot's contents
+type Manifest struct {
+	Tag     string    `json:"tag"`
+	Created time.Time `json:"created"`
+	Folders []Folder  `json:"folders"`
+}
+
+// Info is a snapshot found in the snapshot directory
+type Info struct {
+	Name    string
+	Path    string
+	Tag     string
+	Created time.Time
+	Size    int64
+}
+
+// Create archives folders into dir as <tag>-<time>.tar.zst (or .tar.gz
+// without zstd), skipping directories whose name is in exclude. It
+// returns the archive's path.
+func Create(dir, tagName string, folders []string, exclude []string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s%s", tagName, now.Format(timeLayout), extension())
+	archive := filepath.Join(dir, name)
+
+	file, err := os.OpenFile(archive, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	err = write(file, archive, Manifest{Tag: tagName, Created: now, Folders: archiveNames(folders)}, exclude)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(archive)
+		return "", err
+	}
+	return archive, nil
+}
+
+// write streams the manifest and every folder into file
+func write(file *os.File, archive string, m Manifest, exclude []string) error {
+	compressed, finish, err := compress(file, archive)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(compressed)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: manifestName, Mode: 0644, Size: int64(len(manifest)), ModTime: m.Created}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range exclude {
+		skip[name] = true
+	}
+	for _, f := range m.Folders {
+		if err := addFolder(tw, f, skip); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return finish()
+}
+
+// addFolder writes the folder's tree under its archive name
+func addFolder(tw *tar.Writer, f Folder, skip map[string]bool) error {
+	return filepath.WalkDir(f.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if d.IsDir() && p != f.Path && skip[d.Name()] {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(f.Path, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		} else if !info.Mode().IsRegular() && !info.IsDir() {
+			// Sockets, pipes and devices can't be restored meaningfully
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(f.Name, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+}
+
+// archiveNames gives each folder a unique directory in the archive: its
+// base name, numbered when two folders share one
+func archiveNames(folders []string) []Folder {
+	used := make(map[string]int)
+	result := make([]Folder, len(folders))
+	for i, folder := range folders {
+		base := filepath.Base(folder)
+		name := base
+		if n := used[base]; n > 0 {
+			name = fmt.Sprintf("%s-%d", base, n+1)
+		}
+		used[base]++
+		result[i] = Folder{Path: folder, Name: name}
+	}
+	return result
+}
+
+// List returns the snapshots in dir, newest first. A missing directory
+// has none.
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+	}
+
+	var snapshots []Info
+	for _, e := range entries {
+		info, ok := parseName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		if fi, err := e.Info(); err == nil {
+			info.Size = fi.Size()
+		}
+		info.Path = filepath.Join(dir, e.Name())
+		snapshots = append(snapshots, info)
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Created.After(snapshots[j].Created) })
+	return snapshots, nil
+}
+
+// parseName reads the tag and time out of a snapshot's file name
+func parseName(name string) (Info, bool) {
+	base := name
+	for _, ext := range []string{".tar.zst", ".tar.gz"} {
+		base = strings.TrimSuffix(base, ext)
+	}
+	if base == name || len(base) <= len(timeLayout)+1 {
+		return Info{}, false
+	}
+
+	stamp := base[len(base)-len(timeLayout):]
+	created, err := time.ParseInLocation(timeLayout, stamp, time.Local)
+	if err != nil || base[len(base)-len(timeLayout)-1] != '-' {
+		return Info{}, false
+	}
+	return Info{Name: name, Tag: base[:len(base)-len(timeLayout)-1], Created: created}, true
+}
+
+// Find returns the snapshot in dir called name, which may omit the
+// extension
+func Find(dir, name string) (Info, error) {
+	snapshots, err := List(dir)
+	if err != nil {
+		return Info{}, err
+	}
+	for _, s := range snapshots {
+		if s.Name == name || strings.TrimSuffix(strings.TrimSuffix(s.Name, ".tar.zst"), ".tar.gz") == name {
+			return s, nil
+		}
+	}
+	return Info{}, fmt.Errorf("no snapshot named '%s' in %s", name, dir)
+}
+
+// ReadManifest returns the manifest of a snapshot without extracting it
+func ReadManifest(archive string) (*Manifest, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	r, finish, err := decompress(file, archive)
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	return readManifest(tar.NewReader(r), archive)
+}
+
+// readManifest reads the manifest, which is always the first entry
+func readManifest(tr *tar.Reader, archive string) (*Manifest, error) {
+	hdr, err := tr.Next()
+	if err == io.EOF || (err == nil && hdr.Name != manifestName) {
+		return nil, fmt.Errorf("%s is not a scope snapshot", archive)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	m := &Manifest{}
+	if err := json.NewDecoder(tr).Decode(m); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Restore extracts a snapshot. Each folder goes back to the path it was
+// taken from, or, when into is set, to a directory of its archive name
+// inside into. Files in the snapshot overwrite existing ones; files that
+// aren't in it are left alone.
+func Restore(archive, into string) (*Manifest, error) {
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	r, finish, err := decompress(file, archive)
+	if err != nil {
+		return nil, err
+	}
+	defer finish()
+
+	tr := tar.NewReader(r)
+	manifest, err := readManifest(tr, archive)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, f := range manifest.Folders {
+		targets[f.Name] = f.Path
+		if into != "" {
+			targets[f.Name] = filepath.Join(into, f.Name)
+		}
+	}
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return manifest, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+		if err := extract(tr, hdr, targets); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// extract writes one archive entry under its folder's target
+func extract(tr *tar.Reader, hdr *tar.Header, targets map[string]string) error {
+	name := path.Clean(hdr.Name)
+	folder, rel, _ := strings.Cut(name, "/")
+	root, ok := targets[folder]
+	if !ok || path.IsAbs(name) || rel == ".." || strings.HasPrefix(rel, "../") {
+		return fmt.Errorf("unexpected entry in snapshot: %s", hdr.Name)
+	}
+	dest := filepath.Join(root, filepath.FromSlash(rel))
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(dest, hdr.FileInfo().Mode().Perm()|0700)
+	case tar.TypeSymlink:
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		_ = os.Remove(dest)
+		return os.Symlink(hdr.Linkname, dest)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, tr); err != nil {
+			_ = out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		return os.Chtimes(dest, hdr.ModTime, hdr.ModTime)
+	}
+	return nil
+}
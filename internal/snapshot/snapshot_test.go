@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+}
+
+func TestCreateRestore(t *testing.T) {
+	for _, compressor := range []string{"", "zstd"} {
+		t.Run("compressor="+compressor, func(t *testing.T) {
+			saved := zstdCommand
+			zstdCommand = compressor
+			defer func() { zstdCommand = saved }()
+			if compressor != "" && !hasZstd() {
+				t.Skip("zstd not installed")
+			}
+
+			root := t.TempDir()
+			api := filepath.Join(root, "one", "api")
+			web := filepath.Join(root, "two", "api")
+			writeFile(t, filepath.Join(api, "main.go"), "package main\n")
+			writeFile(t, filepath.Join(api, ".git", "HEAD"), "ref: refs/heads/main\n")
+			writeFile(t, filepath.Join(api, "node_modules", "left-pad", "index.js"), "x")
+			writeFile(t, filepath.Join(web, "cache", "keep.txt"), "web\n")
+
+			dir := filepath.Join(t.TempDir(), "snapshots")
+			archive, err := Create(dir, "work", []string{api, web}, append(DefaultExclude, "cache"))
+			if err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+			if !strings.HasPrefix(filepath.Base(archive), "work-") {
+				t.Errorf("Unexpected snapshot name %s", archive)
+			}
+
+			snapshots, err := List(dir)
+			if err != nil {
+				t.Fatalf("List failed: %v", err)
+			}
+			if len(snapshots) != 1 || snapshots[0].Tag != "work" || snapshots[0].Size == 0 {
+				t.Fatalf("Unexpected snapshots %+v", snapshots)
+			}
+
+			// Restoring in place overwrites changed files
+			writeFile(t, filepath.Join(api, "main.go"), "broken\n")
+			if _, err := Restore(archive, ""); err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if content, _ := os.ReadFile(filepath.Join(api, "main.go")); string(content) != "package main\n" {
+				t.Errorf("Expected main.go restored, got %q", content)
+			}
+
+			into := t.TempDir()
+			m, err := Restore(archive, into)
+			if err != nil {
+				t.Fatalf("Restore failed: %v", err)
+			}
+			if m.Tag != "work" || len(m.Folders) != 2 || m.Folders[1].Name != "api-2" {
+				t.Errorf("Unexpected manifest %+v", m)
+			}
+			if _, err := os.Stat(filepath.Join(into, "api", ".git", "HEAD")); err != nil {
+				t.Errorf("Expected .git to be kept: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(into, "api", "node_modules")); !os.IsNotExist(err) {
+				t.Error("node_modules should be excluded")
+			}
+			if _, err := os.Stat(filepath.Join(into, "api-2", "cache")); !os.IsNotExist(err) {
+				t.Error("Configured exclusions should be skipped")
+			}
+		})
+	}
+}
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		ok   bool
+	}{
+		{"work-20260102-150405.tar.zst", "work", true},
+		{"client-a-20260102-150405.tar.gz", "client-a", true},
+		{"work-20260102.tar.gz", "", false},
+		{"work-20260102-150405.zip", "", false},
+		{"notes.txt", "", false},
+	}
+
+	for _, tt := range tests {
+		info, ok := parseName(tt.name)
+		if ok != tt.ok || info.Tag != tt.tag {
+			t.Errorf("parseName(%q) = %q, %v; want %q, %v", tt.name, info.Tag, ok, tt.tag, tt.ok)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "work-20260102-150405.tar.gz"), "")
+
+	for _, name := range []string{"work-20260102-150405", "work-20260102-150405.tar.gz"} {
+		if _, err := Find(dir, name); err != nil {
+			t.Errorf("Find(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := Find(dir, "other"); err == nil {
+		t.Error("Find should fail for unknown snapshots")
+	}
+}
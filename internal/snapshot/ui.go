@@ -0,0 +1,34 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// ConfirmRestore asks before a snapshot is restored over its folders
+func ConfirmRestore(m *Manifest) (bool, error) {
+	if err := ui.CanPrompt(); err != nil {
+		return false, err
+	}
+
+	paths := make([]string, len(m.Folders))
+	for i, f := range m.Folders {
+		paths[i] = f.Path
+	}
+
+	confirmed := false
+	err := ui.NewForm(huh.NewGroup(
+		huh.NewConfirm().
+			Title(fmt.Sprintf("Restore %d folders from '%s'?", len(m.Folders), m.Tag)).
+			Description("Files in the snapshot overwrite those in:\n" + strings.Join(paths, "\n")).
+			Value(&confirmed),
+	)).Run()
+	if err != nil {
+		return false, fmt.Errorf("restore canceled: %w", err)
+	}
+	return confirmed, nil
+}
@@ -0,0 +1,191 @@
+// Package standup summarizes recent work across tagged repositories: the
+// commits made in each and the time tracked per tag, as Markdown ready to
+// paste into standup notes.
+package standup
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/timetrack"
+)
+
+// Options select what the summary covers
+type Options struct {
+	// Tag limits the summary to folders with this tag; empty covers all
+	Tag string
+	// Since and Until bound the period
+	Since time.Time
+	Until time.Time
+	// AllAuthors includes everyone's commits instead of only those by each
+	// repository's user.email
+	AllAuthors bool
+}
+
+// Repo is a folder and the commits made in it
+type Repo struct {
+	Path    string
+	Commits []git.Commit
+}
+
+// Summary is the work done in a period
+type Summary struct {
+	Since time.Time
+	Until time.Time
+	Tag   string
+	Repos []Repo
+	Time  []timetrack.TagTime
+	// Errors are the folders whose history couldn't be read
+	Errors []error
+}
+
+// DefaultSince returns the start of the previous working day: yesterday,
+// or Friday when now is a Monday
+func DefaultSince(now time.Time) time.Time {
+	days := 1
+	if now.Weekday() == time.Monday {
+		days = 3
+	}
+	return timetrack.StartOfDay(now).AddDate(0, 0, -days)
+}
+
+// Collect gathers the commits and tracked time for opts. Folders that aren't
+// local git repositories are skipped.
+func Collect(m *tag.Manager, tracker *timetrack.Tracker, opts Options) (*Summary, error) {
+	var folders []string
+	var err error
+	if opts.Tag != "" {
+		folders, err = m.ListFoldersByTag(opts.Tag)
+	} else {
+		folders, err = m.ListAllFolders()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Summary{Since: opts.Since, Until: opts.Until, Tag: opts.Tag}
+	for _, folder := range folders {
+		if location.IsRemote(folder) || !inRepo(folder) {
+			continue
+		}
+
+		logOpts := git.LogOptions{Since: opts.Since, Until: opts.Until}
+		if !opts.AllAuthors {
+			logOpts.Author = git.UserEmail(folder)
+		}
+		commits, err := git.Log(folder, logOpts)
+		if err != nil {
+			s.Errors = append(s.Errors, err)
+			continue
+		}
+		if len(commits) > 0 {
+			s.Repos = append(s.Repos, Repo{Path: folder, Commits: commits})
+		}
+	}
+
+	report, err := tracker.Report(opts.Since, opts.Until)
+	if err != nil {
+		return nil, err
+	}
+	for _, tt := range report.Tags {
+		if opts.Tag == "" || tt.Tag == opts.Tag {
+			s.Time = append(s.Time, tt)
+		}
+	}
+
+	return s, nil
+}
+
+// inRepo reports whether dir is a git repository or inside one
+func inRepo(dir string) bool {
+	for {
+		if git.IsRepo(dir) {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// Markdown renders the summary. Folders are shown by name, with the parent
+// directory added when two share a name.
+func (s *Summary) Markdown() string {
+	var b strings.Builder
+
+	title := "Standup"
+	if s.Tag != "" {
+		title += ": " + s.Tag
+	}
+	fmt.Fprintf(&b, "## %s (%s)\n", title, period(s.Since, s.Until))
+
+	b.WriteString("\n### Commits\n\n")
+	if len(s.Repos) == 0 {
+		b.WriteString("No commits.\n")
+	}
+	names := repoNames(s.Repos)
+	for i, repo := range s.Repos {
+		fmt.Fprintf(&b, "**%s**\n", names[i])
+		for _, c := range repo.Commits {
+			fmt.Fprintf(&b, "- %s (`%s`)\n", c.Subject, c.Hash)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(s.Time) > 0 {
+		if len(s.Repos) == 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("### Time\n\n")
+		for _, tt := range s.Time {
+			fmt.Fprintf(&b, "- %s: %s", tt.Tag, timetrack.FormatDuration(tt.Total()))
+			if tt.Sessions > 0 {
+				fmt.Fprintf(&b, " (sessions %s)", timetrack.FormatDuration(tt.Sessions))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// period describes [since, until) by its days, e.g. "Wed Oct 14" or
+// "Fri Oct 9 - Mon Oct 12", with years when they differ
+func period(since, until time.Time) string {
+	end := until.Add(-time.Second)
+	layout := "Mon Jan 2"
+	if since.Year() != end.Year() {
+		layout = "Mon Jan 2 2006"
+	}
+	first := since.Format(layout)
+	last := end.Format(layout)
+	if first == last {
+		return first
+	}
+	return first + " - " + last
+}
+
+// repoNames returns a display name for each repo: its base name, or
+// parent/base when the base name is ambiguous
+func repoNames(repos []Repo) []string {
+	count := make(map[string]int)
+	for _, r := range repos {
+		count[filepath.Base(r.Path)]++
+	}
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		name := filepath.Base(r.Path)
+		if count[name] > 1 {
+			name = filepath.Join(filepath.Base(filepath.Dir(r.Path)), name)
+		}
+		names[i] = name
+	}
+	return names
+}
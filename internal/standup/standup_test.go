@@ -0,0 +1,143 @@
+package standup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/git"
+	"github.com/gabssanto/Scope/internal/tag"
+	"github.com/gabssanto/Scope/internal/timetrack"
+)
+
+func TestDefaultSince(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		// Thursday: since Wednesday
+		{time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC), time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)},
+		// Monday: since Friday
+		{time.Date(2026, 10, 12, 9, 30, 0, 0, time.UTC), time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := DefaultSince(tt.now); !got.Equal(tt.want) {
+			t.Errorf("DefaultSince(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	s := &Summary{
+		Since: time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC),
+		Tag:   "work",
+		Repos: []Repo{
+			{Path: "/code/clientA/api", Commits: []git.Commit{{Hash: "a1b2c3d", Subject: "Fix login"}}},
+			{Path: "/code/clientB/api", Commits: []git.Commit{{Hash: "e4f5a6b", Subject: "Add health check"}}},
+		},
+		Time: []timetrack.TagTime{{Tag: "work", Folders: 2 * time.Hour, Sessions: 30 * time.Minute}},
+	}
+
+	want := "## Standup: work (Wed Oct 14 - Thu Oct 15)\n" +
+		"\n### Commits\n\n" +
+		"**clientA/api**\n- Fix login (`a1b2c3d`)\n\n" +
+		"**clientB/api**\n- Add health check (`e4f5a6b`)\n\n" +
+		"### Time\n\n" +
+		"- work: 2h 30m (sessions 30m)\n"
+	if got := s.Markdown(); got != want {
+		t.Errorf("Unexpected Markdown:\n%s\nwant:\n%s", got, want)
+	}
+
+	empty := &Summary{Since: s.Since, Until: s.Since.Add(24 * time.Hour)}
+	if got := empty.Markdown(); got != "## Standup (Wed Oct 14)\n\n### Commits\n\nNo commits.\n" {
+		t.Errorf("Unexpected Markdown for an empty summary:\n%s", got)
+	}
+}
+
+// gitRun runs git in dir at a fixed date
+func gitRun(t *testing.T, dir string, date time.Time, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_DATE="+date.Format(time.RFC3339), "GIT_COMMITTER_DATE="+date.Format(time.RFC3339),
+		"GIT_AUTHOR_NAME=me", "GIT_AUTHOR_EMAIL=me@example.com",
+		"GIT_COMMITTER_NAME=me", "GIT_COMMITTER_EMAIL=me@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	repo := filepath.Join(tmpDir, "api")
+	plain := filepath.Join(tmpDir, "notes")
+	for _, dir := range []string{repo, plain} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+	gitRun(t, repo, day, "init", "-q")
+	gitRun(t, repo, day, "config", "user.email", "me@example.com")
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	gitRun(t, repo, day.Add(10*time.Hour), "add", ".")
+	gitRun(t, repo, day.Add(10*time.Hour), "commit", "-q", "-m", "Start the API")
+
+	m := tag.NewManager(store)
+	for _, dir := range []string{repo, plain} {
+		if err := m.AddTag(dir, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	tracker := timetrack.NewTracker(store)
+	if err := tracker.RecordSession("work", day.Add(9*time.Hour), day.Add(10*time.Hour)); err != nil {
+		t.Fatalf("RecordSession failed: %v", err)
+	}
+
+	s, err := Collect(m, tracker, Options{Tag: "work", Since: day, Until: day.Add(24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(s.Errors) != 0 {
+		t.Errorf("Unexpected errors %v", s.Errors)
+	}
+	if len(s.Repos) != 1 || s.Repos[0].Path != repo || len(s.Repos[0].Commits) != 1 {
+		t.Fatalf("Expected one commit in %s, got %+v", repo, s.Repos)
+	}
+	if len(s.Time) != 1 || s.Time[0].Sessions != time.Hour {
+		t.Errorf("Expected an hour of sessions, got %+v", s.Time)
+	}
+	if md := s.Markdown(); !strings.Contains(md, "- Start the API") {
+		t.Errorf("Expected the commit in the summary:\n%s", md)
+	}
+
+	// The next day has nothing
+	s, err = Collect(m, tracker, Options{Since: day.Add(24 * time.Hour), Until: day.Add(48 * time.Hour)})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(s.Repos) != 0 || len(s.Time) != 0 {
+		t.Errorf("Expected an empty summary, got %+v", s)
+	}
+}
@@ -0,0 +1,218 @@
+// Package suggest recommends tags for a folder from the tags of its
+// siblings, the tags that co-occur with its current ones, the words in its
+// path and the toolchains found in it.
+package suggest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/project"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// Weights of each signal; a suggestion's score is the sum of its signals
+const (
+	siblingWeight     = 1.0
+	coOccurWeight     = 0.8
+	pathTokenWeight   = 0.6
+	toolchainWeight   = 0.7
+	newToolchainScore = 0.5
+)
+
+// Suggestion is a recommended tag and why it was recommended
+type Suggestion struct {
+	Tag     string
+	Score   float64
+	Reasons []string
+	// New is set when the tag doesn't exist yet
+	New bool
+}
+
+// Label describes the suggestion for display
+func (s Suggestion) Label() string {
+	label := s.Tag
+	if s.New {
+		label += " (new)"
+	}
+	return fmt.Sprintf("%s - %s", label, strings.Join(s.Reasons, "; "))
+}
+
+// collector accumulates scores and reasons per tag
+type collector struct {
+	existing map[string]bool
+	skip     map[string]bool
+	byTag    map[string]*Suggestion
+}
+
+func (c *collector) add(tagName string, score float64, reason string) {
+	if c.skip[tagName] {
+		return
+	}
+	s, ok := c.byTag[tagName]
+	if !ok {
+		s = &Suggestion{Tag: tagName, New: !c.existing[tagName]}
+		c.byTag[tagName] = s
+	}
+	s.Score += score
+	s.Reasons = append(s.Reasons, reason)
+}
+
+// For returns tag suggestions for the folder at path (an absolute,
+// canonical path), best first. Tags the folder already has are excluded.
+func For(m *tag.Manager, path string) ([]Suggestion, error) {
+	folderTags, err := m.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &collector{
+		existing: make(map[string]bool, len(counts)),
+		skip:     make(map[string]bool),
+		byTag:    make(map[string]*Suggestion),
+	}
+	for name := range counts {
+		c.existing[name] = true
+	}
+	current := folderTags[path]
+	for _, name := range current {
+		c.skip[name] = true
+	}
+
+	addSiblings(c, folderTags, path)
+	addCoOccurring(c, folderTags, path, current)
+	addPathTokens(c, counts, path)
+	addToolchains(c, counts, path)
+
+	suggestions := make([]Suggestion, 0, len(c.byTag))
+	for _, s := range c.byTag {
+		suggestions = append(suggestions, *s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Tag < suggestions[j].Tag
+	})
+	return suggestions, nil
+}
+
+// addSiblings scores tags used by other tagged folders in the same parent
+func addSiblings(c *collector, folderTags map[string][]string, path string) {
+	parent := filepath.Dir(path)
+	siblings := 0
+	uses := make(map[string]int)
+	for folder, tags := range folderTags {
+		if folder == path || filepath.Dir(folder) != parent {
+			continue
+		}
+		siblings++
+		for _, name := range tags {
+			uses[name]++
+		}
+	}
+
+	for name, n := range uses {
+		c.add(name, siblingWeight*float64(n)/float64(siblings),
+			fmt.Sprintf("used by %d of %d sibling folders", n, siblings))
+	}
+}
+
+// addCoOccurring scores tags that often appear alongside the folder's
+// current tags
+func addCoOccurring(c *collector, folderTags map[string][]string, path string, current []string) {
+	for _, have := range current {
+		withHave := 0
+		together := make(map[string]int)
+		for folder, tags := range folderTags {
+			if folder == path || !contains(tags, have) {
+				continue
+			}
+			withHave++
+			for _, name := range tags {
+				if name != have {
+					together[name]++
+				}
+			}
+		}
+
+		for name, n := range together {
+			// Ignore coincidences
+			if n < 2 {
+				continue
+			}
+			c.add(name, coOccurWeight*float64(n)/float64(withHave),
+				fmt.Sprintf("used with '%s' in %d of %d folders", have, n, withHave))
+		}
+	}
+}
+
+// addPathTokens scores existing tags named like a component of the path.
+// Tags with a category prefix match on the part after it.
+func addPathTokens(c *collector, counts map[string]int, path string) {
+	tokens := make(map[string]bool)
+	rel := path
+	if home, err := os.UserHomeDir(); err == nil {
+		if r, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(r, "..") {
+			rel = r
+		}
+	}
+	for _, component := range strings.Split(filepath.ToSlash(rel), "/") {
+		component = strings.ToLower(component)
+		if component == "" {
+			continue
+		}
+		tokens[component] = true
+		for _, word := range strings.FieldsFunc(component, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.' || r == ' '
+		}) {
+			tokens[word] = true
+		}
+	}
+
+	for name := range counts {
+		key := strings.ToLower(name)
+		if i := strings.LastIndex(key, ":"); i >= 0 {
+			key = key[i+1:]
+		}
+		if tokens[key] {
+			c.add(name, pathTokenWeight, fmt.Sprintf("matches path component '%s'", key))
+		}
+	}
+}
+
+// addToolchains scores tags for the toolchains detected in the folder,
+// preferring an existing tag named after the toolchain (with or without a
+// category prefix) over a new one
+func addToolchains(c *collector, counts map[string]int, path string) {
+	for _, tc := range project.DetectToolchains(path) {
+		reason := fmt.Sprintf("found %s", tc.Marker)
+
+		matched := false
+		for name := range counts {
+			if name == tc.Name || strings.HasSuffix(name, ":"+tc.Name) {
+				c.add(name, toolchainWeight, reason)
+				matched = true
+			}
+		}
+		if !matched {
+			c.add(tc.Name, newToolchainScore, reason)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
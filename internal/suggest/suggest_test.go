@@ -0,0 +1,142 @@
+package suggest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// setupTestEnv creates a store and a tree of folders under a temp dir
+func setupTestEnv(t *testing.T, folders ...string) (*tag.Manager, string) {
+	t.Helper()
+
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	for _, folder := range folders {
+		if err := os.MkdirAll(filepath.Join(tmpDir, folder), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	return tag.NewManager(store), tmpDir
+}
+
+func addTags(t *testing.T, m *tag.Manager, path string, tags ...string) {
+	t.Helper()
+	for _, name := range tags {
+		if err := m.AddTag(path, name); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+}
+
+func find(suggestions []Suggestion, tagName string) *Suggestion {
+	for i := range suggestions {
+		if suggestions[i].Tag == tagName {
+			return &suggestions[i]
+		}
+	}
+	return nil
+}
+
+func TestForSiblings(t *testing.T) {
+	m, root := setupTestEnv(t, "acme/api", "acme/web", "acme/new")
+	addTags(t, m, filepath.Join(root, "acme/api"), "work", "backend")
+	addTags(t, m, filepath.Join(root, "acme/web"), "work", "frontend")
+
+	suggestions, err := For(m, filepath.Join(root, "acme/new"))
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+
+	if len(suggestions) == 0 || suggestions[0].Tag != "work" {
+		t.Fatalf("Expected 'work' (used by both siblings) first, got %+v", suggestions)
+	}
+	if s := find(suggestions, "backend"); s == nil || s.Score >= suggestions[0].Score {
+		t.Errorf("Expected 'backend' to rank below 'work', got %+v", s)
+	}
+}
+
+func TestForExcludesCurrentTags(t *testing.T) {
+	m, root := setupTestEnv(t, "a", "b")
+	addTags(t, m, filepath.Join(root, "a"), "work")
+	addTags(t, m, filepath.Join(root, "b"), "work")
+
+	suggestions, err := For(m, filepath.Join(root, "b"))
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	if find(suggestions, "work") != nil {
+		t.Errorf("Tags the folder already has must not be suggested: %+v", suggestions)
+	}
+}
+
+func TestForCoOccurrence(t *testing.T) {
+	m, root := setupTestEnv(t, "x/one", "y/two", "z/three")
+	addTags(t, m, filepath.Join(root, "x/one"), "go", "backend")
+	addTags(t, m, filepath.Join(root, "y/two"), "go", "backend")
+	addTags(t, m, filepath.Join(root, "z/three"), "go")
+
+	suggestions, err := For(m, filepath.Join(root, "z/three"))
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+	if find(suggestions, "backend") == nil {
+		t.Errorf("Expected 'backend' (always used with 'go'), got %+v", suggestions)
+	}
+}
+
+func TestForPathTokensAndToolchains(t *testing.T) {
+	m, root := setupTestEnv(t, "elsewhere", "clients/globex-portal")
+	addTags(t, m, filepath.Join(root, "elsewhere"), "client:globex", "lang:rust")
+
+	target := filepath.Join(root, "clients/globex-portal")
+	if err := os.WriteFile(filepath.Join(target, "Cargo.toml"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write marker: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "Dockerfile"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write marker: %v", err)
+	}
+
+	suggestions, err := For(m, target)
+	if err != nil {
+		t.Fatalf("For failed: %v", err)
+	}
+
+	if s := find(suggestions, "client:globex"); s == nil || s.New {
+		t.Errorf("Expected existing 'client:globex' from the path, got %+v", s)
+	}
+	if s := find(suggestions, "lang:rust"); s == nil {
+		t.Errorf("Expected existing 'lang:rust' for Cargo.toml, got %+v", suggestions)
+	}
+	if find(suggestions, "rust") != nil {
+		t.Error("Should prefer the existing 'lang:rust' over a new 'rust' tag")
+	}
+	if s := find(suggestions, "docker"); s == nil || !s.New {
+		t.Errorf("Expected new 'docker' tag for Dockerfile, got %+v", s)
+	}
+}
+
+func TestPreselected(t *testing.T) {
+	suggestions := []Suggestion{
+		{Tag: "go", Score: 0.9},
+		{Tag: "work", Score: 0.5},
+		{Tag: "maybe", Score: 0.2},
+	}
+	if got := strings.Join(Preselected(suggestions), ","); got != "go,work" {
+		t.Errorf("Preselected = %s, expected go,work", got)
+	}
+}
@@ -0,0 +1,58 @@
+package suggest
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// preselectScore is the score from which a suggestion starts selected
+const preselectScore = 0.5
+
+// Preselected returns the tags of the suggestions that start selected:
+// those scored at least preselectScore
+func Preselected(suggestions []Suggestion) []string {
+	var tags []string
+	for _, s := range suggestions {
+		if s.Score >= preselectScore {
+			tags = append(tags, s.Tag)
+		}
+	}
+	return tags
+}
+
+// SelectSuggestions presents an interactive multi-select UI for confirming
+// which suggested tags to apply
+func SelectSuggestions(path string, suggestions []Suggestion) ([]string, error) {
+	if len(suggestions) == 0 {
+		return nil, nil
+	}
+	if err := ui.CanPrompt(); err != nil {
+		return nil, err
+	}
+
+	options := make([]huh.Option[string], len(suggestions))
+	for i, s := range suggestions {
+		options[i] = huh.NewOption(s.Label(), s.Tag).Selected(s.Score >= preselectScore)
+	}
+
+	var selected []string
+
+	form := ui.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(fmt.Sprintf("Select tags for %s", path)).
+				Description("space: toggle, enter: confirm, /: filter").
+				Options(options...).
+				Value(&selected),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("selection canceled: %w", err)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,120 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/db/dbtest"
+)
+
+var (
+	largeOnce    sync.Once
+	largeDir     string
+	largeManager *Manager
+	largeErr     error
+)
+
+// TestMain removes the large database once every benchmark that shares it
+// has run
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if largeManager != nil {
+		_ = largeManager.store.Close()
+	}
+	if largeDir != "" {
+		os.RemoveAll(largeDir)
+	}
+	os.Exit(code)
+}
+
+// largeDB returns a Manager over a seeded 50k-folder database, built once
+// per test binary since seeding takes a few seconds
+func largeDB(b *testing.B) *Manager {
+	b.Helper()
+
+	if testing.Short() {
+		b.Skip("Skipping large database benchmark in short mode")
+	}
+
+	largeOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "scope-bench-large-*")
+		if err != nil {
+			largeErr = err
+			return
+		}
+		largeDir = dir
+		store, err := db.Open(filepath.Join(dir, "scope.db"), db.PoolOptions{})
+		if err != nil {
+			largeErr = err
+			return
+		}
+		if err := dbtest.Seed(store, dbtest.Large); err != nil {
+			largeErr = err
+			return
+		}
+		largeManager = NewManager(store)
+	})
+	if largeErr != nil {
+		b.Fatalf("Failed to seed large database: %v", largeErr)
+	}
+
+	b.ResetTimer()
+	return largeManager
+}
+
+func BenchmarkLargeListTags(b *testing.B) {
+	m := largeDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := m.ListTags(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLargeListFoldersByTag(b *testing.B) {
+	m := largeDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := m.ListFoldersByTag(dbtest.TagName(i % dbtest.Large.Tags)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLargeGetTagsForFolder(b *testing.B) {
+	m := largeDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := m.GetTagsForFolder(dbtest.FolderPath(i % dbtest.Large.Folders)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLargeListAllFolders(b *testing.B) {
+	m := largeDB(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := m.ListAllFolders(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLargeCompletionTags measures the work behind tag completion:
+// every shell completion lists and sorts all tag names
+func BenchmarkLargeCompletionTags(b *testing.B) {
+	m := largeDB(b)
+	for i := 0; i < b.N; i++ {
+		tags, err := m.ListTags()
+		if err != nil {
+			b.Fatal(err)
+		}
+		names := make([]string, 0, len(tags))
+		for name := range tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+}
@@ -0,0 +1,94 @@
+package tag
+
+import (
+	"sort"
+	"strings"
+)
+
+// Category groups tags sharing a prefix (e.g. "client:") under a heading
+type Category struct {
+	Prefix string
+	Title  string
+	Color  string
+}
+
+// TagGroup is a category and the tags that belong to it
+type TagGroup struct {
+	Category Category
+	Tags     []string
+}
+
+// categorySeparator splits a tag's category from the rest of its name
+const categorySeparator = ":"
+
+// GroupTags sorts tag names into categories. Tags matching a configured
+// prefix go under that category (longest prefix wins); other tags with a
+// "name:" prefix get an automatic category; the rest are returned last in
+// a category with an empty prefix. Configured categories keep their order,
+// automatic ones are sorted, and empty categories are omitted.
+func GroupTags(names []string, categories []Category) []TagGroup {
+	configured := make([]TagGroup, len(categories))
+	for i, c := range categories {
+		if c.Title == "" {
+			c.Title = strings.TrimSuffix(c.Prefix, categorySeparator)
+		}
+		configured[i] = TagGroup{Category: c}
+	}
+
+	auto := make(map[string]*TagGroup)
+	other := TagGroup{Category: Category{Title: "other"}}
+
+	sorted := append([]string(nil), names...)
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		best := -1
+		for i, c := range categories {
+			if c.Prefix == "" || !strings.HasPrefix(name, c.Prefix) {
+				continue
+			}
+			if best < 0 || len(c.Prefix) > len(categories[best].Prefix) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			configured[best].Tags = append(configured[best].Tags, name)
+			continue
+		}
+
+		if i := strings.Index(name, categorySeparator); i > 0 {
+			prefix := name[:i+len(categorySeparator)]
+			g, ok := auto[prefix]
+			if !ok {
+				g = &TagGroup{Category: Category{Prefix: prefix, Title: name[:i]}}
+				auto[prefix] = g
+			}
+			g.Tags = append(g.Tags, name)
+			continue
+		}
+
+		other.Tags = append(other.Tags, name)
+	}
+
+	var groups []TagGroup
+	for _, g := range configured {
+		if len(g.Tags) > 0 {
+			groups = append(groups, g)
+		}
+	}
+
+	autoPrefixes := make([]string, 0, len(auto))
+	for prefix := range auto {
+		autoPrefixes = append(autoPrefixes, prefix)
+	}
+	sort.Strings(autoPrefixes)
+	for _, prefix := range autoPrefixes {
+		groups = append(groups, *auto[prefix])
+	}
+
+	if len(other.Tags) > 0 {
+		groups = append(groups, other)
+	}
+
+	return groups
+}
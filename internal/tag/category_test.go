@@ -0,0 +1,59 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupTags(t *testing.T) {
+	names := []string{"work", "client:acme", "lang:go", "client:globex", "lang:rust", "project:api", "project:api:v2", "misc", ":odd"}
+	categories := []Category{
+		{Prefix: "project:", Title: "Projects", Color: "blue"},
+		{Prefix: "project:api:", Title: "API versions"},
+		{Prefix: "client:"},
+		{Prefix: "team:", Title: "Teams"},
+	}
+
+	groups := GroupTags(names, categories)
+
+	var got []string
+	tags := make(map[string][]string)
+	for _, g := range groups {
+		got = append(got, g.Category.Title)
+		tags[g.Category.Title] = g.Tags
+	}
+
+	// Configured first (in config order, empty ones dropped), then
+	// automatic prefixes sorted, then everything else
+	expected := []string{"Projects", "API versions", "client", "lang", "other"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected groups %v, got %v", expected, got)
+	}
+
+	if !reflect.DeepEqual(tags["Projects"], []string{"project:api"}) {
+		t.Errorf("Expected longest prefix to win, Projects got %v", tags["Projects"])
+	}
+	if !reflect.DeepEqual(tags["API versions"], []string{"project:api:v2"}) {
+		t.Errorf("Expected project:api:v2 under API versions, got %v", tags["API versions"])
+	}
+	if !reflect.DeepEqual(tags["client"], []string{"client:acme", "client:globex"}) {
+		t.Errorf("Unexpected client tags: %v", tags["client"])
+	}
+	if !reflect.DeepEqual(tags["other"], []string{":odd", "misc", "work"}) {
+		t.Errorf("Unexpected other tags: %v", tags["other"])
+	}
+	if groups[0].Category.Color != "blue" {
+		t.Errorf("Expected configured color to be kept, got %q", groups[0].Category.Color)
+	}
+}
+
+func TestGroupTagsNoCategories(t *testing.T) {
+	groups := GroupTags([]string{"b", "a"}, nil)
+	if len(groups) != 1 || !reflect.DeepEqual(groups[0].Tags, []string{"a", "b"}) {
+		t.Errorf("Expected a single sorted group, got %+v", groups)
+	}
+
+	if groups := GroupTags(nil, nil); len(groups) != 0 {
+		t.Errorf("Expected no groups for no tags, got %+v", groups)
+	}
+}
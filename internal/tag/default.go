@@ -0,0 +1,232 @@
+package tag
+
+import (
+	"time"
+
+	"github.com/gabssanto/Scope/internal/paths"
+)
+
+// std is the Manager behind the package-level functions. It operates on the
+// default store opened by db.InitDB.
+var std = NewManager(nil)
+
+// Default returns the Manager for the default store
+func Default() *Manager {
+	return std
+}
+
+// SetSymlinkPolicy sets the symlink policy of the default Manager
+func SetSymlinkPolicy(policy paths.SymlinkPolicy) {
+	std.SetSymlinkPolicy(policy)
+}
+
+// SetTrashRetention sets the trash retention of the default Manager
+func SetTrashRetention(d time.Duration) {
+	std.SetTrashRetention(d)
+}
+
+// AddTag adds a tag to a folder using the default store
+func AddTag(path, tagName string) error {
+	return std.AddTag(path, tagName)
+}
+
+// FuzzyTags ranks the tags of the default store against query
+func FuzzyTags(query string) ([]Match, error) {
+	return std.FuzzyTags(query)
+}
+
+// TagFolders adds a tag to many folders in one transaction using the default store
+func TagFolders(folders []string, tagName string) ([]string, error) {
+	return std.TagFolders(folders, tagName)
+}
+
+// RemoveTag removes a specific tag from a folder using the default store
+func RemoveTag(path, tagName string) error {
+	return std.RemoveTag(path, tagName)
+}
+
+// DeleteTag deletes a tag entirely using the default store
+func DeleteTag(tagName string) error {
+	return std.DeleteTag(tagName)
+}
+
+// RemoveFolder forgets a folder entirely using the default store
+func RemoveFolder(path string) error {
+	return std.RemoveFolder(path)
+}
+
+// MoveFolder records that a tagged folder moved using the default store
+func MoveFolder(oldPath, newPath string) error {
+	return std.MoveFolder(oldPath, newPath)
+}
+
+// ListTags returns all tags with their folder counts using the default store
+func ListTags() (map[string]int, error) {
+	return std.ListTags()
+}
+
+// ListFoldersByTag returns all folders with a specific tag using the default store
+func ListFoldersByTag(tagName string) ([]string, error) {
+	return std.ListFoldersByTag(tagName)
+}
+
+// ListFoldersByExactTag returns the folders with a tag itself using the
+// default store
+func ListFoldersByExactTag(tagName string) ([]string, error) {
+	return std.ListFoldersByExactTag(tagName)
+}
+
+// SelectFolders returns the folders selected by a tag or tag expression using the default store
+func SelectFolders(query string) ([]string, error) {
+	return std.SelectFolders(query)
+}
+
+// GetTagsForFolder returns all tags for a specific folder using the default store
+func GetTagsForFolder(path string) ([]string, error) {
+	return std.GetTagsForFolder(path)
+}
+
+// ListAllFolders returns all tagged folders using the default store
+func ListAllFolders() ([]string, error) {
+	return std.ListAllFolders()
+}
+
+// ListFolderTags returns every tagged folder with its tags using the default store
+func ListFolderTags() (map[string][]string, error) {
+	return std.ListFolderTags()
+}
+
+// RenameTag renames a tag across all folders using the default store
+func RenameTag(oldName, newName string) error {
+	return std.RenameTag(oldName, newName)
+}
+
+// MergeTag merges one tag into another using the default store
+func MergeTag(from, into string) error {
+	return std.MergeTag(from, into)
+}
+
+// Prune removes folders that no longer exist using the default store
+func Prune(dryRun bool) (*PruneResult, error) {
+	return std.Prune(dryRun)
+}
+
+// Doctor checks the default store for problems, fixing them if fix is set
+func Doctor(fix bool) (*DoctorReport, error) {
+	return std.Doctor(fix)
+}
+
+// SetNote attaches a note to a folder using the default store
+func SetNote(path, note string) error {
+	return std.SetNote(path, note)
+}
+
+// GetNote returns the note of a folder using the default store
+func GetNote(path string) (string, error) {
+	return std.GetNote(path)
+}
+
+// ListNotes returns the notes of all folders using the default store
+func ListNotes() (map[string]string, error) {
+	return std.ListNotes()
+}
+
+// SetMeta sets metadata of a folder using the default store
+func SetMeta(path string, values map[string]string) error {
+	return std.SetMeta(path, values)
+}
+
+// GetMeta returns the metadata of a folder using the default store
+func GetMeta(path string) (map[string]string, error) {
+	return std.GetMeta(path)
+}
+
+// ListMeta returns the metadata of all folders using the default store
+func ListMeta() (map[string]map[string]string, error) {
+	return std.ListMeta()
+}
+
+// SetSubdir sets the working subdirectory of a folder using the default store
+func SetSubdir(path, subdir string) error {
+	return std.SetSubdir(path, subdir)
+}
+
+// GetSubdir returns the working subdirectory of a folder using the default store
+func GetSubdir(path string) (string, error) {
+	return std.GetSubdir(path)
+}
+
+// ListSubdirs returns the working subdirectories of all folders using the
+// default store
+func ListSubdirs() (map[string]string, error) {
+	return std.ListSubdirs()
+}
+
+// AddTodo attaches a todo to a folder using the default store
+func AddTodo(path, text string) (Todo, error) {
+	return std.AddTodo(path, text)
+}
+
+// SetTodoDone marks a todo as done or open using the default store
+func SetTodoDone(id int64, done bool) (Todo, error) {
+	return std.SetTodoDone(id, done)
+}
+
+// ListTodos returns the todos of a folder using the default store
+func ListTodos(path string, all bool) ([]Todo, error) {
+	return std.ListTodos(path, all)
+}
+
+// ListTodosByTag returns the todos of the folders with a tag using the
+// default store
+func ListTodosByTag(tagName string, all bool) ([]Todo, error) {
+	return std.ListTodosByTag(tagName, all)
+}
+
+// SetOrder sets the order of a tag's folders using the default store
+func SetOrder(tagName string, folders []string) error {
+	return std.SetOrder(tagName, folders)
+}
+
+// IsOrdered reports whether a tag has a custom order using the default store
+func IsOrdered(tagName string) (bool, error) {
+	return std.IsOrdered(tagName)
+}
+
+// Search finds tagged folders by path, tags and note using the default store
+func Search(query string) ([]string, error) {
+	return std.Search(query)
+}
+
+// GetTagInfo returns the description, color and icon of a tag using the
+// default store
+func GetTagInfo(name string) (Info, error) {
+	return std.GetTagInfo(name)
+}
+
+// SetTagInfo changes the description, color or icon of a tag using the
+// default store
+func SetTagInfo(name string, update InfoUpdate) error {
+	return std.SetTagInfo(name, update)
+}
+
+// ListTagInfo returns the tags with a description, color or icon using the
+// default store
+func ListTagInfo() (map[string]Info, error) {
+	return std.ListTagInfo()
+}
+
+// ListTrash returns the deleted tags and folders using the default store
+func ListTrash() ([]TrashItem, error) {
+	return std.ListTrash()
+}
+
+// Restore takes a tag or folder out of the trash using the default store
+func Restore(name string) (TrashItem, error) {
+	return std.Restore(name)
+}
+
+// EmptyTrash deletes the trash for good using the default store
+func EmptyTrash() (int, error) {
+	return std.EmptyTrash()
+}
@@ -0,0 +1,446 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/paths"
+)
+
+// DoctorReport lists the problems Doctor found
+type DoctorReport struct {
+	// Duplicates are folders stored through a symlink, or under several
+	// paths that resolve to the same directory
+	Duplicates []DuplicateFolder
+
+	// Orphans are folders left without any tag, e.g. untagged by a version
+	// of scope that kept them
+	Orphans []string
+
+	// Missing are tagged local folders that no longer exist
+	Missing []string
+
+	// EmptyTags are tags without any folder
+	EmptyTags []string
+
+	// Moved are missing folders that were likely moved or renamed: a
+	// directory of the same name that isn't tagged exists near them. They
+	// are also in Missing.
+	Moved []MovedFolder
+
+	// Fixed is set when the problems were repaired
+	Fixed bool
+}
+
+// DuplicateFolder is one directory and the paths it is stored under
+type DuplicateFolder struct {
+	Canonical string
+	Paths     []string
+}
+
+// MovedFolder is a missing folder and where it may have gone
+type MovedFolder struct {
+	Path       string
+	Candidates []string
+}
+
+// Problems returns the number of problems in the report
+func (r *DoctorReport) Problems() int {
+	return len(r.Duplicates) + len(r.Orphans) + len(r.Missing) + len(r.EmptyTags)
+}
+
+// storedFolder is a row of the folders table
+type storedFolder struct {
+	id   int64
+	path string
+}
+
+// Doctor checks the database for problems, repairing them if fix is set
+func (m *Manager) Doctor(fix bool) (*DoctorReport, error) {
+	report := &DoctorReport{}
+
+	groups, err := m.findDuplicates()
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		dup := DuplicateFolder{Canonical: g.canonical}
+		for _, f := range g.folders {
+			dup.Paths = append(dup.Paths, f.path)
+		}
+		report.Duplicates = append(report.Duplicates, dup)
+	}
+
+	report.Orphans, err = m.findOrphans()
+	if err != nil {
+		return nil, err
+	}
+
+	missing, err := m.findMissing()
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range missing {
+		// An untagged folder is reported once, as an orphan
+		if !slices.Contains(report.Orphans, f.path) {
+			report.Missing = append(report.Missing, f.path)
+		}
+	}
+
+	if len(report.Missing) > 0 {
+		if report.Moved, err = m.findMoved(report.Missing); err != nil {
+			return nil, err
+		}
+	}
+
+	tags, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	for name, count := range tags {
+		if count == 0 {
+			report.EmptyTags = append(report.EmptyTags, name)
+		}
+	}
+	sort.Strings(report.EmptyTags)
+
+	if !fix || report.Problems() == 0 {
+		return report, nil
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		now := time.Now()
+		for _, g := range groups {
+			if err := mergeFolders(tx, g); err != nil {
+				return err
+			}
+		}
+		// Missing folders go to the trash, as Prune puts them
+		for _, f := range missing {
+			if _, err := tx.Exec("UPDATE folders SET deleted_at = ? WHERE id = ?", now.Unix(), f.id); err != nil {
+				return fmt.Errorf("failed to delete folder %s: %w", f.path, err)
+			}
+		}
+		// Only the tags reported empty; pruning folders may empty others,
+		// which are kept as Prune keeps them
+		for _, name := range report.EmptyTags {
+			_, err := tx.Exec(`
+				DELETE FROM tags
+				WHERE name = ? AND id NOT IN (SELECT tag_id FROM folder_tags)
+			`, name)
+			if err != nil {
+				return fmt.Errorf("failed to delete tag %s: %w", name, err)
+			}
+		}
+		if err := deleteOrphanFolders(tx); err != nil {
+			return err
+		}
+		return m.purgeExpired(tx, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report.Fixed = true
+	return report, nil
+}
+
+// MergeDuplicate merges the folders of a single duplicate reported by Doctor
+func (m *Manager) MergeDuplicate(dup DuplicateFolder) error {
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(database, func(tx *sql.Tx) error {
+		g := duplicateGroup{canonical: dup.Canonical}
+		for _, path := range dup.Paths {
+			f := storedFolder{path: path}
+			err := tx.QueryRow("SELECT id FROM folders WHERE path = ? AND deleted_at = 0", path).Scan(&f.id)
+			if err == sql.ErrNoRows {
+				// Already merged or removed since the report was made
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to query folder: %w", err)
+			}
+			g.folders = append(g.folders, f)
+		}
+		if len(g.folders) == 0 {
+			return nil
+		}
+		return mergeFolders(tx, g)
+	})
+}
+
+// duplicateGroup is the set of stored folders resolving to canonical
+type duplicateGroup struct {
+	canonical string
+	folders   []storedFolder
+}
+
+// findDuplicates groups stored folders by canonical path and returns the
+// groups that are not stored exactly once under their canonical path
+func (m *Manager) findDuplicates() ([]duplicateGroup, error) {
+	if m.symlinks == paths.SymlinkKeep {
+		return nil, nil
+	}
+
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query("SELECT id, path FROM folders WHERE deleted_at = 0 ORDER BY path")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folders: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var order []string
+	byCanonical := make(map[string][]storedFolder)
+	for rows.Next() {
+		var f storedFolder
+		if err := rows.Scan(&f.id, &f.path); err != nil {
+			return nil, fmt.Errorf("failed to scan folder: %w", err)
+		}
+		canonical := m.canonical(f.path)
+		if _, ok := byCanonical[canonical]; !ok {
+			order = append(order, canonical)
+		}
+		byCanonical[canonical] = append(byCanonical[canonical], f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read folders: %w", err)
+	}
+
+	var groups []duplicateGroup
+	for _, canonical := range order {
+		folders := byCanonical[canonical]
+		if len(folders) == 1 && folders[0].path == canonical {
+			continue
+		}
+		groups = append(groups, duplicateGroup{canonical: canonical, folders: folders})
+	}
+	return groups, nil
+}
+
+// findOrphans returns the paths of folders without any tag
+func (m *Manager) findOrphans() ([]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(`
+		SELECT path FROM folders
+		WHERE deleted_at = 0 AND id NOT IN (SELECT folder_id FROM folder_tags)
+		ORDER BY path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folders: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var orphans []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, fmt.Errorf("failed to scan folder: %w", err)
+		}
+		orphans = append(orphans, path)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read folders: %w", err)
+	}
+	return orphans, nil
+}
+
+// findMissing returns the local folders that no longer exist. Remote
+// folders can't be checked from here, and folders whose every tag is in
+// the trash are left for a restore to bring back.
+func (m *Manager) findMissing() ([]storedFolder, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(`
+		SELECT id, path FROM folders
+		WHERE kind = ? AND deleted_at = 0 AND EXISTS (
+			SELECT 1 FROM folder_tags ft JOIN tags t ON ft.tag_id = t.id
+			WHERE ft.folder_id = folders.id AND t.deleted_at = 0
+		)
+		ORDER BY path
+	`, location.Local)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folders: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var missing []storedFolder
+	for rows.Next() {
+		var f storedFolder
+		if err := rows.Scan(&f.id, &f.path); err != nil {
+			return nil, fmt.Errorf("failed to scan folder: %w", err)
+		}
+		if _, err := os.Stat(f.path); os.IsNotExist(err) {
+			missing = append(missing, f)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read folders: %w", err)
+	}
+	return missing, nil
+}
+
+// movedSearchDepth is how deep under the directories near a missing
+// folder findMoved looks for it
+const movedSearchDepth = 2
+
+// findMoved looks for the missing folders by name near where they were:
+// under their closest existing ancestor and its parent, which finds a
+// folder moved into a sibling directory (~/code/api to ~/archive/api).
+// Directories that are tagged already are not candidates.
+func (m *Manager) findMoved(missing []string) ([]MovedFolder, error) {
+	all, err := m.ListAllFolders()
+	if err != nil {
+		return nil, err
+	}
+	tagged := make(map[string]bool, len(all))
+	for _, folder := range all {
+		tagged[folder] = true
+	}
+
+	var dirs []string
+	for _, folder := range missing {
+		dir := filepath.Dir(folder)
+		for _, err := os.Stat(dir); err != nil && filepath.Dir(dir) != dir; _, err = os.Stat(dir) {
+			dir = filepath.Dir(dir)
+		}
+		// The root of the filesystem is too wide to search
+		for i := 0; i < 2 && filepath.Dir(dir) != dir; i++ {
+			if !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+
+	byName := make(map[string][]string)
+	for _, folder := range missing {
+		byName[filepath.Base(folder)] = nil
+	}
+	seen := make(map[string]int)
+	for _, dir := range dirs {
+		findByName(dir, movedSearchDepth, byName, seen)
+	}
+
+	var moved []MovedFolder
+	for _, folder := range missing {
+		var candidates []string
+		for _, dir := range byName[filepath.Base(folder)] {
+			if !tagged[dir] {
+				candidates = append(candidates, dir)
+			}
+		}
+		if len(candidates) > 0 {
+			sort.Strings(candidates)
+			moved = append(moved, MovedFolder{Path: folder, Candidates: candidates})
+		}
+	}
+	return moved, nil
+}
+
+// findByName adds the directories under dir, down to depth levels, whose
+// name is a key of byName to it. Hidden directories are skipped, and so
+// are those seen already with as many levels left to search.
+func findByName(dir string, depth int, byName map[string][]string, seen map[string]int) {
+	if depth == 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name()[0] == '.' {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		left, ok := seen[path]
+		if ok && left >= depth {
+			continue
+		}
+		seen[path] = depth
+		if found, named := byName[entry.Name()]; named && !ok {
+			byName[entry.Name()] = append(found, path)
+		}
+		findByName(path, depth-1, byName, seen)
+	}
+}
+
+// mergeFolders collapses a duplicate group into a single folder stored
+// under the canonical path, keeping the union of their tags
+func mergeFolders(tx *sql.Tx, g duplicateGroup) error {
+	keeper := g.folders[0]
+	for _, f := range g.folders {
+		if f.path == g.canonical {
+			keeper = f
+			break
+		}
+	}
+
+	for _, f := range g.folders {
+		if f.id == keeper.id {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO folder_tags (folder_id, tag_id, created_at)
+			SELECT ?, tag_id, created_at FROM folder_tags WHERE folder_id = ?
+		`, keeper.id, f.id)
+		if err != nil {
+			return fmt.Errorf("failed to merge tags of %s: %w", f.path, err)
+		}
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO folder_notes (folder_id, note, updated_at)
+			SELECT ?, note, updated_at FROM folder_notes WHERE folder_id = ?
+		`, keeper.id, f.id)
+		if err != nil {
+			return fmt.Errorf("failed to merge note of %s: %w", f.path, err)
+		}
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO folder_meta (folder_id, key, value, updated_at)
+			SELECT ?, key, value, updated_at FROM folder_meta WHERE folder_id = ?
+		`, keeper.id, f.id)
+		if err != nil {
+			return fmt.Errorf("failed to merge metadata of %s: %w", f.path, err)
+		}
+		if _, err := tx.Exec("DELETE FROM folders WHERE id = ?", f.id); err != nil {
+			return fmt.Errorf("failed to delete folder %s: %w", f.path, err)
+		}
+	}
+
+	if keeper.path != g.canonical {
+		if err := purgeTrashed(tx, g.canonical, ""); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE folders SET path = ? WHERE id = ?", g.canonical, keeper.id); err != nil {
+			return fmt.Errorf("failed to update folder %s: %w", keeper.path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,318 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/paths"
+)
+
+// makeSymlink creates a symlink to target next to it
+func makeSymlink(t *testing.T, target string) string {
+	t.Helper()
+	link := target + "-link"
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	return link
+}
+
+// insertRawFolder stores a path without canonicalizing it, the way
+// versions without a symlink policy did
+func insertRawFolder(t *testing.T, path, tagName string) {
+	t.Helper()
+	database := db.GetDB()
+	now := time.Now().Unix()
+
+	if _, err := database.Exec("INSERT INTO folders (path, created_at) VALUES (?, ?)", path, now); err != nil {
+		t.Fatalf("Failed to insert folder: %v", err)
+	}
+	if _, err := database.Exec("INSERT OR IGNORE INTO tags (name, created_at) VALUES (?, ?)", tagName, now); err != nil {
+		t.Fatalf("Failed to insert tag: %v", err)
+	}
+	_, err := database.Exec(`
+		INSERT INTO folder_tags (folder_id, tag_id, created_at)
+		SELECT f.id, t.id, ? FROM folders f, tags t WHERE f.path = ? AND t.name = ?
+	`, now, path, tagName)
+	if err != nil {
+		t.Fatalf("Failed to insert folder_tag: %v", err)
+	}
+}
+
+func TestAddTagResolvesSymlink(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	link := makeSymlink(t, testFolder)
+
+	if err := AddTag(link, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := AddTag(testFolder, "personal"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	folders, err := ListAllFolders()
+	if err != nil {
+		t.Fatalf("ListAllFolders failed: %v", err)
+	}
+	if !reflect.DeepEqual(folders, []string{testFolder}) {
+		t.Errorf("Expected a single canonical folder %s, got %v", testFolder, folders)
+	}
+
+	// Looking the folder up through the symlink finds the same tags
+	tags, err := GetTagsForFolder(link)
+	if err != nil {
+		t.Fatalf("GetTagsForFolder failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"personal", "work"}) {
+		t.Errorf("Expected [personal work], got %v", tags)
+	}
+
+	if err := RemoveTag(link, "work"); err != nil {
+		t.Errorf("RemoveTag through symlink failed: %v", err)
+	}
+}
+
+func TestAddTagKeepSymlink(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	link := makeSymlink(t, testFolder)
+
+	m := NewManager(nil)
+	m.SetSymlinkPolicy(paths.SymlinkKeep)
+
+	if err := m.AddTag(link, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	folders, err := m.ListFoldersByTag("work")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if len(folders) != 2 {
+		t.Errorf("Expected symlink and target stored separately, got %v", folders)
+	}
+
+	report, err := m.Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if report.Problems() != 0 {
+		t.Errorf("Doctor should not flag symlinks under the keep policy, got %+v", report.Duplicates)
+	}
+}
+
+func TestDoctorDedupesSymlinks(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	link := makeSymlink(t, testFolder)
+	insertRawFolder(t, link, "work")
+	insertRawFolder(t, testFolder, "personal")
+
+	report, err := Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if report.Problems() != 1 || report.Fixed {
+		t.Fatalf("Expected 1 unfixed duplicate, got %+v", report)
+	}
+	dup := report.Duplicates[0]
+	if dup.Canonical != testFolder || len(dup.Paths) != 2 {
+		t.Errorf("Unexpected duplicate: %+v", dup)
+	}
+
+	report, err = Doctor(true)
+	if err != nil {
+		t.Fatalf("Doctor fix failed: %v", err)
+	}
+	if !report.Fixed {
+		t.Error("Expected report to be marked fixed")
+	}
+
+	folders, err := ListAllFolders()
+	if err != nil {
+		t.Fatalf("ListAllFolders failed: %v", err)
+	}
+	if !reflect.DeepEqual(folders, []string{testFolder}) {
+		t.Errorf("Expected only %s after dedup, got %v", testFolder, folders)
+	}
+
+	tags, err := GetTagsForFolder(testFolder)
+	if err != nil {
+		t.Fatalf("GetTagsForFolder failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"personal", "work"}) {
+		t.Errorf("Expected tags to be merged, got %v", tags)
+	}
+
+	report, err = Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if report.Problems() != 0 {
+		t.Errorf("Expected no problems after fix, got %+v", report.Duplicates)
+	}
+}
+
+func TestDoctorRewritesSymlinkOnlyEntry(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	link := makeSymlink(t, testFolder)
+	insertRawFolder(t, link, "work")
+
+	if _, err := Doctor(true); err != nil {
+		t.Fatalf("Doctor fix failed: %v", err)
+	}
+
+	folders, err := ListFoldersByTag("work")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if !reflect.DeepEqual(folders, []string{testFolder}) {
+		t.Errorf("Expected symlink entry rewritten to %s, got %v", testFolder, folders)
+	}
+}
+
+func TestDoctorRemovesOrphans(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	// Older versions kept the folder row after its last tag was removed
+	orphan := testFolder + "-untagged"
+	if _, err := db.GetDB().Exec("INSERT INTO folders (path, created_at) VALUES (?, ?)", orphan, time.Now().Unix()); err != nil {
+		t.Fatalf("Failed to insert folder: %v", err)
+	}
+
+	report, err := Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if !reflect.DeepEqual(report.Orphans, []string{orphan}) {
+		t.Fatalf("Expected orphan %s, got %v", orphan, report.Orphans)
+	}
+	if report.Problems() != 1 {
+		t.Errorf("Expected 1 problem, got %d", report.Problems())
+	}
+
+	if _, err := Doctor(true); err != nil {
+		t.Fatalf("Doctor fix failed: %v", err)
+	}
+	report, err = Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if report.Problems() != 0 {
+		t.Errorf("Expected no problems after fixing, got %+v", report)
+	}
+
+	tags, err := GetTagsForFolder(testFolder)
+	if err != nil {
+		t.Fatalf("GetTagsForFolder failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"work"}) {
+		t.Errorf("Expected tagged folder to be kept, got %v", tags)
+	}
+}
+
+func TestDoctorPrunesMissingAndEmptyTags(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	gone := testFolder + "-gone"
+	if err := os.MkdirAll(gone, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	for _, path := range []string{testFolder, gone} {
+		if err := AddTag(path, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := AddTag(testFolder, "old"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := RemoveTag(testFolder, "old"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if err := os.Remove(gone); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	report, err := Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if !reflect.DeepEqual(report.Missing, []string{gone}) || !reflect.DeepEqual(report.EmptyTags, []string{"old"}) {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	if _, err := Doctor(true); err != nil {
+		t.Fatalf("Doctor fix failed: %v", err)
+	}
+	if report, _ := Doctor(false); report.Problems() != 0 {
+		t.Errorf("Expected no problems after fixing, got %+v", report)
+	}
+	tags, err := ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, map[string]int{"work": 1}) {
+		t.Errorf("Expected only work with its remaining folder, got %v", tags)
+	}
+}
+
+func TestDoctorSuggestsMoves(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	api := filepath.Join(testFolder, "code", "api")
+	moved := filepath.Join(testFolder, "archive", "api")
+	other := filepath.Join(testFolder, "code", "web")
+	for _, path := range []string{api, other, filepath.Dir(moved)} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	for _, path := range []string{api, other} {
+		if err := AddTag(path, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := os.Rename(api, moved); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+
+	report, err := Doctor(false)
+	if err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	expected := []MovedFolder{{Path: api, Candidates: []string{moved}}}
+	if !reflect.DeepEqual(report.Moved, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, report.Moved)
+	}
+
+	// A tagged directory of the same name is not where it went
+	if err := AddTag(moved, "archive"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if report, err = Doctor(false); err != nil {
+		t.Fatalf("Doctor failed: %v", err)
+	}
+	if len(report.Moved) != 0 {
+		t.Errorf("Expected no suggestion once %s is tagged, got %+v", moved, report.Moved)
+	}
+}
@@ -0,0 +1,291 @@
+package tag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Expr is a tag expression selecting the folders whose tags satisfy it,
+// such as "work AND backend NOT archived", "work+go" or "(api | web) !old".
+//
+// NOT (or !) binds tightest, then AND (+, & or just a space), then OR (|
+// or ,). The keywords are only recognized in upper case, so a tag named
+// "and" can still be used. A tag also matches the folders tagged with its
+// children, as work does work/backend.
+type Expr struct {
+	root node
+}
+
+// node is a parsed expression
+type node interface {
+	match(tags map[string]bool) bool
+	String() string
+}
+
+type tagNode string
+
+type notNode struct{ x node }
+
+type andNode []node
+
+type orNode []node
+
+func (n notNode) match(tags map[string]bool) bool { return !n.x.match(tags) }
+
+// match reports whether the folder has the tag or one of its children
+func (n tagNode) match(tags map[string]bool) bool {
+	if tags[string(n)] {
+		return true
+	}
+	for name := range tags {
+		if Within(name, string(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n andNode) match(tags map[string]bool) bool {
+	for _, x := range n {
+		if !x.match(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(tags map[string]bool) bool {
+	for _, x := range n {
+		if x.match(tags) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n tagNode) String() string { return string(n) }
+func (n andNode) String() string { return join(n, " AND ") }
+func (n orNode) String() string  { return join(n, " OR ") }
+
+func (n notNode) String() string {
+	// NOT binds tighter than AND and OR
+	switch n.x.(type) {
+	case andNode, orNode:
+		return "NOT (" + n.x.String() + ")"
+	}
+	return "NOT " + n.x.String()
+}
+
+func join(nodes []node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, x := range nodes {
+		parts[i] = x.String()
+		if _, ok := x.(orNode); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// exprOperators are the characters that are operators wherever they appear
+const exprOperators = "+&|,!()"
+
+// IsExpr reports whether s is more than a plain tag name
+func IsExpr(s string) bool {
+	return strings.ContainsAny(s, exprOperators) || strings.ContainsFunc(s, unicode.IsSpace)
+}
+
+// ParseExpr parses a tag expression
+func ParseExpr(s string) (*Expr, error) {
+	p := &exprParser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty tag expression")
+	}
+	root, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag expression %q: %w", s, err)
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("invalid tag expression %q: unexpected %q", s, tok)
+	}
+	return &Expr{root: root}, nil
+}
+
+// Tag returns the tag name when the expression is a single tag
+func (e *Expr) Tag() (string, bool) {
+	name, ok := e.root.(tagNode)
+	return string(name), ok
+}
+
+// Match reports whether a folder with tags is selected by the expression
+func (e *Expr) Match(tags []string) bool {
+	set := make(map[string]bool, len(tags))
+	for _, name := range tags {
+		set[name] = true
+	}
+	return e.root.match(set)
+}
+
+// String returns the expression with explicit operators
+func (e *Expr) String() string {
+	return e.root.String()
+}
+
+// tokenize splits s into tag names and operators
+func tokenize(s string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case strings.ContainsRune(exprOperators, r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// exprParser is a recursive descent parser over the tokens of an
+// expression
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+// or parses and-expressions separated by OR
+func (p *exprParser) or() (node, error) {
+	var terms orNode
+	for {
+		x, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, x)
+		if tok, ok := p.peek(); !ok || !isOr(tok) {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+// and parses unary expressions separated by AND, or by nothing at all
+func (p *exprParser) and() (node, error) {
+	var terms andNode
+	for {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, x)
+		tok, ok := p.peek()
+		if !ok || tok == ")" || isOr(tok) {
+			break
+		}
+		if isAnd(tok) {
+			p.pos++
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+// unary parses a tag, a negation or a parenthesized expression
+func (p *exprParser) unary() (node, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("expected a tag at the end")
+	}
+	p.pos++
+	switch tok {
+	case "NOT", "!":
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case "(":
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.peek(); !ok || tok != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case ")":
+		return nil, fmt.Errorf("expected a tag before %q", tok)
+	default:
+		if isAnd(tok) || isOr(tok) {
+			return nil, fmt.Errorf("expected a tag before %q", tok)
+		}
+		return tagNode(tok), nil
+	}
+}
+
+func isAnd(tok string) bool { return tok == "AND" || tok == "+" || tok == "&" }
+func isOr(tok string) bool  { return tok == "OR" || tok == "|" || tok == "," }
+
+// SelectFolders returns the folders selected by query: a tag name, or a
+// tag expression (see Expr). A query naming an existing tag is always
+// that tag, so tags with operator characters in their names keep working.
+// Folders selected by an expression are sorted by path.
+func (m *Manager) SelectFolders(query string) ([]string, error) {
+	if !IsExpr(query) {
+		return m.ListFoldersByTag(query)
+	}
+	tags, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tags[query]; ok {
+		return m.ListFoldersByTag(query)
+	}
+
+	expr, err := ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	if name, ok := expr.Tag(); ok {
+		return m.ListFoldersByTag(name)
+	}
+
+	folderTags, err := m.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	for folder, names := range folderTags {
+		if expr.Match(names) {
+			folders = append(folders, folder)
+		}
+	}
+	sort.Strings(folders)
+	return folders, nil
+}
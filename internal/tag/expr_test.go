@@ -0,0 +1,164 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"work", "work"},
+		{"work AND backend NOT archived", "work AND backend AND NOT archived"},
+		{"work+go", "work AND go"},
+		{"api | web, cli", "api OR web OR cli"},
+		{"(api | web) !old", "(api OR web) AND NOT old"},
+		{"a OR b AND c", "a OR b AND c"},
+		{"and or not", "and AND or AND not"},
+		{"NOT (a b) c", "NOT (a AND b) AND c"},
+		{"!(a, b)", "NOT (a OR b)"},
+	}
+	for _, tt := range tests {
+		e, err := ParseExpr(tt.expr)
+		if err != nil {
+			t.Errorf("ParseExpr(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if e.String() != tt.expected {
+			t.Errorf("ParseExpr(%q) = %s; expected %s", tt.expr, e, tt.expected)
+		}
+	}
+
+	for _, invalid := range []string{"", "work AND", "OR work", "(work", "work)", "!"} {
+		if _, err := ParseExpr(invalid); err == nil {
+			t.Errorf("ParseExpr(%q) should fail", invalid)
+		}
+	}
+}
+
+func FuzzParseExpr(f *testing.F) {
+	for _, seed := range []string{"work", "work AND backend NOT archived", "work+go", "api | web, cli", "(api | web) !old",
+		"NOT (a b)", "!(a, b) c", "((a))", "a OR b AND c", "and or not", "work AND", "(work", ")", "ü&ß"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		e, err := ParseExpr(input)
+		if err != nil {
+			return
+		}
+
+		// String must give back an expression that parses to the same one
+		s := e.String()
+		again, err := ParseExpr(s)
+		if err != nil {
+			t.Fatalf("ParseExpr(%q) failed on the String() of %q: %v", s, input, err)
+		}
+		if again.String() != s {
+			t.Errorf("ParseExpr(%q).String() = %q; expected %q (from %q)", s, again, s, input)
+		}
+
+		// and must select the same folders
+		var names []string
+		for _, tok := range tokenize(s) {
+			if !strings.ContainsAny(tok, exprOperators) && tok != "AND" && tok != "OR" && tok != "NOT" {
+				names = append(names, tok)
+			}
+		}
+		if len(names) > 8 {
+			return
+		}
+		for set := 0; set < 1<<len(names); set++ {
+			var tags []string
+			for i, name := range names {
+				if set&(1<<i) != 0 {
+					tags = append(tags, name)
+				}
+			}
+			if e.Match(tags) != again.Match(tags) {
+				t.Fatalf("%q and its String() %q disagree on %v", input, s, tags)
+			}
+		}
+	})
+}
+
+func TestExprMatch(t *testing.T) {
+	e, err := ParseExpr("work AND (backend | api) NOT archived")
+	if err != nil {
+		t.Fatalf("ParseExpr failed: %v", err)
+	}
+	tests := []struct {
+		tags     []string
+		expected bool
+	}{
+		{[]string{"work", "backend"}, true},
+		{[]string{"work", "api", "go"}, true},
+		{[]string{"work", "backend", "archived"}, false},
+		{[]string{"work"}, false},
+		{[]string{"backend"}, false},
+	}
+	for _, tt := range tests {
+		if got := e.Match(tt.tags); got != tt.expected {
+			t.Errorf("Match(%v) = %v; expected %v", tt.tags, got, tt.expected)
+		}
+	}
+}
+
+func TestSelectFolders(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	folders := make(map[string]string)
+	for _, name := range []string{"api", "web", "old"} {
+		folders[name] = filepath.Join(filepath.Dir(testFolder), name)
+		if err := os.MkdirAll(folders[name], 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+	tags := map[string][]string{
+		"api": {"work", "go"},
+		"web": {"work"},
+		"old": {"work", "go", "archived", "c++"},
+	}
+	for name, names := range tags {
+		for _, tagName := range names {
+			if err := AddTag(folders[name], tagName); err != nil {
+				t.Fatalf("AddTag failed: %v", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"work", []string{"api", "old", "web"}},
+		{"work+go", []string{"api", "old"}},
+		{"work AND go NOT archived", []string{"api"}},
+		{"missing | archived", []string{"old"}},
+		{"NOT go", []string{"web"}},
+		{"c++", []string{"old"}},
+	}
+	for _, tt := range tests {
+		got, err := SelectFolders(tt.query)
+		if err != nil {
+			t.Errorf("SelectFolders(%q) failed: %v", tt.query, err)
+			continue
+		}
+		names := make([]string, len(got))
+		for i, folder := range got {
+			names[i] = filepath.Base(folder)
+		}
+		if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+			t.Errorf("SelectFolders(%q) = %v; expected %v", tt.query, names, tt.expected)
+		}
+	}
+
+	if _, err := SelectFolders("work AND"); err == nil {
+		t.Error("SelectFolders should fail for an invalid expression")
+	}
+}
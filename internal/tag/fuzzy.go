@@ -0,0 +1,145 @@
+package tag
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Match is a tag name matched by a fuzzy query, scored from 0 to 1
+type Match struct {
+	Tag   string
+	Score float64
+}
+
+// fuzzyMargin is how close to the best score another match must be for the
+// query to be ambiguous
+const fuzzyMargin = 0.1
+
+// FuzzyMatch ranks names against query, best first. A name the query starts
+// ("back" for "backend") scores highest, then one containing it, then one
+// within a typo or two of it ("wrok" for "work"), then one containing its
+// letters in order ("bknd"). Case is ignored; names that match in none of
+// these ways are left out.
+func FuzzyMatch(query string, names []string) []Match {
+	q := strings.ToLower(query)
+	var matches []Match
+	for _, name := range names {
+		if score := fuzzyScore(q, strings.ToLower(name)); score > 0 {
+			matches = append(matches, Match{Tag: name, Score: score})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Tag < matches[j].Tag
+	})
+	return matches
+}
+
+// fuzzyScore scores name against query, both lower case; 0 is no match
+func fuzzyScore(query, name string) float64 {
+	if query == "" {
+		return 0
+	}
+	qlen, nlen := utf8.RuneCountInString(query), utf8.RuneCountInString(name)
+	// The more of the name the query covers, the better
+	coverage := float64(qlen) / float64(max(nlen, qlen))
+	switch {
+	case query == name:
+		return 1
+	case strings.HasPrefix(name, query):
+		return 0.8 + 0.15*coverage
+	case strings.Contains(name, query):
+		return 0.6 + 0.15*coverage
+	}
+
+	// Short names allow no typos, or everything would match "api"
+	allowed := 0
+	switch {
+	case min(qlen, nlen) >= 7:
+		allowed = 2
+	case min(qlen, nlen) >= 4:
+		allowed = 1
+	}
+	if d := editDistance(query, name); d <= allowed {
+		return 0.6 - 0.1*float64(d)
+	}
+
+	if isSubsequence(query, name) && qlen >= 2 {
+		return 0.2 + 0.2*coverage
+	}
+	return 0
+}
+
+// editDistance is the number of insertions, deletions, substitutions and
+// swaps of adjacent letters that turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// isSubsequence reports whether the letters of query appear in name in
+// order
+func isSubsequence(query, name string) bool {
+	rest := name
+	for _, r := range query {
+		i := strings.IndexRune(rest, r)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// Closest returns the matches that score within a small margin of the best
+// one: a single match means the query is unambiguous. An exact match is
+// always unambiguous.
+func Closest(matches []Match) []Match {
+	if len(matches) == 0 {
+		return nil
+	}
+	if matches[0].Score == 1 {
+		return matches[:1]
+	}
+	n := 1
+	for n < len(matches) && matches[0].Score-matches[n].Score < fuzzyMargin {
+		n++
+	}
+	return matches[:n]
+}
+
+// FuzzyTags ranks the existing tags against query (see FuzzyMatch)
+func (m *Manager) FuzzyTags(query string) ([]Match, error) {
+	tags, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	return FuzzyMatch(query, names), nil
+}
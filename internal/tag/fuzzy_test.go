@@ -0,0 +1,56 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	names := []string{"work", "backend", "backup", "frontend", "api", "app", "personal"}
+
+	tests := []struct {
+		query    string
+		expected []string // tags of the closest matches
+	}{
+		{"work", []string{"work"}},
+		{"wrok", []string{"work"}},
+		{"WORK", []string{"work"}},
+		{"backe", []string{"backend"}},
+		{"back", []string{"backup", "backend"}},
+		{"end", []string{"backend", "frontend"}},
+		{"personl", []string{"personal"}},
+		{"frntnd", []string{"frontend"}},
+		// Short names allow no typos
+		{"apo", nil},
+		{"zzz", nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, m := range Closest(FuzzyMatch(tt.query, names)) {
+			got = append(got, m.Tag)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Closest(FuzzyMatch(%q)) = %v, want %v", tt.query, got, tt.expected)
+		}
+	}
+}
+
+func TestFuzzyTags(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	for _, tagName := range []string{"work", "backend"} {
+		if err := AddTag(testFolder, tagName); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	matches, err := FuzzyTags("back")
+	if err != nil {
+		t.Fatalf("FuzzyTags failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Tag != "backend" {
+		t.Errorf("Expected backend, got %v", matches)
+	}
+}
@@ -0,0 +1,86 @@
+package tag
+
+import (
+	"slices"
+	"strings"
+)
+
+// Separator splits a hierarchical tag into its parent and child, as in
+// work/backend. A tag selects the folders of its children too: scope list
+// work includes the folders tagged work/backend.
+const Separator = "/"
+
+// Within reports whether name is parent or one of its descendants
+func Within(name, parent string) bool {
+	return name == parent || strings.HasPrefix(name, parent+Separator)
+}
+
+// Ancestors returns the parents of name, outermost first: work and
+// work/backend for work/backend/api
+func Ancestors(name string) []string {
+	var ancestors []string
+	for i, r := range name {
+		if string(r) == Separator && i > 0 {
+			ancestors = append(ancestors, name[:i])
+		}
+	}
+	return ancestors
+}
+
+// TreeEntry is one tag of the tree TagTree builds
+type TreeEntry struct {
+	// Name is the full name of the tag
+	Name string
+	// Depth is the number of ancestors above it
+	Depth int
+	// Folders is the number of folders tagged with it or a descendant
+	Folders int
+}
+
+// Label returns the last part of the name, shown under its parent
+func (e TreeEntry) Label() string {
+	return e.Name[strings.LastIndex(e.Name, Separator)+1:]
+}
+
+// TagTree arranges tags (as ListTags returns them) under their parents,
+// depth first and sorted by name at each level, adding the parents that
+// have no tag of their own. folderTags (as ListFolderTags returns them)
+// count the folders under each tag, once per folder.
+func TagTree(tags map[string]int, folderTags map[string][]string) []TreeEntry {
+	names := make(map[string]bool, len(tags))
+	for name := range tags {
+		names[name] = true
+		for _, parent := range Ancestors(name) {
+			names[parent] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	// Compared part by part, so work/backend comes right after work
+	// rather than after work-old
+	slices.SortFunc(sorted, func(a, b string) int {
+		return slices.Compare(strings.Split(a, Separator), strings.Split(b, Separator))
+	})
+
+	counts := make(map[string]int, len(names))
+	for _, folderNames := range folderTags {
+		seen := make(map[string]bool)
+		for _, name := range folderNames {
+			for _, t := range append(Ancestors(name), name) {
+				if !seen[t] {
+					seen[t] = true
+					counts[t]++
+				}
+			}
+		}
+	}
+
+	entries := make([]TreeEntry, len(sorted))
+	for i, name := range sorted {
+		entries[i] = TreeEntry{Name: name, Depth: len(Ancestors(name)), Folders: counts[name]}
+	}
+	return entries
+}
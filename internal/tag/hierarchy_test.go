@@ -0,0 +1,114 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAncestors(t *testing.T) {
+	cases := map[string][]string{
+		"work":             nil,
+		"work/backend":     {"work"},
+		"work/backend/api": {"work", "work/backend"},
+		"/odd":             nil,
+	}
+	for name, expected := range cases {
+		if got := Ancestors(name); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Ancestors(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestTagTree(t *testing.T) {
+	tags := map[string]int{"work/backend": 2, "work/frontend": 1, "work-old": 1, "home": 0}
+	folderTags := map[string][]string{
+		"/api":  {"work/backend", "work/frontend"},
+		"/jobs": {"work/backend"},
+		"/old":  {"work-old"},
+	}
+
+	expected := []TreeEntry{
+		{Name: "home", Depth: 0, Folders: 0},
+		{Name: "work", Depth: 0, Folders: 2},
+		{Name: "work/backend", Depth: 1, Folders: 2},
+		{Name: "work/frontend", Depth: 1, Folders: 1},
+		{Name: "work-old", Depth: 0, Folders: 1},
+	}
+	if got := TagTree(tags, folderTags); !reflect.DeepEqual(got, expected) {
+		t.Errorf("TagTree = %+v\nexpected %+v", got, expected)
+	}
+	if label := expected[2].Label(); label != "backend" {
+		t.Errorf("Expected label backend, got %q", label)
+	}
+}
+
+func TestListFoldersByTagIncludesChildren(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	folders := map[string]string{
+		"api":    "work/backend",
+		"web":    "work/frontend",
+		"hub":    "work",
+		"legacy": "work_old",
+	}
+	paths := make(map[string]string)
+	for name, tagName := range folders {
+		paths[name] = filepath.Join(testFolder, name)
+		if err := os.Mkdir(paths[name], 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+		if err := AddTag(paths[name], tagName); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	// Tagged with two children, listed once
+	if err := AddTag(paths["api"], "work/frontend"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	got, err := ListFoldersByTag("work")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	expected := []string{paths["api"], paths["hub"], paths["web"]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if got, _ = ListFoldersByExactTag("work"); !reflect.DeepEqual(got, []string{paths["hub"]}) {
+		t.Errorf("Expected only %s tagged work itself, got %v", paths["hub"], got)
+	}
+	if got, _ = SelectFolders("work NOT work/frontend"); !reflect.DeepEqual(got, []string{paths["hub"]}) {
+		t.Errorf("Expected the expression to match children, got %v", got)
+	}
+}
+
+func TestListFoldersByTagChildrenCaseSensitive(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	other := filepath.Join(filepath.Dir(testFolder), "other")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := AddTag(other, "Work/backend"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	got, err := ListFoldersByTag("work")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{testFolder}) {
+		t.Errorf("Expected only %s tagged work, got %v", testFolder, got)
+	}
+	if got, _ = ListFoldersByTag("Work"); !reflect.DeepEqual(got, []string{other}) {
+		t.Errorf("Expected only %s under Work, got %v", other, got)
+	}
+}
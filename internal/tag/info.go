@@ -0,0 +1,112 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// Info is what describes a tag besides its folders
+type Info struct {
+	Name        string
+	Description string
+	// Color is a color name for ui.Color, or "" for none
+	Color string
+	// Icon is shown before the tag's name, typically an emoji
+	Icon string
+}
+
+// InfoUpdate changes the fields of a tag's Info that are set; setting one
+// to "" clears it
+type InfoUpdate struct {
+	Description *string
+	Color       *string
+	Icon        *string
+}
+
+// GetTagInfo returns the description, color and icon of a tag
+func (m *Manager) GetTagInfo(name string) (Info, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{Name: name}
+	err = database.QueryRow("SELECT description, color, icon FROM tags WHERE name = ? AND deleted_at = 0", name).
+		Scan(&info.Description, &info.Color, &info.Icon)
+	if err == sql.ErrNoRows {
+		return Info{}, fmt.Errorf("tag not found: %s", name)
+	}
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to query tag: %w", err)
+	}
+	return info, nil
+}
+
+// SetTagInfo changes the description, color or icon of a tag
+func (m *Manager) SetTagInfo(name string, update InfoUpdate) error {
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	columns := []struct {
+		name  string
+		value *string
+	}{
+		{"description", update.Description},
+		{"color", update.Color},
+		{"icon", update.Icon},
+	}
+	return db.WithTx(database, func(tx *sql.Tx) error {
+		var id int64
+		err := tx.QueryRow("SELECT id FROM tags WHERE name = ? AND deleted_at = 0", name).Scan(&id)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("tag not found: %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query tag: %w", err)
+		}
+		for _, c := range columns {
+			if c.value == nil {
+				continue
+			}
+			if _, err := tx.Exec("UPDATE tags SET "+c.name+" = ? WHERE id = ?", *c.value, id); err != nil {
+				return fmt.Errorf("failed to update tag: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ListTagInfo returns the tags that have a description, color or icon, by
+// name
+func (m *Manager) ListTagInfo() (map[string]Info, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(`
+		SELECT name, description, color, icon FROM tags
+		WHERE deleted_at = 0 AND (description != '' OR color != '' OR icon != '')
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	infos := make(map[string]Info)
+	for rows.Next() {
+		var info Info
+		if err := rows.Scan(&info.Name, &info.Description, &info.Color, &info.Icon); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		infos[info.Name] = info
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tags: %w", err)
+	}
+	return infos, nil
+}
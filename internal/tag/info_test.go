@@ -0,0 +1,57 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagInfo(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	description, color := "Day job", "blue"
+	if err := SetTagInfo("work", InfoUpdate{Description: &description, Color: &color}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+	icon := "💼"
+	if err := SetTagInfo("work", InfoUpdate{Icon: &icon}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+
+	expected := Info{Name: "work", Description: "Day job", Color: "blue", Icon: "💼"}
+	info, err := GetTagInfo("work")
+	if err != nil || info != expected {
+		t.Errorf("GetTagInfo = %+v, %v; expected %+v", info, err, expected)
+	}
+	infos, err := ListTagInfo()
+	if err != nil || !reflect.DeepEqual(infos, map[string]Info{"work": expected}) {
+		t.Errorf("ListTagInfo = %+v, %v", infos, err)
+	}
+
+	// Renaming keeps it
+	if err := RenameTag("work", "job"); err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	if info, _ := GetTagInfo("job"); info.Color != "blue" {
+		t.Errorf("Expected the color to follow the rename, got %+v", info)
+	}
+
+	cleared := ""
+	if err := SetTagInfo("job", InfoUpdate{Description: &cleared, Color: &cleared, Icon: &cleared}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+	if infos, _ := ListTagInfo(); len(infos) != 0 {
+		t.Errorf("Expected no described tags after clearing, got %+v", infos)
+	}
+
+	if err := SetTagInfo("missing", InfoUpdate{Color: &color}); err == nil {
+		t.Error("SetTagInfo should fail for a tag that doesn't exist")
+	}
+	if _, err := GetTagInfo("missing"); err == nil {
+		t.Error("GetTagInfo should fail for a tag that doesn't exist")
+	}
+}
@@ -2,47 +2,206 @@ package tag
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/paths"
 )
 
-// AddTag adds a tag to a folder
-func AddTag(path, tagName string) error {
-	// Validate folder exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return fmt.Errorf("folder does not exist: %s", path)
+// Manager performs tag operations against a Store
+type Manager struct {
+	store     *db.Store
+	symlinks  paths.SymlinkPolicy
+	retention time.Duration
+
+	mu        sync.Mutex
+	observers []func(Change)
+}
+
+// NewManager returns a Manager for store. A nil store uses the default
+// store opened by db.InitDB.
+func NewManager(store *db.Store) *Manager {
+	return &Manager{store: store}
+}
+
+// SetSymlinkPolicy sets how paths that are symlinks are stored. The zero
+// policy resolves symlinks.
+func (m *Manager) SetSymlinkPolicy(policy paths.SymlinkPolicy) {
+	m.symlinks = policy
+}
+
+// canonical returns the path stored for path under the symlink policy
+func (m *Manager) canonical(path string) string {
+	if loc, ok := location.Parse(path); ok {
+		return loc.String()
 	}
+	return paths.Canonical(path, m.symlinks)
+}
 
-	database := db.GetDB()
-	if database == nil {
-		return fmt.Errorf("database not initialized")
+// resolve turns a path as a caller may spell it (relative, with ~, $VAR or
+// a trailing slash) into a clean absolute path, so every spelling of a
+// folder finds the same row. Locations are returned in their normal form.
+func (m *Manager) resolve(path string) (string, error) {
+	if loc, ok := location.Parse(path); ok {
+		return loc.String(), nil
+	}
+	return paths.Resolve(path)
+}
+
+// storeOrDefault resolves the store the Manager operates on
+func (m *Manager) storeOrDefault() (*db.Store, error) {
+	store := m.store
+	if store == nil {
+		store = db.Default()
+	}
+	if store == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return store, nil
+}
+
+// writeDB returns the read-write pool, or db.ErrReadOnly if the store
+// must not be modified
+func (m *Manager) writeDB() (*sql.DB, error) {
+	store, err := m.storeOrDefault()
+	if err != nil {
+		return nil, err
+	}
+	if store.ReadOnly() {
+		return nil, db.ErrReadOnly
+	}
+	return store.DB(), nil
+}
+
+// readDB returns the read-only pool
+func (m *Manager) readDB() (*sql.DB, error) {
+	store, err := m.storeOrDefault()
+	if err != nil {
+		return nil, err
+	}
+	return store.ReadDB(), nil
+}
+
+// AddTag adds a tag to a folder. The folder may be a non-local location
+// (user@host:/path, sftp://, docker:// or devpod://), which is stored
+// without checking that it exists.
+func (m *Manager) AddTag(path, tagName string) error {
+	path, kind, err := m.prepare(path)
+	if err != nil {
+		return err
 	}
 
-	tx, err := database.Begin()
+	database, err := m.writeDB()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer func() { _ = tx.Rollback() }()
 
 	now := time.Now().Unix()
 
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		_, err := addTag(tx, path, kind, tagName, now)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	m.notify(Change{Op: OpAdd, Path: path, Tag: tagName})
+	return nil
+}
+
+// TagFolders adds a tag to many folders in a single transaction, so either
+// all of them are tagged or, on error, none. It returns the stored paths of
+// the folders that didn't have the tag yet.
+func (m *Manager) TagFolders(folders []string, tagName string) ([]string, error) {
+	type prepared struct {
+		path string
+		kind location.Kind
+	}
+	all := make([]prepared, 0, len(folders))
+	for _, folder := range folders {
+		path, kind, err := m.prepare(folder)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, prepared{path, kind})
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+
+	var added []string
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		added = added[:0]
+		for _, f := range all {
+			ok, err := addTag(tx, f.path, f.kind, tagName, now)
+			if err != nil {
+				return err
+			}
+			if ok {
+				added = append(added, f.path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range added {
+		m.notify(Change{Op: OpAdd, Path: path, Tag: tagName})
+	}
+	return added, nil
+}
+
+// prepare returns the path a folder is stored under and its kind, checking
+// that a local folder exists
+func (m *Manager) prepare(path string) (string, location.Kind, error) {
+	if loc, ok := location.Parse(path); ok {
+		return loc.String(), loc.Kind, nil
+	}
+	abs, err := paths.Resolve(path)
+	if err != nil {
+		return "", "", err
+	}
+	// Validate folder exists
+	if _, err := os.Stat(abs); os.IsNotExist(err) {
+		return "", "", fmt.Errorf("folder does not exist: %s", path)
+	}
+	return m.canonical(abs), location.Local, nil
+}
+
+// addTag tags the folder stored under path in tx, creating the folder and
+// the tag as needed. It reports whether the folder didn't have the tag yet.
+func addTag(tx *sql.Tx, path string, kind location.Kind, tagName string, now int64) (bool, error) {
+	// Tagging a folder or creating a tag that is in the trash replaces it
+	if err := purgeTrashed(tx, path, tagName); err != nil {
+		return false, err
+	}
+
 	// Insert or get folder
 	var folderID int64
-	err = tx.QueryRow("SELECT id FROM folders WHERE path = ?", path).Scan(&folderID)
+	err := tx.QueryRow("SELECT id FROM folders WHERE path = ?", path).Scan(&folderID)
 	if err == sql.ErrNoRows {
-		result, err := tx.Exec("INSERT INTO folders (path, created_at) VALUES (?, ?)", path, now)
+		result, err := tx.Exec("INSERT INTO folders (path, kind, created_at) VALUES (?, ?, ?)", path, kind, now)
 		if err != nil {
-			return fmt.Errorf("failed to insert folder: %w", err)
+			return false, fmt.Errorf("failed to insert folder: %w", err)
 		}
 		folderID, err = result.LastInsertId()
 		if err != nil {
-			return fmt.Errorf("failed to get folder ID: %w", err)
+			return false, fmt.Errorf("failed to get folder ID: %w", err)
 		}
 	} else if err != nil {
-		return fmt.Errorf("failed to query folder: %w", err)
+		return false, fmt.Errorf("failed to query folder: %w", err)
 	}
 
 	// Insert or get tag
@@ -51,89 +210,227 @@ func AddTag(path, tagName string) error {
 	if err == sql.ErrNoRows {
 		result, err := tx.Exec("INSERT INTO tags (name, created_at) VALUES (?, ?)", tagName, now)
 		if err != nil {
-			return fmt.Errorf("failed to insert tag: %w", err)
+			return false, fmt.Errorf("failed to insert tag: %w", err)
 		}
 		tagID, err = result.LastInsertId()
 		if err != nil {
-			return fmt.Errorf("failed to get tag ID: %w", err)
+			return false, fmt.Errorf("failed to get tag ID: %w", err)
 		}
 	} else if err != nil {
-		return fmt.Errorf("failed to query tag: %w", err)
+		return false, fmt.Errorf("failed to query tag: %w", err)
 	}
 
 	// Insert folder_tag relationship (ignore if already exists)
-	_, err = tx.Exec("INSERT OR IGNORE INTO folder_tags (folder_id, tag_id, created_at) VALUES (?, ?, ?)",
+	result, err := tx.Exec("INSERT OR IGNORE INTO folder_tags (folder_id, tag_id, created_at) VALUES (?, ?, ?)",
 		folderID, tagID, now)
 	if err != nil {
-		return fmt.Errorf("failed to insert folder_tag: %w", err)
+		return false, fmt.Errorf("failed to insert folder_tag: %w", err)
 	}
-
-	return tx.Commit()
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert folder_tag: %w", err)
+	}
+	return n > 0, nil
 }
 
-// RemoveTag removes a specific tag from a folder
-func RemoveTag(path, tagName string) error {
-	database := db.GetDB()
-	if database == nil {
-		return fmt.Errorf("database not initialized")
+// RemoveTag removes a specific tag from a folder. A folder left without
+// tags is forgotten.
+func (m *Manager) RemoveTag(path, tagName string) error {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return err
 	}
 
-	result, err := database.Exec(`
-		DELETE FROM folder_tags
-		WHERE folder_id = (SELECT id FROM folders WHERE path = ?)
-		AND tag_id = (SELECT id FROM tags WHERE name = ?)
-	`, path, tagName)
+	database, err := m.writeDB()
 	if err != nil {
-		return fmt.Errorf("failed to remove tag: %w", err)
+		return err
 	}
 
-	rows, err := result.RowsAffected()
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		// Match both spellings so entries stored before the symlink policy
+		// existed can still be removed
+		result, err := tx.Exec(`
+			DELETE FROM folder_tags
+			WHERE folder_id IN (SELECT id FROM folders WHERE path IN (?, ?) AND deleted_at = 0)
+			AND tag_id = (SELECT id FROM tags WHERE name = ? AND deleted_at = 0)
+		`, abs, m.canonical(abs), tagName)
+		if err != nil {
+			return fmt.Errorf("failed to remove tag: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return fmt.Errorf("tag '%s' not found on folder: %s", tagName, path)
+		}
+
+		return deleteOrphanFolders(tx)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
+		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("tag '%s' not found on folder: %s", tagName, path)
+	m.notify(Change{Op: OpRemove, Path: m.canonical(abs), Tag: tagName})
+	return nil
+}
+
+// DeleteTag deletes a tag entirely (removes from all folders). The tag
+// goes to the trash, from which Restore brings it back with its folders.
+func (m *Manager) DeleteTag(tagName string) error {
+	database, err := m.writeDB()
+	if err != nil {
+		return err
 	}
 
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec("UPDATE tags SET deleted_at = ? WHERE name = ? AND deleted_at = 0", now.Unix(), tagName)
+		if err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return fmt.Errorf("tag not found: %s", tagName)
+		}
+
+		return m.purgeExpired(tx, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	m.notify(Change{Op: OpDeleteTag, Tag: tagName})
 	return nil
 }
 
-// DeleteTag deletes a tag entirely (removes from all folders)
-func DeleteTag(tagName string) error {
-	database := db.GetDB()
-	if database == nil {
-		return fmt.Errorf("database not initialized")
+// deleteOrphanFolders forgets folders left without any tag, so untagging a
+// folder's last tag doesn't leave its row (and note) behind
+func deleteOrphanFolders(tx *sql.Tx) error {
+	_, err := tx.Exec("DELETE FROM folders WHERE id NOT IN (SELECT folder_id FROM folder_tags)")
+	if err != nil {
+		return fmt.Errorf("failed to delete untagged folders: %w", err)
 	}
+	return nil
+}
 
-	result, err := database.Exec("DELETE FROM tags WHERE name = ?", tagName)
+// RemoveFolder forgets a folder entirely (removes all of its tags). The
+// folder goes to the trash, from which Restore brings it back.
+func (m *Manager) RemoveFolder(path string) error {
+	abs, err := m.resolve(path)
 	if err != nil {
-		return fmt.Errorf("failed to delete tag: %w", err)
+		return err
 	}
 
-	rows, err := result.RowsAffected()
+	database, err := m.writeDB()
 	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
+		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("tag not found: %s", tagName)
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		now := time.Now()
+		result, err := tx.Exec("UPDATE folders SET deleted_at = ? WHERE path IN (?, ?) AND deleted_at = 0", now.Unix(), abs, m.canonical(abs))
+		if err != nil {
+			return fmt.Errorf("failed to remove folder: %w", err)
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to check rows affected: %w", err)
+		}
+
+		if rows == 0 {
+			return fmt.Errorf("folder not found: %s", path)
+		}
+		return m.purgeExpired(tx, now)
+	})
+	if err != nil {
+		return err
 	}
 
+	m.notify(Change{Op: OpForget, Path: m.canonical(abs)})
+	return nil
+}
+
+// MoveFolder records that a tagged folder now lives at newPath, which
+// must exist, keeping its tags, note and everything else stored about it.
+// newPath must not be tagged already.
+func (m *Manager) MoveFolder(oldPath, newPath string) error {
+	oldAbs, err := m.resolve(oldPath)
+	if err != nil {
+		return err
+	}
+	newStored, kind, err := m.prepare(newPath)
+	if err != nil {
+		return err
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	var stored string
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		var folderID int64
+		err := tx.QueryRow("SELECT id, path FROM folders WHERE path IN (?, ?) AND deleted_at = 0", oldAbs, m.canonical(oldAbs)).Scan(&folderID, &stored)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("folder is not tagged: %s", oldPath)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+		if stored == newStored {
+			return nil
+		}
+
+		if err := purgeTrashed(tx, newStored, ""); err != nil {
+			return err
+		}
+		var existing int64
+		err = tx.QueryRow("SELECT id FROM folders WHERE path = ?", newStored).Scan(&existing)
+		if err == nil {
+			return fmt.Errorf("%s is already tagged", newStored)
+		}
+		if err != sql.ErrNoRows {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+
+		if _, err := tx.Exec("UPDATE folders SET path = ?, kind = ? WHERE id = ?", newStored, kind, folderID); err != nil {
+			return fmt.Errorf("failed to move folder: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if stored != newStored {
+		m.notify(Change{Op: OpMove, Path: stored, NewPath: newStored})
+	}
 	return nil
 }
 
 // ListTags returns all tags with their folder counts
-func ListTags() (map[string]int, error) {
-	database := db.GetDB()
-	if database == nil {
-		return nil, fmt.Errorf("database not initialized")
+func (m *Manager) ListTags() (map[string]int, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
 	}
 
 	rows, err := database.Query(`
 		SELECT t.name, COUNT(ft.folder_id) as count
 		FROM tags t
 		LEFT JOIN folder_tags ft ON t.id = ft.tag_id
+			AND ft.folder_id IN (SELECT id FROM folders WHERE deleted_at = 0)
+		WHERE t.deleted_at = 0
 		GROUP BY t.id, t.name
 		ORDER BY t.name
 	`)
@@ -155,21 +452,46 @@ func ListTags() (map[string]int, error) {
 	return tags, nil
 }
 
-// ListFoldersByTag returns all folders with a specific tag
-func ListFoldersByTag(tagName string) ([]string, error) {
-	database := db.GetDB()
-	if database == nil {
-		return nil, fmt.Errorf("database not initialized")
+// ListFoldersByTag returns all folders with a specific tag or one of its
+// children (work/backend for work, see Separator), in the order set by
+// SetOrder for the tag itself and then by path. Paths are returned as
+// stored, which AddTag has already made canonical.
+func (m *Manager) ListFoldersByTag(tagName string) ([]string, error) {
+	return m.listFolders(tagName, true)
+}
+
+// ListFoldersByExactTag returns the folders with a specific tag, leaving
+// out those only tagged with its children, in the order of
+// ListFoldersByTag
+func (m *Manager) ListFoldersByExactTag(tagName string) ([]string, error) {
+	return m.listFolders(tagName, false)
+}
+
+// listFolders returns the folders with tagName, and with its children
+// when children is set
+func (m *Manager) listFolders(tagName string, children bool) ([]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
 	}
 
+	// A folder tagged with several children is listed once; only the
+	// position under the tag itself orders it. Children are matched by
+	// prefix rather than LIKE, which ignores case.
+	prefix := ""
+	if children {
+		prefix = tagName + Separator
+	}
 	rows, err := database.Query(`
-		SELECT f.path
+		SELECT f.path, MAX(CASE WHEN t.name = ? THEN ft.position ELSE 0 END) AS position
 		FROM folders f
 		JOIN folder_tags ft ON f.id = ft.folder_id
 		JOIN tags t ON ft.tag_id = t.id
-		WHERE t.name = ?
-		ORDER BY f.path
-	`, tagName)
+		WHERE (t.name = ? OR (? != '' AND substr(t.name, 1, length(?)) = ?))
+			AND f.deleted_at = 0 AND t.deleted_at = 0
+		GROUP BY f.id
+		ORDER BY position = 0, position, f.path
+	`, tagName, tagName, prefix, prefix, prefix)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query folders: %w", err)
 	}
@@ -178,7 +500,8 @@ func ListFoldersByTag(tagName string) ([]string, error) {
 	var folders []string
 	for rows.Next() {
 		var path string
-		if err := rows.Scan(&path); err != nil {
+		var position int
+		if err := rows.Scan(&path, &position); err != nil {
 			return nil, fmt.Errorf("failed to scan folder: %w", err)
 		}
 		folders = append(folders, path)
@@ -188,20 +511,25 @@ func ListFoldersByTag(tagName string) ([]string, error) {
 }
 
 // GetTagsForFolder returns all tags for a specific folder
-func GetTagsForFolder(path string) ([]string, error) {
-	database := db.GetDB()
-	if database == nil {
-		return nil, fmt.Errorf("database not initialized")
+func (m *Manager) GetTagsForFolder(path string) ([]string, error) {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
 	}
 
 	rows, err := database.Query(`
-		SELECT t.name
+		SELECT DISTINCT t.name
 		FROM tags t
 		JOIN folder_tags ft ON t.id = ft.tag_id
 		JOIN folders f ON ft.folder_id = f.id
-		WHERE f.path = ?
+		WHERE f.path IN (?, ?) AND f.deleted_at = 0 AND t.deleted_at = 0
 		ORDER BY t.name
-	`, path)
+	`, abs, m.canonical(abs))
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tags: %w", err)
 	}
@@ -220,16 +548,18 @@ func GetTagsForFolder(path string) ([]string, error) {
 }
 
 // ListAllFolders returns all unique folders that have at least one tag
-func ListAllFolders() ([]string, error) {
-	database := db.GetDB()
-	if database == nil {
-		return nil, fmt.Errorf("database not initialized")
+func (m *Manager) ListAllFolders() ([]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
 	}
 
 	rows, err := database.Query(`
 		SELECT DISTINCT f.path
 		FROM folders f
 		JOIN folder_tags ft ON f.id = ft.folder_id
+		JOIN tags t ON ft.tag_id = t.id
+		WHERE f.deleted_at = 0 AND t.deleted_at = 0
 		ORDER BY f.path
 	`)
 	if err != nil {
@@ -249,81 +579,150 @@ func ListAllFolders() ([]string, error) {
 	return folders, nil
 }
 
-// RenameTag renames a tag across all folders
-func RenameTag(oldName, newName string) error {
-	database := db.GetDB()
-	if database == nil {
-		return fmt.Errorf("database not initialized")
+// ListFolderTags returns every tagged folder with its tags
+func (m *Manager) ListFolderTags() (map[string][]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
 	}
 
-	// Check if old tag exists
-	var oldID int64
-	err := database.QueryRow("SELECT id FROM tags WHERE name = ?", oldName).Scan(&oldID)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("tag not found: %s", oldName)
-	}
+	rows, err := database.Query(`
+		SELECT f.path, t.name
+		FROM folders f
+		JOIN folder_tags ft ON f.id = ft.folder_id
+		JOIN tags t ON ft.tag_id = t.id
+		WHERE f.deleted_at = 0 AND t.deleted_at = 0
+		ORDER BY f.path, t.name
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to query tag: %w", err)
+		return nil, fmt.Errorf("failed to query folder tags: %w", err)
 	}
+	defer func() { _ = rows.Close() }()
 
-	// Check if new tag name already exists
-	var existingID int64
-	err = database.QueryRow("SELECT id FROM tags WHERE name = ?", newName).Scan(&existingID)
-	if err == nil {
-		return fmt.Errorf("tag already exists: %s", newName)
+	folders := make(map[string][]string)
+	for rows.Next() {
+		var path, name string
+		if err := rows.Scan(&path, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan folder tag: %w", err)
+		}
+		folders[path] = append(folders[path], name)
 	}
-	if err != sql.ErrNoRows {
-		return fmt.Errorf("failed to check existing tag: %w", err)
+
+	return folders, nil
+}
+
+// ErrTagExists is returned when renaming a tag to a name already in use
+var ErrTagExists = errors.New("tag already exists")
+
+// RenameTag renames a tag across all folders. The rename is a single
+// statement relying on the UNIQUE constraint on names, so a tag created
+// under the new name at the same time is reported as ErrTagExists rather
+// than checked for beforehand.
+func (m *Manager) RenameTag(oldName, newName string) error {
+	if oldName == newName {
+		return fmt.Errorf("%w: %s", ErrTagExists, newName)
 	}
 
-	// Rename the tag
-	_, err = database.Exec("UPDATE tags SET name = ? WHERE id = ?", newName, oldID)
+	database, err := m.writeDB()
 	if err != nil {
-		return fmt.Errorf("failed to rename tag: %w", err)
+		return err
 	}
 
-	return nil
-}
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		// A tag of that name in the trash makes way
+		if err := purgeTrashed(tx, "", newName); err != nil {
+			return err
+		}
+		result, err := tx.Exec("UPDATE tags SET name = ? WHERE name = ? AND deleted_at = 0", newName, oldName)
+		if db.IsUniqueViolation(err) {
+			return fmt.Errorf("%w: %s", ErrTagExists, newName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to rename tag: %w", err)
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			return fmt.Errorf("tag not found: %s", oldName)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-// PruneResult holds the result of a prune operation
-type PruneResult struct {
-	RemovedFolders []string
-	RemovedCount   int
+	m.notify(Change{Op: OpRename, Tag: oldName, NewTag: newName})
+	return nil
 }
 
-// Prune removes folders that no longer exist from the database
-func Prune(dryRun bool) (*PruneResult, error) {
-	database := db.GetDB()
-	if database == nil {
-		return nil, fmt.Errorf("database not initialized")
+// MergeTag moves every folder tagged from to into and deletes from. If into
+// doesn't exist yet, this is the same as renaming from.
+func (m *Manager) MergeTag(from, into string) error {
+	if from == into {
+		return fmt.Errorf("cannot merge tag '%s' into itself", from)
 	}
 
-	// Get all folders
-	rows, err := database.Query("SELECT id, path FROM folders")
+	database, err := m.writeDB()
 	if err != nil {
-		return nil, fmt.Errorf("failed to query folders: %w", err)
+		return err
 	}
-	defer func() { _ = rows.Close() }()
 
-	var toRemove []struct {
-		id   int64
-		path string
-	}
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		var fromID int64
+		err := tx.QueryRow("SELECT id FROM tags WHERE name = ? AND deleted_at = 0", from).Scan(&fromID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("tag not found: %s", from)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query tag: %w", err)
+		}
 
-	for rows.Next() {
-		var id int64
-		var path string
-		if err := rows.Scan(&id, &path); err != nil {
-			return nil, fmt.Errorf("failed to scan folder: %w", err)
+		if err := purgeTrashed(tx, "", into); err != nil {
+			return err
+		}
+		var intoID int64
+		err = tx.QueryRow("SELECT id FROM tags WHERE name = ?", into).Scan(&intoID)
+		if err == sql.ErrNoRows {
+			if _, err := tx.Exec("UPDATE tags SET name = ? WHERE id = ?", into, fromID); err != nil {
+				return fmt.Errorf("failed to rename tag: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query tag: %w", err)
 		}
 
-		// Check if folder exists
-		if _, err := os.Stat(path); os.IsNotExist(err) {
-			toRemove = append(toRemove, struct {
-				id   int64
-				path string
-			}{id, path})
+		// Folders with both tags keep their existing assignment of into
+		_, err = tx.Exec(`
+			INSERT OR IGNORE INTO folder_tags (folder_id, tag_id, created_at)
+			SELECT folder_id, ?, created_at FROM folder_tags WHERE tag_id = ?
+		`, intoID, fromID)
+		if err != nil {
+			return fmt.Errorf("failed to merge tag: %w", err)
 		}
+		if _, err := tx.Exec("DELETE FROM tags WHERE id = ?", fromID); err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.notify(Change{Op: OpMerge, Tag: from, NewTag: into})
+	return nil
+}
+
+// PruneResult holds the result of a prune operation
+type PruneResult struct {
+	RemovedFolders []string
+	RemovedCount   int
+}
+
+// Prune removes folders that no longer exist from the database, putting
+// them in the trash
+func (m *Manager) Prune(dryRun bool) (*PruneResult, error) {
+	toRemove, err := m.findMissing()
+	if err != nil {
+		return nil, err
 	}
 
 	result := &PruneResult{
@@ -338,12 +737,27 @@ func Prune(dryRun bool) (*PruneResult, error) {
 		return result, nil
 	}
 
-	// Remove non-existent folders
-	for _, f := range toRemove {
-		_, err := database.Exec("DELETE FROM folders WHERE id = ?", f.id)
-		if err != nil {
-			return nil, fmt.Errorf("failed to delete folder %s: %w", f.path, err)
+	database, err := m.writeDB()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove non-existent folders in one transaction so a concurrent AddTag
+	// either lands before the prune or after it, never in between
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		now := time.Now()
+		for _, f := range toRemove {
+			if _, err := tx.Exec("UPDATE folders SET deleted_at = ? WHERE id = ?", now.Unix(), f.id); err != nil {
+				return fmt.Errorf("failed to delete folder %s: %w", f.path, err)
+			}
 		}
+		return m.purgeExpired(tx, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range toRemove {
 		result.RemovedFolders = append(result.RemovedFolders, f.path)
 	}
 	result.RemovedCount = len(toRemove)
@@ -1,10 +1,13 @@
 package tag
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/gabssanto/Scope/internal/db"
@@ -19,6 +22,12 @@ func setupTestEnv(t *testing.T) (string, func()) {
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
 	}
+	// Tagged paths are stored with symlinks resolved, and the temp dir
+	// itself may live behind one (e.g. /var on macOS)
+	tmpDir, err = filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
 
 	testFolder := filepath.Join(tmpDir, "test-folder")
 	if err := os.MkdirAll(testFolder, 0755); err != nil {
@@ -74,6 +83,53 @@ func TestAddTagNonExistentFolder(t *testing.T) {
 	}
 }
 
+func TestAddTagRemote(t *testing.T) {
+	_, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	// Remote locations are stored normalized, without an existence check
+	if err := AddTag("sftp://me@build-box/srv/api", "remote"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	folders, err := ListFoldersByTag("remote")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if !reflect.DeepEqual(folders, []string{"me@build-box:/srv/api"}) {
+		t.Errorf("Expected normalized remote folder, got %v", folders)
+	}
+
+	tags, err := GetTagsForFolder("sftp://me@build-box/srv/api")
+	if err != nil {
+		t.Fatalf("GetTagsForFolder failed: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"remote"}) {
+		t.Errorf("Expected [remote] for either spelling, got %v", tags)
+	}
+
+	if err := AddTag("docker://api-dev/workspace/api", "remote"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	var kind string
+	err = db.GetReadDB().QueryRow("SELECT kind FROM folders WHERE path = ?", "docker://api-dev/workspace/api").Scan(&kind)
+	if err != nil {
+		t.Fatalf("Failed to read folder kind: %v", err)
+	}
+	if kind != "docker" {
+		t.Errorf("Expected kind docker, got %q", kind)
+	}
+
+	// Prune can't see remote folders and must leave them alone
+	result, err := Prune(false)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.RemovedCount != 0 {
+		t.Errorf("Prune removed remote folders: %v", result.RemovedFolders)
+	}
+}
+
 func TestAddMultipleTags(t *testing.T) {
 	testFolder, cleanup := setupTestEnv(t)
 	defer cleanup()
@@ -131,6 +187,44 @@ func TestAddTagIdempotent(t *testing.T) {
 	}
 }
 
+func TestTagFolders(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	other := filepath.Join(filepath.Dir(testFolder), "other")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	// Only the folders without the tag are reported
+	added, err := TagFolders([]string{testFolder, other}, "work")
+	if err != nil {
+		t.Fatalf("TagFolders failed: %v", err)
+	}
+	if !reflect.DeepEqual(added, []string{other}) {
+		t.Errorf("Expected added %v, got %v", []string{other}, added)
+	}
+
+	// A missing folder fails the whole batch
+	third := filepath.Join(filepath.Dir(testFolder), "third")
+	if err := os.MkdirAll(third, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if _, err := TagFolders([]string{third, "/nonexistent/folder"}, "api"); err == nil {
+		t.Fatal("TagFolders should fail for a non-existent folder")
+	}
+	folders, err := ListFoldersByTag("api")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if len(folders) != 0 {
+		t.Errorf("Expected no folders tagged, got %v", folders)
+	}
+}
+
 func TestRemoveTag(t *testing.T) {
 	testFolder, cleanup := setupTestEnv(t)
 	defer cleanup()
@@ -177,6 +271,106 @@ func TestRemoveTagFromNonExistentFolder(t *testing.T) {
 	}
 }
 
+func TestRemoveLastTagForgetsFolder(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	other := filepath.Join(filepath.Dir(testFolder), "other")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+
+	AddTag(testFolder, "work")
+	AddTag(testFolder, "api")
+	AddTag(other, "work")
+
+	countFolders := func() int {
+		t.Helper()
+		var n int
+		if err := db.Default().ReadDB().QueryRow("SELECT COUNT(*) FROM folders").Scan(&n); err != nil {
+			t.Fatalf("Failed to count folders: %v", err)
+		}
+		return n
+	}
+
+	if err := RemoveTag(testFolder, "work"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if n := countFolders(); n != 2 {
+		t.Errorf("Expected a folder with tags left to be kept, got %d folders", n)
+	}
+
+	if err := RemoveTag(testFolder, "api"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if n := countFolders(); n != 1 {
+		t.Errorf("Expected the untagged folder to be forgotten, got %d folders", n)
+	}
+
+	// The deleted tag keeps its folders in the trash until it is emptied
+	if err := DeleteTag("work"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if n := countFolders(); n != 1 {
+		t.Errorf("Expected the trashed tag to keep its folder, got %d folders", n)
+	}
+	if _, err := EmptyTrash(); err != nil {
+		t.Fatalf("EmptyTrash failed: %v", err)
+	}
+	if n := countFolders(); n != 0 {
+		t.Errorf("Expected emptying the trash to forget folders it untagged, got %d folders", n)
+	}
+}
+
+func TestEquivalentPathSpellings(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	// setupTestEnv points HOME at the folder's parent
+	t.Chdir(filepath.Dir(testFolder))
+
+	spellings := []struct {
+		name string
+		path string
+	}{
+		{"trailing slash", testFolder + "/"},
+		{"tilde", "~/test-folder"},
+		{"variable", "$HOME/test-folder"},
+		{"relative", "test-folder"},
+		{"dot segments", filepath.Join(testFolder, "..", "test-folder") + "/."},
+	}
+
+	for _, add := range spellings {
+		for _, remove := range spellings {
+			t.Run(add.name+"/"+remove.name, func(t *testing.T) {
+				if err := AddTag(add.path, "work"); err != nil {
+					t.Fatalf("AddTag failed: %v", err)
+				}
+
+				folders, err := ListAllFolders()
+				if err != nil {
+					t.Fatalf("ListAllFolders failed: %v", err)
+				}
+				if len(folders) != 1 || folders[0] != testFolder {
+					t.Fatalf("Expected %s to be stored as %s, got %v", add.path, testFolder, folders)
+				}
+
+				tags, err := GetTagsForFolder(remove.path)
+				if err != nil {
+					t.Fatalf("GetTagsForFolder failed: %v", err)
+				}
+				if len(tags) != 1 {
+					t.Errorf("Expected 1 tag for %s, got %v", remove.path, tags)
+				}
+
+				if err := RemoveTag(remove.path, "work"); err != nil {
+					t.Fatalf("RemoveTag failed: %v", err)
+				}
+			})
+		}
+	}
+}
+
 func TestDeleteTag(t *testing.T) {
 	testFolder, cleanup := setupTestEnv(t)
 	defer cleanup()
@@ -220,6 +414,143 @@ func TestDeleteTagNonExistent(t *testing.T) {
 	}
 }
 
+func TestRenameTag(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	AddTag(testFolder, "work")
+	AddTag(testFolder, "home")
+
+	if err := RenameTag("work", "home"); !errors.Is(err, ErrTagExists) {
+		t.Errorf("Expected ErrTagExists renaming onto an existing tag, got %v", err)
+	}
+	if err := RenameTag("work", "work"); !errors.Is(err, ErrTagExists) {
+		t.Errorf("Expected ErrTagExists renaming a tag to itself, got %v", err)
+	}
+	if err := RenameTag("missing", "other"); err == nil || errors.Is(err, ErrTagExists) {
+		t.Errorf("Expected tag not found, got %v", err)
+	}
+
+	// Of two renames to the same name at once, one wins and the other is
+	// told the name is taken
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, from := range []string{"work", "home"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = RenameTag(from, "job")
+		}()
+	}
+	wg.Wait()
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			if !errors.Is(err, ErrTagExists) {
+				t.Errorf("Expected ErrTagExists from the losing rename, got %v", err)
+			}
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Expected exactly one rename to fail, got %v", errs)
+	}
+	if tags, _ := ListTags(); tags["job"] != 1 || len(tags) != 2 {
+		t.Errorf("Expected 'job' and one of the old tags, got %v", tags)
+	}
+}
+
+func TestMergeTag(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	tmpDir := filepath.Dir(testFolder)
+	folder2 := filepath.Join(tmpDir, "folder2")
+	os.MkdirAll(folder2, 0755)
+
+	AddTag(testFolder, "Work")
+	AddTag(testFolder, "work")
+	AddTag(folder2, "Work")
+
+	if err := MergeTag("Work", "work"); err != nil {
+		t.Fatalf("MergeTag failed: %v", err)
+	}
+
+	tags, _ := ListTags()
+	if _, exists := tags["Work"]; exists {
+		t.Error("Merged tag should be deleted")
+	}
+	if tags["work"] != 2 {
+		t.Errorf("Expected 'work' on 2 folders, got %d", tags["work"])
+	}
+
+	// Merging into a tag that doesn't exist renames
+	if err := MergeTag("work", "job"); err != nil {
+		t.Fatalf("MergeTag failed: %v", err)
+	}
+	folders, _ := ListFoldersByTag("job")
+	if len(folders) != 2 {
+		t.Errorf("Expected 'job' on 2 folders, got %v", folders)
+	}
+
+	if err := MergeTag("missing", "job"); err == nil {
+		t.Error("MergeTag should fail for a non-existent tag")
+	}
+	if err := MergeTag("job", "job"); err == nil {
+		t.Error("MergeTag should refuse to merge a tag into itself")
+	}
+}
+
+func TestMoveFolder(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	tmpDir := filepath.Dir(testFolder)
+	moved := filepath.Join(tmpDir, "moved")
+	other := filepath.Join(tmpDir, "other")
+	for _, dir := range []string{moved, other} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := SetNote(testFolder, "keep me"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	if err := AddTag(other, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	var changes []Change
+	Default().Observe(func(c Change) { changes = append(changes, c) })
+
+	if err := MoveFolder(testFolder, moved); err != nil {
+		t.Fatalf("MoveFolder failed: %v", err)
+	}
+	tags, _ := GetTagsForFolder(moved)
+	if len(tags) != 1 || tags[0] != "work" {
+		t.Errorf("Expected the moved folder to keep its tags, got %v", tags)
+	}
+	if note, _ := GetNote(moved); note != "keep me" {
+		t.Errorf("Expected the moved folder to keep its note, got %q", note)
+	}
+	if len(changes) != 1 || changes[0] != (Change{Op: OpMove, Path: testFolder, NewPath: moved}) {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+
+	if err := MoveFolder(moved, other); err == nil {
+		t.Error("MoveFolder should refuse a path that is already tagged")
+	}
+	if err := MoveFolder(testFolder, moved); err == nil {
+		t.Error("MoveFolder should fail for a folder that is not tagged")
+	}
+	if err := MoveFolder(moved, filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("MoveFolder should fail for a path that does not exist")
+	}
+}
+
 func TestListTags(t *testing.T) {
 	testFolder, cleanup := setupTestEnv(t)
 	defer cleanup()
@@ -425,6 +756,188 @@ func TestConcurrentTagOperations(t *testing.T) {
 	}
 }
 
+func TestConcurrentAddTag(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	tmpDir := filepath.Dir(testFolder)
+
+	// Many writers racing to create the same tag on different folders
+	const writers = 20
+	folders := make([]string, writers)
+	for i := range folders {
+		folders[i] = filepath.Join(tmpDir, fmt.Sprintf("concurrent-%d", i))
+		os.MkdirAll(folders[i], 0755)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for _, folder := range folders {
+		wg.Add(1)
+		go func(f string) {
+			defer wg.Done()
+			errs <- AddTag(f, "race")
+		}(folder)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent AddTag failed: %v", err)
+		}
+	}
+
+	// No update may be lost
+	got, err := ListFoldersByTag("race")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if len(got) != writers {
+		t.Errorf("Expected %d folders with 'race', got %d", writers, len(got))
+	}
+}
+
+func TestConcurrentAddTagAndPrune(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	tmpDir := filepath.Dir(testFolder)
+
+	// Stale folders for Prune to remove
+	for i := 0; i < 10; i++ {
+		stale := filepath.Join(tmpDir, fmt.Sprintf("stale-%d", i))
+		os.MkdirAll(stale, 0755)
+		if err := AddTag(stale, "mixed"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+		os.RemoveAll(stale)
+	}
+
+	// Live folders added while Prune runs
+	live := make([]string, 10)
+	for i := range live {
+		live[i] = filepath.Join(tmpDir, fmt.Sprintf("live-%d", i))
+		os.MkdirAll(live[i], 0755)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for _, folder := range live {
+			if err := AddTag(folder, "mixed"); err != nil {
+				t.Errorf("AddTag during prune failed: %v", err)
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if _, err := Prune(false); err != nil {
+			t.Errorf("Prune during AddTag failed: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	got, err := ListFoldersByTag("mixed")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+
+	sort.Strings(live)
+	if !reflect.DeepEqual(got, live) {
+		t.Errorf("Expected only live folders %v, got %v", live, got)
+	}
+}
+
+func TestManagerWithExplicitStore(t *testing.T) {
+	t.Parallel()
+
+	// Two stores side by side must not see each other's tags
+	newStore := func() (*db.Store, string) {
+		dir := t.TempDir()
+		store, err := db.Open(filepath.Join(dir, "scope.db"), db.PoolOptions{})
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store, dir
+	}
+
+	storeA, dirA := newStore()
+	storeB, _ := newStore()
+
+	managerA := NewManager(storeA)
+	managerB := NewManager(storeB)
+
+	if err := managerA.AddTag(dirA, "only-a"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	tagsA, err := managerA.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if tagsA["only-a"] != 1 {
+		t.Errorf("Expected 'only-a' in store A, got %v", tagsA)
+	}
+
+	tagsB, err := managerB.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if len(tagsB) != 0 {
+		t.Errorf("Expected store B to be empty, got %v", tagsB)
+	}
+}
+
+func TestManagerReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	folder := filepath.Join(dir, "project")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+
+	path := filepath.Join(dir, "scope.db")
+	store, err := db.Open(path, db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := NewManager(store).AddTag(folder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	store.Close()
+
+	store, err = db.Open(path, db.PoolOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer store.Close()
+	m := NewManager(store)
+
+	if err := m.AddTag(folder, "other"); !errors.Is(err, db.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from AddTag, got %v", err)
+	}
+	if err := m.DeleteTag("work"); !errors.Is(err, db.ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly from DeleteTag, got %v", err)
+	}
+
+	// Reads and dry runs still work
+	if folders, err := m.ListFoldersByTag("work"); err != nil || len(folders) != 1 {
+		t.Errorf("Expected reads to work, got %v, %v", folders, err)
+	}
+	if _, err := m.Prune(true); err != nil {
+		t.Errorf("Prune dry run failed: %v", err)
+	}
+}
+
+func TestManagerWithoutDatabase(t *testing.T) {
+	// The default manager before InitDB
+	if _, err := NewManager(nil).ListTags(); err == nil {
+		t.Error("ListTags should fail when no store is available")
+	}
+}
+
 func BenchmarkAddTag(b *testing.B) {
 	tmpDir, _ := os.MkdirTemp("", "scope-tag-bench-*")
 	defer os.RemoveAll(tmpDir)
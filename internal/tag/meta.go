@@ -0,0 +1,118 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// SetMeta sets metadata of a tagged folder. Keys with an empty value are
+// removed; keys not given are left alone.
+func (m *Manager) SetMeta(path string, values map[string]string) error {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(database, func(tx *sql.Tx) error {
+		var folderID int64
+		err := tx.QueryRow("SELECT id FROM folders WHERE path IN (?, ?) AND deleted_at = 0", abs, m.canonical(abs)).Scan(&folderID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("folder is not tagged: %s", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+
+		now := time.Now().Unix()
+		for key, value := range values {
+			if value == "" {
+				if _, err := tx.Exec("DELETE FROM folder_meta WHERE folder_id = ? AND key = ?", folderID, key); err != nil {
+					return fmt.Errorf("failed to remove %s: %w", key, err)
+				}
+				continue
+			}
+			_, err := tx.Exec(`
+				INSERT INTO folder_meta (folder_id, key, value, updated_at) VALUES (?, ?, ?, ?)
+				ON CONFLICT(folder_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+			`, folderID, key, value, now)
+			if err != nil {
+				return fmt.Errorf("failed to save %s: %w", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetMeta returns the metadata of a folder
+func (m *Manager) GetMeta(path string) (map[string]string, error) {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(`
+		SELECT fm.key, fm.value
+		FROM folder_meta fm
+		JOIN folders f ON fm.folder_id = f.id
+		WHERE f.path IN (?, ?) AND f.deleted_at = 0
+	`, abs, m.canonical(abs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metadata: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	meta := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan metadata: %w", err)
+		}
+		meta[key] = value
+	}
+	return meta, nil
+}
+
+// ListMeta returns the metadata of all folders, keyed by path
+func (m *Manager) ListMeta() (map[string]map[string]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(`
+		SELECT f.path, fm.key, fm.value
+		FROM folder_meta fm
+		JOIN folders f ON fm.folder_id = f.id
+		WHERE f.deleted_at = 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metadata: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	meta := make(map[string]map[string]string)
+	for rows.Next() {
+		var path, key, value string
+		if err := rows.Scan(&path, &key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan metadata: %w", err)
+		}
+		if meta[path] == nil {
+			meta[path] = make(map[string]string)
+		}
+		meta[path][key] = value
+	}
+	return meta, nil
+}
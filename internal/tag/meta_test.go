@@ -0,0 +1,47 @@
+package tag
+
+import (
+	"testing"
+)
+
+func TestSetMeta(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := SetMeta(testFolder, map[string]string{"k": "v"}); err == nil {
+		t.Error("SetMeta should fail for a folder that isn't tagged")
+	}
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	if err := SetMeta(testFolder, map[string]string{"github.description": "API", "github.archived": "true"}); err != nil {
+		t.Fatalf("SetMeta failed: %v", err)
+	}
+	if err := SetMeta(testFolder, map[string]string{"github.description": "Public API", "github.archived": ""}); err != nil {
+		t.Fatalf("SetMeta failed: %v", err)
+	}
+
+	meta, err := GetMeta(testFolder)
+	if err != nil {
+		t.Fatalf("GetMeta failed: %v", err)
+	}
+	if len(meta) != 1 || meta["github.description"] != "Public API" {
+		t.Errorf("Expected the description updated and archived removed, got %v", meta)
+	}
+
+	all, err := ListMeta()
+	if err != nil {
+		t.Fatalf("ListMeta failed: %v", err)
+	}
+	if len(all) != 1 || all[testFolder]["github.description"] != "Public API" {
+		t.Errorf("Unexpected metadata %v", all)
+	}
+
+	if err := RemoveFolder(testFolder); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if all, _ := ListMeta(); len(all) != 0 {
+		t.Errorf("Expected metadata removed with the folder, got %v", all)
+	}
+}
@@ -0,0 +1,119 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// SetNote attaches a free-form note to a tagged folder. An empty note
+// removes it.
+func (m *Manager) SetNote(path, note string) error {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	note = strings.TrimSpace(note)
+
+	var stored string
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		var folderID int64
+		err := tx.QueryRow("SELECT id, path FROM folders WHERE path IN (?, ?) AND deleted_at = 0", abs, m.canonical(abs)).Scan(&folderID, &stored)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("folder is not tagged: %s", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+
+		if note == "" {
+			if _, err := tx.Exec("DELETE FROM folder_notes WHERE folder_id = ?", folderID); err != nil {
+				return fmt.Errorf("failed to remove note: %w", err)
+			}
+			return nil
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO folder_notes (folder_id, note, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT(folder_id) DO UPDATE SET note = excluded.note, updated_at = excluded.updated_at
+		`, folderID, note, time.Now().Unix())
+		if err != nil {
+			return fmt.Errorf("failed to save note: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.notify(Change{Op: OpNote, Path: stored, Note: note})
+	return nil
+}
+
+// GetNote returns the note of a folder, or "" if it has none
+func (m *Manager) GetNote(path string) (string, error) {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	database, err := m.readDB()
+	if err != nil {
+		return "", err
+	}
+
+	var note string
+	err = database.QueryRow(`
+		SELECT n.note
+		FROM folder_notes n
+		JOIN folders f ON n.folder_id = f.id
+		WHERE f.path IN (?, ?) AND f.deleted_at = 0
+	`, abs, m.canonical(abs)).Scan(&note)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query note: %w", err)
+	}
+
+	return note, nil
+}
+
+// ListNotes returns the notes of all folders, keyed by path
+func (m *Manager) ListNotes() (map[string]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query(`
+		SELECT f.path, n.note
+		FROM folder_notes n
+		JOIN folders f ON n.folder_id = f.id
+		WHERE f.deleted_at = 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	notes := make(map[string]string)
+	for rows.Next() {
+		var path, note string
+		if err := rows.Scan(&path, &note); err != nil {
+			return nil, fmt.Errorf("failed to scan note: %w", err)
+		}
+		notes[path] = note
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,78 @@
+package tag
+
+import (
+	"testing"
+)
+
+func TestSetNote(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	if err := SetNote(testFolder, "  Main service  "); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+
+	note, err := GetNote(testFolder)
+	if err != nil {
+		t.Fatalf("GetNote failed: %v", err)
+	}
+	if note != "Main service" {
+		t.Errorf("Expected trimmed note 'Main service', got %q", note)
+	}
+
+	// Overwrite
+	if err := SetNote(testFolder, "Deprecated"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	notes, err := ListNotes()
+	if err != nil {
+		t.Fatalf("ListNotes failed: %v", err)
+	}
+	if len(notes) != 1 || notes[testFolder] != "Deprecated" {
+		t.Errorf("Expected one updated note, got %v", notes)
+	}
+
+	// Clear
+	if err := SetNote(testFolder, ""); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	if note, _ := GetNote(testFolder); note != "" {
+		t.Errorf("Expected note to be cleared, got %q", note)
+	}
+}
+
+func TestSetNoteUntaggedFolder(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := SetNote(testFolder, "hello"); err == nil {
+		t.Error("SetNote should fail for a folder that isn't tagged")
+	}
+}
+
+func TestNoteRemovedWithFolder(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := SetNote(testFolder, "hello"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	if err := RemoveFolder(testFolder); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+
+	notes, err := ListNotes()
+	if err != nil {
+		t.Fatalf("ListNotes failed: %v", err)
+	}
+	if len(notes) != 0 {
+		t.Errorf("Expected note to be deleted with its folder, got %v", notes)
+	}
+}
@@ -0,0 +1,51 @@
+package tag
+
+// Op names a kind of change to the tag database
+type Op string
+
+const (
+	OpAdd       Op = "add"        // Tag added to Path
+	OpRemove    Op = "remove"     // Tag removed from Path
+	OpDeleteTag Op = "delete-tag" // Tag deleted from every folder
+	OpRename    Op = "rename"     // Tag renamed to NewTag
+	OpMerge     Op = "merge"      // Tag merged into NewTag
+	OpForget    Op = "forget"     // Path and all its tags removed
+	OpMove      Op = "move"       // Path moved to NewPath, keeping its tags
+	OpNote      Op = "note"       // Note of Path set (empty when cleared)
+	OpSubdir    Op = "subdir"     // Working subdirectory of Path set (empty when reset)
+)
+
+// Change describes a mutation made through a Manager. Paths are stored
+// paths, as returned by the List functions.
+type Change struct {
+	Op      Op
+	Path    string
+	NewPath string
+	Tag     string
+	NewTag  string
+	Note    string
+	Subdir  string
+}
+
+// Observe registers fn to be called after every successful user-initiated
+// change (AddTag, RemoveTag, DeleteTag, RenameTag, MergeTag, RemoveFolder,
+// MoveFolder, SetNote, SetSubdir, and Restore as OpAdd). Maintenance such as
+// Prune and Doctor is not reported.
+// Observers run synchronously, in registration order, on the goroutine that
+// made the change.
+func (m *Manager) Observe(fn func(Change)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.observers = append(m.observers, fn)
+}
+
+// notify calls the observers with c
+func (m *Manager) notify(c Change) {
+	m.mu.Lock()
+	observers := m.observers
+	m.mu.Unlock()
+
+	for _, fn := range observers {
+		fn(c)
+	}
+}
@@ -0,0 +1,64 @@
+package tag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObserve(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	m := NewManager(nil)
+	var changes []Change
+	m.Observe(func(c Change) { changes = append(changes, c) })
+
+	if err := m.AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.SetNote(testFolder, "  notes  "); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+	if err := m.RenameTag("work", "job"); err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	if err := m.AddTag(testFolder, "jobs"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.MergeTag("jobs", "job"); err != nil {
+		t.Fatalf("MergeTag failed: %v", err)
+	}
+	// A second tag keeps the folder when job is removed
+	if err := m.AddTag(testFolder, "home"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.RemoveTag(testFolder, "job"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+	if err := m.DeleteTag("job"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if err := m.RemoveFolder(testFolder); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+
+	// Failed changes are not reported
+	if err := m.DeleteTag("missing"); err == nil {
+		t.Fatal("DeleteTag should fail for a missing tag")
+	}
+
+	expected := []Change{
+		{Op: OpAdd, Path: testFolder, Tag: "work"},
+		{Op: OpNote, Path: testFolder, Note: "notes"},
+		{Op: OpRename, Tag: "work", NewTag: "job"},
+		{Op: OpAdd, Path: testFolder, Tag: "jobs"},
+		{Op: OpMerge, Tag: "jobs", NewTag: "job"},
+		{Op: OpAdd, Path: testFolder, Tag: "home"},
+		{Op: OpRemove, Path: testFolder, Tag: "job"},
+		{Op: OpDeleteTag, Tag: "job"},
+		{Op: OpForget, Path: testFolder},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+}
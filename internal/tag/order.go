@@ -0,0 +1,67 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// SetOrder puts the folders of a tag in the given order; ListFoldersByTag
+// returns them first, followed by any other folders of the tag by path.
+// Folders are stored paths, as ListFoldersByTag returns them. An empty
+// list resets the tag to path order.
+func (m *Manager) SetOrder(tagName string, folders []string) error {
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(database, func(tx *sql.Tx) error {
+		var tagID int64
+		err := tx.QueryRow("SELECT id FROM tags WHERE name = ? AND deleted_at = 0", tagName).Scan(&tagID)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("tag not found: %s", tagName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query tag: %w", err)
+		}
+
+		if _, err := tx.Exec("UPDATE folder_tags SET position = 0 WHERE tag_id = ?", tagID); err != nil {
+			return fmt.Errorf("failed to reset order: %w", err)
+		}
+		for i, folder := range folders {
+			result, err := tx.Exec(`
+				UPDATE folder_tags SET position = ?
+				WHERE tag_id = ? AND folder_id = (SELECT id FROM folders WHERE path = ? AND deleted_at = 0)
+			`, i+1, tagID, folder)
+			if err != nil {
+				return fmt.Errorf("failed to save order: %w", err)
+			}
+			if n, _ := result.RowsAffected(); n == 0 {
+				return fmt.Errorf("folder %s is not tagged '%s'", folder, tagName)
+			}
+		}
+		return nil
+	})
+}
+
+// IsOrdered reports whether the folders of a tag have a custom order
+func (m *Manager) IsOrdered(tagName string) (bool, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return false, err
+	}
+
+	var ordered bool
+	err = database.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM folder_tags ft JOIN tags t ON ft.tag_id = t.id
+			WHERE t.name = ? AND t.deleted_at = 0 AND ft.position > 0
+		)
+	`, tagName).Scan(&ordered)
+	if err != nil {
+		return false, fmt.Errorf("failed to query order: %w", err)
+	}
+	return ordered, nil
+}
@@ -0,0 +1,69 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSetOrder(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	root := filepath.Dir(testFolder)
+	var folders []string
+	for _, name := range []string{"a", "b", "c", "d"} {
+		folder := filepath.Join(root, name)
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := AddTag(folder, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+		folders = append(folders, folder)
+	}
+	a, b, c, d := folders[0], folders[1], folders[2], folders[3]
+
+	if ordered, err := IsOrdered("work"); err != nil || ordered {
+		t.Errorf("A new tag should be unordered, got %v, %v", ordered, err)
+	}
+
+	// Folders left out of the order follow by path
+	if err := SetOrder("work", []string{c, a}); err != nil {
+		t.Fatalf("SetOrder failed: %v", err)
+	}
+	got, err := ListFoldersByTag("work")
+	if err != nil {
+		t.Fatalf("ListFoldersByTag failed: %v", err)
+	}
+	if expected := []string{c, a, b, d}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if ordered, _ := IsOrdered("work"); !ordered {
+		t.Error("Expected the tag to be ordered")
+	}
+
+	// Other tags of the same folders keep their own order
+	if err := AddTag(d, "oss"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if ordered, _ := IsOrdered("oss"); ordered {
+		t.Error("Ordering one tag shouldn't order another")
+	}
+
+	if err := SetOrder("work", []string{testFolder}); err == nil {
+		t.Error("SetOrder should reject folders without the tag")
+	}
+	if err := SetOrder("missing", nil); err == nil {
+		t.Error("SetOrder should reject unknown tags")
+	}
+
+	if err := SetOrder("work", nil); err != nil {
+		t.Fatalf("SetOrder failed: %v", err)
+	}
+	got, _ = ListFoldersByTag("work")
+	if !reflect.DeepEqual(got, folders) {
+		t.Errorf("Expected path order after reset, got %v", got)
+	}
+}
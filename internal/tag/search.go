@@ -0,0 +1,82 @@
+package tag
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/location"
+)
+
+// Search returns the tagged folders with every word of query in their
+// path, tags or note, ignoring case. Folders whose name is the query come
+// first, then those whose name starts with it, then those whose name
+// contains it, then the rest, each sorted by name. An empty query matches
+// every folder.
+func (m *Manager) Search(query string) ([]string, error) {
+	folderTags, err := m.ListFolderTags()
+	if err != nil {
+		return nil, err
+	}
+	notes, err := m.ListNotes()
+	if err != nil {
+		return nil, err
+	}
+
+	q := strings.ToLower(strings.TrimSpace(query))
+	words := strings.Fields(q)
+	type result struct {
+		path, name string
+		rank       int
+	}
+	var results []result
+	for path, tags := range folderTags {
+		text := strings.ToLower(path + " " + strings.Join(tags, " ") + " " + notes[path])
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(text, word) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		name := strings.ToLower(folderName(path))
+		rank := 3
+		switch {
+		case name == q:
+			rank = 0
+		case strings.HasPrefix(name, q):
+			rank = 1
+		case strings.Contains(name, q):
+			rank = 2
+		}
+		results = append(results, result{path: path, name: name, rank: rank})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.path < b.path
+	})
+	folders := make([]string, len(results))
+	for i, r := range results {
+		folders[i] = r.path
+	}
+	return folders, nil
+}
+
+// folderName is the last element of a folder's path, local or remote
+func folderName(path string) string {
+	if loc, ok := location.Parse(path); ok {
+		return loc.Base()
+	}
+	return filepath.Base(path)
+}
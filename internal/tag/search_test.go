@@ -0,0 +1,55 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	root := filepath.Dir(testFolder)
+	folders := map[string]string{}
+	for _, name := range []string{"api", "api-docs", "web-api", "site"} {
+		folders[name] = filepath.Join(root, name)
+		if err := os.Mkdir(folders[name], 0755); err != nil {
+			t.Fatalf("Mkdir failed: %v", err)
+		}
+		if err := AddTag(folders[name], "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	if err := AddTag(folders["site"], "frontend"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := SetNote(folders["site"], "Talks to the API"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		// By name first, then anything mentioning it
+		{"API", []string{"api", "api-docs", "web-api", "site"}},
+		{"work front", []string{"site"}},
+		{"docs", []string{"api-docs"}},
+		{"nothing", []string{}},
+	}
+	for _, tt := range tests {
+		got, err := Search(tt.query)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", tt.query, err)
+		}
+		want := make([]string, len(tt.expected))
+		for i, name := range tt.expected {
+			want[i] = folders[name]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.query, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// CleanSubdir validates a working subdirectory and returns it in the form
+// it is stored: slash-separated, relative and inside the folder. An empty
+// or "." subdir is returned as "".
+func CleanSubdir(subdir string) (string, error) {
+	subdir = strings.TrimSpace(strings.ReplaceAll(subdir, "\\", "/"))
+	if subdir == "" {
+		return "", nil
+	}
+	if path.IsAbs(subdir) {
+		return "", fmt.Errorf("subdirectory must be relative to the folder: %s", subdir)
+	}
+
+	subdir = path.Clean(subdir)
+	if subdir == "." {
+		return "", nil
+	}
+	if subdir == ".." || strings.HasPrefix(subdir, "../") {
+		return "", fmt.Errorf("subdirectory must be inside the folder: %s", subdir)
+	}
+	return subdir, nil
+}
+
+// SetSubdir sets the subdirectory of a tagged folder that go, each and
+// edit work in, for folders whose interesting code lives below the tagged
+// path. An empty subdir resets it to the folder itself.
+func (m *Manager) SetSubdir(path, subdir string) error {
+	subdir, err := CleanSubdir(subdir)
+	if err != nil {
+		return err
+	}
+	abs, err := m.resolve(path)
+	if err != nil {
+		return err
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return err
+	}
+
+	var stored string
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		var folderID int64
+		err := tx.QueryRow("SELECT id, path FROM folders WHERE path IN (?, ?) AND deleted_at = 0", abs, m.canonical(abs)).Scan(&folderID, &stored)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("folder is not tagged: %s", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+
+		if _, err := tx.Exec("UPDATE folders SET subdir = ? WHERE id = ?", subdir, folderID); err != nil {
+			return fmt.Errorf("failed to save subdirectory: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	m.notify(Change{Op: OpSubdir, Path: stored, Subdir: subdir})
+	return nil
+}
+
+// GetSubdir returns the working subdirectory of a folder, or "" if it has
+// none
+func (m *Manager) GetSubdir(path string) (string, error) {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	database, err := m.readDB()
+	if err != nil {
+		return "", err
+	}
+
+	var subdir string
+	err = database.QueryRow("SELECT subdir FROM folders WHERE path IN (?, ?) AND deleted_at = 0", abs, m.canonical(abs)).Scan(&subdir)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query subdirectory: %w", err)
+	}
+
+	return subdir, nil
+}
+
+// ListSubdirs returns the working subdirectories of all folders that have
+// one, keyed by path
+func (m *Manager) ListSubdirs() (map[string]string, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := database.Query("SELECT path, subdir FROM folders WHERE subdir != '' AND deleted_at = 0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subdirectories: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	subdirs := make(map[string]string)
+	for rows.Next() {
+		var path, subdir string
+		if err := rows.Scan(&path, &subdir); err != nil {
+			return nil, fmt.Errorf("failed to scan subdirectory: %w", err)
+		}
+		subdirs[path] = subdir
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read subdirectories: %w", err)
+	}
+
+	return subdirs, nil
+}
@@ -0,0 +1,96 @@
+package tag
+
+import (
+	"testing"
+)
+
+func TestCleanSubdir(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{".", "", false},
+		{"backend", "backend", false},
+		{"backend/", "backend", false},
+		{"./services/api/", "services/api", false},
+		{`services\api`, "services/api", false},
+		{"a/../b", "b", false},
+		{"..", "", true},
+		{"../other", "", true},
+		{"a/../../other", "", true},
+		{"/abs", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := CleanSubdir(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CleanSubdir(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CleanSubdir(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSetSubdir(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+
+	if err := SetSubdir(testFolder, "backend/"); err != nil {
+		t.Fatalf("SetSubdir failed: %v", err)
+	}
+	subdir, err := GetSubdir(testFolder)
+	if err != nil {
+		t.Fatalf("GetSubdir failed: %v", err)
+	}
+	if subdir != "backend" {
+		t.Errorf("Expected subdir 'backend', got %q", subdir)
+	}
+
+	subdirs, err := ListSubdirs()
+	if err != nil {
+		t.Fatalf("ListSubdirs failed: %v", err)
+	}
+	if len(subdirs) != 1 || subdirs[testFolder] != "backend" {
+		t.Errorf("Expected one subdir, got %v", subdirs)
+	}
+
+	// Adding another tag keeps the subdir
+	if err := AddTag(testFolder, "api"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if subdir, _ := GetSubdir(testFolder); subdir != "backend" {
+		t.Errorf("Expected subdir to survive tagging, got %q", subdir)
+	}
+
+	// Reset
+	if err := SetSubdir(testFolder, ""); err != nil {
+		t.Fatalf("SetSubdir failed: %v", err)
+	}
+	if subdirs, _ := ListSubdirs(); len(subdirs) != 0 {
+		t.Errorf("Expected subdir to be cleared, got %v", subdirs)
+	}
+}
+
+func TestSetSubdirInvalid(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	if err := SetSubdir(testFolder, "backend"); err == nil {
+		t.Error("SetSubdir should fail for a folder that isn't tagged")
+	}
+
+	if err := AddTag(testFolder, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := SetSubdir(testFolder, "../elsewhere"); err == nil {
+		t.Error("SetSubdir should reject a subdirectory outside the folder")
+	}
+}
@@ -0,0 +1,182 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// Todo is a task attached to a tagged folder
+type Todo struct {
+	ID      int64
+	Path    string
+	Text    string
+	Created time.Time
+	// Done is when the todo was completed; zero while it is open
+	Done time.Time
+}
+
+// IsDone reports whether the todo has been completed
+func (t Todo) IsDone() bool {
+	return !t.Done.IsZero()
+}
+
+// AddTodo attaches a todo to a tagged folder
+func (m *Manager) AddTodo(path, text string) (Todo, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Todo{}, fmt.Errorf("todo text cannot be empty")
+	}
+	abs, err := m.resolve(path)
+	if err != nil {
+		return Todo{}, err
+	}
+
+	database, err := m.writeDB()
+	if err != nil {
+		return Todo{}, err
+	}
+
+	todo := Todo{Text: text, Created: time.Unix(time.Now().Unix(), 0)}
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		var folderID int64
+		err := tx.QueryRow("SELECT id, path FROM folders WHERE path IN (?, ?) AND deleted_at = 0", abs, m.canonical(abs)).Scan(&folderID, &todo.Path)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("folder is not tagged: %s", path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+
+		result, err := tx.Exec("INSERT INTO folder_todos (folder_id, text, created_at) VALUES (?, ?, ?)",
+			folderID, text, todo.Created.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to save todo: %w", err)
+		}
+		todo.ID, err = result.LastInsertId()
+		return err
+	})
+	if err != nil {
+		return Todo{}, err
+	}
+
+	return todo, nil
+}
+
+// SetTodoDone marks a todo as done, or open again
+func (m *Manager) SetTodoDone(id int64, done bool) (Todo, error) {
+	database, err := m.writeDB()
+	if err != nil {
+		return Todo{}, err
+	}
+
+	var doneAt int64
+	if done {
+		doneAt = time.Now().Unix()
+	}
+
+	var todo Todo
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`
+			UPDATE folder_todos SET done_at = ?
+			WHERE id = ? AND folder_id IN (SELECT id FROM folders WHERE deleted_at = 0)
+		`, doneAt, id)
+		if err != nil {
+			return fmt.Errorf("failed to update todo: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return fmt.Errorf("no todo with id %d", id)
+		}
+
+		todos, err := queryTodos(tx, "t.id = ?", id)
+		if err != nil {
+			return err
+		}
+		todo = todos[0]
+		return nil
+	})
+	if err != nil {
+		return Todo{}, err
+	}
+
+	return todo, nil
+}
+
+// ListTodos returns the todos of a folder, oldest first. Done todos are
+// included only if all is set.
+func (m *Manager) ListTodos(path string, all bool) ([]Todo, error) {
+	abs, err := m.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	return queryTodos(database, todoFilter("f.path IN (?, ?)", all), abs, m.canonical(abs))
+}
+
+// ListTodosByTag returns the todos of every folder with a tag, grouped by
+// folder and oldest first; an empty tag lists the todos of all folders
+func (m *Manager) ListTodosByTag(tagName string, all bool) ([]Todo, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	if tagName == "" {
+		return queryTodos(database, todoFilter("1", all))
+	}
+	return queryTodos(database, todoFilter(`f.id IN (
+		SELECT ft.folder_id FROM folder_tags ft JOIN tags tg ON ft.tag_id = tg.id WHERE tg.name = ? AND tg.deleted_at = 0
+	)`, all), tagName)
+}
+
+// todoFilter adds the open-only condition to where unless all is set
+func todoFilter(where string, all bool) string {
+	if all {
+		return where
+	}
+	return "(" + where + ") AND t.done_at = 0"
+}
+
+// queryer is the part of *sql.DB and *sql.Tx that queryTodos needs
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// queryTodos returns the todos matching where, ordered by folder and age
+func queryTodos(q queryer, where string, args ...any) ([]Todo, error) {
+	rows, err := q.Query(`
+		SELECT t.id, f.path, t.text, t.created_at, t.done_at
+		FROM folder_todos t
+		JOIN folders f ON t.folder_id = f.id
+		WHERE f.deleted_at = 0 AND (`+where+`)
+		ORDER BY f.path, t.created_at, t.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query todos: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var todos []Todo
+	for rows.Next() {
+		var todo Todo
+		var created, done int64
+		if err := rows.Scan(&todo.ID, &todo.Path, &todo.Text, &created, &done); err != nil {
+			return nil, fmt.Errorf("failed to scan todo: %w", err)
+		}
+		todo.Created = time.Unix(created, 0)
+		if done != 0 {
+			todo.Done = time.Unix(done, 0)
+		}
+		todos = append(todos, todo)
+	}
+
+	return todos, rows.Err()
+}
@@ -0,0 +1,100 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTodos(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	other := filepath.Join(filepath.Dir(testFolder), "other")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	for _, tt := range []struct{ path, tag string }{{testFolder, "work"}, {other, "work"}, {other, "oss"}} {
+		if err := AddTag(tt.path, tt.tag); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	first, err := AddTodo(testFolder, "  Fix flaky test ")
+	if err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+	if first.Text != "Fix flaky test" || first.Path != testFolder || first.ID == 0 || first.IsDone() {
+		t.Errorf("Unexpected todo %+v", first)
+	}
+	if _, err := AddTodo(testFolder, "Update README"); err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+	if _, err := AddTodo(other, "Release 1.0"); err != nil {
+		t.Fatalf("AddTodo failed: %v", err)
+	}
+
+	done, err := SetTodoDone(first.ID, true)
+	if err != nil {
+		t.Fatalf("SetTodoDone failed: %v", err)
+	}
+	if !done.IsDone() || done.Text != "Fix flaky test" {
+		t.Errorf("Expected the todo to be done, got %+v", done)
+	}
+
+	tests := []struct {
+		name  string
+		list  func() ([]Todo, error)
+		texts []string
+	}{
+		{"folder", func() ([]Todo, error) { return ListTodos(testFolder, false) }, []string{"Update README"}},
+		{"folder with done", func() ([]Todo, error) { return ListTodos(testFolder, true) }, []string{"Fix flaky test", "Update README"}},
+		{"tag", func() ([]Todo, error) { return ListTodosByTag("oss", false) }, []string{"Release 1.0"}},
+		{"all", func() ([]Todo, error) { return ListTodosByTag("", false) }, []string{"Release 1.0", "Update README"}},
+	}
+	for _, tt := range tests {
+		todos, err := tt.list()
+		if err != nil {
+			t.Fatalf("%s: listing failed: %v", tt.name, err)
+		}
+		var texts []string
+		for _, todo := range todos {
+			texts = append(texts, todo.Text)
+		}
+		if len(texts) != len(tt.texts) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.texts, texts)
+			continue
+		}
+		for i := range texts {
+			if texts[i] != tt.texts[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.texts, texts)
+				break
+			}
+		}
+	}
+
+	if _, err := SetTodoDone(first.ID, false); err != nil {
+		t.Fatalf("SetTodoDone failed: %v", err)
+	}
+	if todos, _ := ListTodos(testFolder, false); len(todos) != 2 {
+		t.Errorf("Expected the todo to be open again, got %+v", todos)
+	}
+
+	if _, err := SetTodoDone(999, true); err == nil {
+		t.Error("SetTodoDone should fail for an unknown todo")
+	}
+	if _, err := AddTodo(testFolder, "  "); err == nil {
+		t.Error("AddTodo should reject empty text")
+	}
+	if _, err := AddTodo(filepath.Dir(testFolder), "x"); err == nil {
+		t.Error("AddTodo should fail for a folder that isn't tagged")
+	}
+
+	// Todos go with their folder
+	if err := RemoveFolder(other); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if todos, _ := ListTodosByTag("", true); len(todos) != 2 {
+		t.Errorf("Expected the removed folder's todos to be deleted, got %+v", todos)
+	}
+}
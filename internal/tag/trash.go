@@ -0,0 +1,258 @@
+package tag
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+// DefaultTrashRetention is how long deleted tags and folders stay in the
+// trash when no retention is set
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashItem is a deleted tag or folder that can still be restored
+type TrashItem struct {
+	Kind string // "tag" or "folder"
+	Name string // Tag name, or the stored path of a folder
+	// Count is the number of folders of a tag, or of tags of a folder
+	Count     int
+	DeletedAt time.Time
+	ExpiresAt time.Time
+}
+
+// SetTrashRetention sets how long deleted tags and folders can be restored.
+// Zero uses DefaultTrashRetention; a negative retention disables the trash,
+// deleting for good right away.
+func (m *Manager) SetTrashRetention(d time.Duration) {
+	m.retention = d
+}
+
+// TrashEnabled reports whether deleted tags and folders go to the trash
+// rather than being deleted for good
+func (m *Manager) TrashEnabled() bool {
+	return m.trashRetention() > 0
+}
+
+// trashRetention returns the retention in effect, negative when the trash
+// is disabled
+func (m *Manager) trashRetention() time.Duration {
+	if m.retention == 0 {
+		return DefaultTrashRetention
+	}
+	return m.retention
+}
+
+// trashCutoff returns the deletion time at or before which trashed rows
+// have expired
+func (m *Manager) trashCutoff(now time.Time) int64 {
+	if r := m.trashRetention(); r > 0 {
+		return now.Add(-r).Unix()
+	}
+	return now.Unix()
+}
+
+// purgeExpired deletes for good the tags and folders that have been in the
+// trash longer than the retention
+func (m *Manager) purgeExpired(tx *sql.Tx, now time.Time) error {
+	cutoff := m.trashCutoff(now)
+	if _, err := tx.Exec("DELETE FROM tags WHERE deleted_at != 0 AND deleted_at <= ?", cutoff); err != nil {
+		return fmt.Errorf("failed to purge trash: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM folders WHERE deleted_at != 0 AND deleted_at <= ?", cutoff); err != nil {
+		return fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return deleteOrphanFolders(tx)
+}
+
+// purgeTrashed deletes for good the trashed folder stored under path and
+// the trashed tag named tagName, so a live one can take its place. Empty
+// arguments are skipped.
+func purgeTrashed(tx *sql.Tx, path, tagName string) error {
+	var purged int64
+	if path != "" {
+		result, err := tx.Exec("DELETE FROM folders WHERE path = ? AND deleted_at != 0", path)
+		if err != nil {
+			return fmt.Errorf("failed to purge trashed folder: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		purged += n
+	}
+	if tagName != "" {
+		result, err := tx.Exec("DELETE FROM tags WHERE name = ? AND deleted_at != 0", tagName)
+		if err != nil {
+			return fmt.Errorf("failed to purge trashed tag: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		purged += n
+	}
+	if purged == 0 {
+		return nil
+	}
+	return deleteOrphanFolders(tx)
+}
+
+// ListTrash returns the tags and folders in the trash, most recently
+// deleted first
+func (m *Manager) ListTrash() ([]TrashItem, error) {
+	database, err := m.readDB()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rows, err := database.Query(`
+		SELECT 'tag', t.name, COUNT(ft.folder_id), t.deleted_at
+		FROM tags t
+		LEFT JOIN folder_tags ft ON t.id = ft.tag_id
+		WHERE t.deleted_at > ?
+		GROUP BY t.id
+		UNION ALL
+		SELECT 'folder', f.path, COUNT(ft.tag_id), f.deleted_at
+		FROM folders f
+		LEFT JOIN folder_tags ft ON f.id = ft.folder_id
+		WHERE f.deleted_at > ?
+		GROUP BY f.id
+	`, m.trashCutoff(now), m.trashCutoff(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []TrashItem
+	for rows.Next() {
+		var item TrashItem
+		var deletedAt int64
+		if err := rows.Scan(&item.Kind, &item.Name, &item.Count, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trash: %w", err)
+		}
+		item.DeletedAt = time.Unix(deletedAt, 0)
+		item.ExpiresAt = item.DeletedAt.Add(m.trashRetention())
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read trash: %w", err)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].DeletedAt.Equal(items[j].DeletedAt) {
+			return items[i].DeletedAt.After(items[j].DeletedAt)
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+// Restore takes a tag, or failing that a folder, out of the trash. A tag
+// comes back on the folders it had; a folder with the tags it had.
+func (m *Manager) Restore(name string) (TrashItem, error) {
+	database, err := m.writeDB()
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	item := TrashItem{Name: name}
+	var changes []Change
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		changes = nil
+		if err := m.purgeExpired(tx, time.Now()); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec("UPDATE tags SET deleted_at = 0 WHERE name = ? AND deleted_at != 0", name)
+		if err != nil {
+			return fmt.Errorf("failed to restore tag: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			item.Kind = "tag"
+			return restoredChanges(tx, &changes, "t.name = ?", name)
+		}
+
+		abs, err := m.resolve(name)
+		if err != nil {
+			return fmt.Errorf("not in the trash: %s", name)
+		}
+		var stored string
+		err = tx.QueryRow("SELECT path FROM folders WHERE path IN (?, ?) AND deleted_at != 0", abs, m.canonical(abs)).Scan(&stored)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("not in the trash: %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to query folder: %w", err)
+		}
+		if _, err := tx.Exec("UPDATE folders SET deleted_at = 0 WHERE path = ?", stored); err != nil {
+			return fmt.Errorf("failed to restore folder: %w", err)
+		}
+		item.Kind, item.Name = "folder", stored
+		return restoredChanges(tx, &changes, "f.path = ?", stored)
+	})
+	if err != nil {
+		return TrashItem{}, err
+	}
+
+	item.Count = len(changes)
+	for _, c := range changes {
+		m.notify(c)
+	}
+	return item, nil
+}
+
+// restoredChanges appends an OpAdd change to changes for every live pair
+// of folder and tag matching where
+func restoredChanges(tx *sql.Tx, changes *[]Change, where string, args ...any) error {
+	rows, err := tx.Query(`
+		SELECT f.path, t.name
+		FROM folders f
+		JOIN folder_tags ft ON f.id = ft.folder_id
+		JOIN tags t ON ft.tag_id = t.id
+		WHERE f.deleted_at = 0 AND t.deleted_at = 0 AND `+where+`
+		ORDER BY f.path, t.name
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query restored folders: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.Path, &c.Tag); err != nil {
+			return fmt.Errorf("failed to scan restored folder: %w", err)
+		}
+		c.Op = OpAdd
+		*changes = append(*changes, c)
+	}
+	return rows.Err()
+}
+
+// EmptyTrash deletes everything in the trash for good and returns how many
+// tags and folders it held
+func (m *Manager) EmptyTrash() (int, error) {
+	database, err := m.writeDB()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	err = db.WithTx(database, func(tx *sql.Tx) error {
+		count = 0
+		// What has expired isn't counted, as ListTrash doesn't show it
+		if err := m.purgeExpired(tx, time.Now()); err != nil {
+			return err
+		}
+		for _, table := range []string{"tags", "folders"} {
+			result, err := tx.Exec("DELETE FROM " + table + " WHERE deleted_at != 0")
+			if err != nil {
+				return fmt.Errorf("failed to empty trash: %w", err)
+			}
+			n, _ := result.RowsAffected()
+			count += n
+		}
+		return deleteOrphanFolders(tx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
@@ -0,0 +1,241 @@
+package tag
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+)
+
+func TestDeleteTagRestore(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	AddTag(testFolder, "work")
+	AddTag(testFolder, "api")
+	description := "Day job"
+	if err := SetTagInfo("work", InfoUpdate{Description: &description}); err != nil {
+		t.Fatalf("SetTagInfo failed: %v", err)
+	}
+
+	if err := DeleteTag("work"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+
+	tags, _ := ListTags()
+	if _, ok := tags["work"]; ok {
+		t.Errorf("Expected the deleted tag to be hidden, got %v", tags)
+	}
+	folders, _ := ListFoldersByTag("work")
+	if len(folders) != 0 {
+		t.Errorf("Expected no folders for the deleted tag, got %v", folders)
+	}
+	folderTags, _ := GetTagsForFolder(testFolder)
+	if !reflect.DeepEqual(folderTags, []string{"api"}) {
+		t.Errorf("Expected only 'api' left on the folder, got %v", folderTags)
+	}
+	if err := DeleteTag("work"); err == nil {
+		t.Error("Expected deleting a trashed tag again to fail")
+	}
+
+	items, err := ListTrash()
+	if err != nil {
+		t.Fatalf("ListTrash failed: %v", err)
+	}
+	if len(items) != 1 || items[0].Kind != "tag" || items[0].Name != "work" || items[0].Count != 1 {
+		t.Fatalf("Expected the tag in the trash, got %+v", items)
+	}
+
+	item, err := Restore("work")
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if item.Kind != "tag" || item.Count != 1 {
+		t.Errorf("Expected a restored tag with one folder, got %+v", item)
+	}
+	folders, _ = ListFoldersByTag("work")
+	if !reflect.DeepEqual(folders, []string{testFolder}) {
+		t.Errorf("Expected the folder back on the tag, got %v", folders)
+	}
+	info, _ := GetTagInfo("work")
+	if info.Description != "Day job" {
+		t.Errorf("Expected the tag info to survive the trash, got %+v", info)
+	}
+	if items, _ := ListTrash(); len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", items)
+	}
+	if _, err := Restore("work"); err == nil {
+		t.Error("Expected restoring a tag not in the trash to fail")
+	}
+}
+
+func TestRemoveFolderRestore(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	AddTag(testFolder, "work")
+	AddTag(testFolder, "api")
+	if err := SetNote(testFolder, "hello"); err != nil {
+		t.Fatalf("SetNote failed: %v", err)
+	}
+
+	if err := RemoveFolder(testFolder); err != nil {
+		t.Fatalf("RemoveFolder failed: %v", err)
+	}
+	if folders, _ := ListAllFolders(); len(folders) != 0 {
+		t.Errorf("Expected the folder to be hidden, got %v", folders)
+	}
+	if tags, _ := ListTags(); tags["work"] != 0 {
+		t.Errorf("Expected the trashed folder not to be counted, got %v", tags)
+	}
+
+	// Any spelling of the path finds the trashed folder
+	item, err := Restore(testFolder + "/")
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if item.Kind != "folder" || item.Name != testFolder || item.Count != 2 {
+		t.Errorf("Expected a restored folder with two tags, got %+v", item)
+	}
+	if note, _ := GetNote(testFolder); note != "hello" {
+		t.Errorf("Expected the note to survive the trash, got %q", note)
+	}
+}
+
+func TestRecreateTrashedTag(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	other := filepath.Join(filepath.Dir(testFolder), "other")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+
+	AddTag(testFolder, "work")
+	if err := DeleteTag("work"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+
+	// A new tag of the same name replaces the trashed one
+	if err := AddTag(other, "work"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	folders, _ := ListFoldersByTag("work")
+	if !reflect.DeepEqual(folders, []string{other}) {
+		t.Errorf("Expected only the newly tagged folder, got %v", folders)
+	}
+	if items, _ := ListTrash(); len(items) != 0 {
+		t.Errorf("Expected the trashed tag to be gone, got %+v", items)
+	}
+
+	// So does renaming a tag to its name
+	AddTag(testFolder, "api")
+	if err := DeleteTag("api"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if err := RenameTag("work", "api"); err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	if items, _ := ListTrash(); len(items) != 0 {
+		t.Errorf("Expected the trashed tag to be gone, got %+v", items)
+	}
+}
+
+func TestTrashRetention(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+	defer SetTrashRetention(0)
+
+	AddTag(testFolder, "work")
+	AddTag(testFolder, "api")
+	if err := DeleteTag("work"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+
+	// Age the trashed tag past the retention
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	if _, err := db.Default().DB().Exec("UPDATE tags SET deleted_at = ? WHERE name = 'work'", old); err != nil {
+		t.Fatalf("Failed to age tag: %v", err)
+	}
+	SetTrashRetention(time.Hour)
+	if items, _ := ListTrash(); len(items) != 0 {
+		t.Errorf("Expected an expired tag not to be listed, got %+v", items)
+	}
+	if _, err := Restore("work"); err == nil {
+		t.Error("Expected restoring an expired tag to fail")
+	}
+
+	// A negative retention disables the trash
+	SetTrashRetention(-1)
+	if err := DeleteTag("api"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	var n int
+	if err := db.Default().ReadDB().QueryRow("SELECT COUNT(*) FROM tags").Scan(&n); err != nil {
+		t.Fatalf("Failed to count tags: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("Expected tags to be deleted for good, got %d", n)
+	}
+}
+
+func TestPruneToTrash(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	AddTag(testFolder, "work")
+	if err := os.RemoveAll(testFolder); err != nil {
+		t.Fatalf("Failed to remove folder: %v", err)
+	}
+
+	result, err := Prune(false)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.RemovedCount != 1 {
+		t.Fatalf("Expected one pruned folder, got %+v", result)
+	}
+
+	items, _ := ListTrash()
+	if len(items) != 1 || items[0].Kind != "folder" || items[0].Name != testFolder {
+		t.Fatalf("Expected the pruned folder in the trash, got %+v", items)
+	}
+	if n, err := EmptyTrash(); err != nil || n != 1 {
+		t.Errorf("Expected EmptyTrash to delete one item, got %d, %v", n, err)
+	}
+	if items, _ := ListTrash(); len(items) != 0 {
+		t.Errorf("Expected an empty trash, got %+v", items)
+	}
+}
+
+func TestPruneKeepsFoldersOfTrashedTags(t *testing.T) {
+	testFolder, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	AddTag(testFolder, "work")
+	if err := DeleteTag("work"); err != nil {
+		t.Fatalf("DeleteTag failed: %v", err)
+	}
+	if err := os.RemoveAll(testFolder); err != nil {
+		t.Fatalf("Failed to remove folder: %v", err)
+	}
+
+	// The folder is hidden with its tag, so prune leaves it alone
+	result, err := Prune(false)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if result.RemovedCount != 0 {
+		t.Errorf("Expected nothing pruned, got %+v", result)
+	}
+
+	if _, err := Restore("work"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if folders, _ := ListFoldersByTag("work"); !reflect.DeepEqual(folders, []string{testFolder}) {
+		t.Errorf("Expected the folder back on the restored tag, got %v", folders)
+	}
+}
@@ -0,0 +1,207 @@
+// Package tidy gathers cleanup candidates from every check scope has
+// (stale folders, orphaned tags and folders, inactive folders, duplicates)
+// so they can be reviewed and applied in one pass.
+package tidy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// DefaultArchiveAfter is how long a folder must be inactive to be offered
+// for removal
+const DefaultArchiveAfter = 180 * 24 * time.Hour
+
+// Kind is the category of a cleanup candidate
+type Kind int
+
+const (
+	// Stale is a tagged folder that no longer exists; applying prunes it
+	Stale Kind = iota
+	// OrphanTag is a tag with no folders; applying deletes it
+	OrphanTag
+	// Archived is a folder with no recent activity; applying forgets it
+	Archived
+	// Duplicate is one folder stored under several paths; applying merges them
+	Duplicate
+	// OrphanFolder is a stored folder with no tags; applying forgets it
+	OrphanFolder
+)
+
+// String returns the label shown for the category
+func (k Kind) String() string {
+	switch k {
+	case Stale:
+		return "stale"
+	case OrphanTag:
+		return "orphan tag"
+	case Archived:
+		return "archived"
+	case Duplicate:
+		return "duplicate"
+	case OrphanFolder:
+		return "orphan folder"
+	default:
+		return "unknown"
+	}
+}
+
+// Item is a single cleanup candidate
+type Item struct {
+	Kind Kind
+	// Path is the folder for Stale, Archived and OrphanFolder items
+	Path string
+	// Tag is the tag name for OrphanTag items
+	Tag string
+	// LastActivity is set for Archived items
+	LastActivity time.Time
+	// Duplicate is set for Duplicate items
+	Duplicate tag.DuplicateFolder
+}
+
+// Label describes the item and the action applying it takes
+func (i Item) Label() string {
+	switch i.Kind {
+	case Stale:
+		return fmt.Sprintf("[%s] %s (missing, prune)", i.Kind, i.Path)
+	case OrphanTag:
+		return fmt.Sprintf("[%s] %s (no folders, delete)", i.Kind, i.Tag)
+	case Archived:
+		return fmt.Sprintf("[%s] %s (inactive since %s, forget)", i.Kind, i.Path, i.LastActivity.Format("2006-01-02"))
+	case Duplicate:
+		return fmt.Sprintf("[%s] %s (%d entries, merge)", i.Kind, i.Duplicate.Canonical, len(i.Duplicate.Paths))
+	case OrphanFolder:
+		return fmt.Sprintf("[%s] %s (no tags, forget)", i.Kind, i.Path)
+	default:
+		return i.Kind.String()
+	}
+}
+
+// Options control which folders count as archived
+type Options struct {
+	// ArchiveAfter is the inactivity threshold; zero uses DefaultArchiveAfter
+	ArchiveAfter time.Duration
+	// Now is the reference time; zero uses time.Now
+	Now time.Time
+}
+
+// Collect gathers cleanup candidates, grouped by kind
+func Collect(m *tag.Manager, opts Options) ([]Item, error) {
+	if opts.ArchiveAfter == 0 {
+		opts.ArchiveAfter = DefaultArchiveAfter
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	var items []Item
+
+	pruned, err := m.Prune(true)
+	if err != nil {
+		return nil, err
+	}
+	stale := make(map[string]bool)
+	for _, path := range pruned.RemovedFolders {
+		stale[path] = true
+		items = append(items, Item{Kind: Stale, Path: path})
+	}
+
+	tags, err := m.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	var orphans []string
+	for name, count := range tags {
+		if count == 0 {
+			orphans = append(orphans, name)
+		}
+	}
+	sort.Strings(orphans)
+	for _, name := range orphans {
+		items = append(items, Item{Kind: OrphanTag, Tag: name})
+	}
+
+	folders, err := m.ListAllFolders()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := opts.Now.Add(-opts.ArchiveAfter)
+	for _, path := range folders {
+		if stale[path] {
+			continue
+		}
+		last, ok := LastActivity(path)
+		if ok && last.Before(cutoff) {
+			items = append(items, Item{Kind: Archived, Path: path, LastActivity: last})
+		}
+	}
+
+	report, err := m.Doctor(false)
+	if err != nil {
+		return nil, err
+	}
+	for _, dup := range report.Duplicates {
+		items = append(items, Item{Kind: Duplicate, Duplicate: dup})
+	}
+	for _, path := range report.Orphans {
+		items = append(items, Item{Kind: OrphanFolder, Path: path})
+	}
+
+	return items, nil
+}
+
+// LastActivity estimates when a folder was last worked in: the latest
+// modification of the folder itself or of its git index and HEAD
+func LastActivity(path string) (time.Time, bool) {
+	var last time.Time
+	found := false
+	for _, p := range []string{
+		path,
+		filepath.Join(path, ".git", "index"),
+		filepath.Join(path, ".git", "HEAD"),
+	} {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		found = true
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+	return last, found
+}
+
+// Apply performs the action of every item, continuing past failures.
+// It returns how many items were applied and the errors of the rest.
+func Apply(m *tag.Manager, items []Item) (int, []error) {
+	applied := 0
+	var errs []error
+
+	for _, item := range items {
+		var err error
+		switch item.Kind {
+		case Stale, Archived, OrphanFolder:
+			err = m.RemoveFolder(item.Path)
+		case OrphanTag:
+			err = m.DeleteTag(item.Tag)
+		case Duplicate:
+			err = m.MergeDuplicate(item.Duplicate)
+		default:
+			err = fmt.Errorf("unknown cleanup kind %d", item.Kind)
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.Label(), err))
+			continue
+		}
+		applied++
+	}
+
+	return applied, errs
+}
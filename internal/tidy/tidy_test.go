@@ -0,0 +1,165 @@
+package tidy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// setupTestEnv creates a store with a live folder, a stale folder, an
+// orphaned tag and an inactive folder
+func setupTestEnv(t *testing.T) (*tag.Manager, string) {
+	t.Helper()
+
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	m := tag.NewManager(store)
+
+	for _, name := range []string{"live", "stale", "old"} {
+		folder := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := m.AddTag(folder, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	// Orphan a tag by removing its only folder
+	if err := m.AddTag(filepath.Join(tmpDir, "live"), "temp"); err != nil {
+		t.Fatalf("AddTag failed: %v", err)
+	}
+	if err := m.RemoveTag(filepath.Join(tmpDir, "live"), "temp"); err != nil {
+		t.Fatalf("RemoveTag failed: %v", err)
+	}
+
+	if err := os.RemoveAll(filepath.Join(tmpDir, "stale")); err != nil {
+		t.Fatalf("Failed to remove folder: %v", err)
+	}
+
+	old := time.Now().Add(-365 * 24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(tmpDir, "old"), old, old); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+
+	return m, tmpDir
+}
+
+func TestCollect(t *testing.T) {
+	m, tmpDir := setupTestEnv(t)
+
+	items, err := Collect(m, Options{})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	byKind := make(map[Kind][]Item)
+	for _, item := range items {
+		byKind[item.Kind] = append(byKind[item.Kind], item)
+	}
+
+	if len(byKind[Stale]) != 1 || byKind[Stale][0].Path != filepath.Join(tmpDir, "stale") {
+		t.Errorf("Expected stale folder, got %+v", byKind[Stale])
+	}
+	if len(byKind[OrphanTag]) != 1 || byKind[OrphanTag][0].Tag != "temp" {
+		t.Errorf("Expected orphan tag 'temp', got %+v", byKind[OrphanTag])
+	}
+	if len(byKind[Archived]) != 1 || byKind[Archived][0].Path != filepath.Join(tmpDir, "old") {
+		t.Errorf("Expected archived folder, got %+v", byKind[Archived])
+	}
+	if len(byKind[Duplicate]) != 0 {
+		t.Errorf("Expected no duplicates, got %+v", byKind[Duplicate])
+	}
+	if len(byKind[OrphanFolder]) != 0 {
+		t.Errorf("Expected untagging to leave no orphan folders, got %+v", byKind[OrphanFolder])
+	}
+}
+
+func TestCollectArchiveThreshold(t *testing.T) {
+	m, _ := setupTestEnv(t)
+
+	items, err := Collect(m, Options{ArchiveAfter: 2 * 365 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	for _, item := range items {
+		if item.Kind == Archived {
+			t.Errorf("Folder inactive for a year should not pass a two-year threshold: %s", item.Path)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	m, tmpDir := setupTestEnv(t)
+
+	items, err := Collect(m, Options{})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	applied, errs := Apply(m, items)
+	if len(errs) != 0 {
+		t.Fatalf("Apply failed: %v", errs)
+	}
+	if applied != len(items) {
+		t.Errorf("Expected %d applied, got %d", len(items), applied)
+	}
+
+	folders, err := m.ListAllFolders()
+	if err != nil {
+		t.Fatalf("ListAllFolders failed: %v", err)
+	}
+	if len(folders) != 1 || folders[0] != filepath.Join(tmpDir, "live") {
+		t.Errorf("Expected only the live folder to remain, got %v", folders)
+	}
+
+	tags, err := m.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if _, ok := tags["temp"]; ok {
+		t.Error("Orphan tag should have been deleted")
+	}
+
+	remaining, err := Collect(m, Options{})
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("Expected nothing left to tidy, got %+v", remaining)
+	}
+}
+
+func TestApplyReportsFailures(t *testing.T) {
+	m, _ := setupTestEnv(t)
+
+	applied, errs := Apply(m, []Item{{Kind: OrphanTag, Tag: "does-not-exist"}})
+	if applied != 0 || len(errs) != 1 {
+		t.Errorf("Expected one failure, got applied=%d errs=%v", applied, errs)
+	}
+}
+
+func TestPreselected(t *testing.T) {
+	items := []Item{
+		{Kind: Stale, Path: "/gone"},
+		{Kind: Archived, Path: "/old"},
+		{Kind: OrphanTag, Tag: "empty"},
+	}
+	selected := Preselected(items)
+	if len(selected) != 2 || selected[0].Kind != Stale || selected[1].Kind != OrphanTag {
+		t.Errorf("Expected archived folders left out, got %+v", selected)
+	}
+}
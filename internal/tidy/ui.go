@@ -0,0 +1,66 @@
+package tidy
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/gabssanto/Scope/internal/ui"
+)
+
+// preselected reports whether item starts selected. Archived folders must
+// be opted into.
+func preselected(item Item) bool {
+	return item.Kind != Archived
+}
+
+// Preselected returns the items that start selected
+func Preselected(items []Item) []Item {
+	var selected []Item
+	for _, item := range items {
+		if preselected(item) {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+// SelectItems presents an interactive multi-select UI for choosing which
+// cleanups to apply. Stale folders, orphan tags and duplicates start
+// selected; archived folders must be opted into.
+func SelectItems(items []Item) ([]Item, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	if err := ui.CanPrompt(); err != nil {
+		return nil, err
+	}
+
+	options := make([]huh.Option[int], len(items))
+	for i, item := range items {
+		options[i] = huh.NewOption(item.Label(), i).Selected(preselected(item))
+	}
+
+	var selectedIndices []int
+
+	form := ui.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title(fmt.Sprintf("Select cleanups to apply (%d found)", len(items))).
+				Description("space: toggle, enter: confirm, /: filter").
+				Options(options...).
+				Value(&selectedIndices),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("selection canceled: %w", err)
+	}
+
+	selected := make([]Item, 0, len(selectedIndices))
+	for _, idx := range selectedIndices {
+		selected = append(selected, items[idx])
+	}
+
+	return selected, nil
+}
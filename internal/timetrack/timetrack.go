@@ -0,0 +1,363 @@
+// Package timetrack records how long is spent in scope sessions and in
+// tagged folders, and reports it grouped by tag.
+//
+// Sessions are timed exactly, from the moment the workspace is ready until
+// the shell exits. Folder time comes from the shell hook, which records
+// every directory change: a visit to a tagged folder lasts from entering it
+// until leaving it (or the shell exiting), capped at MaxVisit so a terminal
+// left open overnight doesn't count as a day of work.
+package timetrack
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// DefaultMaxVisit caps a single folder visit when no limit is configured
+const DefaultMaxVisit = 2 * time.Hour
+
+// Span kinds stored in the time_spans table
+const (
+	kindSession = "session"
+	kindFolder  = "folder"
+)
+
+// Tracker records and reports time spans in a Store
+type Tracker struct {
+	store *db.Store
+	tags  *tag.Manager
+
+	// MaxVisit caps a single folder visit; zero uses DefaultMaxVisit
+	MaxVisit time.Duration
+}
+
+// NewTracker returns a Tracker for store. A nil store uses the default
+// store opened by db.InitDB.
+func NewTracker(store *db.Store) *Tracker {
+	return &Tracker{store: store, tags: tag.NewManager(store)}
+}
+
+// storeOrDefault resolves the store the Tracker operates on
+func (t *Tracker) storeOrDefault() (*db.Store, error) {
+	store := t.store
+	if store == nil {
+		store = db.Default()
+	}
+	if store == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	return store, nil
+}
+
+// writeDB returns the read-write pool, or db.ErrReadOnly
+func (t *Tracker) writeDB() (*sql.DB, error) {
+	store, err := t.storeOrDefault()
+	if err != nil {
+		return nil, err
+	}
+	if store.ReadOnly() {
+		return nil, db.ErrReadOnly
+	}
+	return store.DB(), nil
+}
+
+// maxVisit returns the configured visit cap
+func (t *Tracker) maxVisit() time.Duration {
+	if t.MaxVisit > 0 {
+		return t.MaxVisit
+	}
+	return DefaultMaxVisit
+}
+
+// RecordSession stores a session for tagName that ran from start to end
+func (t *Tracker) RecordSession(tagName string, start, end time.Time) error {
+	database, err := t.writeDB()
+	if err != nil {
+		return err
+	}
+
+	_, err = database.Exec(
+		"INSERT INTO time_spans (kind, tag, started_at, ended_at) VALUES (?, ?, ?, ?)",
+		kindSession, tagName, start.Unix(), end.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	return nil
+}
+
+// Visit records that shell (an id such as its process id) is now in dir.
+// The shell's previous visit ends, unless dir is still inside the same
+// tagged folder, and a new one starts if dir is inside a tagged folder. An
+// empty dir only ends the previous visit, for when the shell exits.
+func (t *Tracker) Visit(shell int, dir string, now time.Time) error {
+	folder := ""
+	if dir != "" {
+		var err error
+		folder, err = t.folderFor(dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	database, err := t.writeDB()
+	if err != nil {
+		return err
+	}
+
+	return db.WithTx(database, func(tx *sql.Tx) error {
+		var id, started int64
+		var path string
+		err := tx.QueryRow(
+			"SELECT id, path, started_at FROM time_spans WHERE kind = ? AND shell = ? AND ended_at = 0",
+			kindFolder, shell).Scan(&id, &path, &started)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return fmt.Errorf("failed to query visit: %w", err)
+		default:
+			end := min(now.Unix(), started+int64(t.maxVisit()/time.Second))
+			if path == folder && end == now.Unix() {
+				// Still in the same folder, within the cap
+				return nil
+			}
+			if _, err := tx.Exec("UPDATE time_spans SET ended_at = ? WHERE id = ?", max(end, started), id); err != nil {
+				return fmt.Errorf("failed to end visit: %w", err)
+			}
+		}
+
+		if folder == "" {
+			return nil
+		}
+		_, err = tx.Exec(
+			"INSERT INTO time_spans (kind, path, shell, started_at) VALUES (?, ?, ?, ?)",
+			kindFolder, folder, shell, now.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to start visit: %w", err)
+		}
+		return nil
+	})
+}
+
+// folderFor returns the tagged folder dir is in (the deepest one, if
+// folders are nested), or "" if it is in none
+func (t *Tracker) folderFor(dir string) (string, error) {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	folders, err := t.tags.ListAllFolders()
+	if err != nil {
+		return "", err
+	}
+
+	best := ""
+	for _, f := range folders {
+		if dir != f && !strings.HasPrefix(dir, f+string(os.PathSeparator)) {
+			continue
+		}
+		if len(f) > len(best) {
+			best = f
+		}
+	}
+	return best, nil
+}
+
+// TagTime is the time attributed to one tag
+type TagTime struct {
+	Tag string
+	// Folders is the time spent in folders carrying the tag
+	Folders time.Duration
+	// Sessions is the time spent in sessions started with the tag
+	Sessions time.Duration
+}
+
+// Total returns the tag's folder and session time
+func (tt TagTime) Total() time.Duration {
+	return tt.Folders + tt.Sessions
+}
+
+// FolderTime is the time spent in one folder
+type FolderTime struct {
+	Path     string
+	Duration time.Duration
+}
+
+// Report is the time spent between Since and Until
+type Report struct {
+	Since   time.Time
+	Until   time.Time
+	Tags    []TagTime
+	Folders []FolderTime
+}
+
+// Report totals the spans overlapping [since, until), clipping them to it.
+// A folder's time counts toward each of its current tags. Tags and folders
+// are sorted by time spent, most first.
+func (t *Tracker) Report(since, until time.Time) (*Report, error) {
+	store, err := t.storeOrDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.ReadDB().Query(`
+		SELECT kind, tag, path, started_at, ended_at FROM time_spans
+		WHERE started_at < ? AND (ended_at = 0 OR ended_at > ?)
+	`, until.Unix(), since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	sessions := make(map[string]time.Duration)
+	folders := make(map[string]time.Duration)
+	for rows.Next() {
+		var kind, tagName, path string
+		var started, ended int64
+		if err := rows.Scan(&kind, &tagName, &path, &started, &ended); err != nil {
+			return nil, fmt.Errorf("failed to scan time: %w", err)
+		}
+
+		start := time.Unix(started, 0)
+		end := time.Unix(ended, 0)
+		if ended == 0 {
+			// An open visit counts until now, up to the cap
+			end = until
+			if limit := start.Add(t.maxVisit()); end.After(limit) {
+				end = limit
+			}
+		}
+		d := clip(start, end, since, until)
+		if d <= 0 {
+			continue
+		}
+
+		if kind == kindSession {
+			sessions[tagName] += d
+		} else {
+			folders[path] += d
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read time: %w", err)
+	}
+
+	byTag := make(map[string]*TagTime)
+	entry := func(name string) *TagTime {
+		if byTag[name] == nil {
+			byTag[name] = &TagTime{Tag: name}
+		}
+		return byTag[name]
+	}
+	for name, d := range sessions {
+		entry(name).Sessions += d
+	}
+
+	report := &Report{Since: since, Until: until}
+	for path, d := range folders {
+		report.Folders = append(report.Folders, FolderTime{Path: path, Duration: d})
+
+		tags, err := t.tags.GetTagsForFolder(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range tags {
+			entry(name).Folders += d
+		}
+	}
+
+	for _, tt := range byTag {
+		report.Tags = append(report.Tags, *tt)
+	}
+	sort.Slice(report.Tags, func(i, j int) bool {
+		if report.Tags[i].Total() != report.Tags[j].Total() {
+			return report.Tags[i].Total() > report.Tags[j].Total()
+		}
+		return report.Tags[i].Tag < report.Tags[j].Tag
+	})
+	sort.Slice(report.Folders, func(i, j int) bool {
+		if report.Folders[i].Duration != report.Folders[j].Duration {
+			return report.Folders[i].Duration > report.Folders[j].Duration
+		}
+		return report.Folders[i].Path < report.Folders[j].Path
+	})
+
+	return report, nil
+}
+
+// RecentFolder is a folder and when it was last visited
+type RecentFolder struct {
+	Path    string
+	Visited time.Time
+}
+
+// Recent returns up to limit folders, most recently visited first
+func (t *Tracker) Recent(limit int) ([]RecentFolder, error) {
+	store, err := t.storeOrDefault()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := store.ReadDB().Query(`
+		SELECT path, MAX(started_at) AS visited FROM time_spans
+		WHERE kind = ?
+		GROUP BY path
+		ORDER BY visited DESC, path
+		LIMIT ?
+	`, kindFolder, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query visits: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var recent []RecentFolder
+	for rows.Next() {
+		var path string
+		var visited int64
+		if err := rows.Scan(&path, &visited); err != nil {
+			return nil, fmt.Errorf("failed to scan visit: %w", err)
+		}
+		recent = append(recent, RecentFolder{Path: path, Visited: time.Unix(visited, 0)})
+	}
+	return recent, rows.Err()
+}
+
+// clip returns how much of [start, end) falls inside [since, until)
+func clip(start, end, since, until time.Time) time.Duration {
+	if start.Before(since) {
+		start = since
+	}
+	if end.After(until) {
+		end = until
+	}
+	return end.Sub(start)
+}
+
+// StartOfWeek returns midnight on the Monday of now's week
+func StartOfWeek(now time.Time) time.Time {
+	day := StartOfDay(now)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// StartOfDay returns midnight of now's day, in now's location
+func StartOfDay(now time.Time) time.Time {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+}
+
+// FormatDuration renders d as hours and minutes, e.g. "3h 05m"
+func FormatDuration(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
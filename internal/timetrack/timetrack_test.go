@@ -0,0 +1,205 @@
+package timetrack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// setupTracker returns a Tracker over a fresh store, with api tagged work
+// and backend, and web tagged work
+func setupTracker(t *testing.T) (*Tracker, string, string) {
+	t.Helper()
+
+	tmpDir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(tmpDir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	api := filepath.Join(tmpDir, "api")
+	web := filepath.Join(tmpDir, "web")
+	for _, dir := range []string{filepath.Join(api, "cmd"), web} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	m := tag.NewManager(store)
+	for _, tt := range []struct{ path, tag string }{{api, "work"}, {api, "backend"}, {web, "work"}} {
+		if err := m.AddTag(tt.path, tt.tag); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+
+	return NewTracker(store), api, web
+}
+
+func TestVisits(t *testing.T) {
+	tr, api, web := setupTracker(t)
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	steps := []struct {
+		shell   int
+		dir     string
+		minutes int
+	}{
+		{1, api, 0},
+		{1, filepath.Join(api, "cmd"), 20}, // same folder, visit continues
+		{1, web, 30},                       // 30m in api
+		{1, os.TempDir(), 45},              // 15m in web
+		{2, web, 40},                       // another shell
+		{2, "", 50},                        // shell exits: 10m in web
+	}
+	for _, s := range steps {
+		if err := tr.Visit(s.shell, s.dir, at(s.minutes)); err != nil {
+			t.Fatalf("Visit failed: %v", err)
+		}
+	}
+
+	report, err := tr.Report(start, at(60))
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+
+	folders := make(map[string]time.Duration)
+	for _, f := range report.Folders {
+		folders[f.Path] = f.Duration
+	}
+	if folders[api] != 30*time.Minute || folders[web] != 25*time.Minute {
+		t.Errorf("Unexpected folder times %v", folders)
+	}
+
+	tags := make(map[string]time.Duration)
+	for _, tt := range report.Tags {
+		tags[tt.Tag] = tt.Folders
+	}
+	if tags["work"] != 55*time.Minute || tags["backend"] != 30*time.Minute {
+		t.Errorf("Unexpected tag times %v", tags)
+	}
+	if report.Tags[0].Tag != "work" {
+		t.Errorf("Expected the busiest tag first, got %+v", report.Tags)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	tr, api, web := setupTracker(t)
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	for i, dir := range []string{api, web, api} {
+		if err := tr.Visit(1, dir, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("Visit failed: %v", err)
+		}
+	}
+
+	recent, err := tr.Recent(10)
+	if err != nil {
+		t.Fatalf("Recent failed: %v", err)
+	}
+	if len(recent) != 2 || recent[0].Path != api || !recent[0].Visited.Equal(start.Add(2*time.Hour)) || recent[1].Path != web {
+		t.Errorf("Unexpected recent folders %+v", recent)
+	}
+
+	if recent, _ := tr.Recent(1); len(recent) != 1 {
+		t.Errorf("Recent(1) returned %d folders", len(recent))
+	}
+}
+
+func TestVisitCap(t *testing.T) {
+	tr, api, _ := setupTracker(t)
+	tr.MaxVisit = time.Hour
+	start := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+
+	if err := tr.Visit(1, api, start); err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+
+	// An open visit counts up to the cap
+	report, err := tr.Report(start, start.Add(8*time.Hour))
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(report.Folders) != 1 || report.Folders[0].Duration != time.Hour {
+		t.Errorf("Expected an open visit capped at 1h, got %+v", report.Folders)
+	}
+
+	// Coming back to the prompt after the cap starts a new visit
+	if err := tr.Visit(1, api, start.Add(3*time.Hour)); err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+	if err := tr.Visit(1, "", start.Add(3*time.Hour+10*time.Minute)); err != nil {
+		t.Fatalf("Visit failed: %v", err)
+	}
+	report, err = tr.Report(start, start.Add(8*time.Hour))
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if report.Folders[0].Duration != time.Hour+10*time.Minute {
+		t.Errorf("Expected 1h10m, got %v", report.Folders[0].Duration)
+	}
+}
+
+func TestSessionsAndClipping(t *testing.T) {
+	tr, _, _ := setupTracker(t)
+	day := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+
+	// 23:30 to 01:00 straddles midnight
+	if err := tr.RecordSession("work", day.Add(-30*time.Minute), day.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordSession failed: %v", err)
+	}
+
+	report, err := tr.Report(day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(report.Tags) != 1 || report.Tags[0].Sessions != time.Hour || report.Tags[0].Total() != time.Hour {
+		t.Errorf("Expected 1h of work sessions, got %+v", report.Tags)
+	}
+
+	report, err = tr.Report(day.Add(24*time.Hour), day.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("Report failed: %v", err)
+	}
+	if len(report.Tags) != 0 {
+		t.Errorf("Expected nothing the next day, got %+v", report.Tags)
+	}
+}
+
+func TestStartOfWeek(t *testing.T) {
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := StartOfWeek(tt.now); !got.Equal(tt.want) {
+			t.Errorf("StartOfWeek(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                           "0m",
+		45 * time.Minute:            "45m",
+		3*time.Hour + 5*time.Minute: "3h 05m",
+		25 * time.Hour:              "25h 00m",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
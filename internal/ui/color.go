@@ -0,0 +1,64 @@
+// Package ui holds terminal presentation helpers shared by commands.
+package ui
+
+import (
+	"os"
+	"sort"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ANSI SGR codes for the named colors users can pick in config
+var colorCodes = map[string]string{
+	"red":     "1;31",
+	"green":   "1;32",
+	"yellow":  "1;33",
+	"blue":    "1;34",
+	"magenta": "1;35",
+	"cyan":    "1;36",
+	"white":   "1;37",
+	"bold":    "1",
+}
+
+// ColorNames returns the color names accepted by ColorCode, sorted
+func ColorNames() []string {
+	names := make([]string, 0, len(colorCodes))
+	for name := range colorCodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ColorCode returns the ANSI code for a color name
+func ColorCode(name string) (string, bool) {
+	code, ok := colorCodes[name]
+	return code, ok
+}
+
+// ColorEnabled reports whether stdout should be colored: it must be a
+// terminal, NO_COLOR (https://no-color.org) must be unset and accessible
+// output off
+func ColorEnabled() bool {
+	if accessible {
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// Color wraps s in the named color when color output is enabled. Unknown
+// names leave s unchanged.
+func Color(name, s string) string {
+	code, ok := colorCodes[name]
+	if !ok || !ColorEnabled() {
+		return s
+	}
+	return "\033[" + code + "m" + s + "\033[0m"
+}
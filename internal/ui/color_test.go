@@ -0,0 +1,25 @@
+package ui
+
+import "testing"
+
+func TestColorNoColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if ColorEnabled() {
+		t.Error("ColorEnabled should be false when NO_COLOR is set")
+	}
+	if got := Color("red", "text"); got != "text" {
+		t.Errorf("Expected uncolored text, got %q", got)
+	}
+}
+
+func TestColorCode(t *testing.T) {
+	for _, name := range ColorNames() {
+		if _, ok := ColorCode(name); !ok {
+			t.Errorf("ColorNames lists %q but ColorCode rejects it", name)
+		}
+	}
+	if _, ok := ColorCode("chartreuse"); ok {
+		t.Error("ColorCode should reject unknown colors")
+	}
+}
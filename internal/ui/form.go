@@ -0,0 +1,25 @@
+package ui
+
+import "github.com/charmbracelet/huh"
+
+// NewForm returns a huh form that asks its questions as plain numbered
+// prompts when accessible output is on
+func NewForm(groups ...*huh.Group) *huh.Form {
+	return huh.NewForm(groups...).WithAccessible(accessible)
+}
+
+// Confirm asks a yes/no question, failing like CanPrompt when the user
+// can't be asked
+func Confirm(title, description string) (bool, error) {
+	if err := CanPrompt(); err != nil {
+		return false, err
+	}
+	confirmed := false
+	err := NewForm(huh.NewGroup(
+		huh.NewConfirm().
+			Title(title).
+			Description(description).
+			Value(&confirmed),
+	)).Run()
+	return confirmed, err
+}
@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Process-wide output settings, set once from the global flags
+var (
+	quiet      bool
+	noInput    bool
+	accessible bool
+
+	// stdout is where informational output goes; tests may replace it
+	stdout io.Writer = os.Stdout
+)
+
+// ErrNoInput is returned in place of prompting when prompts are disabled
+var ErrNoInput = errors.New("input required, but prompts are disabled (--no-input)")
+
+// SetQuiet suppresses informational output
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// Quiet reports whether informational output is suppressed
+func Quiet() bool {
+	return quiet
+}
+
+// SetNoInput makes commands fail with ErrNoInput instead of prompting
+func SetNoInput(v bool) {
+	noInput = v
+}
+
+// NoInput reports whether prompting is disabled
+func NoInput() bool {
+	return noInput
+}
+
+// SetAccessible switches to output for screen readers and dumb terminals:
+// no colors, and plain line-by-line prompts instead of interactive forms
+func SetAccessible(v bool) {
+	accessible = v
+}
+
+// Accessible reports whether accessible output is on
+func Accessible() bool {
+	return accessible
+}
+
+// Infof prints informational output (confirmations, progress, summaries)
+// to stdout unless quiet. A command's actual results should be printed
+// directly so they survive --quiet.
+func Infof(format string, a ...any) {
+	if quiet {
+		return
+	}
+	_, _ = fmt.Fprintf(stdout, format, a...)
+}
+
+// Infoln is Infof with Println formatting
+func Infoln(a ...any) {
+	if quiet {
+		return
+	}
+	_, _ = fmt.Fprintln(stdout, a...)
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestInfoQuiet(t *testing.T) {
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+		SetQuiet(false)
+	}()
+
+	Infof("Tagged %s\n", "a")
+	Infoln("done")
+	if buf.String() != "Tagged a\ndone\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+
+	buf.Reset()
+	SetQuiet(true)
+	Infof("Tagged %s\n", "b")
+	Infoln("done")
+	if buf.Len() != 0 {
+		t.Errorf("Expected no output when quiet, got %q", buf.String())
+	}
+}
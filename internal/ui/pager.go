@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-isatty"
+)
+
+// defaultPager is used when $PAGER is unset. -F quits at once when the
+// output fits after all, -R keeps colors and -X leaves it on the screen.
+const defaultPager = "less -FRX"
+
+// terminalHeight returns the height of stdout, or false when stdout is not
+// a terminal; tests may replace it
+var terminalHeight = func() (int, bool) {
+	if os.Getenv("TERM") == "dumb" {
+		return 0, false
+	}
+	fd := os.Stdout.Fd()
+	if !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd) {
+		return 0, false
+	}
+	_, height, err := term.GetSize(fd)
+	if err != nil || height <= 0 {
+		return 0, false
+	}
+	return height, true
+}
+
+// Pager collects a command's output and, on Close, shows it through
+// $PAGER when stdout is a terminal the output doesn't fit on
+type Pager struct {
+	buf     bytes.Buffer
+	out     io.Writer
+	enabled bool
+}
+
+// StartPager returns a Pager that informational output also goes to
+// until Close. With enabled false, Close just writes the output.
+func StartPager(enabled bool) *Pager {
+	p := &Pager{out: stdout, enabled: enabled}
+	stdout = p
+	return p
+}
+
+// Write buffers b
+func (p *Pager) Write(b []byte) (int, error) {
+	return p.buf.Write(b)
+}
+
+// Close writes the output, through the pager when it is too long for the
+// screen. If the pager can't be started the output is written directly.
+func (p *Pager) Close() error {
+	stdout = p.out
+	if p.enabled {
+		if height, ok := terminalHeight(); ok && bytes.Count(p.buf.Bytes(), []byte("\n")) >= height {
+			if err := p.page(); err == nil {
+				return nil
+			}
+		}
+	}
+	_, err := p.out.Write(p.buf.Bytes())
+	return err
+}
+
+// page runs $PAGER with the output on its stdin
+func (p *Pager) page() error {
+	command := strings.Fields(os.Getenv("PAGER"))
+	if len(command) == 0 {
+		command = strings.Fields(defaultPager)
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(p.buf.Bytes())
+	cmd.Stdout = p.out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	// The user quitting the pager early is not an error
+	_ = cmd.Wait()
+	return nil
+}
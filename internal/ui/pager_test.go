@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func TestPager(t *testing.T) {
+	if _, err := exec.LookPath("tr"); err != nil {
+		t.Skip("tr not installed")
+	}
+	defer func() { stdout = os.Stdout }()
+	t.Setenv("PAGER", "tr a-z A-Z")
+	height := terminalHeight
+	defer func() { terminalHeight = height }()
+	terminalHeight = func() (int, bool) { return 3, true }
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		lines    int
+		expected string
+	}{
+		{"fits on screen", true, 2, "line\nline\n"},
+		{"too long", true, 3, "LINE\nLINE\nLINE\n"},
+		{"disabled", false, 3, "line\nline\nline\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		stdout = &buf
+		p := StartPager(tt.enabled)
+		for i := 0; i < tt.lines; i++ {
+			if i == 0 {
+				Infoln("line")
+			} else {
+				fmt.Fprintln(p, "line")
+			}
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: expected output to wait for Close, got %q", tt.name, buf.String())
+		}
+		if err := p.Close(); err != nil {
+			t.Fatalf("%s: Close failed: %v", tt.name, err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("%s: got %q, expected %q", tt.name, buf.String(), tt.expected)
+		}
+	}
+}
+
+func TestPagerMissing(t *testing.T) {
+	defer func() { stdout = os.Stdout }()
+	t.Setenv("PAGER", "scope-no-such-pager")
+	height := terminalHeight
+	defer func() { terminalHeight = height }()
+	terminalHeight = func() (int, bool) { return 1, true }
+
+	var buf bytes.Buffer
+	stdout = &buf
+	p := StartPager(true)
+	fmt.Fprintln(p, "a")
+	fmt.Fprintln(p, "b")
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if buf.String() != "a\nb\n" {
+		t.Errorf("Expected the output written directly, got %q", buf.String())
+	}
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrNonInteractive is returned in place of prompting in CI jobs and dumb
+// terminals, for prompts that have no safe default
+var ErrNonInteractive = errors.New("input required, but this is a CI job or a dumb terminal (pass the answer as a flag)")
+
+// PromptMode is what a command does where it would prompt
+type PromptMode int
+
+const (
+	// Ask prompts the user
+	Ask PromptMode = iota
+	// Refuse fails with ErrNoInput, as --no-input asks
+	Refuse
+	// Default takes the command's default answer when it has a safe one
+	// (pick the first folder, apply every .scope file) and fails with
+	// ErrNonInteractive otherwise. Used in CI and dumb terminals, where a
+	// form can't be drawn or answered.
+	Default
+)
+
+// Mode returns the prompt mode of this process
+func Mode() PromptMode {
+	return modeFor(noInput, accessible, os.Getenv)
+}
+
+// modeFor decides the prompt mode. --no-input wins; otherwise CI=true or
+// TERM=dumb fall back to defaults, unless accessible output was asked
+// for, whose plain prompts work in a dumb terminal.
+func modeFor(noInput, accessible bool, getenv func(string) string) PromptMode {
+	switch {
+	case noInput:
+		return Refuse
+	case isCI(getenv("CI")):
+		return Default
+	case getenv("TERM") == "dumb" && !accessible:
+		return Default
+	default:
+		return Ask
+	}
+}
+
+// isCI reports whether the CI variable set by CI services is on
+func isCI(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// CanPrompt returns nil when the user can be asked, and otherwise the
+// error to fail with instead
+func CanPrompt() error {
+	switch Mode() {
+	case Refuse:
+		return ErrNoInput
+	case Default:
+		return ErrNonInteractive
+	}
+	return nil
+}
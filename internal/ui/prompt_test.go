@@ -0,0 +1,47 @@
+package ui
+
+import "testing"
+
+func TestModeFor(t *testing.T) {
+	tests := []struct {
+		name       string
+		noInput    bool
+		accessible bool
+		env        map[string]string
+		expected   PromptMode
+	}{
+		{"terminal", false, false, map[string]string{"TERM": "xterm-256color"}, Ask},
+		{"no input", true, false, map[string]string{"TERM": "xterm"}, Refuse},
+		{"no input in CI", true, false, map[string]string{"CI": "true"}, Refuse},
+		{"CI", false, false, map[string]string{"CI": "true", "TERM": "xterm"}, Default},
+		{"CI=1", false, false, map[string]string{"CI": "1"}, Default},
+		{"CI=false", false, false, map[string]string{"CI": "false", "TERM": "xterm"}, Ask},
+		{"accessible CI", false, true, map[string]string{"CI": "true"}, Default},
+		{"dumb terminal", false, false, map[string]string{"TERM": "dumb"}, Default},
+		{"accessible dumb terminal", false, true, map[string]string{"TERM": "dumb"}, Ask},
+	}
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := modeFor(tt.noInput, tt.accessible, getenv); got != tt.expected {
+			t.Errorf("%s: modeFor = %d, expected %d", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestCanPrompt(t *testing.T) {
+	defer SetNoInput(false)
+	t.Setenv("CI", "")
+	t.Setenv("TERM", "xterm")
+
+	if err := CanPrompt(); err != nil {
+		t.Errorf("Expected prompts allowed, got %v", err)
+	}
+	t.Setenv("CI", "true")
+	if err := CanPrompt(); err != ErrNonInteractive {
+		t.Errorf("Expected ErrNonInteractive in CI, got %v", err)
+	}
+	SetNoInput(true)
+	if err := CanPrompt(); err != ErrNoInput {
+		t.Errorf("Expected ErrNoInput with --no-input, got %v", err)
+	}
+}
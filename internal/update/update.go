@@ -10,6 +10,10 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/gabssanto/Scope/internal/httpcache"
+	"github.com/gabssanto/Scope/internal/network"
+	"github.com/gabssanto/Scope/internal/ui"
 )
 
 const (
@@ -75,19 +79,21 @@ func shouldCheck() bool {
 func fetchLatestRelease() (*Release, error) {
 	url := fmt.Sprintf(githubAPIURL, repoOwner, repoName)
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpcache.Default().Get(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch release: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
 	}
 
 	var release Release
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+	if err := json.Unmarshal(resp.Body, &release); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -204,13 +210,16 @@ func GetUpdateNotice(currentVersion string) string {
 	if !hasUpdate || !compareVersions(currentVersion, version) {
 		return ""
 	}
-	return fmt.Sprintf("\n\033[33m%s\033[0m scope %s available (current: %s) - run \033[1mscope update\033[0m\n",
-		"!", version, currentVersion)
+	return fmt.Sprintf("\n%s scope %s available (current: %s) - run %s\n",
+		ui.Color("yellow", "!"), version, currentVersion, ui.Color("bold", "scope update"))
 }
 
 // PerformUpdate downloads and installs the latest version
 func PerformUpdate(currentVersion string) error {
-	fmt.Println("Checking for updates...")
+	if err := network.Check("scope update"); err != nil {
+		return err
+	}
+	ui.Infoln("Checking for updates...")
 
 	info, err := CheckForUpdate(currentVersion)
 	if err != nil {
@@ -218,12 +227,12 @@ func PerformUpdate(currentVersion string) error {
 	}
 
 	if !info.UpdateAvailable {
-		fmt.Printf("Already up to date (version %s)\n", currentVersion)
+		ui.Infof("Already up to date (version %s)\n", currentVersion)
 		return nil
 	}
 
-	fmt.Printf("New version available: %s (current: %s)\n", info.LatestVersion, info.CurrentVersion)
-	fmt.Printf("Release notes: %s\n\n", info.ReleaseURL)
+	ui.Infof("New version available: %s (current: %s)\n", info.LatestVersion, info.CurrentVersion)
+	ui.Infof("Release notes: %s\n\n", info.ReleaseURL)
 
 	// Determine platform
 	goos := runtime.GOOS
@@ -238,7 +247,7 @@ func PerformUpdate(currentVersion string) error {
 	downloadURL := fmt.Sprintf("https://github.com/%s/%s/releases/download/%s/%s",
 		repoOwner, repoName, info.LatestVersion, assetName)
 
-	fmt.Printf("Downloading %s...\n", assetName)
+	ui.Infof("Downloading %s...\n", assetName)
 
 	// Download the binary
 	client := &http.Client{Timeout: 60 * time.Second}
@@ -304,6 +313,6 @@ func PerformUpdate(currentVersion string) error {
 	cacheFile, _ := getCacheFile()
 	_ = os.Remove(cacheFile)
 
-	fmt.Printf("\nSuccessfully updated to %s!\n", info.LatestVersion)
+	ui.Infof("\nSuccessfully updated to %s!\n", info.LatestVersion)
 	return nil
 }
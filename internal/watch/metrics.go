@@ -0,0 +1,95 @@
+package watch
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/metrics"
+)
+
+// Metrics keeps track of a running Watcher's checks and serves them as
+// /healthz and /metrics
+type Metrics struct {
+	duration *metrics.Histogram
+	mux      *http.ServeMux
+
+	mu        sync.Mutex
+	last      time.Time
+	lastErr   error
+	applied   int
+	moved     int
+	forgotten int
+}
+
+// NewMetrics returns Metrics with no checks recorded
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		duration: metrics.NewHistogram("scope_scan_duration_seconds",
+			"Time taken to check the watched folders, by outcome.", metrics.DefaultBuckets, "result"),
+		mux: http.NewServeMux(),
+	}
+	m.mux.HandleFunc("GET /healthz", m.handleHealth)
+	m.mux.HandleFunc("GET /metrics", m.handleMetrics)
+	return m
+}
+
+// Record adds a check, as passed to Run's callback
+func (m *Metrics) Record(result *Result, took time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.duration.Observe(took.Seconds(), outcome)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.last, m.lastErr = time.Now(), err
+	if result != nil {
+		m.applied += len(result.Applied)
+		m.moved += len(result.Moved)
+		m.forgotten += len(result.Forgotten)
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mux.ServeHTTP(w, r)
+}
+
+// handleHealth reports whether the last check succeeded
+func (m *Metrics) handleHealth(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	err := m.lastErr
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleMetrics writes the check durations and the changes made
+func (m *Metrics) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	last := m.last
+	changes := []metrics.Sample{
+		{Labels: []metrics.Label{{Name: "kind", Value: "applied"}}, Value: float64(m.applied)},
+		{Labels: []metrics.Label{{Name: "kind", Value: "moved"}}, Value: float64(m.moved)},
+		{Labels: []metrics.Label{{Name: "kind", Value: "forgotten"}}, Value: float64(m.forgotten)},
+	}
+	m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = m.duration.Write(w)
+	if !last.IsZero() {
+		_ = metrics.WriteGauge(w, "scope_watch_last_check_timestamp_seconds",
+			"When the watched folders were last checked, in seconds since the epoch.", metrics.Sample{Value: float64(last.Unix())})
+	}
+	_ = metrics.WriteCounter(w, "scope_watch_changes_total",
+		"Folders tagged from .scope files, moved and forgotten since the watcher started.", changes...)
+}
@@ -0,0 +1,41 @@
+package watch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics()
+	m.Record(&Result{Applied: []string{"/a"}, Moved: map[string]string{"/b": "/c"}}, 20*time.Millisecond, nil)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`scope_scan_duration_seconds_count{result="ok"} 1`,
+		`scope_watch_changes_total{kind="applied"} 1`,
+		`scope_watch_changes_total{kind="moved"} 1`,
+		`scope_watch_last_check_timestamp_seconds `,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Expected %q in:\n%s", want, rec.Body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /healthz = %d", rec.Code)
+	}
+
+	m.Record(nil, time.Millisecond, errors.New("database is locked"))
+	rec = httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "database is locked") {
+		t.Errorf("GET /healthz after a failed check = %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchMask is what a directory is watched for: entries appearing,
+// disappearing or being renamed, files written, and the directory itself
+// going away
+const watchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+	unix.IN_CLOSE_WRITE | unix.IN_DELETE_SELF | unix.IN_MOVE_SELF | unix.IN_ONLYDIR
+
+// notifier signals changes under the directories it watches through
+// inotify, with one watch per directory
+type notifier struct {
+	file *os.File
+	fd   int
+	// watches are the watch descriptors by directory. A renamed directory
+	// keeps its descriptor, so two paths may share one until the old is
+	// dropped.
+	watches map[string]int
+	// events receives a value when something changed since it was last
+	// read
+	events chan struct{}
+}
+
+// newNotifier returns a notifier watching nothing yet
+func newNotifier() (*notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+	n := &notifier{
+		// A non-blocking descriptor is read through the runtime poller,
+		// so closing the file ends a pending read
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		fd:      fd,
+		watches: make(map[string]int),
+		events:  make(chan struct{}, 1),
+	}
+	go n.read()
+	return n, nil
+}
+
+// read turns the events of the inotify descriptor into signals until it
+// is closed. What changed isn't needed: a check finds out.
+func (n *notifier) read() {
+	buf := make([]byte, 64*1024)
+	for {
+		if _, err := n.file.Read(buf); err != nil {
+			return
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// sync watches exactly dirs, adding the new ones and dropping the others.
+// Directories removed since they were listed are skipped; running out of
+// watches (fs.inotify.max_user_watches) is an error.
+func (n *notifier) sync(dirs []string) error {
+	kept := make(map[int]bool, len(dirs))
+	keep := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		keep[dir] = true
+		if wd, ok := n.watches[dir]; ok {
+			kept[wd] = true
+			continue
+		}
+		wd, err := unix.InotifyAddWatch(n.fd, dir, watchMask)
+		if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENOTDIR) || errors.Is(err, unix.EACCES) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+		n.watches[dir] = wd
+		kept[wd] = true
+	}
+
+	for dir, wd := range n.watches {
+		if keep[dir] {
+			continue
+		}
+		delete(n.watches, dir)
+		// The directory may live on under another path, or be gone along
+		// with its watch already
+		if !kept[wd] {
+			_, _ = unix.InotifyRmWatch(n.fd, uint32(wd))
+		}
+	}
+	return nil
+}
+
+// close stops watching
+func (n *notifier) close() {
+	_ = n.file.Close()
+}
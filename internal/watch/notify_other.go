@@ -0,0 +1,26 @@
+//go:build !linux
+
+package watch
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// notifier is unsupported outside Linux: scope watch polls instead
+type notifier struct {
+	events chan struct{}
+}
+
+// newNotifier fails outside Linux
+func newNotifier() (*notifier, error) {
+	return nil, fmt.Errorf("not supported on %s", runtime.GOOS)
+}
+
+// sync is never called without a notifier
+func (n *notifier) sync(dirs []string) error {
+	return nil
+}
+
+// close is never called without a notifier
+func (n *notifier) close() {}
@@ -0,0 +1,433 @@
+// Package watch keeps the tags of the folders under some directories in
+// step with the filesystem, for scope watch: .scope files are applied as
+// they appear or change, tagged folders that are renamed or moved keep
+// their tags, and tagged folders that are deleted go to the trash.
+//
+// On Linux it subscribes to filesystem notifications (inotify, a watch
+// per directory) and checks when something changes, with a full check now
+// and then for filesystems that don't deliver them (network shares). It
+// polls elsewhere, and when the notifications can't be set up.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/location"
+	"github.com/gabssanto/Scope/internal/scan"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// DefaultInterval is the time between two checks when polling, unless
+// configured
+const DefaultInterval = 10 * time.Second
+
+// DefaultDepth is how many levels below a root are looked at unless
+// configured: enough for ~/code/<org>/<repo>/<package>
+const DefaultDepth = 5
+
+// rescanInterval is the time between two full checks when notified of
+// changes, for the filesystems that don't notify
+const rescanInterval = 10 * time.Minute
+
+// settle is how long a notified check waits for more changes, which come
+// in bursts (a checkout, an unpacked archive)
+const settle = time.Second
+
+// Result is what a check changed
+type Result struct {
+	// Applied are the folders tagged from a new or changed .scope file
+	Applied []string
+	// Moved maps the old path of each folder that moved to its new one
+	Moved map[string]string
+	// Forgotten are the tagged folders that were deleted or moved out of
+	// the roots, now in the trash
+	Forgotten []string
+}
+
+// Empty reports whether the check changed nothing
+func (r *Result) Empty() bool {
+	return len(r.Applied) == 0 && len(r.Moved) == 0 && len(r.Forgotten) == 0
+}
+
+// Watcher checks the folders under its roots
+type Watcher struct {
+	tags  *tag.Manager
+	roots []string
+	depth int
+
+	// scopeFiles are the .scope files applied, with their modification time
+	scopeFiles map[string]time.Time
+	// folders are the tagged folders under the roots as last seen, to
+	// recognize them after a move
+	folders map[string]os.FileInfo
+	// entries are some of the names in each of those folders as last
+	// seen, to tell a folder modified just before it moved from a new
+	// one that was given its inode
+	entries map[string]map[string]bool
+	// checked is when the last check started
+	checked time.Time
+}
+
+// New returns a Watcher for the folders under roots, which must be
+// absolute
+func New(m *tag.Manager, roots []string) *Watcher {
+	return &Watcher{
+		tags:       m,
+		roots:      roots,
+		scopeFiles: make(map[string]time.Time),
+		folders:    make(map[string]os.FileInfo),
+		entries:    make(map[string]map[string]bool),
+	}
+}
+
+// SetDepth sets how many levels below the roots are looked at. Zero
+// uses DefaultDepth.
+func (w *Watcher) SetDepth(depth int) {
+	w.depth = depth
+}
+
+// Run checks until ctx is done, calling fn after each check with its
+// result and how long it took. It checks when notified of a change, or
+// every interval when notifications aren't available. A failed check is
+// reported to fn and retried at the next change or interval.
+func (w *Watcher) Run(ctx context.Context, interval time.Duration, fn func(*Result, time.Duration, error)) error {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	n, err := newNotifier()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: filesystem notifications unavailable, checking every %s: %v\n", interval, err)
+		return w.poll(ctx, interval, fn)
+	}
+	defer n.close()
+
+	rescan := time.NewTicker(rescanInterval)
+	defer rescan.Stop()
+	for {
+		start := time.Now()
+		result, dirs, err := w.check()
+		fn(result, time.Since(start), err)
+		if err == nil {
+			if err := n.sync(dirs); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v; checking every %s instead\n", err, interval)
+				n.close()
+				return w.poll(ctx, interval, fn)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-rescan.C:
+		case <-n.events:
+			if !waitSettled(ctx, n.events) {
+				return nil
+			}
+		}
+	}
+}
+
+// waitSettled waits until no change has come for settle, reporting false
+// if ctx is done first
+func waitSettled(ctx context.Context, events <-chan struct{}) bool {
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-events:
+			timer.Reset(settle)
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// poll checks every interval until ctx is done
+func (w *Watcher) poll(ctx context.Context, interval time.Duration, fn func(*Result, time.Duration, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		result, err := w.Check()
+		fn(result, time.Since(start), err)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check compares the roots with what they held at the last check and
+// updates the tags to match. Folders that were already missing at the
+// first check are left alone, for scope prune: they may be on a drive that
+// isn't mounted.
+func (w *Watcher) Check() (*Result, error) {
+	result, _, err := w.check()
+	return result, err
+}
+
+// check is Check, also returning the directories it looked at
+func (w *Watcher) check() (*Result, []string, error) {
+	result := &Result{Moved: make(map[string]string)}
+	start := time.Now()
+
+	// Roots that are gone, such as an unmounted drive, are skipped
+	// entirely rather than taken as everything under them being deleted
+	var roots []string
+	for _, root := range w.roots {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			roots = append(roots, root)
+		}
+	}
+
+	tagged, err := w.tagged(roots)
+	if err != nil {
+		return nil, nil, err
+	}
+	depth := w.depth
+	if depth <= 0 {
+		depth = DefaultDepth
+	}
+	dirs, scopeFiles := walk(roots, depth)
+
+	if err := w.followMoves(tagged, dirs, result); err != nil {
+		return nil, nil, err
+	}
+	if err := w.applyScopeFiles(scopeFiles, result); err != nil {
+		return nil, nil, err
+	}
+
+	// Remember the tagged folders as they are now
+	if tagged, err = w.tagged(roots); err != nil {
+		return nil, nil, err
+	}
+	w.folders = make(map[string]os.FileInfo, len(tagged))
+	w.entries = make(map[string]map[string]bool, len(tagged))
+	for _, folder := range tagged {
+		if info, err := os.Stat(folder); err == nil {
+			w.folders[folder] = info
+			w.entries[folder] = entryNames(folder)
+		}
+	}
+	w.checked = start
+	sort.Strings(result.Applied)
+	sort.Strings(result.Forgotten)
+	return result, dirs, nil
+}
+
+// tagged returns the local tagged folders under roots
+func (w *Watcher) tagged(roots []string) ([]string, error) {
+	all, err := w.tags.ListAllFolders()
+	if err != nil {
+		return nil, err
+	}
+	var folders []string
+	for _, folder := range all {
+		if location.IsRemote(folder) {
+			continue
+		}
+		for _, root := range roots {
+			if within(folder, root) {
+				folders = append(folders, folder)
+				break
+			}
+		}
+	}
+	return folders, nil
+}
+
+// followMoves moves the tags of each tagged folder that is gone to the
+// directory it became, or puts it in the trash when it was deleted or
+// moved out of the roots; with the trash disabled, it is left for scope
+// prune. The directory it became has the same identity (inode), and if it
+// was modified since the last check, some of the same entries: a
+// directory created since may have been given the inode of a deleted one.
+func (w *Watcher) followMoves(tagged []string, dirs []string, result *Result) error {
+	var gone []string
+	isTagged := make(map[string]bool, len(tagged))
+	for _, folder := range tagged {
+		isTagged[folder] = true
+		if _, known := w.folders[folder]; !known {
+			continue
+		}
+		if _, err := os.Stat(folder); os.IsNotExist(err) {
+			gone = append(gone, folder)
+		}
+	}
+	if len(gone) == 0 {
+		return nil
+	}
+
+	var candidates []os.FileInfo
+	var candidatePaths []string
+	for _, dir := range dirs {
+		if isTagged[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil {
+			candidates = append(candidates, info)
+			candidatePaths = append(candidatePaths, dir)
+		}
+	}
+
+	for _, folder := range gone {
+		target := ""
+		for i, info := range candidates {
+			if os.SameFile(w.folders[folder], info) &&
+				(!info.ModTime().After(w.checked) || sharesEntry(w.entries[folder], candidatePaths[i])) {
+				target = candidatePaths[i]
+				break
+			}
+		}
+		if target == "" {
+			// Deleting for good is left to scope prune, which is asked for
+			if !w.tags.TrashEnabled() {
+				continue
+			}
+			if err := w.tags.RemoveFolder(folder); err != nil {
+				return fmt.Errorf("failed to forget %s: %w", folder, err)
+			}
+			result.Forgotten = append(result.Forgotten, folder)
+			continue
+		}
+		if err := w.tags.MoveFolder(folder, target); err != nil {
+			return fmt.Errorf("failed to move %s to %s: %w", folder, target, err)
+		}
+		result.Moved[folder] = target
+	}
+	return nil
+}
+
+// maxEntries is how many names of a tagged folder are remembered
+const maxEntries = 64
+
+// entryNames returns some of the names in dir
+func entryNames(dir string) map[string]bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	names, _ := f.Readdirnames(maxEntries)
+	entries := make(map[string]bool, len(names))
+	for _, name := range names {
+		entries[name] = true
+	}
+	return entries
+}
+
+// sharesEntry reports whether dir holds one of entries, or, when entries
+// is empty, is empty too
+func sharesEntry(entries map[string]bool, dir string) bool {
+	names := entryNames(dir)
+	if len(entries) == 0 {
+		return len(names) == 0
+	}
+	for name := range names {
+		if entries[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// applyScopeFiles tags the folders of .scope files that are new or changed
+// since the last check
+func (w *Watcher) applyScopeFiles(files map[string]time.Time, result *Result) error {
+	for file, modified := range files {
+		if applied, ok := w.scopeFiles[file]; ok && applied.Equal(modified) {
+			continue
+		}
+		config, err := scan.ParseScopeFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", file, err)
+			w.scopeFiles[file] = modified
+			continue
+		}
+
+		folder := filepath.Dir(file)
+		changed := false
+		for _, t := range config.Tags {
+			added, err := w.tags.TagFolders([]string{folder}, t)
+			if err != nil {
+				return fmt.Errorf("failed to tag %s with '%s': %w", folder, t, err)
+			}
+			changed = changed || len(added) > 0
+		}
+		if changed {
+			result.Applied = append(result.Applied, folder)
+		}
+		w.scopeFiles[file] = modified
+	}
+
+	for file := range w.scopeFiles {
+		if _, ok := files[file]; !ok {
+			delete(w.scopeFiles, file)
+		}
+	}
+	return nil
+}
+
+// walk returns the directories under roots down to depth levels below
+// them, and their .scope files with their modification times. The
+// directories scope scan skips are skipped.
+func walk(roots []string, depth int) ([]string, map[string]time.Time) {
+	var dirs []string
+	scopeFiles := make(map[string]time.Time)
+	for _, root := range roots {
+		_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && scan.Skip(d.Name()) {
+					return filepath.SkipDir
+				}
+				dirs = append(dirs, path)
+				if levels(path, root) >= depth {
+					// Not looked inside, but its own .scope file counts
+					file := filepath.Join(path, ".scope")
+					if info, err := os.Stat(file); err == nil && !info.IsDir() {
+						scopeFiles[file] = info.ModTime()
+					}
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Name() == ".scope" {
+				if info, err := d.Info(); err == nil {
+					scopeFiles[path] = info.ModTime()
+				}
+			}
+			return nil
+		})
+	}
+	return dirs, scopeFiles
+}
+
+// levels returns how many levels path is below root, which contains it
+func levels(path, root string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// within reports whether path is root or inside it
+func within(path, root string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
@@ -0,0 +1,275 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// newWatcher returns a Watcher for a fresh database and the root it
+// watches
+func newWatcher(t *testing.T) (*Watcher, *tag.Manager, string) {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := db.Open(filepath.Join(dir, "scope.db"), db.PoolOptions{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	root := filepath.Join(dir, "src")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatalf("Mkdir failed: %v", err)
+	}
+	m := tag.NewManager(store)
+	return New(m, []string{root}), m, root
+}
+
+// mkdir creates dir and, with tags, a .scope file listing them
+func mkdir(t *testing.T, dir string, tags string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if tags != "" {
+		if err := os.WriteFile(filepath.Join(dir, ".scope"), []byte("tags: ["+tags+"]\n"), 0644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+}
+
+// check runs a check that must succeed
+func check(t *testing.T, w *Watcher) *Result {
+	t.Helper()
+	result, err := w.Check()
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	w, m, root := newWatcher(t)
+	api, web, gone := filepath.Join(root, "api"), filepath.Join(root, "web"), filepath.Join(root, "gone")
+	mkdir(t, api, "work, backend")
+	mkdir(t, web, "")
+	mkdir(t, gone, "")
+	mkdir(t, filepath.Join(root, "node_modules", "dep"), "vendored")
+	for _, dir := range []string{web, gone} {
+		if err := m.AddTag(dir, "work"); err != nil {
+			t.Fatalf("AddTag failed: %v", err)
+		}
+	}
+	// Missing before the watcher saw it: left for scope prune
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	result := check(t, w)
+	if !reflect.DeepEqual(result.Applied, []string{api}) || len(result.Moved) != 0 || len(result.Forgotten) != 0 {
+		t.Errorf("First check = %+v", result)
+	}
+	if !check(t, w).Empty() {
+		t.Error("A check without changes should change nothing")
+	}
+
+	// A renamed folder keeps its tags; a deleted one is forgotten
+	renamed := filepath.Join(root, "api-v2")
+	if err := os.Rename(api, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(web); err != nil {
+		t.Fatal(err)
+	}
+	mkdir(t, filepath.Join(root, "new"), "work")
+
+	result = check(t, w)
+	if !reflect.DeepEqual(result.Moved, map[string]string{api: renamed}) {
+		t.Errorf("Moved = %v", result.Moved)
+	}
+	if !reflect.DeepEqual(result.Forgotten, []string{web}) {
+		t.Errorf("Forgotten = %v", result.Forgotten)
+	}
+	if !reflect.DeepEqual(result.Applied, []string{filepath.Join(root, "new")}) {
+		t.Errorf("Applied = %v", result.Applied)
+	}
+
+	tags, err := m.GetTagsForFolder(renamed)
+	if err != nil || !reflect.DeepEqual(tags, []string{"backend", "work"}) {
+		t.Errorf("Tags of the renamed folder = %v, %v", tags, err)
+	}
+	folders, err := m.ListAllFolders()
+	if err != nil {
+		t.Fatalf("ListAllFolders failed: %v", err)
+	}
+	want := []string{renamed, gone, filepath.Join(root, "new")}
+	if !reflect.DeepEqual(folders, want) {
+		t.Errorf("Folders = %v, want %v", folders, want)
+	}
+}
+
+func TestCheckMissingRoot(t *testing.T) {
+	w, m, root := newWatcher(t)
+	api := filepath.Join(root, "api")
+	mkdir(t, api, "work")
+	check(t, w)
+
+	// An unmounted drive doesn't mean its folders were deleted
+	if err := os.Rename(root, root+"-unmounted"); err != nil {
+		t.Fatal(err)
+	}
+	if result := check(t, w); !result.Empty() {
+		t.Errorf("Check without the root = %+v", result)
+	}
+	if folders, _ := m.ListAllFolders(); len(folders) != 1 {
+		t.Errorf("Expected the folder to be kept, got %v", folders)
+	}
+}
+
+func TestWalkDepthAndSkip(t *testing.T) {
+	root := t.TempDir()
+	shallow := filepath.Join(root, "org", "api")
+	deep := filepath.Join(root, "a", "b", "c")
+	for _, dir := range []string{shallow, deep, filepath.Join(deep, "d"), filepath.Join(root, "vendor", "lib"), filepath.Join(root, "target", "debug")} {
+		mkdir(t, dir, "work")
+	}
+
+	dirs, scopeFiles := walk([]string{root}, 3)
+	want := []string{root, filepath.Join(root, "a"), filepath.Join(root, "a", "b"), deep, filepath.Join(root, "org"), shallow}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("walk dirs = %v, want %v", dirs, want)
+	}
+	// The .scope file of the deepest directory counts, those below it and
+	// in skipped directories don't
+	var files []string
+	for file := range scopeFiles {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	wantFiles := []string{filepath.Join(deep, ".scope"), filepath.Join(shallow, ".scope")}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("walk .scope files = %v, want %v", files, wantFiles)
+	}
+}
+
+func TestRun(t *testing.T) {
+	w, m, root := newWatcher(t)
+	api := filepath.Join(root, "api")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	checks := make(chan *Result, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Run(ctx, 50*time.Millisecond, func(result *Result, _ time.Duration, err error) {
+			if err != nil {
+				t.Errorf("Check failed: %v", err)
+				return
+			}
+			checks <- result
+		})
+	}()
+
+	// The first check runs right away; a new .scope file is then picked
+	// up by a notification or the next poll
+	<-checks
+	mkdir(t, api, "work")
+	for {
+		select {
+		case result := <-checks:
+			if len(result.Applied) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(result.Applied, []string{api}) {
+				t.Errorf("Applied = %v", result.Applied)
+			}
+		case <-ctx.Done():
+			t.Fatal("The new .scope file was never applied")
+		}
+		break
+	}
+	if tags, _ := m.GetTagsForFolder(api); !reflect.DeepEqual(tags, []string{"work"}) {
+		t.Errorf("Tags = %v", tags)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run failed: %v", err)
+	}
+}
+
+func TestCheckMovedOutOfRoot(t *testing.T) {
+	w, m, root := newWatcher(t)
+	api := filepath.Join(root, "api")
+	mkdir(t, api, "work")
+	check(t, w)
+
+	// Moved where the watcher can't follow: to the trash, not gone for good
+	outside := filepath.Join(filepath.Dir(root), "api")
+	if err := os.Rename(api, outside); err != nil {
+		t.Fatal(err)
+	}
+	if result := check(t, w); !reflect.DeepEqual(result.Forgotten, []string{api}) {
+		t.Errorf("Forgotten = %v", result.Forgotten)
+	}
+	items, err := m.ListTrash()
+	if err != nil || len(items) != 1 || items[0].Name != api {
+		t.Fatalf("Expected the folder in the trash, got %+v, %v", items, err)
+	}
+	if _, err := m.Restore(api); err != nil {
+		t.Errorf("Restore failed: %v", err)
+	}
+
+	// With the trash disabled, it is left for scope prune
+	m.SetTrashRetention(-1)
+	web := filepath.Join(root, "web")
+	mkdir(t, web, "work")
+	check(t, w)
+	if err := os.Rename(web, filepath.Join(filepath.Dir(root), "web")); err != nil {
+		t.Fatal(err)
+	}
+	if result := check(t, w); len(result.Forgotten) != 0 {
+		t.Errorf("Forgotten = %v", result.Forgotten)
+	}
+	if tags, _ := m.GetTagsForFolder(web); !reflect.DeepEqual(tags, []string{"work"}) {
+		t.Errorf("Expected the folder to keep its tags, got %v", tags)
+	}
+}
+
+func TestCheckMovedAfterChange(t *testing.T) {
+	w, m, root := newWatcher(t)
+	api := filepath.Join(root, "api")
+	mkdir(t, api, "work")
+	check(t, w)
+
+	// Changed since the last check, then renamed
+	if err := os.WriteFile(filepath.Join(api, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(api, later, later); err != nil {
+		t.Fatal(err)
+	}
+	renamed := filepath.Join(root, "api-v2")
+	if err := os.Rename(api, renamed); err != nil {
+		t.Fatal(err)
+	}
+
+	result := check(t, w)
+	if !reflect.DeepEqual(result.Moved, map[string]string{api: renamed}) || len(result.Forgotten) != 0 {
+		t.Errorf("Check = %+v", result)
+	}
+	if tags, _ := m.GetTagsForFolder(renamed); !reflect.DeepEqual(tags, []string{"work"}) {
+		t.Errorf("Tags of the renamed folder = %v", tags)
+	}
+}
@@ -0,0 +1,56 @@
+package scope
+
+import (
+	"fmt"
+
+	"github.com/gabssanto/Scope/internal/scan"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// ScopeFile is a .scope file found by a scan
+type ScopeFile struct {
+	// Folder is the directory the file is in
+	Folder string
+	// Path is the file itself
+	Path string
+	// Tags are the tags it lists
+	Tags []string
+}
+
+// Scanner finds .scope files and applies their tags
+type Scanner struct {
+	tags *tag.Manager
+}
+
+// Scan returns the .scope files under root. Files that can't be read or
+// parsed are reported in the error, after the ones that could.
+func (s *Scanner) Scan(root string) ([]ScopeFile, error) {
+	result, err := scan.Scan(root)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]ScopeFile, 0, len(result.Scopes))
+	for _, found := range result.Scopes {
+		files = append(files, ScopeFile{Folder: found.FolderPath, Path: found.FilePath, Tags: found.Tags})
+	}
+	if len(result.Errors) > 0 {
+		first := result.Errors[0]
+		return files, fmt.Errorf("%d .scope files could not be read, first %s: %w", len(result.Errors), first.FilePath, first.Err)
+	}
+	return files, nil
+}
+
+// Apply tags each file's folder with its tags, returning how many tags
+// were added
+func (s *Scanner) Apply(files []ScopeFile) (int, error) {
+	applied := 0
+	for _, file := range files {
+		for _, tagName := range file.Tags {
+			if err := s.tags.AddTag(file.Folder, tagName); err != nil {
+				return applied, fmt.Errorf("failed to add tag '%s' to %s: %w", tagName, file.Folder, err)
+			}
+			applied++
+		}
+	}
+	return applied, nil
+}
@@ -0,0 +1,84 @@
+// Package scope is the supported Go API of Scope, for editor plugins and
+// other tools that want to read and change tags or start sessions without
+// running the scope binary.
+//
+// Open a Store, then use its Tagger, Sessions and Scanner:
+//
+//	store, err := scope.Open("")
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//	folders, err := store.Tagger().Folders("work")
+//
+// A Store works on the same database as the scope command, and is safe to
+// use while it runs. The packages under internal/ may change at any time;
+// this one changes only in backwards compatible ways.
+package scope
+
+import (
+	"github.com/gabssanto/Scope/internal/db"
+	"github.com/gabssanto/Scope/internal/session"
+	"github.com/gabssanto/Scope/internal/tag"
+)
+
+// ErrReadOnly is returned by changes to a read-only or locked database
+var ErrReadOnly = db.ErrReadOnly
+
+// Options control how a Store opens its database
+type Options struct {
+	// ReadOnly opens the database without ever writing to it
+	ReadOnly bool
+}
+
+// Store is an open Scope database
+type Store struct {
+	store *db.Store
+	tags  *tag.Manager
+}
+
+// Open opens the database at path, or the user's database
+// (~/.config/scope/scope.db) when path is empty. It is created if needed.
+func Open(path string) (*Store, error) {
+	return OpenWith(path, Options{})
+}
+
+// OpenWith opens the database at path with opts
+func OpenWith(path string, opts Options) (*Store, error) {
+	if path == "" {
+		var err error
+		if path, err = db.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	store, err := db.Open(path, db.PoolOptions{ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{store: store, tags: tag.NewManager(store)}, nil
+}
+
+// Path returns the database file
+func (s *Store) Path() string {
+	return s.store.Path()
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.store.Close()
+}
+
+// Tagger returns the tag operations of the Store
+func (s *Store) Tagger() *Tagger {
+	return &Tagger{tags: s.tags}
+}
+
+// Sessions returns the session operations of the Store
+func (s *Store) Sessions() *SessionManager {
+	return &SessionManager{sessions: session.NewManager(s.store)}
+}
+
+// Scanner returns the .scope file operations of the Store
+func (s *Store) Scanner() *Scanner {
+	return &Scanner{tags: s.tags}
+}
@@ -0,0 +1,111 @@
+package scope
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to resolve temp dir: %v", err)
+	}
+	store, err := Open(filepath.Join(root, "scope.db"))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, root
+}
+
+func TestTagger(t *testing.T) {
+	store, root := openTestStore(t)
+	api := filepath.Join(root, "api")
+	if err := os.Mkdir(api, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tagger := store.Tagger()
+	if err := tagger.Tag(api, "work"); err != nil {
+		t.Fatalf("Tag failed: %v", err)
+	}
+	folders, err := tagger.Folders("work")
+	if err != nil || len(folders) != 1 || folders[0] != api {
+		t.Fatalf("Expected [%s], got %v, %v", api, folders, err)
+	}
+
+	// Child tags count for Folders but not for ExactFolders
+	web := filepath.Join(root, "web")
+	if err := os.Mkdir(web, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := tagger.Tag(web, "work/frontend"); err != nil {
+		t.Fatalf("Tag failed: %v", err)
+	}
+	if folders, _ := tagger.Folders("work"); len(folders) != 2 {
+		t.Errorf("Expected both folders for work, got %v", folders)
+	}
+	if folders, _ := tagger.ExactFolders("work"); len(folders) != 1 || folders[0] != api {
+		t.Errorf("Expected [%s] for work alone, got %v", api, folders)
+	}
+	if err := tagger.Untag(web, "work/frontend"); err != nil {
+		t.Fatalf("Untag failed: %v", err)
+	}
+
+	if err := tagger.RenameTag("work", "job"); err != nil {
+		t.Fatalf("RenameTag failed: %v", err)
+	}
+	tags, err := tagger.FolderTags(api)
+	if err != nil || len(tags) != 1 || tags[0] != "job" {
+		t.Errorf("Expected [job], got %v, %v", tags, err)
+	}
+	if err := tagger.Untag(api, "job"); err != nil {
+		t.Fatalf("Untag failed: %v", err)
+	}
+	if counts, _ := tagger.Tags(); counts["job"] != 0 {
+		t.Errorf("Expected job on no folders, got %v", counts)
+	}
+}
+
+func TestScanner(t *testing.T) {
+	store, root := openTestStore(t)
+	web := filepath.Join(root, "code", "web")
+	if err := os.MkdirAll(web, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(web, ".scope"), []byte("tags:\n  - frontend\n  - work\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := store.Scanner().Scan(filepath.Join(root, "code"))
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Folder != web || len(files[0].Tags) != 2 {
+		t.Fatalf("Unexpected scan result %+v", files)
+	}
+	applied, err := store.Scanner().Apply(files)
+	if err != nil || applied != 2 {
+		t.Fatalf("Expected 2 tags applied, got %d, %v", applied, err)
+	}
+	if folders, _ := store.Tagger().Folders("frontend"); len(folders) != 1 || folders[0] != web {
+		t.Errorf("Expected %s tagged frontend, got %v", web, folders)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	store, root := openTestStore(t)
+	path := store.Path()
+	store.Close()
+
+	readOnly, err := OpenWith(path, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("OpenWith failed: %v", err)
+	}
+	defer readOnly.Close()
+	if err := readOnly.Tagger().Tag(root, "work"); err == nil {
+		t.Error("Expected tagging a read-only store to fail")
+	}
+}
@@ -0,0 +1,57 @@
+package scope
+
+import "github.com/gabssanto/Scope/internal/session"
+
+// SessionOptions control how a session workspace is laid out
+type SessionOptions struct {
+	// Nested mirrors the parent directories of folders with the same name
+	// as directories (clientA/api) instead of flattening them into the
+	// link name (clientA-api)
+	Nested bool
+	// Record keeps the commands run in the session in this file
+	Record string
+}
+
+// Session describes the session a process runs in
+type Session struct {
+	// Name is the session's tag, or for nested sessions the tags from the
+	// outermost in, joined by >
+	Name string
+	// Tag is the tag of the innermost session
+	Tag string
+	// Workspace is the session's workspace directory
+	Workspace string
+	// Depth is 1 in a session, 2 in a session started inside it, ...
+	Depth int
+}
+
+// SessionManager starts and updates sessions
+type SessionManager struct {
+	sessions *session.Manager
+}
+
+// Start creates a workspace of the folders with tagName and runs the
+// user's shell in it on the process's stdin and stdout, returning when
+// the shell exits. The workspace is removed afterwards.
+func (m *SessionManager) Start(tagName string, opts SessionOptions) error {
+	return m.sessions.StartSession(tagName, session.Options{Nested: opts.Nested, HistoryFile: opts.Record})
+}
+
+// Refresh brings a running session's workspace up to date with its tag,
+// returning the folders linked in and removed
+func (m *SessionManager) Refresh(workspace, tagName string) (added, removed []string, err error) {
+	refreshed, err := m.sessions.RefreshSession(workspace, tagName)
+	if refreshed != nil {
+		added, removed = refreshed.Added, refreshed.Removed
+	}
+	return added, removed, err
+}
+
+// Current returns the session this process runs in, if any
+func Current() (Session, bool) {
+	current, ok := session.CurrentSession()
+	if !ok {
+		return Session{}, false
+	}
+	return Session{Name: current.Name, Tag: current.Tag, Workspace: current.Workspace, Depth: current.Depth}, true
+}
@@ -0,0 +1,66 @@
+package scope
+
+import "github.com/gabssanto/Scope/internal/tag"
+
+// Tagger reads and changes the tags of folders. Paths may be relative or
+// use ~ and environment variables, like arguments to the scope command.
+type Tagger struct {
+	tags *tag.Manager
+}
+
+// Tag adds tagName to the folder at path
+func (t *Tagger) Tag(path, tagName string) error {
+	return t.tags.AddTag(path, tagName)
+}
+
+// Untag removes tagName from the folder at path
+func (t *Tagger) Untag(path, tagName string) error {
+	return t.tags.RemoveTag(path, tagName)
+}
+
+// DeleteTag removes tagName from every folder
+func (t *Tagger) DeleteTag(tagName string) error {
+	return t.tags.DeleteTag(tagName)
+}
+
+// RenameTag renames a tag on every folder
+func (t *Tagger) RenameTag(oldName, newName string) error {
+	return t.tags.RenameTag(oldName, newName)
+}
+
+// Tags returns every tag with the number of folders it is on
+func (t *Tagger) Tags() (map[string]int, error) {
+	return t.tags.ListTags()
+}
+
+// Folders returns the folders with tagName or one of its child tags
+// (work/backend for work), in the tag's order. Use ExactFolders to leave
+// out folders only tagged with a child.
+func (t *Tagger) Folders(tagName string) ([]string, error) {
+	return t.tags.ListFoldersByTag(tagName)
+}
+
+// ExactFolders returns the folders with tagName itself, in the tag's order
+func (t *Tagger) ExactFolders(tagName string) ([]string, error) {
+	return t.tags.ListFoldersByExactTag(tagName)
+}
+
+// FolderTags returns the tags of the folder at path
+func (t *Tagger) FolderTags(path string) ([]string, error) {
+	return t.tags.GetTagsForFolder(path)
+}
+
+// AllFolders returns every tagged folder
+func (t *Tagger) AllFolders() ([]string, error) {
+	return t.tags.ListAllFolders()
+}
+
+// Note returns the note of the folder at path
+func (t *Tagger) Note(path string) (string, error) {
+	return t.tags.GetNote(path)
+}
+
+// SetNote sets the note of the folder at path; an empty note removes it
+func (t *Tagger) SetNote(path, note string) error {
+	return t.tags.SetNote(path, note)
+}